@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/rollback"
+)
+
+var aggregateInto string
+
+func init() {
+	flag.StringVar(&aggregateInto, "into", "", "with aggregate, the title or ID of the overview notebook, created if missing")
+}
+
+// aggregate implements nlm aggregate: it pipes every notebook whose title
+// matches the -match glob into the -into notebook, creating it on the
+// first run. Rerun it from cron to keep the overview current.
+func aggregate(c *api.Client) error {
+	if historyMatch == "" || aggregateInto == "" {
+		return errors.New("aggregate: give the notebooks with -match and the overview with -into")
+	}
+	if _, err := path.Match(historyMatch, ""); err != nil {
+		return fmt.Errorf("aggregate: -match %q: %w", historyMatch, err)
+	}
+	nbs, err := c.ListRecentlyViewedProjects()
+	if err != nil {
+		return fmt.Errorf("aggregate: %w", err)
+	}
+	var undo rollback.Log
+	dst, err := overviewNotebook(c, nbs, aggregateInto, &undo)
+	if err != nil {
+		return fmt.Errorf("aggregate: %w", err)
+	}
+	var srcs []string
+	for _, nb := range nbs {
+		id := nb.GetProjectId()
+		if ok, _ := path.Match(historyMatch, strings.TrimSpace(nb.GetTitle())); ok && id != dst {
+			srcs = append(srcs, id)
+		}
+	}
+	if len(srcs) == 0 {
+		return rollBack("aggregate", &undo, fmt.Errorf("aggregate: no notebook titles match %q", historyMatch))
+	}
+	fmt.Fprintf(os.Stderr, "Collecting the %s of %d notebooks into %s\n", pipeType, len(srcs), dst)
+	added, err := pipeNotebooks(c, srcs, dst)
+	if err != nil && added > 0 {
+		// The overview is kept once it collects anything; the
+		// notebooks that failed are reported and added by the next run.
+		fmt.Fprintf(errorOutput, "aggregate: kept %s with %d of %d notebooks; rerun to add the rest\n", dst, added, len(srcs))
+		return err
+	}
+	return rollBack("aggregate", &undo, err)
+}
+
+// overviewNotebook returns the ID of the notebook with the ID or title
+// into, creating a notebook titled into if there is none and recording it
+// in undo.
+func overviewNotebook(c *api.Client, nbs []*api.Notebook, into string, undo *rollback.Log) (string, error) {
+	var found []string
+	for _, nb := range nbs {
+		if nb.GetProjectId() == into {
+			return into, nil
+		}
+		if strings.TrimSpace(nb.GetTitle()) == into {
+			found = append(found, nb.GetProjectId())
+		}
+	}
+	switch len(found) {
+	case 0:
+		nb, err := c.CreateProject(into, "📊")
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(os.Stderr, "Created notebook %q: %s\n", into, nb.GetProjectId())
+		undoNotebook(undo, c, into, nb.GetProjectId())
+		return nb.GetProjectId(), nil
+	case 1:
+		return found[0], nil
+	}
+	return "", fmt.Errorf("%d notebooks are titled %q; give -into the ID of one", len(found), into)
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	pb "github.com/tmc/nlm/gen/notebooklm/v1alpha1"
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/cleanup"
+	"github.com/tmc/nlm/internal/export"
+	"github.com/tmc/nlm/internal/rollback"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// archiveNotebook exports a notebook and packs it, with its manifest, into
+// a single zip at path.
+func archiveNotebook(c *api.Client, notebookID, path string) error {
+	staging, err := cleanup.MkdirTemp("", "nlm-archive-*")
+	if err != nil {
+		return fmt.Errorf("archive: %w", err)
+	}
+	defer cleanup.Remove(staging)
+
+	m, err := export.Open(staging, notebookID)
+	if err != nil {
+		return fmt.Errorf("archive: %w", err)
+	}
+	if _, err := exportItems(c, m, notebookID); err != nil {
+		return err
+	}
+	if err := m.Finish(); err != nil {
+		return fmt.Errorf("archive: %w", err)
+	}
+
+	if path == "" {
+		name := m.Title
+		if name == "" {
+			name = notebookID
+		}
+		path = outputFilename(name + ".zip")
+	}
+	if err := writeZipAtomic(m, path); err != nil {
+		return fmt.Errorf("archive: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Archived %q to %s (%d items)\n", m.Title, path, len(m.Items))
+	return nil
+}
+
+// writeZipAtomic writes m's archive next to path and renames it into place.
+func writeZipAtomic(m *export.Manifest, path string) error {
+	f, err := cleanup.CreateTemp(filepath.Dir(path), ".tmp-"+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if err := m.WriteZip(f); err != nil {
+		f.Close()
+		cleanup.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		cleanup.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		cleanup.Remove(tmp)
+		return err
+	}
+	cleanup.Forget(tmp)
+	return nil
+}
+
+// importArchive creates a new notebook from an archive written by
+// archiveNotebook. Sources added from web pages or videos are re-added by
+// URL, text sources and notes from their archived content. Audio overviews
+// cannot be uploaded and guides are generated from the sources, so both
+// are skipped.
+func importArchive(c *api.Client, path string) error {
+	a, err := export.OpenArchive(path)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+	m := a.Manifest
+
+	title, emoji := m.Title, "📙"
+	if it, ok := m.Lookup(export.KindNotebook, m.NotebookID); ok {
+		if data, err := a.ReadItem(it); err == nil {
+			var p pb.Project
+			if protojson.Unmarshal(data, &p) == nil && p.Emoji != "" {
+				emoji = p.Emoji
+			}
+		}
+	}
+	if title == "" {
+		title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	nb, err := c.CreateProject(title, emoji)
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	id := nb.GetProjectId()
+	fmt.Fprintf(os.Stderr, "Created notebook %q (%s)\n", title, id)
+	var undo rollback.Log
+	undoNotebook(&undo, c, title, id)
+
+	var failed int
+	for _, it := range m.Items {
+		if it.Kind == export.KindNotebook {
+			continue
+		}
+		if err := importItem(c, a, id, it); err != nil {
+			fmt.Fprintf(os.Stderr, "  ✗ %s %q: %v\n", it.Kind, it.Title, err)
+			failed++
+			continue
+		}
+	}
+	if failed > 0 {
+		if keepPartial {
+			fmt.Println(id)
+		}
+		return rollBack("import", &undo, fmt.Errorf("import: %d of %d items could not be imported", failed, len(m.Items)-1))
+	}
+	fmt.Println(id)
+	return nil
+}
+
+func importItem(c *api.Client, a *export.Archive, notebookID string, it export.Item) error {
+	switch it.Kind {
+	case export.KindAudio:
+		fmt.Fprintf(os.Stderr, "  - skipping audio %q: audio overviews cannot be uploaded\n", it.Title)
+		return nil
+	case export.KindGuide:
+		fmt.Fprintf(os.Stderr, "  - skipping guide %q: guides are generated from the sources\n", it.Title)
+		return nil
+	}
+	data, err := a.ReadItem(it)
+	if err != nil {
+		return err
+	}
+	switch it.Kind {
+	case export.KindSource:
+		if url := export.SourceURL(data); url != "" {
+			_, err = c.AddSourceFromURL(notebookID, url)
+		} else {
+			var text string
+			if text, err = export.SourceText(data); err != nil {
+				return err
+			}
+			_, err = c.AddSourceFromText(notebookID, text, it.Title)
+		}
+	case export.KindNote:
+		var content string
+		if _, _, content, err = api.NoteContent(data); err != nil {
+			return err
+		}
+		_, err = c.CreateNote(notebookID, it.Title, content)
+	default:
+		return fmt.Errorf("unknown item kind")
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "  ✓ %s %q\n", it.Kind, it.Title)
+	return nil
+}
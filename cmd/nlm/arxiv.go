@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/arxiv"
+	"github.com/tmc/nlm/internal/cleanup"
+)
+
+// arXiv flags
+var (
+	arxivQuery  string
+	arxivAuthor string
+	arxivMax    int
+)
+
+func init() {
+	flag.StringVar(&arxivQuery, "query", "", "with arxiv add, add the papers matching this search (words, or arXiv query syntax such as cat:cs.CL)")
+	flag.StringVar(&arxivAuthor, "author", "", "with arxiv add, add papers by this author")
+	flag.IntVar(&arxivMax, "max-results", 5, "with arxiv add -query or -author, number of papers to add")
+}
+
+// arxivDownloadDelay spaces PDF downloads, as arXiv asks of automated
+// clients.
+const arxivDownloadDelay = time.Second
+
+// arxivState records the papers added to a notebook, by arXiv ID.
+type arxivState struct {
+	Papers map[string]arxivPaper `json:"papers"`
+}
+
+type arxivPaper struct {
+	Version  int       `json:"version"`
+	Title    string    `json:"title"`
+	Abstract string    `json:"abstract_source_id"`
+	PDF      string    `json:"pdf_source_id,omitempty"`
+	AddedAt  time.Time `json:"added_at"`
+}
+
+// arxivTitlePrefix starts the titles of the sources of a paper, so papers
+// added before, from this machine or another, are recognized.
+func arxivTitlePrefix(id string) string {
+	return "arXiv:" + id + " "
+}
+
+// arxivAdd adds arXiv papers, given by ID or found with -query and
+// -author, to a notebook: the abstract and metadata as a text source and
+// the PDF as a second source. Papers already in the notebook are skipped.
+func arxivAdd(c *api.Client, notebookID string, ids []string) error {
+	if len(ids) == 0 && arxivQuery == "" && arxivAuthor == "" {
+		return fmt.Errorf("arxiv: give paper IDs, -query or -author")
+	}
+	st, err := openState()
+	if err != nil {
+		return fmt.Errorf("arxiv: %w", err)
+	}
+	name := filepath.Join("arxiv", notebookID+".json")
+	as := &arxivState{Papers: make(map[string]arxivPaper)}
+	if err := st.Load(name, as); err != nil {
+		return fmt.Errorf("arxiv: %w", err)
+	}
+	p, err := ingestPipeline()
+	if err != nil {
+		return fmt.Errorf("arxiv: %w", err)
+	}
+	nb, err := c.GetProject(notebookID)
+	if err != nil {
+		return fmt.Errorf("arxiv: %w", err)
+	}
+
+	ctx, stop := cleanup.NotifyContext(context.Background())
+	defer stop()
+	ax := &arxiv.Client{UserAgent: "nlm/" + buildVersion()}
+	var papers []arxiv.Paper
+	if len(ids) > 0 {
+		for i, in := range ids {
+			id, version, err := arxiv.ParseID(in)
+			if err != nil {
+				return err
+			}
+			if version > 0 {
+				id += "v" + strconv.Itoa(version)
+			}
+			ids[i] = id
+		}
+		fmt.Fprintf(os.Stderr, "Looking up %d papers...\n", len(ids))
+		found, err := ax.Get(ctx, ids)
+		if err != nil {
+			return err
+		}
+		papers = append(papers, found...)
+	}
+	if arxivQuery != "" || arxivAuthor != "" {
+		q := arxiv.Query(arxivQuery, arxivAuthor)
+		fmt.Fprintf(os.Stderr, "Searching arXiv for %s...\n", q)
+		found, err := ax.Search(ctx, q, arxivMax)
+		if err != nil {
+			return err
+		}
+		if len(found) == 0 {
+			return fmt.Errorf("arxiv: no papers match %s", q)
+		}
+		papers = append(papers, found...)
+	}
+
+	var added, failed int
+	t := newTable("ARXIV", "TITLE", "RESULT")
+	for i, paper := range papers {
+		fmt.Fprintf(os.Stderr, "[%d/%d] %s %s\n", i+1, len(papers), paper.ID, paper.Title)
+		if paperInNotebook(as, nb, paper.ID) {
+			t.Append(paper.ID, paper.Title, "skipped: already added")
+			continue
+		}
+		if added > 0 {
+			time.Sleep(arxivDownloadDelay)
+		}
+		rec := arxivPaper{Version: paper.Version, Title: paper.Title}
+		title := arxivTitlePrefix(paper.ID) + "— " + paper.Title
+		text, _ := filterText(p, title, arxivAbstract(paper))
+		if rec.Abstract, err = c.AddSourceFromText(notebookID, text, title+" (abstract)"); err != nil {
+			t.Append(paper.ID, paper.Title, "error: "+err.Error())
+			failed++
+			continue
+		}
+		result := "added"
+		pdf, err := ax.PDF(ctx, paper)
+		if err == nil {
+			rec.PDF, err = c.AddSourceFromBase64(notebookID, base64.StdEncoding.EncodeToString(pdf), title+".pdf", "application/pdf")
+		}
+		if err != nil {
+			result = "added abstract only; pdf: " + err.Error()
+			failed++
+		}
+		rec.AddedAt = time.Now().UTC()
+		as.Papers[paper.ID] = rec
+		if err := st.Save(name, as); err != nil {
+			return fmt.Errorf("arxiv: %w", err)
+		}
+		t.Append(paper.ID, paper.Title, result)
+		added++
+	}
+	if err := t.Render(os.Stdout); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("arxiv: %d papers failed", failed)
+	}
+	return nil
+}
+
+// paperInNotebook reports whether a paper was added to the notebook, as
+// recorded in the state directory or by a source titled with its ID.
+func paperInNotebook(as *arxivState, nb *api.Notebook, id string) bool {
+	if _, ok := as.Papers[id]; ok {
+		return true
+	}
+	for _, s := range nb.Sources {
+		if strings.HasPrefix(strings.TrimSpace(s.GetTitle()), arxivTitlePrefix(id)) {
+			return true
+		}
+	}
+	return false
+}
+
+// arxivAbstract is the text source for a paper's metadata and abstract.
+func arxivAbstract(p arxiv.Paper) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", p.Title)
+	fmt.Fprintf(&b, "arXiv: %sv%d\n", p.ID, p.Version)
+	if len(p.Authors) > 0 {
+		fmt.Fprintf(&b, "Authors: %s\n", strings.Join(p.Authors, ", "))
+	}
+	if len(p.Categories) > 0 {
+		fmt.Fprintf(&b, "Categories: %s\n", strings.Join(p.Categories, ", "))
+	}
+	if !p.Published.IsZero() {
+		fmt.Fprintf(&b, "Published: %s\n", p.Published.Format("2006-01-02"))
+	}
+	if !p.Updated.IsZero() && !p.Updated.Equal(p.Published) {
+		fmt.Fprintf(&b, "Updated: %s\n", p.Updated.Format("2006-01-02"))
+	}
+	fmt.Fprintf(&b, "URL: %s\n\n## Abstract\n\n%s\n", p.AbsURL, p.Abstract)
+	return b.String()
+}
@@ -3,15 +3,16 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/tmc/nlm/internal/auth"
+	"github.com/tmc/nlm/internal/state"
 	"golang.org/x/term"
 )
 
@@ -27,16 +28,13 @@ func handleAuth(args []string, debug bool) (string, string, error) {
 		return detectAuthInfo(string(input))
 	}
 
-	profileName := "Default"
-	if v := os.Getenv("NLM_BROWSER_PROFILE"); v != "" {
-		profileName = v
-	}
+	profileName := browserProfile()
 	if len(args) > 0 {
 		profileName = args[0]
 	}
 
 	a := auth.New(debug)
-	fmt.Fprintf(os.Stderr, "nlm: launching browser to login... (profile:%v)  (set with NLM_BROWSER_PROFILE)\n", profileName)
+	fmt.Fprintf(os.Stderr, "nlm: launching browser to login... (profile:%v)  (set with NLM_PROFILE)\n", profileName)
 	token, cookies, err := a.GetAuth(auth.WithProfileName(profileName))
 	if err != nil {
 		return "", "", fmt.Errorf("browser auth failed: %w", err)
@@ -74,48 +72,56 @@ func detectAuthInfo(cmd string) (string, string, error) {
 	}
 	authToken := atMatch[1]
 	persistAuthToDisk(cookies, authToken, "")
+	freezeCurlHeaders(cmd)
 	return authToken, cookies, nil
 }
 
 func persistAuthToDisk(cookies, authToken, profileName string) (string, string, error) {
-	homeDir, err := os.UserHomeDir()
+	st, err := openState()
 	if err != nil {
-		return "", "", fmt.Errorf("get home dir: %w", err)
-	}
-
-	// Create .nlm directory if it doesn't exist
-	nlmDir := filepath.Join(homeDir, ".nlm")
-	if err := os.MkdirAll(nlmDir, 0700); err != nil {
-		return "", "", fmt.Errorf("create .nlm directory: %w", err)
+		return "", "", err
 	}
 
 	// Create or update env file
-	envFile := filepath.Join(nlmDir, "env")
 	content := fmt.Sprintf("NLM_COOKIES=%q\nNLM_AUTH_TOKEN=%q\nNLM_BROWSER_PROFILE=%q\n",
 		cookies,
 		authToken,
 		profileName,
 	)
 
-	if err := os.WriteFile(envFile, []byte(content), 0600); err != nil {
+	if err := st.WriteFile("env", []byte(content)); err != nil {
 		return "", "", fmt.Errorf("write env file: %w", err)
 	}
 
-	fmt.Fprintf(os.Stderr, "nlm: auth info written to %s\n", envFile)
+	fmt.Fprintf(os.Stderr, "nlm: auth info written to %s\n", st.Path("env"))
 	return authToken, cookies, nil
 }
 
 func loadStoredEnv() {
-	home, err := os.UserHomeDir()
+	for key, value := range readStoredEnv() {
+		if os.Getenv(key) == "" {
+			os.Setenv(key, value)
+		}
+	}
+}
+
+// readStoredEnv returns the variables saved by nlm auth.
+func readStoredEnv() map[string]string {
+	st, err := openState()
 	if err != nil {
-		return
+		return nil
 	}
 
-	data, err := os.ReadFile(filepath.Join(home, ".nlm", "env"))
+	data, err := st.ReadFile("env")
+	if errors.Is(err, state.ErrLocked) {
+		fmt.Fprintf(os.Stderr, "nlm: stored credentials not loaded: %v\n", err)
+		return nil
+	}
 	if err != nil {
-		return
+		return nil
 	}
 
+	env := map[string]string{}
 	s := bufio.NewScanner(strings.NewReader(string(data)))
 	for s.Scan() {
 		line := strings.TrimSpace(s.Text())
@@ -128,15 +134,11 @@ func loadStoredEnv() {
 			continue
 		}
 
-		key = strings.TrimSpace(key)
-		if os.Getenv(key) != "" {
-			continue
-		}
-
 		value = strings.TrimSpace(value)
 		if unquoted, err := strconv.Unquote(value); err == nil {
 			value = unquoted
 		}
-		os.Setenv(key, value)
+		env[strings.TrimSpace(key)] = value
 	}
+	return env
 }
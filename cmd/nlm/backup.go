@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/cleanup"
+	"github.com/tmc/nlm/internal/export"
+	"github.com/tmc/nlm/internal/fanout"
+)
+
+// Backup flags
+var (
+	backupAll  bool
+	backupKeep int
+)
+
+func init() {
+	flag.BoolVar(&backupAll, "all", false, "with backup or share report, cover every notebook")
+	flag.IntVar(&backupKeep, "keep", 7, "with backup, number of snapshots to keep")
+}
+
+const (
+	backupSummaryName = "summary.json"
+	// backupLatestName is written to the backup root after every run, for
+	// monitoring.
+	backupLatestName = "latest.json"
+)
+
+// Backup result statuses.
+const (
+	backupNew       = "new"
+	backupUpdated   = "updated"
+	backupUnchanged = "unchanged"
+	backupFailed    = "failed"
+	// backupSkipped notebooks were not started, after an interrupt or a
+	// failure with -fail-fast.
+	backupSkipped = "skipped"
+)
+
+// backupSummary describes one backup run.
+type backupSummary struct {
+	Snapshot  string         `json:"snapshot"`
+	StartedAt time.Time      `json:"started_at"`
+	Finished  time.Time      `json:"finished_at"`
+	Notebooks []backupResult `json:"notebooks"`
+	Failed    int            `json:"failed"`
+	Skipped   int            `json:"skipped,omitempty"`
+	Pruned    []string       `json:"pruned,omitempty"`
+}
+
+type backupResult struct {
+	ID       string    `json:"id"`
+	Title    string    `json:"title"`
+	Modified time.Time `json:"modified,omitempty"`
+	Status   string    `json:"status"`
+	Items    int       `json:"items"`
+	Error    string    `json:"error,omitempty"`
+}
+
+func defaultBackupDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "nlm-backups"
+	}
+	return filepath.Join(home, "nlm-backups")
+}
+
+// backup writes a new snapshot of the given notebooks (or all notebooks)
+// under root. Notebooks whose modification time matches the previous
+// snapshot are hard linked from it without fetching anything; changed
+// notebooks start from the previous copy and only fetch what is new. Old
+// snapshots beyond -keep are removed, and a summary is written to the
+// snapshot and to root/latest.json.
+func backup(c *api.Client, root string, ids []string) error {
+	if !backupAll && len(ids) == 0 {
+		return fmt.Errorf("backup: give notebook IDs or -all")
+	}
+	if root == "" {
+		root = defaultBackupDir()
+	}
+	nbs, err := c.ListRecentlyViewedProjects()
+	if err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	if !backupAll {
+		want := make(map[string]bool, len(ids))
+		for _, id := range ids {
+			want[id] = true
+		}
+		var selected []*api.Notebook
+		for _, nb := range nbs {
+			if want[nb.GetProjectId()] {
+				selected = append(selected, nb)
+				delete(want, nb.GetProjectId())
+			}
+		}
+		for id := range want {
+			return fmt.Errorf("backup: notebook %s not found", id)
+		}
+		nbs = selected
+	}
+
+	prevSnapshots, err := export.Snapshots(root)
+	if err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	var prevDir string
+	prev := make(map[string]backupResult)
+	if len(prevSnapshots) > 0 {
+		prevDir = filepath.Join(root, prevSnapshots[len(prevSnapshots)-1])
+		var s backupSummary
+		if data, err := os.ReadFile(filepath.Join(prevDir, backupSummaryName)); err == nil && json.Unmarshal(data, &s) == nil {
+			for _, r := range s.Notebooks {
+				prev[r.ID] = r
+			}
+		}
+	}
+
+	start := time.Now()
+	sum := backupSummary{Snapshot: export.SnapshotName(start), StartedAt: start.UTC()}
+	snap := filepath.Join(root, sum.Snapshot)
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	// A new snapshot never reuses a directory, which may be prevDir.
+	if err := os.Mkdir(snap, 0o755); err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+
+	// Notebooks are backed up -workers at a time. An interrupt skips the
+	// ones not yet started, and the snapshot is still summarized.
+	ctx, stop := cleanup.NotifyContext(context.Background())
+	defer stop()
+	var started, finished atomic.Int32
+	results := fanout.Run(ctx, nbs, fanoutOptions(), func(_ context.Context, nb *api.Notebook) (backupResult, error) {
+		r := newBackupResult(nb)
+		fmt.Fprintf(os.Stderr, "(%d/%d) %s\n", started.Add(1), len(nbs), r.Title)
+		reportStep("backup", r.Title, int(finished.Load()), len(nbs))
+		var err error
+		r.Status, r.Items, err = backupNotebook(c, prevDir, snap, r.ID, prev[r.ID], r.Modified)
+		finished.Add(1)
+		if err != nil {
+			if quiet {
+				fmt.Fprintf(errorOutput, "backup %s: %v\n", r.Title, err)
+			} else {
+				fmt.Fprintf(os.Stderr, "  %s -> %v\n", r.Title, err)
+			}
+		}
+		return r, err
+	})
+	for i, res := range results {
+		r := res.Value
+		switch {
+		case res.Skipped():
+			r = newBackupResult(nbs[i])
+			r.Status = backupSkipped
+			sum.Skipped++
+		case res.Err != nil:
+			r.Status = backupFailed
+			r.Error = res.Err.Error()
+			sum.Failed++
+		}
+		sum.Notebooks = append(sum.Notebooks, r)
+	}
+
+	reportDone("backup", len(nbs))
+	if sum.Pruned, err = export.PruneSnapshots(root, backupKeep); err != nil {
+		return fmt.Errorf("backup: rotate: %w", err)
+	}
+	sum.Finished = time.Now().UTC()
+	data, err := json.MarshalIndent(sum, "", "  ")
+	if err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	data = append(data, '\n')
+	if err := export.WriteFileAtomic(filepath.Join(snap, backupSummaryName), data); err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	if err := export.WriteFileAtomic(filepath.Join(root, backupLatestName), data); err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	summarize("Backed up %d notebooks to %s (%d failed, %d skipped, %d old snapshots removed)",
+		len(nbs)-sum.Failed-sum.Skipped, snap, sum.Failed, sum.Skipped, len(sum.Pruned))
+	switch {
+	case sum.Failed > 0:
+		return fmt.Errorf("backup: %d of %d notebooks failed", sum.Failed, len(nbs))
+	case sum.Skipped > 0:
+		return fmt.Errorf("backup: %d of %d notebooks skipped", sum.Skipped, len(nbs))
+	}
+	return nil
+}
+
+// newBackupResult returns the result of nb before it is backed up.
+func newBackupResult(nb *api.Notebook) backupResult {
+	r := backupResult{ID: nb.GetProjectId(), Title: nb.GetTitle()}
+	if ts := nb.GetMetadata().GetModifiedTime(); ts != nil {
+		r.Modified = ts.AsTime().UTC()
+	}
+	return r
+}
+
+// backupNotebook writes one notebook into the snapshot and returns its
+// status and item count.
+func backupNotebook(c *api.Client, prevDir, snap, id string, prev backupResult, modified time.Time) (string, int, error) {
+	dst := filepath.Join(snap, id)
+	status := backupNew
+	if prevDir != "" {
+		src := filepath.Join(prevDir, id)
+		if _, err := os.Stat(src); err == nil {
+			if err := export.LinkTree(src, dst); err != nil {
+				return "", 0, err
+			}
+			status = backupUpdated
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return "", 0, err
+		}
+	}
+	m, err := export.Open(dst, id)
+	if err != nil {
+		return "", 0, err
+	}
+	if status == backupUpdated && m.Complete && !exportForce &&
+		prev.Status != backupFailed && !modified.IsZero() && prev.Modified.Equal(modified) {
+		return backupUnchanged, len(m.Items), nil
+	}
+	if _, err := exportItems(c, m, id); err != nil {
+		return "", 0, err
+	}
+	if err := m.Finish(); err != nil {
+		return "", 0, err
+	}
+	return status, len(m.Items), nil
+}
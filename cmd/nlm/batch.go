@@ -0,0 +1,401 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/tmc/nlm/gen/notebooklm/v1alpha1"
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/batch"
+	"github.com/tmc/nlm/internal/batchexecute"
+	"github.com/tmc/nlm/internal/cleanup"
+	"github.com/tmc/nlm/internal/fanout"
+	"github.com/tmc/nlm/internal/quota"
+)
+
+// batchWorkers is how many batch operations, or notebooks of backup and
+// share, are worked on at once.
+var batchWorkers int
+
+// failFast stops commands that work on many notebooks at the first
+// notebook that fails.
+var failFast bool
+
+func init() {
+	flag.IntVar(&batchWorkers, "workers", 4, "with batch, backup or share, number of operations or notebooks to work on at once")
+	flag.BoolVar(&failFast, "fail-fast", false, "with backup or share, skip the remaining notebooks after one fails")
+}
+
+// fanoutOptions are the options of commands that work on many notebooks.
+func fanoutOptions() fanout.Options {
+	return fanout.Options{Workers: batchWorkers, FailFast: failFast}
+}
+
+// runBatch runs the JSONL operations read from r, writing a JSONL result
+// for each to stdout. Progress, warnings and debug output go to stderr, so
+// stdout can be read by another program. The workers share c, which is
+// safe for concurrent use.
+func runBatch(c *api.Client, r io.Reader) error {
+	ctx, stop := cleanup.NotifyContext(context.Background())
+	defer stop()
+	ctx, halt := context.WithCancelCause(ctx)
+	defer halt(nil)
+	gate := newInterstitialGate()
+	defer gate.close()
+	ops := validatedOps(batchOps(c))
+	ops = pauseOnInterstitial(ops, gate, halt)
+	ops = stopOverBudget(ops, halt)
+	start := time.Now()
+	stats, err := batch.Run(ctx, r, os.Stdout, batchWorkers, ops)
+	stopErr := context.Cause(ctx)
+	if !errors.Is(stopErr, quota.ErrExceeded) && !errors.Is(stopErr, batchexecute.ErrInterstitial) {
+		stopErr = nil
+	}
+	if err != nil && stopErr == nil {
+		return fmt.Errorf("batch: %w", err)
+	}
+	summarize("%d operations succeeded, %d failed in %s", stats.OK, stats.Failed, time.Since(start).Round(time.Millisecond))
+	if stopErr != nil {
+		return fmt.Errorf("batch: stopped: %w", stopErr)
+	}
+	if stats.Failed > 0 {
+		return fmt.Errorf("batch: %d of %d operations failed", stats.Failed, stats.OK+stats.Failed)
+	}
+	return nil
+}
+
+// stopOverBudget wraps ops to end the run once one is refused for going
+// over the -budget, rather than fail every remaining operation the same
+// way.
+func stopOverBudget(ops map[string]batch.Func, cancel context.CancelCauseFunc) map[string]batch.Func {
+	for name, fn := range ops {
+		fn := fn
+		ops[name] = func(ctx context.Context, op batch.Op) (any, error) {
+			out, err := fn(ctx, op)
+			if errors.Is(err, quota.ErrExceeded) {
+				cancel(err)
+			}
+			return out, err
+		}
+	}
+	return ops
+}
+
+// An interstitialGate pauses a batch while the user deals with a page
+// Google showed instead of data, such as a consent screen. Operations run
+// at once, so the first to hit the page asks and the others wait for the
+// answer rather than ask again.
+type interstitialGate struct {
+	mu      sync.Mutex
+	file    *os.File      // the terminal, closed by close
+	tty     *bufio.Reader // nil if there is no terminal to ask on
+	cleared int           // how many times the user has said to go on
+}
+
+// newInterstitialGate returns a gate that asks on the terminal. Batch
+// operations are read from stdin, so the answer cannot come from there.
+func newInterstitialGate() *interstitialGate {
+	g := &interstitialGate{}
+	if tty, err := os.Open("/dev/tty"); err == nil {
+		g.file = tty
+		g.tty = bufio.NewReader(tty)
+	}
+	return g
+}
+
+// close closes the terminal.
+func (g *interstitialGate) close() {
+	if g.file != nil {
+		g.file.Close()
+	}
+}
+
+// generation identifies the answers given so far.
+func (g *interstitialGate) generation() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.cleared
+}
+
+// resume reports whether to retry an operation that failed with ie after
+// starting at generation gen. It returns at once if the user has said to
+// go on since then, and otherwise asks them to visit the page.
+func (g *interstitialGate) resume(ie *batchexecute.InterstitialError, gen int) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.cleared != gen {
+		return true
+	}
+	if g.tty == nil {
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "\nPaused: %v\n", ie)
+	if !confirm(g.tty, "Continue once done in the browser?", true) {
+		return false
+	}
+	g.cleared++
+	return true
+}
+
+// retry runs step, running it again each time it fails with an
+// interstitial the user has since gone through. Without a terminal, or if
+// the user declines, it stops the run with cancel, as every remaining
+// operation would fail the same way.
+func (g *interstitialGate) retry(ctx context.Context, cancel context.CancelCauseFunc, step func() error) error {
+	for {
+		gen := g.generation()
+		err := step()
+		var ie *batchexecute.InterstitialError
+		if !errors.Is(err, batchexecute.ErrInterstitial) || !errors.As(err, &ie) {
+			return err
+		}
+		if ctx.Err() != nil || !g.resume(ie, gen) {
+			cancel(err)
+			return err
+		}
+	}
+}
+
+// stepRetryKey is the context key of the function retrying one step of an
+// operation, as set by pauseOnInterstitial.
+type stepRetryKey struct{}
+
+// retryStep runs step, an RPC of an operation that makes several, so that
+// an interstitial retries that step alone rather than the whole
+// operation, which would redo the steps that succeeded.
+func retryStep(ctx context.Context, step func() error) error {
+	if retry, ok := ctx.Value(stepRetryKey{}).(func(func() error) error); ok {
+		return retry(step)
+	}
+	return step()
+}
+
+// pauseOnInterstitial wraps ops to wait for the user when Google asks for
+// consent or verification, retrying the operation once they have gone
+// through the page; operations that make several calls retry only the
+// failed one, through retryStep. Without a terminal, or if the user
+// declines, the run stops.
+func pauseOnInterstitial(ops map[string]batch.Func, g *interstitialGate, cancel context.CancelCauseFunc) map[string]batch.Func {
+	for name, fn := range ops {
+		fn := fn
+		ops[name] = func(ctx context.Context, op batch.Op) (any, error) {
+			stepCtx := context.WithValue(ctx, stepRetryKey{}, func(step func() error) error {
+				return g.retry(ctx, cancel, step)
+			})
+			var out any
+			err := g.retry(ctx, cancel, func() error {
+				var err error
+				out, err = fn(stepCtx, op)
+				return err
+			})
+			return out, err
+		}
+	}
+	return ops
+}
+
+// batchSource is a source or note as reported by batch operations.
+type batchSource struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Type  string `json:"type,omitempty"`
+}
+
+// batchOps returns the batch operations. They are named after the
+// commands they run and are checked the same way for feature availability
+// and write access; the notebook ID is given by the operation's notebook
+// field rather than as the first argument.
+func batchOps(c *api.Client) map[string]batch.Func {
+	ops := map[string]batch.Func{
+		"list": func(context.Context, batch.Op) (any, error) {
+			nbs, err := c.ListRecentlyViewedProjects()
+			if err != nil {
+				return nil, err
+			}
+			out := []editorNotebook{}
+			for _, nb := range nbs {
+				out = append(out, editorNotebook{
+					ID:      nb.GetProjectId(),
+					Title:   strings.TrimSpace(nb.GetTitle()),
+					Emoji:   strings.TrimSpace(nb.GetEmoji()),
+					Sources: len(nb.GetSources()),
+				})
+			}
+			return out, nil
+		},
+		"create": func(_ context.Context, op batch.Op) (any, error) {
+			if len(op.Args) != 1 {
+				return nil, fmt.Errorf("create needs args [title]")
+			}
+			nb, err := c.CreateProject(op.Args[0], "📙")
+			if err != nil {
+				return nil, err
+			}
+			return map[string]string{"notebook": nb.GetProjectId()}, nil
+		},
+		"rm": func(_ context.Context, op batch.Op) (any, error) {
+			return nil, deleteNotebook(c, op.Notebook)
+		},
+		"sources": func(_ context.Context, op batch.Op) (any, error) {
+			nb, err := c.GetProject(op.Notebook)
+			if err != nil {
+				return nil, err
+			}
+			out := []batchSource{}
+			for _, src := range nb.GetSources() {
+				out = append(out, batchSource{
+					ID:    src.GetSourceId().GetSourceId(),
+					Title: src.GetTitle(),
+					Type:  src.GetMetadata().GetSourceType().String(),
+				})
+			}
+			return out, nil
+		},
+		"add": func(ctx context.Context, op batch.Op) (any, error) {
+			if len(op.Args) == 0 {
+				return nil, fmt.Errorf("add needs args [file|url...]")
+			}
+			ids := []string{}
+			for _, in := range op.Args {
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+				if in == "-" {
+					return nil, fmt.Errorf("add: stdin carries the operations; use add-text")
+				}
+				var id string
+				err := retryStep(ctx, func() (err error) {
+					id, err = addSource(c, op.Notebook, in)
+					return err
+				})
+				if err != nil {
+					return nil, fmt.Errorf("add %s: %w", in, err)
+				}
+				ids = append(ids, id)
+			}
+			return map[string][]string{"sources": ids}, nil
+		},
+		"add-text": func(_ context.Context, op batch.Op) (any, error) {
+			if len(op.Args) < 1 || len(op.Args) > 2 {
+				return nil, fmt.Errorf("add-text needs args [text] or [text, title]")
+			}
+			title := "Text Source"
+			if len(op.Args) == 2 {
+				title = op.Args[1]
+			}
+			p, err := ingestPipeline()
+			if err != nil {
+				return nil, err
+			}
+			text, _ := filterText(p, title, op.Args[0])
+			id, err := c.AddSourceFromText(op.Notebook, text, title)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]string{"source": id}, nil
+		},
+		"rm-source": func(ctx context.Context, op batch.Op) (any, error) {
+			if len(op.Args) == 0 {
+				return nil, fmt.Errorf("rm-source needs args [source-id...]")
+			}
+			// As deleteSources, with each call retried on its own so the
+			// sources are not copied to the trash twice.
+			err := retryStep(ctx, func() error { return moveToTrash(c, op.Notebook, op.Args) })
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", errTrashFailed, err)
+			}
+			return nil, retryStep(ctx, func() error { return c.DeleteSources(op.Notebook, op.Args) })
+		},
+		"rename-source": func(_ context.Context, op batch.Op) (any, error) {
+			if len(op.Args) != 2 {
+				return nil, fmt.Errorf("rename-source needs args [source-id, title]")
+			}
+			_, err := c.MutateSource(op.Args[0], &pb.Source{Title: op.Args[1]})
+			return nil, err
+		},
+		"notes": func(_ context.Context, op batch.Op) (any, error) {
+			notes, err := c.GetNotes(op.Notebook)
+			if err != nil {
+				return nil, err
+			}
+			out := []batchSource{}
+			for _, n := range notes {
+				out = append(out, batchSource{ID: n.GetSourceId().GetSourceId(), Title: n.GetTitle()})
+			}
+			return out, nil
+		},
+		"new-note": func(_ context.Context, op batch.Op) (any, error) {
+			if len(op.Args) < 1 || len(op.Args) > 2 {
+				return nil, fmt.Errorf("new-note needs args [title] or [title, content]")
+			}
+			content := ""
+			if len(op.Args) == 2 {
+				content = op.Args[1]
+			}
+			note, err := c.CreateNote(op.Notebook, op.Args[0], content)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]string{"note": note.GetSourceId().GetSourceId()}, nil
+		},
+		"update-note": func(_ context.Context, op batch.Op) (any, error) {
+			if len(op.Args) < 2 || len(op.Args) > 3 {
+				return nil, fmt.Errorf("update-note needs args [note-id, content] or [note-id, content, title]")
+			}
+			title := ""
+			if len(op.Args) == 3 {
+				title = op.Args[2]
+			}
+			_, err := c.MutateNote(op.Notebook, op.Args[0], op.Args[1], title)
+			return nil, err
+		},
+		"rm-note": func(_ context.Context, op batch.Op) (any, error) {
+			if len(op.Args) == 0 {
+				return nil, fmt.Errorf("rm-note needs args [note-id...]")
+			}
+			return nil, c.DeleteNotes(op.Notebook, op.Args)
+		},
+		"audio-create": func(_ context.Context, op batch.Op) (any, error) {
+			if len(op.Args) != 1 {
+				return nil, fmt.Errorf("audio-create needs args [instructions]")
+			}
+			res, err := c.CreateAudioOverview(op.Notebook, op.Args[0])
+			if err != nil {
+				return nil, err
+			}
+			return map[string]any{"audio": res.AudioId, "ready": res.IsReady}, nil
+		},
+		"generate-guide": func(_ context.Context, op batch.Op) (any, error) {
+			guide, err := c.GenerateNotebookGuide(op.Notebook)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]string{"guide": guide.GetContent()}, nil
+		},
+	}
+	for name, fn := range ops {
+		name, fn := name, fn
+		ops[name] = func(ctx context.Context, op batch.Op) (any, error) {
+			if name != "list" && name != "create" && op.Notebook == "" {
+				return nil, fmt.Errorf("%s needs a notebook", name)
+			}
+			if err := requireFeature(c, name); err != nil {
+				return nil, err
+			}
+			out, err := fn(ctx, op)
+			if err != nil {
+				return nil, explainUnavailable(name, err)
+			}
+			return out, nil
+		}
+	}
+	return ops
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/bucket"
+	"github.com/tmc/nlm/internal/cleanup"
+	"github.com/tmc/nlm/internal/convert"
+	"github.com/tmc/nlm/internal/ingest"
+)
+
+// Bucket source flags
+var (
+	addS3         string
+	addGCS        string
+	objectInclude string
+	objectExclude string
+)
+
+func init() {
+	flag.StringVar(&addS3, "s3", "", "with add, add the objects under an s3://bucket/prefix `uri`")
+	flag.StringVar(&addGCS, "gcs", "", "with add, add the objects under a gs://bucket/prefix `uri`")
+	flag.StringVar(&objectInclude, "object-include", "", "with add -s3 or -gcs, only add objects whose key matches this `glob`, such as *.pdf")
+	flag.StringVar(&objectExclude, "object-exclude", "", "with add -s3 or -gcs, skip objects whose key matches this `glob`")
+}
+
+// maxObjectSize bounds the objects read from a bucket, which are held in
+// memory while they are converted and uploaded.
+const maxObjectSize = 200 << 20
+
+// bucketState records the objects of a bucket prefix added to a notebook,
+// so later runs only add new and changed objects.
+type bucketState struct {
+	URI     string                  `json:"uri"`
+	Objects map[string]bucketObject `json:"objects"` // by key
+}
+
+type bucketObject struct {
+	ETag     string    `json:"etag"`
+	SourceID string    `json:"source_id"`
+	SyncedAt time.Time `json:"synced_at"`
+}
+
+// addFromBucket adds the objects under an S3 or Cloud Storage prefix to a
+// notebook, streaming each one from the bucket through the converters and
+// upload filters. -object-include and -object-exclude select objects by
+// glob. Objects
+// are matched by key across runs: unchanged ones are skipped and changed
+// ones replace their previous source.
+func addFromBucket(c *api.Client, notebookID, uri string) error {
+	ctx, stop := cleanup.NotifyContext(context.Background())
+	defer stop()
+	b, prefix, err := bucket.Open(ctx, uri)
+	if err != nil {
+		return fmt.Errorf("add: %w", err)
+	}
+	for _, pattern := range []string{objectInclude, objectExclude} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("add: invalid pattern %q: %w", pattern, err)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Listing %s...\n", uri)
+	objects, err := b.List(ctx, prefix)
+	if err != nil {
+		return fmt.Errorf("add: %w", err)
+	}
+
+	st, err := openState()
+	if err != nil {
+		return fmt.Errorf("add: %w", err)
+	}
+	sum := sha256.Sum256([]byte(uri))
+	name := filepath.Join("buckets", notebookID+"-"+hex.EncodeToString(sum[:8])+".json")
+	bs := &bucketState{URI: uri, Objects: make(map[string]bucketObject)}
+	if err := st.Load(name, bs); err != nil {
+		return fmt.Errorf("add: %w", err)
+	}
+	var pending []bucket.Object
+	var tooLarge, newObjects int
+	for _, o := range objects {
+		if objectInclude != "" && !bucket.Match(objectInclude, o.Key) || objectExclude != "" && bucket.Match(objectExclude, o.Key) {
+			continue
+		}
+		if o.Size > maxObjectSize {
+			tooLarge++
+			continue
+		}
+		old, seen := bs.Objects[o.Key]
+		if seen && old.ETag == o.ETag {
+			continue
+		}
+		if !seen {
+			newObjects++
+		}
+		pending = append(pending, o)
+	}
+	if tooLarge > 0 {
+		fmt.Fprintf(os.Stderr, "Skipping %d objects larger than %s\n", tooLarge, formatBytes(maxObjectSize))
+	}
+	if len(pending) == 0 {
+		fmt.Fprintln(os.Stderr, "No new or changed objects.")
+		return nil
+	}
+	nb, err := c.GetProject(notebookID)
+	if err != nil {
+		return fmt.Errorf("add: %w", err)
+	}
+	if room := estimateMaxSources - len(nb.Sources); newObjects > room {
+		return fmt.Errorf("add: %d new objects but room for %d more sources (limit %d, see -max-sources)", newObjects, max(room, 0), estimateMaxSources)
+	}
+
+	p, err := ingestPipeline()
+	if err != nil {
+		return fmt.Errorf("add: %w", err)
+	}
+	conv, err := converters()
+	if err != nil {
+		return fmt.Errorf("add: %w", err)
+	}
+	var added, updated, failed int
+	t := newTable("OBJECT", "RESULT")
+	for i, o := range pending {
+		fmt.Fprintf(os.Stderr, "[%d/%d] %s (%s)\n", i+1, len(pending), o.Key, formatBytes(o.Size))
+		id, err := addBucketObject(ctx, c, b, p, conv, notebookID, o)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			t.Append(o.Key, "error: "+err.Error())
+			failed++
+			continue
+		}
+		old, seen := bs.Objects[o.Key]
+		bs.Objects[o.Key] = bucketObject{ETag: o.ETag, SourceID: id, SyncedAt: time.Now().UTC()}
+		if err := st.Save(name, bs); err != nil {
+			return fmt.Errorf("add: %w", err)
+		}
+		if !seen {
+			t.Append(o.Key, "added")
+			added++
+			continue
+		}
+		result := "updated"
+		if err := deleteSources(c, notebookID, []string{old.SourceID}); err != nil {
+			result = "updated; previous version not removed: " + err.Error()
+		}
+		t.Append(o.Key, result)
+		updated++
+	}
+	if err := t.Render(os.Stdout); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d added, %d updated, %d failed\n", added, updated, failed)
+	if failed > 0 {
+		return fmt.Errorf("add: %d of %d objects failed", failed, len(pending))
+	}
+	return nil
+}
+
+// addBucketObject reads an object and adds it as a source titled with its
+// file name: converted to text if a converter handles it, as filtered
+// text if it is text, and otherwise uploaded unchanged.
+func addBucketObject(ctx context.Context, c *api.Client, b bucket.Bucket, p *ingest.Pipeline, conv *convert.Registry, notebookID string, o bucket.Object) (string, error) {
+	rc, err := b.Open(ctx, o.Key)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	title := path.Base(o.Key)
+	if cv, ok := conv.Lookup(o.Key); ok {
+		var buf bytes.Buffer
+		if err := cv.Convert(ctx, title, rc, &buf); err != nil {
+			return "", fmt.Errorf("convert: %w", err)
+		}
+		text, _ := filterText(p, o.Key, buf.String())
+		return c.AddSourceFromText(notebookID, text, title)
+	}
+	data, err := io.ReadAll(io.LimitReader(rc, maxObjectSize))
+	if err != nil {
+		return "", fmt.Errorf("read: %w", err)
+	}
+	typ := http.DetectContentType(data)
+	if strings.HasPrefix(typ, "text/") {
+		text, _ := filterText(p, o.Key, string(data))
+		return c.AddSourceFromText(notebookID, text, title)
+	}
+	if p.Redacts() {
+		return "", fmt.Errorf("cannot apply redactions to an object that is not text; convert it to text first")
+	}
+	if t := mime.TypeByExtension(path.Ext(o.Key)); t != "" {
+		typ = t
+	}
+	return c.AddSourceFromBase64(notebookID, base64.StdEncoding.EncodeToString(data), title, typ)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/batchexecute"
+	"github.com/tmc/nlm/internal/redact"
+)
+
+// State files used for diagnostics.
+const (
+	historyFile     = "history.jsonl"
+	lastFailureFile = "last-failure.json"
+
+	maxHistoryEntries = 500
+	maxHistoryArgLen  = 80
+)
+
+// historyEntry records one command invocation.
+type historyEntry struct {
+	Time       time.Time `json:"time"`
+	Command    string    `json:"command"`
+	Args       []string  `json:"args,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	Error      string    `json:"error,omitempty"`
+	// Usage counts the requests the command sent, for nlm stats -usage.
+	Usage *batchexecute.Usage `json:"usage,omitempty"`
+}
+
+// failureRecord is the last failed command with its final HTTP exchange.
+type failureRecord struct {
+	Time     time.Time              `json:"time"`
+	Command  string                 `json:"command"`
+	Args     []string               `json:"args,omitempty"`
+	Error    string                 `json:"error"`
+	Exchange *batchexecute.Exchange `json:"exchange,omitempty"`
+}
+
+// recordHistory appends the command to the local history, keeping only the
+// most recent entries. The file is rewritten under its lock, so that
+// commands ending together do not drop each other's entries. Failures to
+// record are ignored; history is best effort and must never break a
+// command.
+func recordHistory(cmd string, args []string, start time.Time, usage batchexecute.Usage, cmdErr error) {
+	st, err := openState()
+	if err != nil {
+		return
+	}
+	unlock, err := st.Lock(historyFile)
+	if err != nil {
+		return
+	}
+	defer unlock()
+	e := historyEntry{
+		Time:       start.UTC(),
+		Command:    cmd,
+		Args:       shortArgs(args),
+		DurationMS: time.Since(start).Milliseconds(),
+	}
+	if len(usage.Calls) > 0 {
+		e.Usage = &usage
+	}
+	if cmdErr != nil {
+		e.Error = cmdErr.Error()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	data, _ := st.ReadFile(historyFile)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) == 1 && lines[0] == "" {
+		lines = nil
+	}
+	lines = append(lines, string(line))
+	if len(lines) > maxHistoryEntries {
+		lines = lines[len(lines)-maxHistoryEntries:]
+	}
+	st.WriteFile(historyFile, []byte(strings.Join(lines, "\n")+"\n"))
+}
+
+// recordFailure saves the failed command and the client's last HTTP
+// exchange for inclusion in bug reports.
+func recordFailure(c *api.Client, cmd string, args []string, cmdErr error) {
+	st, err := openState()
+	if err != nil {
+		return
+	}
+	st.Save(lastFailureFile, failureRecord{
+		Time:     time.Now().UTC(),
+		Command:  cmd,
+		Args:     shortArgs(args),
+		Error:    cmdErr.Error(),
+		Exchange: c.LastExchange(),
+	})
+}
+
+// shortArgs truncates long arguments, which are usually pasted text.
+func shortArgs(args []string) []string {
+	out := make([]string, len(args))
+	for i, a := range args {
+		if len(a) > maxHistoryArgLen {
+			a = a[:maxHistoryArgLen] + "…"
+		}
+		out[i] = a
+	}
+	return out
+}
+
+// bugreport writes a zip with version information, environment summary,
+// recent command history, schema drift warnings and the last failure, all
+// redacted, for attaching to GitHub issues.
+func bugreport(path string) error {
+	if path == "" {
+		path = fmt.Sprintf("nlm-bugreport-%s.zip", time.Now().Format("20060102-150405"))
+	}
+	r := redact.New(authToken, cookies)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	add := func(name, content string) error {
+		w, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write([]byte(r.String(content)))
+		return err
+	}
+
+	if err := add("version.txt", versionString()+"\n"); err != nil {
+		return fmt.Errorf("bugreport: %w", err)
+	}
+	if err := add("environment.txt", environmentSummary()); err != nil {
+		return fmt.Errorf("bugreport: %w", err)
+	}
+	if st, err := openState(); err == nil {
+		for _, name := range []string{historyFile, lastFailureFile, driftLog} {
+			data, err := st.ReadFile(name)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				data = []byte(fmt.Sprintf("unreadable: %v\n", err))
+			}
+			if err := add(name, string(data)); err != nil {
+				return fmt.Errorf("bugreport: %w", err)
+			}
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("bugreport: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("bugreport: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote bug report to %s\n", path)
+	fmt.Fprintf(os.Stderr, "Credentials and email addresses are redacted, but requests may include document text; review before attaching.\n")
+	return nil
+}
+
+// environmentSummary lists nlm related settings without their values.
+func environmentSummary() string {
+	var b strings.Builder
+	var names []string
+	for _, kv := range os.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(name, "NLM_") {
+			names = append(names, fmt.Sprintf("%s=<set, %d bytes>", name, len(value)))
+		}
+	}
+	sort.Strings(names)
+	fmt.Fprintf(&b, "environment:\n")
+	for _, n := range names {
+		fmt.Fprintf(&b, "  %s\n", n)
+	}
+	if st, err := openState(); err == nil {
+		fmt.Fprintf(&b, "state dir: %s\n", st.Dir())
+		fmt.Fprintf(&b, "state encrypted: %v\n", st.Encrypted())
+	}
+	fmt.Fprintf(&b, "debug: %v\n", debug)
+	return b.String()
+}
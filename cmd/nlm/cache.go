@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"sync"
+	"time"
+
+	pb "github.com/tmc/nlm/gen/notebooklm/v1alpha1"
+	"github.com/tmc/nlm/internal/api"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	metadataFile = "metadata.json"
+	// listTTL bounds how long a cached notebook list is used where a
+	// notebook created elsewhere in the meantime does no harm, as for
+	// examples and between role checks of one command. nlm list, pin and
+	// the first role check always fetch it.
+	listTTL = 5 * time.Minute
+	// probeTTL bounds how long a cached list is trusted to tell whether a
+	// notebook changed; older lists are refetched first, which is much
+	// cheaper than fetching the notebook with all of its sources.
+	probeTTL = 30 * time.Second
+)
+
+var noCache bool
+
+func init() {
+	flag.BoolVar(&noCache, "no-cache", false, "fetch notebook and source listings from the server instead of the local cache")
+}
+
+// changedAt is when this process last sent a call that may have changed
+// a notebook. Listings fetched before then are not used or saved.
+var (
+	changeMu  sync.Mutex
+	changedAt time.Time
+)
+
+// trackChanges has c drop the metadata cache after every call that may
+// change a notebook, and refuse changes to notebooks the caller may only
+// view.
+func trackChanges(c *api.Client) {
+	c.OnChange(func(string) {
+		changeMu.Lock()
+		changedAt = time.Now().UTC()
+		changeMu.Unlock()
+		if st, err := openState(); err == nil {
+			st.Remove(metadataFile)
+		}
+	})
+	c.CheckChanges(checkEditor(c))
+}
+
+// lastChange returns when this process last changed a notebook.
+func lastChange() time.Time {
+	changeMu.Lock()
+	defer changeMu.Unlock()
+	return changedAt
+}
+
+// metadataCache is the persisted notebook list and the notebooks fetched
+// since, for one login session. Notebooks are kept as protojson.
+type metadataCache struct {
+	Session   string                    `json:"session"`
+	ListedAt  time.Time                 `json:"listed_at"`
+	Notebooks []json.RawMessage         `json:"notebooks"`
+	Projects  map[string]cachedNotebook `json:"projects,omitempty"`
+}
+
+// cachedNotebook is a notebook with its sources, tagged with the
+// modification time reported when it was fetched.
+type cachedNotebook struct {
+	Modified time.Time       `json:"modified"`
+	Fetched  time.Time       `json:"fetched"`
+	Project  json.RawMessage `json:"project"`
+}
+
+func loadMetadata() *metadataCache {
+	mc := &metadataCache{}
+	if noCache {
+		return mc
+	}
+	st, err := openState()
+	if err != nil {
+		return mc
+	}
+	if st.Load(metadataFile, mc) != nil || mc.Session != sessionKey() {
+		return &metadataCache{}
+	}
+	return mc
+}
+
+func (mc *metadataCache) save() {
+	st, err := openState()
+	if err != nil {
+		return
+	}
+	changed := lastChange()
+	if mc.ListedAt.Before(changed) {
+		mc.ListedAt, mc.Notebooks = time.Time{}, nil
+	}
+	for id, cp := range mc.Projects {
+		if cp.Fetched.Before(changed) {
+			delete(mc.Projects, id)
+		}
+	}
+	mc.Session = sessionKey()
+	st.Save(metadataFile, mc)
+}
+
+// notebooks returns the cached notebook list if it is younger than ttl,
+// and fetches and caches it otherwise. With ttl 0 it always fetches.
+func (mc *metadataCache) notebooks(c *api.Client, ttl time.Duration) ([]*api.Notebook, error) {
+	if !mc.ListedAt.IsZero() && time.Since(mc.ListedAt) < ttl && !mc.ListedAt.Before(lastChange()) {
+		nbs := make([]*api.Notebook, 0, len(mc.Notebooks))
+		for _, raw := range mc.Notebooks {
+			var nb pb.Project
+			if err := protojson.Unmarshal(raw, &nb); err != nil {
+				nbs = nil
+				break
+			}
+			nbs = append(nbs, &nb)
+		}
+		if nbs != nil {
+			return nbs, nil
+		}
+	}
+	listed := time.Now().UTC()
+	nbs, err := c.ListRecentlyViewedProjects()
+	if err != nil {
+		return nil, err
+	}
+	mc.ListedAt = listed
+	mc.Notebooks = mc.Notebooks[:0]
+	for _, nb := range nbs {
+		raw, err := protojson.Marshal(nb)
+		if err != nil {
+			return nil, fmt.Errorf("cache notebook list: %w", err)
+		}
+		mc.Notebooks = append(mc.Notebooks, raw)
+	}
+	mc.save()
+	return nbs, nil
+}
+
+// project returns the notebook with its sources. A cached copy is used
+// when the notebook list reports the same modification time it had when
+// it was fetched; notebooks without a reported modification time are
+// always fetched.
+func (mc *metadataCache) project(c *api.Client, id string) (*api.Notebook, error) {
+	var modified time.Time
+	if nbs, err := mc.notebooks(c, probeTTL); err == nil {
+		for _, nb := range nbs {
+			if nb.GetProjectId() == id && nb.GetMetadata().GetModifiedTime() != nil {
+				modified = nb.GetMetadata().GetModifiedTime().AsTime()
+			}
+		}
+	}
+	if cp, ok := mc.Projects[id]; ok && !modified.IsZero() && cp.Modified.Equal(modified) && !cp.Fetched.Before(lastChange()) {
+		var p pb.Project
+		if err := protojson.Unmarshal(cp.Project, &p); err == nil {
+			return &p, nil
+		}
+	}
+	fetched := time.Now().UTC()
+	p, err := c.GetProject(id)
+	if err != nil {
+		return nil, err
+	}
+	if modified.IsZero() {
+		return p, nil
+	}
+	raw, err := protojson.Marshal(p)
+	if err != nil {
+		return p, nil
+	}
+	if mc.Projects == nil {
+		mc.Projects = make(map[string]cachedNotebook)
+	}
+	mc.Projects[id] = cachedNotebook{Modified: modified, Fetched: fetched, Project: raw}
+	mc.save()
+	return p, nil
+}
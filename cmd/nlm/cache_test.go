@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/batchexecute"
+	"github.com/tmc/nlm/internal/fake"
+)
+
+func TestNotebooksTTL(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("NLM_HOME", dir)
+	stateOnce = sync.Once{}
+	t.Cleanup(func() { stateOnce = sync.Once{} })
+
+	s := &fake.Server{Scenario: fake.Success, Path: filepath.Join(dir, "fake.json")}
+	c := api.New("token", "cookies", batchexecute.WithHTTPClient(&http.Client{Transport: s}))
+	if nbs, err := loadMetadata().notebooks(c, listTTL); err != nil || len(nbs) != 0 {
+		t.Fatalf("notebooks = %v, %v; want none", nbs, err)
+	}
+	// A notebook created without trackChanges, as in the web UI, leaves
+	// the cache in place.
+	if _, err := c.CreateProject("Elsewhere", "📙"); err != nil {
+		t.Fatal(err)
+	}
+	if nbs, err := loadMetadata().notebooks(c, listTTL); err != nil || len(nbs) != 0 {
+		t.Fatalf("cached notebooks = %v, %v; want the cached empty list", nbs, err)
+	}
+	if nbs, err := loadMetadata().notebooks(c, 0); err != nil || len(nbs) != 1 {
+		t.Fatalf("notebooks with ttl 0 = %v, %v; want the new notebook", nbs, err)
+	}
+	if nbs, err := loadMetadata().notebooks(c, listTTL); err != nil || len(nbs) != 1 {
+		t.Errorf("notebooks after refresh = %v, %v; want the new notebook", nbs, err)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tmc/nlm/internal/api"
+)
+
+// canaryTitle is the title of the notebook nlm canary creates and deletes.
+const canaryTitle = "nlm canary"
+
+// canaryFile is the state file listing the notebooks nlm canary created
+// and has not yet deleted. Only these are deleted, so a notebook the user
+// happens to title "nlm canary" is left alone.
+const canaryFile = "canary.json"
+
+// canaryState is the content of canaryFile.
+type canaryState struct {
+	Notebooks []string `json:"notebooks"`
+}
+
+// canaryStep is the outcome of one step of nlm canary.
+type canaryStep struct {
+	Name         string `json:"name"`
+	OK           bool   `json:"ok"`
+	Skipped      bool   `json:"skipped,omitempty"`
+	Milliseconds int64  `json:"ms"`
+	Error        string `json:"error,omitempty"`
+}
+
+// canaryReport is the JSON object nlm canary prints.
+type canaryReport struct {
+	Time         time.Time    `json:"time"`
+	OK           bool         `json:"ok"`
+	Milliseconds int64        `json:"ms"`
+	Steps        []canaryStep `json:"steps"`
+}
+
+// canary implements nlm canary: it lists notebooks, creates a canary
+// notebook, adds a text source and checks it is listed, and deletes the
+// notebook, then prints one JSON object with the outcome and timing of
+// each step. It fails if any step failed, for cron and monitoring.
+func canary(c *api.Client) error {
+	st, err := openState()
+	if err != nil {
+		return fmt.Errorf("canary: %w", err)
+	}
+	unlock, err := st.Lock(canaryFile)
+	if err != nil {
+		return fmt.Errorf("canary: %w", err)
+	}
+	defer unlock()
+	// Notebooks left by interrupted runs are deleted with this run's.
+	var pending canaryState
+	if err := st.Load(canaryFile, &pending); err != nil {
+		return fmt.Errorf("canary: %w", err)
+	}
+
+	start := time.Now()
+	report := canaryReport{Time: start.UTC(), OK: true}
+	run := func(name string, fn func() error) bool {
+		t := time.Now()
+		err := fn()
+		s := canaryStep{Name: name, OK: err == nil, Milliseconds: time.Since(t).Milliseconds()}
+		if err != nil {
+			s.Error = redactString(err.Error())
+			report.OK = false
+		}
+		report.Steps = append(report.Steps, s)
+		return err == nil
+	}
+	skip := func(name string, reason error) {
+		report.Steps = append(report.Steps, canaryStep{Name: name, OK: true, Skipped: true, Error: reason.Error()})
+	}
+
+	// Pending notebooks that are no longer listed were deleted some
+	// other way and are forgotten.
+	remove := pending.Notebooks
+	run("list", func() error {
+		nbs, err := c.ListRecentlyViewedProjects()
+		if err != nil {
+			return err
+		}
+		listed := make(map[string]bool)
+		for _, nb := range nbs {
+			listed[nb.GetProjectId()] = true
+		}
+		remove = remove[:0:0]
+		for _, id := range pending.Notebooks {
+			if listed[id] {
+				remove = append(remove, id)
+			}
+		}
+		return nil
+	})
+	var id string
+	created := run("create", func() error {
+		nb, err := c.CreateProject(canaryTitle, "🐤")
+		if err != nil {
+			return err
+		}
+		id = nb.GetProjectId()
+		remove = append(remove, id)
+		// The notebook is recorded before anything else can fail, so
+		// that an interrupted run's notebook is deleted by the next.
+		if err := st.Save(canaryFile, canaryState{Notebooks: remove}); err != nil {
+			return fmt.Errorf("record canary notebook: %w", err)
+		}
+		return nil
+	})
+	if created {
+		run("add-text", func() error {
+			src, err := c.AddSourceFromText(id, "This notebook is created and deleted by nlm canary to check that NotebookLM works.", "canary")
+			if err != nil {
+				return err
+			}
+			nb, err := c.GetProject(id)
+			if err != nil {
+				return fmt.Errorf("list sources: %w", err)
+			}
+			for _, s := range nb.GetSources() {
+				if s.GetSourceId().GetSourceId() == src {
+					return nil
+				}
+			}
+			return fmt.Errorf("source %s was added but is not listed", src)
+		})
+	} else {
+		skip("add-text", errors.New("no notebook"))
+	}
+	if len(remove) > 0 {
+		run("delete", func() error {
+			if err := c.DeleteProjects(remove); err != nil {
+				return err
+			}
+			return st.Remove(canaryFile)
+		})
+	} else {
+		skip("delete", errors.New("no notebook"))
+	}
+	report.Milliseconds = time.Since(start).Milliseconds()
+
+	if err := json.NewEncoder(os.Stdout).Encode(report); err != nil {
+		return fmt.Errorf("canary: %w", err)
+	}
+	failed := 0
+	for _, s := range report.Steps {
+		if !s.OK {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("canary: %d of %d steps failed", failed, len(report.Steps))
+	}
+	summarize("canary: %d steps passed in %s", len(report.Steps), time.Since(start).Round(time.Millisecond))
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/errinfo"
+	"github.com/tmc/nlm/internal/export"
+)
+
+// Changes flags
+var (
+	sinceFlag  string
+	jsonOutput bool
+)
+
+func init() {
+	flag.StringVar(&sinceFlag, "since", "7d", "with changes, stats or list, how far back to look: a duration such as 36h, 7d or 2w, or a date")
+	flag.BoolVar(&jsonOutput, "json", false, "with changes, share report, list, sources or notes, print JSON; on failure, print the error as JSON")
+}
+
+// changeEvent is the last change to a notebook item. NotebookLM reports
+// when sources and notes were last changed but not by whom, and keeps no
+// record of removals, so this is a list of recent changes rather than an
+// audit log: events say neither who made them nor what was removed.
+type changeEvent struct {
+	Time  time.Time `json:"time"`
+	Kind  string    `json:"kind"`
+	ID    string    `json:"id"`
+	Title string    `json:"title"`
+}
+
+// changes lists the sources and notes added or changed in a notebook since
+// the -since cutoff, newest first.
+func changes(c *api.Client, notebookID string) error {
+	since, err := parseSince(sinceFlag, time.Now())
+	if err != nil {
+		return fmt.Errorf("changes: %w", err)
+	}
+	p, err := c.GetProject(notebookID)
+	if err != nil {
+		return fmt.Errorf("changes: %w", err)
+	}
+	notes, err := c.GetNotes(notebookID)
+	if err != nil {
+		return fmt.Errorf("changes: %w", err)
+	}
+
+	var events []changeEvent
+	add := func(kind string, items []*api.Note) {
+		for _, it := range items {
+			ts := it.GetMetadata().GetLastModifiedTime()
+			if ts == nil || ts.AsTime().Before(since) {
+				continue
+			}
+			events = append(events, changeEvent{
+				Time:  ts.AsTime().UTC(),
+				Kind:  kind,
+				ID:    it.GetSourceId().GetSourceId(),
+				Title: strings.TrimSpace(it.GetTitle()),
+			})
+		}
+	}
+	add(export.KindSource, p.Sources)
+	add(export.KindNote, notes)
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.After(events[j].Time) })
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if events == nil {
+			events = []changeEvent{}
+		}
+		return enc.Encode(events)
+	}
+	if templateFile != "" {
+		return renderTemplate(os.Stdout, templateFile, events)
+	}
+	t := newTable("TIME", "KIND", "ID", "TITLE")
+	for _, e := range events {
+		t.Append(e.Time.Format(time.RFC3339), e.Kind, e.ID, e.Title)
+	}
+	return t.Render(os.Stdout)
+}
+
+// parseSince parses a -since value: a Go duration, a number of days or
+// weeks such as 7d or 2w, or a date in RFC 3339 or YYYY-MM-DD form.
+func parseSince(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if n := len(s); n > 1 && (s[n-1] == 'd' || s[n-1] == 'w') {
+		if v, err := strconv.Atoi(s[:n-1]); err == nil && v >= 0 {
+			days := v
+			if s[n-1] == 'w' {
+				days *= 7
+			}
+			return now.AddDate(0, 0, -days), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, errinfo.Usagef("invalid -since %q (want a duration like 7d or a date)", s)
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/cleanup"
+	"github.com/tmc/nlm/internal/confluence"
+	"github.com/tmc/nlm/internal/ingest"
+)
+
+// Confluence import flags
+var (
+	importConfluence bool
+	confluenceSpace  string
+)
+
+func init() {
+	flag.BoolVar(&importConfluence, "confluence", false, "with import, add the pages of a Confluence space to a notebook (see NLM_CONFLUENCE_URL)")
+	flag.StringVar(&confluenceSpace, "space", "", "with import -confluence, `key` of the space to import, such as ENG")
+}
+
+// confluenceState records the pages of a space imported into a notebook
+// and their versions, so later imports only re-add edited pages.
+type confluenceState struct {
+	Site  string                    `json:"site"`
+	Space string                    `json:"space"`
+	Pages map[string]confluencePage `json:"pages"` // by page ID
+}
+
+type confluencePage struct {
+	Title    string    `json:"title"`
+	Version  int       `json:"version"`
+	SourceID string    `json:"source_id"`
+	SyncedAt time.Time `json:"synced_at"`
+}
+
+// importConfluenceSpace adds the pages of the -space Confluence space to
+// a notebook as text sources, converted from storage format to Markdown.
+// The site and credentials come from NLM_CONFLUENCE_URL,
+// NLM_CONFLUENCE_TOKEN and, for Confluence Cloud, NLM_CONFLUENCE_USER.
+// Pages whose version has not changed since the last import are skipped;
+// edited pages replace their previous source.
+func importConfluenceSpace(c *api.Client, notebookID string) error {
+	if confluenceSpace == "" {
+		return fmt.Errorf("import: give the space to import with -space")
+	}
+	cc := &confluence.Client{
+		BaseURL:   settings.Services.ConfluenceURL,
+		Token:     settings.Services.ConfluenceToken,
+		User:      settings.Services.ConfluenceUser,
+		UserAgent: "nlm/" + buildVersion(),
+	}
+	if cc.BaseURL == "" || cc.Token == "" {
+		return fmt.Errorf("import: set NLM_CONFLUENCE_URL to the Confluence site and NLM_CONFLUENCE_TOKEN to a personal access token")
+	}
+	st, err := openState()
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	name := filepath.Join("confluence", notebookID+"-"+strings.ToLower(confluenceSpace)+".json")
+	cs := &confluenceState{Site: cc.BaseURL, Space: confluenceSpace, Pages: make(map[string]confluencePage)}
+	if err := st.Load(name, cs); err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	p, err := ingestPipeline()
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+
+	ctx, stop := cleanup.NotifyContext(context.Background())
+	defer stop()
+	fmt.Fprintf(os.Stderr, "Listing pages of space %s...\n", confluenceSpace)
+	pages, err := cc.Pages(ctx, confluenceSpace)
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	var pending []confluence.Page
+	for _, pg := range pages {
+		if old, ok := cs.Pages[pg.ID]; !ok || old.Version != pg.Version {
+			pending = append(pending, pg)
+		}
+	}
+	if len(pending) == 0 {
+		fmt.Fprintf(os.Stderr, "All %d pages are up to date.\n", len(pages))
+		return nil
+	}
+	nb, err := c.GetProject(notebookID)
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	var newPages int
+	for _, pg := range pending {
+		if _, ok := cs.Pages[pg.ID]; !ok {
+			newPages++
+		}
+	}
+	if room := estimateMaxSources - len(nb.Sources); newPages > room {
+		return fmt.Errorf("import: %d new pages but room for %d more sources (limit %d, see -max-sources)", newPages, max(room, 0), estimateMaxSources)
+	}
+
+	var added, updated, failed int
+	t := newTable("PAGE", "VERSION", "RESULT")
+	for i, pg := range pending {
+		fmt.Fprintf(os.Stderr, "[%d/%d] %s\n", i+1, len(pending), pg.Title)
+		old, seen := cs.Pages[pg.ID]
+		id, err := addConfluencePage(ctx, c, cc, p, notebookID, pg.ID)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		version := strconv.Itoa(pg.Version)
+		if err != nil {
+			t.Append(pg.Title, version, "error: "+err.Error())
+			failed++
+			continue
+		}
+		cs.Pages[pg.ID] = confluencePage{Title: pg.Title, Version: pg.Version, SourceID: id, SyncedAt: time.Now().UTC()}
+		if err := st.Save(name, cs); err != nil {
+			return fmt.Errorf("import: %w", err)
+		}
+		if seen {
+			result := "updated"
+			if err := deleteSources(c, notebookID, []string{old.SourceID}); err != nil {
+				result = "updated; previous version not removed: " + err.Error()
+			}
+			t.Append(pg.Title, fmt.Sprintf("%d → %d", old.Version, pg.Version), result)
+			updated++
+		} else {
+			t.Append(pg.Title, version, "added")
+			added++
+		}
+	}
+	if err := t.Render(os.Stdout); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d added, %d updated, %d unchanged, %d failed\n", added, updated, len(pages)-len(pending), failed)
+	if failed > 0 {
+		return fmt.Errorf("import: %d of %d pages failed", failed, len(pending))
+	}
+	return nil
+}
+
+// addConfluencePage fetches a page and adds it as a text source.
+func addConfluencePage(ctx context.Context, c *api.Client, cc *confluence.Client, p *ingest.Pipeline, notebookID, pageID string) (string, error) {
+	pg, err := cc.Page(ctx, pageID)
+	if err != nil {
+		return "", err
+	}
+	text, err := confluence.Markdown(pg.Storage)
+	if err != nil {
+		return "", err
+	}
+	text, _ = filterText(p, pg.Title, text)
+	doc := fmt.Sprintf("# %s\n\n%s\n", pg.Title, text)
+	if pg.URL != "" {
+		doc += fmt.Sprintf("\nSource: %s (version %d)\n", pg.URL, pg.Version)
+	}
+	return c.AddSourceFromText(notebookID, doc, pg.Title)
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/cleanup"
+	"github.com/tmc/nlm/internal/convert"
+	"github.com/tmc/nlm/internal/crawl"
+)
+
+// Crawl flags
+var (
+	crawlSitemap string
+	crawlInclude string
+	crawlExclude string
+	crawlDelay   time.Duration
+	crawlSeed    string
+	crawlDepth   int
+	crawlSameDom bool
+	crawlMax     int
+)
+
+func init() {
+	flag.StringVar(&crawlSitemap, "sitemap", "", "with crawl, `URL` of the sitemap listing the pages to add")
+	flag.StringVar(&crawlInclude, "include", "", "with crawl, only add pages whose path matches this `pattern` (a regexp)")
+	flag.StringVar(&crawlExclude, "exclude", "", "with crawl, skip pages whose path matches this `pattern` (a regexp)")
+	flag.DurationVar(&crawlDelay, "delay", time.Second, "with crawl, minimum time between requests to the site (raised to its robots.txt Crawl-delay)")
+	flag.StringVar(&crawlSeed, "seed", "", "with crawl, `URL` of the page to start following links from, for sites without a sitemap")
+	flag.IntVar(&crawlDepth, "depth", 2, "with crawl -seed, number of links to follow from the seed page")
+	flag.BoolVar(&crawlSameDom, "same-domain", true, "with crawl -seed, only follow links to the seed's domain and its subdomains")
+	flag.IntVar(&crawlMax, "max-pages", 100, "with crawl -seed, maximum number of pages to fetch")
+}
+
+// crawlSite adds the pages of a website to a notebook as text sources,
+// converted from HTML and passed through the ingest filters. Pages come
+// from the -sitemap sitemap, or from following links from the -seed page.
+// Pages robots.txt disallows are skipped, as are pages whose title a
+// source of the notebook already has, so a crawl can be rerun to pick up
+// new pages.
+func crawlSite(c *api.Client, notebookID string) error {
+	if (crawlSitemap == "") == (crawlSeed == "") {
+		return fmt.Errorf("crawl: give one of -sitemap or -seed")
+	}
+	cr := &crawl.Crawler{
+		UserAgent: "nlm/" + buildVersion(),
+		Delay:     crawlDelay,
+	}
+	var err error
+	if crawlInclude != "" {
+		if cr.Include, err = regexp.Compile(crawlInclude); err != nil {
+			return fmt.Errorf("crawl: -include: %w", err)
+		}
+	}
+	if crawlExclude != "" {
+		if cr.Exclude, err = regexp.Compile(crawlExclude); err != nil {
+			return fmt.Errorf("crawl: -exclude: %w", err)
+		}
+	}
+	p, err := ingestPipeline()
+	if err != nil {
+		return fmt.Errorf("crawl: %w", err)
+	}
+	nb, err := c.GetProject(notebookID)
+	if err != nil {
+		return fmt.Errorf("crawl: %w", err)
+	}
+	titles := make(map[string]bool, len(nb.Sources))
+	for _, s := range nb.Sources {
+		titles[strings.TrimSpace(s.GetTitle())] = true
+	}
+	room := estimateMaxSources - len(nb.Sources)
+	if room <= 0 {
+		return fmt.Errorf("crawl: notebook already has %d sources (limit %d, see -max-sources)", len(nb.Sources), estimateMaxSources)
+	}
+
+	var added, skipped, failed, seen int
+	t := newTable("URL", "TITLE", "RESULT")
+	// add adds a fetched page, returning crawl.SkipAll once the notebook
+	// is full.
+	add := func(u string, page *convert.Page, err error) error {
+		reportStep("crawl", u, seen, 0)
+		seen++
+		fmt.Fprintf(os.Stderr, "[%d] %s\n", seen, u)
+		switch {
+		case errors.Is(err, crawl.ErrNotPage) && crawlSeed != "":
+			t.Append(u, "", "skipped: not a web page")
+			skipped++
+			return nil
+		case err != nil:
+			t.Append(u, "", "error: "+err.Error())
+			failed++
+			return nil
+		}
+		title := page.Title
+		if title == "" {
+			title = pageName(u)
+		}
+		if titles[title] {
+			t.Append(u, title, "skipped: already a source")
+			skipped++
+			return nil
+		}
+		text, _ := filterText(p, u, page.Text)
+		if strings.TrimSpace(text) == "" {
+			t.Append(u, title, "skipped: no text")
+			skipped++
+			return nil
+		}
+		id, err := c.AddSourceFromText(notebookID, fmt.Sprintf("%s\n\nSource: %s\n", text, u), title)
+		if err != nil {
+			t.Append(u, title, "error: "+err.Error())
+			failed++
+			return nil
+		}
+		titles[title] = true
+		t.Append(u, title, id)
+		if added++; added >= room {
+			fmt.Fprintf(os.Stderr, "warning: the notebook is full (limit %d, see -max-sources); stopping\n", estimateMaxSources)
+			return crawl.SkipAll
+		}
+		return nil
+	}
+
+	ctx, stop := cleanup.NotifyContext(context.Background())
+	defer stop()
+	if crawlSeed != "" {
+		fmt.Fprintf(os.Stderr, "Crawling from %s (depth %d)...\n", crawlSeed, crawlDepth)
+		opts := crawl.FollowOptions{Depth: crawlDepth, MaxPages: crawlMax, SameDomain: crawlSameDom}
+		err = cr.Follow(ctx, crawlSeed, opts, add)
+	} else {
+		err = crawlSitemapPages(ctx, cr, add)
+	}
+	if rerr := t.Render(os.Stdout); rerr != nil {
+		return rerr
+	}
+	if err != nil {
+		return err
+	}
+	reportDone("crawl", seen)
+	summarize("%d pages added, %d skipped, %d failed", added, skipped, failed)
+	switch {
+	case seen == 0:
+		return fmt.Errorf("crawl: no pages matched")
+	case failed > 0:
+		return fmt.Errorf("crawl: %d pages failed", failed)
+	}
+	return nil
+}
+
+// crawlSitemapPages fetches the pages listed in the -sitemap sitemap and
+// passes them to add until it returns crawl.SkipAll.
+func crawlSitemapPages(ctx context.Context, cr *crawl.Crawler, add func(string, *convert.Page, error) error) error {
+	fmt.Fprintf(os.Stderr, "Reading sitemap %s...\n", crawlSitemap)
+	pages, err := cr.Sitemap(ctx, crawlSitemap)
+	if err != nil {
+		return err
+	}
+	for _, u := range pages {
+		page, err := cr.Fetch(ctx, u)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := add(u, page, err); err == crawl.SkipAll {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pageName names a page without a title after its URL path.
+func pageName(u string) string {
+	pu, err := url.Parse(u)
+	if err != nil || strings.Trim(pu.Path, "/") == "" {
+		return u
+	}
+	return pu.Host + pu.Path
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/auth"
+	"github.com/tmc/nlm/internal/batchexecute"
+	"github.com/tmc/nlm/internal/cleanup"
+	"github.com/tmc/nlm/internal/daemon"
+	"github.com/tmc/nlm/internal/rpc"
+)
+
+// Daemon flags
+var (
+	// keepAliveInterval is how often the daemon sends a request of its
+	// own to keep its session's credentials in use.
+	keepAliveInterval time.Duration
+	// daemonCacheTTL is how long the daemon reuses responses to read-only
+	// calls.
+	daemonCacheTTL time.Duration
+	// refreshAuth makes the daemon renew expired credentials from the
+	// browser profile.
+	refreshAuth bool
+)
+
+func init() {
+	flag.DurationVar(&keepAliveInterval, "keepalive", 10*time.Minute, "with daemon, how often to send a keep-alive request while idle (0 disables)")
+	flag.DurationVar(&daemonCacheTTL, "daemon-cache", 30*time.Second, "with daemon, how long to answer repeated read-only requests from memory (0 disables)")
+	flag.BoolVar(&refreshAuth, "refresh-auth", false, "with daemon, renew expired credentials from the browser profile in the background, as nlm auth does")
+}
+
+// daemonSocket returns the path of the daemon's unix socket.
+func daemonSocket() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, daemon.SocketName), nil
+}
+
+// daemonOptions routes requests through a running daemon. Commands fall
+// back to direct connections if the daemon goes away, and NLM_NO_DAEMON=1
+// bypasses it.
+func daemonOptions() []batchexecute.Option {
+	if settings.NoDaemon {
+		return nil
+	}
+	socket, err := daemonSocket()
+	if err != nil {
+		return nil
+	}
+	if _, err := os.Stat(socket); err != nil {
+		return nil
+	}
+	if debug {
+		fmt.Fprintf(os.Stderr, "Using daemon at %s\n", socket)
+	}
+	transport := daemon.NewClient(socket).Transport(nil)
+	return []batchexecute.Option{batchexecute.WithHTTPClient(&http.Client{Transport: transport})}
+}
+
+// runDaemon implements nlm daemon [start|status|stop].
+func runDaemon(args []string) error {
+	socket, err := daemonSocket()
+	if err != nil {
+		return err
+	}
+	sub := "start"
+	if len(args) > 0 {
+		sub = args[0]
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	switch sub {
+	case "start":
+		return serveDaemon(socket)
+	case "status":
+		st, err := daemon.NewClient(socket).Status(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("pid %d, nlm %s, up %s, %d requests proxied, %d from the cache\n",
+			st.PID, st.Version, time.Since(st.Started).Round(time.Second), st.Requests, st.CacheHits)
+		return nil
+	case "stop":
+		if err := daemon.NewClient(socket).Stop(ctx); err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, "Daemon stopped")
+		return nil
+	}
+	log.Fatal("usage: nlm daemon [start|status|stop]")
+	return nil
+}
+
+// serveDaemon runs the daemon in the foreground until it is stopped or
+// interrupted. Alongside the proxy socket it serves the editor API on
+// editor.sock.
+func serveDaemon(socket string) error {
+	l, err := daemon.Listen(socket)
+	if err != nil {
+		return fmt.Errorf("daemon: %w", err)
+	}
+	defer os.Remove(socket)
+	editorSocket := filepath.Join(filepath.Dir(socket), daemon.EditorSocketName)
+	el, err := daemon.Listen(editorSocket)
+	if err != nil {
+		l.Close()
+		return fmt.Errorf("daemon: %w", err)
+	}
+	defer os.Remove(editorSocket)
+
+	s := &daemon.Server{
+		Version:  buildVersion(),
+		CacheTTL: daemonCacheTTL,
+		ReadOnly: func(ids []string) bool {
+			for _, id := range ids {
+				if rpc.Writes(id) {
+					return false
+				}
+			}
+			return true
+		},
+	}
+	session := newEditorSession()
+	editor := &daemon.EditorServer{Methods: session.methods()}
+	go func() {
+		if err := editor.Serve(el); err != nil {
+			fmt.Fprintf(os.Stderr, "daemon: editor API: %v\n", err)
+		}
+	}()
+
+	sigCtx, stop := cleanup.NotifyContext(context.Background())
+	defer stop()
+	go func() {
+		<-sigCtx.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		s.Shutdown(ctx)
+	}()
+	go daemon.KeepAlive(sigCtx, keepAliveInterval, session.keepAlive, keepAliveReporter())
+
+	fmt.Fprintf(os.Stderr, "nlm daemon listening on %s (editor API on %s)\n", socket, editorSocket)
+	err = s.Serve(l)
+	editor.Close()
+	return err
+}
+
+// keepAlive sends the lightest request there is, listing recent notebooks,
+// so that the session's cookies and token are refreshed while no editor is
+// using them and expired credentials are replaced before the next request.
+// With -refresh-auth, credentials that expired are renewed from the
+// browser profile and saved, for the daemon and every later command.
+func (s *editorSession) keepAlive(ctx context.Context) error {
+	list := func(c *api.Client) error {
+		_, err := c.ListRecentlyViewedProjects()
+		return err
+	}
+	err := s.call(list)
+	if !refreshAuth || !errors.Is(err, batchexecute.ErrUnauthorized) {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, "daemon: credentials expired; renewing them from the browser profile")
+	profile := browserProfile()
+	token, cookies, aerr := auth.New(debug).GetAuth(auth.WithProfileName(profile))
+	if aerr != nil {
+		return fmt.Errorf("%w; renewing credentials failed: %v", err, aerr)
+	}
+	if _, _, aerr := persistAuthToDisk(cookies, token, profile); aerr != nil {
+		return fmt.Errorf("%w; saving renewed credentials failed: %v", err, aerr)
+	}
+	return s.call(list)
+}
+
+// keepAliveReporter returns a report func for daemon.KeepAlive that logs
+// when keep-alive requests start failing and when they recover, rather than
+// every request.
+func keepAliveReporter() func(error) {
+	failing := false
+	return func(err error) {
+		switch {
+		case err != nil && !failing:
+			fmt.Fprintf(os.Stderr, "daemon: keep-alive failed: %v\n", err)
+		case err == nil && failing:
+			fmt.Fprintln(os.Stderr, "daemon: keep-alive succeeded again")
+		case err == nil && debug:
+			fmt.Fprintln(os.Stderr, "daemon: keep-alive ok")
+		}
+		failing = err != nil
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/tmc/nlm/internal/errinfo"
+	"github.com/tmc/nlm/internal/redact"
+)
+
+// runDebug implements nlm debug, the tools for contributors.
+func runDebug(args []string) error {
+	if len(args) == 2 && args[0] == "sanitize" {
+		return sanitizeTrace(args[1], outputPath)
+	}
+	return errinfo.Usagef("usage: nlm debug sanitize <trace.har> [-o clean.har]")
+}
+
+// sanitizeTrace writes a copy of the HAR trace at path with credentials,
+// email addresses and document text removed to out, or to stdout, so that
+// it can be attached to an issue. The structure of RPC payloads is kept.
+func sanitizeTrace(path, out string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("sanitize: %w", err)
+	}
+	clean, stats, err := redact.New(authToken, cookies).HAR(data)
+	if err != nil {
+		return fmt.Errorf("sanitize: %s: %w", path, err)
+	}
+	clean = append(clean, '\n')
+	if out == "" {
+		if _, err := os.Stdout.Write(clean); err != nil {
+			return fmt.Errorf("sanitize: %w", err)
+		}
+	} else if err := os.WriteFile(out, clean, 0o644); err != nil {
+		return fmt.Errorf("sanitize: %w", err)
+	}
+	summarize("sanitize: %d requests: masked %d secrets, replaced %d strings with their length, removed %d other bodies",
+		stats.Entries, stats.Secrets, stats.Strings, stats.Bodies)
+	return nil
+}
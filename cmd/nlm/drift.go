@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/errinfo"
+)
+
+// checkRPCArgs is a developer flag: it checks the arguments of every call
+// against rpc.ArgSchemas before sending it, so a call that no longer has
+// the layout the web client uses fails at once with the differences.
+var checkRPCArgs bool
+
+func init() {
+	flag.BoolVar(&checkRPCArgs, "check-rpc-args", false, "check the arguments of every call against the known request layouts and fail on a mismatch without sending (for development)")
+}
+
+// shapesMode is "record" to record the shapes of responses as the golden
+// ones, or "check" to warn when a response's shape differs from them.
+var shapesMode string
+
+func init() {
+	flag.StringVar(&shapesMode, "shapes", "", "record the shapes of responses (record), or warn when they differ from the recorded ones (check)")
+}
+
+// shapesFile holds the golden response shapes in the state directory.
+const shapesFile = "shapes.json"
+
+// goldenShapes are the loaded golden shapes, or nil without -shapes.
+var goldenShapes api.Shapes
+
+// loadShapes loads the golden shapes for -shapes.
+func loadShapes() error {
+	switch shapesMode {
+	case "":
+		return nil
+	case "record", "check":
+	default:
+		return errinfo.Usagef("-shapes: want record or check, not %q", shapesMode)
+	}
+	st, err := openState()
+	if err != nil {
+		return fmt.Errorf("-shapes: %w", err)
+	}
+	goldenShapes = make(api.Shapes)
+	if err := st.Load(shapesFile, &goldenShapes); err != nil {
+		return fmt.Errorf("-shapes: %w", err)
+	}
+	if shapesMode == "check" && len(goldenShapes) == 0 {
+		fmt.Fprintln(os.Stderr, "nlm: no response shapes recorded yet; run commands with -shapes record first")
+	}
+	return nil
+}
+
+// saveShapes saves the shapes recorded with -shapes record.
+func saveShapes() {
+	if shapesMode != "record" {
+		return
+	}
+	st, err := openState()
+	if err == nil {
+		err = st.Save(shapesFile, goldenShapes)
+	}
+	if err != nil {
+		fmt.Fprintf(errorOutput, "nlm: response shapes not saved: %v\n", err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Recorded the response shapes of %d calls in %s\n", len(goldenShapes), st.Path(shapesFile))
+}
+
+// debugDir is the directory of the state store that undecodable payloads
+// and the drift log are saved in. They are state files like any other, so
+// they are only readable by the user and are encrypted when the state is.
+const debugDir = "debug"
+
+// driftLog records every schema drift warning, one JSON object per line.
+const driftLog = debugDir + "/drift.jsonl"
+
+// watchDrift saves undecodable payloads and reports schema drift on
+// stderr, so a changed response degrades a command instead of aborting it.
+func watchDrift(c *api.Client) {
+	st, stErr := openState()
+	if stErr == nil {
+		c.SavePayloadsWith(func(name string, data []byte) (string, error) {
+			name = debugDir + "/" + name
+			return st.Path(name), st.WriteFile(name, data)
+		})
+	}
+	if goldenShapes != nil {
+		c.WatchShapes(goldenShapes, shapesMode == "record")
+	}
+	c.OnSchemaDrift(func(d api.SchemaDrift) {
+		if len(d.Changes) > 0 {
+			ch := d.Changes[0]
+			fmt.Fprintf(os.Stderr, "nlm: warning: %s response changed shape: %s was %s, now %s", d.RPC, ch.Path, ch.Old, ch.New)
+			if len(d.Changes) > 1 {
+				fmt.Fprintf(os.Stderr, " (and %d more)", len(d.Changes)-1)
+			}
+			fmt.Fprintln(os.Stderr)
+		} else if d.Recovered {
+			fmt.Fprintf(os.Stderr, "nlm: warning: %s response did not match the expected format; continuing with best-effort results\n", d.Method)
+		} else {
+			fmt.Fprintf(os.Stderr, "nlm: warning: %s response did not match the expected format\n", d.Method)
+		}
+		if d.Payload != "" {
+			fmt.Fprintf(os.Stderr, "nlm: raw payload saved to %s\n", d.Payload)
+		}
+		if stErr != nil {
+			return
+		}
+		line, err := json.Marshal(d)
+		if err != nil {
+			return
+		}
+		unlock, err := st.Lock(driftLog)
+		if err != nil {
+			return
+		}
+		defer unlock()
+		data, err := st.ReadFile(driftLog)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return
+		}
+		st.WriteFile(driftLog, append(append(data, line...), '\n'))
+	})
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/batchexecute"
+	"github.com/tmc/nlm/internal/config"
+	"github.com/tmc/nlm/internal/daemon"
+	"github.com/tmc/nlm/internal/ingest"
+)
+
+// editorSession is the daemon's state for editor plugins: an API client,
+// the credentials it uses and the notebook editors act on by default.
+type editorSession struct {
+	mu      sync.Mutex
+	client  *api.Client
+	token   string
+	cookies string
+	active  string
+}
+
+func newEditorSession() *editorSession {
+	return &editorSession{
+		client:  newEditorClient(authToken, cookies),
+		token:   authToken,
+		cookies: cookies,
+	}
+}
+
+// newEditorClient returns a client for the session.
+func newEditorClient(token, cookies string) *api.Client {
+	c := api.New(token, cookies, editorOptions()...)
+	trackChanges(c)
+	allowExperimental(c)
+	return c
+}
+
+// editorOptions are the options of the session's client.
+func editorOptions() []batchexecute.Option {
+	opts := append(clientHeaderOptions(), batchexecute.WithRedaction(!noRedact))
+	return append(opts, readOnlyOptions()...)
+}
+
+// call runs fn with the session's client. If the credentials have expired,
+// it reloads those saved by nlm auth and tries once more, so the daemon
+// keeps working after the user reauthenticates.
+func (s *editorSession) call(fn func(c *api.Client) error) error {
+	s.mu.Lock()
+	c := s.client
+	s.mu.Unlock()
+	err := fn(c)
+	if !errors.Is(err, batchexecute.ErrUnauthorized) {
+		return err
+	}
+	c, ok := s.reload(c)
+	if !ok {
+		return fmt.Errorf("%w; run nlm auth", err)
+	}
+	return fn(c)
+}
+
+// reload replaces the session's client, if it is still stale, with one
+// using the credentials saved by nlm auth. It reports false if there are
+// no new credentials. Concurrent requests share one reloaded client.
+func (s *editorSession) reload(stale *api.Client) (*api.Client, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client != stale {
+		return s.client, true
+	}
+	env := readStoredEnv()
+	token, cookies := env[config.EnvAuthToken], env[config.EnvCookies]
+	if token == "" || token == s.token && cookies == s.cookies {
+		return nil, false
+	}
+	s.client = newEditorClient(token, cookies)
+	s.token, s.cookies = token, cookies
+	return s.client, true
+}
+
+// notebook returns id, or the active notebook if id is empty.
+func (s *editorSession) notebook(id string) (string, error) {
+	if id != "" {
+		return id, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active == "" {
+		return "", &daemon.Error{Code: daemon.CodeNoNotebook, Message: "no notebook given and none active; send use first"}
+	}
+	return s.active, nil
+}
+
+// editorNotebook is a notebook as reported to editors.
+type editorNotebook struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Emoji   string `json:"emoji,omitempty"`
+	Sources int    `json:"sources"`
+	Active  bool   `json:"active,omitempty"`
+}
+
+// methods returns the editor API.
+func (s *editorSession) methods() map[string]daemon.MethodFunc {
+	return map[string]daemon.MethodFunc{
+		"ping": func(context.Context, json.RawMessage) (any, error) {
+			return map[string]string{"version": buildVersion()}, nil
+		},
+		"list":          s.list,
+		"use":           s.use,
+		"active":        s.activeNotebook,
+		"add-selection": s.addSelection,
+	}
+}
+
+func (s *editorSession) list(context.Context, json.RawMessage) (any, error) {
+	var notebooks []*api.Notebook
+	err := s.call(func(c *api.Client) (err error) {
+		notebooks, err = c.ListRecentlyViewedProjects()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	active := s.active
+	s.mu.Unlock()
+	out := []editorNotebook{}
+	for _, nb := range notebooks {
+		out = append(out, editorNotebook{
+			ID:      nb.ProjectId,
+			Title:   strings.TrimSpace(nb.Title),
+			Emoji:   strings.TrimSpace(nb.Emoji),
+			Sources: len(nb.Sources),
+			Active:  nb.ProjectId == active,
+		})
+	}
+	return out, nil
+}
+
+func (s *editorSession) use(_ context.Context, params json.RawMessage) (any, error) {
+	var p struct {
+		Notebook string `json:"notebook"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil || p.Notebook == "" {
+		return nil, &daemon.Error{Code: daemon.CodeBadRequest, Message: `use needs {"notebook": "<id>"}`}
+	}
+	var nb *api.Notebook
+	err := s.call(func(c *api.Client) (err error) {
+		nb, err = c.GetProject(p.Notebook)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.active = p.Notebook
+	s.mu.Unlock()
+	return editorNotebook{ID: p.Notebook, Title: strings.TrimSpace(nb.Title), Emoji: strings.TrimSpace(nb.Emoji), Sources: len(nb.Sources), Active: true}, nil
+}
+
+func (s *editorSession) activeNotebook(context.Context, json.RawMessage) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return map[string]string{"notebook": s.active}, nil
+}
+
+// addSelection adds highlighted text as a source. The .nlm.yaml ingest
+// filters nearest the edited file apply, as they would for nlm add.
+func (s *editorSession) addSelection(_ context.Context, params json.RawMessage) (any, error) {
+	var p struct {
+		Notebook string `json:"notebook"`
+		Text     string `json:"text"`
+		Title    string `json:"title"`
+		File     string `json:"file"`
+		Line     int    `json:"line"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil || strings.TrimSpace(p.Text) == "" {
+		return nil, &daemon.Error{Code: daemon.CodeBadRequest, Message: `add-selection needs {"text": "..."}`}
+	}
+	id, err := s.notebook(p.Notebook)
+	if err != nil {
+		return nil, err
+	}
+	title := p.Title
+	switch {
+	case title != "":
+	case p.File != "" && p.Line > 0:
+		title = fmt.Sprintf("%s:%d", filepath.Base(p.File), p.Line)
+	case p.File != "":
+		title = filepath.Base(p.File)
+	default:
+		title = "Selection " + time.Now().Format("2006-01-02 15:04")
+	}
+	text, err := filterSelection(p.File, p.Text)
+	if err != nil {
+		return nil, err
+	}
+	var sourceID string
+	err = s.call(func(c *api.Client) (err error) {
+		sourceID, err = c.AddSourceFromText(id, text, title)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"notebook": id, "source": sourceID, "title": title}, nil
+}
+
+// filterSelection applies the ingest filters of the .nlm.yaml nearest
+// file, or of NLM_CONFIG.
+func filterSelection(file, text string) (string, error) {
+	var cfg *config.Config
+	var err error
+	if bootstrap().ConfigFile == "" && file != "" && filepath.IsAbs(file) {
+		var path string
+		if path, err = config.Find(filepath.Dir(file)); err == nil && path != "" {
+			cfg, err = config.Load(path)
+		} else if err == nil {
+			cfg = &config.Config{}
+		}
+	} else {
+		cfg, err = config.LoadDefault()
+	}
+	if err != nil {
+		return "", err
+	}
+	p, err := ingest.New(cfg.Ingest)
+	if err != nil {
+		return "", err
+	}
+	out, _ := p.Apply(text)
+	return out, nil
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tmc/nlm/internal/batchexecute"
+	"github.com/tmc/nlm/internal/config"
+	"github.com/tmc/nlm/internal/rpc"
+)
+
+// probeTimeout bounds each request of nlm endpoints.
+const probeTimeout = 10 * time.Second
+
+var (
+	mitmProxy  string
+	keyLogFile string
+)
+
+func init() {
+	flag.StringVar(&mitmProxy, "mitm", "", "send requests through the mitmproxy listening on this `host:port`, trusting its CA, to capture RPC traces")
+	flag.StringVar(&keyLogFile, "keylog", "", "append TLS session keys to this `file`, to decrypt a packet capture (SSLKEYLOGFILE is not read)")
+}
+
+// currentEndpoint returns the endpoint of the current browser profile,
+// with -mitm applied.
+func currentEndpoint(g *config.Global) (config.Endpoint, error) {
+	if g == nil {
+		g = &config.Global{}
+	}
+	e := g.EndpointFor(browserProfile())
+	if mitmProxy == "" {
+		return e, nil
+	}
+	dir := os.Getenv("MITMPROXY_CONFDIR")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return e, err
+		}
+		dir = filepath.Join(home, ".mitmproxy")
+	}
+	e.Proxy = "http://" + mitmProxy
+	e.CA = filepath.Join(dir, "mitmproxy-ca-cert.pem")
+	return e, nil
+}
+
+// endpointOptions send requests to the endpoint configured for the current
+// browser profile. A configured proxy or CA, or -keylog, replaces the
+// daemon, whose connections nlm does not control.
+func endpointOptions() ([]batchexecute.Option, error) {
+	g, err := loadGlobalConfig()
+	if err != nil {
+		// applyGlobalConfig has reported the error.
+		g = nil
+	}
+	e, err := currentEndpoint(g)
+	if err != nil {
+		return nil, err
+	}
+	var opts []batchexecute.Option
+	if e.Host != "" || e.Path != "" {
+		opts = append(opts, batchexecute.WithEndpoint(e.Host, e.Path))
+	}
+	if e.Proxy != "" || e.CA != "" || keyLogFile != "" {
+		t, err := endpointTransport(e)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, batchexecute.WithHTTPClient(&http.Client{Transport: t}))
+	}
+	if mitmProxy != "" {
+		fmt.Fprintf(os.Stderr, "Sending requests through mitmproxy at %s. Captured flows hold your Google cookies; share traces only after removing them.\n", mitmProxy)
+	}
+	if keyLogFile != "" {
+		fmt.Fprintf(os.Stderr, "Writing TLS session keys to %s. With them, captured traffic, including your Google cookies, can be decrypted; delete the file when done.\n", keyLogFile)
+	}
+	return opts, nil
+}
+
+// endpointTransport returns the default transport for e: requests go
+// through e.Proxy if it is set, or else the proxy of HTTPS_PROXY and
+// NO_PROXY, and the certificates of e.CA are trusted besides the system
+// ones. With -keylog, TLS keys are appended to that file, so that captured
+// traffic can be decrypted, as by Wireshark. SSLKEYLOGFILE is not read: a
+// variable inherited from the environment must not leak the session keys
+// of every command unnoticed.
+func endpointTransport(e config.Endpoint) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if e.Proxy != "" {
+		// The configuration is validated when it is loaded.
+		u, _ := url.Parse(e.Proxy)
+		t.Proxy = http.ProxyURL(u)
+	}
+	tc := &tls.Config{}
+	if e.CA != "" {
+		pem, err := os.ReadFile(e.CA)
+		if err != nil {
+			return nil, fmt.Errorf("endpoint ca: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("endpoint ca: no PEM certificates in %s", e.CA)
+		}
+		tc.RootCAs = pool
+	}
+	if keyLogFile != "" {
+		f, err := os.OpenFile(keyLogFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("keylog: %w", err)
+		}
+		tc.KeyLogWriter = f
+	}
+	t.TLSClientConfig = tc
+	return t, nil
+}
+
+// endpointVariant is an endpoint nlm endpoints tries.
+type endpointVariant struct {
+	name string
+	config.Endpoint
+}
+
+// probeResult is the outcome of trying one endpoint variant.
+type probeResult struct {
+	Variant      string   `json:"variant"`
+	URL          string   `json:"url"`
+	Proxy        string   `json:"proxy,omitempty"`
+	Addrs        []string `json:"addrs,omitempty"`
+	DNSError     string   `json:"dns_error,omitempty"`
+	Status       int      `json:"status,omitempty"`
+	Milliseconds int64    `json:"ms"`
+	Error        string   `json:"error,omitempty"`
+	Reachable    bool     `json:"reachable"`
+}
+
+// runEndpoints implements nlm endpoints: it tries the endpoint configured
+// for the current browser profile, the same endpoint without its proxy,
+// and the default one, and reports which can be reached. Any HTTP
+// response counts, as the requests carry no credentials.
+func runEndpoints() error {
+	g, err := loadGlobalConfig()
+	if err != nil {
+		return fmt.Errorf("endpoints: %w", err)
+	}
+	e, err := currentEndpoint(g)
+	if err != nil {
+		return fmt.Errorf("endpoints: %w", err)
+	}
+	var results []probeResult
+	for _, v := range endpointVariants(e) {
+		results = append(results, probeEndpoint(v))
+	}
+	var reachable []string
+	for _, r := range results {
+		if r.Reachable {
+			reachable = append(reachable, r.Variant)
+		}
+	}
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			return fmt.Errorf("endpoints: %w", err)
+		}
+	} else {
+		t := newTable("VARIANT", "URL", "PROXY", "DNS", "STATUS", "TIME")
+		for _, r := range results {
+			dns := strings.Join(r.Addrs, " ")
+			if r.DNSError != "" {
+				dns = "failed"
+			}
+			status := strconv.Itoa(r.Status)
+			if r.Error != "" {
+				status = r.Error
+			}
+			proxy := r.Proxy
+			if proxy == "" {
+				proxy = "none"
+			}
+			t.Append(r.Variant, r.URL, proxy, dns, status, (time.Duration(r.Milliseconds) * time.Millisecond).String())
+		}
+		if err := t.Render(os.Stdout); err != nil {
+			return err
+		}
+	}
+	if len(reachable) == 0 {
+		return errors.New("endpoints: no endpoint could be reached; set endpoint.host or endpoint.proxy in the global configuration")
+	}
+	summarize("endpoints: reachable: %s", strings.Join(reachable, ", "))
+	return nil
+}
+
+// endpointVariants returns the configured endpoint, without its proxy if
+// it has one, and the default endpoint, leaving out those with the same
+// URL and proxy as an earlier one.
+func endpointVariants(e config.Endpoint) []endpointVariant {
+	def := rpc.New("", "").Config
+	vs := []endpointVariant{{"configured", e}}
+	if e.Proxy != "" {
+		direct := e
+		direct.Proxy = ""
+		vs = append(vs, endpointVariant{"configured without proxy", direct})
+	}
+	vs = append(vs, endpointVariant{"default", config.Endpoint{}})
+	seen := make(map[string]bool)
+	var out []endpointVariant
+	for _, v := range vs {
+		if v.Host == "" {
+			v.Host = def.Host
+		}
+		if v.Path == "" {
+			v.Path = def.URLPath()
+		}
+		key := v.Host + v.Path + " " + v.Proxy
+		if !seen[key] {
+			seen[key] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// probeEndpoint looks up the host of v and sends it a request.
+func probeEndpoint(v endpointVariant) probeResult {
+	u := "https://" + v.Host + v.Path
+	r := probeResult{Variant: v.name, URL: u}
+
+	host := v.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	// Behind a proxy the proxy resolves the host, so a failed lookup here
+	// is reported but does not make the endpoint unreachable.
+	lookupCtx, cancelLookup := context.WithTimeout(context.Background(), probeTimeout)
+	addrs, err := net.DefaultResolver.LookupHost(lookupCtx, host)
+	cancelLookup()
+	if err != nil {
+		r.DNSError = err.Error()
+	} else {
+		r.Addrs = addrs
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	t, err := endpointTransport(v.Endpoint)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	if p, err := t.Proxy(req); err == nil && p != nil {
+		r.Proxy = p.Redacted()
+	}
+	client := &http.Client{
+		Transport: t,
+		// The first response is enough to know the host answers.
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	r.Milliseconds = time.Since(start).Milliseconds()
+	if err != nil {
+		r.Error = probeError(err)
+		return r
+	}
+	resp.Body.Close()
+	r.Status, r.Reachable = resp.StatusCode, true
+	return r
+}
+
+// probeError shortens the errors of failed probes for the table.
+func probeError(err error) string {
+	var ue *url.Error
+	if errors.As(err, &ue) {
+		err = ue.Err
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timeout"
+	}
+	return err.Error()
+}
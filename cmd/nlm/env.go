@@ -1 +1,99 @@
 package main
+
+import (
+	"flag"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tmc/nlm/internal/config"
+	"github.com/tmc/nlm/internal/validate"
+)
+
+// settings are the settings shared by every command, resolved by
+// applySettings.
+var settings config.Settings
+
+// requestTimeout bounds each request to NotebookLM.
+var requestTimeout time.Duration
+
+func init() {
+	flag.DurationVar(&requestTimeout, "timeout", 0, "give up on a request to NotebookLM after this long, such as 2m; 0 means no limit (or set NLM_TIMEOUT)")
+}
+
+// applySettings resolves the profile, default notebook, output format,
+// timeout, debug output, credentials and budget from the flags given, the
+// NLM_* environment variables and the global configuration g, in that
+// order, and applies them to the flags. The other NLM_* variables are
+// read through settings too, except the bootstrap ones of
+// config.ResolveBootstrap.
+func applySettings(g *config.Global) error {
+	given := make(map[string]string)
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "json":
+			// -json is a shorthand for -output json, which -output overrides.
+			if jsonOutput {
+				given[config.SettingOutput] = "json"
+			} else {
+				given[config.SettingOutput] = "table"
+			}
+		case "output", "timeout", "debug", "auth", "cookies", "budget":
+			given[f.Name] = f.Value.String()
+		}
+	})
+	s, err := config.Resolve(given, os.Getenv, g)
+	if err != nil {
+		return err
+	}
+	settings = s
+	debug = s.Debug
+	requestTimeout = s.Timeout
+	budgetSpec = s.Budget
+	// The fake sets its own credentials, which only flags replace.
+	if authToken == "" {
+		authToken = s.AuthToken
+	}
+	if cookies == "" {
+		cookies = s.Cookies
+	}
+	if s.Source[config.SettingOutput] != "flag" {
+		outputFormat = s.Output
+		jsonOutput = s.Output == "json"
+	}
+	return nil
+}
+
+// withDefaultNotebook puts NLM_NOTEBOOK in place of the notebook argument
+// of a command that takes one but was given none: it has no arguments, or
+// fewer than it takes and a first that is not meant as an ID. A first
+// argument that looks like an ID, even an invalid one, or a full set of
+// arguments means the notebook was given, and validation reports it if it
+// is wrong, rather than it being taken as, say, a source to add. Source
+// and note IDs look like notebook IDs, so commands given one first still
+// need the notebook. rm never uses the default, so that a forgotten
+// argument does not delete a notebook.
+func withDefaultNotebook(cmd string, args []string) []string {
+	if settings.Notebook == "" || cmd == "rm" {
+		return args
+	}
+	spec, ok := argumentSpecs[cmd]
+	sub, rest := []string(nil), args
+	if len(args) > 0 {
+		if s, ok2 := argumentSpecs[cmd+" "+args[0]]; ok2 {
+			spec, ok, sub, rest = s, true, args[:1], args[1:]
+		}
+	}
+	if !ok || spec[0] != "notebook" {
+		return args
+	}
+	if len(rest) > 0 && validate.LooksLikeID(rest[0]) {
+		return args
+	}
+	if last := spec[len(spec)-1]; !strings.HasSuffix(last, "...") && len(rest) >= len(spec) {
+		return args
+	}
+	out := append([]string(nil), sub...)
+	out = append(out, settings.Notebook)
+	return append(out, rest...)
+}
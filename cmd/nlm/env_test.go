@@ -0,0 +1,33 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithDefaultNotebook(t *testing.T) {
+	defer func(old string) { settings.Notebook = old }(settings.Notebook)
+	const nb = "0f9e5c3a-8d2b-4c1e-9a7f-3b6d2e1c0a94"
+	settings.Notebook = nb
+	other := "fec1780c-5a14-4f07-8ee6-f8c3ee2930fa"
+	for _, tt := range []struct {
+		cmd  string
+		args []string
+		want []string
+	}{
+		{"sources", nil, []string{nb}},
+		{"add", []string{"doc.txt"}, []string{nb, "doc.txt"}},
+		{"add", []string{other, "doc.txt"}, []string{other, "doc.txt"}},
+		// A mistyped notebook is left for validation to report.
+		{"add", []string{"0F9E5C3A-8D2B-4C1E-9A7F-3B6D2E1C0A94", "doc.txt"}, []string{"0F9E5C3A-8D2B-4C1E-9A7F-3B6D2E1C0A94", "doc.txt"}},
+		{"new-note", []string{"Title"}, []string{nb, "Title"}},
+		{"new-note", []string{"My Notebook", "Title"}, []string{"My Notebook", "Title"}},
+		{"sources", []string{"enable", other}, []string{"enable", other}},
+		{"sources", []string{"check-links"}, []string{"check-links", nb}},
+		{"rm", nil, nil},
+	} {
+		if got := withDefaultNotebook(tt.cmd, tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("withDefaultNotebook(%s, %q) = %q, want %q", tt.cmd, tt.args, got, tt.want)
+		}
+	}
+}
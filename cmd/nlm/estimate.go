@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/tmc/nlm/internal/estimate"
+)
+
+var estimateMaxSources int
+
+func init() {
+	flag.IntVar(&estimateMaxSources, "max-sources", estimate.DefaultLimits.SourcesPerNotebook, "with estimate or crawl, sources allowed per notebook (300 for NotebookLM Plus)")
+}
+
+// estimateCorpus reports words and approximate tokens for the files under
+// each path and whether they fit NotebookLM's limits, without uploading
+// anything.
+func estimateCorpus(paths []string) error {
+	limits := estimate.DefaultLimits
+	limits.SourcesPerNotebook = estimateMaxSources
+
+	var files []string
+	for _, p := range paths {
+		r, err := estimate.Dir(p, limits)
+		if err != nil {
+			return fmt.Errorf("estimate: %w", err)
+		}
+		for _, f := range r.Files {
+			files = append(files, f.Path)
+		}
+	}
+	r, err := estimate.Files(files, limits)
+	if err != nil {
+		return fmt.Errorf("estimate: %w", err)
+	}
+
+	t := newTable("FILE", "SIZE", "WORDS", "TOKENS", "STATUS")
+	var unknown int
+	for _, f := range r.Files {
+		words, tokens := fmt.Sprint(f.Words), fmt.Sprint(f.Tokens)
+		if !f.Known {
+			words, tokens = "?", "?"
+			unknown++
+		}
+		status := "ok"
+		if len(f.Problems) > 0 {
+			status = strings.Join(f.Problems, "; ")
+		}
+		t.Append(f.Path, formatBytes(f.Bytes), words, tokens, status)
+	}
+	if err := t.Render(os.Stdout); err != nil {
+		return err
+	}
+	fmt.Printf("\n%d files, %s, %d words, ~%d tokens\n", len(r.Files), formatBytes(r.Bytes), r.Total.Words, r.Total.Tokens)
+	if unknown > 0 {
+		fmt.Printf("%d files could not be read as text; only their size was checked (install pdftotext to count PDFs)\n", unknown)
+	}
+	for _, p := range r.Problems {
+		fmt.Println(p)
+	}
+	if !r.Fits() {
+		return fmt.Errorf("estimate: corpus does not fit NotebookLM limits")
+	}
+	return nil
+}
+
+// formatBytes formats n with a binary unit.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
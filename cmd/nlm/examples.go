@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/cmddoc"
+)
+
+// printExamples implements nlm examples [command]. It prints the examples
+// of each command, or its shortest invocation, with the IDs of the most
+// recently viewed notebook and its first source and note filled in.
+// Fetching them exercises the notebook, source and note listings, so a
+// failure here points at those APIs.
+func printExamples(c *api.Client, args []string) error {
+	cmds := cmddoc.Commands
+	if len(args) == 1 {
+		if cmds = cmddoc.Lookup(args[0]); len(cmds) == 0 {
+			return fmt.Errorf("examples: unknown command %q", args[0])
+		}
+	}
+	mc := loadMetadata()
+	nbs, err := mc.notebooks(c, listTTL)
+	if err != nil {
+		return fmt.Errorf("examples: list notebooks: %w", err)
+	}
+	if len(nbs) == 0 {
+		return errors.New("examples: no notebooks to fill in; create one with nlm create <title>")
+	}
+	id := nbs[0].GetProjectId()
+	nb, err := mc.project(c, id)
+	if err != nil {
+		return fmt.Errorf("examples: list sources: %w", err)
+	}
+	notes, err := c.GetNotes(id)
+	if err != nil {
+		return fmt.Errorf("examples: list notes: %w", err)
+	}
+	title := strings.Join(strings.Fields(nb.GetTitle()), " ")
+	fmt.Fprintf(os.Stderr, "Using notebook %q (%d notebooks; %d sources and %d notes in this one)\n\n", title, len(nbs), len(nb.GetSources()), len(notes))
+
+	values := map[string]string{"<id>": id}
+	if srcs := nb.GetSources(); len(srcs) > 0 {
+		values["<source-id>"] = srcs[0].GetSourceId().GetSourceId()
+	}
+	if len(notes) > 0 {
+		values["<note-id>"] = notes[0].GetSourceId().GetSourceId()
+	}
+	skipped := 0
+	for _, cmd := range cmds {
+		examples := cmd.Examples
+		if len(examples) == 0 {
+			examples = []cmddoc.Example{{Comment: cmd.Summary, Command: cmd.Invocation()}}
+		}
+		for _, e := range examples {
+			line, ok := cmddoc.Fill(e.Command, values)
+			if !ok {
+				skipped++
+				continue
+			}
+			fmt.Printf("# %s\n%s\n\n", e.Comment, line)
+		}
+	}
+	if skipped > 0 {
+		fmt.Fprintf(os.Stderr, "%d examples need arguments only you know; see nlm help <command>.\n", skipped)
+	}
+	return nil
+}
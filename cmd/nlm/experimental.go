@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/tmc/nlm/internal/api"
+)
+
+// experimental allows the calls whose request layout is a guess that has
+// not been checked against the web client; see api.ErrUnverified.
+var experimental bool
+
+func init() {
+	flag.BoolVar(&experimental, "experimental", false, "allow commands whose NotebookLM calls are unverified and could grant or revoke the wrong access: share bulk and share enforce -apply")
+}
+
+// allowExperimental applies -experimental to c.
+func allowExperimental(c *api.Client) {
+	c.AllowUnverified(experimental)
+}
+
+// requireExperimental fails cmd unless -experimental is given, before it
+// does anything.
+func requireExperimental(cmd string) error {
+	if experimental {
+		return nil
+	}
+	return fmt.Errorf("%s: its calls to NotebookLM are unverified and could grant or revoke the wrong access; rerun with -experimental to use it anyway", cmd)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tmc/nlm/internal/errinfo"
+)
+
+// explainErrors adds a plain-language explanation to the error block.
+var explainErrors bool
+
+func init() {
+	flag.BoolVar(&explainErrors, "explain", false, "on failure, explain what the error code usually means")
+}
+
+// printErrorInfo writes the classification of err after its message: a
+// block of key: value lines, or with -json a single JSON object on one
+// line, that scripts can match on by code. The message itself is printed
+// as text either way, since not every command's output follows -json.
+func printErrorInfo(w io.Writer, err error) {
+	info := errinfo.Classify(err)
+	if !explainErrors {
+		info.Explanation = ""
+	}
+	if jsonOutput {
+		json.NewEncoder(w).Encode(struct {
+			Error string `json:"error"`
+			errinfo.Info
+		}{redactString(err.Error()), info})
+		return
+	}
+	fmt.Fprintf(w, "  code: %s\n", info.Code)
+	if info.RPCID != "" {
+		fmt.Fprintf(w, "  rpcid: %s\n", info.RPCID)
+	}
+	if info.HTTPStatus != 0 {
+		fmt.Fprintf(w, "  http_status: %d\n", info.HTTPStatus)
+	}
+	if info.RPCCode != 0 {
+		fmt.Fprintf(w, "  rpc_code: %d\n", info.RPCCode)
+	}
+	if info.RetryAfter != "" {
+		fmt.Fprintf(w, "  retry_after: %s\n", info.RetryAfter)
+	}
+	if info.URL != "" {
+		fmt.Fprintf(w, "  url: %s\n", info.URL)
+	}
+	fmt.Fprintf(w, "  suggestion: %s\n", info.Suggestion)
+	if info.Explanation != "" {
+		fmt.Fprintf(w, "\n%s\n", wrapText(info.Explanation, 72))
+	}
+}
+
+// wrapText breaks s into lines of at most width columns.
+func wrapText(s string, width int) string {
+	var b strings.Builder
+	n := 0
+	for _, word := range strings.Fields(s) {
+		switch {
+		case n == 0:
+		case n+1+len(word) > width:
+			b.WriteByte('\n')
+			n = 0
+		default:
+			b.WriteByte(' ')
+			n++
+		}
+		b.WriteString(word)
+		n += len(word)
+	}
+	return b.String()
+}
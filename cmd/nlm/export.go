@@ -0,0 +1,207 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/export"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Export flags
+var (
+	outputPath  string
+	exportForce bool
+)
+
+func init() {
+	flag.StringVar(&outputPath, "o", "", "output `path` for commands that write files")
+	flag.BoolVar(&exportForce, "force", false, "redo items an earlier run already recorded as complete")
+}
+
+// exportNotebook writes a notebook's metadata, sources, notes, guide and audio
+// overview into dir. Progress is recorded in dir/manifest.json after every
+// item, so rerunning the same command after an interruption only fetches
+// what is missing.
+func exportNotebook(c *api.Client, notebookID, dir string) error {
+	if dir == "" {
+		dir = notebookID
+	}
+	m, err := export.Open(dir, notebookID)
+	if err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+	if len(m.Items) > 0 && !exportForce {
+		fmt.Fprintf(os.Stderr, "Resuming export into %s (%d items already done)\n", dir, len(m.Items))
+	}
+	skipped, err := exportItems(c, m, notebookID)
+	if err != nil {
+		return err
+	}
+	if err := m.Finish(); err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Exported %q to %s (%d items, %d already present)\n", m.Title, dir, len(m.Items), skipped)
+	return nil
+}
+
+// exportItems writes everything in the notebook that m does not already
+// hold and returns how many items were skipped as already present. Sources,
+// notes and the guide that m holds from a finished export are fetched
+// again and rewritten if they changed. Items that have since been removed
+// from the notebook are pruned from m.
+func exportItems(c *api.Client, m *export.Manifest, notebookID string) (int, error) {
+	m.Begin()
+	p, err := c.GetProject(notebookID)
+	if err != nil {
+		return 0, fmt.Errorf("export: %w", err)
+	}
+	m.Title = strings.TrimSpace(p.Title)
+	nb, err := protojson.MarshalOptions{Multiline: true}.Marshal(p)
+	if err != nil {
+		return 0, fmt.Errorf("export: encode notebook: %w", err)
+	}
+	if _, err := m.Write(export.KindNotebook, notebookID, m.Title, "notebook.json", nb); err != nil {
+		return 0, fmt.Errorf("export: %w", err)
+	}
+
+	notes, err := c.GetNotesRaw(notebookID)
+	if err != nil {
+		return 0, fmt.Errorf("export: %w", err)
+	}
+
+	total := len(p.Sources) + len(notes) + 2
+	var n, skipped int
+	present := map[string]bool{export.KindNotebook + "/" + notebookID: true}
+	step := func(kind, title string) {
+		reportStep("export", kind+": "+title, n, total)
+		n++
+		fmt.Fprintf(os.Stderr, "[%d/%d] %s: %s\n", n, total, kind, title)
+	}
+
+	for _, src := range p.Sources {
+		id := src.GetSourceId().GetSourceId()
+		title := strings.TrimSpace(src.GetTitle())
+		present[export.KindSource+"/"+id] = true
+		if !exportForce && m.Current(export.KindSource, id) {
+			n++
+			skipped++
+			continue
+		}
+		raw, err := c.LoadSourceRaw(id)
+		if err != nil {
+			return 0, fmt.Errorf("export source %s: %w", id, err)
+		}
+		// A source exported earlier may have been refreshed since.
+		if !exportForce && m.Unchanged(export.KindSource, id, raw) {
+			n++
+			skipped++
+			continue
+		}
+		step(export.KindSource, title)
+		rel := filepath.Join("sources", outputFilename(title+".json"))
+		if _, err := m.Write(export.KindSource, id, title, rel, raw); err != nil {
+			return 0, fmt.Errorf("export source %s: %w", id, err)
+		}
+	}
+
+	for _, raw := range notes {
+		id, title, _, err := api.NoteContent(raw)
+		if err != nil {
+			return 0, fmt.Errorf("export: %w", err)
+		}
+		title = strings.TrimSpace(title)
+		present[export.KindNote+"/"+id] = true
+		// Notes are already fetched, so compare contents to pick up edits.
+		if !exportForce && m.Unchanged(export.KindNote, id, raw) {
+			n++
+			skipped++
+			continue
+		}
+		step(export.KindNote, title)
+		rel := filepath.Join("notes", outputFilename(title+".json"))
+		if _, err := m.Write(export.KindNote, id, title, rel, raw); err != nil {
+			return 0, fmt.Errorf("export note %s: %w", id, err)
+		}
+	}
+
+	if !exportForce && m.Done(export.KindAudio, notebookID) {
+		skipped++
+		present[export.KindAudio+"/"+notebookID] = true
+	} else {
+		step(export.KindAudio, "audio overview")
+		if err := exportAudio(c, m, notebookID); err != nil {
+			return 0, err
+		}
+		_, ok := m.Lookup(export.KindAudio, notebookID)
+		present[export.KindAudio+"/"+notebookID] = ok
+	}
+
+	if !exportForce && m.Current(export.KindGuide, notebookID) {
+		skipped++
+		present[export.KindGuide+"/"+notebookID] = true
+	} else {
+		written, err := exportGuide(c, m, notebookID, func() { step(export.KindGuide, "notebook guide") })
+		if err != nil {
+			return 0, err
+		}
+		if !written {
+			skipped++
+		}
+		_, ok := m.Lookup(export.KindGuide, notebookID)
+		present[export.KindGuide+"/"+notebookID] = ok
+	}
+
+	if _, err := m.Prune(func(it export.Item) bool { return present[it.Kind+"/"+it.ID] }); err != nil {
+		return 0, fmt.Errorf("export: %w", err)
+	}
+	reportDone("export", total)
+	return skipped, nil
+}
+
+// exportAudio saves the notebook's audio overview, if one is ready.
+func exportAudio(c *api.Client, m *export.Manifest, notebookID string) error {
+	audio, err := c.GetAudioOverview(notebookID)
+	if err != nil {
+		return fmt.Errorf("export audio: %w", err)
+	}
+	if !audio.IsReady || audio.AudioData == "" {
+		return nil
+	}
+	data, err := api.AudioReader(audio)
+	if err != nil {
+		return fmt.Errorf("export audio: %w", err)
+	}
+	title := audio.Title
+	if title == "" {
+		title = "audio_overview_" + audio.AudioId
+	}
+	rel := filepath.Join("audio", outputFilename(title+".wav"))
+	if _, err := m.WriteFrom(export.KindAudio, notebookID, title, rel, data); err != nil {
+		return fmt.Errorf("export audio: %w", err)
+	}
+	return nil
+}
+
+// exportGuide saves the notebook guide, if the notebook has one, calling
+// step before writing it. It reports whether the guide was written: an
+// unchanged guide from an earlier export is kept as it is.
+func exportGuide(c *api.Client, m *export.Manifest, notebookID string, step func()) (bool, error) {
+	guide, err := c.GenerateNotebookGuide(notebookID)
+	if err != nil {
+		return false, fmt.Errorf("export guide: %w", err)
+	}
+	data := []byte(guide.GetContent())
+	if len(data) == 0 || (!exportForce && m.Unchanged(export.KindGuide, notebookID, data)) {
+		return false, nil
+	}
+	step()
+	if _, err := m.Write(export.KindGuide, notebookID, "Notebook guide", "guide.md", data); err != nil {
+		return false, fmt.Errorf("export guide: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/tmc/nlm/internal/batchexecute"
+	"github.com/tmc/nlm/internal/cleanup"
+	"github.com/tmc/nlm/internal/config"
+	"github.com/tmc/nlm/internal/errinfo"
+	"github.com/tmc/nlm/internal/fake"
+)
+
+const (
+	// fakeModeEnv names the scenario of the fake NotebookLM API to use
+	// instead of the real one.
+	fakeModeEnv = config.EnvMode
+	// fakeStateFile holds the notebooks of the fake API in the state
+	// directory.
+	fakeStateFile = "fake.json"
+)
+
+// beginFake prepares a run against the fake API when NLM_MODE is set.
+// Stored credentials are never used, and the state directory defaults to
+// ~/.nlm/fake, so that the caches and history of the real account are
+// left alone.
+func beginFake() error {
+	mode := bootstrap().Mode
+	if mode == "" {
+		return nil
+	}
+	if _, err := fake.ParseScenario(mode); err != nil {
+		return fmt.Errorf("%s: %w", fakeModeEnv, err)
+	}
+	if bootstrap().Home == "" {
+		dir, err := stateDir()
+		if err != nil {
+			return err
+		}
+		os.Setenv(config.EnvHome, filepath.Join(dir, "fake"))
+	}
+	authToken, cookies = "fake", "fake"
+	return nil
+}
+
+// fakeOptions send requests to the fake API when NLM_MODE is set. They
+// come last, so that they replace the daemon and any configured endpoint.
+func fakeOptions() []batchexecute.Option {
+	b := bootstrap()
+	if b.Mode == "" {
+		return nil
+	}
+	// beginFake has checked the scenario and set NLM_HOME.
+	s := &fake.Server{
+		Scenario: fake.Scenario(b.Mode),
+		Path:     filepath.Join(b.Home, fakeStateFile),
+	}
+	return []batchexecute.Option{batchexecute.WithHTTPClient(&http.Client{Transport: s})}
+}
+
+// runFake implements nlm fake <scenario> [command [arg...]]. With a
+// command, it runs the command against the fake API in a new state
+// directory that is removed afterwards, with this nlm first on its PATH,
+// and exits with its status. Without one, it creates the state directory
+// and prints the shell commands that point later nlm runs at it.
+func runFake(args []string) error {
+	if len(args) == 0 {
+		return errinfo.Usagef("usage: nlm fake <scenario> [command [arg...]]")
+	}
+	sc, err := fake.ParseScenario(args[0])
+	if err != nil {
+		return fmt.Errorf("fake: %w", err)
+	}
+	env := map[string]string{
+		fakeModeEnv:        string(sc),
+		config.EnvNoDaemon: "1",
+	}
+	if len(args) == 1 {
+		home, err := os.MkdirTemp("", "nlm-fake-")
+		if err != nil {
+			return fmt.Errorf("fake: %w", err)
+		}
+		env[config.EnvHome] = home
+		for _, k := range []string{fakeModeEnv, config.EnvHome, config.EnvNoDaemon} {
+			fmt.Printf("export %s=%s\n", k, shellQuote(env[k]))
+		}
+		return nil
+	}
+
+	home, err := cleanup.MkdirTemp("", "nlm-fake-*")
+	if err != nil {
+		return fmt.Errorf("fake: %w", err)
+	}
+	defer cleanup.Remove(home)
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("fake: %w", err)
+	}
+	bin := filepath.Join(home, "bin")
+	if err := os.Mkdir(bin, 0o700); err != nil {
+		return fmt.Errorf("fake: %w", err)
+	}
+	if err := os.Symlink(exe, filepath.Join(bin, "nlm")); err != nil {
+		return fmt.Errorf("fake: %w", err)
+	}
+	env[config.EnvHome] = home
+	env["PATH"] = bin + string(os.PathListSeparator) + os.Getenv("PATH")
+
+	name := args[1]
+	if name == "nlm" {
+		name = exe
+	}
+	c := exec.Command(name, args[2:]...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	c.Env = os.Environ()
+	for k, v := range env {
+		c.Env = append(c.Env, k+"="+v)
+	}
+	err = c.Run()
+	var ee *exec.ExitError
+	if errors.As(err, &ee) && ee.ExitCode() > 0 {
+		cleanup.Run()
+		os.Exit(ee.ExitCode())
+	}
+	if err != nil {
+		return fmt.Errorf("fake: %w", err)
+	}
+	return nil
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/batchexecute"
+)
+
+const (
+	featuresFile = "features.json"
+	// featuresTTL bounds how long probe results are trusted; rollouts
+	// happen without any change on our side.
+	featuresTTL = 24 * time.Hour
+	// featuresVersion is bumped when what the cache records changes, so
+	// that older caches are probed again.
+	featuresVersion = 1
+)
+
+// commandFeatures maps commands to the optional feature they need.
+var commandFeatures = map[string]string{
+	"audio-create":     "audio",
+	"audio-get":        "audio",
+	"audio-rm":         "audio",
+	"audio-share":      "sharing",
+	"generate-guide":   "generation",
+	"generate-outline": "generation",
+	"generate-section": "generation",
+	"pipe":             "generation",
+	"aggregate":        "generation",
+}
+
+// featureCache is the persisted result of probing features for one login
+// session.
+type featureCache struct {
+	Version   int                     `json:"version,omitempty"`
+	Session   string                  `json:"session"`
+	CheckedAt time.Time               `json:"checked_at"`
+	Features  map[string]featureState `json:"features"`
+}
+
+type featureState struct {
+	Available bool   `json:"available"`
+	Known     bool   `json:"known"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// sessionKey identifies the current login without storing the cookies.
+func sessionKey() string {
+	sum := sha256.Sum256([]byte(cookies))
+	return hex.EncodeToString(sum[:8])
+}
+
+// loadFeatures returns cached feature availability, probing the server on
+// the first use in a session, when the cache has expired, or when refresh
+// is set.
+func loadFeatures(c *api.Client, refresh bool) (*featureCache, error) {
+	st, stErr := openState()
+	var fc featureCache
+	if stErr == nil && !refresh {
+		st.Load(featuresFile, &fc)
+		if fc.Version == featuresVersion && fc.Session == sessionKey() && time.Since(fc.CheckedAt) < featuresTTL {
+			return &fc, nil
+		}
+	}
+	fc = featureCache{
+		Version:   featuresVersion,
+		Session:   sessionKey(),
+		CheckedAt: time.Now().UTC(),
+		Features:  make(map[string]featureState),
+	}
+	for name, s := range c.ProbeFeatures("") {
+		if errors.Is(s.Err, batchexecute.ErrUnauthorized) {
+			return nil, s.Err
+		}
+		fs := featureState{Available: s.Available, Known: s.Known}
+		if s.Err != nil {
+			fs.Reason = s.Err.Error()
+		}
+		fc.Features[name] = fs
+	}
+	if stErr == nil {
+		st.Save(featuresFile, fc)
+	}
+	return &fc, nil
+}
+
+// requireFeature returns an *api.UnavailableError if cmd needs a feature
+// the account is known not to have.
+func requireFeature(c *api.Client, cmd string) error {
+	name, ok := commandFeatures[cmd]
+	if !ok {
+		return nil
+	}
+	fc, err := loadFeatures(c, false)
+	if err != nil {
+		return err
+	}
+	if s := fc.Features[name]; s.Known && !s.Available {
+		f, _ := api.LookupFeature(name)
+		return &api.UnavailableError{Feature: f}
+	}
+	return nil
+}
+
+// explainUnavailable turns the server's refusal to implement a gated
+// command into an *api.UnavailableError and remembers it, so the next run
+// fails fast. Permission denied is returned as it is and not remembered,
+// as it may only apply to one notebook.
+func explainUnavailable(cmd string, err error) error {
+	name, ok := commandFeatures[cmd]
+	if !ok || !api.NotOffered(err) {
+		return err
+	}
+	var ue *api.UnavailableError
+	if errors.As(err, &ue) {
+		return err
+	}
+	if st, stErr := openState(); stErr == nil {
+		var fc featureCache
+		st.Load(featuresFile, &fc)
+		if fc.Version == featuresVersion && fc.Session == sessionKey() && fc.Features != nil {
+			fc.Features[name] = featureState{Known: true, Reason: err.Error()}
+			st.Save(featuresFile, fc)
+		}
+	}
+	f, _ := api.LookupFeature(name)
+	if debug {
+		fmt.Fprintf(os.Stderr, "nlm: %v\n", err)
+	}
+	return &api.UnavailableError{Feature: f}
+}
+
+// listFeatures probes and prints feature availability.
+func listFeatures(c *api.Client) error {
+	fc, err := loadFeatures(c, true)
+	if err != nil {
+		return err
+	}
+	t := newTable("FEATURE", "STATUS", "DESCRIPTION")
+	for _, f := range api.Features {
+		s := fc.Features[f.Name]
+		status := "unknown"
+		switch {
+		case s.Available:
+			status = "available"
+		case s.Known:
+			status = "not available"
+		}
+		t.Append(f.Name, status, f.Description)
+	}
+	return t.Render(os.Stdout)
+}
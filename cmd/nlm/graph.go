@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	pb "github.com/tmc/nlm/gen/notebooklm/v1alpha1"
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/beprotojson"
+	"github.com/tmc/nlm/internal/citegraph"
+	"github.com/tmc/nlm/internal/export"
+)
+
+// citationGraph writes the graph of notes citing sources to path, as JSON
+// if path ends in .json and DOT otherwise. With no path, DOT is written to
+// stdout. Chat answers saved as notes are answer nodes; chat history that
+// was not saved is left out, as nlm has no call that reads it.
+func citationGraph(c *api.Client, notebookID, path string) error {
+	p, err := c.GetProject(notebookID)
+	if err != nil {
+		return fmt.Errorf("graph: %w", err)
+	}
+	var sources []citegraph.Node
+	for _, src := range p.Sources {
+		sources = append(sources, citegraph.Node{
+			ID:    src.GetSourceId().GetSourceId(),
+			Label: strings.TrimSpace(src.GetTitle()),
+		})
+	}
+	notes, err := c.GetNotesRaw(notebookID)
+	if err != nil {
+		return fmt.Errorf("graph: %w", err)
+	}
+	var docs []citegraph.Document
+	for i, raw := range notes {
+		var note pb.Source
+		if err := beprotojson.Unmarshal(raw, &note); err != nil {
+			return fmt.Errorf("graph: note %d: %w", i, err)
+		}
+		docs = append(docs, citegraph.Document{
+			ID:    note.GetSourceId().GetSourceId(),
+			Kind:  citegraph.KindNote,
+			Title: strings.TrimSpace(note.GetTitle()),
+			Raw:   raw,
+		})
+	}
+	g := citegraph.Build(strings.TrimSpace(p.GetTitle()), sources, docs)
+
+	var buf bytes.Buffer
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = g.WriteJSON(&buf)
+	} else {
+		err = g.WriteDOT(&buf)
+	}
+	if err != nil {
+		return fmt.Errorf("graph: %w", err)
+	}
+	if path == "" {
+		_, err = os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	if err := export.WriteFileAtomic(path, buf.Bytes()); err != nil {
+		return fmt.Errorf("graph: %w", err)
+	}
+	uncited := g.Uncited()
+	answers := 0
+	for _, n := range g.Nodes {
+		if n.Kind == citegraph.KindAnswer {
+			answers++
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s: %d sources, %d notes (%d saved answers), %d citations, %d sources never cited\n",
+		path, len(sources), len(docs), answers, len(g.Edges), len(uncited))
+	return nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tmc/nlm/internal/batchexecute"
+	"github.com/tmc/nlm/internal/config"
+	"github.com/tmc/nlm/internal/errinfo"
+	"github.com/tmc/nlm/internal/rpc"
+)
+
+// browserProfile returns the browser profile nlm signs in with.
+func browserProfile() string {
+	if settings.Profile != "" {
+		return settings.Profile
+	}
+	return config.DefaultProfile
+}
+
+// clientHeaders returns the User-Agent and client hints sent for the
+// current browser profile, and where they come from.
+func clientHeaders(g *config.Global) (map[string]string, string) {
+	h := rpc.DefaultHeaders()
+	if g == nil {
+		return h, "default"
+	}
+	profile := browserProfile()
+	if f, ok := g.Frozen[profile]; ok {
+		// Send exactly what was frozen: a browser that sent no client
+		// hints must not gain the default ones.
+		return f.Headers(), "frozen for profile " + profile
+	}
+	ch := g.Client.Headers()
+	for k, v := range ch {
+		h[k] = v
+	}
+	if len(ch) > 0 {
+		return h, "configured"
+	}
+	return h, "default"
+}
+
+// clientHeaderOptions returns the options that send the configured or
+// frozen User-Agent and client hints instead of the defaults.
+func clientHeaderOptions() []batchexecute.Option {
+	g, err := loadGlobalConfig()
+	if err != nil || g == nil {
+		// applyGlobalConfig has reported the error.
+		return nil
+	}
+	h, _ := clientHeaders(g)
+	for k := range rpc.DefaultHeaders() {
+		if _, ok := h[k]; !ok {
+			h[k] = "" // removes the default
+		}
+	}
+	return []batchexecute.Option{batchexecute.WithHeaders(h)}
+}
+
+// runHeaders implements nlm headers [freeze|unfreeze].
+func runHeaders(args []string) error {
+	g, err := loadGlobalConfig()
+	if err != nil {
+		return fmt.Errorf("headers: %w", err)
+	}
+	if g == nil {
+		g = &config.Global{}
+	}
+	profile := browserProfile()
+	switch {
+	case len(args) == 0:
+		h, source := clientHeaders(g)
+		return printHeaders(h, source)
+	case args[0] == "freeze":
+		h, _ := clientHeaders(g)
+		return freezeHeaders(g, profile, h)
+	case args[0] == "unfreeze":
+		if _, ok := g.Frozen[profile]; !ok {
+			return fmt.Errorf("headers: nothing frozen for profile %s", profile)
+		}
+		delete(g.Frozen, profile)
+		if _, err := saveGlobalConfig(g); err != nil {
+			return fmt.Errorf("headers: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Profile %s now follows the configured or default headers.\n", profile)
+		return nil
+	}
+	return errinfo.Usagef("usage: nlm headers [freeze|unfreeze]")
+}
+
+// freezeHeaders pins h for the browser profile in the global
+// configuration.
+func freezeHeaders(g *config.Global, profile string, h map[string]string) error {
+	ch := config.ClientHeaders{UserAgent: h["user-agent"], Hints: make(map[string]string)}
+	for k, v := range h {
+		if strings.HasPrefix(k, "sec-ch-") {
+			ch.Hints[k] = v
+		}
+	}
+	if g.Frozen == nil {
+		g.Frozen = make(map[string]config.ClientHeaders)
+	}
+	g.Frozen[profile] = ch
+	path, err := saveGlobalConfig(g)
+	if err != nil {
+		return fmt.Errorf("headers: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Froze the client headers of profile %s in %s\n", profile, path)
+	return nil
+}
+
+func printHeaders(h map[string]string, source string) error {
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]interface{}{"source": source, "headers": h})
+	}
+	names := make([]string, 0, len(h))
+	for k := range h {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	t := newTable("HEADER", "VALUE")
+	for _, k := range names {
+		t.Append(k, h[k])
+	}
+	fmt.Fprintf(os.Stderr, "Client headers (%s):\n", source)
+	return t.Render(os.Stdout)
+}
+
+// curlHeaderRe matches the -H options of a curl command, in single or
+// double quotes.
+var curlHeaderRe = regexp.MustCompile(`-H (?:'([^']*)'|"((?:[^"\\]|\\.)*)")`)
+
+// freezeCurlHeaders freezes the User-Agent and client hints of a curl
+// command copied from the browser, so nlm keeps sending what the browser
+// that owns the cookies sent. It does nothing if the command has no
+// User-Agent.
+func freezeCurlHeaders(cmd string) {
+	h := make(map[string]string)
+	for _, m := range curlHeaderRe.FindAllStringSubmatch(cmd, -1) {
+		header := m[1]
+		if m[2] != "" {
+			header = strings.ReplaceAll(m[2], `\"`, `"`)
+		}
+		name, value, ok := strings.Cut(header, ":")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if ok && (name == "user-agent" || strings.HasPrefix(name, "sec-ch-")) {
+			h[name] = strings.TrimSpace(value)
+		}
+	}
+	if h["user-agent"] == "" {
+		return
+	}
+	g, err := loadGlobalConfig()
+	if err == nil && g == nil {
+		g = &config.Global{}
+	}
+	if err == nil {
+		err = freezeHeaders(g, browserProfile(), h)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "nlm: client headers not frozen: %v\n", err)
+	}
+}
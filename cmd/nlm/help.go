@@ -0,0 +1,54 @@
+package main
+
+//go:generate sh -c "go run . help -man > ../../doc/nlm.1"
+
+import (
+	"flag"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/tmc/nlm/internal/cmddoc"
+	"github.com/tmc/nlm/internal/errinfo"
+)
+
+// helpMan makes nlm help write the man page.
+var helpMan bool
+
+func init() {
+	flag.BoolVar(&helpMan, "man", false, "with help, write the nlm(1) man page to stdout")
+}
+
+// runHelp implements nlm help [command|topic] and nlm help -man.
+func runHelp(args []string) error {
+	switch {
+	case helpMan:
+		cmddoc.WriteMan(os.Stdout, manPage())
+		return nil
+	case len(args) == 0:
+		cmddoc.WriteUsage(os.Stdout)
+		return nil
+	case len(args) > 1:
+		return errinfo.Usagef("usage: nlm help [command|topic] | -man")
+	}
+	return cmddoc.WriteHelp(os.Stdout, args[0])
+}
+
+// manPage describes the man page of this build. The date is taken from
+// SOURCE_DATE_EPOCH when set, so that generated pages are reproducible.
+func manPage() cmddoc.Man {
+	date := time.Now()
+	if s, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64); err == nil {
+		date = time.Unix(s, 0)
+	}
+	m := cmddoc.Man{Version: buildVersion(), Date: date.UTC().Format("January 2006")}
+	flag.VisitAll(func(f *flag.Flag) {
+		_, usage := flag.UnquoteUsage(f)
+		def := f.DefValue
+		if f.Name == "auth" || f.Name == "cookies" {
+			def = "" // from the environment; never print it
+		}
+		m.Flags = append(m.Flags, cmddoc.Flag{Name: f.Name, Usage: usage, Default: def})
+	})
+	return m
+}
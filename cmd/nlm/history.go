@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/cleanup"
+	"github.com/tmc/nlm/internal/config"
+	"github.com/tmc/nlm/internal/errinfo"
+	"github.com/tmc/nlm/internal/history"
+)
+
+// Browser history flags
+var (
+	fromHistory    bool
+	historyLast    string
+	historyMatch   string
+	historyBrowser string
+)
+
+func init() {
+	flag.BoolVar(&fromHistory, "from-history", false, "with add, choose sources from the pages recently visited in the browser")
+	flag.StringVar(&historyLast, "last", "1h", "with add -from-history, how far back to look: a duration such as 90m or 2d, or a date")
+	flag.StringVar(&historyMatch, "match", "", "with add -from-history, only offer pages whose URL or title matches this `regexp`; with aggregate, a glob of notebook titles")
+	flag.StringVar(&historyBrowser, "browser", "chrome", "with add -from-history, browser to read: "+strings.Join(history.Browsers, ", "))
+}
+
+// maxHistoryChoices bounds the visited pages offered for selection.
+const maxHistoryChoices = 100
+
+// addFromHistory lists the web pages visited in the last -last, read from
+// the browser's history (profile NLM_BROWSER_PROFILE), and adds the ones
+// the user picks as URL sources.
+func addFromHistory(c *api.Client, notebookID string) error {
+	since, err := parseSince(historyLast, time.Now())
+	if err != nil {
+		return errinfo.Usagef("add: invalid -last %q (want a duration like 1h or 2d, or a date)", historyLast)
+	}
+	opts := history.Options{
+		Browser: historyBrowser,
+		Since:   since,
+	}
+	// Without a profile set, Firefox's most recently used one is read.
+	if settings.Source[config.SettingProfile] != "default" {
+		opts.Profile = browserProfile()
+	}
+	if historyMatch != "" {
+		if opts.Match, err = regexp.Compile(historyMatch); err != nil {
+			return fmt.Errorf("add: -match: %w", err)
+		}
+	}
+	ctx, stop := cleanup.NotifyContext(context.Background())
+	defer stop()
+	visits, err := history.Read(ctx, opts)
+	if err != nil {
+		return err
+	}
+	if len(visits) == 0 {
+		return fmt.Errorf("add: no pages in the %s history since %s", historyBrowser, since.Format("2006-01-02 15:04"))
+	}
+	if len(visits) > maxHistoryChoices {
+		fmt.Fprintf(os.Stderr, "%d pages visited; offering the latest %d (narrow with -match or -last)\n", len(visits), maxHistoryChoices)
+		visits = visits[:maxHistoryChoices]
+	}
+
+	// Oldest first, so the list reads in the order of the session.
+	for i := len(visits) - 1; i >= 0; i-- {
+		v := visits[i]
+		title := v.Title
+		if title == "" {
+			title = pageName(v.URL)
+		}
+		fmt.Fprintf(os.Stderr, "%3d  %s  %s\n     %s\n", i+1, v.Time.Local().Format("15:04"), title, v.URL)
+	}
+	fmt.Fprint(os.Stderr, "\nAdd which pages? (such as 1,3-5 or all; Enter for none): ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return fmt.Errorf("add: read selection: %w", err)
+	}
+	picked, err := parseSelection(line, len(visits))
+	if err != nil {
+		return fmt.Errorf("add: %w", err)
+	}
+	if len(picked) == 0 {
+		fmt.Fprintln(os.Stderr, "Nothing added.")
+		return nil
+	}
+	urls := make([]string, len(picked))
+	for i, n := range picked {
+		urls[i] = visits[n-1].URL
+	}
+	return addSources(c, notebookID, urls)
+}
+
+// parseSelection parses a list of numbers and ranges from 1 to n, such as
+// "1, 3-5", or "all", returning the numbers in the order given without
+// repeats.
+func parseSelection(s string, n int) ([]int, error) {
+	s = strings.TrimSpace(s)
+	if strings.EqualFold(s, "all") {
+		all := make([]int, n)
+		for i := range all {
+			all[i] = i + 1
+		}
+		return all, nil
+	}
+	var picked []int
+	seen := make(map[int]bool)
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+		lo, hi, isRange := strings.Cut(f, "-")
+		a, err := strconv.Atoi(lo)
+		b := a
+		if err == nil && isRange {
+			b, err = strconv.Atoi(hi)
+		}
+		if err != nil || a < 1 || b > n || a > b {
+			return nil, errinfo.Usagef("invalid selection %q (want numbers from 1 to %d)", f, n)
+		}
+		for i := a; i <= b; i++ {
+			if !seen[i] {
+				seen[i] = true
+				picked = append(picked, i)
+			}
+		}
+	}
+	return picked, nil
+}
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/config"
+	"github.com/tmc/nlm/internal/convert"
+	"github.com/tmc/nlm/internal/estimate"
+	"github.com/tmc/nlm/internal/ingest"
+	"github.com/tmc/nlm/internal/langdetect"
+	"github.com/tmc/nlm/internal/ocr"
+	"github.com/tmc/nlm/internal/transcribe"
+)
+
+var (
+	splitByLanguage bool
+	ipynbOutputs    bool
+	tableRows       int
+	keepTimestamps  bool
+	ocrImages       bool
+	transcribeLocal bool
+)
+
+func init() {
+	flag.BoolVar(&splitByLanguage, "split-by-language", false, "with add, put files in other languages into per-language notebooks")
+	flag.BoolVar(&ipynbOutputs, "ipynb-outputs", false, "with add, include code cell outputs from Jupyter notebooks")
+	flag.BoolVar(&keepTimestamps, "keep-timestamps", false, "with add, keep cue start times when converting subtitles")
+	flag.BoolVar(&ocrImages, "ocr", false, "with add, upload the text recognized in images instead of the images")
+	flag.BoolVar(&transcribeLocal, "transcribe-locally", false, "with add or podcast add, upload local whisper.cpp transcripts of audio files instead of the audio")
+	flag.IntVar(&tableRows, "table-rows", convert.DefaultTableRows, "with add, number of spreadsheet rows to include after the column summary")
+}
+
+// ingestItem is one input to add, with its detected language.
+type ingestItem struct {
+	input string
+	lang  string // langdetect.Unknown for URLs, stdin and binary files
+}
+
+// addSources adds each input to the notebook and prints the new source
+// IDs. The language of local text files is detected and reported; with
+// -split-by-language, files not in the batch's primary language go to sibling
+// notebooks named "<title> [<lang>]", created as needed.
+func addSources(c *api.Client, notebookID string, inputs []string) error {
+	items := make([]ingestItem, len(inputs))
+	for i, in := range inputs {
+		items[i] = ingestItem{input: in, lang: detectLanguage(in)}
+	}
+
+	targets := map[string]string{}
+	primary := primaryLanguage(items)
+	if splitByLanguage {
+		var err error
+		if targets, err = languageNotebooks(c, notebookID, primary, items); err != nil {
+			return err
+		}
+	}
+
+	var failed int
+	for i, it := range items {
+		reportStep("add", it.input, i, len(items))
+		dest := notebookID
+		if id, ok := targets[it.lang]; ok {
+			dest = id
+		}
+		if it.lang != langdetect.Unknown {
+			fmt.Fprintf(os.Stderr, "Detected language %s: %s\n", it.lang, it.input)
+		}
+		id, err := addSource(c, dest, it.input)
+		if err != nil {
+			if len(items) == 1 {
+				return err
+			}
+			fmt.Fprintf(errorOutput, "add %s: %v\n", it.input, err)
+			failed++
+			continue
+		}
+		fmt.Println(id)
+	}
+	reportDone("add", len(items))
+	if failed > 0 {
+		return fmt.Errorf("add: %d of %d sources failed", failed, len(items))
+	}
+	return nil
+}
+
+// detectLanguage returns the language of a local text file, or Unknown.
+func detectLanguage(input string) string {
+	if input == "-" || strings.HasPrefix(input, "http://") || strings.HasPrefix(input, "https://") {
+		return langdetect.Unknown
+	}
+	if _, err := os.Stat(input); err != nil {
+		// Literal text content.
+		return langdetect.Detect(input)
+	}
+	text, ok, err := estimate.ReadText(input)
+	if err != nil || !ok {
+		return langdetect.Unknown
+	}
+	return langdetect.Detect(string(text))
+}
+
+// primaryLanguage returns the most common detected language in items.
+func primaryLanguage(items []ingestItem) string {
+	counts := make(map[string]int)
+	for _, it := range items {
+		if it.lang != langdetect.Unknown {
+			counts[it.lang]++
+		}
+	}
+	var langs []string
+	for l := range counts {
+		langs = append(langs, l)
+	}
+	sort.Strings(langs)
+	primary := langdetect.Unknown
+	for _, l := range langs {
+		if primary == langdetect.Unknown || counts[l] > counts[primary] {
+			primary = l
+		}
+	}
+	return primary
+}
+
+// languageNotebooks returns the notebook for each language in items other
+// than primary, finding existing "<title> [<lang>]" notebooks or creating them.
+func languageNotebooks(c *api.Client, notebookID, primary string, items []ingestItem) (map[string]string, error) {
+	targets := make(map[string]string)
+	var need []string
+	for _, it := range items {
+		if it.lang == langdetect.Unknown || it.lang == primary {
+			continue
+		}
+		if _, ok := targets[it.lang]; !ok {
+			targets[it.lang] = ""
+			need = append(need, it.lang)
+		}
+	}
+	if len(need) == 0 {
+		return targets, nil
+	}
+	p, err := c.GetProject(notebookID)
+	if err != nil {
+		return nil, fmt.Errorf("split by language: %w", err)
+	}
+	nbs, err := c.ListRecentlyViewedProjects()
+	if err != nil {
+		return nil, fmt.Errorf("split by language: %w", err)
+	}
+	base := strings.TrimSpace(p.GetTitle())
+	for _, lang := range need {
+		title := fmt.Sprintf("%s [%s]", base, lang)
+		for _, nb := range nbs {
+			if strings.TrimSpace(nb.GetTitle()) == title {
+				targets[lang] = nb.GetProjectId()
+				break
+			}
+		}
+		if targets[lang] != "" {
+			continue
+		}
+		nb, err := c.CreateProject(title, p.GetEmoji())
+		if err != nil {
+			return nil, fmt.Errorf("create notebook %q: %w", title, err)
+		}
+		fmt.Fprintf(os.Stderr, "Created notebook %q (%s) for %s sources\n", title, nb.GetProjectId(), lang)
+		targets[lang] = nb.GetProjectId()
+	}
+	return targets, nil
+}
+
+var (
+	configOnce sync.Once
+	projectCfg *config.Config
+	configErr  error
+)
+
+// projectConfig returns the nearest .nlm.yaml, loaded once per run,
+// without its restricted settings unless it is trusted.
+func projectConfig() (*config.Config, error) {
+	configOnce.Do(func() {
+		projectCfg, configErr = config.LoadDefault()
+		if configErr == nil && projectCfg.Path() != "" {
+			if debug {
+				fmt.Fprintf(os.Stderr, "Using configuration from %s\n", projectCfg.Path())
+			}
+			restrictUntrusted(projectCfg)
+		}
+	})
+	return projectCfg, configErr
+}
+
+// ingestPipeline returns the pre-upload transforms configured in
+// .nlm.yaml. The pipeline is empty when there is no configuration.
+func ingestPipeline() (*ingest.Pipeline, error) {
+	cfg, err := projectConfig()
+	if err != nil {
+		return nil, err
+	}
+	return ingest.New(cfg.Ingest)
+}
+
+// converters returns the built-in converters and those configured in
+// .nlm.yaml, which take precedence.
+func converters() (*convert.Registry, error) {
+	cfg, err := projectConfig()
+	if err != nil {
+		return nil, err
+	}
+	r := convert.NewRegistry()
+	r.Register(".ipynb", convert.Notebook(convert.NotebookOptions{Outputs: ipynbOutputs}))
+	table := convert.TableOptions{Rows: tableRows, MaxWords: estimate.DefaultLimits.WordsPerSource}
+	r.Register(".csv", convert.CSV(table))
+	r.Register(".tsv", convert.CSV(table))
+	r.Register(".xlsx", convert.XLSX(table))
+	r.Register(".tex", convert.LaTeX())
+	subs := convert.Subtitles(convert.SubtitleOptions{KeepTimestamps: keepTimestamps})
+	r.Register(".srt", subs)
+	r.Register(".vtt", subs)
+	if ocrImages {
+		engine := cfg.OCR.Engine(settings.OCRToken)
+		recognize := convert.Func(func(ctx context.Context, name string, r io.Reader, w io.Writer) error {
+			text, err := engine.Recognize(ctx, name, r)
+			if err != nil {
+				return err
+			}
+			_, err = io.WriteString(w, text)
+			return err
+		})
+		for _, ext := range ocr.Extensions {
+			r.Register(ext, recognize)
+		}
+	}
+	if transcribeLocal {
+		whisper := whisperConfig(cfg)
+		local := convert.Func(func(ctx context.Context, name string, _ io.Reader, w io.Writer) error {
+			fmt.Fprintf(os.Stderr, "Transcribing %s locally...\n", name)
+			text, err := whisper.Transcribe(ctx, filepath.Join(convert.Dir(ctx), name))
+			if err != nil {
+				return err
+			}
+			_, err = io.WriteString(w, text)
+			return err
+		})
+		for _, ext := range transcribe.Extensions {
+			r.Register(ext, local)
+		}
+	}
+	for ext, args := range cfg.Converters {
+		r.Register(ext, convert.Command{Args: args})
+	}
+	return r, nil
+}
+
+// whisperConfig returns the local transcription settings from .nlm.yaml,
+// with the model overridden by NLM_WHISPER_MODEL.
+func whisperConfig(cfg *config.Config) transcribe.Whisper {
+	w := cfg.Transcribe
+	if m := settings.WhisperModel; m != "" {
+		w.Model = m
+	}
+	return w
+}
+
+// fileText returns the text to upload for a local file after conversion
+// and filtering. ok is false if the file should be uploaded unchanged.
+func fileText(p *ingest.Pipeline, path string) (text string, ok bool, err error) {
+	r, err := converters()
+	if err != nil {
+		return "", false, err
+	}
+	if _, found := r.Lookup(path); found {
+		data, err := r.File(context.Background(), path)
+		if err != nil {
+			return "", false, err
+		}
+		fmt.Fprintf(os.Stderr, "Converted %s (%s)\n", path, formatBytes(int64(len(data))))
+		text, _ := filterText(p, path, string(data))
+		return text, true, nil
+	}
+	if p.Empty() {
+		return "", false, nil
+	}
+	return filterFile(p, path)
+}
+
+// filterText applies p to text and reports what changed.
+func filterText(p *ingest.Pipeline, name, text string) (string, ingest.Stats) {
+	out, stats := p.Apply(text)
+	if stats.Changed() {
+		fmt.Fprintf(os.Stderr, "Filtered %s: %s\n", name, stats)
+	}
+	return out, stats
+}
+
+// filterFile returns the filtered text of a local file. ok is false for
+// files that cannot be read as text, which are uploaded unchanged unless
+// the pipeline redacts, in which case they are refused.
+func filterFile(p *ingest.Pipeline, path string) (text string, ok bool, err error) {
+	data, ok, err := estimate.ReadText(path)
+	if err != nil {
+		return "", false, err
+	}
+	if !ok {
+		if p.Redacts() {
+			return "", false, fmt.Errorf("%s: cannot apply redactions to a file that is not text; convert it to text first", path)
+		}
+		fmt.Fprintf(os.Stderr, "warning: %s is not text; ingest filters were not applied\n", path)
+		return "", false, nil
+	}
+	text, _ = filterText(p, path, string(data))
+	return text, true, nil
+}
+
+// filterReader returns the filtered text read from r.
+func filterReader(p *ingest.Pipeline, name string, r io.Reader) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("read %s: %w", name, err)
+	}
+	if !strings.HasPrefix(http.DetectContentType(data), "text/") && p.Redacts() {
+		return "", fmt.Errorf("%s: cannot apply redactions to input that is not text", name)
+	}
+	text, _ := filterText(p, name, string(data))
+	return text, nil
+}
+
+// previewFilter prints a document as it would be uploaded, after any
+// converter and ingest filters.
+func previewFilter(input string) error {
+	p, err := ingestPipeline()
+	if err != nil {
+		return err
+	}
+	var text string
+	if input == "-" {
+		text, err = filterReader(p, "stdin", os.Stdin)
+	} else {
+		var ok bool
+		text, ok, err = fileText(p, input)
+		if err == nil && !ok {
+			data, isText, rerr := estimate.ReadText(input)
+			if rerr != nil {
+				return rerr
+			}
+			if !isText {
+				return fmt.Errorf("%s is not text", input)
+			}
+			text = string(data)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(os.Stdout, text)
+	return err
+}
@@ -0,0 +1,386 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/cleanup"
+	"github.com/tmc/nlm/internal/config"
+	"github.com/tmc/nlm/internal/jobs"
+)
+
+// Job flags
+var (
+	runAsync bool
+	waitDone bool
+)
+
+func init() {
+	flag.BoolVar(&runAsync, "async", false, "run a long command in the background and print its job ID (see nlm jobs)")
+	flag.BoolVar(&waitDone, "wait", false, "with audio-create and audio-get, wait until the audio overview is ready")
+}
+
+// asyncCommands are the commands -async can run in the background.
+var asyncCommands = map[string]bool{
+	"add":              true,
+	"sync":             true,
+	"crawl":            true,
+	"import":           true,
+	"export":           true,
+	"backup":           true,
+	"run":              true,
+	"audio-create":     true,
+	"generate-guide":   true,
+	"generate-outline": true,
+	"generate-section": true,
+	"pipe":             true,
+	"aggregate":        true,
+}
+
+// jobIDEnv names the job a background process runs as.
+const jobIDEnv = config.EnvJobID
+
+func jobRegistry() (*jobs.Registry, error) {
+	st, err := openState()
+	if err != nil {
+		return nil, err
+	}
+	return jobs.NewRegistry(st), nil
+}
+
+// startJob starts nlm again in the background with the same arguments,
+// minus -async, recording it as a job, and prints the job ID.
+func startJob(cmd string, args []string) error {
+	if !asyncCommands[cmd] {
+		return fmt.Errorf("%s cannot run with -async", cmd)
+	}
+	for _, a := range args {
+		if a == "-" {
+			return fmt.Errorf("%s: -async jobs cannot read stdin", cmd)
+		}
+	}
+	r, err := jobRegistry()
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd, err)
+	}
+	var argv []string
+	for _, a := range os.Args[1:] {
+		switch a {
+		case "-async", "--async", "-async=true", "--async=true":
+			continue
+		}
+		argv = append(argv, a)
+	}
+	j, err := r.Create(cmd, args, argv)
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd, err)
+	}
+	if err := spawnJob(r, j, argv); err != nil {
+		return fmt.Errorf("%s: %w", cmd, err)
+	}
+	fmt.Println(j.ID)
+	fmt.Fprintf(os.Stderr, "Started %s in the background; follow it with nlm jobs wait %s\n", cmd, j.ID)
+	return nil
+}
+
+// spawnJob runs nlm with argv in the background as job j, appending its
+// output to the job's log.
+func spawnJob(r *jobs.Registry, j *jobs.Job, argv []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	logFile, err := os.OpenFile(j.Log, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+	c := exec.Command(exe, argv...)
+	c.Env = append(os.Environ(), jobIDEnv+"="+j.ID)
+	c.Stdout, c.Stderr = logFile, logFile
+	jobs.Detach(c)
+	if err := c.Start(); err != nil {
+		r.Finish(j.ID, err)
+		return fmt.Errorf("start job: %w", err)
+	}
+	return c.Process.Release()
+}
+
+// phaseSubmitted is the checkpoint of jobs whose operation the server has
+// accepted and continues on its own.
+const phaseSubmitted = "submitted"
+
+// checkpointJob records a phase of this process if it runs as a job.
+func checkpointJob(phase string) {
+	id := bootstrap().JobID
+	if id == "" {
+		return
+	}
+	if r, err := jobRegistry(); err == nil {
+		r.SetPhase(id, phase)
+	}
+}
+
+// rerunnableCommands are the commands a resumed job may run again: they
+// only read, or keep progress and skip what is done, such as sync and
+// export. Running the others again would add sources or create notebooks
+// and notes a second time.
+var rerunnableCommands = map[string]bool{
+	"sync":             true,
+	"export":           true,
+	"backup":           true,
+	"generate-guide":   true,
+	"generate-outline": true,
+	"generate-section": true,
+}
+
+// resumable reports whether job j can be resumed without repeating work
+// that changed the notebook. Adding from a bucket records each object it
+// added, so it is resumable while other adds are not.
+func resumable(j *jobs.Job) bool {
+	switch j.Command {
+	case "audio-create":
+		return j.Phase == phaseSubmitted && len(j.Args) > 0
+	case "add":
+		for _, a := range j.Argv {
+			name, _, _ := strings.Cut(strings.TrimLeft(a, "-"), "=")
+			if strings.HasPrefix(a, "-") && (name == "s3" || name == "gcs") {
+				return true
+			}
+		}
+		return false
+	}
+	return rerunnableCommands[j.Command]
+}
+
+// resumeJobs restarts the given jobs, or all lost ones that can be. An
+// audio overview that was already submitted is waited for rather than
+// requested again; jobs in rerunnableCommands run their command again.
+// Other jobs are refused, as they may have partly changed a notebook.
+func resumeJobs(r *jobs.Registry, ids []string) error {
+	if len(ids) == 0 {
+		js, err := r.List()
+		if err != nil {
+			return fmt.Errorf("jobs resume: %w", err)
+		}
+		for _, j := range js {
+			if j.State != jobs.Lost {
+				continue
+			}
+			if !resumable(j) {
+				fmt.Fprintf(os.Stderr, "%s: not resuming %s, which may have partly run; check its log and rerun it by hand\n", j.ID, j.Command)
+				continue
+			}
+			ids = append(ids, j.ID)
+		}
+		if len(ids) == 0 {
+			fmt.Fprintln(os.Stderr, "No lost jobs to resume.")
+			return nil
+		}
+	}
+	var failed int
+	for _, id := range ids {
+		if j, err := r.Get(id); err == nil && !resumable(j) {
+			fmt.Fprintf(os.Stderr, "%s: %s cannot be resumed safely, as it may have partly run; check its log and rerun it by hand\n", id, j.Command)
+			failed++
+			continue
+		}
+		j, err := r.Resume(id)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", id, err)
+			failed++
+			continue
+		}
+		argv := j.Argv
+		how := "restarted"
+		if j.Command == "audio-create" {
+			argv = []string{"-wait", "audio-get", j.Args[0]}
+			how = "re-attached to the submitted audio overview"
+		}
+		if err := spawnJob(r, j, argv); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", id, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "%s: %s %s\n", id, j.Command, how)
+	}
+	if failed > 0 {
+		return fmt.Errorf("jobs resume: %d of %d jobs not resumed", failed, len(ids))
+	}
+	return nil
+}
+
+// beginJob records the PID of this process if it runs as a job.
+func beginJob() {
+	id := bootstrap().JobID
+	if id == "" {
+		return
+	}
+	r, err := jobRegistry()
+	if err != nil {
+		return
+	}
+	r.SetPID(id, os.Getpid())
+}
+
+// finishJob records the result of this process if it runs as a job.
+func finishJob(err error) {
+	id := bootstrap().JobID
+	if id == "" {
+		return
+	}
+	r, rerr := jobRegistry()
+	if rerr != nil {
+		return
+	}
+	if err != nil {
+		err = errors.New(redactString(err.Error()))
+	}
+	if ferr := r.Finish(id, err); ferr != nil {
+		fmt.Fprintf(os.Stderr, "nlm: record job result: %v\n", ferr)
+	}
+}
+
+// runJobs implements nlm jobs [status|wait|resume].
+func runJobs(args []string) error {
+	r, err := jobRegistry()
+	if err != nil {
+		return fmt.Errorf("jobs: %w", err)
+	}
+	switch {
+	case len(args) == 0:
+		return listJobs(r)
+	case len(args) == 2 && args[0] == "status":
+		j, err := r.Get(args[1])
+		if err != nil {
+			return fmt.Errorf("jobs: %w", err)
+		}
+		return printJob(j)
+	case len(args) >= 1 && args[0] == "resume":
+		return resumeJobs(r, args[1:])
+	case len(args) == 2 && args[0] == "wait":
+		ctx, stop := cleanup.NotifyContext(context.Background())
+		defer stop()
+		j, err := r.Wait(ctx, args[1], time.Second)
+		if err != nil {
+			return fmt.Errorf("jobs: %w", err)
+		}
+		if !jsonOutput {
+			if f, err := os.Open(j.Log); err == nil {
+				io.Copy(os.Stderr, f)
+				f.Close()
+			}
+		}
+		if err := printJob(j); err != nil {
+			return err
+		}
+		if j.State != jobs.Succeeded {
+			return fmt.Errorf("jobs: %s %s: %s", j.ID, j.State, j.Error)
+		}
+		return nil
+	}
+	log.Fatal("usage: nlm jobs [-json]\n       nlm jobs status <job-id> [-json]\n       nlm jobs wait <job-id> [-json]\n       nlm jobs resume [job-id...]")
+	return nil
+}
+
+func listJobs(r *jobs.Registry) error {
+	js, err := r.List()
+	if err != nil {
+		return fmt.Errorf("jobs: %w", err)
+	}
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if js == nil {
+			js = []*jobs.Job{}
+		}
+		return enc.Encode(js)
+	}
+	t := newTable("ID", "COMMAND", "STATE", "STARTED", "DURATION")
+	for _, j := range js {
+		t.Append(j.ID, j.Command, j.State, j.Started.Local().Format("2006-01-02 15:04:05"), jobDuration(j))
+	}
+	return t.Render(os.Stdout)
+}
+
+func printJob(j *jobs.Job) error {
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(j)
+	}
+	fmt.Printf("id: %s\n", j.ID)
+	fmt.Printf("command: %s %s\n", j.Command, quoteArgs(j.Args))
+	fmt.Printf("state: %s\n", j.State)
+	if j.PID != 0 {
+		fmt.Printf("pid: %d\n", j.PID)
+	}
+	fmt.Printf("started: %s\n", j.Started.Local().Format(time.RFC3339))
+	fmt.Printf("duration: %s\n", jobDuration(j))
+	if j.Phase != "" {
+		fmt.Printf("phase: %s\n", j.Phase)
+	}
+	if j.Resumes > 0 {
+		fmt.Printf("resumes: %d\n", j.Resumes)
+	}
+	if j.Error != "" {
+		fmt.Printf("error: %s\n", j.Error)
+	}
+	fmt.Printf("log: %s\n", j.Log)
+	return nil
+}
+
+// jobDuration returns how long a job ran, or has been running.
+func jobDuration(j *jobs.Job) string {
+	end := j.Finished
+	if end.IsZero() {
+		end = time.Now()
+	}
+	return end.Sub(j.Started).Round(time.Second).String()
+}
+
+// quoteArgs joins args, quoting those a shell would split.
+func quoteArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		if a == "" || strings.ContainsAny(a, " \t\n") {
+			a = strconv.Quote(a)
+		}
+		quoted[i] = a
+	}
+	return strings.Join(quoted, " ")
+}
+
+// waitForAudio polls until the notebook's audio overview is ready.
+func waitForAudio(c *api.Client, projectID string) (*api.AudioOverviewResult, error) {
+	ctx, stop := cleanup.NotifyContext(context.Background())
+	defer stop()
+	fmt.Fprintf(os.Stderr, "Waiting for the audio overview...\n")
+	for {
+		select {
+		case <-time.After(audioPollInterval):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("audio overview not ready: %w", ctx.Err())
+		}
+		reportStep("audio", "waiting", 0, 0)
+		audio, err := c.GetAudioOverview(projectID)
+		if err != nil {
+			return nil, err
+		}
+		if audio.IsReady {
+			reportDone("audio", 0)
+			return audio, nil
+		}
+	}
+}
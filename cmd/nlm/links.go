@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	pb "github.com/tmc/nlm/gen/notebooklm/v1alpha1"
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/export"
+	"github.com/tmc/nlm/internal/linkcheck"
+)
+
+// deadLinkPrefix is prepended to the titles of sources whose links are
+// dead when -mark-dead is given, so they stand out in the web UI.
+const deadLinkPrefix = "[dead link] "
+
+// Link check flags
+var (
+	linksRefresh  bool
+	linksMarkDead bool
+)
+
+func init() {
+	flag.BoolVar(&linksRefresh, "refresh", false, "with sources check-links, refresh sources whose links are alive")
+	flag.BoolVar(&linksMarkDead, "mark-dead", false, "with sources check-links, prefix the titles of dead sources with "+strings.TrimSpace(deadLinkPrefix))
+}
+
+// checkLinks checks the web address of every URL source in a notebook and
+// reports dead links and redirects.
+func checkLinks(c *api.Client, notebookID string) error {
+	p, err := c.GetProject(notebookID)
+	if err != nil {
+		return fmt.Errorf("check links: %w", err)
+	}
+	var (
+		srcs []*pb.Source
+		urls []string
+	)
+	for _, src := range p.Sources {
+		raw, err := c.LoadSourceRaw(src.GetSourceId().GetSourceId())
+		if err != nil {
+			return fmt.Errorf("check links: %w", err)
+		}
+		if u := export.SourceURL(raw); u != "" {
+			srcs = append(srcs, src)
+			urls = append(urls, u)
+		}
+	}
+	if len(urls) == 0 {
+		fmt.Fprintln(os.Stderr, "No URL sources in this notebook.")
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "Checking %d links...\n", len(urls))
+
+	checker := &linkcheck.Checker{UserAgent: "nlm/" + buildVersion()}
+	results := checker.CheckAll(context.Background(), urls)
+
+	var dead int
+	t := newTable("SOURCE", "STATUS", "CODE", "URL")
+	for i, r := range results {
+		src := srcs[i]
+		id := src.GetSourceId().GetSourceId()
+		title := strings.TrimSpace(src.GetTitle())
+		detail := r.URL
+		switch r.Status {
+		case linkcheck.Redirected:
+			detail = r.URL + " -> " + r.FinalURL
+		case linkcheck.Dead:
+			dead++
+			if r.Err != nil {
+				detail = r.URL + " (" + r.Err.Error() + ")"
+			}
+		}
+		code := "-"
+		if r.StatusCode != 0 {
+			code = fmt.Sprint(r.StatusCode)
+		}
+		t.Append(title, r.Status.String(), code, detail)
+
+		switch {
+		case r.Status == linkcheck.Dead && linksMarkDead && !strings.HasPrefix(title, deadLinkPrefix):
+			if _, err := c.MutateSource(id, &pb.Source{Title: deadLinkPrefix + title}); err != nil {
+				fmt.Fprintf(errorOutput, "mark %s: %v\n", title, err)
+			}
+		case r.Status != linkcheck.Dead && linksRefresh:
+			if _, err := c.RefreshSource(id); err != nil {
+				fmt.Fprintf(errorOutput, "refresh %s: %v\n", title, err)
+			}
+		}
+	}
+	if err := t.Render(os.Stdout); err != nil {
+		return err
+	}
+	if dead > 0 {
+		return fmt.Errorf("%d of %d links are dead", dead, len(urls))
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	runtimedebug "runtime/debug"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/batchexecute"
+	"github.com/tmc/nlm/internal/cleanup"
+)
+
+// lowMemory trades speed for a smaller footprint, for small machines such
+// as a Raspberry Pi.
+var lowMemory bool
+
+// maxResponseMB is the size of the largest response read into memory;
+// larger ones, such as full exports, go through a temporary file.
+var maxResponseMB int
+
+func init() {
+	flag.BoolVar(&lowMemory, "low-memory", false, "stream file uploads from disk, run batches one at a time and collect garbage more often")
+	flag.IntVar(&maxResponseMB, "max-response-mb", 64, "spool responses larger than this many MB to a temporary file (8 with -low-memory, 0 for no limit)")
+}
+
+// applyLowMemory applies -low-memory to the settings that are not per
+// client. Flags given explicitly, such as -workers, take precedence.
+func applyLowMemory() {
+	if !lowMemory {
+		return
+	}
+	runtimedebug.SetGCPercent(20)
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if !set["workers"] {
+		batchWorkers = 1
+	}
+	if !set["max-response-mb"] {
+		maxResponseMB = 8
+	}
+}
+
+// memoryOptions bound the memory used to read responses.
+func memoryOptions() []batchexecute.Option {
+	return []batchexecute.Option{batchexecute.WithMaxResponseSize(int64(maxResponseMB) << 20)}
+}
+
+// saveAudio writes the audio of result to path, decoding it as it is
+// written rather than in memory. A partial file is never left at path.
+func saveAudio(result *api.AudioOverviewResult, path string) error {
+	r, err := api.AudioReader(result)
+	if err != nil {
+		return err
+	}
+	f, err := cleanup.CreateTemp(filepath.Dir(path), ".tmp-"+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_, err = io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, 0o644)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		cleanup.Remove(tmp)
+		return err
+	}
+	cleanup.Forget(tmp)
+	return nil
+}
@@ -6,13 +6,21 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
-	"text/tabwriter"
 	"time"
 
 	pb "github.com/tmc/nlm/gen/notebooklm/v1alpha1"
 	"github.com/tmc/nlm/internal/api"
 	"github.com/tmc/nlm/internal/batchexecute"
+	"github.com/tmc/nlm/internal/cleanup"
+	"github.com/tmc/nlm/internal/cmddoc"
+	"github.com/tmc/nlm/internal/errinfo"
+	"github.com/tmc/nlm/internal/listing"
+	"github.com/tmc/nlm/internal/ocr"
+	"github.com/tmc/nlm/internal/redact"
+	"github.com/tmc/nlm/internal/transcribe"
 )
 
 // Global flags
@@ -20,73 +28,52 @@ var (
 	authToken string
 	cookies   string
 	debug     bool
+	noRedact  bool
+	assumeYes bool
 )
 
 func main() {
 	log.SetPrefix("nlm: ")
 	log.SetFlags(0)
+	cleanup.HandleSignals()
+	defer cleanup.OnPanic()
 
-	// change this so flag usage doesn't print these values..
-	flag.StringVar(&authToken, "auth", os.Getenv("NLM_AUTH_TOKEN"), "auth token (or set NLM_AUTH_TOKEN)")
-	flag.StringVar(&cookies, "cookies", os.Getenv("NLM_COOKIES"), "cookies for authentication (or set NLM_COOKIES)")
+	flag.StringVar(&authToken, "auth", "", "auth token (or set NLM_AUTH_TOKEN)")
+	flag.StringVar(&cookies, "cookies", "", "cookies for authentication (or set NLM_COOKIES)")
 	flag.BoolVar(&debug, "debug", false, "enable debug output")
+	flag.BoolVar(&noRedact, "no-redact", false, "show credentials and email addresses in debug output and errors")
+	flag.BoolVar(&assumeYes, "y", false, "remove without asking for confirmation (rm, rm-source)")
 
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: nlm <command> [arguments]\n\n")
-		fmt.Fprintf(os.Stderr, "Notebook Commands:\n")
-		fmt.Fprintf(os.Stderr, "  list, ls          List all notebooks\n")
-		fmt.Fprintf(os.Stderr, "  create <title>    Create a new notebook\n")
-		fmt.Fprintf(os.Stderr, "  rm <id>           Delete a notebook\n")
-		fmt.Fprintf(os.Stderr, "  analytics <id>    Show notebook analytics\n\n")
-
-		fmt.Fprintf(os.Stderr, "Source Commands:\n")
-		fmt.Fprintf(os.Stderr, "  sources <id>      List sources in notebook\n")
-		fmt.Fprintf(os.Stderr, "  add <id> <input>  Add source to notebook\n")
-		fmt.Fprintf(os.Stderr, "  rm-source <id> <source-id>  Remove source\n")
-		fmt.Fprintf(os.Stderr, "  rename-source <source-id> <new-name>  Rename source\n")
-		fmt.Fprintf(os.Stderr, "  refresh-source <source-id>  Refresh source content\n")
-		fmt.Fprintf(os.Stderr, "  check-source <source-id>  Check source freshness\n\n")
-
-		fmt.Fprintf(os.Stderr, "Note Commands:\n")
-		fmt.Fprintf(os.Stderr, "  notes <id>        List notes in notebook\n")
-		fmt.Fprintf(os.Stderr, "  new-note <id> <title>  Create new note\n")
-		fmt.Fprintf(os.Stderr, "  edit-note <id> <note-id> <content>  Edit note\n")
-		fmt.Fprintf(os.Stderr, "  rm-note <note-id>  Remove note\n\n")
-
-		fmt.Fprintf(os.Stderr, "Audio Commands:\n")
-		fmt.Fprintf(os.Stderr, "  audio-create <id> <instructions>  Create audio overview\n")
-		fmt.Fprintf(os.Stderr, "  audio-get <id>    Get audio overview\n")
-		fmt.Fprintf(os.Stderr, "  audio-rm <id>     Delete audio overview\n")
-		fmt.Fprintf(os.Stderr, "  audio-share <id>  Share audio overview\n\n")
-
-		fmt.Fprintf(os.Stderr, "Generation Commands:\n")
-		fmt.Fprintf(os.Stderr, "  generate-guide <id>  Generate notebook guide\n")
-		fmt.Fprintf(os.Stderr, "  generate-outline <id>  Generate content outline\n")
-		fmt.Fprintf(os.Stderr, "  generate-section <id>  Generate new section\n\n")
-
-		fmt.Fprintf(os.Stderr, "Other Commands:\n")
-		fmt.Fprintf(os.Stderr, "  auth [profile]    Setup authentication\n")
-		fmt.Fprintf(os.Stderr, "  share <id>        Share notebook\n")
-		fmt.Fprintf(os.Stderr, "  feedback <msg>    Submit feedback\n")
-		fmt.Fprintf(os.Stderr, "  hb                Send heartbeat\n\n")
-	}
-
-	if err := run(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
+		cmddoc.WriteUsage(os.Stderr)
+	}
+
+	err := run()
+	finishJob(err)
+	cleanup.Run()
+	printQuietSummary(err)
+	if err != nil {
+		fmt.Fprintln(errorOutput, redactString(err.Error()))
+		printErrorInfo(errorOutput, err)
 		os.Exit(1)
 	}
 }
 
+// redactString masks the current credentials, session cookies and email
+// addresses in s unless -no-redact was given.
+func redactString(s string) string {
+	if noRedact {
+		return s
+	}
+	return redact.New(authToken, cookies).String(s)
+}
+
 func run() error {
 	flag.Parse()
-	loadStoredEnv()
-
-	if authToken == "" {
-		authToken = os.Getenv("NLM_AUTH_TOKEN")
-	}
-	if cookies == "" {
-		cookies = os.Getenv("NLM_COOKIES")
+	if err := beginFake(); err != nil {
+		return err
 	}
+	loadStoredEnv()
 
 	if flag.NArg() < 1 {
 		flag.Usage()
@@ -94,41 +81,108 @@ func run() error {
 	}
 
 	cmd := flag.Arg(0)
-	args := flag.Args()[1:]
-
-   // Prepare options for batchexecute, including debug if requested
-   var optsExec []batchexecute.Option
-   if debug {
-       optsExec = append(optsExec, batchexecute.WithDebug(true))
-   }
-   for i := 0; i < 3; i++ {
+	args, err := parseFlags(flag.CommandLine, flag.Args()[1:])
+	if err != nil {
+		return err
+	}
+	if err := applySettings(applyGlobalConfig()); err != nil {
+		return err
+	}
+	args = withDefaultNotebook(cmd, args)
+	if err := applyNotebookDefaults(cmd, args); err != nil {
+		return err
+	}
+	if err := applyOutputFormat(); err != nil {
+		return err
+	}
+	applyLowMemory()
+	if err := beginProgress(); err != nil {
+		return err
+	}
+	if err := loadShapes(); err != nil {
+		return err
+	}
+	beginQuiet(cmd)
+	if err := validateArgs(cmd, args); err != nil {
+		return err
+	}
+	if runAsync && bootstrap().JobID == "" {
+		return startJob(cmd, args)
+	}
+	beginJob()
+
+	// Prepare options for batchexecute, including debug if requested
+	optsExec := []batchexecute.Option{batchexecute.WithRedaction(!noRedact)}
+	optsExec = append(optsExec, daemonOptions()...)
+	optsExec = append(optsExec, clientHeaderOptions()...)
+	optsExec = append(optsExec, memoryOptions()...)
+	optsExec = append(optsExec, readOnlyOptions()...)
+	endpointOpts, err := endpointOptions()
+	if err != nil {
+		return err
+	}
+	optsExec = append(optsExec, endpointOpts...)
+	if settings.LegacyChunks {
+		optsExec = append(optsExec, batchexecute.WithLegacyChunks(true))
+	}
+	if debug {
+		optsExec = append(optsExec, batchexecute.WithDebug(true))
+	}
+	optsExec = append(optsExec, fakeOptions()...)
+	if requestTimeout > 0 {
+		// After the options that replace the HTTP client, which it sets.
+		optsExec = append(optsExec, batchexecute.WithTimeout(requestTimeout))
+	}
+	q, err := startQuota()
+	if err != nil {
+		return err
+	}
+	optsExec = append(optsExec, q.options()...)
+	var lastErr error
+	for i := 0; i < 3; i++ {
 		if i > 1 {
 			fmt.Fprintln(os.Stderr, "nlm: attempting again to obtain login information")
 			debug = true
 		}
 
-       // Attempt the command; enable debug after second failure as well
-       currentOpts := optsExec
-       if i > 1 && !debug {
-           // turn on debug on retry
-           currentOpts = append(currentOpts, batchexecute.WithDebug(true))
-       }
-       client := api.New(authToken, cookies, currentOpts...)
-       if err := runCmd(client, cmd, args...); err == nil {
-           return nil
-       } else if !errors.Is(err, batchexecute.ErrUnauthorized) {
-           return err
-       }
-
-		var err error
+		// Attempt the command; enable debug after second failure as well
+		currentOpts := optsExec
+		if i > 1 && !debug {
+			// turn on debug on retry
+			currentOpts = append(currentOpts, batchexecute.WithDebug(true))
+		}
+		client := api.New(authToken, cookies, currentOpts...)
+		watchDrift(client)
+		trackChanges(client)
+		client.SetStreamUploads(lowMemory)
+		client.SetCheckArgs(checkRPCArgs)
+		allowExperimental(client)
+		start := time.Now()
+		err := runCmd(client, cmd, args...)
+		recordHistory(cmd, args, start, client.Usage(), err)
+		q.save()
+		saveShapes()
+		quietUsage = client.Usage()
+		if err == nil {
+			return nil
+		}
+		recordFailure(client, cmd, args, err)
+		if !errors.Is(err, batchexecute.ErrUnauthorized) {
+			return err
+		}
+		lastErr = err
+
 		if authToken, cookies, err = handleAuth(nil, debug); err != nil {
 			fmt.Fprintf(os.Stderr, "  -> %v\n", err)
 		}
 	}
-	return fmt.Errorf("nlm: failed after 3 attempts")
+	return fmt.Errorf("nlm: failed after 3 attempts: %w", lastErr)
 }
 
 func runCmd(client *api.Client, cmd string, args ...string) error {
+	if err := requireFeature(client, cmd); err != nil {
+		return err
+	}
 	var err error
 	switch cmd {
 	// Notebook operations
@@ -147,22 +201,71 @@ func runCmd(client *api.Client, cmd string, args ...string) error {
 
 	// Source operations
 	case "sources":
+		if len(args) == 2 && args[0] == "check-links" {
+			err = checkLinks(client, args[1])
+			break
+		}
+		if len(args) >= 3 && (args[0] == "enable" || args[0] == "disable") {
+			err = setSourcesEnabled(client, args[1], args[2:], args[0] == "enable")
+			break
+		}
+		if len(args) >= 3 && args[0] == "reprocess" {
+			err = reprocessSources(client, args[1], args[2:])
+			break
+		}
 		if len(args) != 1 {
-			log.Fatal("usage: nlm sources <notebook-id>")
+			log.Fatal("usage: nlm sources <notebook-id>\n       nlm sources check-links <notebook-id> [-refresh] [-mark-dead]\n       nlm sources enable|disable <notebook-id> <source-id>...\n       nlm sources reprocess <notebook-id> <source-id>...")
 		}
 		err = listSources(client, args[0])
 	case "add":
+		if fromHistory {
+			if len(args) != 1 {
+				log.Fatal("usage: nlm add <notebook-id> -from-history [-last 1h] [-match regexp] [-browser chrome]")
+			}
+			err = addFromHistory(client, args[0])
+			break
+		}
+		if addS3 != "" || addGCS != "" {
+			if len(args) != 1 || addS3 != "" && addGCS != "" {
+				log.Fatal("usage: nlm add <notebook-id> (-s3 s3://bucket/prefix | -gcs gs://bucket/prefix) [-include glob] [-exclude glob]")
+			}
+			err = addFromBucket(client, args[0], addS3+addGCS)
+			break
+		}
+		if len(args) < 2 {
+			log.Fatal("usage: nlm add <notebook-id> <file|url|->... [-split-by-language] [-ipynb-outputs] [-table-rows n] [-keep-timestamps] [-ocr] [-transcribe-locally]")
+		}
+		err = addSources(client, args[0], args[1:])
+	case "sync":
 		if len(args) != 2 {
-			log.Fatal("usage: nlm add <notebook-id> <file>")
+			log.Fatal("usage: nlm sync <notebook-id> <dir> [-conflict=prefer-local|prefer-remote|prompt]")
+		}
+		err = syncDir(client, args[0], args[1])
+	case "crawl":
+		if len(args) != 1 {
+			log.Fatal("usage: nlm crawl <notebook-id> (-sitemap <url> | -seed <url> [-depth 2] [-same-domain] [-max-pages 100]) [-include regexp] [-exclude regexp] [-delay 1s]")
+		}
+		err = crawlSite(client, args[0])
+	case "arxiv":
+		if len(args) < 2 || args[0] != "add" {
+			log.Fatal("usage: nlm arxiv add <notebook-id> [arxiv-id...] [-query words] [-author name] [-max-results 5]")
 		}
-		var id string
-		id, err = addSource(client, args[0], args[1])
-		fmt.Println(id)
+		err = arxivAdd(client, args[1], args[2:])
+	case "paper":
+		if len(args) < 2 || args[0] != "add" {
+			log.Fatal("usage: nlm paper add <notebook-id> -doi <doi>[,<doi>...] [doi...]")
+		}
+		err = paperAdd(client, args[1], args[2:])
+	case "podcast":
+		if len(args) != 3 || args[0] != "add" {
+			log.Fatal("usage: nlm podcast add <notebook-id> <rss-url> [-episodes 10] [-transcribe-locally]")
+		}
+		err = podcastAdd(client, args[1], args[2])
 	case "rm-source":
-		if len(args) != 2 {
-			log.Fatal("usage: nlm rm-source <notebook-id> <source-id>")
+		if len(args) < 2 {
+			log.Fatal("usage: nlm rm-source [-y] <notebook-id> <source-id>...")
 		}
-		err = removeSource(client, args[0], args[1])
+		err = removeSources(client, args[0], args[1:])
 	case "rename-source":
 		if len(args) != 2 {
 			log.Fatal("usage: nlm rename-source <source-id> <new-name>")
@@ -170,6 +273,15 @@ func runCmd(client *api.Client, cmd string, args ...string) error {
 		err = renameSource(client, args[0], args[1])
 
 	// Note operations
+	case "notes":
+		if len(args) == 3 && args[0] == "edit" {
+			err = editNoteInEditor(client, args[1], args[2])
+			break
+		}
+		if len(args) != 1 {
+			log.Fatal("usage: nlm notes <notebook-id>\n       nlm notes edit <notebook-id> <note-id>")
+		}
+		err = listNotes(client, args[0])
 	case "new-note":
 		if len(args) != 2 {
 			log.Fatal("usage: nlm new-note <notebook-id> <title>")
@@ -224,8 +336,99 @@ func runCmd(client *api.Client, cmd string, args ...string) error {
 			log.Fatal("usage: nlm generate-section <notebook-id>")
 		}
 		err = generateSection(client, args[0])
+	case "pipe":
+		if len(args) < 2 {
+			log.Fatal("usage: nlm pipe [-type guide] [-as notes|sources] <src-notebook>... <dst-notebook>")
+		}
+		err = pipe(client, args[:len(args)-1], args[len(args)-1])
+	case "aggregate":
+		if len(args) != 0 {
+			log.Fatal("usage: nlm aggregate -match <glob> -into <notebook>")
+		}
+		err = aggregate(client)
+
+	case "export":
+		if len(args) != 1 {
+			log.Fatal("usage: nlm export <notebook-id> [-o dir] [-force]")
+		}
+		err = exportNotebook(client, args[0], outputPath)
+	case "archive":
+		if len(args) != 1 {
+			log.Fatal("usage: nlm archive <notebook-id> [-o file.zip]")
+		}
+		err = archiveNotebook(client, args[0], outputPath)
+	case "filter":
+		if len(args) != 1 {
+			log.Fatal("usage: nlm filter <file|->")
+		}
+		err = previewFilter(args[0])
+	case "estimate":
+		if len(args) == 0 {
+			log.Fatal("usage: nlm estimate <path>... [-max-sources n]")
+		}
+		err = estimateCorpus(args)
+	case "graph":
+		if len(args) != 1 {
+			log.Fatal("usage: nlm graph <notebook-id> [-o graph.dot|graph.json]")
+		}
+		err = citationGraph(client, args[0], outputPath)
+	case "backup":
+		err = backup(client, outputPath, args)
+	case "import":
+		if len(args) != 1 {
+			log.Fatal("usage: nlm import <archive.zip>\n       nlm import (-pocket | -instapaper <export.csv>) <notebook-id> [-tag tags] [-fetch]\n       nlm import -takeout <takeout.zip> <notebook-id> [-as notes|sources] [-tag labels]\n       nlm import -confluence -space <key> <notebook-id>\n       nlm import -sharepoint <site|me> [-library Documents] <notebook-id>")
+		}
+		switch {
+		case importSharePoint != "":
+			err = importSharePointLibrary(client, args[0])
+		case importConfluence:
+			err = importConfluenceSpace(client, args[0])
+		case importTakeout != "":
+			err = importTakeoutArchive(client, args[0])
+		case importPocket || importInstapaper != "":
+			err = importReadLater(client, args[0])
+		default:
+			err = importArchive(client, args[0])
+		}
 
 	// Other operations
+	case "batch":
+		if len(args) != 1 || args[0] != "-" {
+			log.Fatal("usage: nlm batch - [-workers 4] < ops.jsonl")
+		}
+		err = runBatch(client, os.Stdin)
+	case "run":
+		if len(args) != 1 {
+			log.Fatal("usage: nlm run <workflow.yaml> [-set key=value] [-resume] [-json]")
+		}
+		err = runWorkflow(client, args[0])
+	case "changes":
+		if len(args) != 1 {
+			log.Fatal("usage: nlm changes <notebook-id> [-since 7d] [-json]")
+		}
+		err = changes(client, args[0])
+	case "notebooks":
+		switch {
+		case len(args) == 3 && args[0] == "settings" && args[1] == "get":
+			err = showNotebookSettings(client, args[2])
+		case len(args) >= 2 && args[0] == "defaults":
+			err = showNotebookDefaults(args[1], args[2:])
+		default:
+			log.Fatal("usage: nlm notebooks settings get <notebook-id> [-json]\n       nlm notebooks defaults <notebook-id> [<flag>=<value>...]")
+		}
+	case "share":
+		if len(args) >= 1 && args[0] == "report" {
+			err = shareReport(client, args[1:])
+			break
+		}
+		if len(args) >= 1 && args[0] == "enforce" {
+			err = shareEnforce(client, args[1:])
+			break
+		}
+		if len(args) != 2 || args[0] != "bulk" || shareCSV == "" {
+			log.Fatal("usage: nlm share bulk <notebook-id> -csv <roster.csv> [-notify=false]\n       nlm share report <notebook-id>... | -all [-domain example.com] [-no-public] [-json]\n       nlm share enforce <notebook-id>... | -all [-domain example.com] [-no-public] [-apply]")
+		}
+		err = shareBulk(client, args[1], shareCSV)
 	// case "analytics":
 	// 	if len(args) != 1 {
 	// 		log.Fatal("usage: nlm analytics <notebook-id>")
@@ -243,7 +446,61 @@ func runCmd(client *api.Client, cmd string, args ...string) error {
 	// 	err = submitFeedback(client, args[0])
 	case "auth":
 		_, _, err = handleAuth(args, debug)
+	case "setup":
+		err = setup()
+	case "jobs":
+		err = runJobs(args)
+	case "headers":
+		err = runHeaders(args)
+	case "debug":
+		err = runDebug(args)
+	case "endpoints":
+		if len(args) != 0 {
+			log.Fatal("usage: nlm endpoints")
+		}
+		err = runEndpoints()
+	case "help":
+		err = runHelp(args)
+	case "examples":
+		if len(args) > 1 {
+			log.Fatal("usage: nlm examples [command]")
+		}
+		err = printExamples(client, args)
+
+	case "version":
+		err = printVersion()
+	case "self-update":
+		err = selfUpdate()
+	case "bugreport":
+		err = bugreport(outputPath)
+	case "config":
+		err = runConfig(args)
+	case "pin", "unpin":
+		if len(args) == 0 {
+			log.Fatalf("usage: nlm %s <notebook-id>...", cmd)
+		}
+		err = setPinned(client, args, cmd == "pin")
+	case "quota":
+		err = runQuota(args)
+	case "stats":
+		if !statsUsage || len(args) != 0 {
+			log.Fatal("usage: nlm stats -usage [-since 7d] [-json]")
+		}
+		err = showUsageStats()
+	case "daemon":
+		err = runDaemon(args)
 
+	case "canary":
+		if len(args) != 0 {
+			log.Fatal("usage: nlm canary")
+		}
+		err = canary(client)
+	case "fake":
+		err = runFake(args)
+	case "trash":
+		err = runTrash(client, args)
+	case "features":
+		err = listFeatures(client)
 	case "hb":
 		err = heartbeat(client)
 	default:
@@ -251,24 +508,44 @@ func runCmd(client *api.Client, cmd string, args ...string) error {
 		os.Exit(1)
 	}
 
-	return err
+	return explainUnavailable(cmd, err)
 }
 
 // Notebook operations
 func list(c *api.Client) error {
-	notebooks, err := c.ListRecentlyViewedProjects()
+	cutoff, err := listCutoff(time.Now())
+	if err != nil {
+		return fmt.Errorf("list: %w", err)
+	}
+	// The list is the cheapest way to see changes made elsewhere, so it
+	// is always fetched; the cache it refreshes serves the other commands.
+	notebooks, err := loadMetadata().notebooks(c, 0)
 	if err != nil {
 		return err
 	}
-	w := tabwriter.NewWriter(os.Stdout, 0, 4, 4, ' ', 0)
-	fmt.Fprintln(w, "ID\tTITLE\tLAST UPDATED")
-	for _, nb := range notebooks {
-		fmt.Fprintf(w, "%s\t%s\t%s\n",
-			nb.ProjectId, strings.TrimSpace(nb.Emoji)+" "+nb.Title,
-			nb.GetMetadata().GetCreateTime().AsTime().Format(time.RFC3339),
-		)
+	if !cutoff.IsZero() {
+		notebooks = modifiedSince(notebooks, cutoff)
 	}
-	return w.Flush()
+	pins = loadPins()
+	if listPinned {
+		notebooks = pinnedOnly(notebooks)
+	}
+	if notebooks, err = sortNotebooks(notebooks); err != nil {
+		return fmt.Errorf("list: %w", err)
+	}
+	if idsOnly {
+		for _, nb := range notebooks {
+			fmt.Println(nb.GetProjectId())
+		}
+		return nil
+	}
+	if templateFile != "" {
+		return renderTemplate(os.Stdout, templateFile, notebooks)
+	}
+	if err := printListing(records(notebooks, notebookRecord), notebookFields, listing.ID, listing.Title, listing.Sources, listing.Modified); err != nil {
+		return fmt.Errorf("list: %w", err)
+	}
+	return nil
 }
 
 func create(c *api.Client, title string) error {
@@ -281,85 +558,121 @@ func create(c *api.Client, title string) error {
 }
 
 func remove(c *api.Client, id string) error {
-	fmt.Printf("Are you sure you want to delete notebook %s? [y/N] ", id)
-	var response string
-	fmt.Scanln(&response)
-	if !strings.HasPrefix(strings.ToLower(response), "y") {
-		return fmt.Errorf("operation cancelled")
+	if !assumeYes {
+		fmt.Printf("Are you sure you want to delete notebook %s? [y/N] ", id)
+		var response string
+		fmt.Scanln(&response)
+		if !strings.HasPrefix(strings.ToLower(response), "y") {
+			return errinfo.ErrDeclined
+		}
+	}
+	if err := deleteNotebook(c, id); err != nil {
+		return fmt.Errorf("rm: %w", err)
 	}
-	return c.DeleteProjects([]string{id})
+	return nil
 }
 
 // Source operations
 func listSources(c *api.Client, notebookID string) error {
-	p, err := c.GetProject(notebookID)
+	p, err := loadMetadata().project(c, notebookID)
 	if err != nil {
 		return fmt.Errorf("list sources: %w", err)
 	}
-
-	w := tabwriter.NewWriter(os.Stdout, 0, 4, 4, ' ', 0)
-	fmt.Fprintln(w, "ID\tTITLE\tTYPE\tSTATUS\tLAST UPDATED")
-	for _, src := range p.Sources {
-		status := "enabled"
-		if src.Settings != nil {
-			status = src.Settings.Status.String()
-		}
-
-		lastUpdated := "unknown"
-		if src.Metadata != nil && src.Metadata.LastModifiedTime != nil {
-			lastUpdated = src.Metadata.LastModifiedTime.AsTime().Format(time.RFC3339)
+	sources, err := sortSources(p.Sources)
+	if err != nil {
+		return fmt.Errorf("list sources: %w", err)
+	}
+	if idsOnly {
+		for _, src := range sources {
+			fmt.Println(src.GetSourceId().GetSourceId())
 		}
+		return nil
+	}
+	if templateFile != "" {
+		return renderTemplate(os.Stdout, templateFile, sources)
+	}
 
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
-			src.SourceId.GetSourceId(),
-			strings.TrimSpace(src.Title),
-			src.Metadata.GetSourceType(),
-			status,
-			lastUpdated,
-		)
+	if err := printListing(records(sources, sourceRecord), sourceFields, listing.ID, listing.Title, listing.Type, listing.Status, listing.Modified); err != nil {
+		return fmt.Errorf("list sources: %w", err)
 	}
-	return w.Flush()
+	return nil
 }
 
 func addSource(c *api.Client, notebookID, input string) (string, error) {
+	p, err := ingestPipeline()
+	if err != nil {
+		return "", err
+	}
+
 	// Handle special input designators
 	switch input {
 	case "-": // stdin
 		fmt.Fprintln(os.Stderr, "Reading from stdin...")
-		return c.AddSourceFromReader(notebookID, os.Stdin, "Pasted Text")
+		if p.Empty() {
+			return c.AddSourceFromReader(notebookID, os.Stdin, "Pasted Text")
+		}
+		text, err := filterReader(p, "stdin", os.Stdin)
+		if err != nil {
+			return "", err
+		}
+		return c.AddSourceFromText(notebookID, text, "Pasted Text")
 	case "": // empty input
 		return "", fmt.Errorf("input required (file, URL, or '-' for stdin)")
 	}
 
 	// Check if input is a URL
 	if strings.HasPrefix(input, "http://") || strings.HasPrefix(input, "https://") {
-		fmt.Printf("Adding source from URL: %s\n", input)
+		fmt.Fprintf(os.Stderr, "Adding source from URL: %s\n", input)
 		return c.AddSourceFromURL(notebookID, input)
 	}
 
 	// Try as local file
 	if _, err := os.Stat(input); err == nil {
-		fmt.Printf("Adding source from file: %s\n", input)
-		return c.AddSourceFromFile(notebookID, input)
+		fmt.Fprintf(os.Stderr, "Adding source from file: %s\n", input)
+		text, ok, err := fileText(p, input)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			id, err := c.AddSourceFromFile(notebookID, input)
+			if err != nil && transcribe.IsAudio(input) {
+				return "", fmt.Errorf("%w (retry with -transcribe-locally to upload a local transcript instead)", err)
+			}
+			return id, err
+		}
+		title := input
+		if ocrImages && ocr.IsImage(input) || transcribeLocal && transcribe.IsAudio(input) {
+			title = filepath.Base(input)
+		}
+		return c.AddSourceFromText(notebookID, text, title)
 	}
 
 	// If it's not a URL or file, treat as direct text content
-	fmt.Println("Adding text content as source...")
-	return c.AddSourceFromText(notebookID, input, "Text Source")
+	fmt.Fprintln(os.Stderr, "Adding text content as source...")
+	text, _ := filterText(p, "text", input)
+	return c.AddSourceFromText(notebookID, text, "Text Source")
 }
 
-func removeSource(c *api.Client, notebookID, sourceID string) error {
-	fmt.Printf("Are you sure you want to remove source %s? [y/N] ", sourceID)
-	var response string
-	fmt.Scanln(&response)
-	if !strings.HasPrefix(strings.ToLower(response), "y") {
-		return fmt.Errorf("operation cancelled")
+func removeSources(c *api.Client, notebookID string, sourceIDs []string) error {
+	if !assumeYes {
+		what := "source " + sourceIDs[0]
+		if len(sourceIDs) > 1 {
+			what = strconv.Itoa(len(sourceIDs)) + " sources"
+		}
+		fmt.Printf("Are you sure you want to remove %s? [y/N] ", what)
+		var response string
+		fmt.Scanln(&response)
+		if !strings.HasPrefix(strings.ToLower(response), "y") {
+			return errinfo.ErrDeclined
+		}
 	}
 
-	if err := c.DeleteSources(notebookID, []string{sourceID}); err != nil {
+	if err := deleteSources(c, notebookID, sourceIDs); err != nil {
 		return fmt.Errorf("remove source: %w", err)
 	}
-	fmt.Printf("✅ Removed source %s from notebook %s\n", sourceID, notebookID)
+	for _, id := range sourceIDs {
+		fmt.Printf("✅ Removed source %s from notebook %s\n", id, notebookID)
+	}
 	return nil
 }
 
@@ -399,7 +712,7 @@ func removeNote(c *api.Client, notebookID, noteID string) error {
 	var response string
 	fmt.Scanln(&response)
 	if !strings.HasPrefix(strings.ToLower(response), "y") {
-		return fmt.Errorf("operation cancelled")
+		return errinfo.ErrDeclined
 	}
 
 	if err := c.DeleteNotes(notebookID, []string{noteID}); err != nil {
@@ -440,17 +753,23 @@ func listNotes(c *api.Client, notebookID string) error {
 	if err != nil {
 		return fmt.Errorf("list notes: %w", err)
 	}
+	if notes, err = sortNotes(notes); err != nil {
+		return fmt.Errorf("list notes: %w", err)
+	}
+	if idsOnly {
+		for _, note := range notes {
+			fmt.Println(note.GetSourceId().GetSourceId())
+		}
+		return nil
+	}
+	if templateFile != "" {
+		return renderTemplate(os.Stdout, templateFile, notes)
+	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 4, 4, ' ', 0)
-	fmt.Fprintln(w, "ID\tTITLE\tLAST MODIFIED")
-	for _, note := range notes {
-		fmt.Fprintf(w, "%s\t%s\t%s\n",
-			note.GetSourceId(),
-			note.Title,
-			note.GetMetadata().LastModifiedTime.AsTime().Format(time.RFC3339),
-		)
+	if err := printListing(records(notes, noteRecord), noteFields, listing.ID, listing.Title, listing.Modified); err != nil {
+		return fmt.Errorf("list notes: %w", err)
 	}
-	return w.Flush()
+	return nil
 }
 
 func editNote(c *api.Client, notebookID, noteID, content string) error {
@@ -471,6 +790,11 @@ func getAudioOverview(c *api.Client, projectID string) error {
 	if err != nil {
 		return fmt.Errorf("get audio overview: %w", err)
 	}
+	if !result.IsReady && waitDone {
+		if result, err = waitForAudio(c, projectID); err != nil {
+			return fmt.Errorf("get audio overview: %w", err)
+		}
+	}
 
 	if !result.IsReady {
 		fmt.Println("Audio overview is not ready yet. Try again in a few moments.")
@@ -479,18 +803,13 @@ func getAudioOverview(c *api.Client, projectID string) error {
 
 	fmt.Printf("Audio Overview:\n")
 	fmt.Printf("  Title: %s\n", result.Title)
-	fmt.Printf("  ID: %s\n", result.AudioID)
+	fmt.Printf("  ID: %s\n", result.AudioId)
 	fmt.Printf("  Ready: %v\n", result.IsReady)
 
 	// Optionally save the audio file
 	if result.AudioData != "" {
-		audioData, err := result.GetAudioBytes()
-		if err != nil {
-			return fmt.Errorf("decode audio data: %w", err)
-		}
-
-		filename := fmt.Sprintf("audio_overview_%s.wav", result.AudioID)
-		if err := os.WriteFile(filename, audioData, 0644); err != nil {
+		filename := outputFilename(fmt.Sprintf("audio_overview_%s.wav", result.AudioId))
+		if err := saveAudio(result, filename); err != nil {
 			return fmt.Errorf("save audio file: %w", err)
 		}
 		fmt.Printf("  Saved audio to: %s\n", filename)
@@ -504,7 +823,7 @@ func deleteAudioOverview(c *api.Client, notebookID string) error {
 	var response string
 	fmt.Scanln(&response)
 	if !strings.HasPrefix(strings.ToLower(response), "y") {
-		return fmt.Errorf("operation cancelled")
+		return errinfo.ErrDeclined
 	}
 
 	if err := c.DeleteAudioOverview(notebookID); err != nil {
@@ -520,7 +839,7 @@ func shareAudioOverview(c *api.Client, notebookID string) error {
 	if err != nil {
 		return fmt.Errorf("share audio: %w", err)
 	}
-	fmt.Printf("Share URL: %s\n", resp.ShareURL)
+	fmt.Printf("Share URL: %s\n", resp.ShareUrl)
 	return nil
 }
 
@@ -582,7 +901,13 @@ func createAudioOverview(c *api.Client, projectID string, instructions string) e
 	if err != nil {
 		return fmt.Errorf("create audio overview: %w", err)
 	}
+	checkpointJob(phaseSubmitted)
 
+	if !result.IsReady && waitDone {
+		if result, err = waitForAudio(c, projectID); err != nil {
+			return fmt.Errorf("create audio overview: %w", err)
+		}
+	}
 	if !result.IsReady {
 		fmt.Println("✅ Audio overview creation started. Use 'nlm audio-get' to check status.")
 		return nil
@@ -591,17 +916,12 @@ func createAudioOverview(c *api.Client, projectID string, instructions string) e
 	// If the result is immediately ready (unlikely but possible)
 	fmt.Printf("✅ Audio Overview created:\n")
 	fmt.Printf("  Title: %s\n", result.Title)
-	fmt.Printf("  ID: %s\n", result.AudioID)
+	fmt.Printf("  ID: %s\n", result.AudioId)
 
 	// Save audio file if available
 	if result.AudioData != "" {
-		audioData, err := result.GetAudioBytes()
-		if err != nil {
-			return fmt.Errorf("decode audio data: %w", err)
-		}
-
-		filename := fmt.Sprintf("audio_overview_%s.wav", result.AudioID)
-		if err := os.WriteFile(filename, audioData, 0644); err != nil {
+		filename := outputFilename(fmt.Sprintf("audio_overview_%s.wav", result.AudioId))
+		if err := saveAudio(result, filename); err != nil {
 			return fmt.Errorf("save audio file: %w", err)
 		}
 		fmt.Printf("  Saved audio to: %s\n", filename)
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tmc/nlm/internal/config"
+	"github.com/tmc/nlm/internal/errinfo"
+	"github.com/tmc/nlm/internal/validate"
+)
+
+// notebookDefaultsFile holds the flags remembered for each notebook.
+const notebookDefaultsFile = "notebook-defaults.json"
+
+// rememberFlags saves the notebook flags given to a command for its
+// notebook.
+var rememberFlags bool
+
+func init() {
+	flag.BoolVar(&rememberFlags, "remember", false, "remember the -o, -sort, -columns, -output and -template given for the command's notebook, to use when they are not given")
+}
+
+// commandNotebook returns the notebook a command works on, or "" if its
+// first argument is not a single notebook.
+func commandNotebook(cmd string, args []string) string {
+	if cmd == "notebooks" {
+		return ""
+	}
+	spec, ok := argumentSpecs[cmd]
+	if len(args) > 0 {
+		if s, ok2 := argumentSpecs[cmd+" "+args[0]]; ok2 {
+			spec, ok, args = s, true, args[1:]
+		}
+	}
+	if !ok || spec[0] != "notebook" || len(args) == 0 || validate.ID("notebook", args[0]) != nil {
+		return ""
+	}
+	return args[0]
+}
+
+// applyNotebookDefaults sets the flags remembered for the command's
+// notebook that were not given on the command line, so they win over the
+// environment and the global configuration but not over flags. With
+// -remember, the notebook flags given are saved first.
+func applyNotebookDefaults(cmd string, args []string) error {
+	id := commandNotebook(cmd, args)
+	if id == "" {
+		if rememberFlags {
+			return errinfo.Usagef("-remember: %s takes no notebook to remember flags for", cmd)
+		}
+		return nil
+	}
+	given := make(map[string]string)
+	flag.Visit(func(f *flag.Flag) {
+		switch {
+		case f.Name == "json" && jsonOutput:
+			// -json is -output json.
+			given["output"] = "json"
+		case isNotebookFlag(f.Name):
+			given[f.Name] = f.Value.String()
+		}
+	})
+
+	var d config.NotebookDefaults
+	st, err := openState()
+	if err == nil {
+		err = st.Load(notebookDefaultsFile, &d)
+	}
+	if err != nil {
+		// The remembered flags are a convenience, unless asked to save them.
+		if rememberFlags {
+			return fmt.Errorf("-remember: %w", err)
+		}
+		return nil
+	}
+	if rememberFlags && len(given) > 0 {
+		for name, value := range given {
+			if err := d.Set(id, name, absPath(name, value)); err != nil {
+				return fmt.Errorf("-remember: %w", err)
+			}
+		}
+		if err := st.Save(notebookDefaultsFile, &d); err != nil {
+			return fmt.Errorf("-remember: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Remembered %s for notebook %s\n", config.FormatFlags(given), id)
+	}
+
+	applied := make(map[string]string)
+	for name, value := range d.Flags(id) {
+		if _, ok := given[name]; ok {
+			continue
+		}
+		if err := flag.Set(name, value); err != nil {
+			return fmt.Errorf("remembered flags of %s: -%s: %w", id, name, err)
+		}
+		applied[name] = value
+	}
+	if debug && len(applied) > 0 {
+		fmt.Fprintf(os.Stderr, "nlm: using the flags remembered for notebook %s: %s\n", id, config.FormatFlags(applied))
+	}
+	return nil
+}
+
+// showNotebookDefaults prints the flags remembered for a notebook, or
+// changes them with name=value assignments; an empty value forgets a flag.
+func showNotebookDefaults(notebookID string, assignments []string) error {
+	st, err := openState()
+	if err != nil {
+		return fmt.Errorf("defaults: %w", err)
+	}
+	var d config.NotebookDefaults
+	if err := st.Load(notebookDefaultsFile, &d); err != nil {
+		return fmt.Errorf("defaults: %w", err)
+	}
+	if len(assignments) == 0 {
+		flags := d.Flags(notebookID)
+		if len(flags) == 0 {
+			fmt.Fprintf(os.Stderr, "No flags remembered for notebook %s.\n", notebookID)
+			return nil
+		}
+		fmt.Println(config.FormatFlags(flags))
+		return nil
+	}
+	for _, a := range assignments {
+		name, value, ok := strings.Cut(a, "=")
+		if !ok {
+			return errinfo.Usagef("defaults: %q is not name=value", a)
+		}
+		if err := d.Set(notebookID, name, absPath(name, value)); err != nil {
+			return fmt.Errorf("defaults: %w", err)
+		}
+	}
+	if err := st.Save(notebookDefaultsFile, &d); err != nil {
+		return fmt.Errorf("defaults: %w", err)
+	}
+	if flags := d.Flags(notebookID); len(flags) > 0 {
+		fmt.Fprintf(os.Stderr, "Remembered for notebook %s: %s\n", notebookID, config.FormatFlags(flags))
+	} else {
+		fmt.Fprintf(os.Stderr, "No flags remembered for notebook %s\n", notebookID)
+	}
+	return nil
+}
+
+// absPath makes the value of a path flag, such as -o or -template,
+// absolute, so that when remembered it names the same file whatever
+// directory nlm is run in. Other flags are returned as they are.
+func absPath(name, value string) string {
+	name = strings.TrimLeft(name, "-")
+	for _, f := range config.NotebookPathFlags {
+		if f == name && value != "" {
+			if abs, err := filepath.Abs(value); err == nil {
+				return abs
+			}
+		}
+	}
+	return value
+}
+
+func isNotebookFlag(name string) bool {
+	for _, f := range config.NotebookFlags {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestNotebookDefaultsPaths(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("NLM_HOME", filepath.Join(dir, "home"))
+	stateOnce = sync.Once{}
+	t.Cleanup(func() { stateOnce = sync.Once{} })
+	defer func(tmpl, sort, out string) { templateFile, listSort, outputPath = tmpl, sort, out }(templateFile, listSort, outputPath)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	project, elsewhere := filepath.Join(dir, "project"), filepath.Join(dir, "elsewhere")
+	for _, d := range []string{project, elsewhere} {
+		if err := os.Mkdir(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const id = "12345678-1234-4234-8234-123456789abc"
+	if err := os.Chdir(project); err != nil {
+		t.Fatal(err)
+	}
+	if err := showNotebookDefaults(id, []string{"template=list.tmpl", "-o=exports", "sort=title"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(elsewhere); err != nil {
+		t.Fatal(err)
+	}
+	templateFile, listSort, outputPath = "", "", ""
+	if err := applyNotebookDefaults("sources", []string{id}); err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(project, "list.tmpl"); templateFile != want {
+		t.Errorf("-template = %q, want %q", templateFile, want)
+	}
+	if want := filepath.Join(project, "exports"); outputPath != want {
+		t.Errorf("-o = %q, want %q", outputPath, want)
+	}
+	if listSort != "title" {
+		t.Errorf("-sort = %q, want title", listSort)
+	}
+}
+
+func TestAbsPath(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct{ name, value, want string }{
+		{"o", "out", filepath.Join(wd, "out")},
+		{"-template", "t.tmpl", filepath.Join(wd, "t.tmpl")},
+		{"template", "", ""},
+		{"columns", "id,title", "id,title"},
+	} {
+		if got := absPath(tt.name, tt.value); got != tt.want {
+			t.Errorf("absPath(%q, %q) = %q, want %q", tt.name, tt.value, got, tt.want)
+		}
+	}
+}
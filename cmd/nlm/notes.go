@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/cleanup"
+	"github.com/tmc/nlm/internal/merge"
+)
+
+// noteRevision is a note as fetched at one point in time.
+type noteRevision struct {
+	Title   string
+	Content string
+}
+
+// changedSince reports whether the note differs from an earlier revision.
+func (r *noteRevision) changedSince(old *noteRevision) bool {
+	return r.Content != old.Content || r.Title != old.Title
+}
+
+func fetchNote(c *api.Client, notebookID, noteID string) (*noteRevision, error) {
+	notes, err := c.GetNotesRaw(notebookID)
+	if err != nil {
+		return nil, err
+	}
+	for _, raw := range notes {
+		id, title, content, err := api.NoteContent(raw)
+		if err != nil {
+			// Without the content at its known position, saving the
+			// edit could overwrite the note with the wrong text.
+			return nil, err
+		}
+		if id != noteID {
+			continue
+		}
+		return &noteRevision{Title: title, Content: content}, nil
+	}
+	return nil, fmt.Errorf("note %s not found in notebook %s", noteID, notebookID)
+}
+
+// editNoteInEditor opens a note in $VISUAL or $EDITOR and saves the result.
+// If the note was changed in the notebook while it was being edited, both
+// sets of changes are merged; when they overlap, the local version is
+// saved as a separate conflict copy instead of overwriting the note.
+func editNoteInEditor(c *api.Client, notebookID, noteID string) error {
+	base, err := fetchNote(c, notebookID, noteID)
+	if err != nil {
+		return fmt.Errorf("edit note: %w", err)
+	}
+	edited, err := runEditor(base.Content)
+	if err != nil {
+		return fmt.Errorf("edit note: %w", err)
+	}
+	if edited == base.Content {
+		fmt.Fprintln(os.Stderr, "No changes.")
+		return nil
+	}
+
+	cur, err := fetchNote(c, notebookID, noteID)
+	if err != nil {
+		return fmt.Errorf("edit note: %w", err)
+	}
+	content := edited
+	if cur.changedSince(base) {
+		merged, conflicts := merge.Merge(base.Content, edited, cur.Content)
+		if conflicts > 0 {
+			return saveConflictCopy(c, notebookID, noteID, base.Title, edited, merged, conflicts)
+		}
+		fmt.Fprintln(os.Stderr, "Note was changed in the notebook while editing; merged both sets of changes.")
+		content = merged
+	}
+	if _, err := c.MutateNote(notebookID, noteID, content, cur.Title); err != nil {
+		return fmt.Errorf("edit note: %w", err)
+	}
+	fmt.Printf("✅ Updated note: %s\n", cur.Title)
+	return nil
+}
+
+// saveConflictCopy keeps the local edit of a note as a new note and writes
+// the three-way merge, with conflict markers, to a local file.
+func saveConflictCopy(c *api.Client, notebookID, noteID, title, edited, merged string, conflicts int) error {
+	copyTitle := fmt.Sprintf("%s (conflict copy %s)", title, time.Now().Format("2006-01-02 15:04"))
+	if _, err := c.CreateNote(notebookID, copyTitle, edited); err != nil {
+		return fmt.Errorf("edit note: save conflict copy: %w", err)
+	}
+	path := filepath.Join(os.TempDir(), outputFilename(noteID+".merge.md"))
+	if err := os.WriteFile(path, []byte(merged), 0o600); err != nil {
+		return fmt.Errorf("edit note: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Three-way merge with %d conflict(s) written to %s\n", conflicts, path)
+	return fmt.Errorf("note %s was changed in the notebook while editing; your version was saved as %q", noteID, copyTitle)
+}
+
+// runEditor lets the user edit text in $VISUAL or $EDITOR (default vi)
+// and returns the result.
+func runEditor(text string) (string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	f, err := cleanup.CreateTemp("", "nlm-note-*.md")
+	if err != nil {
+		return "", err
+	}
+	defer cleanup.Remove(f.Name())
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	args := append(strings.Fields(editor), f.Name())
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s: %w", editor, err)
+	}
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	pb "github.com/tmc/nlm/gen/notebooklm/v1alpha1"
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/errinfo"
+	"github.com/tmc/nlm/internal/filename"
+	"github.com/tmc/nlm/internal/listing"
+	"github.com/tmc/nlm/internal/table"
+	"golang.org/x/term"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Output flags
+var (
+	templateFile   string
+	noColor        bool
+	wrapCells      bool
+	filenameForm   filename.Form
+	filenamePolicy filename.Policy
+	idsOnly        bool
+	modifiedWithin string
+	listSort       string
+	listFilters    listing.Filters
+	listColumns    string
+	outputFormat   string
+)
+
+func init() {
+	flag.StringVar(&templateFile, "template", "", "render list output with the Go text/template in `file`")
+	flag.BoolVar(&noColor, "no-color", false, "disable colored output (or set NO_COLOR)")
+	flag.BoolVar(&wrapCells, "wrap", false, "wrap long table cells instead of truncating them")
+	flag.Var(&filenameForm, "filename-form", "unicode normalization for file names derived from titles: nfc, nfd, nfkc or ascii")
+	flag.BoolVar(&idsOnly, "ids", false, "with list, sources and notes, print only IDs, one per line")
+	flag.StringVar(&modifiedWithin, "modified-within", "", "with list, only show notebooks modified within this long, such as 36h or 7d")
+	flag.StringVar(&listSort, "sort", "", "with list, sources and notes, sort by title, created, modified or sources")
+	flag.StringVar(&outputFormat, "output", "", "with list, sources, notes or share report, print a table, json or csv")
+	flag.StringVar(&listColumns, "columns", "", "with list, sources and notes, the columns to print, such as id,title,modified")
+	flag.Var(&listFilters, "filter", "with list, sources and notes, only show items matching `field=value` or field~=regexp on id, title or type (repeatable)")
+	flag.Var(&filenamePolicy, "filename-policy", "sanitization for file names written to disk: preserve-unicode, slugify or windows-safe")
+}
+
+// listCutoff returns the time of the oldest change list shows, from
+// -modified-within or an explicit -since, or zero to show all notebooks.
+// With both, the later time applies.
+func listCutoff(now time.Time) (time.Time, error) {
+	var cutoff time.Time
+	if modifiedWithin != "" {
+		t, err := parseSince(modifiedWithin, now)
+		if err != nil {
+			return time.Time{}, errinfo.Usagef("invalid -modified-within %q (want a duration like 7d)", modifiedWithin)
+		}
+		cutoff = t
+	}
+	sinceSet := false
+	flag.Visit(func(f *flag.Flag) { sinceSet = sinceSet || f.Name == "since" })
+	if sinceSet {
+		t, err := parseSince(sinceFlag, now)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if t.After(cutoff) {
+			cutoff = t
+		}
+	}
+	return cutoff, nil
+}
+
+// modifiedSince returns the notebooks last modified at or after t. A
+// notebook with no reported modification time is judged by when it was
+// created.
+func modifiedSince(notebooks []*api.Notebook, t time.Time) []*api.Notebook {
+	var out []*api.Notebook
+	for _, nb := range notebooks {
+		ts := nb.GetMetadata().GetModifiedTime()
+		if ts == nil {
+			ts = nb.GetMetadata().GetCreateTime()
+		}
+		if ts != nil && !ts.AsTime().Before(t) {
+			out = append(out, nb)
+		}
+	}
+	return out
+}
+
+// The fields of notebooks, sources and notes, for -sort, -filter and
+// -columns.
+var (
+	notebookFields = []string{listing.ID, listing.Title, listing.Emoji, listing.Sources, listing.Created, listing.Modified, listing.Pinned}
+	sourceFields   = []string{listing.ID, listing.Title, listing.Type, listing.Status, listing.Modified}
+	noteFields     = []string{listing.ID, listing.Title, listing.Modified}
+)
+
+func notebookRecord(nb *api.Notebook) listing.Record {
+	md := nb.GetMetadata()
+	r := listing.Record{
+		ID:      nb.GetProjectId(),
+		Title:   nb.GetTitle(),
+		Emoji:   nb.GetEmoji(),
+		Sources: len(nb.GetSources()),
+		Pinned:  pins[nb.GetProjectId()],
+	}
+	if ts := md.GetCreateTime(); ts != nil {
+		r.Created = ts.AsTime()
+	}
+	if ts := md.GetModifiedTime(); ts != nil {
+		r.Modified = ts.AsTime()
+	}
+	return r
+}
+
+// sourceRecord describes a source. Its type is the name of its source
+// type, such as SOURCE_TYPE_PDF.
+func sourceRecord(src *pb.Source) listing.Record {
+	r := listing.Record{
+		ID:     src.GetSourceId().GetSourceId(),
+		Title:  strings.TrimSpace(src.GetTitle()),
+		Type:   src.GetMetadata().GetSourceType().String(),
+		Status: "enabled",
+	}
+	if src.GetSettings() != nil {
+		r.Status = src.GetSettings().GetStatus().String()
+	}
+	if ts := src.GetMetadata().GetLastModifiedTime(); ts != nil {
+		r.Modified = ts.AsTime()
+	}
+	return r
+}
+
+func noteRecord(n *api.Note) listing.Record {
+	r := listing.Record{ID: n.GetSourceId().GetSourceId(), Title: n.GetTitle()}
+	if ts := n.GetMetadata().GetLastModifiedTime(); ts != nil {
+		r.Modified = ts.AsTime()
+	}
+	return r
+}
+
+// sortNotebooks applies -sort and -filter to notebooks.
+func sortNotebooks(notebooks []*api.Notebook) ([]*api.Notebook, error) {
+	out, err := listing.Apply(notebooks, notebookRecord, listSort, listFilters, notebookFields...)
+	return out, errinfo.Usage(err)
+}
+
+// sortSources applies -sort and -filter to sources.
+func sortSources(sources []*pb.Source) ([]*pb.Source, error) {
+	out, err := listing.Apply(sources, sourceRecord, listSort, listFilters, sourceFields...)
+	return out, errinfo.Usage(err)
+}
+
+// sortNotes applies -sort and -filter to notes.
+func sortNotes(notes []*api.Note) ([]*api.Note, error) {
+	out, err := listing.Apply(notes, noteRecord, listSort, listFilters, noteFields...)
+	return out, errinfo.Usage(err)
+}
+
+// records describes items for printListing.
+func records[T any](items []T, record func(T) listing.Record) []listing.Record {
+	out := make([]listing.Record, len(items))
+	for i, it := range items {
+		out[i] = record(it)
+	}
+	return out
+}
+
+// printListing prints the records of a list command as a table of the
+// -columns, or of the default columns def, or with -json as a JSON array
+// of objects holding the -columns, or all fields. -output csv prints the
+// table as CSV, with the values of the JSON output.
+func printListing(recs []listing.Record, fields []string, def ...string) error {
+	if jsonOutput {
+		def = fields
+	}
+	cols, err := listing.Columns(listColumns, fields, def...)
+	if err != nil {
+		return errinfo.Usage(err)
+	}
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(listing.Objects(cols, recs))
+	}
+	t := newTable(listing.Headers(cols)...)
+	if outputFormat == "csv" {
+		listing.AppendValues(t, cols, recs)
+	} else {
+		listing.Append(t, cols, recs)
+	}
+	return renderTable(t)
+}
+
+// outputFilename returns the name to use when writing a file derived from
+// titles or IDs: normalized according to -filename-form and made safe as a
+// single path element according to -filename-policy.
+func outputFilename(name string) string {
+	return filename.Sanitize(filename.Normalize(name, filenameForm), filenamePolicy)
+}
+
+// applyOutputFormat checks -output and applies it to -json, which it
+// overrides unless -json was also given.
+func applyOutputFormat() error {
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	switch outputFormat {
+	case "", "table":
+		if set["output"] && !set["json"] {
+			jsonOutput = false
+		}
+	case "json":
+		jsonOutput = true
+	case "csv":
+		if set["json"] && jsonOutput {
+			return errinfo.Usagef("-json and -output csv cannot be used together")
+		}
+		jsonOutput = false
+	default:
+		return errinfo.Usagef("invalid -output %q (want table, json or csv)", outputFormat)
+	}
+	return nil
+}
+
+// renderTable writes t to stdout, or with -output csv writes it as CSV.
+func renderTable(t *table.Table) error {
+	if outputFormat == "csv" {
+		return t.RenderCSV(os.Stdout)
+	}
+	return t.Render(os.Stdout)
+}
+
+// newTable returns a table sized to the terminal on stdout. When stdout is
+// not a terminal the table is neither truncated nor colored, so piped output
+// keeps full values.
+func newTable(headers ...string) *table.Table {
+	t := table.New(headers...)
+	t.Wrap = wrapCells
+	fd := int(os.Stdout.Fd())
+	if !term.IsTerminal(fd) {
+		return t
+	}
+	if width, _, err := term.GetSize(fd); err == nil {
+		t.Width = width
+	} else if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil {
+		t.Width = n
+	}
+	t.Color = useColor()
+	return t
+}
+
+// useColor reports whether ANSI colors should be emitted, honoring -no-color
+// and the NO_COLOR convention (https://no-color.org).
+func useColor() bool {
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return os.Getenv("TERM") != "dumb"
+}
+
+// templateFuncs are available to user supplied output templates.
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"time": func(ts *timestamppb.Timestamp) string {
+		if ts == nil {
+			return ""
+		}
+		return ts.AsTime().Format(time.RFC3339)
+	},
+	"trim":  strings.TrimSpace,
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// renderTemplate executes the template in path against data and writes the
+// result to w. The template sees the typed API values (e.g. []*api.Notebook),
+// so fields are addressed by their Go names: {{range .}}{{.ProjectId}}{{end}}.
+func renderTemplate(w io.Writer, path string, data interface{}) error {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read template: %w", err)
+	}
+	tmpl, err := template.New(filepath.Base(path)).Funcs(templateFuncs).Parse(string(text))
+	if err != nil {
+		return fmt.Errorf("parse template: %w", err)
+	}
+	if err := tmpl.Execute(w, data); err != nil {
+		return fmt.Errorf("execute template: %w", err)
+	}
+	return nil
+}
+
+// parseFlags parses fs from args while allowing flags to be interspersed with
+// positional arguments, so both "nlm -template t list" and
+// "nlm list -template t" work. A "--" argument ends flag parsing.
+// It returns the positional arguments in order.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/cleanup"
+	"github.com/tmc/nlm/internal/scholar"
+)
+
+// Paper flags
+var paperDOI string
+
+func init() {
+	flag.StringVar(&paperDOI, "doi", "", "with paper add, comma-separated `DOIs` of papers to add")
+}
+
+// paperState records the papers added to a notebook, by DOI.
+type paperState struct {
+	Papers map[string]addedPaper `json:"papers"`
+}
+
+type addedPaper struct {
+	Title    string    `json:"title"`
+	Abstract string    `json:"abstract_source_id"`
+	PDF      string    `json:"pdf_source_id,omitempty"`
+	AddedAt  time.Time `json:"added_at"`
+}
+
+// paperTitlePrefix starts the titles of the sources of a paper, so papers
+// added before are recognized.
+func paperTitlePrefix(doi string) string {
+	return "doi:" + doi + " "
+}
+
+// paperAdd resolves DOIs with Semantic Scholar and, if
+// NLM_UNPAYWALL_EMAIL is set, Unpaywall, and adds each paper to a
+// notebook: its metadata and abstract as a text source and its
+// open-access PDF, when one exists, as a second source. Papers already in
+// the notebook are skipped.
+func paperAdd(c *api.Client, notebookID string, dois []string) error {
+	for _, d := range strings.Split(paperDOI, ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			dois = append(dois, d)
+		}
+	}
+	if len(dois) == 0 {
+		return fmt.Errorf("paper: give DOIs with -doi")
+	}
+	for i, d := range dois {
+		doi, err := scholar.NormalizeDOI(d)
+		if err != nil {
+			return err
+		}
+		dois[i] = doi
+	}
+	st, err := openState()
+	if err != nil {
+		return fmt.Errorf("paper: %w", err)
+	}
+	name := filepath.Join("papers", notebookID+".json")
+	ps := &paperState{Papers: make(map[string]addedPaper)}
+	if err := st.Load(name, ps); err != nil {
+		return fmt.Errorf("paper: %w", err)
+	}
+	p, err := ingestPipeline()
+	if err != nil {
+		return fmt.Errorf("paper: %w", err)
+	}
+	nb, err := c.GetProject(notebookID)
+	if err != nil {
+		return fmt.Errorf("paper: %w", err)
+	}
+
+	ctx, stop := cleanup.NotifyContext(context.Background())
+	defer stop()
+	sc := &scholar.Client{
+		UserAgent: "nlm/" + buildVersion(),
+		Email:     settings.Services.UnpaywallEmail,
+		APIKey:    settings.Services.S2APIKey,
+	}
+	if sc.Email == "" {
+		fmt.Fprintln(os.Stderr, "note: set NLM_UNPAYWALL_EMAIL to also find open-access PDFs with Unpaywall")
+	}
+
+	var failed int
+	t := newTable("DOI", "TITLE", "RESULT")
+	for i, doi := range dois {
+		fmt.Fprintf(os.Stderr, "[%d/%d] %s\n", i+1, len(dois), doi)
+		if paperAdded(ps, nb, doi) {
+			t.Append(doi, ps.Papers[doi].Title, "skipped: already added")
+			continue
+		}
+		paper, err := sc.Resolve(ctx, doi)
+		if err != nil {
+			t.Append(doi, "", "error: "+err.Error())
+			failed++
+			continue
+		}
+		rec := addedPaper{Title: paper.Title}
+		title := paperTitlePrefix(doi) + "— " + paper.Title
+		text, _ := filterText(p, title, paperAbstract(paper))
+		if rec.Abstract, err = c.AddSourceFromText(notebookID, text, title+" (abstract)"); err != nil {
+			t.Append(doi, paper.Title, "error: "+err.Error())
+			failed++
+			continue
+		}
+		result := "added with PDF"
+		pdf, err := sc.PDF(ctx, paper)
+		if err == nil {
+			rec.PDF, err = c.AddSourceFromBase64(notebookID, base64.StdEncoding.EncodeToString(pdf), title+".pdf", "application/pdf")
+		}
+		switch {
+		case errors.Is(err, scholar.ErrNoPDF):
+			result = "added abstract only: no open-access PDF"
+		case err != nil:
+			result = "added abstract only; pdf: " + err.Error()
+		}
+		rec.AddedAt = time.Now().UTC()
+		ps.Papers[doi] = rec
+		if err := st.Save(name, ps); err != nil {
+			return fmt.Errorf("paper: %w", err)
+		}
+		t.Append(doi, paper.Title, result)
+	}
+	if err := t.Render(os.Stdout); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("paper: %d of %d papers failed", failed, len(dois))
+	}
+	return nil
+}
+
+// paperAdded reports whether a paper was added to the notebook, as
+// recorded in the state directory or by a source titled with its DOI.
+func paperAdded(ps *paperState, nb *api.Notebook, doi string) bool {
+	if _, ok := ps.Papers[doi]; ok {
+		return true
+	}
+	for _, s := range nb.Sources {
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(s.GetTitle())), paperTitlePrefix(doi)) {
+			return true
+		}
+	}
+	return false
+}
+
+// paperAbstract is the text source for a paper's metadata and abstract.
+func paperAbstract(p *scholar.Paper) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", p.Title)
+	fmt.Fprintf(&b, "DOI: %s\n", p.DOI)
+	if len(p.Authors) > 0 {
+		fmt.Fprintf(&b, "Authors: %s\n", strings.Join(p.Authors, ", "))
+	}
+	if p.Venue != "" {
+		fmt.Fprintf(&b, "Venue: %s\n", p.Venue)
+	}
+	switch {
+	case p.Published != "":
+		fmt.Fprintf(&b, "Published: %s\n", p.Published)
+	case p.Year > 0:
+		fmt.Fprintf(&b, "Published: %s\n", strconv.Itoa(p.Year))
+	}
+	if p.License != "" {
+		fmt.Fprintf(&b, "License: %s\n", p.License)
+	}
+	fmt.Fprintf(&b, "URL: %s\n", p.URL)
+	abstract := p.Abstract
+	if abstract == "" {
+		abstract = "(No abstract available.)"
+	}
+	fmt.Fprintf(&b, "\n## Abstract\n\n%s\n", abstract)
+	return b.String()
+}
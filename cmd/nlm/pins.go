@@ -0,0 +1,117 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/tmc/nlm/internal/api"
+)
+
+// pinsFile lists the pinned notebooks. NotebookLM has no pins of its own,
+// so they are kept on this machine.
+const pinsFile = "pins.json"
+
+// listPinned makes list show only pinned notebooks.
+var listPinned bool
+
+// pins are the pinned notebook IDs, loaded by list.
+var pins map[string]bool
+
+func init() {
+	flag.BoolVar(&listPinned, "pinned", false, "with list, show only pinned notebooks")
+}
+
+// pinList is the contents of pinsFile.
+type pinList struct {
+	IDs []string `json:"ids"`
+}
+
+// loadPins returns the pinned notebook IDs. Pins only change the order of
+// listings, so an unreadable file is treated as empty.
+func loadPins() map[string]bool {
+	var pl pinList
+	if st, err := openState(); err == nil {
+		st.Load(pinsFile, &pl)
+	}
+	m := make(map[string]bool, len(pl.IDs))
+	for _, id := range pl.IDs {
+		m[id] = true
+	}
+	return m
+}
+
+// setPinned pins or unpins notebooks. Only notebooks in the listing can be
+// pinned, while any ID can be unpinned, so that pins of deleted notebooks
+// can be removed.
+func setPinned(c *api.Client, ids []string, pin bool) error {
+	cmd := "unpin"
+	if pin {
+		cmd = "pin"
+	}
+	st, err := openState()
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd, err)
+	}
+	var pl pinList
+	if err := st.Load(pinsFile, &pl); err != nil {
+		return fmt.Errorf("%s: %w", cmd, err)
+	}
+	if pin {
+		nbs, err := loadMetadata().notebooks(c, 0)
+		if err != nil {
+			return fmt.Errorf("pin: %w", err)
+		}
+		known := make(map[string]bool, len(nbs))
+		for _, nb := range nbs {
+			known[nb.GetProjectId()] = true
+		}
+		var missing []string
+		for _, id := range ids {
+			if !known[id] {
+				missing = append(missing, id)
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("pin: notebooks not found: %s", strings.Join(missing, ", "))
+		}
+	}
+
+	current := make(map[string]bool, len(pl.IDs))
+	for _, id := range pl.IDs {
+		current[id] = true
+	}
+	changed := 0
+	for _, id := range ids {
+		if current[id] != pin {
+			current[id] = pin
+			changed++
+		}
+	}
+	// Keep the order pins were made in; new pins go last.
+	var out []string
+	for _, id := range append(pl.IDs, ids...) {
+		if current[id] {
+			out = append(out, id)
+			current[id] = false
+		}
+	}
+	pl.IDs = out
+	if err := st.Save(pinsFile, &pl); err != nil {
+		return fmt.Errorf("%s: %w", cmd, err)
+	}
+	fmt.Fprintf(os.Stderr, "%sned %d of %d notebooks; %d pinned in all\n", strings.ToUpper(cmd[:1])+cmd[1:], changed, len(ids), len(pl.IDs))
+	return nil
+}
+
+// pinnedOnly returns the pinned notebooks.
+func pinnedOnly(nbs []*api.Notebook) []*api.Notebook {
+	var out []*api.Notebook
+	for _, nb := range nbs {
+		if pins[nb.GetProjectId()] {
+			out = append(out, nb)
+		}
+	}
+	return out
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tmc/nlm/internal/api"
+)
+
+var pipeType string
+
+func init() {
+	flag.StringVar(&pipeType, "type", "guide", "with pipe, what to generate: guide, outline, section or guides")
+}
+
+// pipeGenerators generate text from a notebook, by -type.
+var pipeGenerators = map[string]func(c *api.Client, notebookID string) (string, error){
+	"guide": func(c *api.Client, id string) (string, error) {
+		r, err := c.GenerateNotebookGuide(id)
+		return r.GetContent(), err
+	},
+	"outline": func(c *api.Client, id string) (string, error) {
+		r, err := c.GenerateOutline(id)
+		return r.GetContent(), err
+	},
+	"section": func(c *api.Client, id string) (string, error) {
+		r, err := c.GenerateSection(id)
+		return r.GetContent(), err
+	},
+	"guides": func(c *api.Client, id string) (string, error) {
+		r, err := c.GenerateDocumentGuides(id)
+		var parts []string
+		for _, g := range r.GetGuides() {
+			if s := strings.TrimSpace(g.GetContent()); s != "" {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, "\n\n"), err
+	},
+}
+
+// pipesFile is the state file recording the notes and sources pipe
+// added, so that only those are replaced by a later pipe.
+const pipesFile = "pipes.json"
+
+// pipeState is the content of pipesFile: the ID of each note or source
+// pipe added, by destination notebook and then by pipeKey.
+type pipeState struct {
+	Outputs map[string]map[string]string `json:"outputs"`
+}
+
+// pipeKey is the key of an output in pipeState.
+func pipeKey(title string) string { return importAs + ":" + title }
+
+// pipe implements nlm pipe: it runs the -type generator on each source
+// notebook and adds the output to dst as a note or source (-as), titled
+// after the source notebook and the type. Output an earlier pipe added
+// under the same title is replaced, so rerunning a pipe keeps dst current
+// instead of piling up copies; notes and sources pipe did not add are
+// never replaced, whatever their title.
+func pipe(c *api.Client, srcs []string, dst string) error {
+	_, err := pipeNotebooks(c, srcs, dst)
+	return err
+}
+
+// pipeNotebooks does the work of pipe and also returns the number of
+// notebooks whose output was added.
+func pipeNotebooks(c *api.Client, srcs []string, dst string) (added int, err error) {
+	gen, ok := pipeGenerators[pipeType]
+	if !ok {
+		return 0, fmt.Errorf("pipe: unknown -type %q; use one of %s", pipeType, strings.Join(pipeTypes(), ", "))
+	}
+	if importAs != "notes" && importAs != "sources" {
+		return 0, fmt.Errorf("pipe: -as must be notes or sources, not %q", importAs)
+	}
+	st, err := openState()
+	if err != nil {
+		return 0, fmt.Errorf("pipe: %w", err)
+	}
+	unlock, err := st.Lock(pipesFile)
+	if err != nil {
+		return 0, fmt.Errorf("pipe: %w", err)
+	}
+	defer unlock()
+	var ps pipeState
+	if err := st.Load(pipesFile, &ps); err != nil {
+		return 0, fmt.Errorf("pipe: %w", err)
+	}
+	if ps.Outputs == nil {
+		ps.Outputs = make(map[string]map[string]string)
+	}
+	if ps.Outputs[dst] == nil {
+		ps.Outputs[dst] = make(map[string]string)
+	}
+	existing, err := pipeExisting(c, dst, ps.Outputs[dst])
+	if err != nil {
+		return 0, fmt.Errorf("pipe: %w", err)
+	}
+	var failed int
+	for i, src := range srcs {
+		nb, err := c.GetProject(src)
+		if err != nil {
+			fmt.Fprintf(errorOutput, "  ✗ %s: %v\n", src, err)
+			failed++
+			continue
+		}
+		title := fmt.Sprintf("%s (%s)", strings.TrimSpace(nb.GetTitle()), pipeType)
+		reportStep("pipe", title, i, len(srcs))
+		id, err := pipeOne(c, gen, src, dst, title, existing[title])
+		if err != nil {
+			fmt.Fprintf(errorOutput, "  ✗ %s %q: %v\n", src, title, err)
+			failed++
+			continue
+		}
+		ps.Outputs[dst][pipeKey(title)] = id
+		if err := st.Save(pipesFile, ps); err != nil {
+			return added, fmt.Errorf("pipe: %w", err)
+		}
+		fmt.Println(id)
+		added++
+	}
+	reportDone("pipe", len(srcs))
+	if failed > 0 {
+		return added, fmt.Errorf("pipe: %d of %d notebooks failed", failed, len(srcs))
+	}
+	summarize("pipe: added the %s of %d notebooks to %s as %s", pipeType, len(srcs), dst, importAs)
+	return added, nil
+}
+
+// pipeOne generates the output of src and adds it to dst, replacing the
+// note or source oldID if set. A replaced source is deleted only once its
+// successor is added.
+func pipeOne(c *api.Client, gen func(*api.Client, string) (string, error), src, dst, title, oldID string) (string, error) {
+	content, err := gen(c, src)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(content) == "" {
+		return "", fmt.Errorf("the %s is empty", pipeType)
+	}
+	if importAs == "notes" {
+		if oldID != "" {
+			if _, err := c.MutateNote(dst, oldID, content, title); err != nil {
+				return "", err
+			}
+			return oldID, nil
+		}
+		note, err := c.CreateNote(dst, title, content)
+		if err != nil {
+			return "", err
+		}
+		return note.GetSourceId().GetSourceId(), nil
+	}
+	id, err := c.AddSourceFromText(dst, content, title)
+	if err != nil {
+		return "", err
+	}
+	if oldID != "" {
+		if err := deleteSources(c, dst, []string{oldID}); err != nil {
+			return "", fmt.Errorf("added %s, but removing the previous %s failed: %w", id, oldID, err)
+		}
+	}
+	return id, nil
+}
+
+// pipeExisting returns, by title, the IDs of the notes or sources of dst
+// that an earlier pipe added, given the IDs it recorded by pipeKey.
+// Recorded outputs that were since deleted from dst are forgotten.
+func pipeExisting(c *api.Client, dst string, recorded map[string]string) (map[string]string, error) {
+	present := make(map[string]bool)
+	if importAs == "notes" {
+		notes, err := c.GetNotes(dst)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range notes {
+			present[n.GetSourceId().GetSourceId()] = true
+		}
+	} else {
+		nb, err := c.GetProject(dst)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range nb.GetSources() {
+			present[s.GetSourceId().GetSourceId()] = true
+		}
+	}
+	ids := make(map[string]string)
+	for key, id := range recorded {
+		title, ok := strings.CutPrefix(key, importAs+":")
+		if !ok {
+			continue
+		}
+		if !present[id] {
+			delete(recorded, key)
+			continue
+		}
+		ids[title] = id
+	}
+	return ids, nil
+}
+
+func pipeTypes() []string {
+	var types []string
+	for t := range pipeGenerators {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/cleanup"
+	"github.com/tmc/nlm/internal/convert"
+	"github.com/tmc/nlm/internal/feed"
+	"github.com/tmc/nlm/internal/ingest"
+)
+
+// Podcast flags
+var podcastEpisodes int
+
+func init() {
+	flag.IntVar(&podcastEpisodes, "episodes", 10, "with podcast add, maximum number of new episodes to add, newest first (0 for all)")
+}
+
+// maxAudioBytes bounds the size of episode audio uploaded to NotebookLM.
+// Audio is downloaded to a temporary file and streamed from there, so it
+// is never held in memory.
+const maxAudioBytes = 200 << 20
+
+// podcastState records the episodes of a feed already added to a
+// notebook, so later runs add only new episodes.
+type podcastState struct {
+	Feed       string                    `json:"feed"`
+	NotebookID string                    `json:"notebook_id"`
+	Title      string                    `json:"title"`
+	Episodes   map[string]podcastEpisode `json:"episodes"` // by feed.Episode.ID
+}
+
+type podcastEpisode struct {
+	Title      string    `json:"title"`
+	SourceID   string    `json:"source_id"`
+	Transcript string    `json:"transcript"` // feed, local or audio
+	AddedAt    time.Time `json:"added_at"`
+}
+
+// podcastStateName returns the state file tracking feedURL in a notebook.
+func podcastStateName(notebookID, feedURL string) string {
+	sum := sha256.Sum256([]byte(notebookID + "\x00" + feedURL))
+	return filepath.Join("podcasts", hex.EncodeToString(sum[:8])+".json")
+}
+
+// podcastAdd adds the episodes of a podcast feed that are not yet in the
+// notebook. Each episode becomes a text source with its title, date and
+// show notes followed by its transcript: the one the feed links to, or
+// one made with whisper.cpp under -transcribe-locally. Episodes with
+// neither are uploaded as audio for NotebookLM to transcribe.
+func podcastAdd(c *api.Client, notebookID, feedURL string) error {
+	st, err := openState()
+	if err != nil {
+		return fmt.Errorf("podcast: %w", err)
+	}
+	name := podcastStateName(notebookID, feedURL)
+	ps := &podcastState{Feed: feedURL, NotebookID: notebookID, Episodes: make(map[string]podcastEpisode)}
+	if err := st.Load(name, ps); err != nil {
+		return fmt.Errorf("podcast: %w", err)
+	}
+	p, err := ingestPipeline()
+	if err != nil {
+		return fmt.Errorf("podcast: %w", err)
+	}
+
+	ctx, stop := cleanup.NotifyContext(context.Background())
+	defer stop()
+	fmt.Fprintf(os.Stderr, "Reading feed %s...\n", feedURL)
+	resp, err := podcastGet(ctx, feedURL)
+	if err != nil {
+		return fmt.Errorf("podcast: %w", err)
+	}
+	f, err := feed.Parse(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("podcast: %s: %w", feedURL, err)
+	}
+	ps.Title = f.Title
+
+	var pending []feed.Episode
+	for _, e := range f.Episodes {
+		if _, done := ps.Episodes[e.ID()]; !done {
+			pending = append(pending, e)
+		}
+	}
+	if podcastEpisodes > 0 && len(pending) > podcastEpisodes {
+		fmt.Fprintf(os.Stderr, "%d new episodes; adding the newest %d (see -episodes)\n", len(pending), podcastEpisodes)
+		pending = pending[:podcastEpisodes]
+	}
+	if len(pending) == 0 {
+		fmt.Fprintf(os.Stderr, "No new episodes of %s.\n", f.Title)
+		return nil
+	}
+
+	var failed int
+	t := newTable("EPISODE", "PUBLISHED", "TRANSCRIPT", "RESULT")
+	// Add the oldest first, so sources are in broadcast order.
+	for i := len(pending) - 1; i >= 0; i-- {
+		e := pending[i]
+		fmt.Fprintf(os.Stderr, "[%d/%d] %s\n", len(pending)-i, len(pending), e.Title)
+		title := episodeTitle(e)
+		id, how, err := addEpisode(ctx, c, p, notebookID, f, e, title)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		result := id
+		if err != nil {
+			result = "error: " + err.Error()
+			failed++
+		} else {
+			ps.Episodes[e.ID()] = podcastEpisode{Title: e.Title, SourceID: id, Transcript: how, AddedAt: time.Now().UTC()}
+			if err := st.Save(name, ps); err != nil {
+				return fmt.Errorf("podcast: %w", err)
+			}
+		}
+		t.Append(e.Title, publishedDate(e), how, result)
+	}
+	if err := t.Render(os.Stdout); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("podcast: %d of %d episodes failed", failed, len(pending))
+	}
+	return nil
+}
+
+// addEpisode adds one episode and reports where its transcript came from.
+func addEpisode(ctx context.Context, c *api.Client, p *ingest.Pipeline, notebookID string, f *feed.Feed, e feed.Episode, title string) (id, how string, err error) {
+	transcript, how, err := episodeTranscript(ctx, e)
+	if err != nil {
+		return "", how, err
+	}
+	if transcript == "" {
+		how = "audio"
+		if e.AudioURL == "" {
+			return "", how, fmt.Errorf("no transcript or audio")
+		}
+		id, err := uploadEpisodeAudio(ctx, c, notebookID, e, title)
+		return id, how, err
+	}
+	text, _ := filterText(p, title, episodeDocument(f, e, transcript))
+	id, err = c.AddSourceFromText(notebookID, text, title)
+	return id, how, err
+}
+
+// episodeTranscript returns the transcript the feed links to or, with
+// -transcribe-locally, a local transcript of the audio. It returns "" if
+// neither is available.
+func episodeTranscript(ctx context.Context, e feed.Episode) (text, how string, err error) {
+	if tr, ok := e.Transcript(); ok {
+		text, err := fetchTranscript(ctx, tr)
+		if err == nil && strings.TrimSpace(text) != "" {
+			return text, "feed", nil
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s: transcript: %v\n", e.Title, err)
+		}
+	}
+	if !transcribeLocal || e.AudioURL == "" {
+		return "", "", nil
+	}
+	cfg, err := projectConfig()
+	if err != nil {
+		return "", "local", err
+	}
+	dir, err := cleanup.MkdirTemp("", "nlm-podcast-")
+	if err != nil {
+		return "", "local", err
+	}
+	defer cleanup.Remove(dir)
+	file, err := downloadEpisodeAudio(ctx, e, dir)
+	if err != nil {
+		return "", "local", err
+	}
+	fmt.Fprintf(os.Stderr, "Transcribing %s locally...\n", e.Title)
+	text, err = whisperConfig(cfg).Transcribe(ctx, file)
+	return text, "local", err
+}
+
+// fetchTranscript downloads a transcript and converts it to text.
+func fetchTranscript(ctx context.Context, tr feed.Transcript) (string, error) {
+	resp, err := podcastGet(ctx, tr.URL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body := io.LimitReader(resp.Body, 20<<20)
+	switch tr.Type {
+	case "application/json":
+		return feed.JSONTranscript(body)
+	case "text/html":
+		page, err := convert.ReadHTML(body)
+		if err != nil {
+			return "", err
+		}
+		return page.Text, nil
+	case "text/plain":
+		data, err := io.ReadAll(body)
+		return string(data), err
+	}
+	var b bytes.Buffer
+	err = convert.Subtitles(convert.SubtitleOptions{KeepTimestamps: keepTimestamps}).Convert(ctx, path.Base(tr.URL), body, &b)
+	return b.String(), err
+}
+
+// episodeDocument is the text source for an episode: its metadata and
+// show notes, then the transcript.
+func episodeDocument(f *feed.Feed, e feed.Episode, transcript string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", e.Title)
+	if f.Title != "" {
+		fmt.Fprintf(&b, "Podcast: %s\n", f.Title)
+	}
+	if d := publishedDate(e); d != "" {
+		fmt.Fprintf(&b, "Published: %s\n", d)
+	}
+	if e.Link != "" {
+		fmt.Fprintf(&b, "Link: %s\n", e.Link)
+	}
+	if e.Description != "" {
+		notes := e.Description
+		if page, err := convert.ReadHTML(strings.NewReader(notes)); err == nil {
+			notes = page.Text
+		}
+		fmt.Fprintf(&b, "\n## Show Notes\n\n%s", strings.TrimSpace(notes)+"\n")
+	}
+	fmt.Fprintf(&b, "\n## Transcript\n\n%s\n", strings.TrimSpace(transcript))
+	return b.String()
+}
+
+// uploadEpisodeAudio uploads an episode's audio as a source.
+func uploadEpisodeAudio(ctx context.Context, c *api.Client, notebookID string, e feed.Episode, title string) (string, error) {
+	dir, err := cleanup.MkdirTemp("", "nlm-podcast-")
+	if err != nil {
+		return "", err
+	}
+	defer cleanup.Remove(dir)
+	file, err := downloadEpisodeAudio(ctx, e, dir)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	typ := e.AudioType
+	if typ == "" {
+		head := make([]byte, 512)
+		n, _ := io.ReadFull(f, head)
+		typ = http.DetectContentType(head[:n])
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return "", err
+		}
+	}
+	return c.AddSourceFromStream(notebookID, f, title+audioExt(e), typ)
+}
+
+// downloadEpisodeAudio saves an episode's audio in dir, copying it to
+// disk as it arrives, and returns the file's path.
+func downloadEpisodeAudio(ctx context.Context, e feed.Episode, dir string) (string, error) {
+	fmt.Fprintf(os.Stderr, "Downloading %s...\n", e.AudioURL)
+	resp, err := podcastGet(ctx, e.AudioURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	file := filepath.Join(dir, "episode"+audioExt(e))
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return "", err
+	}
+	n, err := io.Copy(f, io.LimitReader(resp.Body, maxAudioBytes+1))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", fmt.Errorf("download audio: %w", err)
+	}
+	if n > maxAudioBytes {
+		return "", fmt.Errorf("audio is larger than %s", formatBytes(maxAudioBytes))
+	}
+	return file, nil
+}
+
+// audioExt returns the file extension of an episode's audio.
+func audioExt(e feed.Episode) string {
+	if ext := path.Ext(strings.SplitN(e.AudioURL, "?", 2)[0]); ext != "" && len(ext) <= 5 {
+		return ext
+	}
+	if exts, _ := mime.ExtensionsByType(e.AudioType); len(exts) > 0 {
+		return exts[0]
+	}
+	return ".mp3"
+}
+
+// episodeTitle is the source title of an episode: its title and date.
+func episodeTitle(e feed.Episode) string {
+	if d := publishedDate(e); d != "" {
+		return fmt.Sprintf("%s (%s)", e.Title, d)
+	}
+	return e.Title
+}
+
+func publishedDate(e feed.Episode) string {
+	if e.Published.IsZero() {
+		return ""
+	}
+	return e.Published.Format("2006-01-02")
+}
+
+// podcastGet fetches a feed, transcript or audio file.
+func podcastGet(ctx context.Context, u string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "nlm/"+buildVersion())
+	resp, err := (&http.Client{Timeout: 10 * time.Minute}).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %s", u, resp.Status)
+	}
+	return resp, nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/tmc/nlm/internal/errinfo"
+	"github.com/tmc/nlm/internal/progress"
+)
+
+// progressFormat selects how long-running commands report progress.
+var progressFormat string
+
+// progressReporter receives progress events with -progress json, and is
+// nil otherwise.
+var progressReporter *progress.Reporter
+
+func init() {
+	flag.StringVar(&progressFormat, "progress", "text", "progress output: text, or json for one event per line on stderr")
+}
+
+// beginProgress sets up -progress. With json, the text progress on stderr
+// is dropped as with -quiet, leaving the events and errors.
+func beginProgress() error {
+	switch progressFormat {
+	case "text":
+		return nil
+	case "json":
+		progressReporter = progress.NewReporter(errorOutput)
+		return nil
+	}
+	return errinfo.Usagef("nlm: unknown -progress %q (want text or json)", progressFormat)
+}
+
+// reportStep reports that stage has started on item after current of
+// total items; total is 0 when it is not known.
+func reportStep(stage, item string, current, total int) {
+	progressReporter.Step(stage, item, current, total)
+}
+
+// reportDone reports that stage has finished its total items.
+func reportDone(stage string, total int) {
+	progressReporter.Done(stage, total)
+}
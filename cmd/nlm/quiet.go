@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/tmc/nlm/internal/batchexecute"
+)
+
+// quiet discards progress and informational output on stderr, leaving
+// errors and one summary line, for cron logs.
+var quiet bool
+
+func init() {
+	flag.BoolVar(&quiet, "quiet", false, "print only errors and a one-line summary on stderr (a JSON object with -json)")
+}
+
+// errorOutput is the real stderr. Errors are written to it so that they
+// survive -quiet, which replaces os.Stderr.
+var errorOutput io.Writer = os.Stderr
+
+var (
+	quietCmd     string
+	quietStart   time.Time
+	quietSummary string
+	quietUsage   batchexecute.Usage
+)
+
+// beginQuiet starts timing cmd and, with -quiet or -progress json,
+// discards stderr.
+func beginQuiet(cmd string) {
+	quietCmd, quietStart = cmd, time.Now()
+	if !quiet && progressReporter == nil {
+		return
+	}
+	if f, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0); err == nil {
+		os.Stderr = f
+	}
+}
+
+// summarize prints the closing line of a long-running command. With
+// -quiet, it becomes the text of the summary line instead.
+func summarize(format string, args ...interface{}) {
+	s := fmt.Sprintf(format, args...)
+	if quiet {
+		quietSummary = s
+		return
+	}
+	fmt.Fprintln(os.Stderr, s)
+}
+
+// printQuietSummary prints the -quiet summary line of the command that
+// ended with err.
+func printQuietSummary(err error) {
+	if !quiet || quietCmd == "" {
+		return
+	}
+	d := time.Since(quietStart).Round(100 * time.Millisecond)
+	requests := 0
+	for _, n := range quietUsage.Calls {
+		requests += n
+	}
+	if jsonOutput {
+		json.NewEncoder(errorOutput).Encode(struct {
+			Command  string  `json:"command"`
+			OK       bool    `json:"ok"`
+			Summary  string  `json:"summary,omitempty"`
+			Seconds  float64 `json:"seconds"`
+			Requests int     `json:"requests"`
+		}{quietCmd, err == nil, quietSummary, d.Seconds(), requests})
+		return
+	}
+	status := "ok"
+	if err != nil {
+		status = "failed"
+	}
+	if quietSummary != "" {
+		status += ": " + quietSummary
+	}
+	fmt.Fprintf(errorOutput, "nlm %s %s (%s, %d requests)\n", quietCmd, status, d, requests)
+}
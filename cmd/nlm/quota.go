@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/tmc/nlm/internal/batchexecute"
+	"github.com/tmc/nlm/internal/quota"
+	"github.com/tmc/nlm/internal/state"
+)
+
+// quotaFile holds the daily request counts of each browser profile.
+const quotaFile = "quota.json"
+
+// quotaKeepDays is how many days of counts quotaFile keeps.
+const quotaKeepDays = 30
+
+// budgetSpec is the daily budget of the current profile, such as
+// "requests=500,upload=200MB".
+var budgetSpec string
+
+// quotaLocal selects the locally tracked usage in nlm quota.
+var quotaLocal bool
+
+func init() {
+	flag.StringVar(&budgetSpec, "budget", "", "refuse requests that would take the profile over this daily budget, such as requests=500,upload=200MB (or set NLM_BUDGET)")
+	flag.BoolVar(&quotaLocal, "local", false, "with quota, show the requests and uploads counted on this machine")
+}
+
+// A quotaRun counts the requests of a command against the profile's
+// budget.
+type quotaRun struct {
+	date    string
+	profile string
+	tracker *quota.Tracker
+	saved   quota.Day
+}
+
+// startQuota returns the counter of a command. With a budget, each
+// request is checked against and added to quotaFile under its lock before
+// it is sent, so that commands run at the same time share the budget;
+// otherwise the counts are added when the command ends.
+func startQuota() (*quotaRun, error) {
+	budget, err := quota.ParseBudget(budgetSpec)
+	if err != nil {
+		return nil, err
+	}
+	q := &quotaRun{date: quota.Today(time.Now()), profile: browserProfile()}
+	if budget.IsZero() {
+		q.tracker = quota.NewTracker(q.profile, quota.Day{}, budget)
+		return q, nil
+	}
+	st, err := openState()
+	if err == nil {
+		// Fail now, rather than on the first request, if the counts
+		// cannot be read.
+		err = quotaLedger{st}.Update(func(*quota.Ledger) error { return nil })
+	}
+	if err != nil {
+		return nil, fmt.Errorf("budget: %w", err)
+	}
+	q.tracker = quota.NewSharedTracker(quotaLedger{st}, q.profile, q.date, budget)
+	return q, nil
+}
+
+// quotaLedger is the quota.Store kept in quotaFile.
+type quotaLedger struct {
+	st *state.Store
+}
+
+// Update updates quotaFile under its lock, dropping the counts older than
+// quotaKeepDays.
+func (l quotaLedger) Update(fn func(*quota.Ledger) error) error {
+	unlock, err := l.st.Lock(quotaFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	var ledger quota.Ledger
+	if err := l.st.Load(quotaFile, &ledger); err != nil {
+		return err
+	}
+	if err := fn(&ledger); err != nil {
+		return err
+	}
+	ledger.Prune(quota.Today(time.Now().AddDate(0, 0, -quotaKeepDays)))
+	return l.st.Save(quotaFile, &ledger)
+}
+
+// options checks each request against the budget.
+func (q *quotaRun) options() []batchexecute.Option {
+	return []batchexecute.Option{batchexecute.WithBudget(q.tracker)}
+}
+
+// save adds the requests sent since the last save to quotaFile, unless
+// the tracker added them as they were sent. Like the history, the counts
+// are best effort: failing to save them does not fail the command.
+func (q *quotaRun) save() {
+	if q.tracker.Shared() {
+		return
+	}
+	run := q.tracker.Run()
+	unsaved := quota.Day{Requests: run.Requests - q.saved.Requests, UploadBytes: run.UploadBytes - q.saved.UploadBytes}
+	if unsaved.Requests == 0 {
+		return
+	}
+	q.saved = run
+	st, err := openState()
+	if err != nil {
+		return
+	}
+	quotaLedger{st}.Update(func(l *quota.Ledger) error {
+		l.Add(q.profile, q.date, unsaved)
+		return nil
+	})
+}
+
+// runQuota prints the requests and uploads counted for each profile over
+// the last week, and the budget of the current one. NotebookLM does not
+// report its own limits, so only the local counts can be shown.
+func runQuota(args []string) error {
+	if !quotaLocal || len(args) != 0 {
+		log.Fatal("usage: nlm quota -local [-json]")
+	}
+	budget, err := quota.ParseBudget(budgetSpec)
+	if err != nil {
+		return fmt.Errorf("quota: %w", err)
+	}
+	st, err := openState()
+	if err != nil {
+		return fmt.Errorf("quota: %w", err)
+	}
+	var ledger quota.Ledger
+	if err := st.Load(quotaFile, &ledger); err != nil {
+		return fmt.Errorf("quota: %w", err)
+	}
+	now := time.Now()
+	entries := ledger.Entries(quota.Today(now.AddDate(0, 0, -6)))
+	profile := browserProfile()
+	today := ledger.Get(profile, quota.Today(now))
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Profile           string        `json:"profile"`
+			Today             quota.Day     `json:"today"`
+			BudgetRequests    int           `json:"budget_requests,omitempty"`
+			BudgetUploadBytes int64         `json:"budget_upload_bytes,omitempty"`
+			Days              []quota.Entry `json:"days"`
+		}{profile, today, budget.Requests, budget.UploadBytes, entries})
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(os.Stderr, "No requests counted in the last 7 days.")
+	} else {
+		t := newTable("PROFILE", "DATE", "REQUESTS", "UPLOADED")
+		for _, e := range entries {
+			t.Append(e.Profile, e.Date, strconv.Itoa(e.Requests), formatBytes(e.UploadBytes))
+		}
+		if err := t.Render(os.Stdout); err != nil {
+			return err
+		}
+		fmt.Println()
+	}
+	fmt.Printf("Today, profile %s: %s\n", profile, formatBudget(today, budget))
+	return nil
+}
+
+// formatBudget describes usage against budget, as in
+// "120 of 500 requests, 3.0 MiB uploaded of 200.0 MiB".
+func formatBudget(d quota.Day, b quota.Budget) string {
+	s := fmt.Sprintf("%d requests", d.Requests)
+	if b.Requests > 0 {
+		s = fmt.Sprintf("%d of %d requests", d.Requests, b.Requests)
+	}
+	s += ", " + formatBytes(d.UploadBytes) + " uploaded"
+	if b.UploadBytes > 0 {
+		s += " of " + formatBytes(b.UploadBytes)
+	}
+	if b.IsZero() {
+		s += " (no budget set)"
+	}
+	return s
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/cleanup"
+	"github.com/tmc/nlm/internal/convert"
+	"github.com/tmc/nlm/internal/crawl"
+	"github.com/tmc/nlm/internal/readlater"
+	"github.com/tmc/nlm/internal/state"
+)
+
+// Read-later import flags
+var (
+	importPocket     bool
+	importInstapaper string
+	importTags       string
+	importFetch      bool
+)
+
+func init() {
+	flag.BoolVar(&importPocket, "pocket", false, "with import, add the articles saved to Pocket to a notebook")
+	flag.StringVar(&importInstapaper, "instapaper", "", "with import, add the articles in an Instapaper CSV export `file` to a notebook")
+	flag.StringVar(&importTags, "tag", "", "with import -pocket, -instapaper or -takeout, only add items with one of these comma-separated tags (Keep labels for -takeout)")
+	flag.BoolVar(&importFetch, "fetch", false, "with import -pocket or -instapaper, fetch and clean up articles locally and add them as text rather than by URL")
+}
+
+// readLaterState records the articles imported into a notebook, so later
+// imports add only new ones.
+type readLaterState struct {
+	// PocketSince is the Pocket server time of the last import, by -tag
+	// filter.
+	PocketSince map[string]int64           `json:"pocket_since,omitempty"`
+	Articles    map[string]readLaterRecord `json:"articles"` // by URL
+}
+
+type readLaterRecord struct {
+	Title    string    `json:"title"`
+	SourceID string    `json:"source_id"`
+	AddedAt  time.Time `json:"added_at"`
+}
+
+// importReadLater adds the articles saved to Pocket, or listed in an
+// Instapaper export, to a notebook, by URL or, with -fetch, as text
+// fetched and converted here. Articles imported before are skipped, and
+// Pocket is only asked for articles saved since the last import.
+func importReadLater(c *api.Client, notebookID string) error {
+	if importPocket && importInstapaper != "" {
+		return fmt.Errorf("import: give only one of -pocket and -instapaper")
+	}
+	var tags []string
+	for _, t := range strings.Split(importTags, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	st, err := openState()
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	name := filepath.Join("readlater", notebookID+".json")
+	rs := &readLaterState{PocketSince: make(map[string]int64), Articles: make(map[string]readLaterRecord)}
+	if err := st.Load(name, rs); err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+
+	ctx, stop := cleanup.NotifyContext(context.Background())
+	defer stop()
+	var articles []readlater.Article
+	var pocketSince time.Time
+	if importPocket {
+		if articles, pocketSince, err = pocketArticles(ctx, rs, tags); err != nil {
+			return fmt.Errorf("import: %w", err)
+		}
+	} else {
+		f, err := os.Open(importInstapaper)
+		if err != nil {
+			return fmt.Errorf("import: %w", err)
+		}
+		articles, err = readlater.ReadInstapaperCSV(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("import: %w", err)
+		}
+	}
+
+	var pending []readlater.Article
+	for _, a := range articles {
+		if _, done := rs.Articles[a.URL]; !done && a.HasTag(tags) {
+			pending = append(pending, a)
+		}
+	}
+	if len(pending) == 0 {
+		fmt.Fprintln(os.Stderr, "No new articles.")
+		return savePocketSince(st, name, rs, tags, pocketSince)
+	}
+	p, err := ingestPipeline()
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	nb, err := c.GetProject(notebookID)
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	room := estimateMaxSources - len(nb.Sources)
+	if len(pending) > room {
+		fmt.Fprintf(os.Stderr, "warning: %d new articles but room for %d (limit %d, see -max-sources); adding the oldest\n", len(pending), max(room, 0), estimateMaxSources)
+		pending = pending[:max(room, 0)]
+		// The rest are offered again next time.
+		pocketSince = time.Time{}
+	}
+
+	cr := &crawl.Crawler{UserAgent: "nlm/" + buildVersion(), Delay: time.Second}
+	var failed int
+	t := newTable("URL", "TITLE", "RESULT")
+	for i, a := range pending {
+		fmt.Fprintf(os.Stderr, "[%d/%d] %s\n", i+1, len(pending), a.URL)
+		title := a.Title
+		var id string
+		if importFetch {
+			var page *convert.Page
+			if page, err = cr.Fetch(ctx, a.URL); err == nil {
+				if title == "" {
+					title = page.Title
+				}
+				if title == "" {
+					title = pageName(a.URL)
+				}
+				text, _ := filterText(p, a.URL, page.Text)
+				id, err = c.AddSourceFromText(notebookID, fmt.Sprintf("%s\n\nSource: %s\n", text, a.URL), title)
+			}
+		} else {
+			id, err = c.AddSourceFromURL(notebookID, a.URL)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			t.Append(a.URL, title, "error: "+err.Error())
+			failed++
+			continue
+		}
+		rs.Articles[a.URL] = readLaterRecord{Title: title, SourceID: id, AddedAt: time.Now().UTC()}
+		if err := st.Save(name, rs); err != nil {
+			return fmt.Errorf("import: %w", err)
+		}
+		t.Append(a.URL, title, id)
+	}
+	if err := t.Render(os.Stdout); err != nil {
+		return err
+	}
+	if failed > 0 {
+		// Leave the Pocket time alone so the failed articles are offered
+		// again next time.
+		return fmt.Errorf("import: %d of %d articles failed", failed, len(pending))
+	}
+	return savePocketSince(st, name, rs, tags, pocketSince)
+}
+
+// pocketArticles returns the articles saved to Pocket since the last
+// import with the same tags. The credentials come from
+// NLM_POCKET_CONSUMER_KEY and NLM_POCKET_ACCESS_TOKEN; without a token the
+// user is walked through authorizing nlm.
+func pocketArticles(ctx context.Context, rs *readLaterState, tags []string) ([]readlater.Article, time.Time, error) {
+	pc := &readlater.Pocket{
+		UserAgent:   "nlm/" + buildVersion(),
+		ConsumerKey: settings.Services.PocketConsumerKey,
+		AccessToken: settings.Services.PocketAccessToken,
+	}
+	if pc.ConsumerKey == "" {
+		return nil, time.Time{}, fmt.Errorf("set NLM_POCKET_CONSUMER_KEY to the consumer key of a Pocket application (https://getpocket.com/developer/apps/)")
+	}
+	if pc.AccessToken == "" {
+		if err := authorizePocket(ctx, pc); err != nil {
+			return nil, time.Time{}, err
+		}
+	}
+	var q readlater.PocketQuery
+	if len(tags) == 1 {
+		q.Tag = tags[0]
+	}
+	if sec, ok := rs.PocketSince[strings.Join(tags, ",")]; ok {
+		q.Since = time.Unix(sec, 0)
+	}
+	fmt.Fprintln(os.Stderr, "Reading Pocket list...")
+	return pc.Saved(ctx, q)
+}
+
+// authorizePocket has the user approve nlm in a browser and prints the
+// resulting access token for NLM_POCKET_ACCESS_TOKEN.
+func authorizePocket(ctx context.Context, pc *readlater.Pocket) error {
+	const redirect = "https://getpocket.com/"
+	code, err := pc.RequestToken(ctx, redirect)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Open this page to let nlm read your Pocket list, then press Enter:\n\n  %s\n\n", readlater.AuthURL(code, redirect))
+	if _, err := bufio.NewReader(os.Stdin).ReadString('\n'); err != nil {
+		return fmt.Errorf("pocket: authorization: %w", err)
+	}
+	token, user, err := pc.Authorize(ctx, code)
+	if err != nil {
+		return err
+	}
+	pc.AccessToken = token
+	fmt.Fprintf(os.Stderr, "Authorized as %s. To skip this step next time:\n\n  export NLM_POCKET_ACCESS_TOKEN=%s\n\n", user, token)
+	return nil
+}
+
+// savePocketSince records the time of a Pocket import with tags, unless
+// since is zero.
+func savePocketSince(st *state.Store, name string, rs *readLaterState, tags []string, since time.Time) error {
+	if since.IsZero() {
+		return nil
+	}
+	rs.PocketSince[strings.Join(tags, ",")] = since.Unix()
+	if err := st.Save(name, rs); err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	return nil
+}
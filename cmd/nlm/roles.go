@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"sync"
+	"time"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/batchexecute"
+	"github.com/tmc/nlm/internal/rpc"
+)
+
+// readOnly refuses every call that would change data.
+var readOnly bool
+
+func init() {
+	flag.BoolVar(&readOnly, "read-only", false, "refuse every call that would change a notebook, source, note or sharing setting")
+}
+
+// readOnlyOptions make the client refuse writes with -read-only, before
+// they are sent.
+func readOnlyOptions() []batchexecute.Option {
+	if !readOnly {
+		return nil
+	}
+	return []batchexecute.Option{batchexecute.WithReadOnly(rpc.Writes)}
+}
+
+// checkEditor returns a check for api.Client.CheckChanges that refuses,
+// with an *api.ReadOnlyError, changes to a notebook shared with the
+// caller as a viewer, rather than letting the server reject them part way
+// through a batch. Roles come from the notebook list, fetched from the
+// server on the first check and again after listTTL, since a role granted
+// or taken away elsewhere does not change the cached list; notebooks
+// missing from it are not checked.
+func checkEditor(c *api.Client) func(notebookID string) error {
+	var (
+		mu     sync.Mutex
+		roles  map[string]*api.Notebook
+		listed time.Time
+	)
+	return func(notebookID string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if roles == nil || time.Since(listed) > listTTL {
+			nbs, err := loadMetadata().notebooks(c, 0)
+			if errors.Is(err, batchexecute.ErrUnauthorized) {
+				return err
+			}
+			roles, listed = make(map[string]*api.Notebook), time.Now()
+			for _, nb := range nbs {
+				roles[nb.GetProjectId()] = nb
+			}
+		}
+		if nb, ok := roles[notebookID]; ok {
+			return api.CheckWritable(nb)
+		}
+		return nil
+	}
+}
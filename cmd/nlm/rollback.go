@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/rollback"
+)
+
+// keepPartial keeps what a command made before it failed, rather than
+// rolling it back.
+var keepPartial bool
+
+func init() {
+	flag.BoolVar(&keepPartial, "keep-partial", false, "with import and aggregate, keep a notebook created by a run that fails partway instead of deleting it")
+}
+
+// undoNotebook records a notebook created by cmd, to be deleted if cmd
+// fails.
+func undoNotebook(l *rollback.Log, c *api.Client, title, id string) {
+	l.Add(fmt.Sprintf("notebook %q (%s)", title, id), func() error {
+		return c.DeleteProjects([]string{id})
+	})
+}
+
+// rollBack undoes what cmd recorded in l if it failed with err, unless
+// -keep-partial is given, and returns err.
+func rollBack(cmd string, l *rollback.Log, err error) error {
+	if err == nil || l.Len() == 0 {
+		return err
+	}
+	if keepPartial {
+		fmt.Fprintf(errorOutput, "%s: keeping what was made before the failure\n", cmd)
+		return err
+	}
+	fmt.Fprintf(errorOutput, "%s: rolling back (use -keep-partial to keep what was made)\n", cmd)
+	uerr := l.Undo(func(what string, err error) {
+		if err != nil {
+			fmt.Fprintf(errorOutput, "  ✗ %s: %v\n", what, err)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "  deleted %s\n", what)
+	})
+	if uerr != nil {
+		return fmt.Errorf("%w; rolling back failed, so delete what was made by hand: %v", err, uerr)
+	}
+	return err
+}
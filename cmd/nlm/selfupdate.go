@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/tmc/nlm/internal/update"
+)
+
+// Release settings, overridable at build time with -ldflags "-X ...".
+var (
+	// updateRepo is the GitHub repository releases are fetched from.
+	updateRepo = "chenle02/nlm"
+	// updatePublicKey is the base64 Ed25519 key that signs release
+	// manifests. scripts/release.sh sets it from the signing key; builds
+	// without it cannot self-update, since an unsigned manifest proves
+	// nothing.
+	updatePublicKey = ""
+)
+
+var versionCheck bool
+
+func init() {
+	flag.BoolVar(&versionCheck, "check", false, "with version, check GitHub for a newer release")
+}
+
+func newUpdateChecker() (*update.Checker, error) {
+	c := &update.Checker{
+		Repo:       updateRepo,
+		HTTPClient: &http.Client{Timeout: 2 * time.Minute},
+	}
+	if updatePublicKey != "" {
+		key, err := update.ParsePublicKey(updatePublicKey)
+		if err != nil {
+			return nil, err
+		}
+		c.PublicKey = key
+	}
+	return c, nil
+}
+
+func printVersion() error {
+	fmt.Println(versionString())
+	if !versionCheck {
+		return nil
+	}
+	c, err := newUpdateChecker()
+	if err != nil {
+		return err
+	}
+	rel, err := c.Latest(context.Background())
+	if err != nil {
+		return err
+	}
+	if update.Newer(rel.TagName, buildVersion()) {
+		fmt.Printf("A newer release is available: %s (%s)\n", rel.TagName, rel.URL)
+		fmt.Println("Run 'nlm self-update' to install it.")
+		return nil
+	}
+	fmt.Printf("nlm is up to date (latest release %s)\n", rel.TagName)
+	return nil
+}
+
+func selfUpdate() error {
+	c, err := newUpdateChecker()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	rel, err := c.Latest(ctx)
+	if err != nil {
+		return err
+	}
+	current := buildVersion()
+	if !update.IsRelease(current) {
+		return fmt.Errorf("self-update: nlm %s is a development build; install a release or rerun go install instead", current)
+	}
+	if updatePublicKey == "" {
+		return fmt.Errorf("self-update: this build has no release signing key to verify downloads with; install a release from %s instead", rel.URL)
+	}
+	if !update.Newer(rel.TagName, current) {
+		fmt.Fprintf(os.Stderr, "nlm %s is up to date\n", current)
+		return nil
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("self-update: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Downloading %s...\n", rel.TagName)
+	data, err := c.Download(ctx, rel, current)
+	if err != nil {
+		return fmt.Errorf("self-update: %w", err)
+	}
+	if err := update.Replace(exe, data); err != nil {
+		return fmt.Errorf("self-update: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Updated %s from %s to %s\n", exe, current, rel.TagName)
+	return nil
+}
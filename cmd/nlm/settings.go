@@ -0,0 +1,33 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/rpc"
+)
+
+// showNotebookSettings prints the settings of a notebook.
+func showNotebookSettings(c *api.Client, notebookID string) error {
+	values, err := c.NotebookSettings(notebookID)
+	if err != nil {
+		return fmt.Errorf("settings get: %w", err)
+	}
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(values)
+	}
+	t := newTable("SETTING", "VALUE", "DESCRIPTION")
+	for _, s := range rpc.NotebookSettings {
+		desc := s.Description
+		if s.Values != nil {
+			desc += " (" + strings.Join(s.ValueNames(), ", ") + ")"
+		}
+		t.Append(s.Name, values[s.Name], desc)
+	}
+	return t.Render(os.Stdout)
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/auth"
+	"github.com/tmc/nlm/internal/batchexecute"
+	"github.com/tmc/nlm/internal/config"
+	"golang.org/x/term"
+)
+
+// loadGlobalConfig returns the configuration written by nlm setup, or nil
+// if there is none.
+func loadGlobalConfig() (*config.Global, error) {
+	st, err := openState()
+	if err != nil {
+		return nil, err
+	}
+	data, err := st.ReadFile(config.GlobalFileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return config.ParseGlobal(data)
+}
+
+// saveGlobalConfig writes g as the global configuration and returns the
+// path of the file.
+func saveGlobalConfig(g *config.Global) (string, error) {
+	data, err := g.Marshal()
+	if err != nil {
+		return "", err
+	}
+	st, err := openState()
+	if err != nil {
+		return "", err
+	}
+	if err := st.WriteFile(config.GlobalFileName, data); err != nil {
+		return "", fmt.Errorf("write config: %w", err)
+	}
+	return st.Path(config.GlobalFileName), nil
+}
+
+// applyGlobalConfig uses the global configuration for the flags that were
+// not given on the command line.
+func applyGlobalConfig() *config.Global {
+	g, err := loadGlobalConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "nlm: global config not loaded: %v\n", err)
+		return nil
+	}
+	if g == nil {
+		return nil
+	}
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if !set["no-color"] {
+		noColor = g.Output.NoColor
+	}
+	if !set["wrap"] {
+		wrapCells = g.Output.Wrap
+	}
+	if !set["trash"] {
+		useTrash = g.Trash.Enabled
+	}
+	if !set["read-only"] {
+		readOnly = g.ReadOnly
+	}
+	trashKeep = g.Trash.Keep
+	return g
+}
+
+// setup walks a new user through choosing a browser profile, signing in
+// and picking output defaults, saves the answers as the global
+// configuration, and checks that NotebookLM can be reached.
+func setup() error {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return errors.New("setup: needs a terminal; use nlm auth with a curl command on stdin instead")
+	}
+	g, err := loadGlobalConfig()
+	if err != nil {
+		return fmt.Errorf("setup: %w", err)
+	}
+	if g == nil {
+		g = &config.Global{}
+	}
+	in := bufio.NewReader(os.Stdin)
+
+	fmt.Fprintf(os.Stderr, "Step 1 of 4: browser profile\n")
+	g.Profile = chooseProfile(in, g.Profile)
+
+	fmt.Fprintf(os.Stderr, "\nStep 2 of 4: sign in\n")
+	if authToken != "" && cookies != "" && !confirm(in, "Credentials are already saved. Sign in again?", false) {
+		fmt.Fprintf(os.Stderr, "Keeping the saved credentials.\n")
+	} else {
+		fmt.Fprintf(os.Stderr, "A browser window opens with profile %q; sign in to NotebookLM there.\n", g.Profile)
+		if authToken, cookies, err = handleAuth([]string{g.Profile}, debug); err != nil {
+			return fmt.Errorf("setup: %w", err)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "\nStep 3 of 4: output\n")
+	format := "table"
+	if g.Output.JSON {
+		format = "json"
+	}
+	for {
+		format = prompt(in, "Output format for listings and errors (table, json)", format)
+		if format == "table" || format == "json" {
+			break
+		}
+	}
+	g.Output.JSON = format == "json"
+	g.Output.NoColor = !confirm(in, "Use colors in tables?", !g.Output.NoColor)
+	g.Output.Wrap = confirm(in, "Wrap long table cells instead of truncating them?", g.Output.Wrap)
+
+	path, err := saveGlobalConfig(g)
+	if err != nil {
+		return fmt.Errorf("setup: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Saved %s\n", path)
+
+	fmt.Fprintf(os.Stderr, "\nStep 4 of 4: connectivity check\n")
+	if err := selfTest(); err != nil {
+		fmt.Fprintf(os.Stderr, "The check failed: %s\n", redactString(err.Error()))
+		printErrorInfo(os.Stderr, err)
+		return errors.New("setup: connectivity check failed; your answers were saved, rerun nlm setup after fixing the problem")
+	}
+	fmt.Fprintf(os.Stderr, "\nAll set. Try nlm list.\n")
+	return nil
+}
+
+// chooseProfile asks for a browser profile, offering the ones found on
+// this machine.
+func chooseProfile(in *bufio.Reader, current string) string {
+	if current == "" {
+		current = browserProfile()
+	}
+	profiles, err := auth.Profiles()
+	if err != nil || len(profiles) == 0 {
+		fmt.Fprintf(os.Stderr, "No Chrome profiles found; enter the profile directory name if you use one.\n")
+		return prompt(in, "Profile", current)
+	}
+	for i, p := range profiles {
+		fmt.Fprintf(os.Stderr, "  %d) %s\n", i+1, p)
+	}
+	answer := prompt(in, "Profile (number or name)", current)
+	if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(profiles) {
+		return profiles[n-1]
+	}
+	return answer
+}
+
+// selfTest lists the user's notebooks with the current credentials.
+func selfTest() error {
+	c := api.New(authToken, cookies, append(clientHeaderOptions(), batchexecute.WithRedaction(!noRedact))...)
+	start := time.Now()
+	nbs, err := c.ListRecentlyViewedProjects()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Reached NotebookLM in %s and found %d notebooks.\n",
+		time.Since(start).Round(10*time.Millisecond), len(nbs))
+	return nil
+}
+
+// prompt asks a question on stderr and returns the answer read from in, or
+// def if the answer is empty.
+func prompt(in *bufio.Reader, question, def string) string {
+	fmt.Fprintf(os.Stderr, "%s [%s]: ", question, def)
+	line, err := in.ReadString('\n')
+	if line = strings.TrimSpace(line); line == "" || (err != nil && err != io.EOF) {
+		return def
+	}
+	return line
+}
+
+// confirm asks a yes or no question.
+func confirm(in *bufio.Reader, question string, def bool) bool {
+	d := "y/N"
+	if def {
+		d = "Y/n"
+	}
+	for {
+		answer := prompt(in, question, d)
+		if answer == d {
+			return def
+		}
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			return true
+		case "n", "no":
+			return false
+		}
+	}
+}
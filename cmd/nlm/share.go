@@ -0,0 +1,353 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	pb "github.com/tmc/nlm/gen/notebooklm/v1alpha1"
+	"github.com/tmc/nlm/internal/access"
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/cleanup"
+	"github.com/tmc/nlm/internal/fanout"
+	"github.com/tmc/nlm/internal/roster"
+)
+
+// Share flags
+var (
+	shareCSV     string
+	shareNotify  bool
+	shareDomains string
+	shareNoPub   bool
+	shareApply   bool
+)
+
+func init() {
+	flag.StringVar(&shareCSV, "csv", "", "with share bulk, CSV `roster` of email addresses and roles")
+	flag.BoolVar(&shareNotify, "notify", true, "with share bulk, email an invitation to each collaborator")
+	flag.StringVar(&shareDomains, "domain", "", "with share report or enforce, comma-separated email `domains` collaborators may belong to (default from .nlm.yaml)")
+	flag.BoolVar(&shareNoPub, "no-public", false, "with share report or enforce, disallow public links")
+	flag.BoolVar(&shareApply, "apply", false, "with share enforce, revoke access instead of listing what would be revoked")
+}
+
+// shareBatchSize is the number of collaborators added per call.
+const shareBatchSize = 25
+
+// shareBulk shares a notebook with everyone in a CSV roster, in batches,
+// and prints the outcome of every row. When a batch is rejected, its rows
+// are retried one at a time so a single bad address does not fail the
+// others.
+func shareBulk(c *api.Client, notebookID, path string) error {
+	if err := requireExperimental("share bulk"); err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("share: %w", err)
+	}
+	rows, err := roster.Read(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("share: %w", err)
+	}
+
+	results := make([]error, len(rows))
+	var batch []int
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		users := make([]*api.Collaborator, len(batch))
+		for i, r := range batch {
+			users[i] = &api.Collaborator{Email: rows[r].Email, Role: pb.Role(rows[r].Role)}
+		}
+		fmt.Fprintf(os.Stderr, "Sharing with %d collaborators...\n", len(users))
+		if err := c.ShareProject(notebookID, users, shareNotify); err != nil && len(batch) > 1 {
+			for i, r := range batch {
+				results[r] = c.ShareProject(notebookID, users[i:i+1], shareNotify)
+			}
+		} else {
+			for _, r := range batch {
+				results[r] = err
+			}
+		}
+		batch = batch[:0]
+	}
+	for i, r := range rows {
+		if r.Err != nil {
+			results[i] = r.Err
+			continue
+		}
+		if batch = append(batch, i); len(batch) == shareBatchSize {
+			flush()
+		}
+	}
+	flush()
+
+	var failed int
+	t := newTable("LINE", "EMAIL", "ROLE", "RESULT")
+	for i, r := range rows {
+		result := "shared"
+		if results[i] != nil {
+			result = results[i].Error()
+			failed++
+		}
+		t.Append(strconv.Itoa(r.Line), r.Email, r.Role.String(), result)
+	}
+	if err := t.Render(os.Stdout); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("share: %d of %d rows failed", failed, len(rows))
+	}
+	return nil
+}
+
+// sharePolicy returns the sharing policy from .nlm.yaml, with -domain
+// replacing its domains and -no-public disallowing public links.
+func sharePolicy() (access.Policy, error) {
+	cfg, err := projectConfig()
+	if err != nil {
+		return access.Policy{}, err
+	}
+	p := cfg.Sharing
+	p.NoPublic = p.NoPublic || shareNoPub
+	if shareDomains != "" {
+		p.Domains = nil
+		for _, d := range strings.Split(shareDomains, ",") {
+			if d = strings.TrimSpace(d); d != "" {
+				p.Domains = append(p.Domains, d)
+			}
+		}
+	}
+	return p, nil
+}
+
+// ownedNotebooks returns the notebooks with the given IDs, or with -all
+// every notebook the caller owns.
+func ownedNotebooks(c *api.Client, ids []string) ([]*api.Notebook, error) {
+	if !backupAll && len(ids) == 0 {
+		return nil, fmt.Errorf("give notebook IDs or -all")
+	}
+	nbs, err := c.ListRecentlyViewedProjects()
+	if err != nil {
+		return nil, err
+	}
+	want := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		want[id] = true
+	}
+	var selected []*api.Notebook
+	for _, nb := range nbs {
+		switch {
+		case backupAll && len(ids) == 0:
+			if r := api.RoleOf(nb); r != api.RoleOwner && r != api.RoleUnknown {
+				continue
+			}
+		case !want[nb.GetProjectId()]:
+			continue
+		}
+		delete(want, nb.GetProjectId())
+		selected = append(selected, nb)
+	}
+	for id := range want {
+		return nil, fmt.Errorf("notebook %s not found", id)
+	}
+	return selected, nil
+}
+
+// notebookAccess is the reviewed sharing of one notebook.
+type notebookAccess struct {
+	ID     string         `json:"id"`
+	Title  string         `json:"title"`
+	Grants []access.Grant `json:"grants"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// violations returns the number of grants that break the policy.
+func (a notebookAccess) violations() int {
+	n := 0
+	for _, g := range a.Grants {
+		if g.Violation != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// reviewAccess fetches and reviews the sharing of each notebook, -workers
+// at a time.
+func reviewAccess(c *api.Client, nbs []*api.Notebook, policy access.Policy) []notebookAccess {
+	ctx, stop := cleanup.NotifyContext(context.Background())
+	defer stop()
+	var started atomic.Int32
+	results := fanout.Run(ctx, nbs, fanoutOptions(), func(_ context.Context, nb *api.Notebook) (notebookAccess, error) {
+		a := notebookAccess{ID: nb.GetProjectId(), Title: strings.TrimSpace(nb.GetTitle())}
+		fmt.Fprintf(os.Stderr, "[%d/%d] %s\n", started.Add(1), len(nbs), a.Title)
+		s, err := c.GetSharing(a.ID)
+		if err != nil {
+			a.Error = err.Error()
+			return a, err
+		}
+		a.Grants = policy.Review(s)
+		return a, nil
+	})
+	out := make([]notebookAccess, 0, len(nbs))
+	for i, r := range results {
+		a := r.Value
+		if r.Skipped() {
+			a = notebookAccess{ID: nbs[i].GetProjectId(), Title: strings.TrimSpace(nbs[i].GetTitle()), Error: r.Err.Error()}
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// shareReport lists who besides their owners can open the given
+// notebooks, flagging public links and collaborators outside the
+// configured domains. It fails if any notebook breaks the policy.
+func shareReport(c *api.Client, ids []string) error {
+	policy, err := sharePolicy()
+	if err != nil {
+		return fmt.Errorf("share report: %w", err)
+	}
+	nbs, err := ownedNotebooks(c, ids)
+	if err != nil {
+		return fmt.Errorf("share report: %w", err)
+	}
+	report := reviewAccess(c, nbs, policy)
+
+	var flagged, failed int
+	for _, a := range report {
+		if a.Error != "" {
+			failed++
+		}
+		if a.violations() > 0 {
+			flagged++
+		}
+	}
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else if templateFile != "" {
+		if err := renderTemplate(os.Stdout, templateFile, report); err != nil {
+			return err
+		}
+	} else {
+		t := newTable("NOTEBOOK", "TITLE", "ACCESS", "ROLE", "FLAG")
+		for _, a := range report {
+			if a.Error != "" {
+				t.Append(a.ID, a.Title, "", "", "error: "+a.Error)
+			}
+			for _, g := range a.Grants {
+				who := g.Email
+				if g.Public() {
+					who = "anyone with the link"
+				}
+				t.Append(a.ID, a.Title, who, g.Role.String(), g.Violation)
+			}
+		}
+		if err := renderTable(t); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%d notebooks reviewed, %d flagged, %d failed\n", len(report), flagged, failed)
+	switch {
+	case failed > 0:
+		return fmt.Errorf("share report: %d notebooks could not be reviewed", failed)
+	case flagged > 0:
+		return fmt.Errorf("share report: %d notebooks break the sharing policy", flagged)
+	}
+	return nil
+}
+
+// shareEnforce revokes public links and collaborators that break the
+// sharing policy on the given notebooks. Without -apply it only lists
+// what would be revoked.
+func shareEnforce(c *api.Client, ids []string) error {
+	policy, err := sharePolicy()
+	if err != nil {
+		return fmt.Errorf("share enforce: %w", err)
+	}
+	if len(policy.Domains) == 0 && !policy.NoPublic {
+		return fmt.Errorf("share enforce: nothing to enforce; give -domain or -no-public, or set sharing in .nlm.yaml")
+	}
+	if shareApply {
+		if err := requireExperimental("share enforce -apply"); err != nil {
+			return err
+		}
+	}
+	nbs, err := ownedNotebooks(c, ids)
+	if err != nil {
+		return fmt.Errorf("share enforce: %w", err)
+	}
+
+	var revoked, failed int
+	t := newTable("NOTEBOOK", "TITLE", "ACCESS", "REASON", "RESULT")
+	for _, a := range reviewAccess(c, nbs, policy) {
+		if a.Error != "" {
+			t.Append(a.ID, a.Title, "", "", "error: "+a.Error)
+			failed++
+			continue
+		}
+		var public bool
+		var emails []string
+		for _, g := range a.Grants {
+			if g.Violation == "" {
+				continue
+			}
+			if g.Public() {
+				public = true
+			} else {
+				emails = append(emails, g.Email)
+			}
+		}
+		result := func(err error) string {
+			switch {
+			case !shareApply:
+				return "would revoke"
+			case err != nil:
+				failed++
+				return "error: " + err.Error()
+			}
+			revoked++
+			return "revoked"
+		}
+		if public {
+			var err error
+			if shareApply {
+				err = c.SetPublic(a.ID, false)
+			}
+			t.Append(a.ID, a.Title, "anyone with the link", "public link", result(err))
+		}
+		if len(emails) > 0 {
+			var err error
+			if shareApply {
+				err = c.RemoveCollaborators(a.ID, emails)
+			}
+			for _, email := range emails {
+				t.Append(a.ID, a.Title, email, "outside "+strings.Join(policy.Domains, ", "), result(err))
+			}
+		}
+	}
+	if err := t.Render(os.Stdout); err != nil {
+		return err
+	}
+	if !shareApply {
+		fmt.Fprintln(os.Stderr, "Dry run; rerun with -apply to revoke.")
+	} else {
+		fmt.Fprintf(os.Stderr, "%d grants revoked\n", revoked)
+	}
+	if failed > 0 {
+		return fmt.Errorf("share enforce: %d failures", failed)
+	}
+	return nil
+}
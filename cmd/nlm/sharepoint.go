@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/cleanup"
+	"github.com/tmc/nlm/internal/graph"
+	"github.com/tmc/nlm/internal/state"
+)
+
+// SharePoint import flags
+var (
+	importSharePoint string
+	sharePointLib    string
+)
+
+func init() {
+	flag.StringVar(&importSharePoint, "sharepoint", "", "with import, add the documents of a SharePoint `site` (such as contoso.sharepoint.com/sites/Eng), or \"me\" for OneDrive, to a notebook")
+	flag.StringVar(&sharePointLib, "library", "Documents", "with import -sharepoint, document library to import")
+}
+
+// sharePointPDF lists the Office formats Graph is asked to convert to PDF
+// before upload; sharePointAsIs those uploaded or converted here.
+var (
+	sharePointPDF   = map[string]bool{".doc": true, ".docx": true, ".ppt": true, ".pptx": true, ".rtf": true, ".odt": true, ".odp": true}
+	sharePointAsIs  = map[string]bool{".pdf": true, ".txt": true, ".md": true, ".csv": true, ".xlsx": true, ".html": true, ".htm": true}
+	graphTokenState = filepath.Join("graph", "token.json")
+)
+
+// sharePointState records the documents of a library imported into a
+// notebook, so later imports only add new and changed documents.
+type sharePointState struct {
+	Site    string                        `json:"site"`
+	Library string                        `json:"library"`
+	Files   map[string]sharePointDocument `json:"files"` // by item ID
+}
+
+type sharePointDocument struct {
+	Path     string    `json:"path"`
+	Version  string    `json:"version"`
+	SourceID string    `json:"source_id"`
+	SyncedAt time.Time `json:"synced_at"`
+}
+
+// importSharePointLibrary adds the documents of a SharePoint library, or
+// of the user's OneDrive, to a notebook. Word and PowerPoint files are
+// converted to PDF by Microsoft Graph; PDFs, text and spreadsheets are
+// added like local files, through the converters and upload filters.
+// Documents are matched by item ID across runs: unchanged ones are
+// skipped and changed ones replace their previous source.
+func importSharePointLibrary(c *api.Client, notebookID string) error {
+	st, err := openState()
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	ctx, stop := cleanup.NotifyContext(context.Background())
+	defer stop()
+	token, err := graphToken(ctx, st)
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	gc := &graph.Client{Token: token.AccessToken}
+	drive, err := gc.Drive(ctx, importSharePoint, sharePointLib)
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Listing %s...\n", sharePointLib)
+	files, err := gc.Files(ctx, drive)
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+
+	sum := sha256.Sum256([]byte(importSharePoint + "\x00" + sharePointLib))
+	name := filepath.Join("sharepoint", notebookID+"-"+hex.EncodeToString(sum[:8])+".json")
+	ss := &sharePointState{Site: importSharePoint, Library: sharePointLib, Files: make(map[string]sharePointDocument)}
+	if err := st.Load(name, ss); err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	var pending []graph.Item
+	var unsupported, newFiles int
+	for _, f := range files {
+		ext := strings.ToLower(path.Ext(f.Name))
+		if !sharePointPDF[ext] && !sharePointAsIs[ext] {
+			unsupported++
+			continue
+		}
+		old, seen := ss.Files[f.ID]
+		if seen && old.Version == f.Version {
+			continue
+		}
+		if !seen {
+			newFiles++
+		}
+		pending = append(pending, f)
+	}
+	if unsupported > 0 {
+		fmt.Fprintf(os.Stderr, "Skipping %d files of unsupported types\n", unsupported)
+	}
+	if len(pending) == 0 {
+		fmt.Fprintln(os.Stderr, "All documents are up to date.")
+		return nil
+	}
+	nb, err := c.GetProject(notebookID)
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	if room := estimateMaxSources - len(nb.Sources); newFiles > room {
+		return fmt.Errorf("import: %d new documents but room for %d more sources (limit %d, see -max-sources)", newFiles, max(room, 0), estimateMaxSources)
+	}
+
+	dir, err := cleanup.MkdirTemp("", "nlm-sharepoint-")
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	defer cleanup.Remove(dir)
+	var added, updated, failed int
+	t := newTable("DOCUMENT", "RESULT")
+	for i, f := range pending {
+		fmt.Fprintf(os.Stderr, "[%d/%d] %s\n", i+1, len(pending), f.Path)
+		id, err := addSharePointFile(ctx, c, gc, drive, dir, notebookID, f)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			t.Append(f.Path, "error: "+err.Error())
+			failed++
+			continue
+		}
+		old, seen := ss.Files[f.ID]
+		ss.Files[f.ID] = sharePointDocument{Path: f.Path, Version: f.Version, SourceID: id, SyncedAt: time.Now().UTC()}
+		if err := st.Save(name, ss); err != nil {
+			return fmt.Errorf("import: %w", err)
+		}
+		if !seen {
+			t.Append(f.Path, "added")
+			added++
+			continue
+		}
+		result := "updated"
+		if err := deleteSources(c, notebookID, []string{old.SourceID}); err != nil {
+			result = "updated; previous version not removed: " + err.Error()
+		}
+		t.Append(f.Path, result)
+		updated++
+	}
+	if err := t.Render(os.Stdout); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d added, %d updated, %d failed\n", added, updated, failed)
+	if failed > 0 {
+		return fmt.Errorf("import: %d of %d documents failed", failed, len(pending))
+	}
+	return nil
+}
+
+// addSharePointFile downloads a document into dir, as PDF for Office
+// formats, and adds it as a local file would be.
+func addSharePointFile(ctx context.Context, c *api.Client, gc *graph.Client, drive, dir, notebookID string, f graph.Item) (string, error) {
+	ext := strings.ToLower(path.Ext(f.Name))
+	asPDF := sharePointPDF[ext]
+	data, err := gc.Download(ctx, drive, f, asPDF)
+	if err != nil {
+		return "", err
+	}
+	local := filepath.Join(dir, f.Name)
+	if asPDF {
+		local = strings.TrimSuffix(local, path.Ext(f.Name)) + ".pdf"
+	}
+	if err := os.WriteFile(local, data, 0o600); err != nil {
+		return "", err
+	}
+	defer os.Remove(local)
+	return addSource(c, notebookID, local)
+}
+
+// graphToken returns a Microsoft Graph access token: the saved one, renewed
+// if it has expired, or a new one from signing in with a device code. The
+// app registration is given by NLM_GRAPH_CLIENT_ID and the directory by
+// NLM_GRAPH_TENANT.
+func graphToken(ctx context.Context, st *state.Store) (*graph.Token, error) {
+	a := &graph.DeviceAuth{
+		ClientID: settings.Services.GraphClientID,
+		Tenant:   settings.Services.GraphTenant,
+		Prompt: func(u, code string) {
+			fmt.Fprintf(os.Stderr, "To sign in to Microsoft, open %s and enter the code %s\n", u, code)
+		},
+	}
+	if a.ClientID == "" {
+		return nil, errors.New("set NLM_GRAPH_CLIENT_ID to the application ID of an Azure app registration that allows public client flows")
+	}
+	tok := new(graph.Token)
+	if err := st.Load(graphTokenState, tok); err != nil {
+		return nil, err
+	}
+	if tok.Valid() {
+		return tok, nil
+	}
+	var err error
+	if tok.RefreshToken != "" {
+		if tok, err = a.Refresh(ctx, tok); err != nil {
+			fmt.Fprintf(os.Stderr, "Renewing the Microsoft sign-in failed (%v); signing in again\n", err)
+		}
+	}
+	if tok == nil || err != nil || !tok.Valid() {
+		if tok, err = a.Login(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if err := st.Save(graphTokenState, tok); err != nil {
+		return nil, err
+	}
+	return tok, nil
+}
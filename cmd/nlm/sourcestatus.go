@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	pb "github.com/tmc/nlm/gen/notebooklm/v1alpha1"
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/errinfo"
+)
+
+// setSourcesEnabled turns sources of a notebook on or off for chat and
+// generation. IDs that are not sources of the notebook are refused before
+// anything changes.
+func setSourcesEnabled(c *api.Client, notebookID string, sourceIDs []string, enabled bool) error {
+	verb := "disable"
+	if enabled {
+		verb = "enable"
+	}
+	sources, err := notebookSources(c, notebookID, sourceIDs)
+	if err != nil {
+		return fmt.Errorf("sources %s: %w", verb, err)
+	}
+
+	want := pb.SourceSettings_SOURCE_STATUS_DISABLED
+	if enabled {
+		want = pb.SourceSettings_SOURCE_STATUS_ENABLED
+	}
+	var failed int
+	t := newTable("ID", "TITLE", "RESULT")
+	for _, id := range sourceIDs {
+		title := strings.TrimSpace(sources[id].GetTitle())
+		if sources[id].GetSettings().GetStatus() == want {
+			t.Append(id, title, "already "+verb+"d")
+			continue
+		}
+		if _, err := c.SetSourceEnabled(id, enabled); err != nil {
+			t.Append(id, title, "error: "+err.Error())
+			failed++
+			continue
+		}
+		t.Append(id, title, verb+"d")
+	}
+	if err := t.Render(os.Stdout); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("sources %s: %d of %d sources failed", verb, failed, len(sourceIDs))
+	}
+	return nil
+}
+
+// notebookSources returns the sources of a notebook with the given IDs, by
+// ID, or an error naming those that are not sources of the notebook.
+func notebookSources(c *api.Client, notebookID string, sourceIDs []string) (map[string]*pb.Source, error) {
+	nb, err := c.GetProject(notebookID)
+	if err != nil {
+		return nil, err
+	}
+	sources := make(map[string]*pb.Source)
+	for _, src := range nb.GetSources() {
+		sources[src.GetSourceId().GetSourceId()] = src
+	}
+	var missing []string
+	for _, id := range sourceIDs {
+		if sources[id] == nil {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("not sources of notebook %s: %s", notebookID, strings.Join(missing, ", "))
+	}
+	return sources, nil
+}
+
+// driveSourceTypes are the sources NotebookLM can sync with their
+// Google Drive documents.
+var driveSourceTypes = map[pb.SourceType]bool{
+	pb.SourceType_SOURCE_TYPE_GOOGLE_DOCS:   true,
+	pb.SourceType_SOURCE_TYPE_GOOGLE_SLIDES: true,
+	pb.SourceType_SOURCE_TYPE_GOOGLE_SHEETS: true,
+}
+
+// reprocessSources has NotebookLM sync Google Drive sources of a notebook
+// with their documents, through the same call as refresh-source, so that
+// they are processed again from the current version. Other sources are
+// refused before any is synced, since the call does not regenerate them.
+// Processing continues in the background; the STATUS column of nlm
+// sources shows when it is done.
+func reprocessSources(c *api.Client, notebookID string, sourceIDs []string) error {
+	sources, err := notebookSources(c, notebookID, sourceIDs)
+	if err != nil {
+		return fmt.Errorf("sources reprocess: %w", err)
+	}
+	var other []string
+	for _, id := range sourceIDs {
+		if !driveSourceTypes[sources[id].GetMetadata().GetSourceType()] {
+			other = append(other, id)
+		}
+	}
+	if len(other) > 0 {
+		return errinfo.Usagef("sources reprocess: not Google Drive sources: %s; only Google Docs, Slides and Sheets can be synced", strings.Join(other, ", "))
+	}
+	var failed int
+	t := newTable("ID", "TITLE", "RESULT")
+	for _, id := range sourceIDs {
+		title := strings.TrimSpace(sources[id].GetTitle())
+		if _, err := c.RefreshSource(id); err != nil {
+			t.Append(id, title, "error: "+err.Error())
+			failed++
+			continue
+		}
+		t.Append(id, title, "reprocessing")
+	}
+	if err := t.Render(os.Stdout); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("sources reprocess: %d of %d sources failed", failed, len(sourceIDs))
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/tmc/nlm/internal/config"
+	"github.com/tmc/nlm/internal/state"
+)
+
+var (
+	stateOnce  sync.Once
+	stateStore *state.Store
+	stateErr   error
+)
+
+// bootstrap returns the settings read before the others: the state
+// directory and its encryption, the ingest configuration, the fake API
+// scenario and the job ID. They are looked up on every call, as nlm fake
+// sets NLM_HOME after startup.
+func bootstrap() config.Bootstrap {
+	return config.ResolveBootstrap(os.Getenv)
+}
+
+// stateDir returns the state directory, NLM_HOME or ~/.nlm.
+func stateDir() (string, error) {
+	return state.Dir(bootstrap().Home)
+}
+
+// openState returns the local state store. Files are encrypted when
+// NLM_STATE_PASSPHRASE is set, or with a key from the OS keychain when
+// NLM_STATE_KEYCHAIN is set.
+func openState() (*state.Store, error) {
+	stateOnce.Do(func() {
+		dir, err := stateDir()
+		if err != nil {
+			stateErr = err
+			return
+		}
+		var opts []state.Option
+		b := bootstrap()
+		if b.StatePassphrase != "" {
+			opts = append(opts, state.WithPassphrase(b.StatePassphrase))
+		} else if b.StateKeychain {
+			key, err := state.KeychainKey()
+			if err != nil {
+				stateErr = fmt.Errorf("state key: %w", err)
+				return
+			}
+			opts = append(opts, state.WithKey(key))
+		}
+		stateStore, stateErr = state.Open(dir, opts...)
+	})
+	return stateStore, stateErr
+}
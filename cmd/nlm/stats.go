@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tmc/nlm/internal/rpc"
+)
+
+// statsUsage selects the usage summary of nlm stats.
+var statsUsage bool
+
+func init() {
+	flag.BoolVar(&statsUsage, "usage", false, "with stats, summarize your own command usage from the local history")
+}
+
+// generationRPCs maps the calls that generate content to the kind of
+// content, for counting generations against quotas.
+var generationRPCs = map[string]string{
+	rpc.RPCGenerateNotebookGuide:  "guide",
+	rpc.RPCGenerateDocumentGuides: "guide",
+	rpc.RPCGenerateOutline:        "outline",
+	rpc.RPCGenerateSection:        "section",
+	rpc.RPCStartSection:           "section",
+	rpc.RPCStartDraft:             "draft",
+	rpc.RPCCreateAudioOverview:    "audio",
+}
+
+// usageStats summarizes the history of one period.
+type usageStats struct {
+	Since       time.Time      `json:"since"`
+	Commands    int            `json:"commands"`
+	Failed      int            `json:"failed"`
+	Requests    int            `json:"requests"`
+	SentBytes   int64          `json:"sent_bytes"`
+	Uploads     int            `json:"uploads"`
+	UploadBytes int64          `json:"upload_bytes"`
+	Generations map[string]int `json:"generations"`
+	ByCommand   []commandUsage `json:"by_command"`
+	ByDay       []dayUsage     `json:"by_day"`
+	byCommand   map[string]*commandUsage
+	byDay       map[string]*dayUsage
+}
+
+type commandUsage struct {
+	Command    string `json:"command"`
+	Runs       int    `json:"runs"`
+	Failed     int    `json:"failed"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+type dayUsage struct {
+	Date        string `json:"date"`
+	Commands    int    `json:"commands"`
+	Requests    int    `json:"requests"`
+	UploadBytes int64  `json:"upload_bytes"`
+	Generations int    `json:"generations"`
+}
+
+// add counts one history entry.
+func (s *usageStats) add(e historyEntry) {
+	s.Commands++
+	cu := s.byCommand[e.Command]
+	if cu == nil {
+		cu = &commandUsage{Command: e.Command}
+		s.byCommand[e.Command] = cu
+	}
+	cu.Runs++
+	cu.DurationMS += e.DurationMS
+	if e.Error != "" {
+		s.Failed++
+		cu.Failed++
+	}
+	date := e.Time.Local().Format("2006-01-02")
+	du := s.byDay[date]
+	if du == nil {
+		du = &dayUsage{Date: date}
+		s.byDay[date] = du
+	}
+	du.Commands++
+	if e.Usage == nil {
+		return
+	}
+	for id, n := range e.Usage.Calls {
+		s.Requests += n
+		du.Requests += n
+		if kind, ok := generationRPCs[id]; ok {
+			s.Generations[kind] += n
+			du.Generations += n
+		}
+		if id == rpc.RPCAddSources {
+			s.Uploads += n
+		}
+	}
+	for id, n := range e.Usage.Bytes {
+		s.SentBytes += n
+		if id == rpc.RPCAddSources {
+			s.UploadBytes += n
+			du.UploadBytes += n
+		}
+	}
+}
+
+// usageStatsSince summarizes the recorded commands run since the given
+// time. The history is never sent anywhere; it only holds the most recent
+// maxHistoryEntries commands.
+func usageStatsSince(since time.Time) (*usageStats, error) {
+	st, err := openState()
+	if err != nil {
+		return nil, err
+	}
+	data, err := st.ReadFile(historyFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	s := &usageStats{
+		Since:       since,
+		Generations: make(map[string]int),
+		byCommand:   make(map[string]*commandUsage),
+		byDay:       make(map[string]*dayUsage),
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		var e historyEntry
+		if strings.TrimSpace(line) == "" || json.Unmarshal([]byte(line), &e) != nil {
+			continue
+		}
+		if e.Time.Before(since) || e.Command == "stats" {
+			continue
+		}
+		s.add(e)
+	}
+	for _, cu := range s.byCommand {
+		s.ByCommand = append(s.ByCommand, *cu)
+	}
+	sort.Slice(s.ByCommand, func(i, j int) bool {
+		a, b := s.ByCommand[i], s.ByCommand[j]
+		if a.Runs != b.Runs {
+			return a.Runs > b.Runs
+		}
+		return a.Command < b.Command
+	})
+	for _, du := range s.byDay {
+		s.ByDay = append(s.ByDay, *du)
+	}
+	sort.Slice(s.ByDay, func(i, j int) bool { return s.ByDay[i].Date < s.ByDay[j].Date })
+	return s, nil
+}
+
+// showUsageStats prints a summary of the user's own nlm usage since
+// -since, from the local command history.
+func showUsageStats() error {
+	since, err := parseSince(sinceFlag, time.Now())
+	if err != nil {
+		return fmt.Errorf("stats: %w", err)
+	}
+	s, err := usageStatsSince(since)
+	if err != nil {
+		return fmt.Errorf("stats: %w", err)
+	}
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(s)
+	}
+	if s.Commands == 0 {
+		fmt.Fprintf(os.Stderr, "No commands recorded since %s.\n", since.Format("2006-01-02 15:04"))
+		return nil
+	}
+	fmt.Printf("Since %s: %d commands (%d failed), %d requests, %s sent\n",
+		since.Format("2006-01-02 15:04"), s.Commands, s.Failed, s.Requests, formatBytes(s.SentBytes))
+	fmt.Printf("Uploads: %d sources, %s\n", s.Uploads, formatBytes(s.UploadBytes))
+	fmt.Printf("Generations: %s\n\n", formatGenerations(s.Generations))
+
+	t := newTable("COMMAND", "RUNS", "FAILED", "AVG TIME")
+	for _, cu := range s.ByCommand {
+		avg := time.Duration(cu.DurationMS/int64(cu.Runs)) * time.Millisecond
+		t.Append(cu.Command, strconv.Itoa(cu.Runs), strconv.Itoa(cu.Failed), avg.Round(100*time.Millisecond).String())
+	}
+	if err := t.Render(os.Stdout); err != nil {
+		return err
+	}
+	fmt.Println()
+	t = newTable("DATE", "COMMANDS", "REQUESTS", "UPLOADED", "GENERATIONS")
+	for _, du := range s.ByDay {
+		t.Append(du.Date, strconv.Itoa(du.Commands), strconv.Itoa(du.Requests), formatBytes(du.UploadBytes), strconv.Itoa(du.Generations))
+	}
+	return t.Render(os.Stdout)
+}
+
+// formatGenerations lists generation counts by kind, such as
+// "3 guide, 1 audio".
+func formatGenerations(g map[string]int) string {
+	if len(g) == 0 {
+		return "none"
+	}
+	kinds := make([]string, 0, len(g))
+	for k := range g {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	parts := make([]string, len(kinds))
+	for i, k := range kinds {
+		parts[i] = fmt.Sprintf("%d %s", g[k], k)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/dirsync"
+)
+
+var syncConflict string
+
+func init() {
+	flag.StringVar(&syncConflict, "conflict", "prompt", "with sync, resolve files changed on both sides: prefer-local, prefer-remote or prompt")
+}
+
+// syncDir makes the notebook's sources mirror the files in dir. Files
+// edited locally are uploaded again, and files deleted locally have their
+// sources removed, unless the notebook changed the same source since the
+// last sync; such conflicts are resolved by -conflict.
+func syncDir(c *api.Client, notebookID, dir string) error {
+	policy, err := dirsync.ParsePolicy(syncConflict)
+	if err != nil {
+		return err
+	}
+	m, err := dirsync.Open(dir, notebookID)
+	if err != nil {
+		return fmt.Errorf("sync: %w", err)
+	}
+	local, err := dirsync.Scan(dir)
+	if err != nil {
+		return fmt.Errorf("sync: %w", err)
+	}
+	remote, err := remoteModTimes(c, notebookID)
+	if err != nil {
+		return fmt.Errorf("sync: %w", err)
+	}
+
+	var uploaded, deleted, kept, skipped, failed int
+	plan := dirsync.Plan(m, local, remote)
+	for i, ch := range plan {
+		reportStep("sync", ch.Path, i, len(plan))
+		if ch.Conflict != "" {
+			switch resolveConflict(policy, ch) {
+			case dirsync.PreferRemote:
+				fmt.Fprintf(os.Stderr, "Keeping notebook version of %s (%s)\n", ch.Path, ch.Conflict)
+				m.KeepRemote(ch, remote)
+				kept++
+				continue
+			case "":
+				fmt.Fprintf(os.Stderr, "Skipping %s (%s)\n", ch.Path, ch.Conflict)
+				skipped++
+				continue
+			}
+		}
+		switch ch.Action {
+		case dirsync.Upload, dirsync.Replace:
+			id, err := addSource(c, notebookID, filepath.Join(dir, filepath.FromSlash(ch.Path)))
+			if err != nil {
+				fmt.Fprintf(errorOutput, "sync %s: %v\n", ch.Path, err)
+				failed++
+				continue
+			}
+			if ch.Action == dirsync.Replace {
+				if err := deleteSources(c, notebookID, []string{ch.SourceID}); err != nil {
+					fmt.Fprintf(errorOutput, "sync %s: remove previous source %s: %v\n", ch.Path, ch.SourceID, err)
+				}
+			}
+			m.Uploaded(ch.Path, ch.Hash, id)
+			uploaded++
+		case dirsync.Delete:
+			fmt.Fprintf(os.Stderr, "Removing source of deleted file %s\n", ch.Path)
+			if err := deleteSources(c, notebookID, []string{ch.SourceID}); err != nil {
+				fmt.Fprintf(errorOutput, "sync %s: %v\n", ch.Path, err)
+				failed++
+				continue
+			}
+			m.Apply(ch)
+			deleted++
+		case dirsync.Tombstone:
+			fmt.Fprintf(os.Stderr, "Not re-adding %s: its source was removed in the notebook\n", ch.Path)
+			m.Apply(ch)
+		default:
+			m.Apply(ch)
+		}
+		if err := m.Save(); err != nil {
+			return fmt.Errorf("sync: %w", err)
+		}
+	}
+
+	if uploaded > 0 {
+		if remote, err = remoteModTimes(c, notebookID); err != nil {
+			return fmt.Errorf("sync: %w", err)
+		}
+	}
+	reportDone("sync", len(plan))
+	m.Stamp(local, remote)
+	if err := m.Save(); err != nil {
+		return fmt.Errorf("sync: %w", err)
+	}
+	summarize("Synced %s: %d uploaded, %d removed, %d kept from notebook, %d skipped", dir, uploaded, deleted, kept, skipped)
+	if failed > 0 {
+		return fmt.Errorf("sync: %d changes failed", failed)
+	}
+	return nil
+}
+
+// remoteModTimes returns the notebook's source IDs and their server-side
+// modification times, zero where the server reports none.
+func remoteModTimes(c *api.Client, notebookID string) (map[string]time.Time, error) {
+	p, err := c.GetProject(notebookID)
+	if err != nil {
+		return nil, err
+	}
+	remote := make(map[string]time.Time, len(p.Sources))
+	for _, src := range p.Sources {
+		var mod time.Time
+		if ts := src.GetMetadata().GetLastModifiedTime(); ts != nil {
+			mod = ts.AsTime()
+		}
+		remote[src.GetSourceId().GetSourceId()] = mod
+	}
+	return remote, nil
+}
+
+// resolveConflict returns the side that wins a conflict, asking on the
+// terminal under the prompt policy; "" means skip the file this time.
+func resolveConflict(policy dirsync.Policy, ch dirsync.Change) dirsync.Policy {
+	if policy != dirsync.Prompt {
+		return policy
+	}
+	fmt.Fprintf(os.Stderr, "Conflict: %s (%s). Keep [l]ocal, [r]emote or [s]kip? ", ch.Path, ch.Conflict)
+	var response string
+	fmt.Scanln(&response)
+	switch strings.ToLower(strings.TrimSpace(response)) {
+	case "l", "local":
+		return dirsync.PreferLocal
+	case "r", "remote":
+		return dirsync.PreferRemote
+	}
+	return ""
+}
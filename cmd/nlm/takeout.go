@@ -0,0 +1,225 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/takeout"
+)
+
+// Takeout import flags
+var (
+	importTakeout string
+	importAs      string
+)
+
+func init() {
+	flag.StringVar(&importTakeout, "takeout", "", "with import, add the Google Keep notes and Tasks lists in these comma-separated Takeout `zips` to a notebook")
+	flag.StringVar(&importAs, "as", "notes", "with import -takeout and pipe, add items as notes or sources")
+}
+
+// takeoutIndex is the local index of the Takeout items imported into a
+// notebook. It keeps each item's Keep labels as tags, which NotebookLM has
+// no place for.
+type takeoutIndex struct {
+	Items map[string]takeoutItem `json:"items"` // by kind/ID
+}
+
+type takeoutItem struct {
+	Kind       string    `json:"kind"` // keep or tasks
+	Title      string    `json:"title"`
+	Tags       []string  `json:"tags,omitempty"`
+	As         string    `json:"as"` // note or source
+	ID         string    `json:"id"` // of the note or source
+	Edited     time.Time `json:"edited"`
+	ImportedAt time.Time `json:"imported_at"`
+}
+
+// takeoutDoc is a Keep note or task list to import.
+type takeoutDoc struct {
+	key    string
+	item   takeoutItem
+	text   string
+	edited time.Time
+}
+
+// importTakeoutArchive adds the Keep notes and Tasks lists of a Google
+// Takeout export to a notebook, as notes or, with -as sources, as text
+// sources. Labels are written into each item and recorded as tags in the
+// local index. Items imported before are skipped unless they were edited
+// since, in which case the note is updated or the source replaced.
+// Trashed Keep notes are left out.
+func importTakeoutArchive(c *api.Client, notebookID string) error {
+	if importAs != "notes" && importAs != "sources" {
+		return fmt.Errorf("import: -as must be notes or sources, not %q", importAs)
+	}
+	as := strings.TrimSuffix(importAs, "s")
+	var tags []string
+	for _, t := range strings.Split(importTags, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	a, err := takeout.Open(strings.Split(importTakeout, ",")...)
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	st, err := openState()
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	name := filepath.Join("takeout", notebookID+".json")
+	idx := &takeoutIndex{Items: make(map[string]takeoutItem)}
+	if err := st.Load(name, idx); err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	p, err := ingestPipeline()
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+
+	var docs []takeoutDoc
+	for _, n := range a.Notes {
+		if n.Trashed || !hasAnyTag(n.Labels, tags) {
+			continue
+		}
+		title := keepTitle(n)
+		docs = append(docs, takeoutDoc{
+			key:    "keep/" + n.ID,
+			item:   takeoutItem{Kind: "keep", Title: title, Tags: n.Labels},
+			text:   keepDocument(n, title),
+			edited: n.Edited,
+		})
+	}
+	for _, l := range a.Lists {
+		if len(l.Tasks) == 0 || !hasAnyTag([]string{"tasks"}, tags) {
+			continue
+		}
+		title := "Tasks: " + l.Title
+		docs = append(docs, takeoutDoc{
+			key:    "tasks/" + l.ID,
+			item:   takeoutItem{Kind: "tasks", Title: title, Tags: []string{"tasks"}},
+			text:   fmt.Sprintf("# %s\n\n%s", title, l.Markdown()),
+			edited: l.Updated,
+		})
+	}
+	if len(docs) == 0 {
+		return fmt.Errorf("import: no Keep notes or Tasks lists in %s", importTakeout)
+	}
+
+	var added, updated, skipped, failed int
+	t := newTable("KIND", "TITLE", "TAGS", "RESULT")
+	for _, d := range docs {
+		old, seen := idx.Items[d.key]
+		if seen && !d.edited.After(old.Edited) {
+			skipped++
+			continue
+		}
+		text, _ := filterText(p, d.item.Title, d.text)
+		var id string
+		switch {
+		case seen && old.As == "note":
+			if _, err = c.MutateNote(notebookID, old.ID, text, d.item.Title); err == nil {
+				id = old.ID
+			}
+		case as == "note":
+			var note *api.Note
+			if note, err = c.CreateNote(notebookID, d.item.Title, text); err == nil {
+				id = note.GetSourceId().GetSourceId()
+			}
+		default:
+			if id, err = c.AddSourceFromText(notebookID, text, d.item.Title); err == nil && seen {
+				err = deleteSources(c, notebookID, []string{old.ID})
+			}
+		}
+		tagList := strings.Join(d.item.Tags, ", ")
+		if err != nil {
+			t.Append(d.item.Kind, d.item.Title, tagList, "error: "+err.Error())
+			failed++
+			continue
+		}
+		d.item.As, d.item.ID = as, id
+		if seen {
+			d.item.As = old.As
+			updated++
+		} else {
+			added++
+		}
+		d.item.Edited, d.item.ImportedAt = d.edited, time.Now().UTC()
+		idx.Items[d.key] = d.item
+		if err := st.Save(name, idx); err != nil {
+			return fmt.Errorf("import: %w", err)
+		}
+		result := "added"
+		if seen {
+			result = "updated"
+		}
+		t.Append(d.item.Kind, d.item.Title, tagList, result)
+	}
+	if added+updated+failed > 0 {
+		if err := t.Render(os.Stdout); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%d added, %d updated, %d unchanged, %d failed\n", added, updated, skipped, failed)
+	if failed > 0 {
+		return fmt.Errorf("import: %d of %d items failed", failed, len(docs))
+	}
+	return nil
+}
+
+// hasAnyTag reports whether have includes any of want, ignoring case. It
+// is true if want is empty.
+func hasAnyTag(have, want []string) bool {
+	if len(want) == 0 {
+		return true
+	}
+	for _, w := range want {
+		for _, h := range have {
+			if strings.EqualFold(h, w) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// keepTitle is the title of a Keep note, or for untitled notes the start
+// of its first line.
+func keepTitle(n takeout.KeepNote) string {
+	if n.Title != "" {
+		return n.Title
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(n.Markdown()), "\n")
+	line = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(line, "- [ ]"), "- [x]"))
+	if r := []rune(line); len(r) > 60 {
+		line = string(r[:60]) + "…"
+	}
+	if line == "" {
+		return "Keep note " + n.Edited.Format("2006-01-02")
+	}
+	return line
+}
+
+// keepDocument is the text of a Keep note: its title, labels and dates,
+// then its content.
+func keepDocument(n takeout.KeepNote, title string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	if len(n.Labels) > 0 {
+		fmt.Fprintf(&b, "Labels: %s\n", strings.Join(n.Labels, ", "))
+	}
+	if !n.Edited.IsZero() {
+		fmt.Fprintf(&b, "Edited: %s\n", n.Edited.Format("2006-01-02"))
+	}
+	if n.Archived {
+		b.WriteString("Archived in Keep\n")
+	}
+	b.WriteString("\n" + n.Markdown())
+	return b.String()
+}
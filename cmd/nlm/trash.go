@@ -0,0 +1,353 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/cleanup"
+	"github.com/tmc/nlm/internal/errinfo"
+	"github.com/tmc/nlm/internal/export"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var (
+	// useTrash makes every command that deletes a notebook or sources
+	// keep a local copy of them first. trash.enabled in the global
+	// configuration turns it on.
+	useTrash bool
+	// trashKeep is how long copies are kept, from trash.keep in the
+	// global configuration.
+	trashKeep string
+)
+
+func init() {
+	flag.BoolVar(&useTrash, "trash", false, "keep a local copy of deleted notebooks and sources, including sources replaced by sync and import, for nlm trash restore-local")
+}
+
+// defaultTrashKeep is how long copies are kept unless trash.keep says
+// otherwise.
+const defaultTrashKeep = "30d"
+
+// parseTrashName parses the file name of a trash entry, made of
+// export.SnapshotName of when the copy was made, whether it holds a whole
+// notebook or some of its sources, and the notebook ID.
+func parseTrashName(file string) (deleted time.Time, kind, notebookID string, ok bool) {
+	stamp, rest, _ := strings.Cut(strings.TrimSuffix(file, ".zip"), "-")
+	kind, notebookID, _ = strings.Cut(rest, "-")
+	if !strings.HasSuffix(file, ".zip") || kind != "notebook" && kind != "sources" || notebookID == "" {
+		return time.Time{}, "", "", false
+	}
+	deleted, err := export.ParseSnapshotName(stamp)
+	if err != nil {
+		return time.Time{}, "", "", false
+	}
+	return deleted, kind, notebookID, true
+}
+
+// trashDir returns the directory trash entries are kept in.
+func trashDir() (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "trash"), nil
+}
+
+// errTrashFailed explains why nothing was deleted.
+const errTrashFailed = "nothing was deleted, as copying to the trash failed (use -trash=false to delete anyway)"
+
+// deleteNotebook deletes a notebook, with -trash after copying it to the
+// trash. Nothing is deleted if the copy fails.
+func deleteNotebook(c *api.Client, id string) error {
+	if err := moveToTrash(c, id, nil); err != nil {
+		return fmt.Errorf("%s: %w", errTrashFailed, err)
+	}
+	return c.DeleteProjects([]string{id})
+}
+
+// deleteSources deletes sources of a notebook, with -trash after copying
+// them to the trash. Nothing is deleted if the copy fails. Every command
+// that deletes or replaces sources goes through it.
+func deleteSources(c *api.Client, notebookID string, sourceIDs []string) error {
+	if err := moveToTrash(c, notebookID, sourceIDs); err != nil {
+		return fmt.Errorf("%s: %w", errTrashFailed, err)
+	}
+	return c.DeleteSources(notebookID, sourceIDs)
+}
+
+// moveToTrash saves a copy of a notebook, or of the given sources of it,
+// to the trash before they are deleted, and removes expired entries. It
+// does nothing without -trash.
+func moveToTrash(c *api.Client, notebookID string, sourceIDs []string) error {
+	// In read-only mode the deletion is refused, so there is nothing to
+	// keep a copy of.
+	if !useTrash || readOnly {
+		return nil
+	}
+	root, err := trashDir()
+	if err != nil {
+		return err
+	}
+	if err := pruneTrash(root, time.Now()); err != nil {
+		return err
+	}
+	staging, err := cleanup.MkdirTemp("", "nlm-trash-*")
+	if err != nil {
+		return err
+	}
+	defer cleanup.Remove(staging)
+	m, err := export.Open(staging, notebookID)
+	if err != nil {
+		return err
+	}
+	if err := trashItems(c, m, notebookID, sourceIDs); err != nil {
+		return err
+	}
+	if err := m.Finish(); err != nil {
+		return err
+	}
+	kind := "notebook"
+	if sourceIDs != nil {
+		kind = "sources"
+	}
+	if err := os.MkdirAll(root, 0o700); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s-%s.zip", export.SnapshotName(time.Now()), kind, notebookID)
+	if err := writeZipAtomic(m, filepath.Join(root, name)); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Saved a copy to the trash: nlm trash restore-local %s\n", strings.TrimSuffix(name, ".zip"))
+	return nil
+}
+
+// trashItems writes the notebook and the sources to delete into m, with
+// the notes too when the whole notebook is deleted. Audio overviews are
+// left out, as they cannot be uploaded again.
+func trashItems(c *api.Client, m *export.Manifest, notebookID string, sourceIDs []string) error {
+	p, err := c.GetProject(notebookID)
+	if err != nil {
+		return err
+	}
+	m.Title = strings.TrimSpace(p.Title)
+	nb, err := protojson.MarshalOptions{Multiline: true}.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("encode notebook: %w", err)
+	}
+	if _, err := m.Write(export.KindNotebook, notebookID, m.Title, "notebook.json", nb); err != nil {
+		return err
+	}
+	want := make(map[string]bool)
+	for _, id := range sourceIDs {
+		want[id] = true
+	}
+	for _, src := range p.Sources {
+		id := src.GetSourceId().GetSourceId()
+		if sourceIDs != nil && !want[id] {
+			continue
+		}
+		delete(want, id)
+		title := strings.TrimSpace(src.GetTitle())
+		raw, err := c.LoadSourceRaw(id)
+		if err != nil {
+			return fmt.Errorf("source %s: %w", id, err)
+		}
+		rel := filepath.Join("sources", outputFilename(title+".json"))
+		if _, err := m.Write(export.KindSource, id, title, rel, raw); err != nil {
+			return err
+		}
+	}
+	for id := range want {
+		return fmt.Errorf("source %s is not in notebook %s", id, notebookID)
+	}
+	if sourceIDs != nil {
+		return nil
+	}
+	notes, err := c.GetNotesRaw(notebookID)
+	if err != nil {
+		return err
+	}
+	for _, raw := range notes {
+		id, title, _, err := api.NoteContent(raw)
+		if err != nil {
+			return err
+		}
+		title = strings.TrimSpace(title)
+		rel := filepath.Join("notes", outputFilename(title+".json"))
+		if _, err := m.Write(export.KindNote, id, title, rel, raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pruneTrash removes the entries of root older than the retention period.
+func pruneTrash(root string, now time.Time) error {
+	keep := trashKeep
+	if keep == "" {
+		keep = defaultTrashKeep
+	}
+	cutoff, err := parseSince(keep, now)
+	if err != nil {
+		return fmt.Errorf("trash.keep: %w", err)
+	}
+	entries, err := trashEntries(root)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.Deleted.Before(cutoff) {
+			if err := os.Remove(e.path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// trashEntry is a copy in the trash.
+type trashEntry struct {
+	Name       string    `json:"name"`
+	Deleted    time.Time `json:"deleted"`
+	Kind       string    `json:"kind"`
+	NotebookID string    `json:"notebook_id"`
+	path       string
+}
+
+// trashEntries returns the entries in root, newest first.
+func trashEntries(root string) ([]trashEntry, error) {
+	files, err := os.ReadDir(root)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []trashEntry
+	for _, f := range files {
+		t, kind, notebookID, ok := parseTrashName(f.Name())
+		if !ok {
+			continue
+		}
+		entries = append(entries, trashEntry{
+			Name:       strings.TrimSuffix(f.Name(), ".zip"),
+			Deleted:    t,
+			Kind:       kind,
+			NotebookID: notebookID,
+			path:       filepath.Join(root, f.Name()),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Deleted.After(entries[j].Deleted) })
+	return entries, nil
+}
+
+// runTrash implements nlm trash [list] and nlm trash restore-local.
+func runTrash(c *api.Client, args []string) error {
+	root, err := trashDir()
+	if err != nil {
+		return fmt.Errorf("trash: %w", err)
+	}
+	switch {
+	case len(args) == 0 || len(args) == 1 && args[0] == "list":
+		return listTrash(root)
+	case len(args) >= 2 && len(args) <= 3 && args[0] == "restore-local":
+		target := ""
+		if len(args) == 3 {
+			target = args[2]
+		}
+		return restoreTrash(c, root, strings.TrimSuffix(args[1], ".zip"), target)
+	}
+	return errinfo.Usagef("usage: nlm trash [list]\n       nlm trash restore-local <name> [notebook-id]")
+}
+
+func listTrash(root string) error {
+	entries, err := trashEntries(root)
+	if err != nil {
+		return fmt.Errorf("trash: %w", err)
+	}
+	type listed struct {
+		trashEntry
+		Title string `json:"title"`
+		Items int    `json:"items"`
+	}
+	out := []listed{}
+	for _, e := range entries {
+		l := listed{trashEntry: e}
+		if a, err := export.OpenArchive(e.path); err == nil {
+			l.Title, l.Items = a.Manifest.Title, len(a.Manifest.Items)-1
+			a.Close()
+		}
+		out = append(out, l)
+	}
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+	if len(out) == 0 {
+		fmt.Fprintln(os.Stderr, "The trash is empty.")
+		return nil
+	}
+	t := newTable("NAME", "DELETED", "WHAT", "TITLE")
+	for _, l := range out {
+		what := "notebook"
+		if l.Kind == "sources" {
+			what = strconv.Itoa(l.Items) + " sources"
+		}
+		t.Append(l.Name, l.Deleted.Local().Format(time.RFC3339), what, l.Title)
+	}
+	return t.Render(os.Stdout)
+}
+
+// restoreTrash recreates a trash entry: a whole notebook as a new
+// notebook, and sources in the notebook they were deleted from, or in
+// target if given.
+func restoreTrash(c *api.Client, root, name, target string) error {
+	path := filepath.Join(root, name+".zip")
+	_, kind, notebookID, ok := parseTrashName(filepath.Base(path))
+	if !ok {
+		return errinfo.Usagef("trash: %q is not a trash entry; see nlm trash", name)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("trash: %w", err)
+	}
+	if kind == "notebook" {
+		if target != "" {
+			return errinfo.Usagef("trash: a deleted notebook is restored as a new notebook; leave out the notebook ID")
+		}
+		return importArchive(c, path)
+	}
+	if target == "" {
+		target = notebookID
+	}
+	a, err := export.OpenArchive(path)
+	if err != nil {
+		return fmt.Errorf("trash: %w", err)
+	}
+	defer a.Close()
+	var failed, n int
+	for _, it := range a.Manifest.Items {
+		if it.Kind == export.KindNotebook {
+			continue
+		}
+		n++
+		if err := importItem(c, a, target, it); err != nil {
+			fmt.Fprintf(errorOutput, "  ✗ %s %q: %v\n", it.Kind, it.Title, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("trash: %d of %d sources could not be restored", failed, n)
+	}
+	fmt.Fprintf(os.Stderr, "Restored %d sources to notebook %s\n", n, target)
+	return nil
+}
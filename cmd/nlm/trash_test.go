@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/batchexecute"
+	"github.com/tmc/nlm/internal/fake"
+)
+
+func TestTrash(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("NLM_HOME", dir)
+	defer func(old bool) { useTrash = old }(useTrash)
+	useTrash = true
+
+	s := &fake.Server{Scenario: fake.Success, Path: filepath.Join(dir, "fake.json")}
+	c := api.New("token", "cookies", batchexecute.WithHTTPClient(&http.Client{Transport: s}))
+	nb, err := c.CreateProject("Trash", "📙")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := nb.GetProjectId()
+	src, err := c.AddSourceFromText(id, "some text", "notes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := deleteSources(c, id, []string{src}); err != nil {
+		t.Fatal(err)
+	}
+
+	root, err := trashDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := trashEntries(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Kind != "sources" || entries[0].NotebookID != id {
+		t.Fatalf("trash entries = %+v, want the deleted source", entries)
+	}
+	if time.Since(entries[0].Deleted) > time.Minute {
+		t.Errorf("entry deleted at %v, want now", entries[0].Deleted)
+	}
+
+	if err := restoreTrash(c, root, entries[0].Name, ""); err != nil {
+		t.Fatal(err)
+	}
+	p, err := c.GetProject(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Sources) != 1 || p.Sources[0].GetTitle() != "notes.txt" {
+		t.Errorf("sources after restore = %v, want notes.txt", p.Sources)
+	}
+
+	if err := pruneTrash(root, time.Now().AddDate(0, 0, 31)); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := trashEntries(root); len(entries) != 0 {
+		t.Errorf("entries after expiry = %+v, want none", entries)
+	}
+}
+
+func TestParseTrashName(t *testing.T) {
+	for _, name := range []string{
+		"20261016T030000.123456789Z-sources-00000000-0000-4000-8000-000000000001.zip",
+		"20261016T030000Z-notebook-nb1.zip",
+	} {
+		if _, _, _, ok := parseTrashName(name); !ok {
+			t.Errorf("parseTrashName(%q) failed", name)
+		}
+	}
+	for _, name := range []string{"notes.zip", "20261016T030000Z-other-nb1.zip", "20261016T030000Z-sources-nb1.txt"} {
+		if _, _, _, ok := parseTrashName(name); ok {
+			t.Errorf("parseTrashName(%q) succeeded", name)
+		}
+	}
+}
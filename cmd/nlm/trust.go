@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/tmc/nlm/internal/config"
+)
+
+// trustFile records the project configurations allowed to run commands and
+// send data elsewhere.
+const trustFile = "trusted-configs.json"
+
+// restrictUntrusted drops the restricted keys of a .nlm.yaml that was found
+// above the working directory and not trusted with nlm config allow, so
+// that running nlm inside a cloned repository cannot run its commands or
+// send the OCR token to its server.
+func restrictUntrusted(cfg *config.Config) {
+	keys := cfg.Restricted()
+	if len(keys) == 0 || cfg.Explicit() {
+		return
+	}
+	var t config.Trusted
+	if st, err := openState(); err == nil && st.Load(trustFile, &t) == nil && t.Allows(cfg) {
+		return
+	}
+	cfg.DropRestricted()
+	fmt.Fprintf(errorOutput, "nlm: ignoring %s in %s, which is not trusted; review it and run nlm config allow to use them\n", strings.Join(keys, ", "), cfg.Path())
+}
+
+// runConfig implements nlm config allow and nlm config deny, which trust or
+// stop trusting the .nlm.yaml at path, or the nearest one.
+func runConfig(args []string) error {
+	if len(args) < 1 || len(args) > 2 || args[0] != "allow" && args[0] != "deny" {
+		log.Fatal("usage: nlm config allow [file]\n       nlm config deny [file]")
+	}
+	path := ""
+	if len(args) == 2 {
+		path = args[1]
+	} else {
+		var err error
+		if path, err = config.Find("."); err != nil {
+			return fmt.Errorf("config: %w", err)
+		}
+		if path == "" {
+			return fmt.Errorf("config: no %s in this directory or its parents", config.FileName)
+		}
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	st, err := openState()
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	var t config.Trusted
+	if err := st.Load(trustFile, &t); err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	if args[0] == "allow" {
+		t.Allow(cfg)
+	} else {
+		t.Deny(cfg.Path())
+	}
+	if err := st.Save(trustFile, &t); err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	if args[0] == "deny" {
+		fmt.Fprintf(os.Stderr, "No longer trusting %s\n", cfg.Path())
+		return nil
+	}
+	keys := "no restricted settings"
+	if r := cfg.Restricted(); len(r) > 0 {
+		keys = strings.Join(r, ", ")
+	}
+	fmt.Fprintf(os.Stderr, "Trusting %s as it is now (%s); it must be allowed again after it changes\n", cfg.Path(), keys)
+	return nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/tmc/nlm/internal/batch"
+	"github.com/tmc/nlm/internal/validate"
+)
+
+// noValidate skips the checks of argumentSpecs, for when NotebookLM
+// changes the format of its IDs before nlm catches up.
+var noValidate bool
+
+func init() {
+	flag.BoolVar(&noValidate, "no-validate", false, "send IDs, titles, URLs and files to the server without checking them first")
+}
+
+// argumentSpecs give the kinds of the positional arguments of commands,
+// keyed by command or by command and subcommand. A kind ending in "..."
+// applies to the remaining arguments; an empty kind is not checked.
+// Batch operations use the same specs, with a leading notebook applying
+// to their notebook field.
+var argumentSpecs = map[string][]string{
+	"create":              {"title"},
+	"rm":                  {"notebook"},
+	"pin":                 {"notebook..."},
+	"unpin":               {"notebook..."},
+	"sources":             {"notebook"},
+	"sources check-links": {"notebook"},
+	"sources enable":      {"notebook", "source..."},
+	"sources disable":     {"notebook", "source..."},
+	"sources reprocess":   {"notebook", "source..."},
+	"add":                 {"notebook", "input..."},
+	"add-text":            {"notebook", "", "title"},
+	"sync":                {"notebook"},
+	"crawl":               {"notebook"},
+	"arxiv add":           {"notebook"},
+	"paper add":           {"notebook"},
+	"podcast add":         {"notebook", "url"},
+	"rm-source":           {"notebook", "source..."},
+	"rename-source":       {"source", "title"},
+	"notes":               {"notebook"},
+	"notes edit":          {"notebook", "note"},
+	"new-note":            {"notebook", "title"},
+	"update-note":         {"notebook", "note", "", "title"},
+	"rm-note":             {"notebook", "note..."},
+	"audio-create":        {"notebook"},
+	"audio-get":           {"notebook"},
+	"audio-rm":            {"notebook"},
+	"audio-share":         {"notebook"},
+	"generate-guide":      {"notebook"},
+	"generate-outline":    {"notebook"},
+	"generate-section":    {"notebook"},
+	"pipe":                {"notebook..."},
+	"export":              {"notebook"},
+	"archive":             {"notebook"},
+	"graph":               {"notebook"},
+	"changes":             {"notebook"},
+	"backup":              {"notebook..."},
+	"share bulk":          {"notebook"},
+	"share report":        {"notebook..."},
+	"share enforce":       {"notebook..."},
+	"notebooks settings":  {"", "notebook"},
+	"notebooks defaults":  {"notebook"},
+	"trash restore-local": {"", "notebook"},
+}
+
+// validateArgs checks the arguments of cmd before anything is sent, so a
+// malformed argument stops the command before it changes anything.
+func validateArgs(cmd string, args []string) error {
+	if noValidate {
+		return nil
+	}
+	spec, ok := argumentSpecs[cmd]
+	if len(args) > 0 {
+		if sub, ok2 := argumentSpecs[cmd+" "+args[0]]; ok2 {
+			spec, ok, args = sub, true, args[1:]
+		}
+	}
+	if !ok || cmd == "add" && (fromHistory || addS3 != "" || addGCS != "") {
+		return nil
+	}
+	if err := checkArgs(spec, args); err != nil {
+		return fmt.Errorf("%s: %w", cmd, err)
+	}
+	return nil
+}
+
+// checkArgs checks args against spec.
+func checkArgs(spec, args []string) error {
+	for i, arg := range args {
+		var kind string
+		switch last := spec[len(spec)-1]; {
+		case i < len(spec)-1:
+			kind = spec[i]
+		case strings.HasSuffix(last, "..."):
+			kind = strings.TrimSuffix(last, "...")
+		case i == len(spec)-1:
+			kind = last
+		default:
+			return nil // the usage check reports extra arguments
+		}
+		if err := checkArg(kind, arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkArg(kind, arg string) error {
+	switch kind {
+	case "notebook", "source", "note":
+		return validate.ID(kind, arg)
+	case "title":
+		return validate.Title(arg)
+	case "url":
+		return validate.URL(arg)
+	case "input":
+		return validate.Input(arg)
+	}
+	return nil
+}
+
+// validatedOps wraps ops so each checks its notebook and arguments before
+// running, failing as a whole rather than part way through its arguments.
+func validatedOps(ops map[string]batch.Func) map[string]batch.Func {
+	if noValidate {
+		return ops
+	}
+	for name, fn := range ops {
+		spec, ok := argumentSpecs[name]
+		if !ok {
+			continue
+		}
+		fn := fn
+		ops[name] = func(ctx context.Context, op batch.Op) (any, error) {
+			args := op.Args
+			if spec[0] == "notebook" {
+				args = append([]string{op.Notebook}, args...)
+			}
+			if err := checkArgs(spec, args); err != nil {
+				return nil, err
+			}
+			return fn(ctx, op)
+		}
+	}
+	return ops
+}
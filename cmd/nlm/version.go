@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	rdebug "runtime/debug"
+	"strings"
+)
+
+// version is the release version, set at build time with
+// -ldflags "-X main.version=v0.1.0". Builds installed with "go install"
+// report the module version instead.
+var version = "devel"
+
+// buildVersion returns the release version of the running binary.
+func buildVersion() string {
+	if version != "devel" {
+		return version
+	}
+	if info, ok := rdebug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return version
+}
+
+// versionString describes the running binary: version, VCS revision, Go
+// version and platform.
+func versionString() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "nlm %s", buildVersion())
+	if info, ok := rdebug.ReadBuildInfo(); ok {
+		var rev, when, modified string
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				rev = s.Value
+			case "vcs.time":
+				when = s.Value
+			case "vcs.modified":
+				if s.Value == "true" {
+					modified = "+dirty"
+				}
+			}
+		}
+		if rev != "" {
+			if len(rev) > 12 {
+				rev = rev[:12]
+			}
+			fmt.Fprintf(&b, " (%s%s %s)", rev, modified, when)
+		}
+	}
+	fmt.Fprintf(&b, " %s %s/%s", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	return b.String()
+}
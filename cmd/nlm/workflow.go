@@ -0,0 +1,358 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/batchexecute"
+	"github.com/tmc/nlm/internal/cleanup"
+	"github.com/tmc/nlm/internal/workflow"
+)
+
+// Workflow flags
+var (
+	workflowResume bool
+	workflowVars   = varsFlag{}
+)
+
+func init() {
+	flag.BoolVar(&workflowResume, "resume", false, "with run, skip the steps completed by the last run of the workflow")
+	flag.Var(workflowVars, "set", "with run, set a workflow variable as `key=value` (repeatable)")
+}
+
+// varsFlag collects repeated key=value flags.
+type varsFlag workflow.Vars
+
+func (v varsFlag) String() string { return workflow.Vars(v).String() }
+
+func (v varsFlag) Set(s string) error {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok || strings.TrimSpace(key) == "" {
+		return fmt.Errorf("want key=value, got %q", s)
+	}
+	v[strings.TrimSpace(key)] = value
+	return nil
+}
+
+// audioPollInterval is how often audio-create with wait checks whether
+// the overview is ready.
+const audioPollInterval = 30 * time.Second
+
+// clientAction is a workflow action given a client bounded by the step's
+// context, so that a step timeout or an interrupt stops its requests.
+type clientAction func(ctx context.Context, c *api.Client, run *workflow.Run, s *workflow.Step) (map[string]string, error)
+
+// workflowActions are the actions available to workflow steps. Actions
+// record what they complete in the step's progress, so a retry does not
+// repeat it.
+func workflowActions(c *api.Client) map[string]workflow.Action {
+	actions := make(map[string]workflow.Action, len(clientActions))
+	for name, fn := range clientActions {
+		fn := fn
+		actions[name] = func(ctx context.Context, run *workflow.Run, s *workflow.Step) (map[string]string, error) {
+			return fn(ctx, c.WithContext(ctx), run, s)
+		}
+	}
+	return actions
+}
+
+var clientActions = map[string]clientAction{
+	"create-notebook": func(ctx context.Context, c *api.Client, run *workflow.Run, s *workflow.Step) (map[string]string, error) {
+		title := strings.TrimSpace(s.String("title"))
+		if title == "" {
+			return nil, fmt.Errorf("create-notebook: title is required")
+		}
+		progress := run.Progress(s)
+		if id := progress["notebook"]; id != "" {
+			return map[string]string{"notebook": id}, nil
+		}
+		if s.Bool("reuse", true) {
+			nbs, err := c.ListRecentlyViewedProjects()
+			if err != nil {
+				return nil, err
+			}
+			for _, nb := range nbs {
+				if strings.TrimSpace(nb.GetTitle()) == title {
+					return map[string]string{"notebook": nb.GetProjectId()}, nil
+				}
+			}
+		}
+		emoji := s.String("emoji")
+		if emoji == "" {
+			emoji = "📙"
+		}
+		nb, err := c.CreateProject(title, emoji)
+		if err != nil {
+			return nil, err
+		}
+		if err := run.Record(s, "notebook", nb.GetProjectId()); err != nil {
+			return nil, err
+		}
+		return map[string]string{"notebook": nb.GetProjectId()}, nil
+	},
+	"add": func(ctx context.Context, c *api.Client, run *workflow.Run, s *workflow.Step) (map[string]string, error) {
+		nb, err := run.NotebookFor(s)
+		if err != nil {
+			return nil, err
+		}
+		inputs, err := expandSources(run, s.Strings("sources"))
+		if err != nil {
+			return nil, err
+		}
+		// Inputs added by an earlier attempt, or by the run being
+		// resumed, are not added again.
+		progress := run.Progress(s)
+		var ids []string
+		for _, in := range inputs {
+			if id, ok := progress[in]; ok {
+				ids = append(ids, id)
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			id, err := addSource(c, nb, in)
+			if err != nil {
+				return nil, fmt.Errorf("add %s: %w", in, err)
+			}
+			if err := run.Record(s, in, id); err != nil {
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+		return map[string]string{"sources": strings.Join(ids, ",")}, nil
+	},
+	"sync": func(ctx context.Context, c *api.Client, run *workflow.Run, s *workflow.Step) (map[string]string, error) {
+		nb, err := run.NotebookFor(s)
+		if err != nil {
+			return nil, err
+		}
+		return nil, syncDir(c, nb, run.Path(s.String("dir")))
+	},
+	"generate-guide": func(ctx context.Context, c *api.Client, run *workflow.Run, s *workflow.Step) (map[string]string, error) {
+		nb, err := run.NotebookFor(s)
+		if err != nil {
+			return nil, err
+		}
+		guide, err := c.GenerateNotebookGuide(nb)
+		if err != nil {
+			return nil, err
+		}
+		out := run.Path(s.String("output"))
+		if out == "" {
+			fmt.Println(guide.Content)
+			return nil, nil
+		}
+		if err := os.WriteFile(out, []byte(guide.Content+"\n"), 0o644); err != nil {
+			return nil, err
+		}
+		return map[string]string{"output": out}, nil
+	},
+	"audio-create": func(ctx context.Context, c *api.Client, run *workflow.Run, s *workflow.Step) (map[string]string, error) {
+		nb, err := run.NotebookFor(s)
+		if err != nil {
+			return nil, err
+		}
+		// An overview requested by an earlier attempt is waited for
+		// rather than requested again.
+		progress := run.Progress(s)
+		if progress["requested"] == "" {
+			if _, err := c.CreateAudioOverview(nb, s.String("instructions")); err != nil {
+				return nil, err
+			}
+			if err := run.Record(s, "requested", nb); err != nil {
+				return nil, err
+			}
+		}
+		if !s.Bool("wait", false) {
+			return nil, nil
+		}
+		for {
+			audio, err := c.GetAudioOverview(nb)
+			if err != nil {
+				return nil, err
+			}
+			if audio.IsReady {
+				return map[string]string{"audio": audio.AudioId}, nil
+			}
+			select {
+			case <-time.After(audioPollInterval):
+			case <-ctx.Done():
+				return nil, fmt.Errorf("audio overview not ready: %w", ctx.Err())
+			}
+		}
+	},
+	"audio-get": func(ctx context.Context, c *api.Client, run *workflow.Run, s *workflow.Step) (map[string]string, error) {
+		nb, err := run.NotebookFor(s)
+		if err != nil {
+			return nil, err
+		}
+		audio, err := c.GetAudioOverview(nb)
+		if err != nil {
+			return nil, err
+		}
+		if !audio.IsReady || audio.AudioData == "" {
+			return nil, fmt.Errorf("audio overview not ready")
+		}
+		out := run.Path(s.String("output"))
+		if out == "" {
+			out = outputFilename(fmt.Sprintf("audio_overview_%s.wav", audio.AudioId))
+		}
+		if err := saveAudio(audio, out); err != nil {
+			return nil, err
+		}
+		return map[string]string{"output": out}, nil
+	},
+	"export": func(ctx context.Context, c *api.Client, run *workflow.Run, s *workflow.Step) (map[string]string, error) {
+		nb, err := run.NotebookFor(s)
+		if err != nil {
+			return nil, err
+		}
+		return nil, exportNotebook(c, nb, run.Path(s.String("dir")))
+	},
+}
+
+// expandSources expands glob patterns among local inputs, relative to the
+// workflow's directory. URLs are kept as they are, and a pattern matching
+// nothing is an error.
+func expandSources(run *workflow.Run, inputs []string) ([]string, error) {
+	var out []string
+	for _, in := range inputs {
+		if strings.HasPrefix(in, "http://") || strings.HasPrefix(in, "https://") {
+			out = append(out, in)
+			continue
+		}
+		matches, err := filepath.Glob(run.Path(in))
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %s", in)
+		}
+		out = append(out, matches...)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no sources given")
+	}
+	return out, nil
+}
+
+// checkpointName returns the state file holding the checkpoint of the
+// workflow at path run with the given matrix values.
+func checkpointName(path string, combo workflow.Vars) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(abs + "\x00" + combo.String()))
+	return filepath.Join("workflows", hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+// runWorkflow executes a workflow file, once for each combination of its
+// matrix values, logging every step attempt to stderr (as JSON with
+// -json) and printing a summary. Completed steps are checkpointed in the
+// state directory; with -resume they are not run again.
+func runWorkflow(c *api.Client, path string) error {
+	w, err := workflow.Load(path)
+	if err != nil {
+		return err
+	}
+	combos, err := w.Combinations()
+	if err != nil {
+		return err
+	}
+	st, err := openState()
+	if err != nil {
+		return fmt.Errorf("run: %w", err)
+	}
+	var handler slog.Handler = slog.NewTextHandler(os.Stderr, nil)
+	if jsonOutput {
+		handler = slog.NewJSONHandler(os.Stderr, nil)
+	}
+	ctx, stop := cleanup.NotifyContext(context.Background())
+	defer stop()
+
+	headers := []string{"STEP", "STATUS", "ATTEMPTS", "DURATION", "ERROR"}
+	if len(w.Matrix) > 0 {
+		headers = append([]string{"MATRIX"}, headers...)
+	}
+	t := newTable(headers...)
+	var failed int
+	var runErr error
+	for _, combo := range combos {
+		vars := make(workflow.Vars, len(workflowVars)+len(combo))
+		for k, v := range workflowVars {
+			vars[k] = v
+		}
+		for k, v := range combo {
+			vars[k] = v
+		}
+		logger := slog.New(handler).With("workflow", w.Name)
+		if len(combo) > 0 {
+			logger = logger.With("matrix", combo.String())
+		}
+		name, err := checkpointName(path, combo)
+		if err != nil {
+			return fmt.Errorf("run: %w", err)
+		}
+		abs, _ := filepath.Abs(path)
+		cp := workflow.NewCheckpoint(abs)
+		if workflowResume {
+			if err := st.Load(name, cp); err != nil {
+				return fmt.Errorf("run: %w", err)
+			}
+			if len(cp.Steps) == 0 {
+				logger.Warn("no checkpoint; running from the start")
+			}
+		}
+		rn := &workflow.Runner{
+			Actions:    workflowActions(c),
+			Logger:     logger,
+			RetryAfter: batchexecute.RetryDelay,
+			Checkpoint: cp,
+			Save:       func(cp *workflow.Checkpoint) error { return st.Save(name, cp) },
+			Vars:       vars,
+		}
+		run, err := rn.Run(ctx, w)
+		if err != nil && run == nil {
+			return err
+		}
+		for _, r := range run.Results {
+			row := []string{r.Step, r.Status, strconv.Itoa(r.Attempts), r.Duration.Round(time.Second).String(), r.Error}
+			if len(w.Matrix) > 0 {
+				row = append([]string{combo.String()}, row...)
+			}
+			t.Append(row...)
+		}
+		if run.Failed() {
+			failed++
+		}
+		if err != nil {
+			runErr = err
+			break
+		}
+	}
+	if err := t.Render(os.Stdout); err != nil {
+		return err
+	}
+	if runErr != nil {
+		return fmt.Errorf("run: %w", runErr)
+	}
+	if failed > 0 {
+		if len(combos) > 1 {
+			return fmt.Errorf("run: %d of %d matrix runs of %s did not complete; fix them and rerun with -resume", failed, len(combos), path)
+		}
+		return fmt.Errorf("run: workflow %s did not complete; fix it and rerun with -resume", path)
+	}
+	return nil
+}
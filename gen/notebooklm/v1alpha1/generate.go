@@ -0,0 +1,3 @@
+package notebooklmv1alpha1
+
+//go:generate go run ../../../internal/protoparse/gengo -I ../../../proto -out ../.. notebooklm/v1alpha1/notebooklm.proto
@@ -2,7 +2,7 @@
 
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.31.0
+// 	protoc-gen-go v1.35.2
 // 	protoc        (unknown)
 // source: notebooklm/v1alpha1/notebooklm.proto
 
@@ -91,6 +91,59 @@ func (SourceType) EnumDescriptor() ([]byte, []int) {
 	return file_notebooklm_v1alpha1_notebooklm_proto_rawDescGZIP(), []int{0}
 }
 
+// Role is a person's access to a notebook.
+type Role int32
+
+const (
+	Role_ROLE_UNKNOWN Role = 0
+	Role_ROLE_OWNER   Role = 1
+	Role_ROLE_EDITOR  Role = 2
+	Role_ROLE_VIEWER  Role = 3
+)
+
+// Enum value maps for Role.
+var (
+	Role_name = map[int32]string{
+		0: "ROLE_UNKNOWN",
+		1: "ROLE_OWNER",
+		2: "ROLE_EDITOR",
+		3: "ROLE_VIEWER",
+	}
+	Role_value = map[string]int32{
+		"ROLE_UNKNOWN": 0,
+		"ROLE_OWNER":   1,
+		"ROLE_EDITOR":  2,
+		"ROLE_VIEWER":  3,
+	}
+)
+
+func (x Role) Enum() *Role {
+	p := new(Role)
+	*p = x
+	return p
+}
+
+func (x Role) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Role) Descriptor() protoreflect.EnumDescriptor {
+	return file_notebooklm_v1alpha1_notebooklm_proto_enumTypes[1].Descriptor()
+}
+
+func (Role) Type() protoreflect.EnumType {
+	return &file_notebooklm_v1alpha1_notebooklm_proto_enumTypes[1]
+}
+
+func (x Role) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Role.Descriptor instead.
+func (Role) EnumDescriptor() ([]byte, []int) {
+	return file_notebooklm_v1alpha1_notebooklm_proto_rawDescGZIP(), []int{1}
+}
+
 type SourceSettings_SourceStatus int32
 
 const (
@@ -127,11 +180,11 @@ func (x SourceSettings_SourceStatus) String() string {
 }
 
 func (SourceSettings_SourceStatus) Descriptor() protoreflect.EnumDescriptor {
-	return file_notebooklm_v1alpha1_notebooklm_proto_enumTypes[1].Descriptor()
+	return file_notebooklm_v1alpha1_notebooklm_proto_enumTypes[2].Descriptor()
 }
 
 func (SourceSettings_SourceStatus) Type() protoreflect.EnumType {
-	return &file_notebooklm_v1alpha1_notebooklm_proto_enumTypes[1]
+	return &file_notebooklm_v1alpha1_notebooklm_proto_enumTypes[2]
 }
 
 func (x SourceSettings_SourceStatus) Number() protoreflect.EnumNumber {
@@ -215,11 +268,11 @@ func (x SourceIssue_Reason) String() string {
 }
 
 func (SourceIssue_Reason) Descriptor() protoreflect.EnumDescriptor {
-	return file_notebooklm_v1alpha1_notebooklm_proto_enumTypes[2].Descriptor()
+	return file_notebooklm_v1alpha1_notebooklm_proto_enumTypes[3].Descriptor()
 }
 
 func (SourceIssue_Reason) Type() protoreflect.EnumType {
-	return &file_notebooklm_v1alpha1_notebooklm_proto_enumTypes[2]
+	return &file_notebooklm_v1alpha1_notebooklm_proto_enumTypes[3]
 }
 
 func (x SourceIssue_Reason) Number() protoreflect.EnumNumber {
@@ -245,11 +298,9 @@ type Project struct {
 
 func (x *Project) Reset() {
 	*x = Project{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[0]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *Project) String() string {
@@ -260,7 +311,7 @@ func (*Project) ProtoMessage() {}
 
 func (x *Project) ProtoReflect() protoreflect.Message {
 	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[0]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -328,11 +379,9 @@ type ProjectMetadata struct {
 
 func (x *ProjectMetadata) Reset() {
 	*x = ProjectMetadata{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[1]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *ProjectMetadata) String() string {
@@ -343,7 +392,7 @@ func (*ProjectMetadata) ProtoMessage() {}
 
 func (x *ProjectMetadata) ProtoReflect() protoreflect.Message {
 	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[1]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -410,11 +459,9 @@ type SourceId struct {
 
 func (x *SourceId) Reset() {
 	*x = SourceId{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[2]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *SourceId) String() string {
@@ -425,7 +472,7 @@ func (*SourceId) ProtoMessage() {}
 
 func (x *SourceId) ProtoReflect() protoreflect.Message {
 	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[2]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -461,11 +508,9 @@ type Source struct {
 
 func (x *Source) Reset() {
 	*x = Source{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[3]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *Source) String() string {
@@ -476,7 +521,7 @@ func (*Source) ProtoMessage() {}
 
 func (x *Source) ProtoReflect() protoreflect.Message {
 	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[3]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -532,7 +577,6 @@ type SourceMetadata struct {
 	unknownFields protoimpl.UnknownFields
 
 	// Types that are assignable to MetadataType:
-	//
 	//	*SourceMetadata_GoogleDocs
 	//	*SourceMetadata_Youtube
 	MetadataType          isSourceMetadata_MetadataType `protobuf_oneof:"metadata_type"`
@@ -544,11 +588,9 @@ type SourceMetadata struct {
 
 func (x *SourceMetadata) Reset() {
 	*x = SourceMetadata{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[4]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *SourceMetadata) String() string {
@@ -559,7 +601,7 @@ func (*SourceMetadata) ProtoMessage() {}
 
 func (x *SourceMetadata) ProtoReflect() protoreflect.Message {
 	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[4]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -642,11 +684,9 @@ type GoogleDocsSourceMetadata struct {
 
 func (x *GoogleDocsSourceMetadata) Reset() {
 	*x = GoogleDocsSourceMetadata{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[5]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *GoogleDocsSourceMetadata) String() string {
@@ -657,7 +697,7 @@ func (*GoogleDocsSourceMetadata) ProtoMessage() {}
 
 func (x *GoogleDocsSourceMetadata) ProtoReflect() protoreflect.Message {
 	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[5]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -690,11 +730,9 @@ type YoutubeSourceMetadata struct {
 
 func (x *YoutubeSourceMetadata) Reset() {
 	*x = YoutubeSourceMetadata{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[6]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *YoutubeSourceMetadata) String() string {
@@ -705,7 +743,7 @@ func (*YoutubeSourceMetadata) ProtoMessage() {}
 
 func (x *YoutubeSourceMetadata) ProtoReflect() protoreflect.Message {
 	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[6]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -744,11 +782,9 @@ type SourceSettings struct {
 
 func (x *SourceSettings) Reset() {
 	*x = SourceSettings{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[7]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *SourceSettings) String() string {
@@ -759,7 +795,7 @@ func (*SourceSettings) ProtoMessage() {}
 
 func (x *SourceSettings) ProtoReflect() protoreflect.Message {
 	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[7]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -791,11 +827,9 @@ type SourceIssue struct {
 
 func (x *SourceIssue) Reset() {
 	*x = SourceIssue{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[8]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *SourceIssue) String() string {
@@ -806,7 +840,7 @@ func (*SourceIssue) ProtoMessage() {}
 
 func (x *SourceIssue) ProtoReflect() protoreflect.Message {
 	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[8]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -838,11 +872,9 @@ type GetNotesResponse struct {
 
 func (x *GetNotesResponse) Reset() {
 	*x = GetNotesResponse{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[9]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *GetNotesResponse) String() string {
@@ -853,7 +885,7 @@ func (*GetNotesResponse) ProtoMessage() {}
 
 func (x *GetNotesResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[9]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -887,11 +919,9 @@ type AudioOverview struct {
 
 func (x *AudioOverview) Reset() {
 	*x = AudioOverview{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[10]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *AudioOverview) String() string {
@@ -902,7 +932,7 @@ func (*AudioOverview) ProtoMessage() {}
 
 func (x *AudioOverview) ProtoReflect() protoreflect.Message {
 	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[10]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -948,11 +978,9 @@ type GenerateDocumentGuidesResponse struct {
 
 func (x *GenerateDocumentGuidesResponse) Reset() {
 	*x = GenerateDocumentGuidesResponse{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[11]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *GenerateDocumentGuidesResponse) String() string {
@@ -963,7 +991,7 @@ func (*GenerateDocumentGuidesResponse) ProtoMessage() {}
 
 func (x *GenerateDocumentGuidesResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[11]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -995,11 +1023,9 @@ type DocumentGuide struct {
 
 func (x *DocumentGuide) Reset() {
 	*x = DocumentGuide{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[12]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *DocumentGuide) String() string {
@@ -1010,7 +1036,7 @@ func (*DocumentGuide) ProtoMessage() {}
 
 func (x *DocumentGuide) ProtoReflect() protoreflect.Message {
 	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[12]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -1042,11 +1068,9 @@ type GenerateNotebookGuideResponse struct {
 
 func (x *GenerateNotebookGuideResponse) Reset() {
 	*x = GenerateNotebookGuideResponse{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[13]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *GenerateNotebookGuideResponse) String() string {
@@ -1057,7 +1081,7 @@ func (*GenerateNotebookGuideResponse) ProtoMessage() {}
 
 func (x *GenerateNotebookGuideResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[13]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -1089,11 +1113,9 @@ type GenerateOutlineResponse struct {
 
 func (x *GenerateOutlineResponse) Reset() {
 	*x = GenerateOutlineResponse{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[14]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *GenerateOutlineResponse) String() string {
@@ -1104,7 +1126,7 @@ func (*GenerateOutlineResponse) ProtoMessage() {}
 
 func (x *GenerateOutlineResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[14]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -1136,11 +1158,9 @@ type GenerateSectionResponse struct {
 
 func (x *GenerateSectionResponse) Reset() {
 	*x = GenerateSectionResponse{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[15]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *GenerateSectionResponse) String() string {
@@ -1151,7 +1171,7 @@ func (*GenerateSectionResponse) ProtoMessage() {}
 
 func (x *GenerateSectionResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[15]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -1181,11 +1201,9 @@ type StartDraftResponse struct {
 
 func (x *StartDraftResponse) Reset() {
 	*x = StartDraftResponse{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[16]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *StartDraftResponse) String() string {
@@ -1196,7 +1214,7 @@ func (*StartDraftResponse) ProtoMessage() {}
 
 func (x *StartDraftResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[16]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -1219,11 +1237,9 @@ type StartSectionResponse struct {
 
 func (x *StartSectionResponse) Reset() {
 	*x = StartSectionResponse{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[17]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *StartSectionResponse) String() string {
@@ -1234,7 +1250,7 @@ func (*StartSectionResponse) ProtoMessage() {}
 
 func (x *StartSectionResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[17]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -1259,11 +1275,9 @@ type ListRecentlyViewedProjectsResponse struct {
 
 func (x *ListRecentlyViewedProjectsResponse) Reset() {
 	*x = ListRecentlyViewedProjectsResponse{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[18]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *ListRecentlyViewedProjectsResponse) String() string {
@@ -1274,7 +1288,7 @@ func (*ListRecentlyViewedProjectsResponse) ProtoMessage() {}
 
 func (x *ListRecentlyViewedProjectsResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[18]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -1296,6 +1310,256 @@ func (x *ListRecentlyViewedProjectsResponse) GetProjects() []*Project {
 	return nil
 }
 
+// AudioOverviewResult is a notebook's audio overview.
+type AudioOverviewResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ProjectId string `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	AudioId   string `protobuf:"bytes,2,opt,name=audio_id,json=audioId,proto3" json:"audio_id,omitempty"`
+	Title     string `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
+	// The audio, base64 encoded, once it is ready.
+	AudioData string `protobuf:"bytes,4,opt,name=audio_data,json=audioData,proto3" json:"audio_data,omitempty"`
+	IsReady   bool   `protobuf:"varint,5,opt,name=is_ready,json=isReady,proto3" json:"is_ready,omitempty"`
+}
+
+func (x *AudioOverviewResult) Reset() {
+	*x = AudioOverviewResult{}
+	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AudioOverviewResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AudioOverviewResult) ProtoMessage() {}
+
+func (x *AudioOverviewResult) ProtoReflect() protoreflect.Message {
+	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AudioOverviewResult.ProtoReflect.Descriptor instead.
+func (*AudioOverviewResult) Descriptor() ([]byte, []int) {
+	return file_notebooklm_v1alpha1_notebooklm_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *AudioOverviewResult) GetProjectId() string {
+	if x != nil {
+		return x.ProjectId
+	}
+	return ""
+}
+
+func (x *AudioOverviewResult) GetAudioId() string {
+	if x != nil {
+		return x.AudioId
+	}
+	return ""
+}
+
+func (x *AudioOverviewResult) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *AudioOverviewResult) GetAudioData() string {
+	if x != nil {
+		return x.AudioData
+	}
+	return ""
+}
+
+func (x *AudioOverviewResult) GetIsReady() bool {
+	if x != nil {
+		return x.IsReady
+	}
+	return false
+}
+
+// ShareAudioResult is the link to a shared audio overview.
+type ShareAudioResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ShareUrl string `protobuf:"bytes,1,opt,name=share_url,json=shareUrl,proto3" json:"share_url,omitempty"`
+	ShareId  string `protobuf:"bytes,2,opt,name=share_id,json=shareId,proto3" json:"share_id,omitempty"`
+	IsPublic bool   `protobuf:"varint,3,opt,name=is_public,json=isPublic,proto3" json:"is_public,omitempty"`
+}
+
+func (x *ShareAudioResult) Reset() {
+	*x = ShareAudioResult{}
+	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShareAudioResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShareAudioResult) ProtoMessage() {}
+
+func (x *ShareAudioResult) ProtoReflect() protoreflect.Message {
+	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShareAudioResult.ProtoReflect.Descriptor instead.
+func (*ShareAudioResult) Descriptor() ([]byte, []int) {
+	return file_notebooklm_v1alpha1_notebooklm_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ShareAudioResult) GetShareUrl() string {
+	if x != nil {
+		return x.ShareUrl
+	}
+	return ""
+}
+
+func (x *ShareAudioResult) GetShareId() string {
+	if x != nil {
+		return x.ShareId
+	}
+	return ""
+}
+
+func (x *ShareAudioResult) GetIsPublic() bool {
+	if x != nil {
+		return x.IsPublic
+	}
+	return false
+}
+
+// Collaborator is a person a notebook is shared with.
+type Collaborator struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Email string `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	Role  Role   `protobuf:"varint,2,opt,name=role,proto3,enum=notebooklm.v1alpha1.Role" json:"role,omitempty"`
+}
+
+func (x *Collaborator) Reset() {
+	*x = Collaborator{}
+	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Collaborator) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Collaborator) ProtoMessage() {}
+
+func (x *Collaborator) ProtoReflect() protoreflect.Message {
+	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Collaborator.ProtoReflect.Descriptor instead.
+func (*Collaborator) Descriptor() ([]byte, []int) {
+	return file_notebooklm_v1alpha1_notebooklm_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *Collaborator) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *Collaborator) GetRole() Role {
+	if x != nil {
+		return x.Role
+	}
+	return Role_ROLE_UNKNOWN
+}
+
+// Sharing is who can open a notebook.
+type Sharing struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Set when anyone with the link can view the notebook.
+	Public        bool            `protobuf:"varint,1,opt,name=public,proto3" json:"public,omitempty"`
+	Collaborators []*Collaborator `protobuf:"bytes,2,rep,name=collaborators,proto3" json:"collaborators,omitempty"`
+}
+
+func (x *Sharing) Reset() {
+	*x = Sharing{}
+	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Sharing) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Sharing) ProtoMessage() {}
+
+func (x *Sharing) ProtoReflect() protoreflect.Message {
+	mi := &file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Sharing.ProtoReflect.Descriptor instead.
+func (*Sharing) Descriptor() ([]byte, []int) {
+	return file_notebooklm_v1alpha1_notebooklm_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *Sharing) GetPublic() bool {
+	if x != nil {
+		return x.Public
+	}
+	return false
+}
+
+func (x *Sharing) GetCollaborators() []*Collaborator {
+	if x != nil {
+		return x.Collaborators
+	}
+	return nil
+}
+
 var File_notebooklm_v1alpha1_notebooklm_proto protoreflect.FileDescriptor
 
 var file_notebooklm_v1alpha1_notebooklm_proto_rawDesc = []byte{
@@ -1483,38 +1747,62 @@ var file_notebooklm_v1alpha1_notebooklm_proto_rawDesc = []byte{
 	0x63, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x6e, 0x6f, 0x74, 0x65,
 	0x62, 0x6f, 0x6f, 0x6b, 0x6c, 0x6d, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e,
 	0x50, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74,
-	0x73, 0x2a, 0x8f, 0x02, 0x0a, 0x0a, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x54, 0x79, 0x70, 0x65,
-	0x12, 0x1b, 0x0a, 0x17, 0x53, 0x4f, 0x55, 0x52, 0x43, 0x45, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f,
-	0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x17, 0x0a,
-	0x13, 0x53, 0x4f, 0x55, 0x52, 0x43, 0x45, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x4e, 0x4b,
-	0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x01, 0x12, 0x1b, 0x0a, 0x17, 0x53, 0x4f, 0x55, 0x52, 0x43, 0x45,
-	0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x47, 0x4f, 0x4f, 0x47, 0x4c, 0x45, 0x5f, 0x44, 0x4f, 0x43,
-	0x53, 0x10, 0x03, 0x12, 0x1d, 0x0a, 0x19, 0x53, 0x4f, 0x55, 0x52, 0x43, 0x45, 0x5f, 0x54, 0x59,
-	0x50, 0x45, 0x5f, 0x47, 0x4f, 0x4f, 0x47, 0x4c, 0x45, 0x5f, 0x53, 0x4c, 0x49, 0x44, 0x45, 0x53,
-	0x10, 0x04, 0x12, 0x1d, 0x0a, 0x19, 0x53, 0x4f, 0x55, 0x52, 0x43, 0x45, 0x5f, 0x54, 0x59, 0x50,
-	0x45, 0x5f, 0x47, 0x4f, 0x4f, 0x47, 0x4c, 0x45, 0x5f, 0x53, 0x48, 0x45, 0x45, 0x54, 0x53, 0x10,
-	0x05, 0x12, 0x1a, 0x0a, 0x16, 0x53, 0x4f, 0x55, 0x52, 0x43, 0x45, 0x5f, 0x54, 0x59, 0x50, 0x45,
-	0x5f, 0x4c, 0x4f, 0x43, 0x41, 0x4c, 0x5f, 0x46, 0x49, 0x4c, 0x45, 0x10, 0x06, 0x12, 0x18, 0x0a,
-	0x14, 0x53, 0x4f, 0x55, 0x52, 0x43, 0x45, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x57, 0x45, 0x42,
-	0x5f, 0x50, 0x41, 0x47, 0x45, 0x10, 0x07, 0x12, 0x1b, 0x0a, 0x17, 0x53, 0x4f, 0x55, 0x52, 0x43,
-	0x45, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x53, 0x48, 0x41, 0x52, 0x45, 0x44, 0x5f, 0x4e, 0x4f,
-	0x54, 0x45, 0x10, 0x08, 0x12, 0x1d, 0x0a, 0x19, 0x53, 0x4f, 0x55, 0x52, 0x43, 0x45, 0x5f, 0x54,
-	0x59, 0x50, 0x45, 0x5f, 0x59, 0x4f, 0x55, 0x54, 0x55, 0x42, 0x45, 0x5f, 0x56, 0x49, 0x44, 0x45,
-	0x4f, 0x10, 0x09, 0x42, 0xdc, 0x01, 0x0a, 0x17, 0x63, 0x6f, 0x6d, 0x2e, 0x6e, 0x6f, 0x74, 0x65,
-	0x62, 0x6f, 0x6f, 0x6b, 0x6c, 0x6d, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x42,
-	0x0f, 0x4e, 0x6f, 0x74, 0x65, 0x62, 0x6f, 0x6f, 0x6b, 0x6c, 0x6d, 0x50, 0x72, 0x6f, 0x74, 0x6f,
-	0x50, 0x01, 0x5a, 0x43, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x74,
-	0x6d, 0x63, 0x2f, 0x6e, 0x6c, 0x6d, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x67, 0x65, 0x6e,
-	0x2f, 0x6e, 0x6f, 0x74, 0x65, 0x62, 0x6f, 0x6f, 0x6b, 0x6c, 0x6d, 0x2f, 0x76, 0x31, 0x61, 0x6c,
-	0x70, 0x68, 0x61, 0x31, 0x3b, 0x6e, 0x6f, 0x74, 0x65, 0x62, 0x6f, 0x6f, 0x6b, 0x6c, 0x6d, 0x76,
-	0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0xa2, 0x02, 0x03, 0x4e, 0x58, 0x58, 0xaa, 0x02, 0x13,
-	0x4e, 0x6f, 0x74, 0x65, 0x62, 0x6f, 0x6f, 0x6b, 0x6c, 0x6d, 0x2e, 0x56, 0x31, 0x61, 0x6c, 0x70,
-	0x68, 0x61, 0x31, 0xca, 0x02, 0x13, 0x4e, 0x6f, 0x74, 0x65, 0x62, 0x6f, 0x6f, 0x6b, 0x6c, 0x6d,
-	0x5c, 0x56, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0xe2, 0x02, 0x1f, 0x4e, 0x6f, 0x74, 0x65,
-	0x62, 0x6f, 0x6f, 0x6b, 0x6c, 0x6d, 0x5c, 0x56, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x5c,
-	0x47, 0x50, 0x42, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0xea, 0x02, 0x14, 0x4e, 0x6f,
-	0x74, 0x65, 0x62, 0x6f, 0x6f, 0x6b, 0x6c, 0x6d, 0x3a, 0x3a, 0x56, 0x31, 0x61, 0x6c, 0x70, 0x68,
-	0x61, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x73, 0x22, 0x9f, 0x01, 0x0a, 0x13, 0x41, 0x75, 0x64, 0x69, 0x6f, 0x4f, 0x76, 0x65, 0x72, 0x76,
+	0x69, 0x65, 0x77, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x72, 0x6f,
+	0x6a, 0x65, 0x63, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x70,
+	0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x49, 0x64, 0x12, 0x19, 0x0a, 0x08, 0x61, 0x75, 0x64, 0x69,
+	0x6f, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x75, 0x64, 0x69,
+	0x6f, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x61, 0x75, 0x64,
+	0x69, 0x6f, 0x5f, 0x64, 0x61, 0x74, 0x61, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x61,
+	0x75, 0x64, 0x69, 0x6f, 0x44, 0x61, 0x74, 0x61, 0x12, 0x19, 0x0a, 0x08, 0x69, 0x73, 0x5f, 0x72,
+	0x65, 0x61, 0x64, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x69, 0x73, 0x52, 0x65,
+	0x61, 0x64, 0x79, 0x22, 0x67, 0x0a, 0x10, 0x53, 0x68, 0x61, 0x72, 0x65, 0x41, 0x75, 0x64, 0x69,
+	0x6f, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x73, 0x68, 0x61, 0x72, 0x65,
+	0x5f, 0x75, 0x72, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x73, 0x68, 0x61, 0x72,
+	0x65, 0x55, 0x72, 0x6c, 0x12, 0x19, 0x0a, 0x08, 0x73, 0x68, 0x61, 0x72, 0x65, 0x5f, 0x69, 0x64,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x68, 0x61, 0x72, 0x65, 0x49, 0x64, 0x12,
+	0x1b, 0x0a, 0x09, 0x69, 0x73, 0x5f, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x08, 0x69, 0x73, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x22, 0x53, 0x0a, 0x0c,
+	0x43, 0x6f, 0x6c, 0x6c, 0x61, 0x62, 0x6f, 0x72, 0x61, 0x74, 0x6f, 0x72, 0x12, 0x14, 0x0a, 0x05,
+	0x65, 0x6d, 0x61, 0x69, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x6d, 0x61,
+	0x69, 0x6c, 0x12, 0x2d, 0x0a, 0x04, 0x72, 0x6f, 0x6c, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e,
+	0x32, 0x19, 0x2e, 0x6e, 0x6f, 0x74, 0x65, 0x62, 0x6f, 0x6f, 0x6b, 0x6c, 0x6d, 0x2e, 0x76, 0x31,
+	0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x52, 0x6f, 0x6c, 0x65, 0x52, 0x04, 0x72, 0x6f, 0x6c,
+	0x65, 0x22, 0x6a, 0x0a, 0x07, 0x53, 0x68, 0x61, 0x72, 0x69, 0x6e, 0x67, 0x12, 0x16, 0x0a, 0x06,
+	0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x70, 0x75,
+	0x62, 0x6c, 0x69, 0x63, 0x12, 0x47, 0x0a, 0x0d, 0x63, 0x6f, 0x6c, 0x6c, 0x61, 0x62, 0x6f, 0x72,
+	0x61, 0x74, 0x6f, 0x72, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x6e, 0x6f,
+	0x74, 0x65, 0x62, 0x6f, 0x6f, 0x6b, 0x6c, 0x6d, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61,
+	0x31, 0x2e, 0x43, 0x6f, 0x6c, 0x6c, 0x61, 0x62, 0x6f, 0x72, 0x61, 0x74, 0x6f, 0x72, 0x52, 0x0d,
+	0x63, 0x6f, 0x6c, 0x6c, 0x61, 0x62, 0x6f, 0x72, 0x61, 0x74, 0x6f, 0x72, 0x73, 0x2a, 0x8f, 0x02,
+	0x0a, 0x0a, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1b, 0x0a, 0x17,
+	0x53, 0x4f, 0x55, 0x52, 0x43, 0x45, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50,
+	0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x17, 0x0a, 0x13, 0x53, 0x4f, 0x55,
+	0x52, 0x43, 0x45, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e,
+	0x10, 0x01, 0x12, 0x1b, 0x0a, 0x17, 0x53, 0x4f, 0x55, 0x52, 0x43, 0x45, 0x5f, 0x54, 0x59, 0x50,
+	0x45, 0x5f, 0x47, 0x4f, 0x4f, 0x47, 0x4c, 0x45, 0x5f, 0x44, 0x4f, 0x43, 0x53, 0x10, 0x03, 0x12,
+	0x1d, 0x0a, 0x19, 0x53, 0x4f, 0x55, 0x52, 0x43, 0x45, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x47,
+	0x4f, 0x4f, 0x47, 0x4c, 0x45, 0x5f, 0x53, 0x4c, 0x49, 0x44, 0x45, 0x53, 0x10, 0x04, 0x12, 0x1d,
+	0x0a, 0x19, 0x53, 0x4f, 0x55, 0x52, 0x43, 0x45, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x47, 0x4f,
+	0x4f, 0x47, 0x4c, 0x45, 0x5f, 0x53, 0x48, 0x45, 0x45, 0x54, 0x53, 0x10, 0x05, 0x12, 0x1a, 0x0a,
+	0x16, 0x53, 0x4f, 0x55, 0x52, 0x43, 0x45, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x4c, 0x4f, 0x43,
+	0x41, 0x4c, 0x5f, 0x46, 0x49, 0x4c, 0x45, 0x10, 0x06, 0x12, 0x18, 0x0a, 0x14, 0x53, 0x4f, 0x55,
+	0x52, 0x43, 0x45, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x57, 0x45, 0x42, 0x5f, 0x50, 0x41, 0x47,
+	0x45, 0x10, 0x07, 0x12, 0x1b, 0x0a, 0x17, 0x53, 0x4f, 0x55, 0x52, 0x43, 0x45, 0x5f, 0x54, 0x59,
+	0x50, 0x45, 0x5f, 0x53, 0x48, 0x41, 0x52, 0x45, 0x44, 0x5f, 0x4e, 0x4f, 0x54, 0x45, 0x10, 0x08,
+	0x12, 0x1d, 0x0a, 0x19, 0x53, 0x4f, 0x55, 0x52, 0x43, 0x45, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f,
+	0x59, 0x4f, 0x55, 0x54, 0x55, 0x42, 0x45, 0x5f, 0x56, 0x49, 0x44, 0x45, 0x4f, 0x10, 0x09, 0x2a,
+	0x4a, 0x0a, 0x04, 0x52, 0x6f, 0x6c, 0x65, 0x12, 0x10, 0x0a, 0x0c, 0x52, 0x4f, 0x4c, 0x45, 0x5f,
+	0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x0e, 0x0a, 0x0a, 0x52, 0x4f, 0x4c,
+	0x45, 0x5f, 0x4f, 0x57, 0x4e, 0x45, 0x52, 0x10, 0x01, 0x12, 0x0f, 0x0a, 0x0b, 0x52, 0x4f, 0x4c,
+	0x45, 0x5f, 0x45, 0x44, 0x49, 0x54, 0x4f, 0x52, 0x10, 0x02, 0x12, 0x0f, 0x0a, 0x0b, 0x52, 0x4f,
+	0x4c, 0x45, 0x5f, 0x56, 0x49, 0x45, 0x57, 0x45, 0x52, 0x10, 0x03, 0x42, 0x3f, 0x5a, 0x3d, 0x67,
+	0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x74, 0x6d, 0x63, 0x2f, 0x6e, 0x6c,
+	0x6d, 0x2f, 0x67, 0x65, 0x6e, 0x2f, 0x6e, 0x6f, 0x74, 0x65, 0x62, 0x6f, 0x6f, 0x6b, 0x6c, 0x6d,
+	0x2f, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x3b, 0x6e, 0x6f, 0x74, 0x65, 0x62, 0x6f,
+	0x6f, 0x6b, 0x6c, 0x6d, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x62, 0x06, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -1529,58 +1817,65 @@ func file_notebooklm_v1alpha1_notebooklm_proto_rawDescGZIP() []byte {
 	return file_notebooklm_v1alpha1_notebooklm_proto_rawDescData
 }
 
-var file_notebooklm_v1alpha1_notebooklm_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
-var file_notebooklm_v1alpha1_notebooklm_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
-var file_notebooklm_v1alpha1_notebooklm_proto_goTypes = []interface{}{
+var file_notebooklm_v1alpha1_notebooklm_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
+var file_notebooklm_v1alpha1_notebooklm_proto_msgTypes = make([]protoimpl.MessageInfo, 23)
+var file_notebooklm_v1alpha1_notebooklm_proto_goTypes = []any{
 	(SourceType)(0),                            // 0: notebooklm.v1alpha1.SourceType
-	(SourceSettings_SourceStatus)(0),           // 1: notebooklm.v1alpha1.SourceSettings.SourceStatus
-	(SourceIssue_Reason)(0),                    // 2: notebooklm.v1alpha1.SourceIssue.Reason
-	(*Project)(nil),                            // 3: notebooklm.v1alpha1.Project
-	(*ProjectMetadata)(nil),                    // 4: notebooklm.v1alpha1.ProjectMetadata
-	(*SourceId)(nil),                           // 5: notebooklm.v1alpha1.SourceId
-	(*Source)(nil),                             // 6: notebooklm.v1alpha1.Source
-	(*SourceMetadata)(nil),                     // 7: notebooklm.v1alpha1.SourceMetadata
-	(*GoogleDocsSourceMetadata)(nil),           // 8: notebooklm.v1alpha1.GoogleDocsSourceMetadata
-	(*YoutubeSourceMetadata)(nil),              // 9: notebooklm.v1alpha1.YoutubeSourceMetadata
-	(*SourceSettings)(nil),                     // 10: notebooklm.v1alpha1.SourceSettings
-	(*SourceIssue)(nil),                        // 11: notebooklm.v1alpha1.SourceIssue
-	(*GetNotesResponse)(nil),                   // 12: notebooklm.v1alpha1.GetNotesResponse
-	(*AudioOverview)(nil),                      // 13: notebooklm.v1alpha1.AudioOverview
-	(*GenerateDocumentGuidesResponse)(nil),     // 14: notebooklm.v1alpha1.GenerateDocumentGuidesResponse
-	(*DocumentGuide)(nil),                      // 15: notebooklm.v1alpha1.DocumentGuide
-	(*GenerateNotebookGuideResponse)(nil),      // 16: notebooklm.v1alpha1.GenerateNotebookGuideResponse
-	(*GenerateOutlineResponse)(nil),            // 17: notebooklm.v1alpha1.GenerateOutlineResponse
-	(*GenerateSectionResponse)(nil),            // 18: notebooklm.v1alpha1.GenerateSectionResponse
-	(*StartDraftResponse)(nil),                 // 19: notebooklm.v1alpha1.StartDraftResponse
-	(*StartSectionResponse)(nil),               // 20: notebooklm.v1alpha1.StartSectionResponse
-	(*ListRecentlyViewedProjectsResponse)(nil), // 21: notebooklm.v1alpha1.ListRecentlyViewedProjectsResponse
-	(*timestamppb.Timestamp)(nil),              // 22: google.protobuf.Timestamp
-	(*wrapperspb.Int32Value)(nil),              // 23: google.protobuf.Int32Value
+	(Role)(0),                                  // 1: notebooklm.v1alpha1.Role
+	(SourceSettings_SourceStatus)(0),           // 2: notebooklm.v1alpha1.SourceSettings.SourceStatus
+	(SourceIssue_Reason)(0),                    // 3: notebooklm.v1alpha1.SourceIssue.Reason
+	(*Project)(nil),                            // 4: notebooklm.v1alpha1.Project
+	(*ProjectMetadata)(nil),                    // 5: notebooklm.v1alpha1.ProjectMetadata
+	(*SourceId)(nil),                           // 6: notebooklm.v1alpha1.SourceId
+	(*Source)(nil),                             // 7: notebooklm.v1alpha1.Source
+	(*SourceMetadata)(nil),                     // 8: notebooklm.v1alpha1.SourceMetadata
+	(*GoogleDocsSourceMetadata)(nil),           // 9: notebooklm.v1alpha1.GoogleDocsSourceMetadata
+	(*YoutubeSourceMetadata)(nil),              // 10: notebooklm.v1alpha1.YoutubeSourceMetadata
+	(*SourceSettings)(nil),                     // 11: notebooklm.v1alpha1.SourceSettings
+	(*SourceIssue)(nil),                        // 12: notebooklm.v1alpha1.SourceIssue
+	(*GetNotesResponse)(nil),                   // 13: notebooklm.v1alpha1.GetNotesResponse
+	(*AudioOverview)(nil),                      // 14: notebooklm.v1alpha1.AudioOverview
+	(*GenerateDocumentGuidesResponse)(nil),     // 15: notebooklm.v1alpha1.GenerateDocumentGuidesResponse
+	(*DocumentGuide)(nil),                      // 16: notebooklm.v1alpha1.DocumentGuide
+	(*GenerateNotebookGuideResponse)(nil),      // 17: notebooklm.v1alpha1.GenerateNotebookGuideResponse
+	(*GenerateOutlineResponse)(nil),            // 18: notebooklm.v1alpha1.GenerateOutlineResponse
+	(*GenerateSectionResponse)(nil),            // 19: notebooklm.v1alpha1.GenerateSectionResponse
+	(*StartDraftResponse)(nil),                 // 20: notebooklm.v1alpha1.StartDraftResponse
+	(*StartSectionResponse)(nil),               // 21: notebooklm.v1alpha1.StartSectionResponse
+	(*ListRecentlyViewedProjectsResponse)(nil), // 22: notebooklm.v1alpha1.ListRecentlyViewedProjectsResponse
+	(*AudioOverviewResult)(nil),                // 23: notebooklm.v1alpha1.AudioOverviewResult
+	(*ShareAudioResult)(nil),                   // 24: notebooklm.v1alpha1.ShareAudioResult
+	(*Collaborator)(nil),                       // 25: notebooklm.v1alpha1.Collaborator
+	(*Sharing)(nil),                            // 26: notebooklm.v1alpha1.Sharing
+	(*timestamppb.Timestamp)(nil),              // 27: google.protobuf.Timestamp
+	(*wrapperspb.Int32Value)(nil),              // 28: google.protobuf.Int32Value
 }
 var file_notebooklm_v1alpha1_notebooklm_proto_depIdxs = []int32{
-	6,  // 0: notebooklm.v1alpha1.Project.sources:type_name -> notebooklm.v1alpha1.Source
-	4,  // 1: notebooklm.v1alpha1.Project.metadata:type_name -> notebooklm.v1alpha1.ProjectMetadata
-	22, // 2: notebooklm.v1alpha1.ProjectMetadata.create_time:type_name -> google.protobuf.Timestamp
-	22, // 3: notebooklm.v1alpha1.ProjectMetadata.modified_time:type_name -> google.protobuf.Timestamp
-	5,  // 4: notebooklm.v1alpha1.Source.source_id:type_name -> notebooklm.v1alpha1.SourceId
-	7,  // 5: notebooklm.v1alpha1.Source.metadata:type_name -> notebooklm.v1alpha1.SourceMetadata
-	10, // 6: notebooklm.v1alpha1.Source.settings:type_name -> notebooklm.v1alpha1.SourceSettings
-	23, // 7: notebooklm.v1alpha1.Source.warnings:type_name -> google.protobuf.Int32Value
-	8,  // 8: notebooklm.v1alpha1.SourceMetadata.google_docs:type_name -> notebooklm.v1alpha1.GoogleDocsSourceMetadata
-	9,  // 9: notebooklm.v1alpha1.SourceMetadata.youtube:type_name -> notebooklm.v1alpha1.YoutubeSourceMetadata
-	23, // 10: notebooklm.v1alpha1.SourceMetadata.last_update_time_seconds:type_name -> google.protobuf.Int32Value
-	22, // 11: notebooklm.v1alpha1.SourceMetadata.last_modified_time:type_name -> google.protobuf.Timestamp
+	7,  // 0: notebooklm.v1alpha1.Project.sources:type_name -> notebooklm.v1alpha1.Source
+	5,  // 1: notebooklm.v1alpha1.Project.metadata:type_name -> notebooklm.v1alpha1.ProjectMetadata
+	27, // 2: notebooklm.v1alpha1.ProjectMetadata.create_time:type_name -> google.protobuf.Timestamp
+	27, // 3: notebooklm.v1alpha1.ProjectMetadata.modified_time:type_name -> google.protobuf.Timestamp
+	6,  // 4: notebooklm.v1alpha1.Source.source_id:type_name -> notebooklm.v1alpha1.SourceId
+	8,  // 5: notebooklm.v1alpha1.Source.metadata:type_name -> notebooklm.v1alpha1.SourceMetadata
+	11, // 6: notebooklm.v1alpha1.Source.settings:type_name -> notebooklm.v1alpha1.SourceSettings
+	28, // 7: notebooklm.v1alpha1.Source.warnings:type_name -> google.protobuf.Int32Value
+	9,  // 8: notebooklm.v1alpha1.SourceMetadata.google_docs:type_name -> notebooklm.v1alpha1.GoogleDocsSourceMetadata
+	10, // 9: notebooklm.v1alpha1.SourceMetadata.youtube:type_name -> notebooklm.v1alpha1.YoutubeSourceMetadata
+	28, // 10: notebooklm.v1alpha1.SourceMetadata.last_update_time_seconds:type_name -> google.protobuf.Int32Value
+	27, // 11: notebooklm.v1alpha1.SourceMetadata.last_modified_time:type_name -> google.protobuf.Timestamp
 	0,  // 12: notebooklm.v1alpha1.SourceMetadata.source_type:type_name -> notebooklm.v1alpha1.SourceType
-	1,  // 13: notebooklm.v1alpha1.SourceSettings.status:type_name -> notebooklm.v1alpha1.SourceSettings.SourceStatus
-	2,  // 14: notebooklm.v1alpha1.SourceIssue.reason:type_name -> notebooklm.v1alpha1.SourceIssue.Reason
-	6,  // 15: notebooklm.v1alpha1.GetNotesResponse.notes:type_name -> notebooklm.v1alpha1.Source
-	15, // 16: notebooklm.v1alpha1.GenerateDocumentGuidesResponse.guides:type_name -> notebooklm.v1alpha1.DocumentGuide
-	3,  // 17: notebooklm.v1alpha1.ListRecentlyViewedProjectsResponse.projects:type_name -> notebooklm.v1alpha1.Project
-	18, // [18:18] is the sub-list for method output_type
-	18, // [18:18] is the sub-list for method input_type
-	18, // [18:18] is the sub-list for extension type_name
-	18, // [18:18] is the sub-list for extension extendee
-	0,  // [0:18] is the sub-list for field type_name
+	2,  // 13: notebooklm.v1alpha1.SourceSettings.status:type_name -> notebooklm.v1alpha1.SourceSettings.SourceStatus
+	3,  // 14: notebooklm.v1alpha1.SourceIssue.reason:type_name -> notebooklm.v1alpha1.SourceIssue.Reason
+	7,  // 15: notebooklm.v1alpha1.GetNotesResponse.notes:type_name -> notebooklm.v1alpha1.Source
+	16, // 16: notebooklm.v1alpha1.GenerateDocumentGuidesResponse.guides:type_name -> notebooklm.v1alpha1.DocumentGuide
+	4,  // 17: notebooklm.v1alpha1.ListRecentlyViewedProjectsResponse.projects:type_name -> notebooklm.v1alpha1.Project
+	1,  // 18: notebooklm.v1alpha1.Collaborator.role:type_name -> notebooklm.v1alpha1.Role
+	25, // 19: notebooklm.v1alpha1.Sharing.collaborators:type_name -> notebooklm.v1alpha1.Collaborator
+	20, // [20:20] is the sub-list for method output_type
+	20, // [20:20] is the sub-list for method input_type
+	20, // [20:20] is the sub-list for extension type_name
+	20, // [20:20] is the sub-list for extension extendee
+	0,  // [0:20] is the sub-list for field type_name
 }
 
 func init() { file_notebooklm_v1alpha1_notebooklm_proto_init() }
@@ -1588,237 +1883,7 @@ func file_notebooklm_v1alpha1_notebooklm_proto_init() {
 	if File_notebooklm_v1alpha1_notebooklm_proto != nil {
 		return
 	}
-	if !protoimpl.UnsafeEnabled {
-		file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Project); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ProjectMetadata); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SourceId); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Source); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SourceMetadata); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GoogleDocsSourceMetadata); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*YoutubeSourceMetadata); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SourceSettings); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SourceIssue); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GetNotesResponse); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*AudioOverview); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GenerateDocumentGuidesResponse); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*DocumentGuide); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GenerateNotebookGuideResponse); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GenerateOutlineResponse); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GenerateSectionResponse); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*StartDraftResponse); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*StartSectionResponse); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ListRecentlyViewedProjectsResponse); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-	}
-	file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[4].OneofWrappers = []interface{}{
+	file_notebooklm_v1alpha1_notebooklm_proto_msgTypes[4].OneofWrappers = []any{
 		(*SourceMetadata_GoogleDocs)(nil),
 		(*SourceMetadata_Youtube)(nil),
 	}
@@ -1827,8 +1892,8 @@ func file_notebooklm_v1alpha1_notebooklm_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_notebooklm_v1alpha1_notebooklm_proto_rawDesc,
-			NumEnums:      3,
-			NumMessages:   19,
+			NumEnums:      4,
+			NumMessages:   23,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
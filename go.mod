@@ -7,8 +7,12 @@ require (
 	github.com/chromedp/chromedp v0.11.2
 	github.com/davecgh/go-spew v1.1.1
 	github.com/google/go-cmp v0.6.0
+	golang.org/x/crypto v0.31.0
+	golang.org/x/sys v0.28.0
 	golang.org/x/term v0.27.0
+	golang.org/x/text v0.21.0
 	google.golang.org/protobuf v1.35.2
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -18,5 +22,4 @@ require (
 	github.com/gobwas/ws v1.4.0 // indirect
 	github.com/josharian/intern v1.0.0 // indirect
 	github.com/mailru/easyjson v0.7.7 // indirect
-	golang.org/x/sys v0.28.0 // indirect
 )
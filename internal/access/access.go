@@ -0,0 +1,75 @@
+// Package access reviews who notebooks are shared with against an
+// organization's sharing policy.
+package access
+
+import (
+	"strings"
+
+	"github.com/tmc/nlm/internal/api"
+)
+
+// Policy is an organization's sharing policy, set under "sharing" in
+// .nlm.yaml.
+type Policy struct {
+	// Domains lists the email domains collaborators may belong to,
+	// including their subdomains. Empty allows every domain.
+	Domains []string `yaml:"domains"`
+	// NoPublic disallows "anyone with the link" access.
+	NoPublic bool `yaml:"no_public"`
+}
+
+// Internal reports whether email belongs to one of the policy's domains.
+func (p Policy) Internal(email string) bool {
+	if len(p.Domains) == 0 {
+		return true
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(email[at+1:])
+	for _, d := range p.Domains {
+		d = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(d), "@"))
+		if domain == d || strings.HasSuffix(domain, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// Grant is one way a notebook is accessible: a collaborator, or the
+// public link when Email is empty.
+type Grant struct {
+	Email string   `json:"email,omitempty"`
+	Role  api.Role `json:"role"`
+	// Violation explains why the grant breaks the policy, if it does.
+	Violation string `json:"violation,omitempty"`
+}
+
+// Public reports whether the grant is the public link.
+func (g Grant) Public() bool { return g.Email == "" }
+
+// Review lists who besides its owners can open a notebook, marking the
+// grants that break the policy.
+func (p Policy) Review(s *api.Sharing) []Grant {
+	var grants []Grant
+	if s.GetPublic() {
+		g := Grant{Role: api.RoleViewer}
+		if p.NoPublic {
+			g.Violation = "public link"
+		}
+		grants = append(grants, g)
+	}
+	for _, c := range s.GetCollaborators() {
+		role := api.Role(c.GetRole())
+		if role == api.RoleOwner {
+			continue
+		}
+		g := Grant{Email: c.GetEmail(), Role: role}
+		if !p.Internal(c.GetEmail()) {
+			g.Violation = "outside " + strings.Join(p.Domains, ", ")
+		}
+		grants = append(grants, g)
+	}
+	return grants
+}
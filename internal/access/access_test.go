@@ -0,0 +1,57 @@
+package access
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	pb "github.com/tmc/nlm/gen/notebooklm/v1alpha1"
+	"github.com/tmc/nlm/internal/api"
+)
+
+func TestInternal(t *testing.T) {
+	p := Policy{Domains: []string{"example.com", "@Partner.org"}}
+	tests := map[string]bool{
+		"ada@example.com":      true,
+		"ada@EXAMPLE.com":      true,
+		"bob@eng.example.com":  true,
+		"cy@partner.org":       true,
+		"dee@notexample.com":   false,
+		"eve@example.com.evil": false,
+		"no-at-sign":           false,
+	}
+	for email, want := range tests {
+		if got := p.Internal(email); got != want {
+			t.Errorf("Internal(%q) = %v, want %v", email, got, want)
+		}
+	}
+	if !(Policy{}).Internal("anyone@anywhere.test") {
+		t.Error("a policy without domains rejected an address")
+	}
+}
+
+func TestReview(t *testing.T) {
+	s := &api.Sharing{
+		Public: true,
+		Collaborators: []*api.Collaborator{
+			{Email: "owner@example.com", Role: pb.Role_ROLE_OWNER},
+			{Email: "ada@example.com", Role: pb.Role_ROLE_EDITOR},
+			{Email: "bob@gmail.com", Role: pb.Role_ROLE_VIEWER},
+		},
+	}
+	got := Policy{Domains: []string{"example.com"}, NoPublic: true}.Review(s)
+	want := []Grant{
+		{Role: api.RoleViewer, Violation: "public link"},
+		{Email: "ada@example.com", Role: api.RoleEditor},
+		{Email: "bob@gmail.com", Role: api.RoleViewer, Violation: "outside example.com"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Review mismatch (-want +got):\n%s", diff)
+	}
+
+	// Without a policy nothing is a violation.
+	for _, g := range (Policy{}).Review(s) {
+		if g.Violation != "" {
+			t.Errorf("empty policy flagged %+v", g)
+		}
+	}
+}
@@ -2,6 +2,7 @@
 package api
 
 import (
+   "context"
    "encoding/base64"
    "encoding/json"
    "fmt"
@@ -11,6 +12,7 @@ import (
    "os"
    "os/exec"
    "strings"
+   "sync"
    "path/filepath"
    "time"
 
@@ -18,24 +20,118 @@ import (
 	pb "github.com/tmc/nlm/gen/notebooklm/v1alpha1"
 	"github.com/tmc/nlm/internal/batchexecute"
 	"github.com/tmc/nlm/internal/beprotojson"
+	"github.com/tmc/nlm/internal/filename"
 	"github.com/tmc/nlm/internal/rpc"
+	"github.com/tmc/nlm/internal/rpc/rpctypes"
+	"google.golang.org/protobuf/proto"
 )
 
 type Notebook = pb.Project
 type Note = pb.Source
 
+// normalizeTitle prepares a user supplied title for the positional payloads.
+// Titles are NFC normalized so that names typed on macOS (which decomposes
+// accents) match those created in the web UI, and invalid UTF-8 is replaced
+// rather than silently mangled by the JSON encoder.
+func normalizeTitle(title string) string {
+	return filename.Normalize(title, filename.NFC)
+}
+
 // Client handles NotebookLM API interactions.
 type Client struct {
-	rpc *rpc.Client
+	rpc           *rpc.Client
+	savePayloadFn func(name string, data []byte) (string, error)
+	onDrift       func(SchemaDrift)
+	// stream sends file uploads from the reader instead of memory.
+	stream bool
+	// unverified allows the calls that return ErrUnverified.
+	unverified bool
+
+	// notebooks maps the IDs of sources seen in fetched notebooks to
+	// their notebook, so calls on a source can name it.
+	notebooks *sourceNotebooks
+}
+
+// sourceNotebooks maps source IDs to notebook IDs; it is shared by the
+// copies WithContext makes.
+type sourceNotebooks struct {
+	mu sync.Mutex
+	m  map[string]string
 }
 
 // New creates a new NotebookLM API client.
 func New(authToken, cookies string, opts ...batchexecute.Option) *Client {
 	return &Client{
-		rpc: rpc.New(authToken, cookies, opts...),
+		rpc:       rpc.New(authToken, cookies, opts...),
+		notebooks: &sourceNotebooks{m: make(map[string]string)},
 	}
 }
 
+// WithContext returns a copy of c whose calls are bounded by ctx, so that
+// canceling ctx or reaching its deadline stops the request in progress.
+// The copy shares c's settings, hooks and usage counts.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	cp := *c
+	cp.rpc = c.rpc.WithContext(ctx)
+	return &cp
+}
+
+// LastExchange returns the most recent HTTP round trip, for diagnostics
+// such as bug reports.
+func (c *Client) LastExchange() *batchexecute.Exchange {
+	return c.rpc.LastExchange()
+}
+
+// Usage returns the requests sent so far, by RPC ID, for local usage
+// statistics.
+func (c *Client) Usage() batchexecute.Usage {
+	return c.rpc.Usage()
+}
+
+// SetStreamUploads makes AddSourceFromReader stream binary files that can
+// be seeked, such as open files, into the request instead of reading them
+// into memory first. The file is read twice: once to size the request and
+// once to send it.
+func (c *Client) SetStreamUploads(stream bool) {
+	c.stream = stream
+}
+
+// SetCheckArgs makes every call check its arguments against the layout
+// the web client sends, failing with the differences instead of sending
+// a call that does not match. It is meant for development: a failure
+// means nlm builds a call differently from what rpc.ArgSchemas records.
+func (c *Client) SetCheckArgs(check bool) {
+	c.rpc.SetCheckArgs(check)
+}
+
+// AllowUnverified lets calls whose request layout has not been checked
+// against the web client be sent; without it they fail with
+// ErrUnverified.
+func (c *Client) AllowUnverified(allow bool) {
+	c.unverified = allow
+}
+
+// OnChange registers fn to be called after every call that may have
+// changed a notebook, its sources, notes or sharing, with the notebook ID
+// if the call names one. It is called for failed calls too, since those
+// may have been applied in part, so that cached copies can be dropped.
+func (c *Client) OnChange(fn func(notebookID string)) {
+	c.rpc.OnWrite(func(call rpc.Call) { fn(call.NotebookID) })
+}
+
+// CheckChanges registers check to be called with the notebook ID of every
+// call that would change a notebook, before it is sent. If check returns
+// an error, such as a *ReadOnlyError, the call fails with it unsent.
+// Calls that name only a source are not checked.
+func (c *Client) CheckChanges(check func(notebookID string) error) {
+	c.rpc.BeforeWrite(func(call rpc.Call) error {
+		if call.NotebookID == "" {
+			return nil
+		}
+		return check(call.NotebookID)
+	})
+}
+
 // Project/Notebook operations
 
 func (c *Client) ListRecentlyViewedProjects() ([]*Notebook, error) {
@@ -48,8 +144,8 @@ func (c *Client) ListRecentlyViewedProjects() ([]*Notebook, error) {
 	}
 
 	var response pb.ListRecentlyViewedProjectsResponse
-	if err := beprotojson.Unmarshal(resp, &response); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
+	if err := c.decode("ListRecentlyViewedProjects", rpc.RPCListRecentlyViewedProjects, resp, &response, scanProjects); err != nil {
+		return nil, err
 	}
 	return response.Projects, nil
 }
@@ -57,15 +153,15 @@ func (c *Client) ListRecentlyViewedProjects() ([]*Notebook, error) {
 func (c *Client) CreateProject(title string, emoji string) (*Notebook, error) {
 	resp, err := c.rpc.Do(rpc.Call{
 		ID:   rpc.RPCCreateProject,
-		Args: []interface{}{title, emoji},
+		Args: []interface{}{normalizeTitle(title), emoji},
 	})
 	if err != nil {
 		return nil, fmt.Errorf("create project: %w", err)
 	}
 
 	var project pb.Project
-	if err := beprotojson.Unmarshal(resp, &project); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
+	if err := c.decode("CreateProject", rpc.RPCCreateProject, resp, &project, scanProject); err != nil {
+		return nil, err
 	}
 	return &project, nil
 }
@@ -81,9 +177,10 @@ func (c *Client) GetProject(projectID string) (*Notebook, error) {
 	}
 
 	var project pb.Project
-	if err := beprotojson.Unmarshal(resp, &project); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
+	if err := c.decode("GetProject", rpc.RPCGetProject, resp, &project, scanProject); err != nil {
+		return nil, err
 	}
+	c.rememberSources(&project)
 	return &project, nil
 }
 
@@ -109,8 +206,8 @@ func (c *Client) MutateProject(projectID string, updates *pb.Project) (*Notebook
 	}
 
 	var project pb.Project
-	if err := beprotojson.Unmarshal(resp, &project); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
+	if err := c.decode("MutateProject", rpc.RPCMutateProject, resp, &project, scanProject); err != nil {
+		return nil, err
 	}
 	return &project, nil
 }
@@ -144,6 +241,23 @@ func (c *Client) AddSources(projectID string, sources []*pb.Source) ([]*pb.Sourc
 }
 */
 
+// rememberSources records the notebook of each of nb's sources.
+func (c *Client) rememberSources(nb *Notebook) {
+	c.notebooks.mu.Lock()
+	defer c.notebooks.mu.Unlock()
+	for _, src := range nb.GetSources() {
+		c.notebooks.m[src.GetSourceId().GetSourceId()] = nb.GetProjectId()
+	}
+}
+
+// notebookOf returns the notebook of a source in a notebook fetched
+// before, or "".
+func (c *Client) notebookOf(sourceID string) string {
+	c.notebooks.mu.Lock()
+	defer c.notebooks.mu.Unlock()
+	return c.notebooks.m[sourceID]
+}
+
 func (c *Client) DeleteSources(projectID string, sourceIDs []string) error {
 	_, err := c.rpc.Do(rpc.Call{
 		ID: rpc.RPCDeleteSources,
@@ -155,34 +269,54 @@ func (c *Client) DeleteSources(projectID string, sourceIDs []string) error {
 	return err
 }
 
+// MutateSource applies the fields set in updates, such as a new title, to
+// a source. updates is not modified.
 func (c *Client) MutateSource(sourceID string, updates *pb.Source) (*pb.Source, error) {
+	if updates == nil {
+		return nil, fmt.Errorf("mutate source: no updates")
+	}
+	updates = proto.Clone(updates).(*pb.Source)
+	updates.Title = normalizeTitle(updates.Title)
 	resp, err := c.rpc.Do(rpc.Call{
-		ID:   rpc.RPCMutateSource,
-		Args: []interface{}{sourceID, updates},
+		ID:         rpc.RPCMutateSource,
+		Args:       []interface{}{sourceID, updates},
+		NotebookID: c.notebookOf(sourceID),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("mutate source: %w", err)
 	}
 
 	var source pb.Source
-	if err := beprotojson.Unmarshal(resp, &source); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
+	if err := c.decode("MutateSource", rpc.RPCMutateSource, resp, &source, scanSource); err != nil {
+		return nil, err
 	}
 	return &source, nil
 }
 
+// SetSourceEnabled turns a source on or off for chat and generation, like
+// the checkbox next to it in the web UI. Disabled sources stay in the
+// notebook but are left out of answers, guides and audio overviews.
+func (c *Client) SetSourceEnabled(sourceID string, enabled bool) (*pb.Source, error) {
+	status := pb.SourceSettings_SOURCE_STATUS_DISABLED
+	if enabled {
+		status = pb.SourceSettings_SOURCE_STATUS_ENABLED
+	}
+	return c.MutateSource(sourceID, &pb.Source{Settings: &pb.SourceSettings{Status: status}})
+}
+
 func (c *Client) RefreshSource(sourceID string) (*pb.Source, error) {
 	resp, err := c.rpc.Do(rpc.Call{
-		ID:   rpc.RPCRefreshSource,
-		Args: []interface{}{sourceID},
+		ID:         rpc.RPCRefreshSource,
+		Args:       []interface{}{sourceID},
+		NotebookID: c.notebookOf(sourceID),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("refresh source: %w", err)
 	}
 
 	var source pb.Source
-	if err := beprotojson.Unmarshal(resp, &source); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
+	if err := c.decode("RefreshSource", rpc.RPCRefreshSource, resp, &source, scanSource); err != nil {
+		return nil, err
 	}
 	return &source, nil
 }
@@ -197,12 +331,25 @@ func (c *Client) LoadSource(sourceID string) (*pb.Source, error) {
 	}
 
 	var source pb.Source
-	if err := beprotojson.Unmarshal(resp, &source); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
+	if err := c.decode("LoadSource", rpc.RPCLoadSource, resp, &source, scanSource); err != nil {
+		return nil, err
 	}
 	return &source, nil
 }
 
+// LoadSourceRaw returns the undecoded LoadSource payload, which includes
+// fields not modeled by the proto definitions such as the extracted text.
+func (c *Client) LoadSourceRaw(sourceID string) (json.RawMessage, error) {
+	resp, err := c.rpc.Do(rpc.Call{
+		ID:   rpc.RPCLoadSource,
+		Args: []interface{}{sourceID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load source: %w", err)
+	}
+	return resp, nil
+}
+
 /*
 func (c *Client) CheckSourceFreshness(sourceID string) (*pb.CheckSourceFreshnessResponse, error) {
 	resp, err := c.rpc.Do(rpc.Call{
@@ -233,6 +380,9 @@ func (c *Client) ActOnSources(projectID string, action string, sourceIDs []strin
 // Source upload utility methods
 
 func (c *Client) AddSourceFromReader(projectID string, r io.Reader, filename string) (string, error) {
+	if rs, ok := r.(io.ReadSeeker); ok && c.stream {
+		return c.streamSource(projectID, rs, filename)
+	}
 	content, err := io.ReadAll(r)
 	if err != nil {
 		return "", fmt.Errorf("read content: %w", err)
@@ -257,7 +407,7 @@ func (c *Client) AddSourceFromText(projectID string, content, title string) (str
 				[]interface{}{
 					nil,
 					[]string{
-						title,
+						normalizeTitle(title),
 						content,
 					},
 					nil,
@@ -278,7 +428,43 @@ func (c *Client) AddSourceFromText(projectID string, content, title string) (str
 	return sourceID, nil
 }
 
+// streamSource uploads r like AddSourceFromReader, streaming binary
+// content. Text is still read whole, as it is sent as a text source.
+func (c *Client) streamSource(projectID string, r io.ReadSeeker, filename string) (string, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", fmt.Errorf("read content: %w", err)
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("read content: %w", err)
+	}
+	contentType := http.DetectContentType(head[:n])
+	if strings.HasPrefix(contentType, "text/") {
+		content, err := io.ReadAll(r)
+		if err != nil {
+			return "", fmt.Errorf("read content: %w", err)
+		}
+		return c.AddSourceFromText(projectID, string(content), filename)
+	}
+	return c.addBinarySource(projectID, &batchexecute.Blob{R: r}, filename, contentType)
+}
+
 func (c *Client) AddSourceFromBase64(projectID string, content, filename, contentType string) (string, error) {
+	return c.addBinarySource(projectID, content, filename, contentType)
+}
+
+// AddSourceFromStream adds the binary content of r, of contentType, as a
+// file source, streaming it into the request as base64 rather than
+// holding it in memory. r is read twice: once to size the request and
+// once to send it.
+func (c *Client) AddSourceFromStream(projectID string, r io.ReadSeeker, filename, contentType string) (string, error) {
+	return c.addBinarySource(projectID, &batchexecute.Blob{R: r}, filename, contentType)
+}
+
+// addBinarySource adds a file source; content is the base64 string or a
+// *batchexecute.Blob streaming it.
+func (c *Client) addBinarySource(projectID string, content interface{}, filename, contentType string) (string, error) {
 	resp, err := c.rpc.Do(rpc.Call{
 		ID:         rpc.RPCAddSources,
 		NotebookID: projectID,
@@ -286,7 +472,7 @@ func (c *Client) AddSourceFromBase64(projectID string, content, filename, conten
            []interface{}{
                []interface{}{
                    content,
-                   filename,
+                   normalizeTitle(filename),
                    contentType,
                    "base64",
                    pb.SourceType_SOURCE_TYPE_LOCAL_FILE,
@@ -301,8 +487,7 @@ func (c *Client) AddSourceFromBase64(projectID string, content, filename, conten
 
 	sourceID, err := extractSourceID(resp)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, resp)
-		spew.Dump(resp)
+		fmt.Fprintln(os.Stderr, c.rpc.Redact(string(resp)))
 		return "", fmt.Errorf("extract source ID: %w", err)
 	}
 	return sourceID, nil
@@ -413,9 +598,9 @@ func (c *Client) AddSourceFromURL(projectID string, url string) (string, error)
 
 func (c *Client) AddYouTubeSource(projectID, videoID string) (string, error) {
 	if c.rpc.Config.Debug {
-		fmt.Printf("=== AddYouTubeSource ===\n")
-		fmt.Printf("Project ID: %s\n", projectID)
-		fmt.Printf("Video ID: %s\n", videoID)
+		fmt.Fprintf(os.Stderr, "=== AddYouTubeSource ===\n")
+		fmt.Fprintf(os.Stderr, "Project ID: %s\n", projectID)
+		fmt.Fprintf(os.Stderr, "Video ID: %s\n", videoID)
 	}
 
 	// Modified payload structure for YouTube
@@ -433,8 +618,8 @@ func (c *Client) AddYouTubeSource(projectID, videoID string) (string, error) {
 	}
 
 	if c.rpc.Config.Debug {
-		fmt.Printf("\nPayload Structure:\n")
-		spew.Dump(payload)
+		fmt.Fprintf(os.Stderr, "\nPayload Structure:\n")
+		fmt.Fprint(os.Stderr, c.rpc.Redact(spew.Sdump(payload)))
 	}
 
 	resp, err := c.rpc.Do(rpc.Call{
@@ -447,7 +632,7 @@ func (c *Client) AddYouTubeSource(projectID, videoID string) (string, error) {
 	}
 
 	if c.rpc.Config.Debug {
-		fmt.Printf("\nRaw Response:\n%s\n", string(resp))
+		fmt.Fprintf(os.Stderr, "\nRaw Response:\n%s\n", c.rpc.Redact(string(resp)))
 	}
 
 	if len(resp) == 0 {
@@ -535,7 +720,7 @@ func (c *Client) CreateNote(projectID string, title string, initialContent strin
 			initialContent,
 			[]int{1}, // note type
 			nil,
-			title,
+			normalizeTitle(title),
 		},
 		NotebookID: projectID,
 	})
@@ -544,8 +729,8 @@ func (c *Client) CreateNote(projectID string, title string, initialContent strin
 	}
 
 	var note Note
-	if err := beprotojson.Unmarshal(resp, &note); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
+	if err := c.decode("CreateNote", rpc.RPCCreateNote, resp, &note, scanSource); err != nil {
+		return nil, err
 	}
 	return &note, nil
 }
@@ -557,7 +742,7 @@ func (c *Client) MutateNote(projectID string, noteID string, content string, tit
 			projectID,
 			noteID,
 			[][][]interface{}{{
-				{content, title, []interface{}{}},
+				{content, normalizeTitle(title), []interface{}{}},
 			}},
 		},
 		NotebookID: projectID,
@@ -567,8 +752,8 @@ func (c *Client) MutateNote(projectID string, noteID string, content string, tit
 	}
 
 	var note Note
-	if err := beprotojson.Unmarshal(resp, &note); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
+	if err := c.decode("MutateNote", rpc.RPCMutateNote, resp, &note, scanSource); err != nil {
+		return nil, err
 	}
 	return &note, nil
 }
@@ -595,12 +780,79 @@ func (c *Client) GetNotes(projectID string) ([]*Note, error) {
 	}
 
 	var response pb.GetNotesResponse
-	if err := beprotojson.Unmarshal(resp, &response); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
+	if err := c.decode("GetNotes", rpc.RPCGetNotes, resp, &response, scanNotes); err != nil {
+		return nil, err
 	}
 	return response.Notes, nil
 }
 
+// GetNotesRaw returns the undecoded payload of each note in the notebook.
+// Unlike GetNotes, the payloads retain the note content.
+func (c *Client) GetNotesRaw(projectID string) ([]json.RawMessage, error) {
+	resp, err := c.rpc.Do(rpc.Call{
+		ID:         rpc.RPCGetNotes,
+		Args:       []interface{}{projectID},
+		NotebookID: projectID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get notes: %w", err)
+	}
+
+	// GetNotesResponse is [[note, note, ...]].
+	var response []json.RawMessage
+	if err := json.Unmarshal(resp, &response); err != nil {
+		return nil, fmt.Errorf("parse response: %w", err)
+	}
+	if len(response) == 0 {
+		return nil, nil
+	}
+	var notes []json.RawMessage
+	if err := json.Unmarshal(response[0], &notes); err != nil {
+		return nil, fmt.Errorf("parse notes: %w", err)
+	}
+	return notes, nil
+}
+
+// NoteContent decodes the ID, title and text of a note payload from
+// GetNotesRaw. A note is [[noteID], [noteID, content, metadata, null,
+// title], ...], the order in which CreateNote takes the same fields. A
+// payload in any other layout is an error rather than a guess, so that a
+// caller never saves some other field of the note as its content.
+func NoteContent(raw json.RawMessage) (id, title, content string, err error) {
+	var note []json.RawMessage
+	if err := json.Unmarshal(raw, &note); err != nil {
+		return "", "", "", fmt.Errorf("parse note: %w", err)
+	}
+	var ids []string
+	var fields []json.RawMessage
+	if len(note) < 2 || json.Unmarshal(note[0], &ids) != nil || len(ids) != 1 ||
+		json.Unmarshal(note[1], &fields) != nil || len(fields) < 5 {
+		return "", "", "", fmt.Errorf("parse note: unrecognized layout")
+	}
+	if inner, ok := jsonString(fields[0]); !ok || inner != ids[0] {
+		return "", "", "", fmt.Errorf("parse note %s: unrecognized layout", ids[0])
+	}
+	content, ok := jsonString(fields[1])
+	if !ok {
+		return "", "", "", fmt.Errorf("parse note %s: content is not a string", ids[0])
+	}
+	title, ok = jsonString(fields[4])
+	if !ok {
+		return "", "", "", fmt.Errorf("parse note %s: title is not a string", ids[0])
+	}
+	return ids[0], title, content, nil
+}
+
+// jsonString decodes raw if it is a JSON string, which unlike
+// json.Unmarshal into a string rejects null.
+func jsonString(raw json.RawMessage) (string, bool) {
+	var s string
+	if len(raw) == 0 || raw[0] != '"' || json.Unmarshal(raw, &s) != nil {
+		return "", false
+	}
+	return s, true
+}
+
 // Audio operations
 
 func (c *Client) CreateAudioOverview(projectID string, instructions string) (*AudioOverviewResult, error) {
@@ -632,7 +884,7 @@ func (c *Client) CreateAudioOverview(projectID string, instructions string) (*Au
    }
 
    result := &AudioOverviewResult{
-       ProjectID: projectID,
+       ProjectId: projectID,
    }
 
    // Handle empty or nil response
@@ -672,7 +924,7 @@ func (c *Client) CreateAudioOverview(projectID string, instructions string) (*Au
 
 		// Extract ID (index 2)
 		if id, ok := audioData[2].(string); ok {
-			result.AudioID = id
+			result.AudioId = id
 		}
 
 		// Extract title (index 3)
@@ -710,7 +962,7 @@ func (c *Client) GetAudioOverview(projectID string) (*AudioOverviewResult, error
 	}
 
 	result := &AudioOverviewResult{
-		ProjectID: projectID,
+		ProjectId: projectID,
 	}
 
 	// Handle empty or nil response
@@ -738,7 +990,7 @@ func (c *Client) GetAudioOverview(projectID string) (*AudioOverviewResult, error
 
 		// Extract ID (index 2)
 		if id, ok := audioData[2].(string); ok {
-			result.AudioID = id
+			result.AudioId = id
 		}
 
 		// Extract title (index 3)
@@ -757,21 +1009,24 @@ func (c *Client) GetAudioOverview(projectID string) (*AudioOverviewResult, error
 	return result, nil
 }
 
-// AudioOverviewResult represents an audio overview response
-type AudioOverviewResult struct {
-	ProjectID string
-	AudioID   string
-	Title     string
-	AudioData string // Base64 encoded audio data
-	IsReady   bool
+// AudioOverviewResult is a notebook's audio overview.
+type AudioOverviewResult = pb.AudioOverviewResult
+
+// AudioBytes returns the decoded audio of r.
+func AudioBytes(r *AudioOverviewResult) ([]byte, error) {
+	if r.GetAudioData() == "" {
+		return nil, fmt.Errorf("no audio data available")
+	}
+	return base64.StdEncoding.DecodeString(r.GetAudioData())
 }
 
-// GetAudioBytes returns the decoded audio data
-func (r *AudioOverviewResult) GetAudioBytes() ([]byte, error) {
-	if r.AudioData == "" {
+// AudioReader returns a reader decoding the audio of r as it is read,
+// without a second copy of the audio in memory.
+func AudioReader(r *AudioOverviewResult) (io.Reader, error) {
+	if r.GetAudioData() == "" {
 		return nil, fmt.Errorf("no audio data available")
 	}
-	return base64.StdEncoding.DecodeString(r.AudioData)
+	return base64.NewDecoder(base64.StdEncoding, strings.NewReader(r.GetAudioData())), nil
 }
 
 func (c *Client) DeleteAudioOverview(projectID string) error {
@@ -796,8 +1051,8 @@ func (c *Client) GenerateDocumentGuides(projectID string) (*pb.GenerateDocumentG
 	}
 
 	var guides pb.GenerateDocumentGuidesResponse
-	if err := beprotojson.Unmarshal(resp, &guides); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
+	if err := c.decode("GenerateDocumentGuides", rpc.RPCGenerateDocumentGuides, resp, &guides, nil); err != nil {
+		return nil, err
 	}
 	return &guides, nil
 }
@@ -813,8 +1068,8 @@ func (c *Client) GenerateNotebookGuide(projectID string) (*pb.GenerateNotebookGu
 	}
 
 	var guide pb.GenerateNotebookGuideResponse
-	if err := beprotojson.Unmarshal(resp, &guide); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
+	if err := c.decode("GenerateNotebookGuide", rpc.RPCGenerateNotebookGuide, resp, &guide, nil); err != nil {
+		return nil, err
 	}
 	return &guide, nil
 }
@@ -830,8 +1085,8 @@ func (c *Client) GenerateOutline(projectID string) (*pb.GenerateOutlineResponse,
 	}
 
 	var outline pb.GenerateOutlineResponse
-	if err := beprotojson.Unmarshal(resp, &outline); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
+	if err := c.decode("GenerateOutline", rpc.RPCGenerateOutline, resp, &outline, nil); err != nil {
+		return nil, err
 	}
 	return &outline, nil
 }
@@ -847,8 +1102,8 @@ func (c *Client) GenerateSection(projectID string) (*pb.GenerateSectionResponse,
 	}
 
 	var section pb.GenerateSectionResponse
-	if err := beprotojson.Unmarshal(resp, &section); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
+	if err := c.decode("GenerateSection", rpc.RPCGenerateSection, resp, &section, nil); err != nil {
+		return nil, err
 	}
 	return &section, nil
 }
@@ -864,8 +1119,8 @@ func (c *Client) StartDraft(projectID string) (*pb.StartDraftResponse, error) {
 	}
 
 	var draft pb.StartDraftResponse
-	if err := beprotojson.Unmarshal(resp, &draft); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
+	if err := c.decode("StartDraft", rpc.RPCStartDraft, resp, &draft, nil); err != nil {
+		return nil, err
 	}
 	return &draft, nil
 }
@@ -881,8 +1136,8 @@ func (c *Client) StartSection(projectID string) (*pb.StartSectionResponse, error
 	}
 
 	var section pb.StartSectionResponse
-	if err := beprotojson.Unmarshal(resp, &section); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
+	if err := c.decode("StartSection", rpc.RPCStartSection, resp, &section, nil); err != nil {
+		return nil, err
 	}
 	return &section, nil
 }
@@ -897,52 +1152,28 @@ const (
 	SharePublic  ShareOption = 1
 )
 
-// ShareAudioResult represents the response from sharing audio
-type ShareAudioResult struct {
-	ShareURL string
-	ShareID  string
-	IsPublic bool
-}
+// ShareAudioResult is the link to a shared audio overview.
+type ShareAudioResult = pb.ShareAudioResult
 
 // ShareAudio shares an audio overview with optional public access
 func (c *Client) ShareAudio(projectID string, shareOption ShareOption) (*ShareAudioResult, error) {
-	resp, err := c.rpc.Do(rpc.Call{
-		ID: rpc.RPCShareAudio,
-		Args: []interface{}{
-			[]int{int(shareOption)},
-			projectID,
-		},
-		NotebookID: projectID,
-	})
+	resp, err := c.rpc.Do(rpctypes.ShareAudioArgs{
+		ShareOption: int(shareOption),
+		ProjectID:   projectID,
+	}.Call())
 	if err != nil {
 		return nil, fmt.Errorf("share audio: %w", err)
 	}
 
-	// Parse the raw response
-	var data []interface{}
-	if err := json.Unmarshal(resp, &data); err != nil {
+	r, err := rpctypes.DecodeShareAudioResult(resp)
+	if err != nil {
 		return nil, fmt.Errorf("parse response: %w", err)
 	}
-
-	result := &ShareAudioResult{
+	return &ShareAudioResult{
+		ShareUrl: r.URL,
+		ShareId:  r.ID,
 		IsPublic: shareOption == SharePublic,
-	}
-
-	// Extract share URL and ID from response
-	if len(data) > 0 {
-		if shareData, ok := data[0].([]interface{}); ok && len(shareData) > 0 {
-			if shareURL, ok := shareData[0].(string); ok {
-				result.ShareURL = shareURL
-			}
-			if len(shareData) > 1 {
-				if shareID, ok := shareData[1].(string); ok {
-					result.ShareID = shareID
-				}
-			}
-		}
-	}
-
-	return result, nil
+	}, nil
 }
 
 // Helper functions to identify and extract YouTube video IDs
@@ -981,5 +1212,6 @@ func (c *Client) GetSources(projectID string) ([]*pb.Source, error) {
    if err := beprotojson.Unmarshal(resp, &project); err != nil {
        return nil, fmt.Errorf("parse project response: %w", err)
    }
+   c.rememberSources(&project)
    return project.GetSources(), nil
 }
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	pb "github.com/tmc/nlm/gen/notebooklm/v1alpha1"
+	"github.com/tmc/nlm/internal/batchexecute"
+)
+
+// roundTripFunc is an http.RoundTripper calling itself.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestMutateSource(t *testing.T) {
+	var sent string
+	c := New("token", "cookies", batchexecute.WithHTTPClient(&http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body, _ := io.ReadAll(r.Body)
+		sent, _ = url.QueryUnescape(string(body))
+		return nil, errors.New("offline")
+	})}))
+
+	if _, err := c.MutateSource("src1", nil); err == nil {
+		t.Error("MutateSource(nil) succeeded")
+	}
+
+	// The title is sent NFC normalized, but the caller's copy is left
+	// as it was.
+	updates := &pb.Source{Title: "Cafe\u0301"}
+	if _, err := c.MutateSource("src1", updates); err == nil {
+		t.Fatal("MutateSource succeeded offline")
+	}
+	if updates.Title != "Cafe\u0301" {
+		t.Errorf("updates.Title = %q, want it unchanged", updates.Title)
+	}
+	if !strings.Contains(sent, "Caf\u00e9") {
+		t.Errorf("request %q does not carry the normalized title", sent)
+	}
+}
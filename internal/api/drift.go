@@ -0,0 +1,191 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	pb "github.com/tmc/nlm/gen/notebooklm/v1alpha1"
+	"github.com/tmc/nlm/internal/beprotojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// SchemaDrift describes a response that no longer matches the layout nlm
+// expects, usually because NotebookLM changed its payloads.
+type SchemaDrift struct {
+	Time   time.Time `json:"time"`
+	Method string    `json:"method"`
+	RPC    string    `json:"rpc"`
+	Error  string    `json:"error"`
+	// Recovered is set when a best-effort scan extracted enough of the
+	// response for the caller to continue.
+	Recovered bool `json:"recovered"`
+	// Payload is the path of the saved raw response, if any.
+	Payload string `json:"payload,omitempty"`
+	// Changes are the positions whose type differs from the golden shape,
+	// for drift found by WatchShapes.
+	Changes []ShapeChange `json:"changes,omitempty"`
+}
+
+// SavePayloadsWith sets the func that saves the raw payload of a response
+// that fails to decode, already redacted, under a file name such as
+// "payload-wXbhsf-20261016T030000.000.json". It returns where the payload
+// was saved, for the error message. Payloads are not saved when save is
+// nil.
+func (c *Client) SavePayloadsWith(save func(name string, data []byte) (string, error)) {
+	c.savePayloadFn = save
+}
+
+// OnSchemaDrift registers fn to be called whenever a response fails to
+// decode, whether or not it could be recovered.
+func (c *Client) OnSchemaDrift(fn func(SchemaDrift)) {
+	c.onDrift = fn
+}
+
+// decode unmarshals resp into m, first rewriting a response in a previous
+// layout of its RPC into the current one. If the payload no longer matches
+// the proto layout, the raw payload is saved, fallback (if any) is given
+// the decoded JSON to extract what it can into m, and the drift is
+// reported. decode only fails if there is no fallback or the fallback
+// finds nothing.
+func (c *Client) decode(method, rpcID string, resp json.RawMessage, m proto.Message, fallback func(v interface{}, m proto.Message) bool) error {
+	data, _ := adapt(rpcID, resp)
+	err := beprotojson.Unmarshal(data, m)
+	if err == nil {
+		return nil
+	}
+	d := SchemaDrift{
+		Time:    time.Now().UTC(),
+		Method:  method,
+		RPC:     rpcID,
+		Error:   err.Error(),
+		Payload: c.savePayload(rpcID, resp),
+	}
+	if fallback != nil {
+		var v interface{}
+		if json.Unmarshal(data, &v) == nil {
+			proto.Reset(m)
+			d.Recovered = fallback(v, m)
+		}
+	}
+	if c.onDrift != nil {
+		c.onDrift(d)
+	}
+	if d.Recovered {
+		return nil
+	}
+	if d.Payload != "" {
+		return fmt.Errorf("parse response: %w (raw payload saved to %s)", err, d.Payload)
+	}
+	return fmt.Errorf("parse response: %w", err)
+}
+
+// savePayload saves a redacted copy of resp and returns where, or "" if it
+// was not saved.
+func (c *Client) savePayload(rpcID string, resp json.RawMessage) string {
+	if c.savePayloadFn == nil {
+		return ""
+	}
+	name := fmt.Sprintf("payload-%s-%s.json", rpcID, time.Now().UTC().Format("20060102T150405.000"))
+	where, err := c.savePayloadFn(name, []byte(c.rpc.Redact(string(resp))))
+	if err != nil {
+		return ""
+	}
+	return where
+}
+
+var uuidRE = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// scanRecord looks for an ID and title directly inside arr. The ID is a
+// UUID element, or a UUID wrapped in a one element array as source IDs
+// are; the title is the first other string element.
+func scanRecord(arr []interface{}) (id, title string) {
+	for _, e := range arr {
+		switch e := e.(type) {
+		case string:
+			if id == "" && uuidRE.MatchString(e) {
+				id = e
+			} else if title == "" && !uuidRE.MatchString(e) {
+				title = e
+			}
+		case []interface{}:
+			if s, ok := singleString(e); ok && id == "" && uuidRE.MatchString(s) {
+				id = s
+			}
+		}
+	}
+	return id, title
+}
+
+func singleString(arr []interface{}) (string, bool) {
+	if len(arr) != 1 {
+		return "", false
+	}
+	s, ok := arr[0].(string)
+	return s, ok
+}
+
+// scanRecords returns the outermost arrays in v that hold an ID, in order.
+// Nested records, such as the sources inside a notebook, are not visited.
+func scanRecords(v interface{}, visit func(id, title string)) {
+	arr, ok := v.([]interface{})
+	if !ok {
+		return
+	}
+	if id, title := scanRecord(arr); id != "" {
+		visit(id, title)
+		return
+	}
+	for _, e := range arr {
+		scanRecords(e, visit)
+	}
+}
+
+// scanProjects recovers notebook IDs and titles from a drifted
+// ListRecentlyViewedProjects response.
+func scanProjects(v interface{}, m proto.Message) bool {
+	resp := m.(*pb.ListRecentlyViewedProjectsResponse)
+	seen := make(map[string]bool)
+	scanRecords(v, func(id, title string) {
+		if seen[id] {
+			return
+		}
+		seen[id] = true
+		resp.Projects = append(resp.Projects, &pb.Project{ProjectId: id, Title: title})
+	})
+	return len(resp.Projects) > 0
+}
+
+// scanProject recovers a notebook's ID and title from a drifted payload.
+func scanProject(v interface{}, m proto.Message) bool {
+	p := m.(*pb.Project)
+	scanRecords(v, func(id, title string) {
+		if p.ProjectId == "" {
+			p.ProjectId, p.Title = id, title
+		}
+	})
+	return p.ProjectId != ""
+}
+
+// scanSource recovers a source or note ID and title from a drifted
+// payload.
+func scanSource(v interface{}, m proto.Message) bool {
+	s := m.(*pb.Source)
+	scanRecords(v, func(id, title string) {
+		if s.SourceId == nil {
+			s.SourceId = &pb.SourceId{SourceId: id}
+			s.Title = title
+		}
+	})
+	return s.SourceId != nil
+}
+
+// scanNotes recovers note IDs and titles from a drifted GetNotes response.
+func scanNotes(v interface{}, m proto.Message) bool {
+	resp := m.(*pb.GetNotesResponse)
+	scanRecords(v, func(id, title string) {
+		resp.Notes = append(resp.Notes, &pb.Source{SourceId: &pb.SourceId{SourceId: id}, Title: title})
+	})
+	return len(resp.Notes) > 0
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	pb "github.com/tmc/nlm/gen/notebooklm/v1alpha1"
+	"github.com/tmc/nlm/internal/rpc"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+const (
+	nb1  = "fec1780c-5a14-4f07-8ee6-f8c3ee2930fa"
+	nb2  = "437c839c-5a24-455b-b8da-d35ba8931811"
+	src1 = "0b4c1a56-8d7e-4b39-9f1e-2a6c3d5e7f80"
+)
+
+func TestDecodeDrift(t *testing.T) {
+	tests := []struct {
+		name      string
+		payload   string
+		want      *pb.ListRecentlyViewedProjectsResponse
+		recovered bool
+		wantErr   bool
+	}{
+		{
+			name:    "matches schema",
+			payload: `[[["one",null,"` + nb1 + `","📘"]]]`,
+			want: &pb.ListRecentlyViewedProjectsResponse{Projects: []*pb.Project{
+				{Title: "one", ProjectId: nb1, Emoji: "📘"},
+			}},
+		},
+		{
+			name: "id moved into the sources slot",
+			payload: `[[["one","` + nb1 + `",[[["` + src1 + `"],"source"]]],` +
+				`["two",[],"` + nb2 + `",7]]]`,
+			want: &pb.ListRecentlyViewedProjectsResponse{Projects: []*pb.Project{
+				{ProjectId: nb1, Title: "one"},
+				{ProjectId: nb2, Title: "two"},
+			}},
+			recovered: true,
+		},
+		{
+			name:    "nothing recognizable",
+			payload: `[{"projects":true}]`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{rpc: rpc.New("", "")}
+			saved := make(map[string][]byte)
+			c.SavePayloadsWith(func(name string, data []byte) (string, error) {
+				saved[name] = data
+				return name, nil
+			})
+			var drift *SchemaDrift
+			c.OnSchemaDrift(func(d SchemaDrift) { drift = &d })
+
+			var got pb.ListRecentlyViewedProjectsResponse
+			err := c.decode("ListRecentlyViewedProjects", rpc.RPCListRecentlyViewedProjects, []byte(tt.payload), &got, scanProjects)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decode error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want != nil {
+				if diff := cmp.Diff(tt.want, &got, protocmp.Transform()); diff != "" {
+					t.Errorf("decode mismatch (-want +got):\n%s", diff)
+				}
+			}
+			if (drift == nil) != (!tt.recovered && !tt.wantErr) {
+				t.Fatalf("drift reported = %v", drift)
+			}
+			if drift == nil {
+				return
+			}
+			if drift.Recovered != tt.recovered {
+				t.Errorf("Recovered = %v, want %v", drift.Recovered, tt.recovered)
+			}
+			data, ok := saved[drift.Payload]
+			if !ok {
+				t.Fatalf("payload %q not saved", drift.Payload)
+			}
+			if string(data) != tt.payload {
+				t.Errorf("saved payload = %s, want %s", data, tt.payload)
+			}
+		})
+	}
+}
+
+func TestScanSource(t *testing.T) {
+	var s pb.Source
+	v := []interface{}{[]interface{}{"extra", []interface{}{[]interface{}{src1}, "My source", 3.0}}}
+	if !scanSource(v, &s) {
+		t.Fatal("scanSource found nothing")
+	}
+	if s.GetSourceId().GetSourceId() != src1 || !strings.HasPrefix(s.GetTitle(), "My") {
+		t.Errorf("scanSource = %v", &s)
+	}
+}
+
+func TestNoteContent(t *testing.T) {
+	raw := `[["` + src1 + `"],["` + src1 + `","Line one\nLine two",[2,"x"],null,"Meeting notes"]]`
+	id, title, content, err := NoteContent([]byte(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != src1 || title != "Meeting notes" || content != "Line one\nLine two" {
+		t.Errorf("NoteContent = %q, %q, %q", id, title, content)
+	}
+
+	for _, raw := range []string{
+		`[["` + src1 + `"],"Meeting notes"]`,
+		`[["` + src1 + `"],["` + src1 + `","Line one"]]`,
+		`[["` + src1 + `"],["` + nb1 + `","Line one",[2],null,"Meeting notes"]]`,
+		`[["` + src1 + `"],["` + src1 + `",null,[2],null,"Meeting notes"]]`,
+		`[["` + src1 + `"],["` + src1 + `","Line one",[2],null,["Meeting notes"]]]`,
+	} {
+		if _, _, content, err := NoteContent([]byte(raw)); err == nil {
+			t.Errorf("NoteContent(%s) = %q, want error", raw, content)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tmc/nlm/internal/batchexecute"
+	"github.com/tmc/nlm/internal/rpc"
+)
+
+// Feature is an optional NotebookLM capability that is rolled out per
+// account and may be missing from some builds of the service.
+type Feature struct {
+	Name        string
+	Description string
+	// probe makes a read-only call that fails with ErrUnavailable when the
+	// feature is missing. Features without a probe are only learned from
+	// failed calls. Probes that need a notebook receive one of the user's
+	// notebook IDs.
+	probe         func(c *Client, projectID string) error
+	needsNotebook bool
+}
+
+// Features lists the optional capabilities nlm knows how to detect.
+var Features = []Feature{
+	{
+		Name:        "audio",
+		Description: "audio overviews",
+		probe: func(c *Client, projectID string) error {
+			_, err := c.rpc.Do(rpc.Call{
+				ID:         rpc.RPCGetAudioOverview,
+				Args:       []interface{}{projectID, 1},
+				NotebookID: projectID,
+			})
+			return err
+		},
+		needsNotebook: true,
+	},
+	{
+		Name:        "sharing",
+		Description: "notebook and audio sharing",
+		probe: func(c *Client, projectID string) error {
+			_, err := c.rpc.Do(rpc.Call{
+				ID:         rpc.RPCGetProjectDetails,
+				Args:       []interface{}{projectID},
+				NotebookID: projectID,
+			})
+			return err
+		},
+		needsNotebook: true,
+	},
+	{
+		Name:        "guidebooks",
+		Description: "guidebooks",
+		probe: func(c *Client, projectID string) error {
+			_, err := c.rpc.Do(rpc.Call{
+				ID:   rpc.RPCListRecentlyViewedGuidebooks,
+				Args: []interface{}{},
+			})
+			return err
+		},
+	},
+	{
+		Name:        "generation",
+		Description: "notebook guides, outlines and sections",
+	},
+}
+
+// LookupFeature returns the feature with the given name.
+func LookupFeature(name string) (Feature, bool) {
+	for _, f := range Features {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Feature{}, false
+}
+
+// FeatureStatus is the outcome of probing a feature.
+type FeatureStatus struct {
+	Available bool
+	// Known is false when the feature has no probe or the probe failed for
+	// an unrelated reason, such as a network error or a permission the
+	// account lacks only for the notebook probed.
+	Known bool
+	Err   error
+}
+
+// NotOffered reports whether err is the server saying it does not
+// implement a call for this account at all. Permission denied is not
+// included: it may apply only to the notebook, such as one shared with
+// the user as a viewer, so it says nothing about the account.
+func NotOffered(err error) bool {
+	var re *batchexecute.RPCError
+	return errors.As(err, &re) && re.Code == batchexecute.CodeUnimplemented
+}
+
+// ProbeFeatures checks which optional features are available to the
+// account. projectID is used by probes that need a notebook; if empty the
+// most recently viewed notebook is used and notebook-scoped probes are
+// skipped when there is none.
+func (c *Client) ProbeFeatures(projectID string) map[string]FeatureStatus {
+	if projectID == "" {
+		if nbs, err := c.ListRecentlyViewedProjects(); err == nil && len(nbs) > 0 {
+			projectID = nbs[0].GetProjectId()
+		}
+	}
+	out := make(map[string]FeatureStatus, len(Features))
+	for _, f := range Features {
+		if f.probe == nil || (f.needsNotebook && projectID == "") {
+			out[f.Name] = FeatureStatus{}
+			continue
+		}
+		err := f.probe(c, projectID)
+		switch {
+		case err == nil:
+			out[f.Name] = FeatureStatus{Available: true, Known: true}
+		case NotOffered(err):
+			out[f.Name] = FeatureStatus{Known: true, Err: err}
+		default:
+			out[f.Name] = FeatureStatus{Err: err}
+		}
+	}
+	return out
+}
+
+// UnavailableError reports a command that needs a feature the account
+// does not have.
+type UnavailableError struct {
+	Feature Feature
+}
+
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("%s (%s) not available for your account yet", e.Feature.Name, e.Feature.Description)
+}
+
+func (e *UnavailableError) Unwrap() error { return batchexecute.ErrUnavailable }
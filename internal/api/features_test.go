@@ -0,0 +1,27 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/tmc/nlm/internal/batchexecute"
+)
+
+func TestNotOffered(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&batchexecute.RPCError{Code: batchexecute.CodeUnimplemented}, true},
+		{fmt.Errorf("create audio overview: %w", &batchexecute.RPCError{Code: batchexecute.CodeUnimplemented}), true},
+		{&batchexecute.RPCError{Code: batchexecute.CodePermissionDenied}, false},
+		{&batchexecute.RPCError{Code: batchexecute.CodeUnavailable}, false},
+		{errors.New("connection reset"), false},
+	}
+	for _, tt := range tests {
+		if got := NotOffered(tt.err); got != tt.want {
+			t.Errorf("NotOffered(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"encoding/json"
+
+	"github.com/tmc/nlm/internal/rpc"
+)
+
+// A format is a layout the response of an RPC has had. NotebookLM rolls
+// payload changes out to accounts over days, so during a rollout the same
+// RPC answers in the new layout for some users and the old one for others.
+type format struct {
+	name string
+	// schema is the layout, in the notation of rpc.ArgSchemas.
+	schema string
+	// upgrade rewrites a payload in this layout into the current one. It
+	// is nil for the current layout.
+	upgrade func(v interface{}) interface{}
+}
+
+// projectSchema is the layout of a notebook in project responses.
+const projectSchema = `[title: string, any, projectID: string, ...]`
+
+// formats holds the known layouts of the responses of the most used RPCs,
+// by RPC ID, current first. Keep the previous layout when a new one is
+// added, and drop layouts once no account is served them.
+var formats = map[string][]format{
+	rpc.RPCListRecentlyViewedProjects: {
+		{name: "current", schema: `[[` + projectSchema + `...], ...]`},
+		// The notebooks were the response itself rather than its first
+		// field.
+		{name: "unwrapped", schema: `[` + projectSchema + `...]`, upgrade: wrap},
+	},
+	rpc.RPCCreateProject: {
+		{name: "current", schema: projectSchema},
+		// The notebook was wrapped in a one element response.
+		{name: "wrapped", schema: `[` + projectSchema + `, ...]`, upgrade: first},
+	},
+}
+
+func wrap(v interface{}) interface{} { return []interface{}{v} }
+
+func first(v interface{}) interface{} { return v.([]interface{})[0] }
+
+// adapt returns resp in the current layout of the response of rpcID, and
+// the name of the layout it was found in. A response that matches no known
+// layout is returned unchanged with an empty name, for decode to report as
+// drift.
+func adapt(rpcID string, resp json.RawMessage) (json.RawMessage, string) {
+	fs, ok := formats[rpcID]
+	if !ok {
+		return resp, ""
+	}
+	var v interface{}
+	if json.Unmarshal(resp, &v) != nil {
+		return resp, ""
+	}
+	for _, f := range fs {
+		p, err := rpc.ParseSchema(f.schema)
+		if err != nil || !p.Matches(v) {
+			continue
+		}
+		if f.upgrade == nil {
+			return resp, f.name
+		}
+		data, err := json.Marshal(f.upgrade(v))
+		if err != nil {
+			return resp, ""
+		}
+		return data, f.name
+	}
+	return resp, ""
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	pb "github.com/tmc/nlm/gen/notebooklm/v1alpha1"
+	"github.com/tmc/nlm/internal/rpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestFormatsParse(t *testing.T) {
+	for id, fs := range formats {
+		for _, f := range fs {
+			if _, err := rpc.ParseSchema(f.schema); err != nil {
+				t.Errorf("%s %s: %v", id, f.name, err)
+			}
+		}
+	}
+}
+
+func TestAdapt(t *testing.T) {
+	list := &pb.ListRecentlyViewedProjectsResponse{Projects: []*pb.Project{
+		{Title: "one", ProjectId: nb1, Emoji: "📘"},
+		{Title: "two", ProjectId: nb2, Emoji: "📗", Metadata: &pb.ProjectMetadata{UserRole: 1}},
+	}}
+	project := &pb.Project{Title: "one", ProjectId: nb1, Emoji: "📘"}
+	tests := []struct {
+		name    string
+		rpcID   string
+		payload string
+		format  string
+		want    proto.Message
+	}{
+		{
+			name:    "list current",
+			rpcID:   rpc.RPCListRecentlyViewedProjects,
+			payload: `[[["one",null,"` + nb1 + `","📘"],["two",[],"` + nb2 + `","📗",null,[1]]]]`,
+			format:  "current",
+			want:    list,
+		},
+		{
+			name:    "list unwrapped",
+			rpcID:   rpc.RPCListRecentlyViewedProjects,
+			payload: `[["one",null,"` + nb1 + `","📘"],["two",[],"` + nb2 + `","📗",null,[1]]]`,
+			format:  "unwrapped",
+			want:    list,
+		},
+		{
+			name:    "list empty",
+			rpcID:   rpc.RPCListRecentlyViewedProjects,
+			payload: `[[]]`,
+			format:  "current",
+			want:    &pb.ListRecentlyViewedProjectsResponse{},
+		},
+		{
+			name:    "create current",
+			rpcID:   rpc.RPCCreateProject,
+			payload: `["one",null,"` + nb1 + `","📘"]`,
+			format:  "current",
+			want:    project,
+		},
+		{
+			name:    "create wrapped",
+			rpcID:   rpc.RPCCreateProject,
+			payload: `[["one",null,"` + nb1 + `","📘"]]`,
+			format:  "wrapped",
+			want:    project,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, format := adapt(tt.rpcID, []byte(tt.payload))
+			if format != tt.format {
+				t.Errorf("format = %q, want %q", format, tt.format)
+			}
+			c := &Client{rpc: rpc.New("", "")}
+			var drift *SchemaDrift
+			c.OnSchemaDrift(func(d SchemaDrift) { drift = &d })
+			got := tt.want.ProtoReflect().New().Interface()
+			if err := c.decode(tt.name, tt.rpcID, data, got, nil); err != nil {
+				t.Fatal(err)
+			}
+			if drift != nil {
+				t.Errorf("drift reported: %+v", drift)
+			}
+			if diff := cmp.Diff(tt.want, got, protocmp.Transform()); diff != "" {
+				t.Errorf("decode mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAdaptUnknownLayout(t *testing.T) {
+	for _, payload := range []string{`[{"projects":true}]`, `not json`} {
+		data, format := adapt(rpc.RPCListRecentlyViewedProjects, []byte(payload))
+		if format != "" || string(data) != payload {
+			t.Errorf("adapt(%s) = %s, %q; want it unchanged", payload, data, format)
+		}
+	}
+	if _, format := adapt(rpc.RPCGetProject, []byte(`["one",null,"`+nb1+`"]`)); format != "" {
+		t.Errorf("format for an RPC without formats = %q, want none", format)
+	}
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Role is the caller's access to a notebook, as reported in the
+// notebook's metadata.
+type Role int32
+
+// Notebook roles. RoleUnknown is reported for notebooks whose metadata
+// carries no role; such notebooks are treated as writable.
+const (
+	RoleUnknown Role = 0
+	RoleOwner   Role = 1
+	RoleEditor  Role = 2
+	RoleViewer  Role = 3
+)
+
+func (r Role) String() string {
+	switch r {
+	case RoleUnknown:
+		return "unknown"
+	case RoleOwner:
+		return "owner"
+	case RoleEditor:
+		return "editor"
+	case RoleViewer:
+		return "viewer"
+	}
+	return fmt.Sprintf("role %d", int32(r))
+}
+
+// MarshalText encodes the role by name.
+func (r Role) MarshalText() ([]byte, error) { return []byte(r.String()), nil }
+
+// CanEdit reports whether the role allows changing the notebook.
+func (r Role) CanEdit() bool { return r != RoleViewer }
+
+// RoleOf returns the caller's role on nb.
+func RoleOf(nb *Notebook) Role {
+	return Role(nb.GetMetadata().GetUserRole())
+}
+
+// ErrReadOnly is matched by errors reporting a change to a notebook the
+// caller may only view.
+var ErrReadOnly = errors.New("notebook is read-only")
+
+// ReadOnlyError reports a command that would change a notebook shared
+// with the caller as a viewer.
+type ReadOnlyError struct {
+	NotebookID string
+	Title      string
+	Role       Role
+}
+
+func (e *ReadOnlyError) Error() string {
+	name := e.NotebookID
+	if e.Title != "" {
+		name = fmt.Sprintf("%q (%s)", e.Title, e.NotebookID)
+	}
+	return fmt.Sprintf("notebook %s is shared with you as a %s; ask its owner for editor access", name, e.Role)
+}
+
+func (e *ReadOnlyError) Unwrap() error { return ErrReadOnly }
+
+// CheckWritable returns a *ReadOnlyError if the caller's role on nb does
+// not allow changes.
+func CheckWritable(nb *Notebook) error {
+	if r := RoleOf(nb); !r.CanEdit() {
+		return &ReadOnlyError{NotebookID: nb.GetProjectId(), Title: nb.GetTitle(), Role: r}
+	}
+	return nil
+}
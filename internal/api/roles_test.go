@@ -0,0 +1,30 @@
+package api
+
+import (
+	"errors"
+	"testing"
+
+	pb "github.com/tmc/nlm/gen/notebooklm/v1alpha1"
+)
+
+func TestCheckWritable(t *testing.T) {
+	tests := []struct {
+		role    int32
+		wantErr bool
+	}{
+		{role: 0},
+		{role: 1},
+		{role: 2},
+		{role: 3, wantErr: true},
+	}
+	for _, tt := range tests {
+		nb := &pb.Project{ProjectId: nb1, Title: "Team", Metadata: &pb.ProjectMetadata{UserRole: tt.role}}
+		err := CheckWritable(nb)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("role %v: CheckWritable = %v, want error %v", Role(tt.role), err, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrReadOnly) {
+			t.Errorf("role %v: error %v does not match ErrReadOnly", Role(tt.role), err)
+		}
+	}
+}
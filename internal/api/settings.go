@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/tmc/nlm/internal/rpc"
+)
+
+// NotebookSettings returns the value of each setting in
+// rpc.NotebookSettings, by name. Enumerated values are given by name.
+func (c *Client) NotebookSettings(projectID string) (map[string]string, error) {
+	responses := make(map[string]interface{})
+	values := make(map[string]string, len(rpc.NotebookSettings))
+	for _, s := range rpc.NotebookSettings {
+		v, ok := responses[s.Get]
+		if !ok {
+			var err error
+			if v, err = c.settingsResponse(s.Get, projectID); err != nil {
+				return nil, fmt.Errorf("get settings: %w", err)
+			}
+			responses[s.Get] = v
+		}
+		values[s.Name] = formatSetting(s, valueAt(v, s.Path))
+	}
+	return values, nil
+}
+
+// settingsResponse makes the read call rpcID for a notebook and returns
+// the decoded response.
+func (c *Client) settingsResponse(rpcID, projectID string) (interface{}, error) {
+	resp, err := c.rpc.Do(rpc.Call{
+		ID:         rpcID,
+		Args:       []interface{}{projectID},
+		NotebookID: projectID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := json.Unmarshal(resp, &v); err != nil {
+		return nil, fmt.Errorf("parse response: %w", err)
+	}
+	return v, nil
+}
+
+// valueAt returns the element of v at path, or nil if it is missing.
+func valueAt(v interface{}, path []int) interface{} {
+	for _, i := range path {
+		arr, ok := v.([]interface{})
+		if !ok || i >= len(arr) {
+			return nil
+		}
+		v = arr[i]
+	}
+	return v
+}
+
+// formatSetting returns the value v of s as nlm shows it.
+func formatSetting(s rpc.NotebookSetting, v interface{}) string {
+	switch v := v.(type) {
+	case float64:
+		if name, ok := s.ValueName(int(v)); ok {
+			return name
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		if v != "" {
+			return v
+		}
+	}
+	return s.Default
+}
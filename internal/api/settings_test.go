@@ -0,0 +1,32 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tmc/nlm/internal/rpc"
+)
+
+func TestFormatSettings(t *testing.T) {
+	var project interface{}
+	payload := `["Notebook",[],"nb1","📘",null,[1,false],[[3,null],[4],["de"]]]`
+	if err := json.Unmarshal([]byte(payload), &project); err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, s := range rpc.NotebookSettings {
+		if s.Get == rpc.RPCGetProject {
+			got[s.Name] = formatSetting(s, valueAt(project, s.Path))
+		}
+	}
+	want := map[string]string{
+		"chat.goal":   "learning-guide",
+		"chat.prompt": "",
+		"chat.length": "longer",
+		"language":    "de",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("settings mismatch (-want +got):\n%s", diff)
+	}
+}
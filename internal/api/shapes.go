@@ -0,0 +1,162 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Shapes holds the golden shape of the response of each RPC, by RPC ID. A
+// shape is the response with every value replaced by its type: "string",
+// "number", "bool", "null" or "object", with arrays kept as arrays of
+// shapes. Types seen to vary at a position are recorded as "any".
+type Shapes map[string]interface{}
+
+// A ShapeChange is a position whose type differs from the golden shape.
+type ShapeChange struct {
+	// Path is the position by array index, such as "[0][2][1]".
+	Path string `json:"path"`
+	Old  string `json:"old"`
+	New  string `json:"new"`
+}
+
+// maxShapeChanges bounds the changes reported for one response.
+const maxShapeChanges = 20
+
+// WatchShapes compares the shape of every response with golden. With
+// record set, shapes are merged into golden instead, so it can be saved
+// as the new reference; otherwise changed shapes are reported as schema
+// drift, even when the response still decodes. RPCs missing from golden
+// are not checked.
+func (c *Client) WatchShapes(golden Shapes, record bool) {
+	var mu sync.Mutex
+	c.rpc.OnResponse(func(id string, data json.RawMessage) {
+		var v interface{}
+		if json.Unmarshal(data, &v) != nil {
+			return // decode reports unparseable responses
+		}
+		shape := shapeOf(v)
+		mu.Lock()
+		defer mu.Unlock()
+		if record {
+			if old, ok := golden[id]; ok {
+				shape = mergeShapes(old, shape)
+			}
+			golden[id] = shape
+			return
+		}
+		old, ok := golden[id]
+		if !ok {
+			return
+		}
+		changes := compareShapes("", old, shape, nil)
+		if len(changes) == 0 || c.onDrift == nil {
+			return
+		}
+		c.onDrift(SchemaDrift{
+			Time:      time.Now().UTC(),
+			RPC:       id,
+			Error:     fmt.Sprintf("response shape changed at %d positions", len(changes)),
+			Recovered: true,
+			Payload:   c.savePayload(id, data),
+			Changes:   changes,
+		})
+	})
+}
+
+// shapeOf returns the shape of a decoded JSON value.
+func shapeOf(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = shapeOf(e)
+		}
+		return s
+	case map[string]interface{}:
+		return "object"
+	}
+	return typeName(v)
+}
+
+// typeName names the type of a JSON value or shape.
+func typeName(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		// A shape leaf is already a type name.
+		switch v {
+		case "number", "bool", "null", "object", "any":
+			return v
+		}
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "bool"
+	case []interface{}:
+		return "array"
+	}
+	return "object"
+}
+
+// mergeShapes combines two observed shapes of the same response: null
+// gives way to any other type, arrays merge element by element, and
+// conflicting types become "any".
+func mergeShapes(a, b interface{}) interface{} {
+	ta, tb := typeName(a), typeName(b)
+	switch {
+	case ta == "null":
+		return b
+	case tb == "null":
+		return a
+	case ta == "array" && tb == "array":
+		aa, ba := a.([]interface{}), b.([]interface{})
+		if len(ba) > len(aa) {
+			aa, ba = ba, aa
+		}
+		m := make([]interface{}, len(aa))
+		for i := range aa {
+			m[i] = aa[i]
+			if i < len(ba) {
+				m[i] = mergeShapes(aa[i], ba[i])
+			}
+		}
+		return m
+	case ta == tb:
+		return a
+	}
+	return "any"
+}
+
+// compareShapes appends to changes the positions where cur has a
+// different type from old. Nulls and missing trailing elements are
+// expected of optional fields and not reported; array elements beyond the
+// golden ones, as in longer lists, are compared with the last golden one.
+func compareShapes(path string, old, cur interface{}, changes []ShapeChange) []ShapeChange {
+	to, tc := typeName(old), typeName(cur)
+	switch {
+	case len(changes) >= maxShapeChanges:
+		return changes
+	case to == "any" || to == "null" || tc == "null":
+		return changes
+	case to != tc:
+		return append(changes, ShapeChange{Path: path, Old: to, New: tc})
+	case to != "array":
+		return changes
+	}
+	oa, ca := old.([]interface{}), cur.([]interface{})
+	if len(oa) == 0 {
+		return changes
+	}
+	for i, e := range ca {
+		o := oa[len(oa)-1]
+		if i < len(oa) {
+			o = oa[i]
+		}
+		changes = compareShapes(fmt.Sprintf("%s[%d]", path, i), o, e, changes)
+	}
+	return changes
+}
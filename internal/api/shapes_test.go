@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func decodeJSON(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestShapeOf(t *testing.T) {
+	got := shapeOf(decodeJSON(t, `[["Notebook", null, 3, true, {"a": 1}, ["x"]]]`))
+	want := []interface{}{[]interface{}{"string", "null", "number", "bool", "object", []interface{}{"string"}}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("shape mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMergeShapes(t *testing.T) {
+	a := shapeOf(decodeJSON(t, `[null, "x", 1, [1]]`))
+	b := shapeOf(decodeJSON(t, `["y", 2, 1, [1, "z"], true]`))
+	want := []interface{}{"string", "any", "number", []interface{}{"number", "string"}, "bool"}
+	if diff := cmp.Diff(want, mergeShapes(a, b)); diff != "" {
+		t.Errorf("merge mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCompareShapes(t *testing.T) {
+	golden := shapeOf(decodeJSON(t, `[[["Notebook A", [], "id-a", 1]], [1]]`))
+	tests := []struct {
+		name string
+		resp string
+		want []ShapeChange
+	}{
+		{
+			name: "same shape, longer list",
+			resp: `[[["Notebook A", [], "id-a", 1], ["Notebook B", [["s"]], "id-b", 2]], [1]]`,
+		},
+		{
+			name: "nulls and missing trailing fields",
+			resp: `[[[null, [], "id-a"]]]`,
+		},
+		{
+			name: "fields shifted",
+			resp: `[[[[], "Notebook A", "id-a", 1]], [1]]`,
+			want: []ShapeChange{
+				{Path: "[0][0][0]", Old: "string", New: "array"},
+				{Path: "[0][0][1]", Old: "array", New: "string"},
+			},
+		},
+		{
+			name: "count became a string in the second record",
+			resp: `[[["Notebook A", [], "id-a", 1], ["Notebook B", [], "id-b", "2"]], [1]]`,
+			want: []ShapeChange{{Path: "[0][1][3]", Old: "number", New: "string"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := compareShapes("", golden, shapeOf(decodeJSON(t, tt.resp)), nil)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("changes mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCompareShapesIgnoresAny(t *testing.T) {
+	golden := Shapes{"x": mergeShapes(shapeOf(decodeJSON(t, `["a", 1]`)), shapeOf(decodeJSON(t, `[1, 1]`)))}
+	if got := compareShapes("", golden["x"], shapeOf(decodeJSON(t, `[true, 1]`)), nil); len(got) != 0 {
+		t.Errorf("changes at a position seen with several types: %v", got)
+	}
+}
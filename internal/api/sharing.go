@@ -0,0 +1,166 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	pb "github.com/tmc/nlm/gen/notebooklm/v1alpha1"
+	"github.com/tmc/nlm/internal/rpc"
+)
+
+// ErrUnverified is returned by calls whose request layout is a guess that
+// has not been checked against a request recorded from the web client,
+// unless the client allows them with AllowUnverified. A wrong guess could
+// grant or revoke the wrong access.
+var ErrUnverified = errors.New("request layout is unverified")
+
+// checkVerified returns ErrUnverified for the unverified call name unless
+// c allows such calls.
+func (c *Client) checkVerified(name string) error {
+	if c.unverified {
+		return nil
+	}
+	return fmt.Errorf("%s: %w", name, ErrUnverified)
+}
+
+// Collaborator is a person a notebook is shared with. Its role converts
+// to and from Role.
+type Collaborator = pb.Collaborator
+
+// Sharing is who can open a notebook.
+type Sharing = pb.Sharing
+
+// GetSharing returns a notebook's sharing settings.
+func (c *Client) GetSharing(projectID string) (*Sharing, error) {
+	resp, err := c.rpc.Do(rpc.Call{
+		ID:         rpc.RPCGetProjectDetails,
+		Args:       []interface{}{projectID},
+		NotebookID: projectID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get sharing: %w", err)
+	}
+	s, err := parseSharing(resp)
+	if err != nil {
+		return nil, fmt.Errorf("get sharing: %w", err)
+	}
+	return s, nil
+}
+
+// ShareProject shares a notebook with collaborators in one call. If notify
+// is set, they are sent the invitation email. The role numbers and the
+// layout of the call are unverified; see ErrUnverified.
+func (c *Client) ShareProject(projectID string, collaborators []*Collaborator, notify bool) error {
+	if err := c.checkVerified("share notebook"); err != nil {
+		return err
+	}
+	users := make([]interface{}, len(collaborators))
+	for i, u := range collaborators {
+		users[i] = []interface{}{u.GetEmail(), nil, int32(u.GetRole())}
+	}
+	if err := c.share(projectID, users, nil, notify); err != nil {
+		return fmt.Errorf("share notebook: %w", err)
+	}
+	return nil
+}
+
+// roleRemove is the role thought to revoke access. It has not been seen
+// in a recorded request.
+const roleRemove = 4
+
+// RemoveCollaborators revokes the access of the given people. It is
+// unverified; see ErrUnverified.
+func (c *Client) RemoveCollaborators(projectID string, emails []string) error {
+	if err := c.checkVerified("remove collaborators"); err != nil {
+		return err
+	}
+	users := make([]interface{}, len(emails))
+	for i, email := range emails {
+		users[i] = []interface{}{email, nil, roleRemove}
+	}
+	if err := c.share(projectID, users, nil, false); err != nil {
+		return fmt.Errorf("remove collaborators: %w", err)
+	}
+	return nil
+}
+
+// SetPublic turns "anyone with the link" access to a notebook on or off.
+// The [access] payload mirrors what GetProjectDetails returns but has not
+// been seen in a recorded request, so the call is unverified; see
+// ErrUnverified.
+func (c *Client) SetPublic(projectID string, public bool) error {
+	if err := c.checkVerified("set link access"); err != nil {
+		return err
+	}
+	access := 0
+	if public {
+		access = 1
+	}
+	if err := c.share(projectID, nil, []int{access}, false); err != nil {
+		return fmt.Errorf("set link access: %w", err)
+	}
+	return nil
+}
+
+// share sends a ShareProject call changing users, link access, or both;
+// nil leaves that part unchanged.
+func (c *Client) share(projectID string, users []interface{}, access []int, notify bool) error {
+	var u, a interface{}
+	if users != nil {
+		u = users
+	}
+	if access != nil {
+		a = access
+	}
+	_, err := c.rpc.Do(rpc.Call{
+		ID: rpc.RPCShareProject,
+		Args: []interface{}{
+			[]interface{}{
+				[]interface{}{projectID, u, a, notify},
+			},
+		},
+		NotebookID: projectID,
+	})
+	return err
+}
+
+// parseSharing decodes a GetProjectDetails response. The web client
+// sends [[user, ...], [access], ...] where a user is
+// [email, role, ...] and access is 1 for "anyone with the link"; users
+// are found anywhere in the first element, to tolerate extra nesting.
+func parseSharing(resp json.RawMessage) (*Sharing, error) {
+	var data []interface{}
+	if err := json.Unmarshal(resp, &data); err != nil {
+		return nil, fmt.Errorf("parse response: %w", err)
+	}
+	s := &Sharing{}
+	if len(data) > 0 {
+		var walk func(v interface{})
+		walk = func(v interface{}) {
+			arr, ok := v.([]interface{})
+			if !ok {
+				return
+			}
+			if len(arr) > 1 {
+				email, _ := arr[0].(string)
+				role, isNum := arr[1].(float64)
+				if isNum && strings.Contains(email, "@") {
+					s.Collaborators = append(s.Collaborators, &Collaborator{Email: email, Role: pb.Role(role)})
+					return
+				}
+			}
+			for _, e := range arr {
+				walk(e)
+			}
+		}
+		walk(data[0])
+	}
+	if len(data) > 1 {
+		if access, ok := data[1].([]interface{}); ok && len(access) > 0 {
+			s.Public = access[0] == float64(1)
+		}
+	}
+	return s, nil
+}
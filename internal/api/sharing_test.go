@@ -0,0 +1,61 @@
+package api
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	pb "github.com/tmc/nlm/gen/notebooklm/v1alpha1"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestParseSharing(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    *Sharing
+	}{
+		{
+			name:    "private",
+			payload: `[[["owner@example.com",1,[],["Owner",null]],["ada@example.com",2,[],["Ada",null]]],[0],1000]`,
+			want: &Sharing{Collaborators: []*Collaborator{
+				{Email: "owner@example.com", Role: pb.Role_ROLE_OWNER},
+				{Email: "ada@example.com", Role: pb.Role_ROLE_EDITOR},
+			}},
+		},
+		{
+			name:    "public link with nested users",
+			payload: `[[[["bob@gmail.com",3]]],[1]]`,
+			want:    &Sharing{Public: true, Collaborators: []*Collaborator{{Email: "bob@gmail.com", Role: pb.Role_ROLE_VIEWER}}},
+		},
+		{
+			name:    "empty",
+			payload: `[]`,
+			want:    &Sharing{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSharing([]byte(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got, protocmp.Transform()); diff != "" {
+				t.Errorf("parseSharing mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUnverifiedSharing(t *testing.T) {
+	c := &Client{}
+	if err := c.ShareProject("nb1", []*Collaborator{{Email: "ada@example.com", Role: pb.Role_ROLE_EDITOR}}, false); !errors.Is(err, ErrUnverified) {
+		t.Errorf("ShareProject: err = %v, want ErrUnverified", err)
+	}
+	if err := c.RemoveCollaborators("nb1", []string{"ada@example.com"}); !errors.Is(err, ErrUnverified) {
+		t.Errorf("RemoveCollaborators: err = %v, want ErrUnverified", err)
+	}
+	if err := c.SetPublic("nb1", false); !errors.Is(err, ErrUnverified) {
+		t.Errorf("SetPublic: err = %v, want ErrUnverified", err)
+	}
+}
@@ -0,0 +1,250 @@
+// Package arxiv looks up papers with the arXiv API and downloads their
+// PDFs.
+package arxiv
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the arXiv API endpoint.
+const DefaultBaseURL = "https://export.arxiv.org/api/query"
+
+// A Paper is an arXiv preprint.
+type Paper struct {
+	ID         string // without version, such as 2401.12345 or hep-th/9901001
+	Version    int
+	Title      string
+	Authors    []string
+	Abstract   string
+	Categories []string
+	Published  time.Time
+	Updated    time.Time
+	AbsURL     string
+	PDFURL     string
+}
+
+var (
+	newIDRE = regexp.MustCompile(`^(\d{4}\.\d{4,5})(?:v(\d+))?$`)
+	oldIDRE = regexp.MustCompile(`^([a-z][a-z.-]*/\d{7})(?:v(\d+))?$`)
+)
+
+// ParseID returns the identifier and version (0 if unspecified) of a
+// paper given as an ID such as 2401.12345v2, arXiv:2401.12345, or an
+// arxiv.org abstract or PDF URL.
+func ParseID(s string) (id string, version int, err error) {
+	in := s
+	s = strings.TrimSpace(s)
+	if u, err := url.Parse(s); err == nil && strings.HasSuffix(u.Host, "arxiv.org") {
+		p := strings.TrimPrefix(u.Path, "/")
+		for _, prefix := range []string{"abs/", "pdf/", "html/"} {
+			if rest, ok := strings.CutPrefix(p, prefix); ok {
+				s = strings.TrimSuffix(strings.TrimSuffix(rest, "/"), ".pdf")
+				break
+			}
+		}
+	}
+	if len(s) > 6 && strings.EqualFold(s[:6], "arxiv:") {
+		s = s[6:]
+	}
+	m := newIDRE.FindStringSubmatch(s)
+	if m == nil {
+		m = oldIDRE.FindStringSubmatch(s)
+	}
+	if m == nil {
+		return "", 0, fmt.Errorf("arxiv: invalid identifier %q", in)
+	}
+	if m[2] != "" {
+		version, _ = strconv.Atoi(m[2])
+	}
+	return m[1], version, nil
+}
+
+// Query returns an arXiv search query matching all of terms, searched in
+// every field, and author. Terms already in arXiv query syntax, such as
+// "cat:cs.CL AND ti:transformer", are used as they are.
+func Query(terms, author string) string {
+	var parts []string
+	if terms = strings.TrimSpace(terms); terms != "" {
+		if strings.Contains(terms, ":") {
+			parts = append(parts, "("+terms+")")
+		} else {
+			for _, w := range strings.Fields(terms) {
+				parts = append(parts, "all:"+w)
+			}
+		}
+	}
+	if author = strings.TrimSpace(author); author != "" {
+		parts = append(parts, `au:"`+strings.ReplaceAll(author, `"`, "")+`"`)
+	}
+	return strings.Join(parts, " AND ")
+}
+
+// A Client queries the arXiv API. Its zero value uses DefaultBaseURL.
+type Client struct {
+	HTTP      *http.Client // defaults to a client with a 60s timeout
+	BaseURL   string
+	UserAgent string
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTP != nil {
+		return c.HTTP
+	}
+	return &http.Client{Timeout: 60 * time.Second}
+}
+
+// Get returns the papers with the given IDs, in the same order. A
+// version in an ID selects that version.
+func (c *Client) Get(ctx context.Context, ids []string) ([]Paper, error) {
+	v := url.Values{"id_list": {strings.Join(ids, ",")}, "max_results": {strconv.Itoa(len(ids))}}
+	papers, err := c.query(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+	if len(papers) != len(ids) {
+		return nil, fmt.Errorf("arxiv: asked for %d papers, got %d", len(ids), len(papers))
+	}
+	return papers, nil
+}
+
+// Search returns up to max papers matching an arXiv search query, most
+// relevant first.
+func (c *Client) Search(ctx context.Context, query string, max int) ([]Paper, error) {
+	if query == "" {
+		return nil, errors.New("arxiv: empty search")
+	}
+	v := url.Values{
+		"search_query": {query},
+		"max_results":  {strconv.Itoa(max)},
+		"sortBy":       {"relevance"},
+	}
+	return c.query(ctx, v)
+}
+
+func (c *Client) query(ctx context.Context, v url.Values) ([]Paper, error) {
+	base := c.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	resp, err := c.get(ctx, base+"?"+v.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return parseFeed(resp.Body)
+}
+
+// PDF downloads the PDF of a paper.
+func (c *Client) PDF(ctx context.Context, p Paper) ([]byte, error) {
+	resp, err := c.get(ctx, p.PDFURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 200<<20))
+	if err != nil {
+		return nil, fmt.Errorf("arxiv: download %s: %w", p.PDFURL, err)
+	}
+	if !strings.HasPrefix(string(data), "%PDF") {
+		return nil, fmt.Errorf("arxiv: %s is not a PDF", p.PDFURL)
+	}
+	return data, nil
+}
+
+func (c *Client) get(ctx context.Context, u string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("arxiv: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("arxiv: %s: %s", u, resp.Status)
+	}
+	return resp, nil
+}
+
+// feed is an arXiv API response, an Atom feed.
+type feed struct {
+	Entries []struct {
+		ID        string `xml:"id"`
+		Title     string `xml:"title"`
+		Summary   string `xml:"summary"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+		Authors   []struct {
+			Name string `xml:"name"`
+		} `xml:"author"`
+		Links []struct {
+			Href  string `xml:"href,attr"`
+			Rel   string `xml:"rel,attr"`
+			Title string `xml:"title,attr"`
+			Type  string `xml:"type,attr"`
+		} `xml:"link"`
+		Categories []struct {
+			Term string `xml:"term,attr"`
+		} `xml:"category"`
+	} `xml:"http://www.w3.org/2005/Atom entry"`
+}
+
+func parseFeed(r io.Reader) ([]Paper, error) {
+	var f feed
+	if err := xml.NewDecoder(r).Decode(&f); err != nil {
+		return nil, fmt.Errorf("arxiv: parse response: %w", err)
+	}
+	papers := make([]Paper, 0, len(f.Entries))
+	for _, e := range f.Entries {
+		if strings.Contains(e.ID, "/api/errors") {
+			return nil, fmt.Errorf("arxiv: %s", oneLine(e.Summary))
+		}
+		p := Paper{
+			Title:    oneLine(e.Title),
+			Abstract: oneLine(e.Summary),
+			AbsURL:   e.ID,
+		}
+		id, version, err := ParseID(e.ID)
+		if err != nil {
+			return nil, err
+		}
+		p.ID, p.Version = id, version
+		p.Published, _ = time.Parse(time.RFC3339, strings.TrimSpace(e.Published))
+		p.Updated, _ = time.Parse(time.RFC3339, strings.TrimSpace(e.Updated))
+		for _, a := range e.Authors {
+			p.Authors = append(p.Authors, oneLine(a.Name))
+		}
+		for _, c := range e.Categories {
+			p.Categories = append(p.Categories, c.Term)
+		}
+		for _, l := range e.Links {
+			if l.Title == "pdf" || l.Type == "application/pdf" {
+				p.PDFURL = l.Href
+			}
+		}
+		if p.PDFURL == "" {
+			p.PDFURL = "https://arxiv.org/pdf/" + p.ID
+		}
+		papers = append(papers, p)
+	}
+	return papers, nil
+}
+
+// oneLine collapses the line breaks arXiv wraps titles and abstracts with.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,117 @@
+package arxiv
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseID(t *testing.T) {
+	tests := []struct {
+		in      string
+		id      string
+		version int
+		wantErr bool
+	}{
+		{in: "2401.12345", id: "2401.12345"},
+		{in: " arXiv:2401.12345v3 ", id: "2401.12345", version: 3},
+		{in: "https://arxiv.org/abs/2401.12345v2", id: "2401.12345", version: 2},
+		{in: "https://arxiv.org/pdf/2401.12345.pdf", id: "2401.12345"},
+		{in: "http://arxiv.org/abs/hep-th/9901001v1", id: "hep-th/9901001", version: 1},
+		{in: "0704.0001", id: "0704.0001"},
+		{in: "1234", wantErr: true},
+		{in: "https://example.com/abs/2401.12345", wantErr: true},
+	}
+	for _, tt := range tests {
+		id, version, err := ParseID(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseID(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if id != tt.id || version != tt.version {
+			t.Errorf("ParseID(%q) = %q, %d; want %q, %d", tt.in, id, version, tt.id, tt.version)
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	tests := []struct{ terms, author, want string }{
+		{"sparse attention", "", "all:sparse AND all:attention"},
+		{"", "Yann LeCun", `au:"Yann LeCun"`},
+		{"cat:cs.CL AND ti:llm", "Smith", `(cat:cs.CL AND ti:llm) AND au:"Smith"`},
+	}
+	for _, tt := range tests {
+		if got := Query(tt.terms, tt.author); got != tt.want {
+			t.Errorf("Query(%q, %q) = %q, want %q", tt.terms, tt.author, got, tt.want)
+		}
+	}
+}
+
+const testFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:arxiv="http://arxiv.org/schemas/atom">
+  <entry>
+    <id>http://arxiv.org/abs/2401.12345v2</id>
+    <updated>2024-02-01T10:00:00Z</updated>
+    <published>2024-01-22T18:00:00Z</published>
+    <title>Attention Is
+      Still All You Need</title>
+    <summary>  We revisit
+  attention.
+</summary>
+    <author><name>Ada Lovelace</name></author>
+    <author><name>Alan Turing</name></author>
+    <link href="http://arxiv.org/abs/2401.12345v2" rel="alternate" type="text/html"/>
+    <link title="pdf" href="http://arxiv.org/pdf/2401.12345v2" rel="related" type="application/pdf"/>
+    <arxiv:primary_category term="cs.CL"/>
+    <category term="cs.CL"/>
+    <category term="cs.LG"/>
+  </entry>
+</feed>`
+
+func TestGet(t *testing.T) {
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		if r.URL.Query().Get("id_list") == "bad" {
+			w.Write([]byte(`<feed xmlns="http://www.w3.org/2005/Atom"><entry><id>http://arxiv.org/api/errors#incorrect_id_format_for_bad</id><title>Error</title><summary>incorrect id format for bad</summary></entry></feed>`))
+			return
+		}
+		w.Write([]byte(testFeed))
+	}))
+	defer srv.Close()
+
+	c := &Client{BaseURL: srv.URL}
+	papers, err := c.Get(context.Background(), []string{"2401.12345"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "id_list=2401.12345&max_results=1" {
+		t.Errorf("query = %q", query)
+	}
+	want := []Paper{{
+		ID:         "2401.12345",
+		Version:    2,
+		Title:      "Attention Is Still All You Need",
+		Authors:    []string{"Ada Lovelace", "Alan Turing"},
+		Abstract:   "We revisit attention.",
+		Categories: []string{"cs.CL", "cs.LG"},
+		Published:  time.Date(2024, 1, 22, 18, 0, 0, 0, time.UTC),
+		Updated:    time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC),
+		AbsURL:     "http://arxiv.org/abs/2401.12345v2",
+		PDFURL:     "http://arxiv.org/pdf/2401.12345v2",
+	}}
+	if diff := cmp.Diff(want, papers); diff != "" {
+		t.Errorf("Get mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := c.Get(context.Background(), []string{"bad"}); err == nil || err.Error() != "arxiv: incorrect id format for bad" {
+		t.Errorf("Get(bad) error = %v", err)
+	}
+	if _, err := c.Get(context.Background(), []string{"2401.12345", "2401.00001"}); err == nil {
+		t.Error("Get accepted a response missing a paper")
+	}
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/chromedp"
+	"github.com/tmc/nlm/internal/cleanup"
 )
 
 type BrowserAuth struct {
@@ -51,7 +52,7 @@ func (ba *BrowserAuth) GetAuth(opts ...Option) (token, cookies string, err error
 	// Create temp directory for new Chrome instance
 	if ba.debug {
 	}
-	tempDir, err := os.MkdirTemp("", "nlm-chrome-*")
+	tempDir, err := cleanup.MkdirTemp("", "nlm-chrome-*")
 	if err != nil {
 		return "", "", fmt.Errorf("create temp dir: %w", err)
 	}
@@ -235,7 +236,7 @@ func (ba *BrowserAuth) cleanup() {
 		ba.chromeCmd.Process.Kill()
 	}
 	if ba.tempDir != "" {
-		os.RemoveAll(ba.tempDir)
+		cleanup.Remove(ba.tempDir)
 	}
 }
 
@@ -335,3 +336,22 @@ func (ba *BrowserAuth) tryExtractAuth(ctx context.Context) (token, cookies strin
 
 	return token, cookies, nil
 }
+
+// Profiles returns the names of the Chrome profiles on this machine, such
+// as "Default" and "Profile 1", for use with WithProfileName.
+func Profiles() ([]string, error) {
+	entries, err := os.ReadDir(getProfilePath())
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(getProfilePath(), e.Name(), "Preferences")); err == nil {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,175 @@
+// Package batch runs operations read as JSON lines on a pool of workers
+// and writes their results as JSON lines, so other programs can drive nlm
+// through a pipe.
+//
+// Each input line is an operation:
+//
+//	{"id": "a1", "op": "add", "notebook": "nb123", "args": ["https://example.com"]}
+//
+// and produces one result line, in the order operations finish:
+//
+//	{"line": 1, "id": "a1", "op": "add", "notebook": "nb123", "ok": true, "output": {...}}
+//
+// The optional id is echoed back to match results with operations; line
+// is the operation's line number in the input.
+package batch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// An Op is an operation to run.
+type Op struct {
+	ID       string   `json:"id,omitempty"`
+	Op       string   `json:"op"`
+	Notebook string   `json:"notebook,omitempty"`
+	Args     []string `json:"args,omitempty"`
+}
+
+// A Result is the outcome of an operation.
+type Result struct {
+	Line     int    `json:"line"`
+	ID       string `json:"id,omitempty"`
+	Op       string `json:"op,omitempty"`
+	Notebook string `json:"notebook,omitempty"`
+	OK       bool   `json:"ok"`
+	Output   any    `json:"output,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// A Func runs an operation and returns its output, which is encoded as
+// JSON in the result.
+type Func func(ctx context.Context, op Op) (any, error)
+
+// Stats counts the operations of a run.
+type Stats struct {
+	OK     int
+	Failed int
+}
+
+// maxLine bounds the length of an input line, which may carry the text
+// of a source.
+const maxLine = 64 << 20
+
+// Run reads operations from r until EOF, runs them with up to workers at
+// a time using the function registered for each op, and writes a result
+// for each to w. Malformed lines and unknown ops produce failed results
+// rather than stopping the run. Run returns early only if ctx is
+// cancelled or r or w fails.
+func Run(ctx context.Context, r io.Reader, w io.Writer, workers int, ops map[string]Func) (Stats, error) {
+	if workers < 1 {
+		workers = 1
+	}
+	var (
+		mu    sync.Mutex
+		stats Stats
+		werr  error
+	)
+	enc := json.NewEncoder(w)
+	emit := func(res Result) {
+		mu.Lock()
+		defer mu.Unlock()
+		if res.OK {
+			stats.OK++
+		} else {
+			stats.Failed++
+		}
+		if werr == nil {
+			werr = enc.Encode(res)
+		}
+	}
+
+	type job struct {
+		line int
+		op   Op
+		fn   Func
+	}
+	jobs := make(chan job)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				res := Result{Line: j.line, ID: j.op.ID, Op: j.op.Op, Notebook: j.op.Notebook}
+				out, err := call(ctx, j.fn, j.op)
+				if err != nil {
+					res.Error = err.Error()
+				} else {
+					res.OK, res.Output = true, out
+				}
+				emit(res)
+			}
+		}()
+	}
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), maxLine)
+	line := 0
+	var err error
+	for sc.Scan() {
+		line++
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		var op Op
+		if err := json.Unmarshal([]byte(text), &op); err != nil {
+			emit(Result{Line: line, Error: fmt.Sprintf("invalid operation: %v", err)})
+			continue
+		}
+		fn, ok := ops[op.Op]
+		if !ok {
+			emit(Result{Line: line, ID: op.ID, Op: op.Op, Notebook: op.Notebook, Error: fmt.Sprintf("unknown op %q (have %s)", op.Op, strings.Join(Names(ops), ", "))})
+			continue
+		}
+		select {
+		case jobs <- job{line, op, fn}:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		if err != nil {
+			break
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if err == nil {
+		err = sc.Err()
+	}
+	if err == nil {
+		err = werr
+	}
+	return stats, err
+}
+
+// call runs fn, turning a panic into an error so one bad operation does
+// not end the run.
+func call(ctx context.Context, fn Func, op Op) (out any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	if ctx.Err() != nil {
+		return nil, context.Cause(ctx)
+	}
+	return fn(ctx, op)
+}
+
+// Names returns the sorted names of ops.
+func Names(ops map[string]Func) []string {
+	names := make([]string, 0, len(ops))
+	for name := range ops {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
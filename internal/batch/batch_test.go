@@ -0,0 +1,94 @@
+package batch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRun(t *testing.T) {
+	ops := map[string]Func{
+		"echo": func(_ context.Context, op Op) (any, error) {
+			return map[string]any{"args": op.Args}, nil
+		},
+		"fail": func(context.Context, Op) (any, error) {
+			return nil, errors.New("boom")
+		},
+		"panic": func(context.Context, Op) (any, error) {
+			panic("oops")
+		},
+	}
+	in := `{"id":"a","op":"echo","notebook":"nb1","args":["x","y"]}
+
+# comment
+{"op":"fail"}
+not json
+{"op":"nope"}
+{"op":"panic"}
+`
+	var out bytes.Buffer
+	stats, err := Run(context.Background(), strings.NewReader(in), &out, 3, ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats != (Stats{OK: 1, Failed: 4}) {
+		t.Errorf("stats = %+v", stats)
+	}
+	var got []Result
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var r Result
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("output line %q: %v", line, err)
+		}
+		got = append(got, r)
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i].Line < got[j].Line })
+	want := []Result{
+		{Line: 1, ID: "a", Op: "echo", Notebook: "nb1", OK: true, Output: map[string]any{"args": []any{"x", "y"}}},
+		{Line: 4, Op: "fail", Error: "boom"},
+		{Line: 5, Error: "invalid operation: invalid character 'o' in literal null (expecting 'u')"},
+		{Line: 6, Op: "nope", Error: `unknown op "nope" (have echo, fail, panic)`},
+		{Line: 7, Op: "panic", Error: "panic: oops"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("results mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunConcurrency(t *testing.T) {
+	var running, peak atomic.Int32
+	ops := map[string]Func{
+		"wait": func(context.Context, Op) (any, error) {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			return nil, nil
+		},
+	}
+	in := strings.Repeat(`{"op":"wait"}`+"\n", 8)
+	var out bytes.Buffer
+	stats, err := Run(context.Background(), strings.NewReader(in), &out, 2, ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.OK != 8 {
+		t.Errorf("stats = %+v", stats)
+	}
+	if p := peak.Load(); p != 2 {
+		t.Errorf("peak concurrency = %d, want 2", p)
+	}
+}
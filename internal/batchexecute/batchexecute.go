@@ -2,29 +2,45 @@ package batchexecute
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"html"
 	"io"
 	"math/rand"
 	"net/http"
 	"net/url"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/tmc/nlm/internal/redact"
 )
 
 // ErrUnauthorized represent an unauthorized request.
 var ErrUnauthorized = errors.New("unauthorized")
 
+// ErrReadOnlyMode is wrapped by errors for calls refused because they
+// would change data; see WithReadOnly.
+var ErrReadOnlyMode = errors.New("refused in read-only mode")
+
+// ErrUnavailable is wrapped by RPC errors for calls the server does not
+// support or does not allow for this account.
+var ErrUnavailable = errors.New("not available")
+
 // RPC represents a single RPC call
 type RPC struct {
 	ID        string            // RPC endpoint ID
 	Args      []interface{}     // Arguments for the call
 	Index     string            // "generic" or numeric index
 	URLParams map[string]string // Request-specific URL parameters
+	// Context, if set, bounds the request; Execute uses that of the first
+	// RPC.
+	Context context.Context
 }
 
 // Response represents a decoded RPC response
@@ -33,17 +49,219 @@ type Response struct {
 	ID    string          `json:"id"`
 	Data  json.RawMessage `json:"data"`
 	Error string          `json:"error"`
+	// Code is the status code the server attached to an empty payload, or
+	// zero. Codes follow google.rpc.Code.
+	Code int `json:"code,omitempty"`
+	// RetryAfter is the wait the status asked for before retrying, from
+	// its google.rpc.RetryInfo detail, or zero.
+	RetryAfter time.Duration `json:"retry_after,omitempty"`
+}
+
+// Status codes reported in RPC envelopes (google.rpc.Code).
+const (
+	CodeInvalidArgument    = 3
+	CodeNotFound           = 5
+	CodePermissionDenied   = 7
+	CodeResourceExhausted  = 8
+	CodeFailedPrecondition = 9
+	CodeUnimplemented      = 12
+	CodeUnavailable        = 14
+	CodeUnauthenticated    = 16
+)
+
+var codeNames = map[int]string{
+	CodeInvalidArgument:    "invalid argument",
+	CodeNotFound:           "not found",
+	CodePermissionDenied:   "permission denied",
+	CodeResourceExhausted:  "resource exhausted",
+	CodeFailedPrecondition: "failed precondition",
+	CodeUnimplemented:      "unimplemented",
+	CodeUnavailable:        "service unavailable",
+	CodeUnauthenticated:    "unauthenticated",
+}
+
+// RPCError is a call the server answered with a status code instead of a
+// payload.
+type RPCError struct {
+	ID         string
+	Code       int
+	RetryAfter time.Duration // the wait the server asked for, or zero
+}
+
+func (e *RPCError) Error() string {
+	name := codeNames[e.Code]
+	if name == "" {
+		name = "error"
+	}
+	s := fmt.Sprintf("rpc %s: %s (code %d)", e.ID, name, e.Code)
+	if e.RetryAfter > 0 {
+		s += fmt.Sprintf(" (retry after %s)", e.RetryAfter)
+	}
+	return s
+}
+
+func (e *RPCError) Unwrap() error {
+	switch e.Code {
+	case CodePermissionDenied, CodeUnimplemented:
+		return ErrUnavailable
+	case CodeUnauthenticated:
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+// Err returns an *RPCError if the response carries a status code.
+func (r *Response) Err() error {
+	if r.Code == 0 {
+		return nil
+	}
+	return &RPCError{ID: r.ID, Code: r.Code, RetryAfter: r.RetryAfter}
+}
+
+// envelopeCode returns the status code at position 5 of a wrb.fr envelope.
+func envelopeCode(rpcData []interface{}) int {
+	if len(rpcData) < 6 {
+		return 0
+	}
+	status, ok := rpcData[5].([]interface{})
+	if !ok || len(status) == 0 {
+		return 0
+	}
+	code, _ := status[0].(float64)
+	return int(code)
+}
+
+// envelopeRetryDelay returns the delay of a google.rpc.RetryInfo detail in
+// the status at position 5 of a wrb.fr envelope, or zero.
+func envelopeRetryDelay(rpcData []interface{}) time.Duration {
+	if len(rpcData) < 6 {
+		return 0
+	}
+	return retryInfo(rpcData[5])
+}
+
+// retryInfo finds a detail of the form ["type.googleapis.com/google.rpc.RetryInfo",
+// payload] in a status and returns the first [seconds, nanos] duration in
+// its payload.
+func retryInfo(v interface{}) time.Duration {
+	a, ok := v.([]interface{})
+	if !ok {
+		return 0
+	}
+	if len(a) > 1 {
+		if t, ok := a[0].(string); ok && strings.HasSuffix(t, "google.rpc.RetryInfo") {
+			return jspbDuration(a[1:])
+		}
+	}
+	for _, e := range a {
+		if d := retryInfo(e); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// jspbDuration returns the first google.protobuf.Duration, an array
+// [seconds, nanos] with nanos optional, nested in v.
+func jspbDuration(v interface{}) time.Duration {
+	a, ok := v.([]interface{})
+	if !ok || len(a) == 0 {
+		return 0
+	}
+	if secs, ok := a[0].(float64); ok {
+		d := time.Duration(secs * float64(time.Second))
+		if len(a) > 1 {
+			if nanos, ok := a[1].(float64); ok {
+				d += time.Duration(nanos)
+			}
+		}
+		return d
+	}
+	for _, e := range a {
+		if d := jspbDuration(e); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// RetryDelay returns the wait the server asked for before retrying the
+// request that failed with err: the Retry-After header of an HTTP error,
+// or the RetryInfo detail of an RPC status.
+func RetryDelay(err error) (time.Duration, bool) {
+	var be *BatchExecuteError
+	if errors.As(err, &be) && be.RetryAfter != "" {
+		return parseRetryAfter(be.RetryAfter, time.Now())
+	}
+	var re *RPCError
+	if errors.As(err, &re) && re.RetryAfter > 0 {
+		return re.RetryAfter, true
+	}
+	return 0, false
+}
+
+// parseRetryAfter parses a Retry-After header, in seconds or as an HTTP
+// date.
+func parseRetryAfter(s string, now time.Time) (time.Duration, bool) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 {
+		return time.Duration(n) * time.Second, true
+	}
+	if t, err := http.ParseTime(s); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
 }
 
 // BatchExecuteError represents a batchexecute error
 type BatchExecuteError struct {
+	RPCIDs     string // comma-separated IDs of the calls in the request
 	StatusCode int
 	Message    string
-	Response   *http.Response
+	// Body is the response body, capped at maxErrorBody. It often says
+	// why the request was refused, as in "Request-URI Too Large".
+	Body        string
+	ContentType string
+	RetryAfter  string // the Retry-After header, if any
+	Response    *http.Response
 }
 
 func (e *BatchExecuteError) Error() string {
-	return fmt.Sprintf("batchexecute error: %s (status: %d)", e.Message, e.StatusCode)
+	s := fmt.Sprintf("batchexecute error: %s (status: %d)", e.Message, e.StatusCode)
+	if r := e.Reason(); r != "" {
+		s += ": " + r
+	}
+	if e.RetryAfter != "" {
+		s += " (retry after " + e.RetryAfter + ")"
+	}
+	return s
+}
+
+// maxErrorBody caps the response body kept in a BatchExecuteError.
+const maxErrorBody = 4 << 10
+
+// maxReason caps the text Reason returns.
+const maxReason = 200
+
+var (
+	htmlBlockRe = regexp.MustCompile(`(?is)<(script|style)\b.*?</(script|style)>`)
+	htmlTagRe   = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// Reason returns the text of the response body on one line, without
+// markup, or "" if there is none.
+func (e *BatchExecuteError) Reason() string {
+	s := e.Body
+	if strings.Contains(e.ContentType, "html") || strings.HasPrefix(strings.TrimSpace(s), "<") {
+		s = htmlBlockRe.ReplaceAllString(s, " ")
+		s = htmlTagRe.ReplaceAllString(s, " ")
+		s = html.UnescapeString(s)
+	}
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > maxReason {
+		s = string(r[:maxReason]) + "..."
+	}
+	return s
 }
 
 func (e *BatchExecuteError) Unwrap() error {
@@ -53,9 +271,64 @@ func (e *BatchExecuteError) Unwrap() error {
 	return nil
 }
 
-// Do executes a single RPC call
+// MaxRetryWait is the longest wait Do honors before retrying a call the
+// server turned away. When the server asks for longer, the error is
+// returned instead, so that a command does not hang for an hour.
+const MaxRetryWait = time.Minute
+
+// maxRetries is the number of times Do retries a call.
+const maxRetries = 2
+
+// Do executes a single RPC call. A call the server turned away without
+// running it, with HTTP 429 or 503 or a RESOURCE_EXHAUSTED or UNAVAILABLE
+// status, is retried after the wait the server asked for, if it asked for
+// at most MaxRetryWait. Calls that upload a Blob are not retried, as the
+// blob cannot be read again.
 func (c *Client) Do(rpc RPC) (*Response, error) {
-	return c.Execute([]RPC{rpc})
+	for attempt := 0; ; attempt++ {
+		resp, err := c.Execute([]RPC{rpc})
+		status := err
+		if err == nil {
+			status = resp.Err()
+		}
+		wait, ok := retryWait(status)
+		if !ok || wait > MaxRetryWait || attempt == maxRetries || findBlob(rpc.Args) != nil {
+			return resp, err
+		}
+		c.debug("rpc %s turned away (%v); retrying in %s", rpc.ID, status, wait)
+		ctx := rpc.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return resp, err
+		}
+	}
+}
+
+// retryWait returns the wait before retrying a call that failed with err,
+// if the server turned the call away without running it and said how long
+// to wait.
+func retryWait(err error) (time.Duration, bool) {
+	var be *BatchExecuteError
+	var re *RPCError
+	switch {
+	case errors.As(err, &be):
+		if be.StatusCode != http.StatusTooManyRequests && be.StatusCode != http.StatusServiceUnavailable {
+			return 0, false
+		}
+	case errors.As(err, &re):
+		if re.Code != CodeResourceExhausted && re.Code != CodeUnavailable {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	return RetryDelay(err)
 }
 
 func buildRPCData(rpc RPC) []interface{} {
@@ -72,7 +345,14 @@ func buildRPCData(rpc RPC) []interface{} {
 
 // Execute performs the batch execute request
 func (c *Client) Execute(rpcs []RPC) (*Response, error) {
-	u, err := url.Parse(fmt.Sprintf("https://%s/_/%s/data/batchexecute", c.config.Host, c.config.App))
+	if c.writes != nil {
+		for _, rpc := range rpcs {
+			if c.writes(rpc.ID) {
+				return nil, fmt.Errorf("rpc %s: %w", rpc.ID, ErrReadOnlyMode)
+			}
+		}
+	}
+	u, err := url.Parse("https://" + c.config.Host + c.config.URLPath())
 	if err != nil {
 		return nil, fmt.Errorf("parse url: %w", err)
 	}
@@ -99,8 +379,8 @@ func (c *Client) Execute(rpcs []RPC) (*Response, error) {
 	u.RawQuery = q.Encode()
 
 	if c.config.Debug {
-		fmt.Printf("\n=== BatchExecute Request ===\n")
-		fmt.Printf("URL: %s\n", u.String())
+		c.printf("\n=== BatchExecute Request ===\n")
+		c.printf("URL: %s\n", u.String())
 	}
 
 	// Build request body
@@ -119,15 +399,29 @@ func (c *Client) Execute(rpcs []RPC) (*Response, error) {
 	form.Set("at", c.config.AuthToken)
 
 	if c.config.Debug {
-		fmt.Printf("\nRequest Body:\n%s\n", form.Encode())
-		fmt.Printf("\nDecoded Request Body:\n%s\n", string(reqBody))
+		c.printf("\nRequest Body:\n%s\n", form.Encode())
+		c.printf("\nDecoded Request Body:\n%s\n", string(reqBody))
 	}
 
 	// Create request
-	req, err := http.NewRequest("POST", u.String(), strings.NewReader(form.Encode()))
+	ctx := rpcs[0].Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", u.String(), strings.NewReader(form.Encode()))
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
+	for _, rpc := range rpcs {
+		if blob := findBlob(rpc.Args); blob != nil {
+			body, size, err := blobBody(form.Encode(), blob)
+			if err != nil {
+				return nil, fmt.Errorf("create request: %w", err)
+			}
+			req.Body, req.ContentLength, req.GetBody = body, size, nil
+			break
+		}
+	}
 
 	// Set headers
 	req.Header.Set("content-type", "application/x-www-form-urlencoded;charset=UTF-8")
@@ -137,9 +431,15 @@ func (c *Client) Execute(rpcs []RPC) (*Response, error) {
 	req.Header.Set("cookie", c.config.Cookies)
 
 	if c.config.Debug {
-		fmt.Printf("\nRequest Headers:\n")
+		c.printf("\nRequest Headers:\n")
 		for k, v := range req.Header {
-			fmt.Printf("%s: %v\n", k, v)
+			c.printf("%s: %v\n", k, v)
+		}
+	}
+
+	if c.budget != nil {
+		if err := c.budget.Spend(q.Get("rpcids"), req.ContentLength); err != nil {
+			return nil, err
 		}
 	}
 
@@ -149,33 +449,74 @@ func (c *Client) Execute(rpcs []RPC) (*Response, error) {
 		return nil, fmt.Errorf("execute request: %w", err)
 	}
 	defer resp.Body.Close()
+	c.recordUsage(q.Get("rpcids"), req.ContentLength)
 
-	body, err := io.ReadAll(resp.Body)
+	body, spool, err := readBody(resp.Body, c.config.MaxResponseSize)
 	if err != nil {
 		return nil, fmt.Errorf("read response: %w", err)
 	}
+	// raw is converted once: responses to exports can be megabytes.
+	raw := string(body)
+	capped := func(n int) string { return capString(raw, n) }
+	if spool != nil {
+		defer spool.close()
+		capped = spool.capped
+	}
+	c.recordExchange(&Exchange{
+		Time:         time.Now(),
+		RPCIDs:       q.Get("rpcids"),
+		URL:          u.String(),
+		RequestBody:  string(reqBody),
+		Status:       resp.StatusCode,
+		ResponseBody: capped(maxExchangeBody),
+	})
 
 	if c.config.Debug {
-		fmt.Printf("\nResponse Status: %s\n", resp.Status)
-		fmt.Printf("Response Body:\n%s\n", string(body))
+		c.printf("\nResponse Status: %s\n", resp.Status)
+		if spool != nil {
+			c.printf("Response Body (spooled to %s):\n%s\n", spool.f.Name(), capped(maxExchangeBody))
+		} else {
+			c.printf("Response Body:\n%s\n", raw)
+		}
+	}
+
+	// Account pages come back as HTML, which would otherwise fail to
+	// decode or be reported by status alone.
+	if ie := interstitial(resp, capped(maxErrorBody)); ie != nil {
+		ie.RPCIDs = q.Get("rpcids")
+		return nil, ie
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, &BatchExecuteError{
-			StatusCode: resp.StatusCode,
-			Message:    fmt.Sprintf("request failed: %s", resp.Status),
-			Response:   resp,
+			RPCIDs:      q.Get("rpcids"),
+			StatusCode:  resp.StatusCode,
+			Message:     fmt.Sprintf("request failed: %s", resp.Status),
+			Body:        capped(maxErrorBody),
+			ContentType: resp.Header.Get("Content-Type"),
+			RetryAfter:  resp.Header.Get("Retry-After"),
+			Response:    resp,
 		}
 	}
 
 	// Parse chunked response
-	responses, err := decodeChunkedResponse(string(body))
+	var responses []Response
+	if spool != nil {
+		responses, err = spool.decode()
+		if err != nil {
+			return nil, fmt.Errorf("decode response: %w", err)
+		}
+	} else if c.config.LegacyChunks {
+		responses, err = decodeChunkedResponse(raw)
+	} else {
+		responses, err = decodeChunks(body)
+	}
 	if err != nil {
 		if c.config.Debug {
-			fmt.Printf("Failed to decode chunked response: %v\n", err)
+			c.printf("Failed to decode chunked response: %v\n", err)
 		}
 		// Fallback to regular response parsing
-		responses, err = decodeResponse(string(body))
+		responses, err = decodeResponse(raw)
 		if err != nil {
 			return nil, fmt.Errorf("decode response: %w", err)
 		}
@@ -188,38 +529,57 @@ func (c *Client) Execute(rpcs []RPC) (*Response, error) {
 	return &responses[0], nil
 }
 
-var debug = true
+// debug prints the chunks of responses as they are decoded. Responses
+// can be megabytes, so it is off outside of debugging the decoder.
+var debug = false
 
 // decodeResponse decodes the batchexecute response
 func decodeResponse(raw string) ([]Response, error) {
 	// Remove JSON prefix
 	raw = strings.TrimPrefix(raw, ")]}'")
-	// Handle literal "\n" sequences (e.g., in raw string inputs) as newlines
-	raw = strings.ReplaceAll(raw, "\\n", "\n")
+	// Handle literal "\n" sequences (e.g., in raw string inputs) as
+	// newlines. Real responses have newlines, and the payloads in them have
+	// escaped newlines that must be left alone.
+	if !strings.Contains(raw, "\n") {
+		raw = strings.ReplaceAll(raw, "\\n", "\n")
+	}
 	if raw == "" {
 		return nil, fmt.Errorf("empty response after trimming prefix")
 	}
-	// Remove any chunk length lines (pure numbers) and blank lines
-	var builder strings.Builder
-	for _, line := range strings.Split(raw, "\n") {
+	// Skip chunk length lines (pure numbers) and blank lines up to the
+	// first chunk, which is decoded on its own, so that the rest of a
+	// large response is not copied or scanned.
+	var line string
+	for raw != "" {
+		var rest string
+		line, rest, _ = strings.Cut(raw, "\n")
 		trimmed := strings.TrimSpace(line)
-		if trimmed == "" {
-			continue
-		}
-		if _, err := strconv.Atoi(trimmed); err == nil {
-			// skip chunk length line
-			continue
+		if trimmed != "" && !isDigits(trimmed) {
+			break
 		}
-		builder.WriteString(line)
+		raw = rest
 	}
-	filtered := builder.String()
 	var responses [][]interface{}
-	if err := json.NewDecoder(strings.NewReader(filtered)).Decode(&responses); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
+	if json.Unmarshal([]byte(line), &responses) != nil {
+		// The chunk spans lines; the decoder stops at its end.
+		responses = nil
+		if err := json.NewDecoder(strings.NewReader(raw)).Decode(&responses); err != nil {
+			return nil, fmt.Errorf("decode response: %w", err)
+		}
 	}
 
-	var result []Response
-	for _, rpcData := range responses {
+	result := appendResponses(nil, responses)
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no valid responses found")
+	}
+
+	return result, nil
+}
+
+// appendResponses appends the RPC responses in a decoded chunk to result,
+// skipping the other entries.
+func appendResponses(result []Response, chunk [][]interface{}) []Response {
+	for _, rpcData := range chunk {
 		if len(rpcData) < 7 {
 			continue
 		}
@@ -238,6 +598,8 @@ func decodeResponse(raw string) ([]Response, error) {
 		case string:
 			resp.Data = json.RawMessage(v)
 		case nil:
+			resp.Code = envelopeCode(rpcData)
+			resp.RetryAfter = envelopeRetryDelay(rpcData)
 			// explicit null or empty payload: capture full RPC envelope for error inspection
 			if full, err2 := json.Marshal(rpcData); err2 == nil {
 				resp.Data = json.RawMessage(full)
@@ -259,12 +621,17 @@ func decodeResponse(raw string) ([]Response, error) {
 
 		result = append(result, resp)
 	}
+	return result
+}
 
-	if len(result) == 0 {
-		return nil, fmt.Errorf("no valid responses found")
+// isDigits reports whether s is a chunk length.
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
 	}
-
-	return result, nil
+	return s != ""
 }
 
 // decodeChunkedResponse decodes the batchexecute response
@@ -282,6 +649,9 @@ func decodeChunkedResponse(raw string) ([]Response, error) {
 	// If that fails, try parsing as a chunked response
 	reader := bufio.NewReader(strings.NewReader(raw))
 	var builder strings.Builder
+	builder.Grow(len(raw))
+	// chunk is reused for each chunk read.
+	var chunk []byte
 	for {
 		lengthLine, err := reader.ReadString('\n')
 		if err == io.EOF {
@@ -297,7 +667,7 @@ func decodeChunkedResponse(raw string) ([]Response, error) {
 		totalLength, err := strconv.Atoi(lengthStr)
 		if err != nil {
 			if debug {
-				fmt.Printf("Invalid length string: %q\n", lengthStr)
+				fmt.Fprint(os.Stderr, redact.String(fmt.Sprintf("Invalid length string: %q\n", lengthStr)))
 			}
 			// Try parsing as a regular response again
 			if responses, err := decodeResponse(raw); err == nil {
@@ -308,11 +678,14 @@ func decodeChunkedResponse(raw string) ([]Response, error) {
 		if totalLength == 0 {
 			break
 		}
-		chunk := make([]byte, totalLength)
+		if cap(chunk) < totalLength {
+			chunk = make([]byte, totalLength)
+		}
+		chunk = chunk[:totalLength]
 		n, err := io.ReadFull(reader, chunk)
 		if err != nil {
 			if debug {
-				fmt.Printf("Failed to read chunk: got %d bytes, wanted %d: %v\n", n, totalLength, err)
+				fmt.Fprintf(os.Stderr, "Failed to read chunk: got %d bytes, wanted %d: %v\n", n, totalLength, err)
 			}
 			// Try parsing as a regular response again
 			if responses, err := decodeResponse(raw); err == nil {
@@ -324,15 +697,15 @@ func decodeChunkedResponse(raw string) ([]Response, error) {
 	}
 	full := builder.String()
 	if debug {
-		fmt.Printf("Full chunked JSON: %s\n", full)
+		fmt.Fprint(os.Stderr, redact.String(fmt.Sprintf("Full chunked JSON: %s\n", full)))
 	}
 	return decodeResponse(full)
 }
 
 func handleChunk(chunk []byte, responses *[]Response) error {
 	if debug {
-		fmt.Printf("Processing chunk (%d bytes): %q\n", len(chunk),
-			string(chunk[:min(100, len(chunk))]))
+		fmt.Fprint(os.Stderr, redact.String(fmt.Sprintf("Processing chunk (%d bytes): %q\n", len(chunk),
+			string(chunk[:min(100, len(chunk))]))))
 	}
 
 	// Parse the chunk
@@ -345,14 +718,14 @@ func handleChunk(chunk []byte, responses *[]Response) error {
 	for _, rpcData := range rpcBatch {
 		if len(rpcData) < 7 {
 			if debug {
-				fmt.Printf("Skipping short RPC data: %v\n", rpcData)
+				fmt.Fprint(os.Stderr, redact.String(fmt.Sprintf("Skipping short RPC data: %v\n", rpcData)))
 			}
 			continue
 		}
 		rpcType, ok := rpcData[0].(string)
 		if !ok || rpcType != "wrb.fr" {
 			if debug {
-				fmt.Printf("Skipping non-wrb.fr RPC: %v\n", rpcData[0])
+				fmt.Fprint(os.Stderr, redact.String(fmt.Sprintf("Skipping non-wrb.fr RPC: %v\n", rpcData[0])))
 			}
 			continue
 		}
@@ -367,6 +740,8 @@ func handleChunk(chunk []byte, responses *[]Response) error {
 		case string:
 			resp.Data = json.RawMessage(v)
 		case nil:
+			resp.Code = envelopeCode(rpcData)
+			resp.RetryAfter = envelopeRetryDelay(rpcData)
 			// No direct data; fall back to full rpcData envelope
 			if full, err := json.Marshal(rpcData); err == nil {
 				resp.Data = json.RawMessage(full)
@@ -414,12 +789,57 @@ func WithDebug(debug bool) Option {
 		c.config.Debug = debug
 		if debug {
 			c.debug = func(format string, args ...interface{}) {
-				fmt.Fprintf(os.Stderr, "DEBUG: "+format+"\n", args...)
+				fmt.Fprint(os.Stderr, c.Redact(fmt.Sprintf("DEBUG: "+format+"\n", args...)))
 			}
 		}
 	}
 }
 
+// WithRedaction controls whether credentials and email addresses are masked
+// in debug output. Redaction is enabled by default.
+func WithRedaction(enabled bool) Option {
+	return func(c *Client) {
+		c.config.NoRedact = !enabled
+	}
+}
+
+// WithLegacyChunks decodes responses with the previous chunk parser.
+func WithLegacyChunks(enabled bool) Option {
+	return func(c *Client) {
+		c.config.LegacyChunks = enabled
+	}
+}
+
+// WithMaxResponseSize spools responses larger than n bytes to a temporary
+// file; see Config.MaxResponseSize.
+func WithMaxResponseSize(n int64) Option {
+	return func(c *Client) {
+		c.config.MaxResponseSize = n
+	}
+}
+
+// A Budget decides whether a request may be sent. Spend is called before
+// each request with its RPC IDs and body size, and the request is not sent
+// if it returns an error.
+type Budget interface {
+	Spend(rpcIDs string, size int64) error
+}
+
+// WithBudget checks every request against b before sending it.
+func WithBudget(b Budget) Option {
+	return func(c *Client) {
+		c.budget = b
+	}
+}
+
+// WithReadOnly refuses, without sending them, requests with a call for
+// which writes reports true, failing with ErrReadOnlyMode.
+func WithReadOnly(writes func(rpcID string) bool) Option {
+	return func(c *Client) {
+		c.writes = writes
+	}
+}
+
 // WithTimeout sets the HTTP client timeout
 func WithTimeout(timeout time.Duration) Option {
 	return func(c *Client) {
@@ -433,13 +853,17 @@ func WithTimeout(timeout time.Duration) Option {
 	}
 }
 
-// WithHeaders adds additional headers
+// WithHeaders adds additional headers. An empty value removes the header.
 func WithHeaders(headers map[string]string) Option {
 	return func(c *Client) {
 		if c.config.Headers == nil {
 			c.config.Headers = make(map[string]string)
 		}
 		for k, v := range headers {
+			if v == "" {
+				delete(c.config.Headers, k)
+				continue
+			}
 			c.config.Headers[k] = v
 		}
 	}
@@ -464,16 +888,114 @@ func WithReqIDGenerator(reqid *ReqIDGenerator) Option {
 	}
 }
 
+// WithEndpoint sends requests to host and path instead of the configured
+// ones. Empty arguments keep them.
+func WithEndpoint(host, path string) Option {
+	return func(c *Client) {
+		if host != "" {
+			c.config.Host = host
+		}
+		if path != "" {
+			c.config.Path = path
+		}
+	}
+}
+
+// maxExchangeBody caps the response body kept in an Exchange.
+const maxExchangeBody = 64 << 10
+
+// Exchange is a record of one batchexecute HTTP round trip, kept for
+// diagnostics such as bug reports. Bodies are not redacted.
+type Exchange struct {
+	Time         time.Time `json:"time"`
+	RPCIDs       string    `json:"rpcids"`
+	URL          string    `json:"url"`
+	RequestBody  string    `json:"request_body"` // decoded f.req value
+	Status       int       `json:"status"`
+	ResponseBody string    `json:"response_body"`
+}
+
+// LastExchange returns the most recent round trip, or nil if no request
+// has completed.
+func (c *Client) LastExchange() *Exchange {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.last
+}
+
+func (c *Client) recordExchange(e *Exchange) {
+	c.mu.Lock()
+	c.last = e
+	c.mu.Unlock()
+}
+
+// Usage counts the requests a client has sent, by RPC ID.
+type Usage struct {
+	Calls map[string]int   `json:"calls,omitempty"`
+	Bytes map[string]int64 `json:"bytes,omitempty"` // request bodies
+}
+
+// Usage returns the requests sent so far.
+func (c *Client) Usage() Usage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u := Usage{Calls: make(map[string]int), Bytes: make(map[string]int64)}
+	for id, n := range c.usage.Calls {
+		u.Calls[id] = n
+	}
+	for id, n := range c.usage.Bytes {
+		u.Bytes[id] = n
+	}
+	return u
+}
+
+func (c *Client) recordUsage(rpcids string, n int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.usage.Calls == nil {
+		c.usage = Usage{Calls: make(map[string]int), Bytes: make(map[string]int64)}
+	}
+	c.usage.Calls[rpcids]++
+	c.usage.Bytes[rpcids] += n
+}
+
+func capString(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + fmt.Sprintf("\n... [%d bytes truncated]", len(s)-n)
+}
+
 // Config holds the configuration for batch execute
 type Config struct {
-	Host      string
-	App       string
+	Host string
+	App  string
+	// Path is the path of the batchexecute handler. Empty means
+	// /_/<App>/data/batchexecute.
+	Path      string
 	AuthToken string
 	Cookies   string
 	Headers   map[string]string
 	URLParams map[string]string
 	Debug     bool
 	UseHTTP   bool
+	NoRedact  bool // show credentials and emails in debug output
+	// LegacyChunks decodes responses with the chunk parser that preceded
+	// decodeChunks, in case the new one mishandles a response.
+	LegacyChunks bool
+	// MaxResponseSize is the largest response body kept in memory as it
+	// is read. Larger ones are written to a temporary file and decoded
+	// from it, which saves holding the body alongside the payload decoded
+	// from it. Zero means no limit.
+	MaxResponseSize int64
+}
+
+// URLPath returns the path requests are sent to.
+func (c Config) URLPath() string {
+	if c.Path != "" {
+		return c.Path
+	}
+	return "/_/" + c.App + "/data/batchexecute"
 }
 
 // Client handles batchexecute operations
@@ -482,6 +1004,15 @@ type Client struct {
 	httpClient *http.Client
 	debug      func(format string, args ...interface{})
 	reqid      *ReqIDGenerator
+
+	redactOnce sync.Once
+	redactor   *redact.Redactor
+
+	mu     sync.Mutex
+	last   *Exchange
+	usage  Usage
+	budget Budget
+	writes func(rpcID string) bool
 }
 
 // NewClient creates a new batchexecute client
@@ -502,6 +1033,24 @@ func (c *Client) Config() Config {
 	return c.config
 }
 
+// Redact masks the client's credentials, session cookies and email addresses
+// in s, unless redaction was disabled with WithRedaction(false).
+func (c *Client) Redact(s string) string {
+	if c.config.NoRedact {
+		return s
+	}
+	c.redactOnce.Do(func() {
+		c.redactor = redact.New(c.config.AuthToken, c.config.Cookies)
+	})
+	return c.redactor.String(s)
+}
+
+// printf writes debug output to stderr with secrets redacted, so that it
+// never mixes with a command's output.
+func (c *Client) printf(format string, args ...interface{}) {
+	fmt.Fprint(os.Stderr, c.Redact(fmt.Sprintf(format, args...)))
+}
+
 // ReqIDGenerator generates sequential request IDs
 type ReqIDGenerator struct {
 	base     int // Initial 4-digit number
@@ -3,11 +3,13 @@ package batchexecute
 import (
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -235,4 +237,332 @@ func TestExecute(t *testing.T) {
 	if string(response.Data) != string(expectedData) {
 		t.Errorf("Unexpected response data:\ngot:  %s\nwant: %s", string(response.Data), string(expectedData))
 	}
+
+	if _, err := client.Execute([]RPC{rpc}); err != nil {
+		t.Fatal(err)
+	}
+	u := client.Usage()
+	if u.Calls["VUsiyb"] != 2 || u.Bytes["VUsiyb"] == 0 {
+		t.Errorf("Usage() = %+v, want 2 calls to VUsiyb", u)
+	}
+}
+
+func TestExecuteErrorBody(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		header http.Header
+		body   string
+		want   string
+	}{
+		{
+			name:   "html error page",
+			status: http.StatusRequestURITooLong,
+			header: http.Header{"Content-Type": {"text/html; charset=UTF-8"}},
+			body: `<!DOCTYPE html><html lang=en><meta charset=utf-8>
+<title>Error 414 (Request-URI Too Large)!!1</title>
+<style>*{margin:0;padding:0}</style>
+<p><b>414.</b> <ins>That&#8217;s an error.</ins>
+<p>The requested URL is too large to process. <ins>That&#8217;s all we know.</ins>`,
+			want: "batchexecute error: request failed: 414 Request URI Too Long (status: 414): " +
+				"Error 414 (Request-URI Too Large)!!1 414. That’s an error. The requested URL is too large to process. That’s all we know.",
+		},
+		{
+			name:   "rate limited",
+			status: http.StatusTooManyRequests,
+			header: http.Header{"Retry-After": {"30"}},
+			want:   "batchexecute error: request failed: 429 Too Many Requests (status: 429) (retry after 30)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tt.header {
+					w.Header()[k] = v
+				}
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+			client := NewClient(Config{
+				Host:    strings.TrimPrefix(server.URL, "http://"),
+				App:     "notebooklm",
+				UseHTTP: true,
+			}, WithHTTPClient(server.Client()))
+
+			_, err := client.Execute([]RPC{{ID: "wXbhsf", Args: []interface{}{nil, 1}}})
+			var be *BatchExecuteError
+			if !errors.As(err, &be) {
+				t.Fatalf("Execute error = %v, want a BatchExecuteError", err)
+			}
+			if be.Body != tt.body || be.RetryAfter != tt.header.Get("Retry-After") || be.ContentType != tt.header.Get("Content-Type") {
+				t.Errorf("error = %+v, want the body and headers of the response", be)
+			}
+			if got := be.Error(); got != tt.want {
+				t.Errorf("Error() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResponseCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		code    int
+		wantErr error
+	}{
+		{
+			name:  "payload",
+			input: `)]}'` + "\n" + `[["wrb.fr","wXbhsf","[[]]",null,null,null,"generic"]]`,
+		},
+		{
+			name:    "unimplemented",
+			input:   `)]}'` + "\n" + `[["wrb.fr","wXbhsf",null,null,null,[12],"generic"]]`,
+			code:    CodeUnimplemented,
+			wantErr: ErrUnavailable,
+		},
+		{
+			name:    "permission denied with details",
+			input:   `)]}'` + "\n" + `[["wrb.fr","wXbhsf",null,null,null,[7,null,[]],"generic"]]`,
+			code:    CodePermissionDenied,
+			wantErr: ErrUnavailable,
+		},
+		{
+			name:  "invalid argument",
+			input: `)]}'` + "\n" + `[["wrb.fr","izAoDd",null,null,null,[3],"generic"]]`,
+			code:  CodeInvalidArgument,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := decodeResponse(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := resp[0].Code; got != tc.code {
+				t.Errorf("Code = %d, want %d", got, tc.code)
+			}
+			err = resp[0].Err()
+			if (err != nil) != (tc.code != 0) {
+				t.Errorf("Err() = %v, want error: %v", err, tc.code != 0)
+			}
+			if tc.wantErr != nil && !errors.Is(err, tc.wantErr) {
+				t.Errorf("Err() = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestWithEndpoint(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		fmt.Fprint(w, `)]}'`+"\n"+`[["wrb.fr","wXbhsf","[]",null,null,null,"generic"]]`)
+	}))
+	defer server.Close()
+	client := NewClient(Config{Host: "notebooklm.google.com", App: "LabsTailwindUi", UseHTTP: true},
+		WithHTTPClient(server.Client()),
+		WithEndpoint(strings.TrimPrefix(server.URL, "http://"), "/regional/batchexecute"))
+	if _, err := client.Execute([]RPC{{ID: "wXbhsf"}}); err != nil {
+		t.Fatal(err)
+	}
+	if path != "/regional/batchexecute" {
+		t.Errorf("request path = %q, want /regional/batchexecute", path)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	resp, err := decodeResponse(`)]}'` + "\n" +
+		`[["wrb.fr","izAoDd",null,null,null,[8,null,[["type.googleapis.com/google.rpc.RetryInfo",[[12,500000000]]]]],"generic"]]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	tests := []struct {
+		name string
+		err  error
+		want time.Duration
+		ok   bool
+	}{
+		{"retry info", fmt.Errorf("execute rpc: %w", resp[0].Err()), 12500 * time.Millisecond, true},
+		{"seconds header", &BatchExecuteError{StatusCode: 429, RetryAfter: "30"}, 30 * time.Second, true},
+		{"date header", &BatchExecuteError{StatusCode: 503, RetryAfter: date}, time.Hour, true},
+		{"bad header", &BatchExecuteError{StatusCode: 429, RetryAfter: "soon"}, 0, false},
+		{"no hint", &RPCError{ID: "izAoDd", Code: CodeResourceExhausted}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := RetryDelay(tt.err)
+			// An HTTP date has whole seconds, so allow a second less.
+			if ok != tt.ok || got > tt.want || got < tt.want-time.Second {
+				t.Errorf("RetryDelay(%v) = %v, %v; want %v, %v", tt.err, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestDoRetry(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter string
+		status     int
+		wantCalls  int
+		wantErr    bool
+	}{
+		{"short wait", "0", http.StatusTooManyRequests, 2, false},
+		{"wait too long", "3600", http.StatusTooManyRequests, 1, true},
+		{"no hint", "", http.StatusTooManyRequests, 1, true},
+		{"not turned away", "0", http.StatusInternalServerError, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if calls == 1 {
+					if tt.retryAfter != "" {
+						w.Header().Set("Retry-After", tt.retryAfter)
+					}
+					w.WriteHeader(tt.status)
+					return
+				}
+				fmt.Fprint(w, `)]}'`+"\n"+`[["wrb.fr","wXbhsf","[]",null,null,null,"generic"]]`)
+			}))
+			defer server.Close()
+			client := NewClient(Config{Host: strings.TrimPrefix(server.URL, "http://"), App: "LabsTailwindUi", UseHTTP: true},
+				WithHTTPClient(server.Client()))
+			_, err := client.Do(RPC{ID: "wXbhsf"})
+			if (err != nil) != tt.wantErr || calls != tt.wantCalls {
+				t.Errorf("Do: err = %v after %d calls; want error %v after %d", err, calls, tt.wantErr, tt.wantCalls)
+			}
+		})
+	}
+}
+
+type countBudget struct {
+	left int
+	ids  []string
+}
+
+func (b *countBudget) Spend(rpcIDs string, size int64) error {
+	if b.left == 0 {
+		return errors.New("over budget")
+	}
+	b.left--
+	b.ids = append(b.ids, rpcIDs)
+	return nil
+}
+
+func TestWithBudget(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `)]}'`+"\n"+`[["wrb.fr","wXbhsf","[]",null,null,null,"generic"]]`)
+	}))
+	defer server.Close()
+	budget := &countBudget{left: 1}
+	client := NewClient(Config{Host: strings.TrimPrefix(server.URL, "http://"), App: "notebooklm", UseHTTP: true},
+		WithHTTPClient(server.Client()), WithBudget(budget))
+	if _, err := client.Execute([]RPC{{ID: "wXbhsf"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Execute([]RPC{{ID: "wXbhsf"}}); err == nil || err.Error() != "over budget" {
+		t.Fatalf("second request: err = %v, want the budget's error", err)
+	}
+	if requests != 1 {
+		t.Errorf("server got %d requests, want 1", requests)
+	}
+	if len(budget.ids) != 1 || budget.ids[0] != "wXbhsf" {
+		t.Errorf("budget saw %v, want [wXbhsf]", budget.ids)
+	}
+}
+
+func TestWithReadOnly(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `)]}'`+"\n"+`[["wrb.fr","wXbhsf","[]",null,null,null,"generic"]]`)
+	}))
+	defer server.Close()
+	writes := func(id string) bool { return id == "CCqFvf" }
+	client := NewClient(Config{Host: strings.TrimPrefix(server.URL, "http://"), App: "notebooklm", UseHTTP: true},
+		WithHTTPClient(server.Client()), WithReadOnly(writes))
+	if _, err := client.Execute([]RPC{{ID: "wXbhsf"}}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := client.Execute([]RPC{{ID: "CCqFvf"}})
+	if !errors.Is(err, ErrReadOnlyMode) || !strings.Contains(err.Error(), "CCqFvf") {
+		t.Fatalf("write: err = %v, want ErrReadOnlyMode naming the call", err)
+	}
+	if requests != 1 {
+		t.Errorf("server got %d requests, want 1", requests)
+	}
+}
+
+func TestDecodeResponseEscapedNewlines(t *testing.T) {
+	raw := largeResponse(3)
+	for name, decode := range map[string]func(string) ([]Response, error){
+		"decodeResponse":        decodeResponse,
+		"decodeChunkedResponse": decodeChunkedResponse,
+	} {
+		resp, err := decode(raw)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		var notes [][]string
+		if err := json.Unmarshal(resp[0].Data, &notes); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(notes) != 3 || notes[2][1] != largeNote(2) {
+			t.Errorf("%s: got %d notes, last %q", name, len(notes), notes[len(notes)-1])
+		}
+	}
+}
+
+// largeNote returns the text of note i of largeResponse.
+func largeNote(i int) string {
+	return fmt.Sprintf("Note %d\n\n%s", i, strings.Repeat("A \"quoted\" line with\ttabs and \\ slashes.\n", 50))
+}
+
+// largeResponse returns a chunked response holding n notes of about 2 kB,
+// as an export of a large notebook would.
+func largeResponse(n int) string {
+	notes := make([][]string, n)
+	for i := range notes {
+		notes[i] = []string{fmt.Sprintf("note-%d", i), largeNote(i)}
+	}
+	payload, err := json.Marshal(notes)
+	if err != nil {
+		panic(err)
+	}
+	chunk, err := json.Marshal([][]interface{}{{"wrb.fr", "cFji9", string(payload), nil, nil, nil, "generic"}})
+	if err != nil {
+		panic(err)
+	}
+	tail := `[["e",4,null,null,237]]`
+	return fmt.Sprintf(")]}'\n\n%d\n%s\n%d\n%s\n", len(chunk), chunk, len(tail), tail)
+}
+
+func BenchmarkDecodeResponse(b *testing.B) {
+	benchmarkDecode(b, decodeResponse)
+}
+
+func BenchmarkDecodeChunkedResponse(b *testing.B) {
+	benchmarkDecode(b, decodeChunkedResponse)
+}
+
+func benchmarkDecode(b *testing.B, decode func(string) ([]Response, error)) {
+	for _, n := range []int{10, 1000, 5000} {
+		raw := largeResponse(n)
+		b.Run(fmt.Sprintf("%dkB", len(raw)/1000), func(b *testing.B) {
+			b.SetBytes(int64(len(raw)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := decode(raw); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
 }
@@ -0,0 +1,123 @@
+package batchexecute
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+)
+
+// blobToken stands in for a Blob in the encoded request until the body is
+// sent. It is unchanged by JSON and form encoding.
+const blobToken = "nlm-blob-6d1f0c2a"
+
+// Blob is an RPC argument sent as base64 that is streamed into the request
+// body from R rather than held in memory. Only one Blob may appear in a
+// request, anywhere in the arguments. If R is an io.Seeker, it is read
+// twice: once to compute the Content-Length, then to send it; otherwise
+// the request is sent chunked.
+type Blob struct {
+	R io.Reader
+}
+
+// MarshalJSON encodes the placeholder the body is later split at.
+func (b *Blob) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + blobToken + `"`), nil
+}
+
+// findBlob returns the first Blob in v, searching nested slices.
+func findBlob(v interface{}) *Blob {
+	switch v := v.(type) {
+	case *Blob:
+		return v
+	case []interface{}:
+		for _, e := range v {
+			if b := findBlob(e); b != nil {
+				return b
+			}
+		}
+	}
+	return nil
+}
+
+// blobBody returns a reader producing body with the placeholder replaced by
+// the form-escaped base64 of b, and its length, or -1 if it is unknown.
+// Closing the reader stops the encoding.
+func blobBody(body string, b *Blob) (io.ReadCloser, int64, error) {
+	i := strings.Index(body, blobToken)
+	if i < 0 {
+		return nil, 0, errors.New("blob placeholder not found in request")
+	}
+	size := int64(-1)
+	if s, ok := b.R.(io.Seeker); ok {
+		start, err := s.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, 0, err
+		}
+		var n countWriter
+		if err := encodeBlob(&n, b.R); err != nil {
+			return nil, 0, err
+		}
+		if _, err := s.Seek(start, io.SeekStart); err != nil {
+			return nil, 0, err
+		}
+		size = int64(len(body)-len(blobToken)) + int64(n)
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(encodeBlob(pw, b.R))
+	}()
+	r := io.MultiReader(strings.NewReader(body[:i]), pr, strings.NewReader(body[i+len(blobToken):]))
+	return &pipeBody{Reader: r, pr: pr}, size, nil
+}
+
+// pipeBody is a request body fed by a goroutine writing to pr.
+type pipeBody struct {
+	io.Reader
+	pr *io.PipeReader
+}
+
+func (b *pipeBody) Close() error { return b.pr.Close() }
+
+// encodeBlob writes r to w as base64, escaped as a form value.
+func encodeBlob(w io.Writer, r io.Reader) error {
+	enc := base64.NewEncoder(base64.StdEncoding, &formEscaper{w: w})
+	if _, err := io.Copy(enc, r); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// formEscaper escapes the base64 characters that url.QueryEscape escapes.
+type formEscaper struct {
+	w   io.Writer
+	buf []byte
+}
+
+func (e *formEscaper) Write(p []byte) (int, error) {
+	e.buf = e.buf[:0]
+	for _, c := range p {
+		switch c {
+		case '+':
+			e.buf = append(e.buf, "%2B"...)
+		case '/':
+			e.buf = append(e.buf, "%2F"...)
+		case '=':
+			e.buf = append(e.buf, "%3D"...)
+		default:
+			e.buf = append(e.buf, c)
+		}
+	}
+	if _, err := e.w.Write(e.buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// countWriter counts the bytes written to it.
+type countWriter int
+
+func (n *countWriter) Write(p []byte) (int, error) {
+	*n += countWriter(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,63 @@
+package batchexecute
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestBlobBody(t *testing.T) {
+	data := make([]byte, 70000)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	args := []interface{}{[]interface{}{"name.pdf", &Blob{R: bytes.NewReader(data)}}, "nb1"}
+	blob := findBlob(args)
+	if blob == nil {
+		t.Fatal("findBlob did not find the nested blob")
+	}
+	argsJSON, err := json.Marshal(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	form := url.Values{}
+	form.Set("f.req", string(argsJSON))
+	form.Set("at", "token")
+
+	for _, r := range []io.Reader{bytes.NewReader(data), io.MultiReader(bytes.NewReader(data))} {
+		blob.R = r
+		body, size, err := blobBody(form.Encode(), blob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b64 := base64.StdEncoding.EncodeToString(data)
+		want := strings.Replace(form.Encode(), blobToken, url.QueryEscape(b64), 1)
+		if string(got) != want {
+			t.Errorf("body differs from the form-encoded payload")
+		}
+		if _, seekable := r.(io.Seeker); seekable && size != int64(len(want)) {
+			t.Errorf("size = %d, want %d", size, len(want))
+		} else if !seekable && size != -1 {
+			t.Errorf("size = %d for unseekable reader, want -1", size)
+		}
+	}
+}
+
+func TestBlobBodyClose(t *testing.T) {
+	body, _, err := blobBody(blobToken, &Blob{R: bytes.NewReader(make([]byte, 1<<20))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body.Close()
+	if _, err := io.ReadAll(body); err == nil {
+		t.Error("read after Close succeeded")
+	}
+}
@@ -0,0 +1,95 @@
+package batchexecute
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// A chunkScanner splits a chunked response into its chunks in place,
+// without copying them. Each chunk follows a line holding its length.
+type chunkScanner struct {
+	buf   []byte
+	chunk []byte
+	err   error
+}
+
+// scan advances to the next chunk, which is then in s.chunk. It reports
+// false at the end of the response or on an error, which is left in s.err.
+func (s *chunkScanner) scan() bool {
+	s.buf = bytes.TrimLeft(s.buf, " \t\r\n")
+	if len(s.buf) == 0 {
+		return false
+	}
+	line, rest := s.buf, s.buf[len(s.buf):]
+	if i := bytes.IndexByte(s.buf, '\n'); i >= 0 {
+		line, rest = s.buf[:i], s.buf[i+1:]
+	}
+	n, ok := parseLength(bytes.TrimSpace(line))
+	if !ok {
+		s.err = fmt.Errorf("invalid chunk length %q", line)
+		return false
+	}
+	if n == 0 {
+		return false
+	}
+	// Lengths are counted in bytes by some servers and in UTF-16 units, or
+	// with the newline before the chunk, by others. A length that does not
+	// end a line is not in bytes, and the chunk is the rest of the line. A
+	// truncated chunk is left for the JSON decoder to report.
+	end := n
+	if end > len(rest) || end < len(rest) && rest[end] != '\n' && rest[end] != '\r' {
+		end = len(rest)
+		if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+			end = i
+		}
+	}
+	s.chunk, s.buf = bytes.TrimRight(rest[:end], " \t\r\n"), rest[end:]
+	return true
+}
+
+// parseLength parses a chunk length, without the allocation of converting
+// b to a string for strconv.
+func parseLength(b []byte) (int, bool) {
+	if len(b) == 0 || len(b) > 10 {
+		return 0, false
+	}
+	n := 0
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		n = n*10 + int(c-'0')
+	}
+	return n, true
+}
+
+// decodeChunks decodes a response body, which may be chunked. It replaces
+// decodeChunkedResponse, which is kept behind WithLegacyChunks until this
+// parser has seen the responses of every RPC.
+func decodeChunks(body []byte) ([]Response, error) {
+	body = bytes.TrimSpace(bytes.TrimPrefix(body, []byte(")]}'")))
+	if len(body) == 0 {
+		return nil, fmt.Errorf("empty response after trimming prefix")
+	}
+	if body[0] < '0' || body[0] > '9' {
+		// Not chunked.
+		return decodeResponse(string(body))
+	}
+	s := chunkScanner{buf: body}
+	var result []Response
+	for s.scan() {
+		var chunk [][]interface{}
+		if err := json.Unmarshal(s.chunk, &chunk); err != nil {
+			return nil, fmt.Errorf("decode chunk: %w", err)
+		}
+		result = appendResponses(result, chunk)
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no valid responses found")
+	}
+	return result, nil
+}
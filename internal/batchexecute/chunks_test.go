@@ -0,0 +1,142 @@
+package batchexecute
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestChunkScanner(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    []string
+		wantErr error
+	}{
+		{
+			name: "byte lengths",
+			in:   "\n7\n[[\"a\"]]\n5\n[[1]]\n",
+			want: []string{`[["a"]]`, `[[1]]`},
+		},
+		{
+			name: "utf-16 lengths",
+			in:   "9\n[[\"é€\"]]\n5\n[[1]]",
+			want: []string{`[["é€"]]`, `[[1]]`},
+		},
+		{
+			name: "lengths with newline",
+			in:   "8\n[[\"a\"]]\n6\n[[1]]\n",
+			want: []string{`[["a"]]`, `[[1]]`},
+		},
+		{
+			name: "carriage returns",
+			in:   "7\r\n[[\"a\"]]\r\n0\r\n",
+			want: []string{`[["a"]]`},
+		},
+		{
+			name: "terminator",
+			in:   "5\n[[1]]\n0\n5\n[[2]]",
+			want: []string{`[[1]]`},
+		},
+		{
+			name:    "invalid length",
+			in:      "abc\n[[1]]",
+			wantErr: errors.New(`invalid chunk length "abc"`),
+		},
+		{
+			name: "truncated",
+			in:   "100\n[[\"wrb.fr\",\"test\",\"",
+			want: []string{`[["wrb.fr","test","`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := chunkScanner{buf: []byte(tt.in)}
+			var got []string
+			for s.scan() {
+				got = append(got, string(s.chunk))
+			}
+			if tt.wantErr != nil {
+				if s.err == nil || s.err.Error() != tt.wantErr.Error() {
+					t.Fatalf("err = %v, want %v", s.err, tt.wantErr)
+				}
+				return
+			}
+			if s.err != nil {
+				t.Fatal(s.err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("chunks (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDecodeChunks(t *testing.T) {
+	for _, raw := range []string{
+		largeResponse(3),
+		")]}'\n\n[[\"wrb.fr\",\"VUsiyb\",\"[1]\",null,null,null,\"generic\"]]",
+		")]}'\n\n105\n[[\"wrb.fr\",\"izAoDd\",null,null,null,[3],\"generic\"]]\n25\n[[\"e\",4,null,null,237]]",
+	} {
+		want, err := decodeChunkedResponse(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := decodeChunks([]byte(raw))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("decodeChunks(%.40q) (-legacy +new):\n%s", raw, diff)
+		}
+	}
+
+	// The responses in every chunk are decoded, not only the first.
+	raw := "5\n[[1]]\n44\n[[\"wrb.fr\",\"b\",\"[2]\",null,null,null,\"1\"]]"
+	got, err := decodeChunks([]byte(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != "b" || got[0].Index != 1 {
+		t.Errorf("decodeChunks(%q) = %+v", raw, got)
+	}
+
+	raw = "100\n[[\"wrb.fr\",\"test\",\""
+	if _, err := decodeChunks([]byte(raw)); err == nil || !strings.HasPrefix(err.Error(), "decode chunk: ") {
+		t.Errorf("decodeChunks(%q) error = %v, want a decode chunk error", raw, err)
+	}
+}
+
+func BenchmarkChunkScanner(b *testing.B) {
+	raw := []byte(largeResponse(5000))
+	raw = raw[strings.IndexByte(string(raw), '\n'):]
+	b.SetBytes(int64(len(raw)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s := chunkScanner{buf: raw}
+		for s.scan() {
+		}
+		if s.err != nil {
+			b.Fatal(s.err)
+		}
+	}
+}
+
+func BenchmarkDecodeChunks(b *testing.B) {
+	for _, n := range []int{10, 1000, 5000} {
+		raw := []byte(largeResponse(n))
+		b.Run(fmt.Sprintf("%dkB", len(raw)/1000), func(b *testing.B) {
+			b.SetBytes(int64(len(raw)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := decodeChunks(raw); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
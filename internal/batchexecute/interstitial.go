@@ -0,0 +1,104 @@
+package batchexecute
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ErrInterstitial is wrapped by errors for requests Google answered with a
+// page the user must deal with in a browser, such as a consent screen or
+// a "verify it's you" check, instead of data.
+var ErrInterstitial = errors.New("account interstitial")
+
+// Kinds of interstitial.
+const (
+	InterstitialConsent = "consent" // terms or privacy consent
+	InterstitialVerify  = "verify"  // "verify it's you" and other challenges
+	InterstitialSignIn  = "sign-in" // the session is no longer signed in
+)
+
+// InterstitialError is a request answered with an account page instead
+// of data. Sign-in pages wrap ErrUnauthorized, as new credentials are
+// needed; the other kinds wrap ErrInterstitial, as the same credentials
+// work again once the user has visited URL.
+type InterstitialError struct {
+	RPCIDs string
+	Kind   string
+	URL    string // the page to visit
+}
+
+func (e *InterstitialError) Error() string {
+	switch e.Kind {
+	case InterstitialConsent:
+		return fmt.Sprintf("rpc %s: Google is asking for consent before continuing; visit %s in the browser signed in to this account", e.RPCIDs, e.URL)
+	case InterstitialVerify:
+		return fmt.Sprintf("rpc %s: Google wants to verify it's you; visit %s in the browser signed in to this account", e.RPCIDs, e.URL)
+	}
+	return fmt.Sprintf("rpc %s: Google asked to sign in again (%s)", e.RPCIDs, e.URL)
+}
+
+func (e *InterstitialError) Unwrap() error {
+	if e.Kind == InterstitialSignIn {
+		return ErrUnauthorized
+	}
+	return ErrInterstitial
+}
+
+// interstitialHosts serve the pages shown instead of data.
+var interstitialHosts = map[string]bool{
+	"accounts.google.com": true,
+	"consent.google.com":  true,
+}
+
+// interstitialURLRe finds links to interstitialHosts in a page.
+var interstitialURLRe = regexp.MustCompile(`https://(?:accounts|consent)\.google\.com/[^"'\s<>\\]*`)
+
+// interstitial returns the interstitial resp is, or nil: a redirect to
+// an account page, whether followed or not, or an HTML page linking to
+// one where a batchexecute response was expected. body is the start of
+// the response body.
+func interstitial(resp *http.Response, body string) *InterstitialError {
+	var u *url.URL
+	switch {
+	case resp.Request != nil && resp.Request.URL != nil && interstitialHosts[resp.Request.URL.Host]:
+		u = resp.Request.URL
+	case resp.StatusCode >= 300 && resp.StatusCode < 400:
+		loc, err := resp.Location()
+		if err != nil || !interstitialHosts[loc.Host] {
+			return nil
+		}
+		u = loc
+	case resp.StatusCode == http.StatusOK && strings.Contains(resp.Header.Get("Content-Type"), "html"):
+		m := interstitialURLRe.FindString(body)
+		if m == "" {
+			return nil
+		}
+		loc, err := url.Parse(html.UnescapeString(m))
+		if err != nil {
+			return nil
+		}
+		u = loc
+	default:
+		return nil
+	}
+	return &InterstitialError{Kind: interstitialKind(u), URL: u.String()}
+}
+
+// interstitialKind tells the kinds of account page apart by their URL.
+func interstitialKind(u *url.URL) string {
+	if u.Host == "consent.google.com" {
+		return InterstitialConsent
+	}
+	p := strings.ToLower(u.Path)
+	for _, s := range []string{"challenge", "speedbump", "verify", "confirm", "reauth"} {
+		if strings.Contains(p, s) {
+			return InterstitialVerify
+		}
+	}
+	return InterstitialSignIn
+}
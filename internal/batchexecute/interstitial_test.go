@@ -0,0 +1,104 @@
+package batchexecute
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestExecuteInterstitial(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		header   http.Header
+		body     string
+		kind     string
+		url      string
+		sentinel error
+	}{
+		{
+			name:     "consent page",
+			status:   http.StatusOK,
+			header:   http.Header{"Content-Type": {"text/html; charset=utf-8"}},
+			body:     `<html><form action="https://consent.google.com/save?continue=https://notebooklm.google.com/&amp;gl=DE" method="POST">`,
+			kind:     InterstitialConsent,
+			url:      "https://consent.google.com/save?continue=https://notebooklm.google.com/&gl=DE",
+			sentinel: ErrInterstitial,
+		},
+		{
+			name:     "verify it's you",
+			status:   http.StatusFound,
+			header:   http.Header{"Location": {"https://accounts.google.com/signin/v2/challenge/pwd?continue=x"}},
+			kind:     InterstitialVerify,
+			url:      "https://accounts.google.com/signin/v2/challenge/pwd?continue=x",
+			sentinel: ErrInterstitial,
+		},
+		{
+			name:     "signed out",
+			status:   http.StatusFound,
+			header:   http.Header{"Location": {"https://accounts.google.com/ServiceLogin?continue=x"}},
+			kind:     InterstitialSignIn,
+			url:      "https://accounts.google.com/ServiceLogin?continue=x",
+			sentinel: ErrUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tt.header {
+					w.Header()[k] = v
+				}
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+			hc := server.Client()
+			hc.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+			client := NewClient(Config{
+				Host:    strings.TrimPrefix(server.URL, "http://"),
+				App:     "notebooklm",
+				UseHTTP: true,
+			}, WithHTTPClient(hc))
+
+			_, err := client.Execute([]RPC{{ID: "wXbhsf"}})
+			var ie *InterstitialError
+			if !errors.As(err, &ie) {
+				t.Fatalf("Execute error = %v, want an InterstitialError", err)
+			}
+			if ie.Kind != tt.kind || ie.URL != tt.url || ie.RPCIDs != "wXbhsf" {
+				t.Errorf("error = %+v, want kind %s and URL %s", ie, tt.kind, tt.url)
+			}
+			if !errors.Is(err, tt.sentinel) {
+				t.Errorf("error does not wrap %v", tt.sentinel)
+			}
+			if tt.kind != InterstitialSignIn && !strings.Contains(err.Error(), tt.url) {
+				t.Errorf("Error() = %q, want the URL to visit", err)
+			}
+		})
+	}
+}
+
+func TestInterstitialFollowed(t *testing.T) {
+	// After following a redirect, the response is the page itself.
+	u, _ := url.Parse("https://accounts.google.com/speedbump/idvreenable?continue=x")
+	resp := &http.Response{StatusCode: http.StatusOK, Request: &http.Request{URL: u}, Header: http.Header{}}
+	ie := interstitial(resp, "<html>")
+	if ie == nil || ie.Kind != InterstitialVerify || ie.URL != u.String() {
+		t.Errorf("interstitial = %+v, want the verify page", ie)
+	}
+
+	// Error pages and data are left alone.
+	u, _ = url.Parse("https://notebooklm.google.com/_/LabsTailwindUi/data/batchexecute")
+	for _, resp := range []*http.Response{
+		{StatusCode: http.StatusOK, Request: &http.Request{URL: u}, Header: http.Header{"Content-Type": {"application/json"}}},
+		{StatusCode: http.StatusUnauthorized, Request: &http.Request{URL: u}, Header: http.Header{"Content-Type": {"text/html"}}},
+	} {
+		if ie := interstitial(resp, `<a href="https://accounts.google.com/ServiceLogin">`); ie != nil {
+			t.Errorf("status %d: interstitial = %+v, want nil", resp.StatusCode, ie)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package batchexecute
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/tmc/nlm/internal/cleanup"
+)
+
+// A spooled response is a body larger than Config.MaxResponseSize, kept in
+// a temporary file rather than in memory.
+type spooled struct {
+	f    *os.File
+	size int64
+	// head is the start of the body, for debug output and errors.
+	head string
+}
+
+// readBody reads a response body, keeping up to max bytes in memory. A
+// larger body is written to a temporary file instead and returned as a
+// spooled response, which the caller closes. A max of 0 means no limit.
+func readBody(r io.Reader, max int64) ([]byte, *spooled, error) {
+	if max <= 0 {
+		b, err := io.ReadAll(r)
+		return b, nil, err
+	}
+	body, err := io.ReadAll(io.LimitReader(r, max+1))
+	if err != nil || int64(len(body)) <= max {
+		return body, nil, err
+	}
+	f, err := cleanup.CreateTemp("", "nlm-response-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	s := &spooled{f: f}
+	n, err := f.Write(body)
+	if err == nil {
+		var m int64
+		m, err = io.Copy(f, r)
+		s.size = int64(n) + m
+	}
+	if err == nil {
+		head := make([]byte, min(int(s.size), maxExchangeBody))
+		_, err = f.ReadAt(head, 0)
+		s.head = string(head)
+	}
+	if err != nil {
+		s.close()
+		return nil, nil, err
+	}
+	return nil, s, nil
+}
+
+// close removes the file.
+func (s *spooled) close() {
+	s.f.Close()
+	cleanup.Remove(s.f.Name())
+}
+
+// capped is capString for a spooled body.
+func (s *spooled) capped(n int) string {
+	head := s.head[:min(len(s.head), n)]
+	return head + fmt.Sprintf("\n... [%d bytes truncated]", s.size-int64(len(head)))
+}
+
+// decode decodes the first chunk of the body, which holds the RPC
+// response, with the same decoder as in-memory responses. It is read from
+// the file, so the body is never held in memory whole, but the payload
+// still is, and may be briefly more than once while it is unescaped.
+func (s *spooled) decode() ([]Response, error) {
+	if _, err := s.f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	r := bufio.NewReaderSize(s.f, 64<<10)
+	if err := skipFraming(r); err != nil {
+		return nil, err
+	}
+	var chunk [][]interface{}
+	if err := json.NewDecoder(r).Decode(&chunk); err != nil {
+		return nil, fmt.Errorf("decode chunk: %w", err)
+	}
+	result := appendResponses(nil, chunk)
+	if len(result) == 0 {
+		return nil, errors.New("no valid responses found")
+	}
+	return result, nil
+}
+
+// skipFraming skips the )]}' prefix and the chunk length lines before the
+// first chunk.
+func skipFraming(r *bufio.Reader) error {
+	if b, _ := r.Peek(4); string(b) == ")]}'" {
+		r.Discard(4)
+	}
+	for {
+		if err := skipSpace(r); err != nil {
+			return err
+		}
+		b, err := r.Peek(1)
+		if err != nil {
+			return err
+		}
+		if b[0] < '0' || b[0] > '9' {
+			return nil
+		}
+		if _, err := r.ReadSlice('\n'); err != nil {
+			return err
+		}
+	}
+}
+
+func skipSpace(r *bufio.Reader) error {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+			return r.UnreadByte()
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package batchexecute
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSpooledResponse(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"payload", largeResponse(50)},
+		{"unchunked", ")]}'\n\n[[\"wrb.fr\",\"VUsiyb\",\"[\\\"é€ \\\\u003c😀\\\"]\",null,null,null,\"generic\"]]"},
+		{"error code", ")]}'\n\n105\n[[\"wrb.fr\",\"izAoDd\",null,null,null,[3],\"generic\"]]\n25\n[[\"e\",4,null,null,237]]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := decodeChunks([]byte(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+			client := NewClient(Config{Host: strings.TrimPrefix(server.URL, "http://"), App: "notebooklm", UseHTTP: true},
+				WithHTTPClient(server.Client()), WithMaxResponseSize(16))
+			got, err := client.Do(RPC{ID: want[0].ID, Index: "generic"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(want[0], *got); diff != "" {
+				t.Errorf("spooled response (-in memory +spooled):\n%s", diff)
+			}
+			if len(tt.body) > maxExchangeBody {
+				suffix := fmt.Sprintf("[%d bytes truncated]", len(tt.body)-maxExchangeBody)
+				if e := client.LastExchange(); !strings.HasSuffix(e.ResponseBody, suffix) {
+					t.Errorf("exchange body ends %q, want %q", e.ResponseBody[len(e.ResponseBody)-40:], suffix)
+				}
+			}
+			if files, _ := os.ReadDir(tmp); len(files) != 0 {
+				t.Errorf("temporary files left: %v", files)
+			}
+		})
+	}
+}
+
+func BenchmarkSpooledDecode(b *testing.B) {
+	raw := largeResponse(5000)
+	f, err := os.CreateTemp(b.TempDir(), "response")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(raw); err != nil {
+		b.Fatal(err)
+	}
+	s := &spooled{f: f, size: int64(len(raw))}
+	b.SetBytes(int64(len(raw)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.decode(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -115,6 +115,21 @@ func TestUnmarshal(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "unicode titles",
+			json: `["研究ノート \u0645\u0644\u0627\u062d\u0638\u0627\u062a 👩‍🔬", [[["s1"], "Café \u00e9t\u00e9 — 第1章"]], "id3", "🧪"]`,
+			want: &pb.Project{
+				Title: "研究ノート ملاحظات 👩‍🔬",
+				Sources: []*pb.Source{
+					{
+						SourceId: &pb.SourceId{SourceId: "s1"},
+						Title:    "Café été — 第1章",
+					},
+				},
+				ProjectId: "id3",
+				Emoji:     "🧪",
+			},
+		},
 		{
 			name:    "invalid json",
 			json:    `not json`,
@@ -0,0 +1,194 @@
+package bucket
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// gcsScope is the OAuth scope requested for Cloud Storage; nlm only reads.
+const gcsScope = "https://www.googleapis.com/auth/devstorage.read_only"
+
+// defaultTokenURL is Google's OAuth token endpoint, used when a
+// credentials file does not name one.
+const defaultTokenURL = "https://oauth2.googleapis.com/token"
+
+// defaultToken returns an access token found the way Application Default
+// Credentials are: the file named by GOOGLE_APPLICATION_CREDENTIALS, the
+// file written by gcloud auth application-default login, and the metadata
+// server of Compute Engine, Cloud Run and GKE. User and service account
+// files are supported; other credential types are reported as errors. It
+// returns "" if none is found.
+func defaultToken(ctx context.Context) (string, error) {
+	file := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if file == "" {
+		file = wellKnownCredentials()
+	}
+	if file != "" {
+		data, err := os.ReadFile(file)
+		switch {
+		case err == nil:
+			return fileToken(ctx, data)
+		case !errors.Is(err, os.ErrNotExist) || os.Getenv("GOOGLE_APPLICATION_CREDENTIALS") != "":
+			return "", fmt.Errorf("bucket: %w", err)
+		}
+	}
+	return metadataToken(ctx)
+}
+
+// wellKnownCredentials returns where gcloud keeps application default
+// credentials.
+func wellKnownCredentials() string {
+	dir := os.Getenv("CLOUDSDK_CONFIG")
+	if dir == "" {
+		if runtime.GOOS == "windows" {
+			dir = filepath.Join(os.Getenv("APPDATA"), "gcloud")
+		} else if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, ".config", "gcloud")
+		} else {
+			return ""
+		}
+	}
+	return filepath.Join(dir, "application_default_credentials.json")
+}
+
+// credentialsFile is the JSON of a user or service account credentials
+// file.
+type credentialsFile struct {
+	Type         string `json:"type"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// fileToken exchanges the credentials of a file for an access token.
+func fileToken(ctx context.Context, data []byte) (string, error) {
+	var f credentialsFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return "", fmt.Errorf("bucket: credentials file: %w", err)
+	}
+	tokenURL := f.TokenURI
+	if tokenURL == "" {
+		tokenURL = defaultTokenURL
+	}
+	switch f.Type {
+	case "authorized_user":
+		return exchangeToken(ctx, tokenURL, url.Values{
+			"grant_type":    {"refresh_token"},
+			"client_id":     {f.ClientID},
+			"client_secret": {f.ClientSecret},
+			"refresh_token": {f.RefreshToken},
+		})
+	case "service_account":
+		assertion, err := signJWT(f, tokenURL, time.Now())
+		if err != nil {
+			return "", err
+		}
+		return exchangeToken(ctx, tokenURL, url.Values{
+			"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+			"assertion":  {assertion},
+		})
+	}
+	return "", fmt.Errorf("bucket: credentials of type %q are not supported; use a user or service account key, or set GOOGLE_OAUTH_ACCESS_TOKEN", f.Type)
+}
+
+// signJWT returns the assertion a service account exchanges for a token.
+func signJWT(f credentialsFile, aud string, now time.Time) (string, error) {
+	block, _ := pem.Decode([]byte(f.PrivateKey))
+	if block == nil {
+		return "", errors.New("bucket: service account key: no PEM private key")
+	}
+	var key *rsa.PrivateKey
+	if k, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		key, _ = k.(*rsa.PrivateKey)
+	} else if k, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		key = k
+	}
+	if key == nil {
+		return "", errors.New("bucket: service account key: not an RSA private key")
+	}
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]any{
+		"iss":   f.ClientEmail,
+		"scope": gcsScope,
+		"aud":   aud,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", fmt.Errorf("bucket: service account key: %w", err)
+	}
+	return unsigned + "." + enc.EncodeToString(sig), nil
+}
+
+// exchangeToken posts form to a token endpoint and returns the access
+// token of the response.
+func exchangeToken(ctx context.Context, tokenURL string, form url.Values) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("bucket: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("bucket: get access token: %w", err)
+	}
+	defer resp.Body.Close()
+	var r struct {
+		AccessToken string `json:"access_token"`
+		Error       string `json:"error"`
+		Description string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return "", fmt.Errorf("bucket: get access token: %s", resp.Status)
+	}
+	if r.AccessToken == "" {
+		return "", fmt.Errorf("bucket: get access token: %s: %s %s", resp.Status, r.Error, r.Description)
+	}
+	return r.AccessToken, nil
+}
+
+// metadataToken returns the token of the default service account from
+// the metadata server, or "" if there is none. GCE_METADATA_HOST sets its
+// address, as in the client libraries.
+func metadataToken(ctx context.Context) (string, error) {
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = "metadata.google.internal"
+	}
+	body, err := metadataRequest(ctx, http.MethodGet,
+		"http://"+host+"/computeMetadata/v1/instance/service-accounts/default/token?scopes="+url.QueryEscape(gcsScope),
+		http.Header{"Metadata-Flavor": {"Google"}})
+	if err != nil {
+		return "", nil
+	}
+	var r struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(body, &r); err != nil || r.AccessToken == "" {
+		return "", errors.New("bucket: metadata server returned no access token")
+	}
+	return r.AccessToken, nil
+}
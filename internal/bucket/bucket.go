@@ -0,0 +1,83 @@
+// Package bucket lists and reads the objects of Amazon S3 and Google Cloud
+// Storage buckets, with credentials found the way the providers' SDKs
+// find them.
+package bucket
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// An Object is an object in a bucket.
+type Object struct {
+	Key      string
+	Size     int64
+	ETag     string // changes whenever the content does
+	Modified time.Time
+}
+
+// A Bucket is a storage bucket.
+type Bucket interface {
+	// List returns the objects whose keys start with prefix.
+	List(ctx context.Context, prefix string) ([]Object, error)
+	// Open streams the content of an object.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// Open returns the bucket and key prefix of an s3:// or gs:// URI, such as
+// s3://reports/2024/.
+func Open(ctx context.Context, uri string) (Bucket, string, error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Host == "" {
+		return nil, "", fmt.Errorf("bucket: invalid URI %q (want s3://bucket/prefix or gs://bucket/prefix)", uri)
+	}
+	prefix := strings.TrimPrefix(u.Path, "/")
+	switch u.Scheme {
+	case "s3":
+		s, err := NewS3(ctx, u.Host)
+		return s, prefix, err
+	case "gs":
+		g, err := NewGCS(ctx, u.Host)
+		return g, prefix, err
+	}
+	return nil, "", fmt.Errorf("bucket: unsupported scheme %q in %s (want s3 or gs)", u.Scheme, uri)
+}
+
+// Match reports whether key matches a glob pattern such as *.pdf or
+// reports/*/q?.pdf. Patterns without a slash are matched against the
+// last element of the key only.
+func Match(pattern, key string) bool {
+	if !strings.Contains(pattern, "/") {
+		key = path.Base(key)
+	}
+	ok, _ := path.Match(pattern, key)
+	return ok
+}
+
+func httpClient(c *http.Client) *http.Client {
+	if c != nil {
+		return c
+	}
+	return &http.Client{Timeout: 10 * time.Minute}
+}
+
+// statusError describes an S3 error response, including the message in
+// its XML body.
+func statusError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+	msg := strings.TrimSpace(string(body))
+	if i := strings.Index(msg, "<Message>"); i >= 0 {
+		msg = msg[i+len("<Message>"):]
+		msg, _, _ = strings.Cut(msg, "</Message>")
+	}
+	if msg == "" {
+		return fmt.Errorf("bucket: %s", resp.Status)
+	}
+	return fmt.Errorf("bucket: %s: %s", resp.Status, msg)
+}
@@ -0,0 +1,215 @@
+package bucket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// metadataTimeout bounds each request to an instance or container
+// metadata endpoint, which is not there outside the cloud.
+const metadataTimeout = 2 * time.Second
+
+// loadCredentials finds AWS credentials in the order of the SDKs' default
+// chain: the environment, the shared credentials and config files (keys
+// or credential_process), the container credentials endpoint of ECS and
+// EKS, and the EC2 instance metadata service. Profiles that assume a role
+// or sign in with SSO are not supported; their error says how to export
+// credentials for them instead.
+func loadCredentials(ctx context.Context, profile string) (Credentials, error) {
+	c := Credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if c.AccessKeyID != "" && c.SecretAccessKey != "" {
+		return c, nil
+	}
+	credsFile := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if credsFile == "" {
+		credsFile = awsFile("credentials")
+	}
+	configFile := os.Getenv("AWS_CONFIG_FILE")
+	if configFile == "" {
+		configFile = awsFile("config")
+	}
+	section := "profile " + profile
+	if profile == "default" {
+		section = "default"
+	}
+	for _, f := range []struct{ file, section string }{{credsFile, profile}, {configFile, section}} {
+		ini, err := readINI(f.file)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return c, fmt.Errorf("bucket: %w", err)
+		}
+		sec := ini[f.section]
+		c := Credentials{
+			AccessKeyID:     sec["aws_access_key_id"],
+			SecretAccessKey: sec["aws_secret_access_key"],
+			SessionToken:    sec["aws_session_token"],
+		}
+		switch {
+		case c.AccessKeyID != "" && c.SecretAccessKey != "":
+			return c, nil
+		case sec["credential_process"] != "":
+			return processCredentials(ctx, sec["credential_process"])
+		case sec["role_arn"] != "" || sec["sso_session"] != "" || sec["sso_start_url"] != "":
+			return c, fmt.Errorf("bucket: AWS profile %q assumes a role or uses SSO, which nlm does not support; export its credentials with: eval \"$(aws configure export-credentials --profile %s --format env)\"", profile, profile)
+		}
+	}
+	if c, ok, err := containerCredentials(ctx); ok || err != nil {
+		return c, err
+	}
+	if c, ok, err := instanceCredentials(ctx); ok || err != nil {
+		return c, err
+	}
+	return c, fmt.Errorf("bucket: no AWS credentials: set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, or add profile %q to %s", profile, credsFile)
+}
+
+// awsCredentials is the JSON form of credentials returned by
+// credential_process commands and the metadata endpoints.
+type awsCredentials struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	SessionToken    string `json:"SessionToken"`
+	Token           string `json:"Token"` // the metadata endpoints' name
+}
+
+func (a awsCredentials) credentials(from string) (Credentials, error) {
+	if a.AccessKeyID == "" || a.SecretAccessKey == "" {
+		return Credentials{}, fmt.Errorf("bucket: no AWS credentials in the response of %s", from)
+	}
+	token := a.SessionToken
+	if token == "" {
+		token = a.Token
+	}
+	return Credentials{AccessKeyID: a.AccessKeyID, SecretAccessKey: a.SecretAccessKey, SessionToken: token}, nil
+}
+
+// processCredentials runs the credential_process command of a profile.
+func processCredentials(ctx context.Context, command string) (Credentials, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return Credentials{}, fmt.Errorf("bucket: credential_process: %w", err)
+	}
+	var a awsCredentials
+	if err := json.Unmarshal(out, &a); err != nil {
+		return Credentials{}, fmt.Errorf("bucket: credential_process: %w", err)
+	}
+	return a.credentials("credential_process")
+}
+
+// containerCredentials fetches the credentials of an ECS task or EKS pod,
+// if the environment names the endpoint.
+func containerCredentials(ctx context.Context) (Credentials, bool, error) {
+	endpoint := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if rel := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); rel != "" {
+		endpoint = "http://169.254.170.2" + rel
+	}
+	if endpoint == "" {
+		return Credentials{}, false, nil
+	}
+	token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	if file := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); file != "" {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return Credentials{}, true, fmt.Errorf("bucket: %w", err)
+		}
+		token = strings.TrimSpace(string(b))
+	}
+	header := http.Header{}
+	if token != "" {
+		header.Set("Authorization", token)
+	}
+	body, err := metadataRequest(ctx, http.MethodGet, endpoint, header)
+	if err != nil {
+		return Credentials{}, true, fmt.Errorf("bucket: container credentials: %w", err)
+	}
+	var a awsCredentials
+	if err := json.Unmarshal(body, &a); err != nil {
+		return Credentials{}, true, fmt.Errorf("bucket: container credentials: %w", err)
+	}
+	c, err := a.credentials("the container credentials endpoint")
+	return c, true, err
+}
+
+// instanceCredentials fetches the credentials of the EC2 instance's role
+// with IMDSv2. It reports false, without an error, when there is no
+// metadata service, as off EC2.
+func instanceCredentials(ctx context.Context) (Credentials, bool, error) {
+	if strings.EqualFold(os.Getenv("AWS_EC2_METADATA_DISABLED"), "true") {
+		return Credentials{}, false, nil
+	}
+	endpoint := os.Getenv("AWS_EC2_METADATA_SERVICE_ENDPOINT")
+	if endpoint == "" {
+		endpoint = "http://169.254.169.254"
+	}
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	token, err := metadataRequest(ctx, http.MethodPut, endpoint+"/latest/api/token",
+		http.Header{"X-Aws-Ec2-Metadata-Token-Ttl-Seconds": {"21600"}})
+	if err != nil {
+		return Credentials{}, false, nil
+	}
+	header := http.Header{"X-Aws-Ec2-Metadata-Token": {string(token)}}
+	const path = "/latest/meta-data/iam/security-credentials/"
+	roles, err := metadataRequest(ctx, http.MethodGet, endpoint+path, header)
+	if err != nil {
+		return Credentials{}, true, fmt.Errorf("bucket: instance credentials: %w", err)
+	}
+	role, _, _ := strings.Cut(strings.TrimSpace(string(roles)), "\n")
+	if role == "" {
+		return Credentials{}, true, errors.New("bucket: instance credentials: the instance has no IAM role")
+	}
+	body, err := metadataRequest(ctx, http.MethodGet, endpoint+path+role, header)
+	if err != nil {
+		return Credentials{}, true, fmt.Errorf("bucket: instance credentials: %w", err)
+	}
+	var a awsCredentials
+	if err := json.Unmarshal(body, &a); err != nil {
+		return Credentials{}, true, fmt.Errorf("bucket: instance credentials: %w", err)
+	}
+	c, err := a.credentials("the instance metadata service")
+	return c, true, err
+}
+
+// metadataRequest sends a request to a metadata endpoint and returns the
+// body of a successful response.
+func metadataRequest(ctx context.Context, method, url string, header http.Header) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, metadataTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return body, nil
+}
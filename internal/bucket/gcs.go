@@ -0,0 +1,146 @@
+package bucket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultGCSURL is the Cloud Storage JSON API endpoint.
+const DefaultGCSURL = "https://storage.googleapis.com"
+
+// GCS is a Google Cloud Storage bucket.
+type GCS struct {
+	Bucket  string
+	BaseURL string // default DefaultGCSURL
+	Token   string // OAuth access token; empty for public buckets
+	HTTP    *http.Client
+}
+
+// NewGCS returns a bucket authorized with the access token in
+// GOOGLE_OAUTH_ACCESS_TOKEN or, failing that, one from Application
+// Default Credentials (see defaultToken) or, as a last resort, the one the
+// gcloud command prints for the signed-in account. Without any of them,
+// only public buckets can be read. STORAGE_EMULATOR_HOST sets the
+// endpoint, which is then used without credentials, as in the client
+// libraries.
+func NewGCS(ctx context.Context, bucket string) (*GCS, error) {
+	g := &GCS{Bucket: bucket, Token: os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")}
+	if host := os.Getenv("STORAGE_EMULATOR_HOST"); host != "" {
+		if !strings.Contains(host, "://") {
+			host = "http://" + host
+		}
+		g.BaseURL = host
+		return g, nil
+	}
+	if g.Token != "" {
+		return g, nil
+	}
+	token, err := defaultToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	g.Token = token
+	if g.Token == "" {
+		if _, err := exec.LookPath("gcloud"); err == nil {
+			out, err := exec.CommandContext(ctx, "gcloud", "auth", "print-access-token").Output()
+			if err != nil {
+				return nil, fmt.Errorf("bucket: gcloud auth print-access-token: %w (run gcloud auth application-default login, or set GOOGLE_OAUTH_ACCESS_TOKEN)", err)
+			}
+			g.Token = strings.TrimSpace(string(out))
+		}
+	}
+	return g, nil
+}
+
+// List returns the objects whose names start with prefix.
+func (g *GCS) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	token := ""
+	for {
+		q := url.Values{
+			"prefix": {prefix},
+			"fields": {"items(name,size,etag,updated),nextPageToken"},
+		}
+		if token != "" {
+			q.Set("pageToken", token)
+		}
+		resp, err := g.get(ctx, "/storage/v1/b/"+url.PathEscape(g.Bucket)+"/o?"+q.Encode())
+		if err != nil {
+			return nil, err
+		}
+		var r struct {
+			Items []struct {
+				Name    string    `json:"name"`
+				Size    string    `json:"size"` // int64 as a string
+				ETag    string    `json:"etag"`
+				Updated time.Time `json:"updated"`
+			} `json:"items"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&r)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("bucket: parse listing: %w", err)
+		}
+		for _, it := range r.Items {
+			if strings.HasSuffix(it.Name, "/") {
+				continue
+			}
+			size, _ := strconv.ParseInt(it.Size, 10, 64)
+			objects = append(objects, Object{Key: it.Name, Size: size, ETag: it.ETag, Modified: it.Updated})
+		}
+		if r.NextPageToken == "" {
+			return objects, nil
+		}
+		token = r.NextPageToken
+	}
+}
+
+// Open streams the content of an object.
+func (g *GCS) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := g.get(ctx, "/storage/v1/b/"+url.PathEscape(g.Bucket)+"/o/"+url.PathEscape(key)+"?alt=media")
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (g *GCS) get(ctx context.Context, p string) (*http.Response, error) {
+	base := g.BaseURL
+	if base == "" {
+		base = DefaultGCSURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(base, "/")+p, nil)
+	if err != nil {
+		return nil, fmt.Errorf("bucket: %w", err)
+	}
+	if g.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.Token)
+	}
+	resp, err := httpClient(g.HTTP).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("bucket: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		var e struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&e) == nil && e.Error.Message != "" {
+			return nil, fmt.Errorf("bucket: %s: %s", resp.Status, e.Error.Message)
+		}
+		return nil, fmt.Errorf("bucket: %s", resp.Status)
+	}
+	return resp, nil
+}
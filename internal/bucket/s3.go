@@ -0,0 +1,284 @@
+package bucket
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Credentials are AWS access keys.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// S3 is an Amazon S3 bucket, or a bucket of an S3-compatible service.
+type S3 struct {
+	Bucket string
+	Region string
+	// Endpoint is the service address for S3-compatible services, such as
+	// http://localhost:9000; buckets are then addressed by path.
+	Endpoint string
+	Creds    Credentials
+	HTTP     *http.Client
+
+	now func() time.Time // for tests
+}
+
+// NewS3 returns a bucket configured like the AWS SDKs: credentials from
+// the default chain (see loadCredentials), the region from AWS_REGION or
+// the shared config file, and the endpoint from AWS_ENDPOINT_URL_S3 or
+// AWS_ENDPOINT_URL. AWS_PROFILE selects the profile.
+func NewS3(ctx context.Context, bucket string) (*S3, error) {
+	profile := os.Getenv("AWS_PROFILE")
+	if profile == "" {
+		profile = "default"
+	}
+	creds, err := loadCredentials(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := os.Getenv("AWS_ENDPOINT_URL_S3")
+	if endpoint == "" {
+		endpoint = os.Getenv("AWS_ENDPOINT_URL")
+	}
+	return &S3{Bucket: bucket, Region: loadRegion(profile), Endpoint: endpoint, Creds: creds}, nil
+}
+
+func loadRegion(profile string) string {
+	for _, env := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if r := os.Getenv(env); r != "" {
+			return r
+		}
+	}
+	file := os.Getenv("AWS_CONFIG_FILE")
+	if file == "" {
+		file = awsFile("config")
+	}
+	ini, _ := readINI(file)
+	section := "profile " + profile
+	if profile == "default" {
+		section = "default"
+	}
+	if r := ini[section]["region"]; r != "" {
+		return r
+	}
+	return "us-east-1"
+}
+
+func awsFile(name string) string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".aws", name)
+}
+
+// readINI reads the sections of an AWS shared configuration file.
+func readINI(file string) (map[string]map[string]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	ini := make(map[string]map[string]string)
+	var sec map[string]string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case line == "" || line[0] == '#' || line[0] == ';':
+		case line[0] == '[' && strings.HasSuffix(line, "]"):
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if ini[name] == nil {
+				ini[name] = make(map[string]string)
+			}
+			sec = ini[name]
+		case sec != nil:
+			if k, v, ok := strings.Cut(line, "="); ok {
+				sec[strings.ToLower(strings.TrimSpace(k))] = strings.TrimSpace(v)
+			}
+		}
+	}
+	return ini, sc.Err()
+}
+
+// List returns the objects whose keys start with prefix.
+func (s *S3) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	token := ""
+	for {
+		q := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		resp, err := s.get(ctx, "", q)
+		if err != nil {
+			return nil, err
+		}
+		var r struct {
+			Contents []struct {
+				Key          string    `xml:"Key"`
+				Size         int64     `xml:"Size"`
+				ETag         string    `xml:"ETag"`
+				LastModified time.Time `xml:"LastModified"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&r)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("bucket: parse listing: %w", err)
+		}
+		for _, c := range r.Contents {
+			if strings.HasSuffix(c.Key, "/") { // folder placeholder
+				continue
+			}
+			objects = append(objects, Object{Key: c.Key, Size: c.Size, ETag: strings.Trim(c.ETag, `"`), Modified: c.LastModified})
+		}
+		if !r.IsTruncated || r.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = r.NextContinuationToken
+	}
+}
+
+// Open streams the content of an object.
+func (s *S3) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := s.get(ctx, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *S3) get(ctx context.Context, key string, q url.Values) (*http.Response, error) {
+	base, p := fmt.Sprintf("https://%s.s3.%s.amazonaws.com", s.Bucket, s.Region), "/"+key
+	if s.Endpoint != "" {
+		base, p = strings.TrimSuffix(s.Endpoint, "/"), "/"+s.Bucket+"/"+key
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base, nil)
+	if err != nil {
+		return nil, fmt.Errorf("bucket: %w", err)
+	}
+	// Keys may hold any character; escape them as the signature does.
+	req.URL.Path, req.URL.RawPath = p, uriEncode(p, false)
+	req.URL.RawQuery = canonicalQuery(q)
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	s.sign(req, now().UTC())
+	resp, err := httpClient(s.HTTP).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("bucket: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		return nil, statusError(resp)
+	}
+	return resp, nil
+}
+
+// emptyHash is the SHA-256 of an empty payload.
+const emptyHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// sign adds an AWS Signature Version 4 Authorization header to a request
+// without a body. The host, Range and x-amz-* headers are signed.
+func (s *S3) sign(req *http.Request, t time.Time) {
+	amzDate := t.Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", emptyHash)
+	if s.Creds.SessionToken != "" {
+		req.Header.Set("x-amz-security-token", s.Creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		if lk := strings.ToLower(k); lk == "range" || strings.HasPrefix(lk, "x-amz-") {
+			headers[lk] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonHeaders strings.Builder
+	for _, k := range names {
+		canonHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signed := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonHeaders.String(),
+		signed,
+		emptyHash,
+	}, "\n")
+	scope := date + "/" + s.Region + "/s3/aws4_request"
+	sum := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(sum[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.Creds.SecretAccessKey), date)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	sig := hex.EncodeToString(hmacSHA256(key, toSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.Creds.AccessKeyID, scope, signed, sig))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// canonicalQuery encodes a query string the way Signature Version 4
+// requires: sorted by name, with every reserved character escaped.
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		for _, v := range q[k] {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes all but unreserved characters and, unless
+// encodeSlash is set, slashes.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,143 @@
+// Package citegraph builds the graph of which sources are cited by which
+// notes and answers in a notebook.
+package citegraph
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Node kinds.
+const (
+	KindSource = "source"
+	KindNote   = "note"
+	KindAnswer = "answer"
+)
+
+// Node is a source, note or answer.
+type Node struct {
+	ID    string `json:"id"`
+	Kind  string `json:"kind"`
+	Label string `json:"label"`
+	// Cited counts the documents citing a source.
+	Cited int `json:"cited,omitempty"`
+}
+
+// Edge records that From (a note or answer) cites To (a source) Weight
+// times.
+type Edge struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Weight int    `json:"weight"`
+}
+
+// Graph is a citation graph.
+type Graph struct {
+	Notebook string `json:"notebook"`
+	Nodes    []Node `json:"nodes"`
+	Edges    []Edge `json:"edges"`
+}
+
+// Document is a note or answer whose payload may reference sources.
+type Document struct {
+	ID    string
+	Kind  string
+	Title string
+	// Raw is the document payload. Citations are found by looking for
+	// source IDs anywhere in it, since NotebookLM stores them positionally.
+	Raw []byte
+}
+
+// Build returns the graph of documents citing sources. sources are
+// source nodes; their Cited counts are filled in.
+//
+// A note that cites sources becomes an answer node: notes written in
+// NotebookLM cannot carry citations, so one that does is a chat answer
+// saved to the notebook.
+func Build(notebook string, sources []Node, docs []Document) *Graph {
+	g := &Graph{Notebook: notebook}
+	index := make(map[string]int, len(sources))
+	for _, s := range sources {
+		s.Kind = KindSource
+		s.Cited = 0
+		index[s.ID] = len(g.Nodes)
+		g.Nodes = append(g.Nodes, s)
+	}
+	for _, d := range docs {
+		node := len(g.Nodes)
+		g.Nodes = append(g.Nodes, Node{ID: d.ID, Kind: d.Kind, Label: d.Title})
+		for _, s := range sources {
+			n := bytes.Count(d.Raw, []byte(s.ID))
+			if n == 0 || s.ID == d.ID {
+				continue
+			}
+			g.Edges = append(g.Edges, Edge{From: d.ID, To: s.ID, Weight: n})
+			g.Nodes[index[s.ID]].Cited++
+			if d.Kind == KindNote {
+				g.Nodes[node].Kind = KindAnswer
+			}
+		}
+	}
+	return g
+}
+
+// Uncited returns the sources no document cites.
+func (g *Graph) Uncited() []Node {
+	var out []Node
+	for _, n := range g.Nodes {
+		if n.Kind == KindSource && n.Cited == 0 {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// WriteJSON writes g as indented JSON.
+func (g *Graph) WriteJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// WriteDOT writes g in Graphviz DOT format. Sources are boxes, drawn
+// dashed and grey when uncited; edge widths follow citation counts.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph citations {\n")
+	fmt.Fprintf(&b, "\tlabel=%s;\n", quote(g.Notebook))
+	fmt.Fprintf(&b, "\trankdir=LR;\n")
+	fmt.Fprintf(&b, "\tnode [fontname=\"Helvetica\"];\n")
+	for _, n := range g.Nodes {
+		attrs := []string{"label=" + quote(n.Label)}
+		switch n.Kind {
+		case KindSource:
+			attrs = append(attrs, "shape=box")
+			if n.Cited == 0 {
+				attrs = append(attrs, "style=dashed", "color=gray", "fontcolor=gray")
+			}
+		case KindAnswer:
+			attrs = append(attrs, "shape=note")
+		default:
+			attrs = append(attrs, "shape=ellipse")
+		}
+		fmt.Fprintf(&b, "\t%s [%s];\n", quote(n.ID), strings.Join(attrs, ", "))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "\t%s -> %s [penwidth=%d];\n", quote(e.From), quote(e.To), min(e.Weight, 5))
+	}
+	fmt.Fprintf(&b, "}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// quote returns s as a DOT double-quoted string.
+func quote(s string) string {
+	return strconv.Quote(s)
+}
@@ -0,0 +1,64 @@
+package citegraph
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBuild(t *testing.T) {
+	sources := []Node{
+		{ID: "s1", Label: "Paper A"},
+		{ID: "s2", Label: "Paper \"B\""},
+		{ID: "s3", Label: "Unused"},
+	}
+	docs := []Document{
+		{ID: "n1", Kind: KindNote, Title: "Summary", Raw: []byte(`[["n1"],"Summary",[[["s1"]],[["s1"]],[["s2"]]]]`)},
+		{ID: "n2", Kind: KindNote, Title: "Plain", Raw: []byte(`[["n2"],"Plain","no citations"]`)},
+	}
+	g := Build("My notebook", sources, docs)
+
+	wantEdges := []Edge{
+		{From: "n1", To: "s1", Weight: 2},
+		{From: "n1", To: "s2", Weight: 1},
+	}
+	if diff := cmp.Diff(wantEdges, g.Edges); diff != "" {
+		t.Errorf("edges mismatch (-want +got):\n%s", diff)
+	}
+	var uncited []string
+	for _, n := range g.Uncited() {
+		uncited = append(uncited, n.ID)
+	}
+	if diff := cmp.Diff([]string{"s3"}, uncited); diff != "" {
+		t.Errorf("uncited mismatch (-want +got):\n%s", diff)
+	}
+
+	kinds := make(map[string]string)
+	for _, n := range g.Nodes {
+		kinds[n.ID] = n.Kind
+	}
+	wantKinds := map[string]string{"s1": KindSource, "s2": KindSource, "s3": KindSource, "n1": KindAnswer, "n2": KindNote}
+	if diff := cmp.Diff(wantKinds, kinds); diff != "" {
+		t.Errorf("kinds mismatch (-want +got):\n%s", diff)
+	}
+
+	var buf bytes.Buffer
+	if err := g.WriteDOT(&buf); err != nil {
+		t.Fatal(err)
+	}
+	dot := buf.String()
+	for _, want := range []string{
+		`digraph citations {`,
+		`"s2" [label="Paper \"B\"", shape=box];`,
+		`"s3" [label="Unused", shape=box, style=dashed, color=gray, fontcolor=gray];`,
+		`"n1" -> "s1" [penwidth=2];`,
+		`"n1" [label="Summary", shape=note];`,
+		`"n2" [label="Plain", shape=ellipse];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT output missing %q:\n%s", want, dot)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Package cleanup creates temporary files and directories that are removed
+// when nlm exits, even when it is interrupted or panics, so aborted
+// commands do not leave partial artifacts behind.
+//
+// Commands that stop cleanly on an interrupt take their context from
+// NotifyContext; while one is active, the first SIGINT or SIGTERM only
+// cancels it. Otherwise, and on a second signal, the registered paths are
+// removed and the process exits.
+package cleanup
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+var (
+	mu       sync.Mutex
+	paths    = make(map[string]bool)
+	graceful int
+	handle   sync.Once
+
+	// exit is replaced in tests.
+	exit = os.Exit
+)
+
+// CreateTemp is os.CreateTemp, registering the file for removal.
+func CreateTemp(dir, pattern string) (*os.File, error) {
+	f, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return nil, err
+	}
+	Register(f.Name())
+	return f, nil
+}
+
+// MkdirTemp is os.MkdirTemp, registering the directory for removal.
+func MkdirTemp(dir, pattern string) (string, error) {
+	dir, err := os.MkdirTemp(dir, pattern)
+	if err != nil {
+		return "", err
+	}
+	Register(dir)
+	return dir, nil
+}
+
+// Register adds path to the paths removed by Run.
+func Register(path string) {
+	mu.Lock()
+	paths[path] = true
+	mu.Unlock()
+}
+
+// Forget takes path off the list, for example once a temporary file has
+// been renamed into place.
+func Forget(path string) {
+	mu.Lock()
+	delete(paths, path)
+	mu.Unlock()
+}
+
+// Remove removes path and everything below it, and forgets it.
+func Remove(path string) error {
+	Forget(path)
+	return os.RemoveAll(path)
+}
+
+// Run removes all registered paths.
+func Run() {
+	mu.Lock()
+	ps := paths
+	paths = make(map[string]bool)
+	mu.Unlock()
+	for p := range ps {
+		os.RemoveAll(p)
+	}
+}
+
+// OnPanic runs the cleanup if the calling goroutine is panicking, then
+// continues the panic. It must be deferred directly:
+//
+//	defer cleanup.OnPanic()
+func OnPanic() {
+	if r := recover(); r != nil {
+		Run()
+		panic(r)
+	}
+}
+
+// HandleSignals installs the SIGINT and SIGTERM handler. Calling it more
+// than once has no effect.
+func HandleSignals() {
+	handle.Do(func() {
+		ch := make(chan os.Signal, 2)
+		signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			for n := 1; ; n++ {
+				<-ch
+				mu.Lock()
+				g := graceful
+				mu.Unlock()
+				if g > 0 && n == 1 {
+					continue // the command stops on its own
+				}
+				Run()
+				exit(130)
+			}
+		}()
+	})
+}
+
+// NotifyContext returns a context canceled by the first SIGINT or SIGTERM.
+// Until stop is called, that signal is left to the caller to handle.
+func NotifyContext(parent context.Context) (ctx context.Context, stop context.CancelFunc) {
+	ctx, cancel := signal.NotifyContext(parent, os.Interrupt, syscall.SIGTERM)
+	mu.Lock()
+	graceful++
+	mu.Unlock()
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			mu.Lock()
+			graceful--
+			mu.Unlock()
+		})
+		cancel()
+	}
+}
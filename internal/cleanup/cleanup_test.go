@@ -0,0 +1,61 @@
+package cleanup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestRun(t *testing.T) {
+	base := t.TempDir()
+	f, err := CreateTemp(base, "partial-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	dir, err := MkdirTemp(base, "staging-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0o644)
+	kept, err := CreateTemp(base, "renamed-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept.Close()
+	Forget(kept.Name())
+
+	Run()
+	for _, p := range []string{f.Name(), dir} {
+		if exists(p) {
+			t.Errorf("%s not removed", p)
+		}
+	}
+	if !exists(kept.Name()) {
+		t.Errorf("forgotten %s was removed", kept.Name())
+	}
+}
+
+func TestOnPanic(t *testing.T) {
+	dir, err := MkdirTemp(t.TempDir(), "staging-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("recovered %v, want the original panic", r)
+			}
+		}()
+		defer OnPanic()
+		panic("boom")
+	}()
+	if exists(dir) {
+		t.Error("directory not removed after panic")
+	}
+}
@@ -0,0 +1,52 @@
+//go:build !windows
+
+package cleanup
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSignals(t *testing.T) {
+	exited := make(chan int, 1)
+	exit = func(code int) { exited <- code }
+	defer func() { exit = os.Exit }()
+	HandleSignals()
+
+	dir, err := MkdirTemp(t.TempDir(), "staging-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, stop := NotifyContext(context.Background())
+	syscall.Kill(os.Getpid(), syscall.SIGINT)
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("context not canceled by SIGINT")
+	}
+	select {
+	case code := <-exited:
+		t.Fatalf("exited with %d while a command handles the signal", code)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if !exists(dir) {
+		t.Fatal("directory removed while a command handles the signal")
+	}
+	stop()
+
+	syscall.Kill(os.Getpid(), syscall.SIGINT)
+	select {
+	case code := <-exited:
+		if code != 130 {
+			t.Errorf("exit code %d, want 130", code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no exit after second SIGINT")
+	}
+	if exists(dir) {
+		t.Error("directory not removed on SIGINT")
+	}
+}
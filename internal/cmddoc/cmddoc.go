@@ -0,0 +1,270 @@
+// Package cmddoc describes nlm's commands and renders the description as
+// the short usage summary, long-form help for a topic, and a man page, so
+// that the three cannot drift apart.
+package cmddoc
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Command documents one form of a command. A command with several forms,
+// such as "share bulk" and "share report", has an entry for each.
+type Command struct {
+	// Name is the command as typed, including any subcommand.
+	Name string
+	// Aliases are other names of the command, such as "ls" for "list".
+	Aliases []string
+	// Args is the argument synopsis, such as "<id> [-o dir]".
+	Args string
+	// Summary is a one-line description.
+	Summary string
+	// Group is the heading the command is listed under.
+	Group string
+	// Description is the long-form help, in paragraphs separated by
+	// blank lines. It may be empty.
+	Description string
+	Examples    []Example
+}
+
+// Example is a command line with a comment saying what it does.
+type Example struct {
+	Comment string
+	Command string
+}
+
+// Topic is a help topic that is not a command, such as "environment".
+type Topic struct {
+	Name    string
+	Summary string
+	Text    string
+}
+
+// Flag documents a global flag for the man page.
+type Flag struct {
+	Name    string
+	Usage   string
+	Default string
+}
+
+// synopsis returns the name, aliases and arguments as typed.
+func (c *Command) synopsis() string {
+	s := strings.Join(append([]string{c.Name}, c.Aliases...), ", ")
+	if c.Args != "" {
+		s += " " + c.Args
+	}
+	return s
+}
+
+// topic returns the help topic of c: its first word.
+func (c *Command) topic() string {
+	name, _, _ := strings.Cut(c.Name, " ")
+	return name
+}
+
+// WriteUsage writes the summary of all commands by group.
+func WriteUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: nlm <command> [arguments]\n")
+	for _, g := range Groups {
+		fmt.Fprintf(w, "\n%s:\n", g)
+		for _, c := range Commands {
+			if c.Group != g {
+				continue
+			}
+			writeItem(w, c.synopsis(), c.Summary)
+		}
+	}
+	fmt.Fprintf(w, "\nRun 'nlm help <command>' for details and examples, or 'nlm help topics' for other topics.\n")
+}
+
+// writeItem writes a line of a list, aligning short names.
+func writeItem(w io.Writer, name, summary string) {
+	if len(name) < 18 {
+		fmt.Fprintf(w, "  %-17s %s\n", name, summary)
+		return
+	}
+	fmt.Fprintf(w, "  %s  %s\n", name, summary)
+}
+
+// Lookup returns the commands documented under name, which may be a
+// command, a subcommand such as "share bulk", or an alias.
+func Lookup(name string) []Command {
+	var cs []Command
+	for _, c := range Commands {
+		match := c.Name == name || c.topic() == name
+		for _, a := range c.Aliases {
+			match = match || a == name
+		}
+		if match {
+			cs = append(cs, c)
+		}
+	}
+	return cs
+}
+
+// WriteHelp writes the long-form help of a command or topic.
+func WriteHelp(w io.Writer, name string) error {
+	if name == "topics" {
+		fmt.Fprintf(w, "Help topics:\n")
+		for _, t := range Topics {
+			writeItem(w, t.Name, t.Summary)
+		}
+		fmt.Fprintf(w, "\nEvery command is also a topic; 'nlm help' lists them.\n")
+		return nil
+	}
+	for _, t := range Topics {
+		if t.Name == name {
+			fmt.Fprintf(w, "%s\n\n%s\n", t.Summary, indent(t.Text))
+			return nil
+		}
+	}
+	cs := Lookup(name)
+	if len(cs) == 0 {
+		return fmt.Errorf("unknown help topic %q; run nlm help for the commands or nlm help topics", name)
+	}
+	for i, c := range cs {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "usage: nlm %s\n\n", c.synopsis())
+		fmt.Fprintf(w, "%s\n", indent(c.Summary+"."))
+		if c.Description != "" {
+			fmt.Fprintf(w, "\n%s\n", indent(c.Description))
+		}
+		if len(c.Examples) > 0 {
+			fmt.Fprintf(w, "\nExamples:\n")
+			for _, e := range c.Examples {
+				fmt.Fprintf(w, "    # %s\n    %s\n", e.Comment, e.Command)
+			}
+		}
+	}
+	return nil
+}
+
+// indent indents the lines of s by four spaces, leaving blank lines empty.
+func indent(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	for i, l := range lines {
+		if l != "" {
+			lines[i] = "    " + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Man describes the man page being written.
+type Man struct {
+	Version string
+	// Date is shown in the footer, for example "October 2026".
+	Date  string
+	Flags []Flag
+}
+
+// WriteMan writes nlm(1) in roff.
+func WriteMan(w io.Writer, m Man) {
+	fmt.Fprintf(w, ".TH NLM 1 %q %q \"User Commands\"\n", m.Date, "nlm "+m.Version)
+	fmt.Fprintf(w, ".SH NAME\nnlm \\- command-line interface for Google NotebookLM\n")
+	fmt.Fprintf(w, ".SH SYNOPSIS\n.B nlm\n[\\fIflags\\fR] \\fIcommand\\fR [\\fIarguments\\fR]\n")
+	fmt.Fprintf(w, ".SH DESCRIPTION\n%s\n", roff(Overview))
+	fmt.Fprintf(w, ".SH COMMANDS\n")
+	for _, g := range Groups {
+		fmt.Fprintf(w, ".SS %s\n", roff(g))
+		for _, c := range Commands {
+			if c.Group != g {
+				continue
+			}
+			fmt.Fprintf(w, ".TP\n.B %s\n%s.\n", roff(c.synopsis()), roff(c.Summary))
+			for _, p := range paragraphs(c.Description) {
+				fmt.Fprintf(w, ".IP\n%s\n", roff(p))
+			}
+			for _, e := range c.Examples {
+				fmt.Fprintf(w, ".IP\n.nf\n# %s\n%s\n.fi\n", roff(e.Comment), roff(e.Command))
+			}
+		}
+	}
+	if len(m.Flags) > 0 {
+		flags := append([]Flag(nil), m.Flags...)
+		sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+		fmt.Fprintf(w, ".SH OPTIONS\n")
+		for _, f := range flags {
+			usage := f.Usage
+			if f.Default != "" && f.Default != "false" {
+				usage += fmt.Sprintf(" (default %s)", f.Default)
+			}
+			fmt.Fprintf(w, ".TP\n.B \\-%s\n%s\n", roff(f.Name), roff(usage))
+		}
+	}
+	for _, t := range Topics {
+		fmt.Fprintf(w, ".SH %s\n", strings.ToUpper(roff(t.Name)))
+		for i, p := range paragraphs(t.Text) {
+			if i > 0 {
+				fmt.Fprintf(w, ".PP\n")
+			}
+			fmt.Fprintf(w, "%s\n", roff(p))
+		}
+	}
+	fmt.Fprintf(w, ".SH SEE ALSO\nhttps://github.com/tmc/nlm\n")
+}
+
+// paragraphs splits s at blank lines.
+func paragraphs(s string) []string {
+	var ps []string
+	for _, p := range strings.Split(strings.TrimSpace(s), "\n\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			ps = append(ps, p)
+		}
+	}
+	return ps
+}
+
+// roff escapes s for use as roff text.
+func roff(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	s = strings.ReplaceAll(s, "-", `\-`)
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if strings.HasPrefix(l, ".") || strings.HasPrefix(l, "'") {
+			lines[i] = `\&` + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Invocation returns the shortest command line of c: its name and required
+// arguments, without optional ones.
+func (c *Command) Invocation() string {
+	var b strings.Builder
+	depth := 0
+	for _, r := range c.Args {
+		switch {
+		case r == '[':
+			depth++
+		case r == ']':
+			depth--
+		case depth == 0:
+			b.WriteRune(r)
+		}
+	}
+	args := strings.Join(strings.Fields(strings.ReplaceAll(b.String(), "...", "")), " ")
+	return strings.TrimSpace("nlm " + c.Name + " " + args)
+}
+
+var placeholderRe = regexp.MustCompile(`<[a-z-]+>`)
+
+// Fill replaces the placeholders of an example command line, such as
+// <id>, with values. It reports whether every placeholder had a value.
+func Fill(cmd string, values map[string]string) (string, bool) {
+	ok := true
+	cmd = placeholderRe.ReplaceAllStringFunc(cmd, func(p string) string {
+		v, found := values[p]
+		if !found || v == "" {
+			ok = false
+			return p
+		}
+		return v
+	})
+	return cmd, ok
+}
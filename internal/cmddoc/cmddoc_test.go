@@ -0,0 +1,117 @@
+package cmddoc
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCommandsAreGrouped(t *testing.T) {
+	groups := make(map[string]bool)
+	for _, g := range Groups {
+		groups[g] = true
+	}
+	for _, c := range Commands {
+		if !groups[c.Group] {
+			t.Errorf("%s: unknown group %q", c.Name, c.Group)
+		}
+		if c.Summary == "" || strings.HasSuffix(c.Summary, ".") {
+			t.Errorf("%s: summary %q should be a phrase without a final period", c.Name, c.Summary)
+		}
+	}
+}
+
+func TestWriteUsage(t *testing.T) {
+	var buf bytes.Buffer
+	WriteUsage(&buf)
+	for _, want := range []string{
+		"\nNotebook Commands:\n  list, ls [-ids]   List all notebooks\n",
+		"  sources <id> [-ids]  List sources in notebook\n",
+		"\nOther Commands:\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("usage lacks %q", want)
+		}
+	}
+}
+
+func TestWriteHelp(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteHelp(&buf, "ls"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "usage: nlm list, ls [-ids]\n") || !strings.Contains(buf.String(), "Examples:\n") {
+		t.Errorf("help for ls:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteHelp(&buf, "share"); err != nil {
+		t.Fatal(err)
+	}
+	for _, sub := range []string{"share bulk", "share report", "share enforce"} {
+		if !strings.Contains(buf.String(), "usage: nlm "+sub) {
+			t.Errorf("help for share lacks %s", sub)
+		}
+	}
+
+	if err := WriteHelp(&buf, "environment"); err != nil {
+		t.Error(err)
+	}
+	if err := WriteHelp(&buf, "no-such-command"); err == nil {
+		t.Error("help for an unknown topic: want error")
+	}
+}
+
+func TestWriteMan(t *testing.T) {
+	var buf bytes.Buffer
+	WriteMan(&buf, Man{
+		Version: "v1.2.3",
+		Date:    "October 2026",
+		Flags:   []Flag{{Name: "json", Usage: "print JSON"}, {Name: "workers", Usage: "with batch, workers", Default: "4"}},
+	})
+	man := buf.String()
+	for _, want := range []string{
+		".TH NLM 1 \"October 2026\" \"nlm v1.2.3\"",
+		".SS Source Commands\n",
+		".B rm\\-source [\\-y] <id> <source\\-id>...\n",
+		".B \\-workers\nwith batch, workers (default 4)\n",
+		".SH ENVIRONMENT\n",
+	} {
+		if !strings.Contains(man, want) {
+			t.Errorf("man page lacks %q", want)
+		}
+	}
+	for _, line := range strings.Split(man, "\n") {
+		if strings.HasPrefix(line, ".") && !strings.HasPrefix(line, ".TH") && !strings.HasPrefix(line, ".S") &&
+			!strings.HasPrefix(line, ".TP") && !strings.HasPrefix(line, ".B ") && !strings.HasPrefix(line, ".IP") &&
+			!strings.HasPrefix(line, ".PP") && line != ".nf" && line != ".fi" {
+			t.Errorf("unexpected request line %q", line)
+		}
+	}
+}
+
+func TestInvocation(t *testing.T) {
+	for _, tt := range []struct {
+		c    Command
+		want string
+	}{
+		{Command{Name: "list", Args: "[-ids]"}, "nlm list"},
+		{Command{Name: "rm-source", Args: "<id> <source-id>..."}, "nlm rm-source <id> <source-id>"},
+		{Command{Name: "export", Args: "<id> [-o dir [-zip]]"}, "nlm export <id>"},
+		{Command{Name: "hb"}, "nlm hb"},
+	} {
+		if got := tt.c.Invocation(); got != tt.want {
+			t.Errorf("Invocation(%q) = %q, want %q", tt.c.Args, got, tt.want)
+		}
+	}
+}
+
+func TestFill(t *testing.T) {
+	values := map[string]string{"<id>": "nb1", "<source-id>": ""}
+	if got, ok := Fill("nlm sources <id>", values); got != "nlm sources nb1" || !ok {
+		t.Errorf("Fill = %q, %v", got, ok)
+	}
+	if got, ok := Fill("nlm rm-source <id> <source-id>", values); got != "nlm rm-source nb1 <source-id>" || ok {
+		t.Errorf("Fill with a missing value = %q, %v", got, ok)
+	}
+}
@@ -0,0 +1,781 @@
+package cmddoc
+
+// Overview introduces nlm at the top of the man page.
+const Overview = `nlm manages Google NotebookLM notebooks, sources, notes and audio
+overviews from the command line. It signs in with the cookies of a browser
+profile (see nlm auth) and talks to the same API as the web application.`
+
+// Groups are the command groups, in the order they are listed.
+var Groups = []string{
+	"Notebook Commands",
+	"Source Commands",
+	"Note Commands",
+	"Audio Commands",
+	"Generation Commands",
+	"Export Commands",
+	"Other Commands",
+}
+
+// Commands documents every command, in the order they are listed.
+var Commands = []Command{
+	{
+		Name: "list", Aliases: []string{"ls"}, Args: "[-ids]",
+		Summary: "List all notebooks",
+		Group:   "Notebook Commands",
+		Description: `Lists your notebooks, most recently viewed first, with their IDs,
+titles and source counts. With -ids only the IDs are printed, one per line,
+for use with xargs. Listings are cached briefly; commands that change a
+notebook refresh the cache.
+
+-sort title, created, modified or sources orders the listing, and -filter
+narrows it as described in nlm help sources. -columns picks the columns,
+from id, title, emoji, sources, created, modified and pinned; with -json
+they are the keys of the objects printed. -output csv prints the table as
+CSV.
+
+Notebooks pinned with nlm pin come first, marked with a pin, whatever the
+sort; -pinned lists only them.
+
+-since and -modified-within leave out notebooks last modified before a
+date or longer ago than a duration, going by the modification times
+NotebookLM reports. Unlike with changes, -since only filters when given.`,
+		Examples: []Example{
+			{"Print the IDs of all notebooks", "nlm ls -ids"},
+			{"Back up the notebooks changed this week", "nlm -modified-within 7d ls -ids | xargs nlm backup"},
+			{"List notebooks as JSON", "nlm -json ls"},
+		},
+	},
+	{
+		Name: "create", Args: "<title>",
+		Summary: "Create a new notebook",
+		Group:   "Notebook Commands",
+		Examples: []Example{
+			{"Create a notebook and keep its ID", `nb=$(nlm create "Reading group")`},
+		},
+	},
+	{
+		Name: "rm", Args: "<id>",
+		Summary: "Delete a notebook",
+		Group:   "Notebook Commands",
+		Description: `Asks for confirmation unless -y is given. Deleting a notebook deletes
+its sources, notes and audio overview. With -trash, a copy of the
+notebook is kept first; see nlm trash.`,
+	},
+	{
+		Name: "pin", Args: "<id>...",
+		Summary: "Pin notebooks to the top of listings",
+		Group:   "Notebook Commands",
+		Description: `NotebookLM has no pinned or favorite notebooks, so pins are kept on
+this machine, in ~/.nlm/pins.json, and only affect nlm's listings.`,
+		Examples: []Example{
+			{"Pin a notebook and list the pinned ones", "nlm pin <id> && nlm -pinned ls"},
+		},
+	},
+	{
+		Name: "unpin", Args: "<id>...",
+		Summary: "Unpin notebooks",
+		Group:   "Notebook Commands",
+		Description: `Any ID can be unpinned, including that of a notebook that has since been
+deleted.`,
+	},
+	{
+		Name: "analytics", Args: "<id>",
+		Summary: "Show notebook analytics",
+		Group:   "Notebook Commands",
+	},
+	{
+		Name: "notebooks settings get", Args: "<id>",
+		Summary: "Show chat, language and sharing settings",
+		Group:   "Notebook Commands",
+		Description: `Shows chat.goal, chat.prompt, chat.length, language and sharing.link.
+Where NotebookLM keeps these is not confirmed, so nlm only reads them;
+change them in the web app.`,
+	},
+	{
+		Name: "notebooks defaults", Args: "<id> [<flag>=<value>...]",
+		Summary: "Show or change the flags remembered for a notebook",
+		Group:   "Notebook Commands",
+		Description: `Commands on a notebook use the -o, -sort, -columns, -output and
+-template remembered for it when those flags are not given; they win over
+NLM_OUTPUT and the global configuration. Flags are remembered with
+flag=value, forgotten with flag=, or saved from any command on the
+notebook with -remember. They are kept on this machine, in
+~/.nlm/notebook-defaults.json. Chat and source settings are kept by
+NotebookLM itself; see nlm notebooks settings and nlm sources enable.
+
+-debug shows the remembered flags a command uses.`,
+		Examples: []Example{
+			{"Export a notebook to the same directory every time", "nlm notebooks defaults <id> o=exports/reading"},
+			{"Keep sorting a notebook's notes by title", "nlm -remember -sort title notes <id>"},
+		},
+	},
+	{
+		Name: "share bulk", Args: "<id> -csv <file>",
+		Summary: "Share with everyone in a CSV roster",
+		Group:   "Notebook Commands",
+		Description: `The CSV has an email column and, optionally, a role column (viewer or
+editor). With -notify=false collaborators are not emailed. Requires
+-experimental: the sharing call and its role numbers are unverified.`,
+	},
+	{
+		Name: "share report", Args: "<id>... | -all [-domain d]",
+		Summary: "Review collaborators and public links",
+		Group:   "Notebook Commands",
+		Examples: []Example{
+			{"Save the review of every notebook for a spreadsheet", "nlm share report -all -output csv > sharing.csv"},
+		},
+	},
+	{
+		Name: "share enforce", Args: "<id>... | -all [-domain d] [-no-public] [-apply]",
+		Summary: "Revoke access that breaks the policy",
+		Group:   "Notebook Commands",
+		Description: `Without -apply, only prints what would be revoked: collaborators
+outside -domain and, with -no-public, public links. -apply requires
+-experimental, as the calls that revoke access are unverified.`,
+	},
+	{
+		Name: "changes", Args: "<id> [-since 7d] [-json]",
+		Summary: "List sources and notes changed recently",
+		Group:   "Notebook Commands",
+		Description: `Lists each source and note whose last change falls after -since, going
+by the modification times NotebookLM reports. It is not an audit log:
+NotebookLM does not say who changed an item, and removed items are not
+listed.`,
+	},
+	{
+		Name: "run", Args: "<workflow.yaml>",
+		Summary: "Run the steps of a workflow file",
+		Group:   "Notebook Commands",
+		Description: `Steps run in dependency order with retries, and their outputs can be
+used by later steps. See scripts/workflow.yaml for an example.`,
+	},
+	{
+		Name: "batch", Args: "- [-workers n]",
+		Summary: "Run JSONL operations from stdin, writing JSONL results",
+		Group:   "Notebook Commands",
+		Description: `Each input line is an object with op, notebook, args and an optional
+id. Results are written to stdout as operations finish, one JSON line
+each, with ok and either output or error. If Google asks for consent or
+to verify it's you, the batch pauses until you confirm on the terminal
+that the page has been dealt with.`,
+		Examples: []Example{
+			{"List the sources of two notebooks", `printf '{"op":"sources","notebook":"nb1"}\n{"op":"sources","notebook":"nb2"}\n' | nlm batch -`},
+		},
+	},
+	{
+		Name: "jobs", Args: "[status|wait <job-id>]",
+		Summary: "List or follow commands started with -async",
+		Group:   "Notebook Commands",
+		Examples: []Example{
+			{"Create an audio overview in the background and wait for it", `job=$(nlm -async audio-create -wait <id> "focus on the methods") && nlm jobs wait $job`},
+		},
+	},
+	{
+		Name: "jobs resume", Args: "[job-id...]",
+		Summary: "Restart lost jobs, re-attaching to submitted audio overviews",
+		Group:   "Notebook Commands",
+		Description: `Restarts the lost jobs, or the lost or failed jobs given, that can run
+again without repeating changes: sync, export, backup, bucket imports,
+guide generation and submitted audio overviews. Other jobs are left for
+you to check and rerun.`,
+	},
+
+	{
+		Name: "sources", Args: "<id> [-ids]",
+		Summary: "List sources in notebook",
+		Group:   "Source Commands",
+		Description: `Lists the sources of a notebook in the order NotebookLM returns them.
+-sort title or -sort modified reorders them, and -filter, which may be
+repeated, keeps those whose id, title or type match: type=SOURCE_TYPE_PDF
+exactly, or title~=regexp as a regular expression; != and !~= invert the
+match. -columns picks from id, title, type, status and modified.`,
+		Examples: []Example{
+			{"List the PDFs, most recently changed first", "nlm -sort modified -filter type=SOURCE_TYPE_PDF sources <id>"},
+			{"Print only the titles and types", "nlm -columns title,type sources <id>"},
+		},
+	},
+	{
+		Name: "sources check-links", Args: "<id> [-refresh] [-mark-dead]",
+		Summary: "Report dead and redirected source URLs",
+		Group:   "Source Commands",
+	},
+	{
+		Name: "sources enable|disable", Args: "<id> <source-id>...",
+		Summary: "Include or leave out sources in chat and generation",
+		Group:   "Source Commands",
+	},
+	{
+		Name: "sources reprocess", Args: "<id> <source-id>...",
+		Summary: "Sync Google Drive sources with their documents",
+		Group:   "Source Commands",
+		Description: `Asks NotebookLM to sync Google Docs, Slides and Sheets sources with their
+documents in Drive, as refresh-source does for one, so that they are
+processed again from the current version. Other sources, such as PDFs
+and web pages, are refused before anything is synced, since the call
+does not regenerate their summaries. Processing continues after the
+command returns; the status column of nlm sources shows when it is done.
+Sources that fail to sync are reported and the others still are.`,
+		Examples: []Example{
+			{"Sync every Google Doc of a notebook", "nlm sources reprocess <id> $(nlm -filter type=SOURCE_TYPE_GOOGLE_DOCS sources <id> -ids)"},
+		},
+	},
+	{
+		Name: "add", Args: "<id> <input>... [-split-by-language] [-ipynb-outputs] [-table-rows n] [-keep-timestamps] [-ocr] [-transcribe-locally]",
+		Summary: "Add sources to notebook",
+		Group:   "Source Commands",
+		Description: `Each input is a URL, a file, - for stdin, or text. Files are converted
+and filtered as configured in .nlm.yaml before upload; nlm filter shows
+the result. Binary files such as PDFs and audio are uploaded as they are.`,
+		Examples: []Example{
+			{"Add a web page and a PDF", "nlm add <id> https://example.com/post paper.pdf"},
+			{"Add text from another command", "pbpaste | nlm add <id> -"},
+		},
+	},
+	{
+		Name: "add", Args: "<id> -from-history [-last 1h] [-match regexp]",
+		Summary: "Pick recently visited pages to add",
+		Group:   "Source Commands",
+	},
+	{
+		Name: "add", Args: "<id> -s3 s3://bucket/prefix [-object-include '*.pdf']",
+		Summary: "Add the objects of an S3 or GCS (-gcs gs://...) bucket",
+		Group:   "Source Commands",
+	},
+	{
+		Name: "sync", Args: "<id> <dir> [-conflict policy]",
+		Summary: "Mirror a directory into notebook sources",
+		Group:   "Source Commands",
+		Description: `New files are added, edited files are uploaded again and files
+deleted locally have their sources removed. State is kept in
+.nlm-sync.json in the directory.
+
+When a file and its source both changed, -conflict decides: prefer-local,
+prefer-remote, or prompt (the default), which asks for each file.`,
+		Examples: []Example{
+			{"Sync nightly from cron, printing only errors and a summary", "nlm -quiet sync <id> ~/notes -conflict=prefer-local"},
+		},
+	},
+	{
+		Name: "podcast add", Args: "<id> <rss-url> [-episodes n] [-transcribe-locally]",
+		Summary: "Add new podcast episodes with transcripts",
+		Group:   "Source Commands",
+	},
+	{
+		Name: "arxiv add", Args: "<id> <arxiv-id>... | -query q | -author name",
+		Summary: "Add arXiv papers (abstract and PDF)",
+		Group:   "Source Commands",
+	},
+	{
+		Name: "paper add", Args: "<id> -doi <doi>[,<doi>...]",
+		Summary: "Add papers by DOI (abstract and open-access PDF)",
+		Group:   "Source Commands",
+	},
+	{
+		Name: "crawl", Args: "<id> -sitemap <url> | -seed <url> [-depth n] [-include re] [-exclude re]",
+		Summary: "Add the pages of a website",
+		Group:   "Source Commands",
+		Description: `Pages disallowed by robots.txt are skipped and requests to the site are
+spaced out. Each page is converted to text and added until the notebook is
+full; pages whose titles are already in the notebook are left out.`,
+		Examples: []Example{
+			{"Add a documentation site", "nlm crawl <id> -seed https://example.com/docs/ -depth 2 -include '^https://example.com/docs/'"},
+		},
+	},
+	{
+		Name: "filter", Args: "<file|->",
+		Summary: "Show a document as it would be uploaded after conversion and filters",
+		Group:   "Source Commands",
+	},
+	{
+		Name: "estimate", Args: "<path>...",
+		Summary: "Count words and tokens against upload limits",
+		Group:   "Source Commands",
+	},
+	{
+		Name: "rm-source", Args: "[-y] <id> <source-id>...",
+		Summary: "Remove sources",
+		Group:   "Source Commands",
+		Examples: []Example{
+			{"Remove every source of a notebook", "nlm sources -ids <id> | xargs nlm rm-source -y <id>"},
+		},
+	},
+	{
+		Name: "rename-source", Args: "<source-id> <new-name>",
+		Summary: "Rename source",
+		Group:   "Source Commands",
+	},
+	{
+		Name: "refresh-source", Args: "<source-id>",
+		Summary: "Refresh source content",
+		Group:   "Source Commands",
+	},
+	{
+		Name: "check-source", Args: "<source-id>",
+		Summary: "Check source freshness",
+		Group:   "Source Commands",
+	},
+
+	{
+		Name: "notes", Args: "<id> [-ids]",
+		Summary: "List notes in notebook",
+		Group:   "Note Commands",
+		Description: `Lists the notes of a notebook. -sort title or -sort modified reorders
+them, and -filter id=... or -filter title~=regexp keeps the matching ones,
+as for sources. -columns picks from id, title and modified.`,
+		Examples: []Example{
+			{"Print the IDs of the notes whose title starts with Draft", "nlm -filter 'title~=^Draft' notes <id> -ids"},
+		},
+	},
+	{
+		Name: "notes edit", Args: "<id> <note-id>",
+		Summary: "Edit a note in $EDITOR, merging concurrent changes",
+		Group:   "Note Commands",
+		Description: `Before saving, the note is fetched again. Changes a collaborator made
+in the meantime are merged; if they overlap yours, your version is saved
+as a conflict copy and the three-way merge is left in a temporary file.`,
+	},
+	{
+		Name: "new-note", Args: "<id> <title>",
+		Summary: "Create new note",
+		Group:   "Note Commands",
+	},
+	{
+		Name: "edit-note", Args: "<id> <note-id> <content>",
+		Summary: "Edit note",
+		Group:   "Note Commands",
+	},
+	{
+		Name: "rm-note", Args: "<note-id>",
+		Summary: "Remove note",
+		Group:   "Note Commands",
+	},
+
+	{
+		Name: "audio-create", Args: "<id> <instructions> [-wait]",
+		Summary: "Create audio overview",
+		Group:   "Audio Commands",
+		Description: `Generation takes several minutes. With -wait, nlm polls until the
+audio is ready and saves it as a WAV file in the current directory.`,
+		Examples: []Example{
+			{"Create an overview and download it when ready", `nlm audio-create -wait <id> "speak in a professional tone"`},
+		},
+	},
+	{
+		Name: "audio-get", Args: "<id> [-wait]",
+		Summary: "Get audio overview",
+		Group:   "Audio Commands",
+	},
+	{
+		Name: "audio-rm", Args: "<id>",
+		Summary: "Delete audio overview",
+		Group:   "Audio Commands",
+	},
+	{
+		Name: "audio-share", Args: "<id>",
+		Summary: "Share audio overview",
+		Group:   "Audio Commands",
+	},
+
+	{
+		Name: "generate-guide", Args: "<id>",
+		Summary: "Generate notebook guide",
+		Group:   "Generation Commands",
+	},
+	{
+		Name: "generate-outline", Args: "<id>",
+		Summary: "Generate content outline",
+		Group:   "Generation Commands",
+	},
+	{
+		Name: "generate-section", Args: "<id>",
+		Summary: "Generate new section",
+		Group:   "Generation Commands",
+	},
+	{
+		Name: "pipe", Args: "<src-id>... <dst-id>",
+		Summary: "Add what is generated from notebooks to another notebook",
+		Group:   "Generation Commands",
+		Description: `Generates the -type of each source notebook (guide, outline, section or
+guides) and adds it to the destination notebook as a note, or as a
+source with -as sources, titled after the source notebook and the type.
+Rerunning a pipe replaces what it added before instead of adding copies,
+so a notebook can collect the summaries of many project notebooks and be
+kept current from cron. Only notes and sources that pipe added, recorded
+in the state directory, are replaced. Prints the ID of each note or source.`,
+		Examples: []Example{
+			{"Collect the guides of two notebooks as sources of a third", "nlm pipe -as sources <src-id> <src-id> <dst-id>"},
+		},
+	},
+	{
+		Name: "aggregate", Args: "-match <glob> -into <title>",
+		Summary: "Collect the guides of matching notebooks into an overview notebook",
+		Group:   "Generation Commands",
+		Description: `Pipes every notebook whose title matches the -match glob into the -into
+notebook, given by title or ID and created if missing. Takes the -type
+and -as options of pipe. Rerun it on a schedule to keep the overview
+current; notebooks that match later are added on the next run. If the run
+that creates the overview notebook adds nothing to it, the notebook is
+deleted again unless -keep-partial is given; if only some notebooks fail,
+it is kept and the failures are reported.`,
+		Examples: []Example{
+			{"Refresh an overview of project notebooks every morning", "0 7 * * * nlm -quiet aggregate -match 'Project-*' -into Overview"},
+		},
+	},
+
+	{
+		Name: "export", Args: "<id> [-o dir]",
+		Summary: "Export notebook content (resumable)",
+		Group:   "Export Commands",
+		Description: `Writes notebook.json, the sources, the notes, the notebook guide and
+the audio overview into the directory, with a manifest. Rerunning the command after an
+interruption only fetches what is missing; -force fetches everything.`,
+	},
+	{
+		Name: "archive", Args: "<id> [-o file.zip]",
+		Summary: "Archive a notebook into a single zip",
+		Group:   "Export Commands",
+	},
+	{
+		Name: "import", Args: "<file.zip>",
+		Summary: "Create a notebook from an archive",
+		Group:   "Export Commands",
+		Description: `Creates a notebook with the sources and notes of an archive made by nlm
+archive. If any of them cannot be added, the new notebook is deleted
+again, unless -keep-partial is given.`,
+		Examples: []Example{
+			{"Copy a notebook", "nlm -o copy.zip archive <id> && nlm import copy.zip"},
+		},
+	},
+	{
+		Name: "import", Args: "-pocket <id>",
+		Summary: "Add articles saved to Pocket (or -instapaper <export.csv>)",
+		Group:   "Export Commands",
+	},
+	{
+		Name: "import", Args: "-takeout <takeout.zip> <id>",
+		Summary: "Add Google Keep notes and Tasks lists",
+		Group:   "Export Commands",
+	},
+	{
+		Name: "import", Args: "-confluence -space <key> <id>",
+		Summary: "Add or update the pages of a Confluence space",
+		Group:   "Export Commands",
+	},
+	{
+		Name: "import", Args: "-sharepoint <site> <id>",
+		Summary: "Add or update the documents of a SharePoint library or OneDrive",
+		Group:   "Export Commands",
+	},
+	{
+		Name: "trash", Args: "[list]",
+		Summary: "List the copies of deleted notebooks and sources kept by -trash",
+		Group:   "Export Commands",
+		Description: `With -trash, or trash.enabled in the global configuration, every
+command that deletes a notebook or sources, including those that replace
+sources with newer versions such as sync and import, saves them to the
+trash directory first, as archives. Copies older than trash.keep (30d by
+default) are removed the next time something is deleted.`,
+	},
+	{
+		Name: "trash restore-local", Args: "<name> [id]",
+		Summary: "Recreate deleted notebooks or sources from the trash",
+		Group:   "Export Commands",
+		Description: `A deleted notebook is recreated as a new notebook, with its sources and
+notes. Deleted sources are added back to the notebook they were removed
+from, or to the notebook given. Audio overviews are not kept.`,
+		Examples: []Example{
+			{"Delete a source, keeping a copy", "nlm -trash rm-source <id> <source-id>"},
+			{"See what is in the trash", "nlm trash"},
+		},
+	},
+	{
+		Name: "graph", Args: "<id> [-o graph.dot|graph.json]",
+		Summary: "Export the source citation graph",
+		Group:   "Export Commands",
+		Description: `Links each note and saved chat answer to the sources it cites. Notes
+that cite sources are shown as answers, since only answers saved from the
+chat carry citations. Chat history that was not saved as a note is not
+included.`,
+	},
+	{
+		Name: "backup", Args: "[-all] [-keep n] [-o dir] [id...]",
+		Summary: "Incremental snapshot backups",
+		Group:   "Export Commands",
+		Description: `Each run writes a timestamped snapshot directory. Notebooks that have
+not changed are hard linked from the previous snapshot. summary.json and
+latest.json list the result for each notebook, and the exit status is
+non-zero if any failed.
+
+Notebooks are backed up -workers at a time (4 by default). A notebook that
+fails does not stop the others; with -fail-fast, the notebooks not yet
+started are skipped instead, as they are after an interrupt.`,
+		Examples: []Example{
+			{"Back up everything nightly, keeping a week of snapshots", "0 3 * * * nlm -quiet backup -all -o ~/nlm-backups -keep 7"},
+			{"Back up one notebook at a time, stopping at the first failure", "nlm -workers 1 -fail-fast backup -all"},
+		},
+	},
+
+	{
+		Name:    "setup",
+		Summary: "Sign in and choose defaults, step by step",
+		Group:   "Other Commands",
+	},
+	{
+		Name: "auth", Args: "[profile]",
+		Summary: "Setup authentication",
+		Group:   "Other Commands",
+		Description: `Opens the browser profile (NLM_PROFILE, or Default) to read
+the NotebookLM cookies. Alternatively, copy a batchexecute request from the
+browser's developer tools as curl and pipe it in; its User-Agent and client
+hints are then frozen for the profile.`,
+		Examples: []Example{
+			{"Sign in with a second Chrome profile", `nlm auth "Profile 1"`},
+			{"Sign in from a copied curl command", "pbpaste | nlm auth"},
+		},
+	},
+	{
+		Name: "headers", Args: "[freeze|unfreeze]",
+		Summary: "Show or pin the User-Agent and client hints of this profile",
+		Group:   "Other Commands",
+	},
+	{
+		Name: "debug sanitize", Args: "<trace.har> [-o clean.har]",
+		Summary: "Remove credentials and text from a captured trace for sharing",
+		Group:   "Other Commands",
+		Description: `Masks cookies, auth headers and tokens, replaces the text in RPC
+requests and responses with its length, and removes other response bodies,
+keeping the structure of the payloads, IDs and numbers, so the trace can be
+attached to an issue about a new response format. Writes to stdout unless
+-o is given.`,
+		Examples: []Example{
+			{"Clean a trace saved from the browser's developer tools", "nlm debug sanitize notebooklm.har -o clean.har"},
+		},
+	},
+	{
+		Name:    "endpoints",
+		Summary: "Check which NotebookLM endpoint can be reached from here",
+		Group:   "Other Commands",
+		Description: `Looks up and requests the endpoint configured for this browser profile,
+the same endpoint without its proxy, and the default endpoint, and shows
+the addresses, proxy, HTTP status and time of each. No credentials are
+sent, so any HTTP status means the endpoint answers. Fails if none does.`,
+	},
+	{
+		Name: "help", Args: "[command|topic]",
+		Summary: "Show detailed help and examples",
+		Group:   "Other Commands",
+	},
+	{
+		Name: "examples", Args: "[command]",
+		Summary: "Print runnable examples using your notebooks",
+		Group:   "Other Commands",
+		Description: `Prints example invocations of every command, or of one command, with
+the IDs of your most recently viewed notebook and its first source and note
+filled in, ready to copy and paste. Some examples change that notebook, so
+read each one before running it. Fetching the notebook, its sources and its notes first makes this a
+quick check that listing works with your login.`,
+		Examples: []Example{
+			{"Show ways to use the sources command on a real notebook", "nlm examples sources"},
+		},
+	},
+	{
+		Name: "share", Args: "<id>",
+		Summary: "Share notebook",
+		Group:   "Other Commands",
+	},
+	{
+		Name: "feedback", Args: "<msg>",
+		Summary: "Submit feedback",
+		Group:   "Other Commands",
+	},
+	{
+		Name:    "hb",
+		Summary: "Send heartbeat",
+		Group:   "Other Commands",
+	},
+	{
+		Name:    "features",
+		Summary: "Show which optional features your account has",
+		Group:   "Other Commands",
+	},
+	{
+		Name:    "canary",
+		Summary: "Check that NotebookLM works, for cron and monitoring",
+		Group:   "Other Commands",
+		Description: `Lists notebooks, creates a notebook titled "nlm canary", adds a text
+source and checks that it is listed, and deletes the notebook again,
+along with any an interrupted run left behind. The IDs of the notebooks it
+creates are kept in the state directory until they are deleted; other
+notebooks titled "nlm canary" are never touched.
+
+Prints one line of JSON with the outcome and duration of each step, and
+exits non-zero if any step failed.`,
+		Examples: []Example{
+			{"Check every 15 minutes, appending results to a log", "*/15 * * * * nlm -quiet canary >> ~/nlm-canary.jsonl"},
+		},
+	},
+	{
+		Name: "fake", Args: "<scenario> [command [arg...]]",
+		Summary: "Run a command against a fake NotebookLM, for testing scripts",
+		Group:   "Other Commands",
+		Description: `Runs command with nlm answered by a built-in fake of the NotebookLM API
+instead of the real one, in a new state directory that is removed
+afterwards. This nlm is first on the command's PATH, so scripts that run
+nlm use the fake too, and nlm fake exits with the command's status. No
+credentials are needed or sent. Put -- before a command with flags.
+
+The fake keeps notebooks, sources and audio overviews between the nlm
+runs of the command, and numbers IDs in order, so a run is the same every
+time. The scenario sets how it behaves:
+
+  success  every call succeeds; audio is ready when first fetched
+  fail     every call fails with an HTTP 500 error
+  slow     every call takes two seconds; audio is ready on the fourth fetch
+  partial  notebooks can be listed and created, but the first attempt to
+           add each source fails and audio overviews hit the daily limit
+
+Without a command, nlm fake creates the state directory and prints shell
+commands that set NLM_MODE and NLM_HOME, so that later nlm runs use the
+fake. Setting NLM_MODE to a scenario by hand does the same, keeping the
+fake's state in ~/.nlm/fake unless NLM_HOME is set.`,
+		Examples: []Example{
+			{"Test the workflow script without an account", "nlm fake success -- ./scripts/nlm_workflow.sh paper.pdf"},
+			{"Check that a wrapper retries failed uploads", "nlm fake partial -- ./upload.sh"},
+			{"Use the fake for the rest of a CI job", `eval "$(nlm fake slow)"`},
+		},
+	},
+	{
+		Name: "version", Args: "[-check]",
+		Summary: "Show version information, optionally checking for updates",
+		Group:   "Other Commands",
+	},
+	{
+		Name: "config allow", Args: "[file]",
+		Summary: "Trust a project .nlm.yaml to run commands and send data",
+		Group:   "Other Commands",
+		Description: `A .nlm.yaml found in the working directory or a parent may have come
+with a cloned repository, so its converters, ocr.command, ocr.url,
+transcribe.command and transcribe.ffmpeg are ignored, with a warning,
+until the file is trusted. Trust is recorded for the file's contents as
+they are: after the file changes it must be allowed again. A file named
+by NLM_CONFIG is always trusted. nlm config deny [file] withdraws trust.`,
+		Examples: []Example{
+			{"Trust the .nlm.yaml of the current project", "nlm config allow"},
+		},
+	},
+	{
+		Name:    "self-update",
+		Summary: "Install the latest verified release",
+		Group:   "Other Commands",
+		Description: `Installs a release only if its manifest, which names the version and
+the checksum of each binary, carries a valid signature from the release
+signing key built into nlm, and the signed version is newer than the
+running one. Development builds, and builds without the key, refuse to
+update themselves.`,
+	},
+	{
+		Name: "bugreport", Args: "[-o file.zip]",
+		Summary: "Bundle redacted diagnostics for an issue",
+		Group:   "Other Commands",
+	},
+	{
+		Name: "stats", Args: "-usage [-since 30d]",
+		Summary: "Summarize your own usage from the local history",
+		Group:   "Other Commands",
+	},
+	{
+		Name: "quota", Args: "-local",
+		Summary: "Show today's requests and uploads against -budget",
+		Group:   "Other Commands",
+		Description: `nlm counts the requests and upload bytes each browser profile sends per
+day, on this machine only. With -budget, or NLM_BUDGET, a request that
+would take the profile over its daily budget is refused before it is
+sent, failing with budget_exceeded; a batch stops reading operations
+when that happens. NotebookLM does not report its own limits, so -local
+is required.`,
+		Examples: []Example{
+			{"Stop an import after 300 requests or 100 MB of uploads today", "nlm -budget requests=300,upload=100MB batch < ops.jsonl"},
+			{"See what each profile used this week", "nlm quota -local"},
+		},
+	},
+	{
+		Name: "daemon", Args: "[start|status|stop]",
+		Summary: "Keep connections warm for faster commands",
+		Group:   "Other Commands",
+		Description: `Repeated read-only requests are answered from memory for -daemon-cache
+(30s by default, 0 disables); any request that may change data clears
+the cache. While idle, the daemon sends a keep-alive request every
+-keepalive (10m by default, 0 disables) so that the editor session's
+credentials stay in use and expired ones are reloaded from nlm auth
+before an editor needs them; with -refresh-auth, it renews them from the
+browser profile itself. Failures are logged once, until a request
+succeeds again.`,
+	},
+}
+
+// Topics are the help topics that are not commands. They also become
+// sections of the man page.
+var Topics = []Topic{
+	{
+		Name:    "environment",
+		Summary: "Environment variables nlm reads",
+		Text: `NLM_AUTH_TOKEN and NLM_COOKIES hold the credentials; nlm auth saves
+them in ~/.nlm/env, which is read when they are not set.
+
+NLM_PROFILE is the browser profile nlm auth signs in with;
+NLM_BROWSER_PROFILE, its older name, is still read.
+
+NLM_NOTEBOOK is the notebook of commands given none, when their first
+argument is not a notebook ID. rm never uses it.
+
+NLM_OUTPUT (table, json or csv), NLM_TIMEOUT (such as 2m) and NLM_DEBUG
+(true or false) set -output, -timeout and -debug.
+
+Flags given on the command line win over these variables, which win over
+the global configuration.
+
+NLM_HOME moves the state directory from ~/.nlm. NLM_STATE_PASSPHRASE or
+NLM_STATE_KEYCHAIN encrypt it.
+
+NLM_CONFIG names the project configuration instead of the nearest
+.nlm.yaml.
+
+NLM_NO_DAEMON bypasses a running nlm daemon.
+
+NLM_LEGACY_CHUNKS decodes responses with the previous parser, should the
+current one fail on a response that used to work.
+
+NLM_BUDGET is the daily budget of the current profile, as for -budget.
+
+NLM_MODE makes nlm answer requests with a fake NotebookLM instead of the
+real one, behaving as the scenario it names; see nlm fake.
+
+NLM_OCR_TOKEN, NLM_WHISPER_MODEL, NLM_UNPAYWALL_EMAIL,
+NLM_POCKET_CONSUMER_KEY, NLM_POCKET_ACCESS_TOKEN, NLM_CONFLUENCE_URL,
+NLM_CONFLUENCE_USER, NLM_CONFLUENCE_TOKEN, NLM_GRAPH_CLIENT_ID and
+NLM_GRAPH_TENANT configure the converters and importers that use them.`,
+	},
+	{
+		Name:    "files",
+		Summary: "Files nlm reads and writes",
+		Text: `~/.nlm/env holds the credentials saved by nlm auth.
+
+~/.nlm/config.yaml holds the defaults saved by nlm setup and the client
+headers (see nlm help headers).
+
+.nlm.yaml, in the current directory or a parent, configures converters,
+ingest filters and OCR for a project.
+
+~/.nlm/quota.json holds the daily request counts of each profile, and
+~/.nlm/pins.json the notebooks pinned with nlm pin.
+
+~/.nlm/trusted-configs.json holds the .nlm.yaml files trusted with nlm
+config allow.
+
+~/.nlm/notebook-defaults.json holds the flags remembered for notebooks
+(see nlm notebooks defaults).
+
+~/.nlm/jobs holds the records and logs of background jobs, and
+~/.nlm/debug the payloads of responses that failed to decode.`,
+	},
+}
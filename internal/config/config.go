@@ -0,0 +1,188 @@
+// Package config loads per-project settings from a .nlm.yaml file.
+//
+// The file is found by walking up from the working directory, like .git,
+// so settings apply to every command run inside a project. NLM_CONFIG
+// names a file explicitly.
+//
+// A .nlm.yaml can come with a cloned repository, so the settings that run
+// commands or send data elsewhere (see Restricted) are only used from a
+// file named by NLM_CONFIG or one the user has trusted, as recorded in
+// Trusted.
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/tmc/nlm/internal/access"
+	"github.com/tmc/nlm/internal/ingest"
+	"github.com/tmc/nlm/internal/ocr"
+	"github.com/tmc/nlm/internal/transcribe"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the project configuration file.
+const FileName = ".nlm.yaml"
+
+// Config is the contents of .nlm.yaml.
+type Config struct {
+	// Ingest configures transforms applied to documents before upload.
+	Ingest ingest.Options `yaml:"ingest"`
+
+	// Converters maps file extensions to commands that read a document on
+	// stdin and write its text to stdout, for example
+	// ".ipynb: [jupyter, nbconvert, --to, markdown, --stdin, --stdout]".
+	Converters map[string][]string `yaml:"converters"`
+
+	// OCR selects the engine used by nlm add -ocr.
+	OCR ocr.Config `yaml:"ocr"`
+
+	// Transcribe configures local speech recognition for
+	// nlm add -transcribe-locally.
+	Transcribe transcribe.Whisper `yaml:"transcribe"`
+
+	// Sharing is the policy checked by nlm share report.
+	Sharing access.Policy `yaml:"sharing"`
+
+	path     string
+	sum      string
+	explicit bool
+}
+
+// Path returns the file the configuration was loaded from, or "" if no
+// file was found.
+func (c *Config) Path() string { return c.path }
+
+// Explicit reports whether the file was named by NLM_CONFIG rather than
+// found above the working directory.
+func (c *Config) Explicit() bool { return c.explicit }
+
+// Restricted returns the keys set in c that run commands or send data
+// to a server: converters, ocr.command, ocr.url, transcribe.command and
+// transcribe.ffmpeg.
+func (c *Config) Restricted() []string {
+	var keys []string
+	if len(c.Converters) > 0 {
+		keys = append(keys, "converters")
+	}
+	for key, v := range map[string]string{
+		"ocr.command":        c.OCR.Command,
+		"ocr.url":            c.OCR.URL,
+		"transcribe.command": c.Transcribe.Command,
+		"transcribe.ffmpeg":  c.Transcribe.FFmpeg,
+	} {
+		if v != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// DropRestricted clears the keys Restricted returns, leaving the defaults
+// in their place.
+func (c *Config) DropRestricted() {
+	c.Converters = nil
+	c.OCR.Command, c.OCR.URL = "", ""
+	c.Transcribe.Command, c.Transcribe.FFmpeg = "", ""
+}
+
+// Trusted records the project configuration files the user allows to use
+// restricted keys, with the SHA-256 of the contents they allowed, so that
+// a file that changes must be trusted again.
+type Trusted struct {
+	Files map[string]string `json:"files,omitempty"`
+}
+
+// Allows reports whether c was trusted as it is now.
+func (t *Trusted) Allows(c *Config) bool {
+	return c.path != "" && t.Files[c.path] == c.sum
+}
+
+// Allow trusts c as it is now.
+func (t *Trusted) Allow(c *Config) {
+	if t.Files == nil {
+		t.Files = make(map[string]string)
+	}
+	t.Files[c.path] = c.sum
+}
+
+// Deny stops trusting the file at path.
+func (t *Trusted) Deny(path string) {
+	delete(t.Files, path)
+}
+
+// Find returns the path of the nearest .nlm.yaml in dir or its parents, or
+// "" if there is none.
+func Find(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		path := filepath.Join(dir, FileName)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// Load reads the configuration at path. Unknown keys are errors, so typos
+// in security relevant settings such as redactions are not silently
+// ignored.
+func Load(path string) (*Config, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	c := &Config{path: path, sum: hex.EncodeToString(sum[:])}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("config: %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// LoadDefault loads the file named by NLM_CONFIG, or the nearest
+// .nlm.yaml above the working directory. It returns an empty configuration
+// if there is neither. Whether the restricted keys of a file found above
+// the working directory may be used is up to the caller.
+func LoadDefault() (*Config, error) {
+	if path := ResolveBootstrap(os.Getenv).ConfigFile; path != "" {
+		c, err := Load(path)
+		if err != nil {
+			return nil, err
+		}
+		c.explicit = true
+		return c, nil
+	}
+	path, err := Find(".")
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	if path == "" {
+		return &Config{}, nil
+	}
+	return Load(path)
+}
@@ -0,0 +1,216 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tmc/nlm/internal/ingest"
+	"github.com/tmc/nlm/internal/ocr"
+)
+
+func TestFindAndLoad(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if path, err := Find(sub); err != nil || path != "" {
+		t.Fatalf("Find with no file = %q, %v", path, err)
+	}
+
+	content := `ingest:
+  strip_front_matter: true
+  drop_code_blocks: true
+  max_length: 100000
+  redact:
+    - pattern: 'sk-[A-Za-z0-9]+'
+    - pattern: '\b\d{3}-\d{2}-\d{4}\b'
+      replace: '[ssn]'
+converters:
+  .ipynb: [jupyter, nbconvert, --to, markdown, --stdin, --stdout]
+ocr:
+  languages: eng+deu
+`
+	want := filepath.Join(root, FileName)
+	if err := os.WriteFile(want, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path, err := Find(sub)
+	if err != nil || path != want {
+		t.Fatalf("Find = %q, %v; want %q", path, err, want)
+	}
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantIngest := ingest.Options{
+		StripFrontMatter: true,
+		DropCodeBlocks:   true,
+		MaxLength:        100000,
+		Redact: []ingest.Redaction{
+			{Pattern: `sk-[A-Za-z0-9]+`},
+			{Pattern: `\b\d{3}-\d{2}-\d{4}\b`, Replace: "[ssn]"},
+		},
+	}
+	if diff := cmp.Diff(wantIngest, c.Ingest); diff != "" {
+		t.Errorf("Ingest mismatch (-want +got):\n%s", diff)
+	}
+	wantConverters := map[string][]string{
+		".ipynb": {"jupyter", "nbconvert", "--to", "markdown", "--stdin", "--stdout"},
+	}
+	if diff := cmp.Diff(wantConverters, c.Converters); diff != "" {
+		t.Errorf("Converters mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(ocr.Config{Languages: "eng+deu"}, c.OCR); diff != "" {
+		t.Errorf("OCR mismatch (-want +got):\n%s", diff)
+	}
+	if c.Path() != want {
+		t.Errorf("Path = %q, want %q", c.Path(), want)
+	}
+}
+
+func TestLoadRejectsUnknownKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	os.WriteFile(path, []byte("ingest:\n  redactions:\n    - pattern: x\n"), 0o644)
+	if _, err := Load(path); err == nil {
+		t.Error("Load with misspelled key: want error")
+	}
+}
+
+func TestLoadEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	os.WriteFile(path, nil, 0o644)
+	if _, err := Load(path); err != nil {
+		t.Errorf("Load of empty file: %v", err)
+	}
+}
+
+func TestGlobalRoundTrip(t *testing.T) {
+	want := &Global{
+		Profile: "Profile 1",
+		Output:  Output{JSON: true, Wrap: true},
+		Client:  ClientHeaders{Hints: map[string]string{"sec-ch-ua-platform": `"Linux"`}},
+		Frozen: map[string]ClientHeaders{
+			"Default": {UserAgent: "Mozilla/5.0 (X11; Linux x86_64)", Hints: map[string]string{"sec-ch-ua-mobile": "?0"}},
+		},
+		Trash:    Trash{Enabled: true, Keep: "2w"},
+		Endpoint: Endpoint{Proxy: "http://proxy.example.com:3128"},
+		Endpoints: map[string]Endpoint{
+			"Work": {Host: "notebooklm.example.com", Path: "/nlm/batchexecute", CA: "/etc/ssl/corp.pem"},
+		},
+		ReadOnly: true,
+	}
+	data, err := want.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ParseGlobal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Global mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := ParseGlobal([]byte("output:\n  colour: false\n")); err == nil {
+		t.Error("ParseGlobal with misspelled key: want error")
+	}
+}
+
+func TestClientHeadersFor(t *testing.T) {
+	g, err := ParseGlobal([]byte(`client:
+  user_agent: configured
+  hints:
+    Sec-CH-UA-Platform: '"Linux"'
+frozen:
+  Work:
+    user_agent: frozen
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"user-agent": "configured", "sec-ch-ua-platform": `"Linux"`}
+	if diff := cmp.Diff(want, g.ClientHeadersFor("Default").Headers()); diff != "" {
+		t.Errorf("unfrozen profile headers (-want +got):\n%s", diff)
+	}
+	want = map[string]string{"user-agent": "frozen"}
+	if diff := cmp.Diff(want, g.ClientHeadersFor("Work").Headers()); diff != "" {
+		t.Errorf("frozen profile headers (-want +got):\n%s", diff)
+	}
+	if _, err := ParseGlobal([]byte("client:\n  hints:\n    cookie: x\n")); err == nil {
+		t.Error("ParseGlobal with a hint that is not sec-ch-*: want error")
+	}
+}
+
+func TestEndpointFor(t *testing.T) {
+	g, err := ParseGlobal([]byte(`endpoint:
+  proxy: http://proxy.example.com:3128
+endpoints:
+  Work:
+    host: notebooklm.example.com:8443
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Endpoint{Proxy: "http://proxy.example.com:3128"}
+	if got := g.EndpointFor("Default"); got != want {
+		t.Errorf("EndpointFor(Default) = %+v, want %+v", got, want)
+	}
+	want.Host = "notebooklm.example.com:8443"
+	if got := g.EndpointFor("Work"); got != want {
+		t.Errorf("EndpointFor(Work) = %+v, want %+v", got, want)
+	}
+	for _, bad := range []string{
+		"endpoint:\n  host: https://notebooklm.google.com\n",
+		"endpoint:\n  path: _/data\n",
+		"endpoints:\n  Work:\n    proxy: proxy.example.com\n",
+		"endpoint:\n  proxy: ftp://proxy.example.com\n",
+	} {
+		if _, err := ParseGlobal([]byte(bad)); err == nil {
+			t.Errorf("ParseGlobal(%q): want error", bad)
+		}
+	}
+}
+
+func TestTrusted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	write := func(s string) *Config {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(s), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		c, err := Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	c := write("converters:\n  .x: [sh, -c, evil]\nocr:\n  url: https://example.com/ocr\n  languages: eng\ntranscribe:\n  command: ./whisper\n  ffmpeg: ./ffmpeg\n")
+	if diff := cmp.Diff([]string{"converters", "ocr.url", "transcribe.command", "transcribe.ffmpeg"}, c.Restricted()); diff != "" {
+		t.Errorf("Restricted mismatch (-want +got):\n%s", diff)
+	}
+
+	var tr Trusted
+	if tr.Allows(c) {
+		t.Error("an unknown file is trusted")
+	}
+	tr.Allow(c)
+	if !tr.Allows(c) {
+		t.Error("an allowed file is not trusted")
+	}
+	changed := write("converters:\n  .x: [sh, -c, worse]\n")
+	if tr.Allows(changed) {
+		t.Error("a file changed after it was allowed is still trusted")
+	}
+	tr.Allow(changed)
+	tr.Deny(changed.Path())
+	if tr.Allows(changed) {
+		t.Error("a denied file is trusted")
+	}
+
+	c.DropRestricted()
+	if len(c.Restricted()) != 0 || c.OCR.Languages != "eng" {
+		t.Errorf("after DropRestricted: restricted %v, languages %q", c.Restricted(), c.OCR.Languages)
+	}
+}
@@ -0,0 +1,242 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Environment variables holding nlm's general settings.
+const (
+	EnvProfile        = "NLM_PROFILE"
+	EnvBrowserProfile = "NLM_BROWSER_PROFILE" // the older name of NLM_PROFILE
+	EnvNotebook       = "NLM_NOTEBOOK"
+	EnvOutput         = "NLM_OUTPUT"
+	EnvTimeout        = "NLM_TIMEOUT"
+	EnvDebug          = "NLM_DEBUG"
+
+	EnvAuthToken    = "NLM_AUTH_TOKEN"
+	EnvCookies      = "NLM_COOKIES"
+	EnvBudget       = "NLM_BUDGET"
+	EnvNoDaemon     = "NLM_NO_DAEMON"
+	EnvLegacyChunks = "NLM_LEGACY_CHUNKS"
+	EnvOCRToken     = "NLM_OCR_TOKEN"
+	EnvWhisperModel = "NLM_WHISPER_MODEL"
+
+	EnvConfig          = "NLM_CONFIG"
+	EnvHome            = "NLM_HOME"
+	EnvStatePassphrase = "NLM_STATE_PASSPHRASE"
+	EnvStateKeychain   = "NLM_STATE_KEYCHAIN"
+	EnvMode            = "NLM_MODE"
+	EnvJobID           = "NLM_JOB_ID"
+)
+
+// Environment variables holding the credentials of the services nlm
+// imports from.
+const (
+	EnvUnpaywallEmail    = "NLM_UNPAYWALL_EMAIL"
+	EnvS2APIKey          = "NLM_S2_API_KEY"
+	EnvPocketConsumerKey = "NLM_POCKET_CONSUMER_KEY"
+	EnvPocketAccessToken = "NLM_POCKET_ACCESS_TOKEN"
+	EnvConfluenceURL     = "NLM_CONFLUENCE_URL"
+	EnvConfluenceToken   = "NLM_CONFLUENCE_TOKEN"
+	EnvConfluenceUser    = "NLM_CONFLUENCE_USER"
+	EnvGraphClientID     = "NLM_GRAPH_CLIENT_ID"
+	EnvGraphTenant       = "NLM_GRAPH_TENANT"
+)
+
+// Names of the settings, as keys of Settings.Source and of the flags given
+// to Resolve.
+const (
+	SettingProfile  = "profile"
+	SettingNotebook = "notebook"
+	SettingOutput   = "output"
+	SettingTimeout  = "timeout"
+	SettingDebug    = "debug"
+	SettingAuth     = "auth"
+	SettingCookies  = "cookies"
+	SettingBudget   = "budget"
+)
+
+// DefaultProfile is the browser profile used when none is set.
+const DefaultProfile = "Default"
+
+// Settings are the settings that apply to every command.
+type Settings struct {
+	// Profile is the browser profile nlm signs in with.
+	Profile string
+	// Notebook is the notebook used by commands given none.
+	Notebook string
+	// Output is table, json or csv, or "" to leave the output as it is.
+	Output string
+	// Timeout bounds each request to NotebookLM; zero means no limit.
+	Timeout time.Duration
+	// Debug turns on debug output.
+	Debug bool
+
+	// AuthToken and Cookies are the credentials of the NotebookLM session.
+	AuthToken string
+	Cookies   string
+	// Budget is the daily budget of the profile, such as
+	// "requests=500,upload=200MB".
+	Budget string
+	// NoDaemon makes commands connect directly even if a daemon runs.
+	NoDaemon bool
+	// LegacyChunks decodes responses with the previous parser.
+	LegacyChunks bool
+	// OCRToken is sent to the HTTP OCR service as a bearer token.
+	OCRToken string
+	// WhisperModel is the whisper.cpp model used to transcribe locally.
+	WhisperModel string
+	// Services holds the credentials of the services nlm imports from.
+	Services Services
+
+	// Source says where each setting came from: "flag", the name of an
+	// environment variable, "config" or "default".
+	Source map[string]string
+}
+
+// Services are the credentials of the services nlm imports from, each
+// set only in the environment.
+type Services struct {
+	UnpaywallEmail    string
+	S2APIKey          string
+	PocketConsumerKey string
+	PocketAccessToken string
+	ConfluenceURL     string
+	ConfluenceToken   string
+	ConfluenceUser    string
+	GraphClientID     string
+	GraphTenant       string
+}
+
+// Bootstrap are the settings needed before the others can be resolved:
+// where the ingest configuration is, where the local state, which holds
+// the global configuration and stored credentials, is kept and how it is
+// encrypted, and whether nlm runs against the fake API or as a background
+// job. They are only set in the environment.
+type Bootstrap struct {
+	// ConfigFile is the ingest configuration to use instead of the
+	// nearest .nlm.yaml.
+	ConfigFile string
+	// Home is the state directory to use instead of ~/.nlm.
+	Home string
+	// Mode is the scenario of the fake API to answer requests with.
+	Mode string
+	// JobID is the background job this process runs as.
+	JobID string
+	// StatePassphrase encrypts the local state with a key derived from it.
+	StatePassphrase string
+	// StateKeychain encrypts the local state with a key kept in the
+	// operating system keychain.
+	StateKeychain bool
+}
+
+// ResolveBootstrap works out the bootstrap settings, looking up
+// environment variables with getenv.
+func ResolveBootstrap(getenv func(string) string) Bootstrap {
+	return Bootstrap{
+		ConfigFile:      getenv(EnvConfig),
+		Home:            getenv(EnvHome),
+		Mode:            getenv(EnvMode),
+		JobID:           getenv(EnvJobID),
+		StatePassphrase: getenv(EnvStatePassphrase),
+		StateKeychain:   isSet(getenv(EnvStateKeychain)),
+	}
+}
+
+// isSet reports whether a switch such as NLM_NO_DAEMON is on: set to
+// anything but a false value such as 0 or false.
+func isSet(v string) bool {
+	if v == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(v)
+	return err != nil || b
+}
+
+// Resolve works out the settings. For each, a flag given on the command
+// line wins over the environment, which wins over the global
+// configuration g, which may be nil. flags holds the flags that were
+// given, by setting name, and getenv looks up environment variables.
+func Resolve(flags map[string]string, getenv func(string) string, g *Global) (Settings, error) {
+	if g == nil {
+		g = &Global{}
+	}
+	s := Settings{Source: make(map[string]string)}
+	// pick returns the first non-empty value, recording its source.
+	pick := func(name string, candidates ...[2]string) string {
+		for _, c := range candidates {
+			if c[1] != "" {
+				s.Source[name] = c[0]
+				return c[1]
+			}
+		}
+		s.Source[name] = "default"
+		return ""
+	}
+	env := func(key string) [2]string { return [2]string{key, getenv(key)} }
+	flag := func(name string) [2]string { return [2]string{"flag", flags[name]} }
+
+	s.Profile = pick(SettingProfile, flag(SettingProfile), env(EnvProfile), env(EnvBrowserProfile), [2]string{"config", g.Profile})
+	if s.Profile == "" {
+		s.Profile = DefaultProfile
+	}
+
+	s.Notebook = pick(SettingNotebook, flag(SettingNotebook), env(EnvNotebook))
+
+	var configOutput string
+	if g.Output.JSON {
+		configOutput = "json"
+	}
+	s.Output = pick(SettingOutput, flag(SettingOutput), env(EnvOutput), [2]string{"config", configOutput})
+	switch s.Output {
+	case "", "table", "json", "csv":
+	default:
+		return s, fmt.Errorf("invalid %s %q (want table, json or csv)", describe(s.Source[SettingOutput], "-output"), s.Output)
+	}
+
+	if v := pick(SettingTimeout, flag(SettingTimeout), env(EnvTimeout)); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return s, fmt.Errorf("invalid %s %q (want a duration such as 90s or 5m)", describe(s.Source[SettingTimeout], "-timeout"), v)
+		}
+		s.Timeout = d
+	}
+
+	if v := pick(SettingDebug, flag(SettingDebug), env(EnvDebug)); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return s, fmt.Errorf("invalid %s %q (want true or false)", describe(s.Source[SettingDebug], "-debug"), v)
+		}
+		s.Debug = b
+	}
+
+	s.AuthToken = pick(SettingAuth, flag(SettingAuth), env(EnvAuthToken))
+	s.Cookies = pick(SettingCookies, flag(SettingCookies), env(EnvCookies))
+	s.Budget = pick(SettingBudget, flag(SettingBudget), env(EnvBudget))
+	s.NoDaemon = isSet(getenv(EnvNoDaemon))
+	s.LegacyChunks = isSet(getenv(EnvLegacyChunks))
+	s.OCRToken = getenv(EnvOCRToken)
+	s.WhisperModel = getenv(EnvWhisperModel)
+	s.Services = Services{
+		UnpaywallEmail:    getenv(EnvUnpaywallEmail),
+		S2APIKey:          getenv(EnvS2APIKey),
+		PocketConsumerKey: getenv(EnvPocketConsumerKey),
+		PocketAccessToken: getenv(EnvPocketAccessToken),
+		ConfluenceURL:     getenv(EnvConfluenceURL),
+		ConfluenceToken:   getenv(EnvConfluenceToken),
+		ConfluenceUser:    getenv(EnvConfluenceUser),
+		GraphClientID:     getenv(EnvGraphClientID),
+		GraphTenant:       getenv(EnvGraphTenant),
+	}
+	return s, nil
+}
+
+// describe names the source of a setting in errors.
+func describe(source, flagName string) string {
+	if source == "flag" {
+		return flagName
+	}
+	return source
+}
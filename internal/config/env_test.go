@@ -0,0 +1,119 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolvePrecedence(t *testing.T) {
+	g := &Global{Profile: "Config", Output: Output{JSON: true}}
+	tests := []struct {
+		name    string
+		flags   map[string]string
+		env     map[string]string
+		g       *Global
+		profile string
+		output  string
+		source  string // of the profile
+	}{
+		{name: "defaults", profile: "Default", source: "default"},
+		{name: "config", g: g, profile: "Config", output: "json", source: "config"},
+		{
+			name:    "older variable over config",
+			env:     map[string]string{EnvBrowserProfile: "Old"},
+			g:       g,
+			profile: "Old", output: "json", source: EnvBrowserProfile,
+		},
+		{
+			name:    "environment over config",
+			env:     map[string]string{EnvProfile: "Env", EnvBrowserProfile: "Old", EnvOutput: "csv"},
+			g:       g,
+			profile: "Env", output: "csv", source: EnvProfile,
+		},
+		{
+			name:    "flags over environment",
+			flags:   map[string]string{SettingProfile: "Flag", SettingOutput: "table"},
+			env:     map[string]string{EnvProfile: "Env", EnvOutput: "csv"},
+			g:       g,
+			profile: "Flag", output: "table", source: "flag",
+		},
+	}
+	for _, tt := range tests {
+		s, err := Resolve(tt.flags, func(k string) string { return tt.env[k] }, tt.g)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if s.Profile != tt.profile || s.Output != tt.output || s.Source[SettingProfile] != tt.source {
+			t.Errorf("%s: profile %q from %s, output %q; want %q from %s, output %q",
+				tt.name, s.Profile, s.Source[SettingProfile], s.Output, tt.profile, tt.source, tt.output)
+		}
+	}
+}
+
+func TestResolveBootstrap(t *testing.T) {
+	for _, tt := range []struct {
+		keychain string
+		want     bool
+	}{{"", false}, {"1", true}, {"yes", true}, {"0", false}, {"false", false}} {
+		env := map[string]string{EnvConfig: "/etc/nlm.yaml", EnvStateKeychain: tt.keychain}
+		b := ResolveBootstrap(func(k string) string { return env[k] })
+		if b.ConfigFile != "/etc/nlm.yaml" || b.StateKeychain != tt.want {
+			t.Errorf("NLM_STATE_KEYCHAIN=%q: %+v, want keychain %v", tt.keychain, b, tt.want)
+		}
+	}
+
+	env := map[string]string{EnvHome: "/tmp/nlm", EnvMode: "success", EnvJobID: "j1"}
+	b := ResolveBootstrap(func(k string) string { return env[k] })
+	if b.Home != "/tmp/nlm" || b.Mode != "success" || b.JobID != "j1" {
+		t.Errorf("ResolveBootstrap = %+v", b)
+	}
+}
+
+func TestResolveValues(t *testing.T) {
+	env := map[string]string{EnvNotebook: "nb1", EnvTimeout: "90s", EnvDebug: "1"}
+	s, err := Resolve(nil, func(k string) string { return env[k] }, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Notebook != "nb1" || s.Timeout != 90*time.Second || !s.Debug {
+		t.Errorf("Resolve = %+v", s)
+	}
+	s, err = Resolve(map[string]string{SettingDebug: "false", SettingTimeout: "0s"}, func(k string) string { return env[k] }, nil)
+	if err != nil || s.Debug || s.Timeout != 0 {
+		t.Errorf("flags over environment: %+v, %v", s, err)
+	}
+
+	env = map[string]string{EnvBudget: "500", EnvNoDaemon: "1", EnvLegacyChunks: "0", EnvAuthToken: "tok", EnvConfluenceUser: "me"}
+	s, err = Resolve(map[string]string{SettingBudget: "100"}, func(k string) string { return env[k] }, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Budget != "100" || !s.NoDaemon || s.LegacyChunks || s.AuthToken != "tok" || s.Services.ConfluenceUser != "me" {
+		t.Errorf("Resolve = %+v", s)
+	}
+	if s.Source[SettingBudget] != "flag" || s.Source[SettingAuth] != EnvAuthToken {
+		t.Errorf("sources = %v", s.Source)
+	}
+
+	for _, tt := range []struct{ env, want string }{
+		{EnvOutput + "=yaml", `NLM_OUTPUT "yaml"`},
+		{EnvTimeout + "=soon", `NLM_TIMEOUT "soon"`},
+		{EnvTimeout + "=-5s", `NLM_TIMEOUT "-5s"`},
+		{EnvDebug + "=maybe", `NLM_DEBUG "maybe"`},
+	} {
+		k, v, _ := strings.Cut(tt.env, "=")
+		_, err := Resolve(nil, func(key string) string {
+			if key == k {
+				return v
+			}
+			return ""
+		}, nil)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want it to name %s", tt.env, err, tt.want)
+		}
+	}
+	if _, err := Resolve(map[string]string{SettingOutput: "yaml"}, func(string) string { return "" }, nil); err == nil || !strings.Contains(err.Error(), "-output") {
+		t.Errorf("bad flag: err = %v, want it to name -output", err)
+	}
+}
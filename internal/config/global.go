@@ -0,0 +1,196 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// GlobalFileName is the name of the global configuration in nlm's state
+// directory.
+const GlobalFileName = "config.yaml"
+
+// Global holds the user's own defaults, written by nlm setup. Unlike
+// .nlm.yaml it is kept in nlm's state directory and applies everywhere;
+// flags given on the command line override it.
+type Global struct {
+	// Profile is the browser profile nlm auth signs in with.
+	Profile string `yaml:"profile,omitempty"`
+
+	// Output sets the defaults of the output flags.
+	Output Output `yaml:"output"`
+
+	// Client replaces the default User-Agent and client hints. Empty
+	// fields keep the defaults.
+	Client ClientHeaders `yaml:"client,omitempty"`
+
+	// Frozen holds the headers pinned for each browser profile with nlm
+	// headers freeze. They are sent as they are, ignoring Client, so that
+	// an upgrade of nlm does not change what a signed-in session sends.
+	Frozen map[string]ClientHeaders `yaml:"frozen,omitempty"`
+
+	// Trash sets up the local copies nlm rm and nlm rm-source keep of
+	// what they delete.
+	Trash Trash `yaml:"trash,omitempty"`
+
+	// Endpoint replaces the host, path or proxy requests are sent to,
+	// and Endpoints does so for single browser profiles, field by field.
+	Endpoint  Endpoint            `yaml:"endpoint,omitempty"`
+	Endpoints map[string]Endpoint `yaml:"endpoints,omitempty"`
+
+	// ReadOnly refuses every call that would change data, as -read-only
+	// does; -read-only=false overrides it.
+	ReadOnly bool `yaml:"read_only,omitempty"`
+}
+
+// Endpoint is where batchexecute requests go. Empty fields keep the
+// defaults.
+type Endpoint struct {
+	// Host is the host name, with an optional port, such as
+	// notebooklm.google.com.
+	Host string `yaml:"host,omitempty"`
+	// Path is the path of the batchexecute handler, such as
+	// /_/LabsTailwindUi/data/batchexecute.
+	Path string `yaml:"path,omitempty"`
+	// Proxy is the URL of the proxy to use instead of the one in
+	// HTTPS_PROXY, such as the one a PAC file picks.
+	Proxy string `yaml:"proxy,omitempty"`
+	// CA is a PEM file of certificates to trust besides the system ones,
+	// such as the CA of an intercepting proxy.
+	CA string `yaml:"ca,omitempty"`
+}
+
+// EndpointFor returns the endpoint for the browser profile: the fields
+// set for it, with the rest from Endpoint.
+func (g *Global) EndpointFor(profile string) Endpoint {
+	e := g.Endpoint
+	if p, ok := g.Endpoints[profile]; ok {
+		if p.Host != "" {
+			e.Host = p.Host
+		}
+		if p.Path != "" {
+			e.Path = p.Path
+		}
+		if p.Proxy != "" {
+			e.Proxy = p.Proxy
+		}
+		if p.CA != "" {
+			e.CA = p.CA
+		}
+	}
+	return e
+}
+
+func (e Endpoint) validate() error {
+	if e.Host != "" {
+		if u, err := url.Parse("https://" + e.Host); err != nil || u.Host != e.Host {
+			return fmt.Errorf("host %q is not a host name, with an optional port", e.Host)
+		}
+	}
+	if e.Path != "" && !strings.HasPrefix(e.Path, "/") {
+		return fmt.Errorf("path %q does not start with /", e.Path)
+	}
+	if e.Proxy != "" {
+		u, err := url.Parse(e.Proxy)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("proxy %q is not a URL", e.Proxy)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return fmt.Errorf("proxy %q: want an http, https or socks5 URL", e.Proxy)
+		}
+	}
+	return nil
+}
+
+// Trash configures the local trash.
+type Trash struct {
+	// Enabled keeps a copy of notebooks and sources before they are
+	// deleted; -trash overrides it.
+	Enabled bool `yaml:"enabled,omitempty"`
+	// Keep is how long copies are kept, such as 30d or 2w. Empty means
+	// 30 days.
+	Keep string `yaml:"keep,omitempty"`
+}
+
+// ClientHeaders are the headers a browser identifies itself with.
+type ClientHeaders struct {
+	UserAgent string `yaml:"user_agent,omitempty"`
+	// Hints are client hint headers by name, such as sec-ch-ua-platform.
+	Hints map[string]string `yaml:"hints,omitempty"`
+}
+
+// Headers returns h as request headers with lower-case names.
+func (h ClientHeaders) Headers() map[string]string {
+	m := make(map[string]string, len(h.Hints)+1)
+	if h.UserAgent != "" {
+		m["user-agent"] = h.UserAgent
+	}
+	for k, v := range h.Hints {
+		m[strings.ToLower(k)] = v
+	}
+	return m
+}
+
+// ClientHeadersFor returns the headers to send for the browser profile:
+// those frozen for it, or else the configured overrides.
+func (g *Global) ClientHeadersFor(profile string) ClientHeaders {
+	if h, ok := g.Frozen[profile]; ok {
+		return h
+	}
+	return g.Client
+}
+
+func (h ClientHeaders) validate() error {
+	for k := range h.Hints {
+		if !strings.HasPrefix(strings.ToLower(k), "sec-ch-") {
+			return fmt.Errorf("hint %q is not a sec-ch-* header", k)
+		}
+	}
+	return nil
+}
+
+// Output holds the defaults of the output flags.
+type Output struct {
+	JSON    bool `yaml:"json,omitempty"`
+	NoColor bool `yaml:"no_color,omitempty"`
+	Wrap    bool `yaml:"wrap,omitempty"`
+}
+
+// ParseGlobal decodes a global configuration. Unknown keys are errors.
+func ParseGlobal(data []byte) (*Global, error) {
+	g := &Global{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(g); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("config: %s: %w", GlobalFileName, err)
+	}
+	if err := g.Client.validate(); err != nil {
+		return nil, fmt.Errorf("config: %s: client: %w", GlobalFileName, err)
+	}
+	for profile, h := range g.Frozen {
+		if err := h.validate(); err != nil {
+			return nil, fmt.Errorf("config: %s: frozen %s: %w", GlobalFileName, profile, err)
+		}
+	}
+	if err := g.Endpoint.validate(); err != nil {
+		return nil, fmt.Errorf("config: %s: endpoint: %w", GlobalFileName, err)
+	}
+	for profile, e := range g.Endpoints {
+		if err := e.validate(); err != nil {
+			return nil, fmt.Errorf("config: %s: endpoints %s: %w", GlobalFileName, profile, err)
+		}
+	}
+	return g, nil
+}
+
+// Marshal encodes g as YAML.
+func (g *Global) Marshal() ([]byte, error) {
+	return yaml.Marshal(g)
+}
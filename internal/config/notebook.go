@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// NotebookFlags are the flags that can be remembered for a notebook: where
+// files go and how listings are printed.
+var NotebookFlags = []string{"columns", "o", "output", "sort", "template"}
+
+// NotebookPathFlags are the NotebookFlags that name files or directories.
+// They are remembered as absolute paths, so that they name the same file
+// whatever directory nlm is run in.
+var NotebookPathFlags = []string{"o", "template"}
+
+// NotebookDefaults are flags remembered for notebooks, applied to commands
+// on a notebook when they are not given on the command line.
+type NotebookDefaults struct {
+	// Notebooks holds the flag values of each notebook, by notebook ID and
+	// flag name.
+	Notebooks map[string]map[string]string `json:"notebooks,omitempty"`
+}
+
+// Flags returns the flags remembered for a notebook.
+func (d *NotebookDefaults) Flags(notebookID string) map[string]string {
+	return d.Notebooks[notebookID]
+}
+
+// Set remembers a flag value for a notebook, or forgets the flag if value
+// is empty.
+func (d *NotebookDefaults) Set(notebookID, name, value string) error {
+	name = strings.TrimLeft(name, "-")
+	if !isNotebookFlag(name) {
+		return fmt.Errorf("-%s cannot be remembered (want one of -%s)", name, strings.Join(NotebookFlags, ", -"))
+	}
+	if value == "" {
+		delete(d.Notebooks[notebookID], name)
+		if len(d.Notebooks[notebookID]) == 0 {
+			delete(d.Notebooks, notebookID)
+		}
+		return nil
+	}
+	if d.Notebooks == nil {
+		d.Notebooks = make(map[string]map[string]string)
+	}
+	if d.Notebooks[notebookID] == nil {
+		d.Notebooks[notebookID] = make(map[string]string)
+	}
+	d.Notebooks[notebookID][name] = value
+	return nil
+}
+
+// FormatFlags formats flags as they would be given on the command line,
+// sorted by name, as in "-o notes -sort title".
+func FormatFlags(flags map[string]string) string {
+	names := make([]string, 0, len(flags))
+	for name := range flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		v := flags[name]
+		if v == "" || strings.ContainsAny(v, " \t\"'") {
+			v = fmt.Sprintf("%q", v)
+		}
+		fmt.Fprintf(&b, "-%s %s", name, v)
+	}
+	return b.String()
+}
+
+func isNotebookFlag(name string) bool {
+	for _, f := range NotebookFlags {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNotebookDefaults(t *testing.T) {
+	var d NotebookDefaults
+	if err := d.Set("nb1", "-o", "notes dir"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Set("nb1", "sort", "title"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Set("nb2", "columns", "id,title"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := FormatFlags(d.Flags("nb1")), `-o "notes dir" -sort title`; got != want {
+		t.Errorf("nb1 flags = %s, want %s", got, want)
+	}
+	if err := d.Set("nb1", "debug", "true"); err == nil || !strings.Contains(err.Error(), "-columns") {
+		t.Errorf("Set(debug): err = %v, want the flags that can be remembered", err)
+	}
+
+	d.Set("nb2", "columns", "")
+	if _, ok := d.Notebooks["nb2"]; ok {
+		t.Error("forgetting a notebook's last flag kept the notebook")
+	}
+	d.Set("nb1", "o", "")
+	if got := FormatFlags(d.Flags("nb1")); got != "-sort title" {
+		t.Errorf("nb1 flags after forgetting -o = %s", got)
+	}
+	if got := d.Flags("missing"); len(got) != 0 {
+		t.Errorf("flags of an unknown notebook = %v", got)
+	}
+}
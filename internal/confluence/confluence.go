@@ -0,0 +1,196 @@
+// Package confluence reads the pages of a Confluence space with the
+// Confluence REST API and converts them from Confluence's storage format
+// to Markdown-style text.
+package confluence
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tmc/nlm/internal/convert"
+)
+
+// A Page is a Confluence page. Storage is only set by Client.Page.
+type Page struct {
+	ID      string
+	Title   string
+	Version int
+	URL     string
+	Storage string // body in storage format (XHTML with Confluence macros)
+}
+
+// A Client reads a Confluence site. BaseURL is the site's address, such
+// as https://confluence.example.com or, for Confluence Cloud,
+// https://example.atlassian.net/wiki. Token is a personal access token,
+// sent as a bearer token, or with User set, a Cloud API token sent with
+// basic authentication.
+type Client struct {
+	HTTP      *http.Client // defaults to a client with a 60s timeout
+	BaseURL   string
+	Token     string
+	User      string
+	UserAgent string
+}
+
+// pageSize is the number of pages listed per request.
+const pageSize = 50
+
+// Pages lists the current pages of a space, without their bodies.
+func (c *Client) Pages(ctx context.Context, space string) ([]Page, error) {
+	v := url.Values{
+		"spaceKey": {space},
+		"type":     {"page"},
+		"status":   {"current"},
+		"expand":   {"version"},
+		"limit":    {strconv.Itoa(pageSize)},
+	}
+	next := "/rest/api/content?" + v.Encode()
+	var pages []Page
+	for next != "" {
+		var r struct {
+			Results []content `json:"results"`
+			Links   struct {
+				Next string `json:"next"`
+			} `json:"_links"`
+		}
+		if err := c.get(ctx, next, &r); err != nil {
+			return nil, err
+		}
+		for _, ct := range r.Results {
+			pages = append(pages, c.page(ct))
+		}
+		next = r.Links.Next
+	}
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("confluence: no pages in space %s", space)
+	}
+	return pages, nil
+}
+
+// Page returns a page with its body.
+func (c *Client) Page(ctx context.Context, id string) (*Page, error) {
+	var ct content
+	if err := c.get(ctx, "/rest/api/content/"+url.PathEscape(id)+"?expand=body.storage,version", &ct); err != nil {
+		return nil, err
+	}
+	p := c.page(ct)
+	p.Storage = ct.Body.Storage.Value
+	return &p, nil
+}
+
+// content is a page as the REST API returns it.
+type content struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Version struct {
+		Number int `json:"number"`
+	} `json:"version"`
+	Body struct {
+		Storage struct {
+			Value string `json:"value"`
+		} `json:"storage"`
+	} `json:"body"`
+	Links struct {
+		WebUI string `json:"webui"`
+	} `json:"_links"`
+}
+
+func (c *Client) page(ct content) Page {
+	p := Page{ID: ct.ID, Title: ct.Title, Version: ct.Version.Number}
+	if ct.Links.WebUI != "" {
+		p.URL = strings.TrimSuffix(c.BaseURL, "/") + ct.Links.WebUI
+	}
+	return p
+}
+
+func (c *Client) get(ctx context.Context, path string, v interface{}) error {
+	if c.BaseURL == "" {
+		return errors.New("confluence: no site URL")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(c.BaseURL, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	switch {
+	case c.User != "":
+		req.SetBasicAuth(c.User, c.Token)
+	case c.Token != "":
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	client := c.HTTP
+	if client == nil {
+		client = &http.Client{Timeout: 60 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("confluence: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		var e struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&e)
+		if e.Message != "" {
+			return fmt.Errorf("confluence: %s: %s", resp.Status, e.Message)
+		}
+		return fmt.Errorf("confluence: %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("confluence: parse response: %w", err)
+	}
+	return nil
+}
+
+var (
+	codeMacroRE = regexp.MustCompile(`(?s)<ac:structured-macro[^>]*ac:name="(?:code|noformat)"[^>]*>.*?<ac:plain-text-body>\s*<!\[CDATA\[(.*?)\]\]>\s*</ac:plain-text-body>.*?</ac:structured-macro>`)
+	cdataRE     = regexp.MustCompile(`(?s)<!\[CDATA\[(.*?)\]\]>`)
+	parameterRE = regexp.MustCompile(`(?s)<ac:parameter\b[^>]*>.*?</ac:parameter>`)
+	panelRE     = regexp.MustCompile(`<ac:structured-macro[^>]*ac:name="(info|note|warning|tip)"[^>]*>`)
+	pageRefRE   = regexp.MustCompile(`<ri:page\b[^>]*ri:content-title="([^"]*)"[^>]*/>`)
+	taskRE      = regexp.MustCompile(`(?s)<ac:task>\s*(?:<ac:task-id>[^<]*</ac:task-id>\s*)?<ac:task-status>([^<]*)</ac:task-status>`)
+	dropRE      = regexp.MustCompile(`(?s)<ac:(?:emoticon|image|placeholder)\b.*?(?:/>|</ac:(?:emoticon|image|placeholder)>)|<ri:[^>]*/>`)
+)
+
+// Markdown converts a page body in storage format to Markdown-style text.
+// Code blocks, panels, task lists and links to other pages are kept;
+// images, emoticons and macros without text are dropped.
+func Markdown(storage string) (string, error) {
+	s := codeMacroRE.ReplaceAllStringFunc(storage, func(m string) string {
+		return "<pre>" + html.EscapeString(codeMacroRE.FindStringSubmatch(m)[1]) + "</pre>"
+	})
+	s = cdataRE.ReplaceAllStringFunc(s, func(m string) string {
+		return html.EscapeString(cdataRE.FindStringSubmatch(m)[1])
+	})
+	s = parameterRE.ReplaceAllString(s, "")
+	s = panelRE.ReplaceAllStringFunc(s, func(m string) string {
+		return "<p><strong>" + strings.ToUpper(panelRE.FindStringSubmatch(m)[1]) + ":</strong></p>"
+	})
+	s = pageRefRE.ReplaceAllString(s, "$1")
+	s = taskRE.ReplaceAllStringFunc(s, func(m string) string {
+		if taskRE.FindStringSubmatch(m)[1] == "complete" {
+			return "<li>[x] "
+		}
+		return "<li>[ ] "
+	})
+	s = strings.NewReplacer("<ac:task-list>", "<ul>", "</ac:task-list>", "</ul>", "</ac:task>", "</li>").Replace(s)
+	s = dropRE.ReplaceAllString(s, "")
+	p, err := convert.ReadHTML(strings.NewReader(s))
+	if err != nil {
+		return "", err
+	}
+	return p.Text, nil
+}
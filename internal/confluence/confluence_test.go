@@ -0,0 +1,94 @@
+package confluence
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPages(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer pat" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"statusCode":401,"message":"Authentication required"}`))
+			return
+		}
+		switch {
+		case r.URL.Path == "/rest/api/content" && r.URL.Query().Get("start") == "":
+			if r.URL.Query().Get("spaceKey") != "ENG" {
+				t.Errorf("spaceKey = %q", r.URL.Query().Get("spaceKey"))
+			}
+			w.Write([]byte(`{"results":[{"id":"1","title":"Home","version":{"number":3},"_links":{"webui":"/display/ENG/Home"}}],
+				"_links":{"next":"/rest/api/content?spaceKey=ENG&start=1"}}`))
+		case r.URL.Path == "/rest/api/content":
+			w.Write([]byte(`{"results":[{"id":"2","title":"Runbook","version":{"number":1}}],"_links":{}}`))
+		case r.URL.Path == "/rest/api/content/2":
+			w.Write([]byte(`{"id":"2","title":"Runbook","version":{"number":1},"body":{"storage":{"value":"<p>Restart it.</p>"}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	c := &Client{BaseURL: srv.URL, Token: "pat"}
+	got, err := c.Pages(context.Background(), "ENG")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Page{
+		{ID: "1", Title: "Home", Version: 3, URL: srv.URL + "/display/ENG/Home"},
+		{ID: "2", Title: "Runbook", Version: 1},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Pages mismatch (-want +got):\n%s", diff)
+	}
+	p, err := c.Page(context.Background(), "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Storage != "<p>Restart it.</p>" {
+		t.Errorf("Storage = %q", p.Storage)
+	}
+
+	c.Token = "wrong"
+	if _, err := c.Pages(context.Background(), "ENG"); err == nil || !strings.Contains(err.Error(), "Authentication required") {
+		t.Errorf("Pages with a bad token: err = %v", err)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	storage := `<h1>Deploying</h1><p>See <ac:link><ri:page ri:content-title="Runbook" /></ac:link> first.</p>` +
+		`<ac:structured-macro ac:name="info" ac:schema-version="1"><ac:parameter ac:name="title">Heads up</ac:parameter>` +
+		`<ac:rich-text-body><p>Deploys freeze on Fridays.</p></ac:rich-text-body></ac:structured-macro>` +
+		`<ac:structured-macro ac:name="code"><ac:parameter ac:name="language">bash</ac:parameter>` +
+		`<ac:plain-text-body><![CDATA[make deploy ENV=prod && echo "<done>"]]></ac:plain-text-body></ac:structured-macro>` +
+		`<ac:task-list><ac:task><ac:task-id>1</ac:task-id><ac:task-status>complete</ac:task-status><ac:task-body>Tag release</ac:task-body></ac:task>` +
+		`<ac:task><ac:task-id>2</ac:task-id><ac:task-status>incomplete</ac:task-status><ac:task-body>Announce</ac:task-body></ac:task></ac:task-list>` +
+		`<p><ac:emoticon ac:name="smile" /><ac:image><ri:attachment ri:filename="diagram.png" /></ac:image></p>`
+	got, err := Markdown(storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# Deploying",
+		"See Runbook first.",
+		"INFO:",
+		"Deploys freeze on Fridays.",
+		"```\nmake deploy ENV=prod && echo \"<done>\"\n```",
+		"- [x] Tag release",
+		"- [ ] Announce",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Markdown() missing %q in:\n%s", want, got)
+		}
+	}
+	for _, unwanted := range []string{"Heads up", "bash", "diagram.png", "smile"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("Markdown() kept %q in:\n%s", unwanted, got)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package convert
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// bibEntry is a BibTeX entry or a \bibitem from a thebibliography
+// environment. Field values are already rendered to plain text.
+type bibEntry struct {
+	Fields map[string]string
+	Label  string // \bibitem[label], if given
+	Text   string // \bibitem text
+}
+
+var authorSepRE = regexp.MustCompile(`\s+and\s+`)
+
+// surnames returns "Knuth", "Knuth and Plass" or "Knuth et al.".
+func (e bibEntry) surnames() string {
+	authors := e.Fields["author"]
+	if authors == "" {
+		authors = e.Fields["editor"]
+	}
+	if authors == "" {
+		return ""
+	}
+	var names []string
+	for _, a := range authorSepRE.Split(authors, -1) {
+		if last, _, ok := strings.Cut(a, ","); ok {
+			names = append(names, strings.TrimSpace(last))
+		} else if f := strings.Fields(a); len(f) > 0 {
+			names = append(names, f[len(f)-1])
+		}
+	}
+	switch len(names) {
+	case 0:
+		return ""
+	case 1:
+		return names[0]
+	case 2:
+		return names[0] + " and " + names[1]
+	}
+	return names[0] + " et al."
+}
+
+// short is the inline form of a citation: "Knuth 1984, Literate Programming".
+func (e bibEntry) short() string {
+	if e.Fields == nil {
+		if e.Label != "" {
+			return e.Label
+		}
+		text := strings.TrimSuffix(e.Text, ".")
+		if len(text) > 80 {
+			if i := strings.LastIndexByte(text[:80], ' '); i > 0 {
+				text = text[:i] + "…"
+			}
+		}
+		return text
+	}
+	who := strings.TrimSpace(e.surnames() + " " + e.Fields["year"])
+	title := e.Fields["title"]
+	switch {
+	case who == "":
+		return title
+	case title == "":
+		return who
+	}
+	return who + ", " + title
+}
+
+// reference is the form listed under References.
+func (e bibEntry) reference() string {
+	if e.Fields == nil {
+		return e.Text
+	}
+	var parts []string
+	head := e.Fields["author"]
+	if head == "" {
+		head = e.Fields["editor"]
+	}
+	if y := e.Fields["year"]; y != "" {
+		head = strings.TrimSpace(head + " (" + y + ")")
+	}
+	parts = append(parts, head, e.Fields["title"])
+	venue := e.Fields["journal"]
+	if venue == "" {
+		venue = e.Fields["booktitle"]
+	}
+	if venue == "" {
+		venue = e.Fields["publisher"]
+	}
+	if v := e.Fields["volume"]; v != "" && venue != "" {
+		venue += " " + v
+		if n := e.Fields["number"]; n != "" {
+			venue += "(" + n + ")"
+		}
+	}
+	parts = append(parts, venue)
+	if u := e.Fields["doi"]; u != "" {
+		parts = append(parts, "https://doi.org/"+u)
+	} else if u := e.Fields["url"]; u != "" {
+		parts = append(parts, u)
+	}
+	var out []string
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, strings.TrimSuffix(p, "."))
+		}
+	}
+	return strings.Join(out, ". ") + "."
+}
+
+// loadBib reads a .bib file next to the document.
+func (c *texConverter) loadBib(name string) {
+	if c.dir == "" || name == "" {
+		return
+	}
+	path := filepath.Join(c.dir, name)
+	if filepath.Ext(path) != ".bib" {
+		path += ".bib"
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	for key, fields := range parseBib(string(data)) {
+		for k, v := range fields {
+			fields[k] = oneLine(c.render(v, false))
+		}
+		c.bib[key] = bibEntry{Fields: fields}
+	}
+}
+
+var bibitemRE = regexp.MustCompile(`\\bibitem\s*(?:\[([^\]]*)\])?\s*\{([^}]*)\}`)
+
+// loadBibItems records the entries of a thebibliography environment.
+func (c *texConverter) loadBibItems(src string) {
+	i := strings.Index(src, `\begin{thebibliography}`)
+	if i < 0 {
+		return
+	}
+	body, _ := environment(src, i+len(`\begin{thebibliography}`), "thebibliography")
+	items := bibitemRE.FindAllStringSubmatchIndex(body, -1)
+	for n, m := range items {
+		end := len(body)
+		if n+1 < len(items) {
+			end = items[n+1][0]
+		}
+		e := bibEntry{Text: oneLine(c.render(body[m[1]:end], false))}
+		if m[2] >= 0 {
+			e.Label = oneLine(c.render(body[m[2]:m[3]], false))
+		}
+		if _, ok := c.bib[body[m[4]:m[5]]]; !ok {
+			c.bib[strings.TrimSpace(body[m[4]:m[5]])] = e
+		}
+	}
+}
+
+// parseBib returns the raw fields of each entry in a BibTeX file, keyed
+// by citation key. Field names are lower-cased.
+func parseBib(s string) map[string]map[string]string {
+	entries := map[string]map[string]string{}
+	for i := 0; i < len(s); {
+		at := strings.IndexByte(s[i:], '@')
+		if at < 0 {
+			break
+		}
+		i += at + 1
+		j := i
+		for j < len(s) && isLetter(s[j]) {
+			j++
+		}
+		kind := strings.ToLower(s[i:j])
+		j = skipSpace(s, j)
+		if j >= len(s) || (s[j] != '{' && s[j] != '(') {
+			i = j
+			continue
+		}
+		closer := byte('}')
+		if s[j] == '(' {
+			closer = ')'
+		}
+		if kind == "comment" || kind == "preamble" || kind == "string" {
+			if s[j] == '{' {
+				_, i, _ = group(s, j)
+			} else {
+				i = j + 1
+			}
+			continue
+		}
+		comma := strings.IndexByte(s[j:], ',')
+		if comma < 0 {
+			break
+		}
+		key := strings.TrimSpace(s[j+1 : j+comma])
+		fields := map[string]string{}
+		i = j + comma + 1
+		for i < len(s) {
+			i = skipSpace(s, i)
+			for i < len(s) && (s[i] == ',' || s[i] == ' ' || s[i] == '\n' || s[i] == '\t' || s[i] == '\r') {
+				i++
+			}
+			if i >= len(s) || s[i] == closer {
+				i++
+				break
+			}
+			eq := strings.IndexByte(s[i:], '=')
+			if eq < 0 {
+				i = len(s)
+				break
+			}
+			name := strings.ToLower(strings.TrimSpace(s[i : i+eq]))
+			var value string
+			value, i = bibValue(s, i+eq+1, closer)
+			fields[name] = value
+		}
+		entries[key] = fields
+	}
+	return entries
+}
+
+// bibValue reads a field value: brace groups, quoted strings and bare
+// words, joined with #.
+func bibValue(s string, i int, closer byte) (string, int) {
+	var b strings.Builder
+	for {
+		i = skipSpace(s, i)
+		if i >= len(s) {
+			return b.String(), i
+		}
+		switch s[i] {
+		case '{':
+			v, j, _ := group(s, i)
+			b.WriteString(v)
+			i = j
+		case '"':
+			j, depth := i+1, 0
+			for ; j < len(s); j++ {
+				if s[j] == '{' {
+					depth++
+				} else if s[j] == '}' {
+					depth--
+				} else if s[j] == '"' && depth == 0 && s[j-1] != '\\' {
+					break
+				}
+			}
+			b.WriteString(s[i+1 : min(j, len(s))])
+			i = j + 1
+		default:
+			j := i
+			for j < len(s) && s[j] != ',' && s[j] != closer && s[j] != '#' && s[j] != '\n' {
+				j++
+			}
+			b.WriteString(strings.TrimSpace(s[i:j]))
+			i = j
+		}
+		i = skipSpace(s, i)
+		if i < len(s) && s[i] == '#' {
+			i++
+			continue
+		}
+		return b.String(), i
+	}
+}
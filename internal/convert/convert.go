@@ -0,0 +1,146 @@
+// Package convert turns files NotebookLM cannot read into text before they
+// are uploaded.
+//
+// Converters are keyed by file extension. The simplest converter is any
+// program that reads the document on stdin and writes Markdown or plain
+// text to stdout, so users can add formats without patching nlm.
+package convert
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrNoConverter is returned by Registry.File when no converter is
+// registered for a file's extension.
+var ErrNoConverter = errors.New("no converter")
+
+// A Converter reads a document and writes its text.
+type Converter interface {
+	// Convert reads the document called name from r and writes the
+	// converted text to w.
+	Convert(ctx context.Context, name string, r io.Reader, w io.Writer) error
+}
+
+// Func adapts a function to the Converter interface.
+type Func func(ctx context.Context, name string, r io.Reader, w io.Writer) error
+
+// Convert calls f.
+func (f Func) Convert(ctx context.Context, name string, r io.Reader, w io.Writer) error {
+	return f(ctx, name, r, w)
+}
+
+// Command is a converter run as a subprocess. The document is written to
+// its standard input and the converted text is read from its standard
+// output. The document's file name is passed in the NLM_CONVERT_NAME
+// environment variable.
+type Command struct {
+	Args []string // program and arguments
+}
+
+// Convert runs the command.
+func (c Command) Convert(ctx context.Context, name string, r io.Reader, w io.Writer) error {
+	if len(c.Args) == 0 {
+		return errors.New("convert: empty command")
+	}
+	cmd := exec.CommandContext(ctx, c.Args[0], c.Args[1:]...)
+	cmd.Env = append(os.Environ(), "NLM_CONVERT_NAME="+name)
+	cmd.Stdin = r
+	cmd.Stdout = w
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("convert: %s: %w: %s", c.Args[0], err, msg)
+		}
+		return fmt.Errorf("convert: %s: %w", c.Args[0], err)
+	}
+	return nil
+}
+
+type dirKey struct{}
+
+// WithDir returns a context that tells converters which directory the
+// document is in, for formats that refer to neighbouring files such as
+// LaTeX's \input and \bibliography.
+func WithDir(ctx context.Context, dir string) context.Context {
+	return context.WithValue(ctx, dirKey{}, dir)
+}
+
+// Dir returns the directory recorded by WithDir, or "".
+func Dir(ctx context.Context) string {
+	dir, _ := ctx.Value(dirKey{}).(string)
+	return dir
+}
+
+// Registry maps file extensions to converters.
+type Registry struct {
+	m map[string]Converter
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{m: make(map[string]Converter)}
+}
+
+// Register sets the converter for files with extension ext, replacing any
+// previous one. The extension is matched case-insensitively, with or
+// without its leading dot.
+func (r *Registry) Register(ext string, c Converter) {
+	r.m[normalize(ext)] = c
+}
+
+// Lookup returns the converter for the file called name.
+func (r *Registry) Lookup(name string) (Converter, bool) {
+	if r == nil {
+		return nil, false
+	}
+	c, ok := r.m[normalize(filepath.Ext(name))]
+	return c, ok
+}
+
+// Extensions returns the registered extensions in sorted order.
+func (r *Registry) Extensions() []string {
+	var exts []string
+	for ext := range r.m {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return exts
+}
+
+// File converts the file at path. It returns ErrNoConverter if no
+// converter handles the file's extension.
+func (r *Registry) File(ctx context.Context, path string) ([]byte, error) {
+	c, ok := r.Lookup(path)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", path, ErrNoConverter)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var buf bytes.Buffer
+	ctx = WithDir(ctx, filepath.Dir(path))
+	if err := c.Convert(ctx, filepath.Base(path), f, &buf); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return buf.Bytes(), nil
+}
+
+func normalize(ext string) string {
+	ext = strings.ToLower(ext)
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
@@ -0,0 +1,98 @@
+package convert
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRegistryLookup(t *testing.T) {
+	upper := Func(func(_ context.Context, _ string, r io.Reader, w io.Writer) error {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, strings.ToUpper(string(data)))
+		return err
+	})
+	r := NewRegistry()
+	r.Register("IPYNB", upper)
+	r.Register(".rst", upper)
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"analysis.ipynb", true},
+		{"ANALYSIS.IPYNB", true},
+		{"dir/notes.rst", true},
+		{"notes.md", false},
+		{"Makefile", false},
+	}
+	for _, tt := range tests {
+		if _, ok := r.Lookup(tt.name); ok != tt.want {
+			t.Errorf("Lookup(%q) = %v, want %v", tt.name, ok, tt.want)
+		}
+	}
+	if diff := cmp.Diff([]string{".ipynb", ".rst"}, r.Extensions()); diff != "" {
+		t.Errorf("Extensions() mismatch (-want +got):\n%s", diff)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.rst")
+	if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := r.File(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "HELLO" {
+		t.Errorf("File() = %q, want %q", got, "HELLO")
+	}
+	if _, err := r.File(context.Background(), filepath.Join(dir, "a.md")); !errors.Is(err, ErrNoConverter) {
+		t.Errorf("File(a.md) error = %v, want ErrNoConverter", err)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh")
+	}
+	tests := []struct {
+		name    string
+		script  string
+		want    string
+		wantErr string
+	}{
+		{"stdin to stdout", `tr a-z A-Z`, "DOC", ""},
+		{"name in environment", `cat >/dev/null; printf %s "$NLM_CONVERT_NAME"`, "doc.x", ""},
+		{"failure includes stderr", `echo broken >&2; exit 3`, "", "broken"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			err := Command{Args: []string{sh, "-c", tt.script}}.Convert(context.Background(), "doc.x", strings.NewReader("doc"), &out)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Convert() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("Convert() = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
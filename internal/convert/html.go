@@ -0,0 +1,375 @@
+package convert
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// A Page is the readable content of an HTML document.
+type Page struct {
+	Title string
+	// Text is the content as Markdown-style text: headings, list items
+	// and preformatted blocks are kept, and markup is removed.
+	Text string
+	// Links are the targets of the page's links, as written, including
+	// those in navigation.
+	Links []string
+}
+
+// HTML returns a converter from HTML to text. Only the main content is
+// kept when the page marks it with <main> or <article>; navigation,
+// headers, footers, scripts and styles are dropped.
+func HTML() Converter {
+	return Func(func(_ context.Context, name string, r io.Reader, w io.Writer) error {
+		p, err := ReadHTML(r)
+		if err != nil {
+			return fmt.Errorf("html: %w", err)
+		}
+		if p.Title != "" {
+			if _, err := fmt.Fprintf(w, "# %s\n\n", p.Title); err != nil {
+				return err
+			}
+		}
+		_, err = io.WriteString(w, p.Text)
+		return err
+	})
+}
+
+var (
+	// rawTextRE matches comments and elements whose content is not
+	// markup.
+	rawTextRE    = regexp.MustCompile(`(?is)<(script|style|noscript|template)\b.*?</(script|style|noscript|template)\s*>|<!--.*?-->`)
+	htmlSpacesRE = regexp.MustCompile(`[ \t]{2,}`)
+	htmlBlankRE  = regexp.MustCompile(`\n{3,}`)
+)
+
+// skipped elements hold no readable content.
+var skipped = map[string]bool{
+	"head": true, "nav": true, "header": true, "footer": true, "aside": true,
+	"form": true, "button": true, "svg": true, "iframe": true, "select": true,
+}
+
+// blocks are elements that start a new paragraph.
+var blocks = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "main": true,
+	"table": true, "tr": true, "ul": true, "ol": true, "dl": true, "dt": true,
+	"dd": true, "blockquote": true, "figure": true, "figcaption": true, "hr": true,
+}
+
+// ReadHTML extracts the title and readable text of an HTML document. The
+// parser is lenient: unclosed, mismatched and unknown elements are
+// tolerated, as browsers do.
+func ReadHTML(r io.Reader) (*Page, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		out      strings.Builder
+		title    strings.Builder
+		h1       string
+		heading  *strings.Builder
+		main     []string
+		mainAt   = -1
+		mainName string
+		mainOpen int
+		skipName string
+		skip     int
+		pre      int
+		lists    int
+		inTitle  bool
+	)
+	var links []string
+	start := func(name, attrs string) {
+		if name == "a" {
+			if href, ok := attr(attrs, "href"); ok && href != "" {
+				links = append(links, href)
+			}
+		}
+		switch {
+		case name == "title":
+			inTitle = true
+			return
+		case skip > 0:
+			if name == skipName {
+				skip++
+			}
+			return
+		case skipped[name]:
+			skipName, skip = name, 1
+			return
+		}
+		switch {
+		case name == mainName:
+			mainOpen++
+		case (name == "main" || name == "article") && mainAt < 0:
+			mainAt, mainName, mainOpen = out.Len(), name, 1
+			out.WriteString("\n\n")
+		case isHeading(name):
+			out.WriteString("\n\n" + strings.Repeat("#", int(name[1]-'0')) + " ")
+			if name == "h1" && h1 == "" {
+				heading = &strings.Builder{}
+			}
+		case name == "li":
+			out.WriteString("\n" + strings.Repeat("  ", max(lists-1, 0)) + "- ")
+		case name == "ul" || name == "ol":
+			if lists == 0 {
+				out.WriteString("\n")
+			}
+			lists++
+		case name == "pre":
+			pre++
+			out.WriteString("\n\n```\n")
+		case name == "code" && pre == 0:
+			out.WriteString("`")
+		case name == "br":
+			out.WriteString("\n")
+		case name == "td" || name == "th":
+			out.WriteString(" ")
+		case blocks[name]:
+			out.WriteString("\n\n")
+		}
+	}
+	end := func(name string) {
+		switch {
+		case name == "title":
+			inTitle = false
+			return
+		case skip > 0:
+			if name == skipName {
+				skip--
+			}
+			return
+		}
+		switch {
+		case name == mainName && mainAt >= 0:
+			if mainOpen--; mainOpen == 0 {
+				main = append(main, out.String()[mainAt:])
+				mainAt, mainName = -1, ""
+			}
+		case isHeading(name):
+			if heading != nil {
+				h1, heading = strings.Join(strings.Fields(heading.String()), " "), nil
+			}
+			out.WriteString("\n\n")
+		case name == "ul" || name == "ol":
+			if lists > 0 {
+				lists--
+			}
+			if lists == 0 {
+				out.WriteString("\n\n")
+			}
+		case name == "pre":
+			if pre > 0 {
+				pre--
+				out.WriteString("\n```\n\n")
+			}
+		case name == "code" && pre == 0:
+			out.WriteString("`")
+		case blocks[name]:
+			out.WriteString("\n\n")
+		}
+	}
+	text := func(t string) {
+		switch {
+		case inTitle:
+			title.WriteString(t)
+		case skip > 0:
+		case pre > 0:
+			out.WriteString(t)
+		default:
+			s := strings.Join(strings.Fields(t), " ")
+			if s == "" {
+				if t != "" {
+					out.WriteString(" ")
+				}
+				return
+			}
+			if isSpace(t[0]) {
+				out.WriteString(" ")
+			}
+			out.WriteString(s)
+			if isSpace(t[len(t)-1]) {
+				out.WriteString(" ")
+			}
+			if heading != nil {
+				heading.WriteString(s + " ")
+			}
+		}
+	}
+	tokenize(rawTextRE.ReplaceAllString(string(data), ""), start, end, text)
+
+	body := out.String()
+	if mainAt >= 0 {
+		main = append(main, body[mainAt:])
+	}
+	if len(main) > 0 {
+		body = strings.Join(main, "\n\n")
+	}
+	p := &Page{
+		Title: strings.Join(strings.Fields(title.String()), " "),
+		Text:  tidyText(body),
+		Links: links,
+	}
+	if p.Title == "" {
+		p.Title = h1
+	}
+	return p, nil
+}
+
+// tokenize splits HTML into tags and text, calling start with lowercased
+// element names and their raw attributes, end with element names, and
+// text with unescaped character data. Declarations are skipped and stray
+// angle brackets kept as text.
+func tokenize(s string, start func(name, attrs string), end func(name string), text func(string)) {
+	for s != "" {
+		i := strings.IndexByte(s, '<')
+		if i < 0 {
+			text(html.UnescapeString(s))
+			return
+		}
+		if i > 0 {
+			text(html.UnescapeString(s[:i]))
+			s = s[i:]
+		}
+		closing := len(s) > 1 && s[1] == '/'
+		j := 1
+		if closing {
+			j = 2
+		}
+		n := j
+		for n < len(s) && (isLetter(s[n]) || (n > j && (s[n] >= '0' && s[n] <= '9' || s[n] == '-'))) {
+			n++
+		}
+		if n == j {
+			if len(s) > 1 && (s[1] == '!' || s[1] == '?') {
+				if k := strings.IndexByte(s, '>'); k >= 0 {
+					s = s[k+1:]
+					continue
+				}
+				return
+			}
+			text("<")
+			s = s[1:]
+			continue
+		}
+		name := strings.ToLower(s[j:n])
+		k := tagEnd(s, n)
+		if k < 0 {
+			return
+		}
+		if closing {
+			end(name)
+		} else {
+			start(name, s[n:k])
+			if s[k-1] == '/' && !isVoid[name] {
+				end(name)
+			}
+		}
+		s = s[k+1:]
+	}
+}
+
+// tagEnd returns the index of the '>' closing the tag whose attributes
+// start at i, skipping quoted attribute values, or -1.
+func tagEnd(s string, i int) int {
+	var quote byte
+	for ; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return i
+		}
+	}
+	return -1
+}
+
+// attr returns the unescaped value of the named attribute in the raw
+// attributes of a start tag.
+func attr(attrs, name string) (string, bool) {
+	for attrs != "" {
+		attrs = strings.TrimLeft(attrs, " \t\r\n/")
+		n := 0
+		for n < len(attrs) && !strings.ContainsRune(" \t\r\n=/>", rune(attrs[n])) {
+			n++
+		}
+		if n == 0 {
+			return "", false
+		}
+		key := strings.ToLower(attrs[:n])
+		attrs = strings.TrimLeft(attrs[n:], " \t\r\n")
+		var value string
+		if strings.HasPrefix(attrs, "=") {
+			attrs = strings.TrimLeft(attrs[1:], " \t\r\n")
+			switch {
+			case attrs == "":
+			case attrs[0] == '"' || attrs[0] == '\'':
+				end := strings.IndexByte(attrs[1:], attrs[0])
+				if end < 0 {
+					value, attrs = attrs[1:], ""
+				} else {
+					value, attrs = attrs[1:end+1], attrs[end+2:]
+				}
+			default:
+				end := strings.IndexAny(attrs, " \t\r\n")
+				if end < 0 {
+					end = len(attrs)
+				}
+				value, attrs = attrs[:end], attrs[end:]
+			}
+		}
+		if key == name {
+			return html.UnescapeString(value), true
+		}
+	}
+	return "", false
+}
+
+// isVoid elements have no end tag.
+var isVoid = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true,
+	"img": true, "input": true, "link": true, "meta": true, "source": true, "wbr": true,
+}
+
+func isHeading(name string) bool {
+	return len(name) == 2 && name[0] == 'h' && name[1] >= '1' && name[1] <= '6'
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
+
+// tidyText trims lines and collapses runs of spaces and blank lines,
+// leaving the lines of fenced code blocks as they are.
+func tidyText(s string) string {
+	lines := strings.Split(s, "\n")
+	fenced := false
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "```" {
+			fenced = !fenced
+			lines[i] = "```"
+			continue
+		}
+		if fenced {
+			lines[i] = strings.TrimRight(line, " \t\r")
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		line = strings.TrimSpace(htmlSpacesRE.ReplaceAllString(line, " "))
+		if strings.HasPrefix(line, "- ") {
+			line = strings.Repeat(" ", indent) + line
+		}
+		lines[i] = line
+	}
+	s = htmlBlankRE.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(s) + "\n"
+}
@@ -0,0 +1,65 @@
+package convert
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testHTML = `<!DOCTYPE html>
+<html><head><title>Install &amp; Setup | Docs</title><meta charset=utf-8>
+<script>if (a < b && c) { x = "</div>"; }</script><style>p { color: red }</style></head>
+<body><nav><a href=/>Home</a> <a href=/guide/>Guide</a></nav>
+<main><h1>Install</h1>
+<p>Run the <code>nlm</code> installer.<br>Then log in.</p>
+<!-- <p>hidden</p> -->
+<ul><li>First<ul><li>Nested</li></ul></li><li>Second &mdash; done</li></ul>
+<pre>func main() {
+    fmt.Println("hi")
+}</pre>
+<p>Unclosed paragraph
+<p>Another &nbsp; one <img src="a>b.png"> 3 < 4</div></main>
+<footer>Copyright</footer></body></html>`
+
+func TestHTML(t *testing.T) {
+	var b strings.Builder
+	if err := HTML().Convert(context.Background(), "install.html", strings.NewReader(testHTML), &b); err != nil {
+		t.Fatal(err)
+	}
+	want := "# Install & Setup | Docs\n\n" +
+		"# Install\n\n" +
+		"Run the `nlm` installer.\nThen log in.\n\n" +
+		"- First\n  - Nested\n- Second — done\n\n" +
+		"```\nfunc main() {\n    fmt.Println(\"hi\")\n}\n```\n\n" +
+		"Unclosed paragraph\n\n" +
+		"Another one 3 < 4\n"
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("HTML mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReadHTMLFallbacks(t *testing.T) {
+	// Without <main> the whole body is kept, and without <title> the first
+	// heading names the page.
+	p, err := ReadHTML(strings.NewReader(`<body><header>Site</header><h1>Quick <em>start</em></h1><div>Body text</div><h1>Other</h1>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Page{Title: "Quick start", Text: "# Quick start\n\nBody text\n\n# Other\n"}
+	if diff := cmp.Diff(want, p); diff != "" {
+		t.Errorf("ReadHTML mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestHTMLLinks(t *testing.T) {
+	p, err := ReadHTML(strings.NewReader(testHTML + `<a class=x HREF='/a?b=1&amp;c=2'>q</a><a name=top>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"/", "/guide/", "/a?b=1&c=2"}
+	if diff := cmp.Diff(want, p.Links); diff != "" {
+		t.Errorf("links mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,158 @@
+package convert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// NotebookOptions controls Jupyter notebook conversion.
+type NotebookOptions struct {
+	// Outputs includes the text outputs of code cells. Images and other
+	// binary outputs are replaced by a placeholder.
+	Outputs bool
+}
+
+// Notebook returns a converter from Jupyter notebooks (.ipynb, nbformat 4)
+// to Markdown. Markdown cells are copied, code cells become fenced blocks
+// in the kernel's language and raw cells are dropped.
+func Notebook(opts NotebookOptions) Converter {
+	return Func(func(_ context.Context, name string, r io.Reader, w io.Writer) error {
+		var nb notebook
+		if err := json.NewDecoder(r).Decode(&nb); err != nil {
+			return fmt.Errorf("ipynb: %w", err)
+		}
+		if nb.Format > 0 && nb.Format < 4 {
+			return fmt.Errorf("ipynb: nbformat %d is not supported; upgrade with jupyter nbconvert --to notebook", nb.Format)
+		}
+		_, err := io.WriteString(w, nb.markdown(name, opts))
+		return err
+	})
+}
+
+type notebook struct {
+	Format   int `json:"nbformat"`
+	Metadata struct {
+		Title        string `json:"title"`
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+		KernelSpec struct {
+			Language string `json:"language"`
+		} `json:"kernelspec"`
+	} `json:"metadata"`
+	Cells []cell `json:"cells"`
+}
+
+type cell struct {
+	Type    string    `json:"cell_type"`
+	Source  multiline `json:"source"`
+	Outputs []output  `json:"outputs"`
+}
+
+type output struct {
+	Type  string               `json:"output_type"`
+	Name  string               `json:"name"` // stream name
+	Text  multiline            `json:"text"` // stream text
+	Data  map[string]multiline `json:"data"`
+	EName string               `json:"ename"`
+	Value string               `json:"evalue"`
+}
+
+// multiline is a notebook string, stored either as a string or as a list
+// of lines.
+type multiline string
+
+func (m *multiline) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*m = multiline(s)
+		return nil
+	}
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err != nil {
+		return err
+	}
+	*m = multiline(strings.Join(lines, ""))
+	return nil
+}
+
+func (nb *notebook) language() string {
+	if l := nb.Metadata.LanguageInfo.Name; l != "" {
+		return l
+	}
+	return nb.Metadata.KernelSpec.Language
+}
+
+func (nb *notebook) markdown(name string, opts NotebookOptions) string {
+	var blocks []string
+	title := nb.Metadata.Title
+	if title == "" && name != "" {
+		title = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	if title != "" {
+		blocks = append(blocks, "# "+title)
+	}
+	lang := nb.language()
+	for _, c := range nb.Cells {
+		src := strings.TrimRight(string(c.Source), "\n ")
+		switch c.Type {
+		case "markdown":
+			if src != "" {
+				blocks = append(blocks, src)
+			}
+		case "code":
+			if src != "" {
+				blocks = append(blocks, fence(lang, src))
+			}
+			if opts.Outputs {
+				if out := outputs(c.Outputs); out != "" {
+					blocks = append(blocks, "Output:\n\n"+fence("", out))
+				}
+			}
+		}
+	}
+	if len(blocks) == 0 {
+		return ""
+	}
+	return strings.Join(blocks, "\n\n") + "\n"
+}
+
+// outputs returns the text of a code cell's outputs.
+func outputs(outs []output) string {
+	var parts []string
+	for _, o := range outs {
+		var s string
+		switch o.Type {
+		case "stream":
+			s = string(o.Text)
+		case "execute_result", "display_data":
+			if t, ok := o.Data["text/markdown"]; ok {
+				s = string(t)
+			} else if t, ok := o.Data["text/plain"]; ok {
+				s = string(t)
+			} else if len(o.Data) > 0 {
+				s = "[non-text output]"
+			}
+		case "error":
+			s = o.EName + ": " + o.Value
+		}
+		if s = strings.TrimRight(s, "\n "); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// fence wraps s in a code fence long enough not to clash with any fence
+// inside it.
+func fence(lang, s string) string {
+	ticks := "```"
+	for strings.Contains(s, ticks) {
+		ticks += "`"
+	}
+	return ticks + lang + "\n" + s + "\n" + ticks
+}
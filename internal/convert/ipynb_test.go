@@ -0,0 +1,84 @@
+package convert
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testNotebook = `{
+ "nbformat": 4,
+ "nbformat_minor": 5,
+ "metadata": {"language_info": {"name": "python"}},
+ "cells": [
+  {"cell_type": "markdown", "source": ["# Churn analysis\n", "Monthly cohort data."]},
+  {"cell_type": "code", "source": "import pandas as pd\ndf = pd.read_csv('churn.csv')", "outputs": []},
+  {"cell_type": "code", "source": ["df.shape"], "outputs": [
+   {"output_type": "execute_result", "data": {"text/plain": ["(1200, 8)"]}}
+  ]},
+  {"cell_type": "code", "source": "df.plot()\nprint('done')", "outputs": [
+   {"output_type": "display_data", "data": {"image/png": "iVBORw0KGgo="}},
+   {"output_type": "stream", "name": "stdout", "text": ["done\n"]}
+  ]},
+  {"cell_type": "raw", "source": "ignored"},
+  {"cell_type": "code", "source": "1/0", "outputs": [
+   {"output_type": "error", "ename": "ZeroDivisionError", "evalue": "division by zero", "traceback": []}
+  ]}
+ ]
+}`
+
+func TestNotebook(t *testing.T) {
+	tests := []struct {
+		name string
+		opts NotebookOptions
+		want string
+	}{
+		{
+			name: "cells only",
+			want: "# churn\n\n# Churn analysis\nMonthly cohort data.\n\n" +
+				"```python\nimport pandas as pd\ndf = pd.read_csv('churn.csv')\n```\n\n" +
+				"```python\ndf.shape\n```\n\n" +
+				"```python\ndf.plot()\nprint('done')\n```\n\n" +
+				"```python\n1/0\n```\n",
+		},
+		{
+			name: "with outputs",
+			opts: NotebookOptions{Outputs: true},
+			want: "# churn\n\n# Churn analysis\nMonthly cohort data.\n\n" +
+				"```python\nimport pandas as pd\ndf = pd.read_csv('churn.csv')\n```\n\n" +
+				"```python\ndf.shape\n```\n\nOutput:\n\n```\n(1200, 8)\n```\n\n" +
+				"```python\ndf.plot()\nprint('done')\n```\n\nOutput:\n\n```\n[non-text output]\ndone\n```\n\n" +
+				"```python\n1/0\n```\n\nOutput:\n\n```\nZeroDivisionError: division by zero\n```\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := Notebook(tt.opts).Convert(context.Background(), "churn.ipynb", strings.NewReader(testNotebook), &out); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, out.String()); diff != "" {
+				t.Errorf("Convert() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNotebookErrors(t *testing.T) {
+	for _, in := range []string{`not json`, `{"nbformat": 3, "worksheets": []}`} {
+		var out strings.Builder
+		if err := Notebook(NotebookOptions{}).Convert(context.Background(), "x.ipynb", strings.NewReader(in), &out); err == nil {
+			t.Errorf("Convert(%q): want error", in)
+		}
+	}
+}
+
+func TestFenceNesting(t *testing.T) {
+	got := fence("md", "```go\nx\n```")
+	want := "````md\n```go\nx\n```\n````"
+	if got != want {
+		t.Errorf("fence() = %q, want %q", got, want)
+	}
+}
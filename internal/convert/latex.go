@@ -0,0 +1,820 @@
+package convert
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxInputDepth bounds nested \input and \include.
+const maxInputDepth = 10
+
+// LaTeX returns a converter from LaTeX sources to Markdown. Sectioning
+// commands become headings, math is rendered in readable Unicode notation
+// (x² + √(y), α ≤ β) and \cite commands are expanded inline from the
+// BibTeX files named by \bibliography or \addbibresource, with the cited
+// works listed at the end. \input and \include are followed when the
+// document's directory is known (see WithDir).
+func LaTeX() Converter {
+	return Func(func(ctx context.Context, name string, r io.Reader, w io.Writer) error {
+		src, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		c := &texConverter{dir: Dir(ctx), bib: map[string]bibEntry{}}
+		_, err = io.WriteString(w, c.document(string(src)))
+		return err
+	})
+}
+
+type texConverter struct {
+	dir   string
+	bib   map[string]bibEntry
+	cited []string // keys in order of first citation
+	lists []string // enclosing list environments
+	depth int      // \input nesting
+}
+
+var (
+	bibCommandRE = regexp.MustCompile(`\\(?:bibliography|addbibresource)\s*(?:\[[^\]]*\])?\s*\{([^}]*)\}`)
+	blankLinesRE = regexp.MustCompile(`\n{3,}`)
+	spacesRE     = regexp.MustCompile(`[ \t]+`)
+	andRE        = regexp.MustCompile(`\s*\\and\b\s*`)
+	listItemRE   = regexp.MustCompile(`^\s*(?:- |1\. )`)
+)
+
+func (c *texConverter) document(src string) string {
+	src = stripComments(src)
+	for _, m := range bibCommandRE.FindAllStringSubmatch(src, -1) {
+		for _, name := range strings.Split(m[1], ",") {
+			c.loadBib(strings.TrimSpace(name))
+		}
+	}
+	c.loadBibItems(src)
+
+	var b strings.Builder
+	body := src
+	if i := strings.Index(src, `\begin{document}`); i >= 0 {
+		preamble := src[:i]
+		body = src[i+len(`\begin{document}`):]
+		if j := strings.Index(body, `\end{document}`); j >= 0 {
+			body = body[:j]
+		}
+		if t, ok := commandArg(preamble, "title"); ok {
+			fmt.Fprintf(&b, "# %s\n\n", oneLine(c.render(t, false)))
+		}
+		if a, ok := commandArg(preamble, "author"); ok {
+			a = andRE.ReplaceAllString(a, ", ")
+			fmt.Fprintf(&b, "%s\n\n", oneLine(c.render(a, false)))
+		}
+	}
+	b.WriteString(c.render(body, false))
+
+	if len(c.cited) > 0 {
+		b.WriteString("\n\n## References\n\n")
+		for _, key := range c.cited {
+			if e, ok := c.bib[key]; ok {
+				fmt.Fprintf(&b, "- %s\n", e.reference())
+			}
+		}
+	}
+	return tidy(b.String())
+}
+
+// tidy normalizes whitespace: single spaces within lines and at most one
+// blank line between blocks.
+func tidy(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if strings.HasPrefix(l, "    ") {
+			lines[i] = strings.TrimRight(l, " \t")
+			continue
+		}
+		lines[i] = strings.TrimSpace(spacesRE.ReplaceAllString(l, " "))
+	}
+	// Items are written on fresh lines, leaving blank lines between them.
+	for i := 1; i+1 < len(lines); i++ {
+		if lines[i] == "" && listItemRE.MatchString(lines[i+1]) && lines[i-1] != "" && !strings.HasPrefix(lines[i-1], "#") {
+			lines = append(lines[:i], lines[i+1:]...)
+			i--
+		}
+	}
+	s = strings.Join(lines, "\n")
+	s = blankLinesRE.ReplaceAllString(s, "\n\n")
+	return strings.Trim(s, "\n") + "\n"
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// stripComments removes % comments, keeping escaped \%.
+func stripComments(s string) string {
+	var b strings.Builder
+	for _, line := range strings.SplitAfter(s, "\n") {
+		for i := 0; i < len(line); i++ {
+			if line[i] == '\\' {
+				i++
+				continue
+			}
+			if line[i] == '%' {
+				nl := strings.HasSuffix(line, "\n")
+				line = line[:i]
+				if nl {
+					line += "\n"
+				}
+				break
+			}
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// commandArg returns the argument of the first \name{...} in s.
+func commandArg(s, name string) (string, bool) {
+	re := regexp.MustCompile(`\\` + name + `\s*(?:\[[^\]]*\])?\s*\{`)
+	loc := re.FindStringIndex(s)
+	if loc == nil {
+		return "", false
+	}
+	arg, _, ok := group(s, loc[1]-1)
+	return arg, ok
+}
+
+// group returns the contents of the brace group starting at s[i] and the
+// index just past it.
+func group(s string, i int) (string, int, bool) {
+	if i >= len(s) || s[i] != '{' {
+		return "", i, false
+	}
+	depth := 0
+	for j := i; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return s[i+1 : j], j + 1, true
+			}
+		}
+	}
+	return s[i+1:], len(s), true
+}
+
+func skipSpace(s string, i int) int {
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t' || s[i] == '\n') {
+		i++
+	}
+	return i
+}
+
+// arg reads a mandatory argument: a brace group, a command or a single
+// character.
+func arg(s string, i int) (string, int) {
+	i = skipSpace(s, i)
+	if i >= len(s) {
+		return "", i
+	}
+	if s[i] == '{' {
+		a, j, _ := group(s, i)
+		return a, j
+	}
+	if s[i] == '\\' {
+		j := i + 1
+		for j < len(s) && isLetter(s[j]) {
+			j++
+		}
+		if j == i+1 && j < len(s) {
+			j++
+		}
+		return s[i:j], j
+	}
+	_, size := utf8.DecodeRuneInString(s[i:])
+	return s[i : i+size], i + size
+}
+
+// optional reads an optional [argument], if present.
+func optional(s string, i int) (string, int, bool) {
+	j := skipSpace(s, i)
+	if j >= len(s) || s[j] != '[' {
+		return "", i, false
+	}
+	depth := 0
+	for k := j; k < len(s); k++ {
+		switch s[k] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ']':
+			if depth == 0 {
+				return s[j+1 : k], k + 1, true
+			}
+		}
+	}
+	return "", i, false
+}
+
+func isLetter(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// environment returns the body of the environment whose \begin{name}
+// ended just before s[i], and the index past its \end{name}.
+func environment(s string, i int, name string) (string, int) {
+	begin, end := `\begin{`+name+`}`, `\end{`+name+`}`
+	depth := 1
+	for j := i; j < len(s); {
+		nb := strings.Index(s[j:], begin)
+		ne := strings.Index(s[j:], end)
+		if ne < 0 {
+			return s[i:], len(s)
+		}
+		if nb >= 0 && nb < ne {
+			depth++
+			j += nb + len(begin)
+			continue
+		}
+		depth--
+		if depth == 0 {
+			return s[i : j+ne], j + ne + len(end)
+		}
+		j += ne + len(end)
+	}
+	return s[i:], len(s)
+}
+
+// render converts LaTeX to Markdown; in math mode it produces Unicode
+// notation.
+func (c *texConverter) render(s string, math bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		ch := s[i]
+		switch {
+		case ch == '\\':
+			i = c.command(&b, s, i+1, math)
+		case ch == '$':
+			if strings.HasPrefix(s[i:], "$$") {
+				end := strings.Index(s[i+2:], "$$")
+				if end < 0 {
+					end = len(s) - i - 2
+				}
+				c.displayMath(&b, s[i+2:i+2+end])
+				i += 2 + end + 2
+			} else {
+				end := closingDollar(s, i+1)
+				b.WriteString(c.render(s[i+1:end], true))
+				i = end + 1
+			}
+		case ch == '{':
+			inner, j, _ := group(s, i)
+			b.WriteString(c.render(inner, math))
+			i = j
+		case ch == '}':
+			i++
+		case ch == '~':
+			b.WriteByte(' ')
+			i++
+		case math && (ch == '^' || ch == '_'):
+			a, j := arg(s, i+1)
+			b.WriteString(script(c.render(a, true), ch == '^'))
+			i = j
+		case math && ch == '&':
+			i++
+		case !math && ch == '&':
+			b.WriteString(" | ")
+			i++
+		case !math && strings.HasPrefix(s[i:], "---"):
+			b.WriteString("—")
+			i += 3
+		case !math && strings.HasPrefix(s[i:], "--"):
+			b.WriteString("–")
+			i += 2
+		case !math && (strings.HasPrefix(s[i:], "``") || strings.HasPrefix(s[i:], "''")):
+			b.WriteByte('"')
+			i += 2
+		default:
+			b.WriteByte(ch)
+			i++
+		}
+	}
+	return b.String()
+}
+
+func closingDollar(s string, i int) int {
+	for j := i; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '$':
+			return j
+		}
+	}
+	return len(s)
+}
+
+func (c *texConverter) displayMath(b *strings.Builder, s string) {
+	var lines []string
+	for _, l := range regexp.MustCompile(`\\\\`).Split(s, -1) {
+		if l = oneLine(c.render(l, true)); l != "" {
+			lines = append(lines, "    "+l)
+		}
+	}
+	if len(lines) > 0 {
+		b.WriteString("\n\n" + strings.Join(lines, "\n") + "\n\n")
+	}
+}
+
+var headingLevels = map[string]string{
+	"part":          "#",
+	"chapter":       "#",
+	"section":       "##",
+	"subsection":    "###",
+	"subsubsection": "####",
+}
+
+var mathEnvironments = map[string]bool{
+	"equation": true, "align": true, "gather": true, "multline": true,
+	"displaymath": true, "eqnarray": true, "flalign": true, "math": true,
+}
+
+// ignoredCommands are dropped together with their arguments.
+var ignoredCommands = map[string]int{
+	"label": 1, "vspace": 1, "hspace": 1, "includegraphics": 1,
+	"bibliographystyle": 1, "usepackage": 1, "documentclass": 1,
+	"bibliography": 1, "addbibresource": 1, "pagestyle": 1, "thispagestyle": 1,
+	"setlength": 2, "setcounter": 2, "newcommand": 2, "renewcommand": 2,
+	"maketitle": 0, "tableofcontents": 0, "centering": 0, "noindent": 0,
+	"newpage": 0, "clearpage": 0, "hline": 0, "toprule": 0, "midrule": 0,
+	"bottomrule": 0, "small": 0, "footnotesize": 0, "large": 0, "Large": 0,
+	"displaystyle": 0, "limits": 0, "nonumber": 0, "notag": 0, "quad": 0,
+	"qquad": 0, "medskip": 0, "bigskip": 0, "smallskip": 0, "par": 0,
+	"title": 1, "author": 1, "date": 1, "and": 0,
+}
+
+var emphasis = map[string]string{
+	"emph": "*", "textit": "*", "textsl": "*", "mathit": "",
+	"textbf": "**", "texttt": "`",
+}
+
+var accents = map[string]string{
+	`"`: "̈", `'`: "́", "`": "̀", "^": "̂", "~": "̃",
+	"=": "̄", ".": "̇", "c": "̧", "v": "̌", "u": "̆",
+	"H": "̋",
+}
+
+// command handles the control sequence starting at s[i], just after the
+// backslash, and returns the index past it.
+func (c *texConverter) command(b *strings.Builder, s string, i int, math bool) int {
+	if i >= len(s) {
+		return i
+	}
+	if !isLetter(s[i]) {
+		ch := s[i]
+		i++
+		switch ch {
+		case '\\':
+			if _, j, ok := optional(s, i); ok {
+				i = j
+			}
+			b.WriteByte('\n')
+		case '[':
+			end := strings.Index(s[i:], `\]`)
+			if end < 0 {
+				end = len(s) - i
+			}
+			c.displayMath(b, s[i:i+end])
+			return min(i+end+2, len(s))
+		case '(':
+			end := strings.Index(s[i:], `\)`)
+			if end < 0 {
+				end = len(s) - i
+			}
+			b.WriteString(c.render(s[i:i+end], true))
+			return min(i+end+2, len(s))
+		case ',', ';', ':', ' ', '>':
+			b.WriteByte(' ')
+		case '!', '/', '-':
+		case '{', '}', '%', '&', '$', '#', '_':
+			b.WriteByte(ch)
+		case '|':
+			b.WriteString("‖")
+		default:
+			if comb, ok := accents[string(ch)]; ok {
+				a, j := arg(s, i)
+				b.WriteString(norm.NFC.String(c.render(a, false) + comb))
+				return j
+			}
+			b.WriteByte(ch)
+		}
+		return i
+	}
+
+	j := i
+	for j < len(s) && isLetter(s[j]) {
+		j++
+	}
+	name := s[i:j]
+	i = j
+	if i < len(s) && s[i] == '*' {
+		i++
+	}
+
+	if sym, ok := texSymbols[name]; ok {
+		b.WriteString(sym)
+		return i
+	}
+	if level, ok := headingLevels[name]; ok {
+		_, i, _ = optional(s, i)
+		a, j := arg(s, i)
+		fmt.Fprintf(b, "\n\n%s %s\n\n", level, oneLine(c.render(a, false)))
+		return j
+	}
+	if mark, ok := emphasis[name]; ok {
+		a, j := arg(s, i)
+		if text := strings.TrimSpace(c.render(a, math)); text != "" {
+			b.WriteString(mark + text + mark)
+		}
+		return j
+	}
+	if comb, ok := accents[name]; ok && !math {
+		a, j := arg(s, i)
+		b.WriteString(norm.NFC.String(c.render(a, false) + comb))
+		return j
+	}
+	if n, ok := ignoredCommands[name]; ok {
+		for k := 0; k < n; k++ {
+			_, i, _ = optional(s, i)
+			_, i = arg(s, i)
+		}
+		return i
+	}
+
+	switch name {
+	case "begin":
+		env, j := arg(s, i)
+		return c.begin(b, s, j, strings.TrimSuffix(env, "*"), math)
+	case "end":
+		env, j := arg(s, i)
+		switch strings.TrimSuffix(env, "*") {
+		case "itemize", "enumerate", "description":
+			if len(c.lists) > 0 {
+				c.lists = c.lists[:len(c.lists)-1]
+			}
+		}
+		b.WriteString("\n\n")
+		return j
+	case "item":
+		label, j, hasLabel := optional(s, i)
+		kind := ""
+		if len(c.lists) > 0 {
+			kind = c.lists[len(c.lists)-1]
+		}
+		indent := strings.Repeat("  ", max(len(c.lists)-1, 0))
+		switch {
+		case hasLabel:
+			fmt.Fprintf(b, "\n%s- **%s**: ", indent, oneLine(c.render(label, false)))
+		case kind == "enumerate":
+			fmt.Fprintf(b, "\n%s1. ", indent)
+		default:
+			fmt.Fprintf(b, "\n%s- ", indent)
+		}
+		return j
+	case "paragraph", "subparagraph":
+		a, j := arg(s, i)
+		fmt.Fprintf(b, "\n\n**%s** ", oneLine(c.render(a, false)))
+		return j
+	case "caption":
+		_, i, _ = optional(s, i)
+		a, j := arg(s, i)
+		fmt.Fprintf(b, "\n\nCaption: %s\n\n", oneLine(c.render(a, false)))
+		return j
+	case "footnote":
+		a, j := arg(s, i)
+		fmt.Fprintf(b, " (%s)", strings.TrimSpace(c.render(a, false)))
+		return j
+	case "url":
+		a, j := arg(s, i)
+		b.WriteString(a)
+		return j
+	case "href":
+		u, j := arg(s, i)
+		t, j := arg(s, j)
+		fmt.Fprintf(b, "[%s](%s)", c.render(t, false), u)
+		return j
+	case "ref", "eqref", "autoref", "cref", "Cref", "pageref", "nameref":
+		a, j := arg(s, i)
+		fmt.Fprintf(b, "[%s]", a)
+		return j
+	case "cite", "citep", "citet", "parencite", "textcite", "autocite", "citealp", "citeauthor", "citeyear", "footcite":
+		_, i, _ = optional(s, i)
+		_, i, _ = optional(s, i)
+		a, j := arg(s, i)
+		b.WriteString(c.cite(a))
+		return j
+	case "input", "include", "subfile":
+		a, j := arg(s, i)
+		b.WriteString(c.input(strings.TrimSpace(a)))
+		return j
+	case "frac", "dfrac", "tfrac", "cfrac":
+		num, j := arg(s, i)
+		den, j := arg(s, j)
+		b.WriteString(operand(c.render(num, true)) + "/" + operand(c.render(den, true)))
+		return j
+	case "binom", "tbinom", "dbinom":
+		n, j := arg(s, i)
+		k, j := arg(s, j)
+		fmt.Fprintf(b, "C(%s, %s)", c.render(n, true), c.render(k, true))
+		return j
+	case "sqrt":
+		root, j, ok := optional(s, i)
+		a, j := arg(s, j)
+		if ok {
+			b.WriteString(script(c.render(root, true), true))
+		}
+		b.WriteString("√" + operand(c.render(a, true)))
+		return j
+	case "mathbb":
+		a, j := arg(s, i)
+		b.WriteString(doubleStruck(c.render(a, true)))
+		return j
+	case "left", "right", "bigl", "bigr", "Bigl", "Bigr", "big", "Big":
+		d, j := arg(s, i)
+		if d != "." {
+			b.WriteString(c.render(d, true))
+		}
+		return j
+	case "overline", "bar":
+		a, j := arg(s, i)
+		b.WriteString(c.render(a, true) + "̅")
+		return j
+	case "hat", "widehat":
+		a, j := arg(s, i)
+		b.WriteString(norm.NFC.String(c.render(a, true) + "̂"))
+		return j
+	case "vec":
+		a, j := arg(s, i)
+		b.WriteString(c.render(a, true) + "⃗")
+		return j
+	case "tilde", "widetilde":
+		a, j := arg(s, i)
+		b.WriteString(norm.NFC.String(c.render(a, true) + "̃"))
+		return j
+	case "dot":
+		a, j := arg(s, i)
+		b.WriteString(norm.NFC.String(c.render(a, true) + "̇"))
+		return j
+	}
+
+	// Unknown commands keep the text of their arguments.
+	for {
+		_, k, ok := optional(s, i)
+		if !ok {
+			break
+		}
+		i = k
+	}
+	if k := skipSpace(s, i); k < len(s) && s[k] == '{' && !math {
+		a, k := arg(s, k)
+		b.WriteString(c.render(a, math))
+		return k
+	}
+	if math && (name == "mathrm" || name == "text" || name == "mathbf" || name == "operatorname" ||
+		name == "mathcal" || name == "mathsf" || name == "mathtt" || name == "textrm" || name == "mbox") {
+		a, k := arg(s, i)
+		b.WriteString(c.render(a, true))
+		return k
+	}
+	if math {
+		// Unknown math operators such as \Tr read well as their names.
+		b.WriteString(name)
+	}
+	return i
+}
+
+// begin handles \begin{env}; i is the index past its name.
+func (c *texConverter) begin(b *strings.Builder, s string, i int, env string, math bool) int {
+	switch {
+	case mathEnvironments[env]:
+		body, j := environment(s, i, env)
+		c.displayMath(b, strings.TrimSuffix(body, "*"))
+		return j
+	case env == "abstract":
+		b.WriteString("\n\n## Abstract\n\n")
+	case env == "itemize" || env == "enumerate" || env == "description":
+		_, i, _ = optional(s, i)
+		c.lists = append(c.lists, env)
+		b.WriteString("\n")
+	case env == "tabular" || env == "tabularx" || env == "array":
+		if env == "tabularx" {
+			_, i = arg(s, i)
+		}
+		_, i, _ = optional(s, i)
+		_, i = arg(s, i)
+		b.WriteString("\n\n")
+	case env == "verbatim" || env == "lstlisting" || env == "minted" || env == "Verbatim":
+		_, i, _ = optional(s, i)
+		if env == "minted" {
+			_, i = arg(s, i)
+		}
+		body, j := environment(s, i, env)
+		b.WriteString("\n\n" + fence("", strings.Trim(body, "\n")) + "\n\n")
+		return j
+	case env == "thebibliography" || env == "tikzpicture" || env == "comment" || env == "filecontents":
+		_, j := environment(s, i, env)
+		return j
+	case env == "figure" || env == "table" || env == "figure*" || env == "table*":
+		_, i, _ = optional(s, i)
+		b.WriteString("\n\n")
+	default:
+		_, i, _ = optional(s, i)
+		if !math {
+			b.WriteString("\n\n")
+		}
+	}
+	return i
+}
+
+// input renders an \input or \include file.
+func (c *texConverter) input(name string) string {
+	if c.dir == "" || c.depth >= maxInputDepth || name == "" {
+		return ""
+	}
+	path := filepath.Join(c.dir, name)
+	if filepath.Ext(path) == "" {
+		path += ".tex"
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	c.depth++
+	defer func() { c.depth-- }()
+	return "\n\n" + c.render(stripComments(string(data)), false) + "\n\n"
+}
+
+// cite expands a comma separated list of citation keys.
+func (c *texConverter) cite(keys string) string {
+	var parts []string
+	for _, key := range strings.Split(keys, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		e, ok := c.bib[key]
+		if !ok {
+			parts = append(parts, key)
+			continue
+		}
+		seen := false
+		for _, k := range c.cited {
+			seen = seen || k == key
+		}
+		if !seen {
+			c.cited = append(c.cited, key)
+		}
+		parts = append(parts, e.short())
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(parts, "; ") + ")"
+}
+
+// operand wraps s in parentheses unless it is a single symbol or already
+// bracketed.
+func operand(s string) string {
+	s = strings.TrimSpace(s)
+	if len([]rune(s)) <= 1 || isBracketed(s) {
+		return s
+	}
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '.' {
+			return "(" + s + ")"
+		}
+	}
+	return s
+}
+
+func isBracketed(s string) bool {
+	if !strings.HasPrefix(s, "(") || !strings.HasSuffix(s, ")") {
+		return false
+	}
+	depth := 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 && i != len(s)-1 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+var (
+	superscripts = map[rune]rune{
+		'0': '⁰', '1': '¹', '2': '²', '3': '³', '4': '⁴', '5': '⁵', '6': '⁶',
+		'7': '⁷', '8': '⁸', '9': '⁹', '+': '⁺', '-': '⁻', '=': '⁼', '(': '⁽',
+		')': '⁾', 'n': 'ⁿ', 'i': 'ⁱ', 'T': 'ᵀ', '′': '′', '*': '*',
+	}
+	subscripts = map[rune]rune{
+		'0': '₀', '1': '₁', '2': '₂', '3': '₃', '4': '₄', '5': '₅', '6': '₆',
+		'7': '₇', '8': '₈', '9': '₉', '+': '₊', '-': '₋', '=': '₌', '(': '₍',
+		')': '₎', 'a': 'ₐ', 'e': 'ₑ', 'o': 'ₒ', 'x': 'ₓ', 'i': 'ᵢ', 'j': 'ⱼ',
+		'k': 'ₖ', 'n': 'ₙ', 'm': 'ₘ', 't': 'ₜ',
+	}
+)
+
+// script renders a superscript or subscript, using Unicode script
+// characters when every character has one.
+func script(s string, super bool) string {
+	s = strings.TrimSpace(s)
+	table, mark := subscripts, "_"
+	if super {
+		table, mark = superscripts, "^"
+	}
+	var b strings.Builder
+	for _, r := range s {
+		m, ok := table[r]
+		if !ok {
+			return mark + operand(s)
+		}
+		b.WriteRune(m)
+	}
+	return b.String()
+}
+
+func doubleStruck(s string) string {
+	m := map[rune]string{'R': "ℝ", 'N': "ℕ", 'Z': "ℤ", 'Q': "ℚ", 'C': "ℂ", 'P': "ℙ", 'E': "𝔼"}
+	var b strings.Builder
+	for _, r := range s {
+		if d, ok := m[r]; ok {
+			b.WriteString(d)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// texSymbols maps symbol commands to Unicode.
+var texSymbols = map[string]string{
+	"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ε",
+	"varepsilon": "ε", "zeta": "ζ", "eta": "η", "theta": "θ", "vartheta": "ϑ",
+	"iota": "ι", "kappa": "κ", "lambda": "λ", "mu": "μ", "nu": "ν", "xi": "ξ",
+	"pi": "π", "varpi": "ϖ", "rho": "ρ", "varrho": "ϱ", "sigma": "σ",
+	"varsigma": "ς", "tau": "τ", "upsilon": "υ", "phi": "φ", "varphi": "φ",
+	"chi": "χ", "psi": "ψ", "omega": "ω",
+	"Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ", "Xi": "Ξ",
+	"Pi": "Π", "Sigma": "Σ", "Upsilon": "Υ", "Phi": "Φ", "Psi": "Ψ", "Omega": "Ω",
+
+	"sum": "Σ", "prod": "∏", "coprod": "∐", "int": "∫", "iint": "∬", "oint": "∮",
+	"partial": "∂", "nabla": "∇", "infty": "∞", "forall": "∀", "exists": "∃",
+	"nexists": "∄", "emptyset": "∅", "varnothing": "∅", "in": "∈", "notin": "∉",
+	"ni": "∋", "subset": "⊂", "subseteq": "⊆", "supset": "⊃", "supseteq": "⊇",
+	"cup": "∪", "cap": "∩", "bigcup": "⋃", "bigcap": "⋂", "setminus": "∖",
+	"wedge": "∧", "land": "∧", "vee": "∨", "lor": "∨", "neg": "¬", "lnot": "¬",
+	"leq": "≤", "le": "≤", "geq": "≥", "ge": "≥", "neq": "≠", "ne": "≠",
+	"approx": "≈", "equiv": "≡", "sim": "∼", "simeq": "≃", "cong": "≅",
+	"propto": "∝", "ll": "≪", "gg": "≫", "pm": "±", "mp": "∓", "times": "×",
+	"div": "÷", "cdot": "·", "circ": "∘", "ast": "∗", "star": "⋆",
+	"oplus": "⊕", "otimes": "⊗", "perp": "⊥", "parallel": "∥", "mid": "∣",
+	"to": "→", "rightarrow": "→", "leftarrow": "←", "gets": "←",
+	"leftrightarrow": "↔", "Rightarrow": "⇒", "Leftarrow": "⇐",
+	"Leftrightarrow": "⇔", "implies": "⟹", "iff": "⟺", "mapsto": "↦",
+	"uparrow": "↑", "downarrow": "↓", "langle": "⟨", "rangle": "⟩",
+	"lfloor": "⌊", "rfloor": "⌋", "lceil": "⌈", "rceil": "⌉",
+	"lbrace": "{", "rbrace": "}", "vert": "|", "Vert": "‖",
+	"cdots": "⋯", "ldots": "…", "dots": "…", "vdots": "⋮", "ddots": "⋱",
+	"prime": "′", "hbar": "ℏ", "ell": "ℓ", "Re": "ℜ", "Im": "ℑ", "aleph": "ℵ",
+	"angle": "∠", "triangle": "△", "square": "□", "checkmark": "✓",
+	"top": "⊤", "bot": "⊥", "vdash": "⊢", "models": "⊨",
+	"sin": "sin", "cos": "cos", "tan": "tan", "log": "log", "ln": "ln",
+	"exp": "exp", "lim": "lim", "max": "max", "min": "min", "sup": "sup",
+	"inf": "inf", "det": "det", "arg": "arg", "deg": "deg", "dim": "dim",
+	"ker": "ker", "gcd": "gcd", "Pr": "Pr", "limsup": "lim sup", "liminf": "lim inf",
+
+	"LaTeX": "LaTeX", "TeX": "TeX", "BibTeX": "BibTeX", "today": "",
+	"textbackslash": `\`, "textasciitilde": "~", "S": "§", "P": "¶",
+	"dag": "†", "ddag": "‡", "copyright": "©", "textregistered": "®",
+	"ss": "ß", "o": "ø", "O": "Ø", "ae": "æ", "AE": "Æ", "oe": "œ", "OE": "Œ",
+	"aa": "å", "AA": "Å", "l": "ł", "L": "Ł", "i": "ı", "j": "ȷ",
+	"textendash": "–", "textemdash": "—", "textquotedblleft": "\"",
+	"textquotedblright": "\"", "ldquo": "\"", "rdquo": "\"", "euro": "€",
+}
@@ -0,0 +1,129 @@
+package convert
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLaTeX(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"paper.tex": `\documentclass{article}
+\usepackage{amsmath}
+\title{On the Heat Equation}
+\author{A. Author \and B. Writer}
+\begin{document}
+\maketitle
+\begin{abstract}
+We study $u_t = \Delta u$ on $\mathbb{R}^n$. % a comment
+\end{abstract}
+\section{Introduction}\label{sec:intro}
+Following~\cite{knuth84,evans}, we show that
+\begin{equation}
+  \int_0^\infty e^{-x^2}\,dx = \frac{\sqrt{\pi}}{2}
+\end{equation}
+holds for $\alpha \leq \beta$ and $x_{ij}^{2n}$. See Section~\ref{sec:intro}.
+\subsection*{Notation}
+\begin{itemize}
+  \item \emph{Time} $t \in [0, T]$
+  \item Space --- \textbf{bold}
+\end{itemize}
+Costs 5\% more; Schr\"odinger was ` + "``right''" + `.
+\input{appendix}
+\bibliography{refs}
+\end{document}
+`,
+		"appendix.tex": "\\section{Appendix}\nMore $a^{k+1}$.\n",
+		"refs.bib": `@article{knuth84,
+  author = {Knuth, Donald E.},
+  title = {Literate {P}rogramming},
+  journal = "The Computer Journal",
+  volume = 27, number = {2},
+  year = {1984}
+}
+@comment{ignored}
+@book{evans,
+  author = {Lawrence C. Evans and Other Person and Third One},
+  title = {Partial Differential Equations},
+  publisher = {AMS},
+  year = 2010,
+}
+`,
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := NewRegistry()
+	r.Register(".tex", LaTeX())
+	got, err := r.File(context.Background(), filepath.Join(dir, "paper.tex"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# On the Heat Equation
+
+A. Author, B. Writer
+
+## Abstract
+
+We study uₜ = Δ u on ℝⁿ.
+
+## Introduction
+
+Following (Knuth 1984, Literate Programming; Evans et al. 2010, Partial Differential Equations), we show that
+
+    ∫₀^∞ e^(-x²) dx = (√π)/2
+
+holds for α ≤ β and xᵢⱼ²ⁿ. See Section [sec:intro].
+
+### Notation
+
+- *Time* t ∈ [0, T]
+- Space — **bold**
+
+Costs 5% more; Schrödinger was "right".
+
+## Appendix
+
+More a^(k+1).
+
+## References
+
+- Knuth, Donald E. (1984). Literate Programming. The Computer Journal 27(2).
+- Lawrence C. Evans and Other Person and Third One (2010). Partial Differential Equations. AMS.
+`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("LaTeX mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLaTeXFragments(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"display brackets", `\[ a \neq b \]`, "    a ≠ b\n"},
+		{"aligned lines", "\\begin{align*}\nx &= 1 \\\\\ny &= 2\n\\end{align*}", "    x = 1\n    y = 2\n"},
+		{"enumerate", "\\begin{enumerate}\n\\item one\n\\item two\n\\end{enumerate}", "1. one\n1. two\n"},
+		{"unknown bibitem", `see \cite{missing}`, "see (missing)\n"},
+		{"thebibliography", "A \\cite{k}.\n\\begin{thebibliography}{9}\n\\bibitem{k} D. Knuth. The Art. 1968.\n\\end{thebibliography}", "A (D. Knuth. The Art. 1968).\n\n## References\n\n- D. Knuth. The Art. 1968.\n"},
+		{"verbatim", "\\begin{verbatim}\n$x$ \\o\n\\end{verbatim}", "```\n$x$ \\o\n```\n"},
+		{"href and footnote", `\href{https://go.dev}{Go}\footnote{A language.}`, "[Go](https://go.dev) (A language.)\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := LaTeX().Convert(context.Background(), "x.tex", strings.NewReader(tt.in), &out); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, out.String()); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package convert
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SubtitleOptions controls subtitle conversion.
+type SubtitleOptions struct {
+	// KeepTimestamps prefixes each paragraph with its start time.
+	KeepTimestamps bool
+}
+
+// paragraphGap is the pause between cues that starts a new paragraph.
+const paragraphGap = 2 * time.Second
+
+// maxParagraph is the length in bytes after which a paragraph is broken at
+// the next sentence end.
+const maxParagraph = 600
+
+// Subtitles returns a converter from SubRip (.srt) and WebVTT (.vtt)
+// files to text. Cue numbers, timings and markup are removed, captions
+// repeated by rolling auto-captioning are collapsed, and cues are merged
+// into paragraphs, broken at pauses and speaker changes.
+func Subtitles(opts SubtitleOptions) Converter {
+	return Func(func(_ context.Context, name string, r io.Reader, w io.Writer) error {
+		cues, err := ReadCues(r)
+		if err != nil {
+			return fmt.Errorf("subtitles: %w", err)
+		}
+		_, err = io.WriteString(w, paragraphs(cues, opts))
+		return err
+	})
+}
+
+// A Cue is one timed caption.
+type Cue struct {
+	Start, End time.Duration
+	Speaker    string
+	Text       string
+}
+
+var (
+	timingRE  = regexp.MustCompile(`^((?:\d+:)?\d{1,2}:\d{2}[,.]\d{1,3})\s*-->\s*((?:\d+:)?\d{1,2}:\d{2}[,.]\d{1,3})`)
+	voiceRE   = regexp.MustCompile(`<v(?:\.[^ >]*)?\s+([^>]+)>`)
+	tagRE     = regexp.MustCompile(`</?[a-zA-Z][^>]*>|<\d[^>]*>|\{\\[^}]*\}`)
+	speakerRE = regexp.MustCompile(`^(?:-\s*)?([A-Z][A-Za-z .'-]{0,30}):\s+`)
+)
+
+// ReadCues parses SRT or WebVTT.
+func ReadCues(r io.Reader) ([]Cue, error) {
+	var (
+		cues  []Cue
+		cur   *Cue
+		skip  bool // inside a NOTE, STYLE or REGION block
+		lines []string
+	)
+	flush := func() {
+		if cur != nil {
+			cur.Text = strings.Join(lines, " ")
+			if cur.Text != "" {
+				cues = append(cues, *cur)
+			}
+		}
+		cur, lines, skip = nil, nil, false
+	}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	first := true
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if first {
+			line = strings.TrimPrefix(line, "\ufeff")
+			first = false
+		}
+		switch {
+		case line == "":
+			flush()
+		case skip:
+		case cur == nil && (strings.HasPrefix(line, "WEBVTT") || line == "NOTE" || strings.HasPrefix(line, "NOTE ") ||
+			line == "STYLE" || line == "REGION"):
+			skip = true
+		case timingRE.MatchString(line):
+			m := timingRE.FindStringSubmatch(line)
+			start, err := parseTimestamp(m[1])
+			if err != nil {
+				return nil, err
+			}
+			end, err := parseTimestamp(m[2])
+			if err != nil {
+				return nil, err
+			}
+			cur, lines = &Cue{Start: start, End: end}, nil
+		case cur == nil:
+			// Cue number or identifier.
+		default:
+			if m := voiceRE.FindStringSubmatch(line); m != nil && cur.Speaker == "" {
+				cur.Speaker = strings.TrimSpace(m[1])
+			} else if m := speakerRE.FindStringSubmatch(line); m != nil && len(lines) == 0 {
+				cur.Speaker = strings.TrimSpace(m[1])
+				line = line[len(m[0]):]
+			}
+			line = strings.TrimSpace(tagRE.ReplaceAllString(line, ""))
+			line = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&nbsp;", " ").Replace(line)
+			if line != "" {
+				lines = append(lines, line)
+			}
+		}
+	}
+	flush()
+	return cues, sc.Err()
+}
+
+// parseTimestamp parses "01:02:03,456", "01:02:03.456" or "02:03.456".
+func parseTimestamp(s string) (time.Duration, error) {
+	s = strings.Replace(s, ",", ".", 1)
+	parts := strings.Split(s, ":")
+	var d time.Duration
+	for i, p := range parts {
+		if i < len(parts)-1 {
+			n, err := strconv.Atoi(p)
+			if err != nil {
+				return 0, fmt.Errorf("bad timestamp %q", s)
+			}
+			d = d*60 + time.Duration(n)
+			continue
+		}
+		f, err := strconv.ParseFloat(p, 64)
+		if err != nil {
+			return 0, fmt.Errorf("bad timestamp %q", s)
+		}
+		d = d*60*time.Second + time.Duration(f*float64(time.Second))
+	}
+	return d, nil
+}
+
+func paragraphs(cues []Cue, opts SubtitleOptions) string {
+	var (
+		out     []string
+		para    strings.Builder
+		start   time.Duration
+		speaker string
+		prevEnd time.Duration
+		prev    string
+	)
+	emit := func() {
+		if para.Len() == 0 {
+			return
+		}
+		text := para.String()
+		if speaker != "" {
+			text = speaker + ": " + text
+		}
+		if opts.KeepTimestamps {
+			text = "[" + formatTimestamp(start) + "] " + text
+		}
+		out = append(out, text)
+		para.Reset()
+	}
+	for i, c := range cues {
+		text := dedupe(prev, c.Text)
+		prev = c.Text
+		if text == "" {
+			prevEnd = c.End
+			continue
+		}
+		newSpeaker := c.Speaker != "" && c.Speaker != speaker
+		pause := i > 0 && c.Start-prevEnd >= paragraphGap
+		long := para.Len() >= maxParagraph && endsSentence(para.String())
+		if para.Len() > 0 && (newSpeaker || pause || long) {
+			emit()
+		}
+		if para.Len() == 0 {
+			start = c.Start
+			if c.Speaker != "" {
+				speaker = c.Speaker
+			}
+		} else {
+			para.WriteByte(' ')
+		}
+		para.WriteString(text)
+		prevEnd = c.End
+	}
+	emit()
+	if len(out) == 0 {
+		return ""
+	}
+	return strings.Join(out, "\n\n") + "\n"
+}
+
+// dedupe removes the part of text that repeats the end of prev, as rolling
+// auto-captions show each line twice.
+func dedupe(prev, text string) string {
+	if prev == "" {
+		return text
+	}
+	if text == prev {
+		return ""
+	}
+	pw, tw := strings.Fields(prev), strings.Fields(text)
+	for n := min(len(pw), len(tw)); n > 0; n-- {
+		if strings.Join(pw[len(pw)-n:], " ") == strings.Join(tw[:n], " ") {
+			return strings.Join(tw[n:], " ")
+		}
+	}
+	return text
+}
+
+func endsSentence(s string) bool {
+	return strings.HasSuffix(s, ".") || strings.HasSuffix(s, "?") || strings.HasSuffix(s, "!")
+}
+
+func formatTimestamp(d time.Duration) string {
+	d = d.Truncate(time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+}
@@ -0,0 +1,94 @@
+package convert
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testSRT = `1
+00:00:01,000 --> 00:00:03,000
+Welcome to the <i>weekly</i> sync.
+
+2
+00:00:03,100 --> 00:00:05,000
+Let's start with
+the roadmap.
+
+3
+00:00:09,000 --> 00:00:11,500
+BOB: Thanks. Shipping is on track.
+`
+
+const testVTT = `WEBVTT
+Kind: captions
+
+NOTE recorded on Tuesday
+
+intro
+00:01.000 --> 00:02.500 align:start
+<v Alice>so the plan</v>
+
+00:02.500 --> 00:04.000
+so the plan is to ship
+
+00:04.000 --> 00:05.000
+is to ship on Friday
+`
+
+func TestSubtitles(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		opts SubtitleOptions
+		want string
+	}{
+		{
+			name: "srt",
+			in:   testSRT,
+			want: "Welcome to the weekly sync. Let's start with the roadmap.\n\nBOB: Thanks. Shipping is on track.\n",
+		},
+		{
+			name: "srt with timestamps",
+			in:   testSRT,
+			opts: SubtitleOptions{KeepTimestamps: true},
+			want: "[00:00:01] Welcome to the weekly sync. Let's start with the roadmap.\n\n[00:00:09] BOB: Thanks. Shipping is on track.\n",
+		},
+		{
+			name: "vtt with rolling captions",
+			in:   testVTT,
+			want: "Alice: so the plan is to ship on Friday\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := Subtitles(tt.opts).Convert(context.Background(), "x.srt", strings.NewReader(tt.in), &out); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, out.String()); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"00:00:01,500", 1500 * time.Millisecond},
+		{"01:02:03.004", time.Hour + 2*time.Minute + 3*time.Second + 4*time.Millisecond},
+		{"02:03.5", 2*time.Minute + 3500*time.Millisecond},
+	}
+	for _, tt := range tests {
+		got, err := parseTimestamp(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("parseTimestamp(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,357 @@
+package convert
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TableOptions controls how spreadsheets are summarized.
+type TableOptions struct {
+	// Rows is the number of leading rows reproduced verbatim.
+	Rows int
+	// MaxWords caps the size of the summary; leading rows are dropped
+	// to stay within it. Zero means no cap.
+	MaxWords int
+}
+
+// DefaultTableRows is the number of rows shown when TableOptions.Rows is
+// zero.
+const DefaultTableRows = 20
+
+// maxDistinct bounds the values tracked per column for distinct counts.
+const maxDistinct = 10000
+
+// Table is a parsed sheet: a header row and the data rows below it.
+type Table struct {
+	Name   string
+	Header []string
+	Rows   [][]string
+}
+
+// CSV returns a converter that summarizes comma, semicolon or tab
+// separated files as Markdown: the schema, per-column statistics and the
+// first rows. Raw spreadsheets upload poorly, and a summary lets
+// NotebookLM answer questions about the data's shape.
+func CSV(opts TableOptions) Converter {
+	return Func(func(_ context.Context, name string, r io.Reader, w io.Writer) error {
+		t, err := ReadCSV(r, strings.EqualFold(filepath.Ext(name), ".tsv"))
+		if err != nil {
+			return fmt.Errorf("csv: %w", err)
+		}
+		_, err = io.WriteString(w, Summarize(name, []*Table{t}, opts))
+		return err
+	})
+}
+
+// XLSX returns a converter that summarizes each sheet of an Excel
+// workbook like CSV.
+func XLSX(opts TableOptions) Converter {
+	return Func(func(_ context.Context, name string, r io.Reader, w io.Writer) error {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		tables, err := ReadXLSX(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return fmt.Errorf("xlsx: %w", err)
+		}
+		_, err = io.WriteString(w, Summarize(name, tables, opts))
+		return err
+	})
+}
+
+// ReadCSV parses delimited text. The delimiter is tab if tab is set, and
+// otherwise whichever of comma and semicolon appears more in the first line.
+func ReadCSV(r io.Reader, tab bool) (*Table, error) {
+	br := bufio.NewReader(r)
+	if b, err := br.Peek(3); err == nil && string(b) == "\xef\xbb\xbf" {
+		br.Discard(3)
+	}
+	cr := csv.NewReader(br)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	if tab {
+		cr.Comma = '\t'
+	} else {
+		first, _ := br.Peek(4096)
+		line, _, _ := bytes.Cut(first, []byte("\n"))
+		if bytes.Count(line, []byte(";")) > bytes.Count(line, []byte(",")) {
+			cr.Comma = ';'
+		}
+	}
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	return newTable("", records), nil
+}
+
+// newTable splits records into a header and rows, naming unnamed columns.
+func newTable(name string, records [][]string) *Table {
+	t := &Table{Name: name}
+	if len(records) == 0 {
+		return t
+	}
+	width := 0
+	for _, rec := range records {
+		width = max(width, len(rec))
+	}
+	seen := map[string]bool{}
+	for i := 0; i < width; i++ {
+		var h string
+		if i < len(records[0]) {
+			h = strings.TrimSpace(records[0][i])
+		}
+		if h == "" || seen[h] {
+			h = fmt.Sprintf("column %d", i+1)
+		}
+		seen[h] = true
+		t.Header = append(t.Header, h)
+	}
+	t.Rows = records[1:]
+	return t
+}
+
+// Summarize renders tables as Markdown.
+func Summarize(name string, tables []*Table, opts TableOptions) string {
+	if opts.Rows == 0 {
+		opts.Rows = DefaultTableRows
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", name)
+	for _, t := range tables {
+		b.WriteString("\n")
+		level := "##"
+		if len(tables) > 1 || t.Name != "" {
+			fmt.Fprintf(&b, "## Sheet: %s\n\n", t.Name)
+			level = "###"
+		}
+		fmt.Fprintf(&b, "%d rows, %d columns.\n\n", len(t.Rows), len(t.Header))
+		if len(t.Header) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "%s Columns\n\n", level)
+		b.WriteString("| Column | Type | Non-empty | Distinct | Summary |\n")
+		b.WriteString("|---|---|---|---|---|\n")
+		for i, h := range t.Header {
+			s := summarizeColumn(t.Rows, i)
+			fmt.Fprintf(&b, "| %s | %s | %d | %s | %s |\n", escapeCell(h), s.kind, s.count, s.distinct(), escapeCell(s.summary))
+		}
+		b.WriteString("\n")
+
+		rows := t.Rows
+		if len(rows) > opts.Rows {
+			rows = rows[:opts.Rows]
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		header := tableRow(t.Header, len(t.Header)) + "|" + strings.Repeat("---|", len(t.Header)) + "\n"
+		budget := -1
+		if opts.MaxWords > 0 {
+			// Leave room for the header row and the section heading.
+			budget = opts.MaxWords - len(strings.Fields(b.String())) - len(strings.Fields(header)) - 3
+		}
+		var head strings.Builder
+		n := 0
+		for _, row := range rows {
+			line := tableRow(row, len(t.Header))
+			if budget >= 0 {
+				budget -= len(strings.Fields(line))
+				if budget < 0 {
+					break
+				}
+			}
+			head.WriteString(line)
+			n++
+		}
+		if n == 0 {
+			continue
+		}
+		switch {
+		case n == len(t.Rows):
+			fmt.Fprintf(&b, "%s Rows\n\n", level)
+		case n == 1:
+			fmt.Fprintf(&b, "%s First row\n\n", level)
+		default:
+			fmt.Fprintf(&b, "%s First %d rows\n\n", level, n)
+		}
+		b.WriteString(header)
+		b.WriteString(head.String())
+	}
+	return b.String()
+}
+
+func tableRow(row []string, width int) string {
+	var b strings.Builder
+	b.WriteString("|")
+	for i := 0; i < width; i++ {
+		var v string
+		if i < len(row) {
+			v = row[i]
+		}
+		b.WriteString(" " + escapeCell(v) + " |")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// escapeCell escapes a value for a Markdown table cell.
+func escapeCell(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// Column types, from most to least specific.
+const (
+	kindEmpty   = "empty"
+	kindBool    = "boolean"
+	kindInteger = "integer"
+	kindNumber  = "number"
+	kindDate    = "date"
+	kindText    = "text"
+)
+
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	time.RFC3339,
+	"01/02/2006",
+	"2006/01/02",
+}
+
+type columnStats struct {
+	kind       string
+	count      int
+	values     map[string]int
+	overflow   bool
+	summary    string
+	nums       []float64
+	minT, maxT time.Time
+}
+
+func (s *columnStats) distinct() string {
+	if s.overflow {
+		return fmt.Sprintf("%d+", maxDistinct)
+	}
+	return strconv.Itoa(len(s.values))
+}
+
+func summarizeColumn(rows [][]string, i int) *columnStats {
+	s := &columnStats{kind: kindEmpty, values: map[string]int{}}
+	for _, row := range rows {
+		if i >= len(row) {
+			continue
+		}
+		v := strings.TrimSpace(row[i])
+		if v == "" {
+			continue
+		}
+		s.count++
+		if _, ok := s.values[v]; ok || len(s.values) < maxDistinct {
+			s.values[v]++
+		} else {
+			s.overflow = true
+		}
+		s.kind = widen(s.kind, valueKind(v))
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			s.nums = append(s.nums, f)
+		}
+		if t, ok := parseDate(v); ok {
+			if s.minT.IsZero() || t.Before(s.minT) {
+				s.minT = t
+			}
+			if t.After(s.maxT) {
+				s.maxT = t
+			}
+		}
+	}
+	switch s.kind {
+	case kindInteger, kindNumber:
+		lo, hi, sum := math.Inf(1), math.Inf(-1), 0.0
+		for _, f := range s.nums {
+			lo, hi, sum = math.Min(lo, f), math.Max(hi, f), sum+f
+		}
+		s.summary = fmt.Sprintf("min %s, max %s, mean %s", num(lo), num(hi), num(sum/float64(len(s.nums))))
+	case kindDate:
+		s.summary = fmt.Sprintf("from %s to %s", s.minT.Format("2006-01-02"), s.maxT.Format("2006-01-02"))
+	case kindBool, kindText:
+		s.summary = "top: " + topValues(s.values, 3)
+	}
+	s.nums = nil
+	return s
+}
+
+func valueKind(v string) string {
+	switch strings.ToLower(v) {
+	case "true", "false":
+		return kindBool
+	}
+	if _, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return kindInteger
+	}
+	if _, err := strconv.ParseFloat(v, 64); err == nil {
+		return kindNumber
+	}
+	if _, ok := parseDate(v); ok {
+		return kindDate
+	}
+	return kindText
+}
+
+// widen returns the type that holds values of both a and b.
+func widen(a, b string) string {
+	switch {
+	case a == kindEmpty || a == b:
+		return b
+	case a == kindInteger && b == kindNumber, a == kindNumber && b == kindInteger:
+		return kindNumber
+	}
+	return kindText
+}
+
+func parseDate(v string) (time.Time, bool) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func num(f float64) string {
+	return strconv.FormatFloat(f, 'g', 6, 64)
+}
+
+func topValues(values map[string]int, n int) string {
+	type kv struct {
+		v string
+		n int
+	}
+	var all []kv
+	for v, c := range values {
+		all = append(all, kv{v, c})
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].n != all[j].n {
+			return all[i].n > all[j].n
+		}
+		return all[i].v < all[j].v
+	})
+	var parts []string
+	for _, e := range all[:min(n, len(all))] {
+		parts = append(parts, fmt.Sprintf("%s (%d)", e.v, e.n))
+	}
+	return strings.Join(parts, ", ")
+}
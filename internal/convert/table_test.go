@@ -0,0 +1,130 @@
+package convert
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testCSV = "region,revenue,units,active,signup\n" +
+	"North,10.5,3,true,2024-01-05\n" +
+	"South,4,1,false,2024-03-01\n" +
+	"North,1.5,,true,2023-12-31\n"
+
+func TestCSV(t *testing.T) {
+	var out strings.Builder
+	if err := CSV(TableOptions{}).Convert(context.Background(), "sales.csv", strings.NewReader(testCSV), &out); err != nil {
+		t.Fatal(err)
+	}
+	want := `# sales.csv
+
+3 rows, 5 columns.
+
+## Columns
+
+| Column | Type | Non-empty | Distinct | Summary |
+|---|---|---|---|---|
+| region | text | 3 | 2 | top: North (2), South (1) |
+| revenue | number | 3 | 3 | min 1.5, max 10.5, mean 5.33333 |
+| units | integer | 2 | 2 | min 1, max 3, mean 2 |
+| active | boolean | 3 | 2 | top: true (2), false (1) |
+| signup | date | 3 | 3 | from 2023-12-31 to 2024-03-01 |
+
+## Rows
+
+| region | revenue | units | active | signup |
+|---|---|---|---|---|
+| North | 10.5 | 3 | true | 2024-01-05 |
+| South | 4 | 1 | false | 2024-03-01 |
+| North | 1.5 |  | true | 2023-12-31 |
+`
+	if diff := cmp.Diff(want, out.String()); diff != "" {
+		t.Errorf("Convert() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSummarizeLimits(t *testing.T) {
+	tbl, err := ReadCSV(strings.NewReader("a;b\n1;x|y\n2;z\n3;w\n"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"a", "b"}, tbl.Header); diff != "" {
+		t.Fatalf("semicolon header mismatch (-want +got):\n%s", diff)
+	}
+
+	got := Summarize("t.csv", []*Table{tbl}, TableOptions{Rows: 2})
+	if !strings.Contains(got, "## First 2 rows") || !strings.Contains(got, `x\|y`) || strings.Contains(got, "| 3 | w |") {
+		t.Errorf("Rows: 2 summary:\n%s", got)
+	}
+
+	full := Summarize("t.csv", []*Table{tbl}, TableOptions{})
+	capped := Summarize("t.csv", []*Table{tbl}, TableOptions{MaxWords: len(strings.Fields(full)) - 3})
+	if !strings.Contains(capped, "## First 2 rows") {
+		t.Errorf("MaxWords summary did not drop a row:\n%s", capped)
+	}
+}
+
+func TestXLSX(t *testing.T) {
+	files := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Q1" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst><si><t>name</t></si><si><t>score</t></si><si><r><t>Ada </t></r><r><t>L.</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>
+<row r="2"><c r="A2" t="s"><v>2</v></c><c r="C2" t="inlineStr"><is><t>note</t></is></c></row>
+<row r="3"><c r="A3" t="inlineStr"><is><t>Bob</t></is></c><c r="B3"><v>7</v></c></row>
+</sheetData></worksheet>`,
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	tables, err := ReadXLSX(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*Table{{
+		Name:   "Q1",
+		Header: []string{"name", "score", "column 3"},
+		Rows:   [][]string{{"Ada L.", "", "note"}, {"Bob", "7"}},
+	}}
+	if diff := cmp.Diff(want, tables); diff != "" {
+		t.Errorf("ReadXLSX mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestColumnIndex(t *testing.T) {
+	for _, tt := range []struct {
+		ref  string
+		want int
+		ok   bool
+	}{
+		{"A1", 0, true},
+		{"AB12", 27, true},
+		{"XFD1", 16383, true},
+		{"XFE1", 0, false},
+		{"ZZZZZZZZZZZZZZ1", 0, false},
+		{"12", 0, false},
+		{"a1", 0, false},
+	} {
+		got, err := columnIndex(tt.ref)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("columnIndex(%q) = %d, %v; want %d, ok %v", tt.ref, got, err, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package convert
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ReadXLSX parses the sheets of an Excel workbook. Formulas are read as
+// their cached values and dates as the serial numbers Excel stores.
+func ReadXLSX(r io.ReaderAt, size int64) ([]*Table, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var wb struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodeXML(files, "xl/workbook.xml", &wb); err != nil {
+		return nil, err
+	}
+	var rels struct {
+		Rels []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodeXML(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	targets := map[string]string{}
+	for _, rel := range rels.Rels {
+		t := rel.Target
+		if strings.HasPrefix(t, "/") {
+			t = strings.TrimPrefix(t, "/")
+		} else {
+			t = path.Join("xl", t)
+		}
+		targets[rel.ID] = t
+	}
+
+	var shared []string
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		var sst struct {
+			Items []inlineString `xml:"si"`
+		}
+		if err := decodeXML(files, "xl/sharedStrings.xml", &sst); err != nil {
+			return nil, err
+		}
+		for _, si := range sst.Items {
+			shared = append(shared, si.text())
+		}
+	}
+
+	var tables []*Table
+	for _, s := range wb.Sheets {
+		name, ok := targets[s.RID]
+		if !ok {
+			return nil, fmt.Errorf("sheet %q: missing relationship %s", s.Name, s.RID)
+		}
+		records, err := readSheet(files, name, shared)
+		if err != nil {
+			return nil, fmt.Errorf("sheet %q: %w", s.Name, err)
+		}
+		tables = append(tables, newTable(s.Name, records))
+	}
+	return tables, nil
+}
+
+type inlineString struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (s inlineString) text() string {
+	if len(s.Runs) == 0 {
+		return s.T
+	}
+	var b strings.Builder
+	for _, r := range s.Runs {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+func readSheet(files map[string]*zip.File, name string, shared []string) ([][]string, error) {
+	var ws struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string       `xml:"r,attr"`
+				Type   string       `xml:"t,attr"`
+				Value  string       `xml:"v"`
+				Inline inlineString `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := decodeXML(files, name, &ws); err != nil {
+		return nil, err
+	}
+	var records [][]string
+	for _, row := range ws.Rows {
+		var rec []string
+		for _, c := range row.Cells {
+			// A cell without a reference follows the one before it.
+			col := len(rec)
+			if c.Ref != "" {
+				var err error
+				if col, err = columnIndex(c.Ref); err != nil {
+					return nil, err
+				}
+			}
+			v := c.Value
+			switch c.Type {
+			case "s":
+				n, err := strconv.Atoi(v)
+				if err != nil || n < 0 || n >= len(shared) {
+					return nil, fmt.Errorf("cell %s: bad shared string %q", c.Ref, v)
+				}
+				v = shared[n]
+			case "inlineStr":
+				v = c.Inline.text()
+			case "b":
+				v = strconv.FormatBool(v == "1")
+			}
+			for len(rec) <= col {
+				rec = append(rec, "")
+			}
+			rec[col] = v
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// maxColumns is the number of columns of an Excel sheet, A to XFD. Cells
+// past it are rejected rather than padding their row to that size.
+const maxColumns = 16384
+
+// columnIndex returns the zero-based column of a cell reference like "AB12".
+func columnIndex(ref string) (int, error) {
+	n := 0
+	for i := 0; i < len(ref) && 'A' <= ref[i] && ref[i] <= 'Z'; i++ {
+		n = n*26 + int(ref[i]-'A') + 1
+		if n > maxColumns {
+			return 0, fmt.Errorf("cell %q: column past XFD", ref)
+		}
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("bad cell reference %q", ref)
+	}
+	return n - 1, nil
+}
+
+func decodeXML(files map[string]*zip.File, name string, v any) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("missing %s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,370 @@
+// Package crawl finds the pages of a website, from its sitemap or by
+// following links from a seed page, and fetches them politely: pages
+// disallowed by robots.txt are skipped, and requests to a site are spaced
+// by a delay, raised to the site's Crawl-delay.
+package crawl
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tmc/nlm/internal/convert"
+)
+
+// maxSitemapDepth bounds how deeply sitemap indexes may nest.
+const maxSitemapDepth = 3
+
+// maxBody bounds the size of a fetched page or sitemap.
+const maxBody = 20 << 20
+
+// A Crawler fetches pages from websites. Its zero value is usable.
+type Crawler struct {
+	Client *http.Client // defaults to a client with a 30s timeout
+	// UserAgent is sent with requests and selects the robots.txt rules
+	// that apply.
+	UserAgent string
+	// Delay is the minimum time between requests to a site; it defaults
+	// to one second.
+	Delay time.Duration
+	// Include and Exclude filter pages by their URL path: a page is kept
+	// if it matches Include (or Include is nil) and not Exclude.
+	Include, Exclude *regexp.Regexp
+
+	mu     sync.Mutex
+	last   map[string]time.Time // by host
+	robots map[string]*robots   // by host
+}
+
+func (c *Crawler) client() *http.Client {
+	if c.Client != nil {
+		return c.Client
+	}
+	return &http.Client{Timeout: 30 * time.Second}
+}
+
+// Sitemap returns the page URLs listed in the sitemap at sitemapURL,
+// following sitemap indexes, in sitemap order and without duplicates.
+// Only pages on the sitemap's host that pass the Include and Exclude
+// filters and that robots.txt allows are returned.
+func (c *Crawler) Sitemap(ctx context.Context, sitemapURL string) ([]string, error) {
+	base, err := url.Parse(sitemapURL)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") {
+		return nil, fmt.Errorf("crawl: invalid sitemap URL %q", sitemapURL)
+	}
+	seen := make(map[string]bool)
+	var pages []string
+	var walk func(u string, depth int) error
+	walk = func(u string, depth int) error {
+		doc, err := c.sitemap(ctx, u)
+		if err != nil {
+			return err
+		}
+		for _, s := range doc.Sitemaps {
+			if depth >= maxSitemapDepth {
+				return fmt.Errorf("crawl: sitemap indexes nested more than %d deep", maxSitemapDepth)
+			}
+			if err := walk(strings.TrimSpace(s.Loc), depth+1); err != nil {
+				return err
+			}
+		}
+		for _, p := range doc.URLs {
+			loc := strings.TrimSpace(p.Loc)
+			pu, err := url.Parse(loc)
+			if err != nil || pu.Host != base.Host || seen[loc] {
+				continue
+			}
+			seen[loc] = true
+			if !c.wanted(pu.Path) {
+				continue
+			}
+			ok, err := c.Allowed(ctx, loc)
+			if err != nil {
+				return err
+			}
+			if ok {
+				pages = append(pages, loc)
+			}
+		}
+		return nil
+	}
+	if err := walk(sitemapURL, 0); err != nil {
+		return nil, err
+	}
+	return pages, nil
+}
+
+// sitemapDoc is a sitemap (urlset) or a sitemap index.
+type sitemapDoc struct {
+	URLs []struct {
+		Loc string `xml:"loc"`
+	} `xml:"url"`
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+func (c *Crawler) sitemap(ctx context.Context, u string) (*sitemapDoc, error) {
+	resp, err := c.get(ctx, u)
+	if err != nil {
+		return nil, fmt.Errorf("crawl: sitemap: %w", err)
+	}
+	defer resp.Body.Close()
+	var r io.Reader = io.LimitReader(resp.Body, maxBody)
+	if strings.HasSuffix(resp.Request.URL.Path, ".gz") || resp.Header.Get("Content-Type") == "application/x-gzip" {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("crawl: sitemap %s: %w", u, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	var doc sitemapDoc
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("crawl: sitemap %s: %w", u, err)
+	}
+	return &doc, nil
+}
+
+func (c *Crawler) wanted(path string) bool {
+	if c.Include != nil && !c.Include.MatchString(path) {
+		return false
+	}
+	return c.Exclude == nil || !c.Exclude.MatchString(path)
+}
+
+// Fetch downloads a page and converts it to text.
+func (c *Crawler) Fetch(ctx context.Context, pageURL string) (*convert.Page, error) {
+	resp, err := c.get(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body := io.LimitReader(resp.Body, maxBody)
+	switch ct := resp.Header.Get("Content-Type"); {
+	case ct == "" || strings.Contains(ct, "html"):
+		return convert.ReadHTML(body)
+	case strings.HasPrefix(ct, "text/"):
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		return &convert.Page{Text: string(data)}, nil
+	default:
+		return nil, fmt.Errorf("%s: %w (%s)", pageURL, ErrNotPage, ct)
+	}
+}
+
+// ErrNotPage is returned by Fetch for documents that are not HTML or text.
+var ErrNotPage = errors.New("not a web page")
+
+// SkipAll may be returned by a visit function to end a crawl early.
+var SkipAll = errors.New("skip everything and stop the crawl")
+
+// FollowOptions bound a crawl from a seed page.
+type FollowOptions struct {
+	// Depth is the number of links followed from the seed; 0 fetches only
+	// the seed.
+	Depth int
+	// MaxPages bounds the number of pages fetched; 0 means 100.
+	MaxPages int
+	// SameDomain keeps the crawl on the seed's host and its subdomains.
+	SameDomain bool
+}
+
+// skipExt are extensions of links that are not followed because they
+// name documents that are not web pages.
+var skipExt = map[string]bool{
+	".pdf": true, ".zip": true, ".gz": true, ".tgz": true, ".tar": true,
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true,
+	".webp": true, ".ico": true, ".css": true, ".js": true, ".json": true,
+	".xml": true, ".mp3": true, ".mp4": true, ".woff": true, ".woff2": true,
+}
+
+// Follow crawls breadth-first from seed, following links up to
+// opts.Depth hops away, and calls visit with every page fetched whose
+// path passes the Include and Exclude filters, or with the error fetching
+// it. Pages that do not pass Include are still fetched to follow their
+// links; pages that match Exclude are not fetched at all. Links
+// robots.txt disallows are not followed. If visit returns SkipAll the
+// crawl stops without error; any other error stops it and is returned.
+func (c *Crawler) Follow(ctx context.Context, seed string, opts FollowOptions, visit func(u string, p *convert.Page, err error) error) error {
+	start, err := url.Parse(seed)
+	if err != nil || (start.Scheme != "http" && start.Scheme != "https") {
+		return fmt.Errorf("crawl: invalid seed URL %q", seed)
+	}
+	start.Fragment = ""
+	max := opts.MaxPages
+	if max <= 0 {
+		max = 100
+	}
+	type item struct {
+		u     *url.URL
+		depth int
+	}
+	queue := []item{{start, 0}}
+	seen := map[string]bool{start.String(): true}
+	for fetched := 0; len(queue) > 0 && fetched < max; {
+		it := queue[0]
+		queue = queue[1:]
+		u := it.u.String()
+		if c.Exclude != nil && c.Exclude.MatchString(it.u.Path) {
+			continue
+		}
+		ok, err := c.Allowed(ctx, u)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		fetched++
+		p, err := c.Fetch(ctx, u)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if c.wanted(it.u.Path) {
+			if err := visit(u, p, err); err == SkipAll {
+				return nil
+			} else if err != nil {
+				return err
+			}
+		}
+		if p == nil || it.depth >= opts.Depth {
+			continue
+		}
+		for _, href := range p.Links {
+			next, err := it.u.Parse(strings.TrimSpace(href))
+			if err != nil || (next.Scheme != "http" && next.Scheme != "https") {
+				continue
+			}
+			next.Fragment = ""
+			if skipExt[strings.ToLower(path.Ext(next.Path))] {
+				continue
+			}
+			if opts.SameDomain && !sameDomain(next.Hostname(), start.Hostname()) {
+				continue
+			}
+			if k := next.String(); !seen[k] {
+				seen[k] = true
+				queue = append(queue, item{next, it.depth + 1})
+			}
+		}
+	}
+	return nil
+}
+
+// sameDomain reports whether host is seed or one of its subdomains,
+// ignoring a leading "www.".
+func sameDomain(host, seed string) bool {
+	host = strings.TrimPrefix(strings.ToLower(host), "www.")
+	seed = strings.TrimPrefix(strings.ToLower(seed), "www.")
+	return host == seed || strings.HasSuffix(host, "."+seed)
+}
+
+// Allowed reports whether robots.txt allows fetching u.
+func (c *Crawler) Allowed(ctx context.Context, u string) (bool, error) {
+	pu, err := url.Parse(u)
+	if err != nil {
+		return false, err
+	}
+	rb, err := c.robotsFor(ctx, pu)
+	if err != nil {
+		return false, err
+	}
+	path := pu.EscapedPath()
+	if pu.RawQuery != "" {
+		path += "?" + pu.RawQuery
+	}
+	return rb.allowed(path), nil
+}
+
+func (c *Crawler) robotsFor(ctx context.Context, u *url.URL) (*robots, error) {
+	c.mu.Lock()
+	rb, ok := c.robots[u.Host]
+	c.mu.Unlock()
+	if ok {
+		return rb, nil
+	}
+	rb = &robots{}
+	resp, err := c.get(ctx, u.Scheme+"://"+u.Host+"/robots.txt")
+	switch {
+	case err == nil:
+		rb = parseRobots(io.LimitReader(resp.Body, 1<<20), c.UserAgent)
+		resp.Body.Close()
+	case resp != nil && resp.StatusCode >= 400 && resp.StatusCode < 500:
+		// No robots.txt: everything is allowed.
+	default:
+		return nil, fmt.Errorf("crawl: robots.txt: %w", err)
+	}
+	c.mu.Lock()
+	if c.robots == nil {
+		c.robots = make(map[string]*robots)
+	}
+	c.robots[u.Host] = rb
+	c.mu.Unlock()
+	return rb, nil
+}
+
+// get waits for the host's politeness delay and fetches u. A response
+// with an error status is returned along with the error.
+func (c *Crawler) get(ctx context.Context, u string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	if err := c.wait(ctx, req.URL.Host); err != nil {
+		return nil, err
+	}
+	resp, err := c.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		resp.Body.Close()
+		return resp, fmt.Errorf("%s: %s", u, resp.Status)
+	}
+	return resp, nil
+}
+
+// wait blocks until a request to host is allowed.
+func (c *Crawler) wait(ctx context.Context, host string) error {
+	delay := c.Delay
+	if delay == 0 {
+		delay = time.Second
+	}
+	c.mu.Lock()
+	if rb := c.robots[host]; rb != nil && rb.delay > delay {
+		delay = rb.delay
+	}
+	if c.last == nil {
+		c.last = make(map[string]time.Time)
+	}
+	next := c.last[host].Add(delay)
+	now := time.Now()
+	if next.Before(now) {
+		next = now
+	}
+	c.last[host] = next
+	c.mu.Unlock()
+
+	select {
+	case <-time.After(time.Until(next)):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
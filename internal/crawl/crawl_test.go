@@ -0,0 +1,191 @@
+package crawl
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tmc/nlm/internal/convert"
+)
+
+func TestRobots(t *testing.T) {
+	const txt = `# comment
+User-agent: *
+Disallow: /private/
+Allow: /private/public$
+Crawl-delay: 2
+
+User-agent: otherbot
+User-agent: nlm
+Disallow: /
+Allow: /guide/
+Disallow: /guide/*.pdf$
+`
+	star := parseRobots(strings.NewReader(txt), "somebot/1.0")
+	if star.delay != 2*time.Second {
+		t.Errorf("delay = %v, want 2s", star.delay)
+	}
+	named := parseRobots(strings.NewReader(txt), "nlm/v0.1.0")
+	tests := []struct {
+		rb   *robots
+		path string
+		want bool
+	}{
+		{star, "/guide/install", true},
+		{star, "/private/keys", false},
+		{star, "/private/public", true},
+		{star, "/private/public/more", false},
+		{named, "/guide/install", true},
+		{named, "/blog/", false},
+		{named, "/guide/manual.pdf", false},
+	}
+	for _, tt := range tests {
+		if got := tt.rb.allowed(tt.path); got != tt.want {
+			t.Errorf("allowed(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCrawl(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			w.Write([]byte("User-agent: *\nDisallow: /guide/secret\n"))
+		case "/sitemap.xml":
+			w.Write([]byte(`<?xml version="1.0"?><sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<sitemap><loc>` + srv.URL + `/guide.xml</loc></sitemap></sitemapindex>`))
+		case "/guide.xml":
+			w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>` + srv.URL + `/guide/install</loc></url>
+<url><loc> ` + srv.URL + `/guide/install </loc></url>
+<url><loc>` + srv.URL + `/guide/secret</loc></url>
+<url><loc>` + srv.URL + `/blog/news</loc></url>
+<url><loc>https://elsewhere.example/guide/x</loc></url>
+</urlset>`))
+		case "/guide/install":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte(`<title>Install</title><nav>Menu</nav><main><p>Run it.</p></main>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	c := &Crawler{Delay: time.Millisecond, Include: regexp.MustCompile("^/guide/")}
+	pages, err := c.Sitemap(context.Background(), srv.URL+"/sitemap.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{srv.URL + "/guide/install"}, pages); diff != "" {
+		t.Errorf("pages mismatch (-want +got):\n%s", diff)
+	}
+	p, err := c.Fetch(context.Background(), pages[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Title != "Install" || p.Text != "Run it.\n" {
+		t.Errorf("Fetch = %+v", p)
+	}
+	if _, err := c.Fetch(context.Background(), srv.URL+"/missing"); err == nil {
+		t.Error("Fetch of a missing page succeeded")
+	}
+}
+
+func TestWaitSpacesRequests(t *testing.T) {
+	c := &Crawler{Delay: 20 * time.Millisecond}
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := c.wait(context.Background(), "example.com"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := time.Since(start); d < 40*time.Millisecond {
+		t.Errorf("three requests took %v, want at least 40ms", d)
+	}
+}
+
+func TestFollow(t *testing.T) {
+	pages := map[string]string{
+		"/":              `<nav><a href="/docs/">Docs</a> <a href="/blog/">Blog</a> <a href="https://other.example/">Other</a></nav><p>Home</p>`,
+		"/docs/":         `<title>Docs</title><a href="a#intro">A</a> <a href="/docs/b">B</a> <a href="/docs/manual.pdf">PDF</a> <a href="mailto:x@example.com">Mail</a>`,
+		"/docs/a":        `<title>A</title><a href="/docs/deeper">Deeper</a>`,
+		"/docs/b":        `<title>B</title><a href="/">Home</a>`,
+		"/docs/deeper":   `<title>Deeper</title>`,
+		"/blog/":         `<title>Blog</title>`,
+		"/docs/internal": `<title>Internal</title>`,
+	}
+	var fetched []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.Write([]byte("User-agent: *\nDisallow: /docs/b\n"))
+			return
+		}
+		fetched = append(fetched, r.URL.Path)
+		body, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	c := &Crawler{
+		Delay:   time.Millisecond,
+		Include: regexp.MustCompile("^/docs/"),
+		Exclude: regexp.MustCompile("^/blog/"),
+	}
+	var visited []string
+	err := c.Follow(context.Background(), srv.URL+"/", FollowOptions{Depth: 2, SameDomain: true}, func(u string, p *convert.Page, err error) error {
+		if err != nil {
+			return err
+		}
+		visited = append(visited, strings.TrimPrefix(u, srv.URL)+" "+p.Title)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"/docs/ Docs", "/docs/a A"}, visited); diff != "" {
+		t.Errorf("visited mismatch (-want +got):\n%s", diff)
+	}
+	// The home page is fetched only for its links, the blog is excluded,
+	// /docs/b is disallowed, and /docs/deeper is three links away.
+	if diff := cmp.Diff([]string{"/", "/docs/", "/docs/a"}, fetched); diff != "" {
+		t.Errorf("fetched mismatch (-want +got):\n%s", diff)
+	}
+
+	visited = nil
+	err = c.Follow(context.Background(), srv.URL+"/docs/", FollowOptions{Depth: 5}, func(u string, p *convert.Page, err error) error {
+		visited = append(visited, u)
+		return SkipAll
+	})
+	if err != nil || len(visited) != 1 {
+		t.Errorf("SkipAll: err = %v, visited = %v; want one page", err, visited)
+	}
+}
+
+func TestSameDomain(t *testing.T) {
+	tests := []struct {
+		host, seed string
+		want       bool
+	}{
+		{"docs.example.com", "docs.example.com", true},
+		{"www.example.com", "example.com", true},
+		{"api.example.com", "www.example.com", true},
+		{"example.com", "docs.example.com", false},
+		{"notexample.com", "example.com", false},
+	}
+	for _, tt := range tests {
+		if got := sameDomain(tt.host, tt.seed); got != tt.want {
+			t.Errorf("sameDomain(%q, %q) = %v, want %v", tt.host, tt.seed, got, tt.want)
+		}
+	}
+}
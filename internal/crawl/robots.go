@@ -0,0 +1,111 @@
+package crawl
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// robots holds the robots.txt rules that apply to the crawler.
+type robots struct {
+	rules []rule
+	delay time.Duration
+}
+
+// rule is an Allow or Disallow line. Its pattern may use * for any
+// characters and end in $ to anchor it at the end of the path.
+type rule struct {
+	allow   bool
+	pattern string
+	re      *regexp.Regexp
+}
+
+// allowed reports whether path may be fetched: the longest matching rule
+// decides, with Allow winning ties, and paths no rule matches are allowed.
+func (rb *robots) allowed(path string) bool {
+	best, allow := -1, true
+	for _, r := range rb.rules {
+		if !r.re.MatchString(path) {
+			continue
+		}
+		if n := len(r.pattern); n > best || (n == best && r.allow) {
+			best, allow = n, r.allow
+		}
+	}
+	return allow
+}
+
+// parseRobots reads the rules of the robots.txt group that names
+// userAgent, or of the * group if none does.
+func parseRobots(r io.Reader, userAgent string) *robots {
+	agent := strings.ToLower(userAgent)
+	if i := strings.IndexAny(agent, "/ "); i >= 0 {
+		agent = agent[:i]
+	}
+	var (
+		named, star robots
+		hasNamed    bool
+		cur         []*robots // groups the current lines apply to
+		inAgents    bool      // reading a group's User-agent lines
+	)
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if key == "user-agent" {
+			if !inAgents {
+				cur = nil
+			}
+			inAgents = true
+			switch ua := strings.ToLower(value); {
+			case ua == "*":
+				cur = append(cur, &star)
+			case agent != "" && strings.Contains(agent, ua):
+				cur = append(cur, &named)
+				hasNamed = true
+			}
+			continue
+		}
+		inAgents = false
+		for _, g := range cur {
+			switch key {
+			case "allow", "disallow":
+				if value == "" {
+					continue
+				}
+				g.rules = append(g.rules, rule{allow: key == "allow", pattern: value, re: patternRE(value)})
+			case "crawl-delay":
+				if s, err := strconv.ParseFloat(value, 64); err == nil && s > 0 {
+					g.delay = time.Duration(s * float64(time.Second))
+				}
+			}
+		}
+	}
+	if hasNamed {
+		return &named
+	}
+	return &star
+}
+
+// patternRE compiles a robots.txt path pattern.
+func patternRE(p string) *regexp.Regexp {
+	anchored := strings.HasSuffix(p, "$")
+	p = strings.TrimSuffix(p, "$")
+	parts := strings.Split(p, "*")
+	for i, s := range parts {
+		parts[i] = regexp.QuoteMeta(s)
+	}
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
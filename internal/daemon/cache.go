@@ -0,0 +1,117 @@
+package daemon
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache limits: larger requests and responses are proxied without caching.
+const (
+	maxCachedRequest  = 64 << 10
+	maxCachedResponse = 4 << 20
+)
+
+// responseCache holds the responses to read-only requests for a short
+// time, keyed by everything that determines them, so that commands run in
+// quick succession share one round trip.
+type responseCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*cachedResponse
+	hits    int64
+	// gen counts the calls to clear, so that a response fetched before
+	// a change is not stored after it.
+	gen int64
+}
+
+type cachedResponse struct {
+	status  int
+	header  http.Header
+	body    []byte
+	fetched time.Time
+}
+
+// cacheKey returns the key of a request to target with body. The request
+// ID parameter, which differs for every request, is left out; the cookies
+// are kept, so that accounts never share entries.
+func cacheKey(method string, target *url.URL, header http.Header, body []byte) string {
+	u := *target
+	q := u.Query()
+	q.Del("_reqid")
+	u.RawQuery = q.Encode()
+	h := sha256.New()
+	for _, s := range []string{method, u.String(), header.Get("Cookie")} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// get returns the fresh entry for key, if any.
+func (c *responseCache) get(key string) *cachedResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.entries[key]
+	if e == nil || time.Since(e.fetched) > c.ttl {
+		return nil
+	}
+	c.hits++
+	return e
+}
+
+// generation returns the current generation, to pass to put.
+func (c *responseCache) generation() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen
+}
+
+// put stores the response for key, fetched in generation gen, unless the
+// cache was cleared since. Expired entries are dropped.
+func (c *responseCache) put(key string, gen int64, e *cachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		return
+	}
+	if c.entries == nil {
+		c.entries = make(map[string]*cachedResponse)
+	}
+	for k, old := range c.entries {
+		if time.Since(old.fetched) > c.ttl {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = e
+}
+
+// clear drops every entry, after a request that may have changed data.
+func (c *responseCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = nil
+	c.gen++
+}
+
+// stats returns the number of requests served from the cache.
+func (c *responseCache) stats() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits
+}
+
+// rpcIDs returns the calls a batchexecute request to target makes.
+func rpcIDs(target *url.URL) []string {
+	ids := target.Query().Get("rpcids")
+	if ids == "" {
+		return nil
+	}
+	return strings.Split(ids, ",")
+}
@@ -0,0 +1,141 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+)
+
+// Client talks to a daemon.
+type Client struct {
+	Socket string
+	http   *http.Client
+}
+
+// NewClient returns a client for the daemon listening on socket.
+func NewClient(socket string) *Client {
+	return &Client{
+		Socket: socket,
+		http: &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+			MaxIdleConns: 4,
+		}},
+	}
+}
+
+// Do sends an API request to the daemon. path is relative to the API
+// root, such as "/v1/status".
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	req.URL.Scheme = "http"
+	req.URL.Host = "nlm-daemon"
+	resp, err := c.http.Do(req)
+	if err != nil && notRunning(err) {
+		return nil, fmt.Errorf("%w on %s", ErrNotRunning, c.Socket)
+	}
+	return resp, err
+}
+
+// Call sends a JSON request to an API endpoint and decodes the JSON
+// response into out, if out is not nil.
+func (c *Client) Call(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = strings.NewReader(string(data))
+	}
+	req, err := http.NewRequestWithContext(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("daemon: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Status returns the daemon's status.
+func (c *Client) Status(ctx context.Context) (*Status, error) {
+	var st Status
+	if err := c.Call(ctx, http.MethodGet, "/v1/status", nil, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// Stop asks the daemon to exit.
+func (c *Client) Stop(ctx context.Context) error {
+	return c.Call(ctx, http.MethodPost, "/v1/shutdown", nil, nil)
+}
+
+// Transport returns an http.RoundTripper that sends requests through the
+// daemon. If the daemon cannot be reached, requests go directly through
+// fallback (http.DefaultTransport if nil).
+func (c *Client) Transport(fallback http.RoundTripper) http.RoundTripper {
+	if fallback == nil {
+		fallback = http.DefaultTransport
+	}
+	return &transport{c: c, fallback: fallback}
+}
+
+type transport struct {
+	c        *Client
+	fallback http.RoundTripper
+	down     atomic.Bool // set after the daemon was found unreachable
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.down.Load() || req.URL.Scheme != "https" {
+		return t.fallback.RoundTrip(req)
+	}
+	out := req.Clone(req.Context())
+	out.Header.Set(targetHeader, req.URL.String())
+	out.URL.Path = "/v1/proxy"
+	out.URL.RawPath = ""
+	out.URL.RawQuery = ""
+	out.Host = ""
+	resp, err := t.c.Do(out)
+	if errors.Is(err, ErrNotRunning) && (req.Body == nil || req.GetBody != nil) {
+		t.down.Store(true)
+		if req.GetBody != nil {
+			body, gerr := req.GetBody()
+			if gerr != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+		return t.fallback.RoundTrip(req)
+	}
+	return resp, err
+}
+
+// notRunning reports whether err means nothing is listening on the socket.
+func notRunning(err error) bool {
+	return errors.Is(err, syscall.ENOENT) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, os.ErrNotExist)
+}
@@ -0,0 +1,272 @@
+// Package daemon implements a long-lived local process that nlm commands
+// talk to over a unix socket.
+//
+// The daemon forwards the CLI's HTTP requests to NotebookLM over a pool of
+// connections it keeps open, so each command skips DNS, TCP and TLS
+// setup, and answers repeated read-only calls from a short-lived cache.
+// The socket is created with owner-only permissions and only Google hosts
+// are proxied.
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// SocketName is the name of the daemon's socket in the state directory.
+const SocketName = "daemon.sock"
+
+// targetHeader carries the upstream URL of a proxied request.
+const targetHeader = "X-Nlm-Target"
+
+// ErrNotRunning is returned when no daemon is listening on the socket.
+var ErrNotRunning = errors.New("daemon not running")
+
+// Status describes a running daemon.
+type Status struct {
+	PID      int       `json:"pid"`
+	Version  string    `json:"version"`
+	Started  time.Time `json:"started"`
+	Requests int64     `json:"requests"`
+	// CacheHits is the number of requests answered from the cache.
+	CacheHits int64 `json:"cache_hits"`
+}
+
+// Server serves the daemon API.
+type Server struct {
+	// Version is reported by the status endpoint.
+	Version string
+	// Transport sends proxied requests. It defaults to a transport that
+	// keeps idle connections open for several minutes.
+	Transport http.RoundTripper
+	// AllowHost reports whether requests may be proxied to host. It
+	// defaults to GoogleHost.
+	AllowHost func(host string) bool
+	// CacheTTL is how long responses to read-only calls are reused for
+	// identical requests. Zero disables the cache.
+	CacheTTL time.Duration
+	// ReadOnly reports whether the batchexecute calls with the given IDs
+	// only read data, so that their responses may be cached. Any other
+	// request clears the cache. If nil, nothing is cached.
+	ReadOnly func(rpcIDs []string) bool
+
+	mux      *http.ServeMux
+	srv      *http.Server
+	started  time.Time
+	requests atomic.Int64
+	cache    responseCache
+}
+
+func (s *Server) init() {
+	if s.mux != nil {
+		return
+	}
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("GET /v1/status", s.status)
+	s.mux.HandleFunc("POST /v1/shutdown", s.shutdown)
+	s.mux.HandleFunc("/v1/proxy", s.proxy)
+}
+
+// Serve accepts connections on l until Shutdown is called.
+func (s *Server) Serve(l net.Listener) error {
+	s.init()
+	if s.Transport == nil {
+		s.Transport = &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			ForceAttemptHTTP2:   true,
+			MaxIdleConnsPerHost: 8,
+			IdleConnTimeout:     15 * time.Minute,
+			TLSHandshakeTimeout: 10 * time.Second,
+		}
+	}
+	if s.AllowHost == nil {
+		s.AllowHost = GoogleHost
+	}
+	s.cache.ttl = s.CacheTTL
+	s.started = time.Now()
+	s.srv = &http.Server{Handler: s.mux, ReadHeaderTimeout: 10 * time.Second}
+	err := s.srv.Serve(l)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown stops the server after in-flight requests finish.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if s.srv == nil {
+		return nil
+	}
+	return s.srv.Shutdown(ctx)
+}
+
+func (s *Server) status(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, Status{
+		PID:       os.Getpid(),
+		Version:   s.Version,
+		Started:   s.started,
+		Requests:  s.requests.Load(),
+		CacheHits: s.cache.stats(),
+	})
+}
+
+func (s *Server) shutdown(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+	go s.Shutdown(context.Background())
+}
+
+// hopHeaders are not forwarded in either direction.
+var hopHeaders = []string{"Connection", "Keep-Alive", "Proxy-Connection", "Transfer-Encoding", "Upgrade", "Te", "Trailer", targetHeader}
+
+func (s *Server) proxy(w http.ResponseWriter, r *http.Request) {
+	s.requests.Add(1)
+	target, err := url.Parse(r.Header.Get(targetHeader))
+	if err != nil || target.Scheme != "https" {
+		http.Error(w, "missing or invalid "+targetHeader, http.StatusBadRequest)
+		return
+	}
+	if !s.AllowHost(target.Hostname()) {
+		http.Error(w, "host not allowed: "+target.Hostname(), http.StatusForbidden)
+		return
+	}
+	body := io.Reader(r.Body)
+	var key string
+	var gen int64
+	ids := rpcIDs(target)
+	switch {
+	case s.CacheTTL <= 0 || s.ReadOnly == nil || r.Method == http.MethodGet || r.Method == http.MethodHead:
+	case r.Method == http.MethodPost && len(ids) > 0 && s.ReadOnly(ids) && r.ContentLength >= 0 && r.ContentLength <= maxCachedRequest:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		key = cacheKey(r.Method, target, r.Header, data)
+		if e := s.cache.get(key); e != nil {
+			writeResponse(w, e.status, e.header, bytes.NewReader(e.body))
+			return
+		}
+		gen = s.cache.generation()
+		body = bytes.NewReader(data)
+	default:
+		// The request may change data: drop what was cached before it
+		// and, below, what was fetched while it ran.
+		s.cache.clear()
+		defer s.cache.clear()
+	}
+	out, err := http.NewRequestWithContext(r.Context(), r.Method, target.String(), body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	out.ContentLength = r.ContentLength
+	out.Header = r.Header.Clone()
+	for _, h := range hopHeaders {
+		out.Header.Del(h)
+	}
+	resp, err := s.Transport.RoundTrip(out)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	if key != "" && resp.StatusCode == http.StatusOK && resp.ContentLength <= maxCachedResponse {
+		data, err := io.ReadAll(io.LimitReader(resp.Body, maxCachedResponse+1))
+		if err == nil && len(data) <= maxCachedResponse {
+			s.cache.put(key, gen, &cachedResponse{status: resp.StatusCode, header: resp.Header.Clone(), body: data, fetched: time.Now()})
+		}
+		writeResponse(w, resp.StatusCode, resp.Header, io.MultiReader(bytes.NewReader(data), resp.Body))
+		return
+	}
+	writeResponse(w, resp.StatusCode, resp.Header, resp.Body)
+}
+
+// writeResponse writes a proxied response, without hop-by-hop headers.
+func writeResponse(w http.ResponseWriter, status int, header http.Header, body io.Reader) {
+	for k, v := range header {
+		w.Header()[k] = v
+	}
+	for _, h := range hopHeaders {
+		w.Header().Del(h)
+	}
+	w.WriteHeader(status)
+	io.Copy(w, body)
+}
+
+// GoogleHost reports whether host is google.com, googleusercontent.com or
+// one of their subdomains.
+func GoogleHost(host string) bool {
+	for _, d := range []string{"google.com", "googleusercontent.com"} {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// Listen creates the daemon socket at path with owner-only permissions. A
+// socket left behind by a daemon that exited is replaced; a live one is an
+// error.
+//
+// The socket is bound inside a new directory only its owner can enter and
+// moved into place once it is private, so that no other user can connect
+// to it in between, whatever the permissions of path's directory.
+func Listen(path string) (net.Listener, error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	if Running(path) {
+		return nil, fmt.Errorf("already running on %s", path)
+	}
+	private, err := os.MkdirTemp(dir, ".sock-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(private)
+	tmp := filepath.Join(private, "s")
+	l, err := net.Listen("unix", tmp)
+	if err != nil {
+		return nil, err
+	}
+	// The listener must not remove the path it was bound to when it is
+	// closed: by then that is gone, and path may be another daemon's.
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	if err := os.Chmod(tmp, 0o600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	os.Remove(path)
+	if err := os.Rename(tmp, path); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// Running reports whether a daemon is accepting connections on path.
+func Running(path string) bool {
+	conn, err := net.DialTimeout("unix", path, 200*time.Millisecond)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
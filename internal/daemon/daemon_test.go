@@ -0,0 +1,208 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startServer runs a daemon that proxies to upstream and returns a client.
+// The daemon caches calls whose IDs start with "r".
+func startServer(t *testing.T, upstream *httptest.Server) *Client {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), SocketName)
+	l, err := Listen(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{
+		Version:   "test",
+		Transport: upstream.Client().Transport,
+		AllowHost: func(host string) bool { return host == "127.0.0.1" },
+		CacheTTL:  time.Minute,
+		ReadOnly: func(ids []string) bool {
+			for _, id := range ids {
+				if !strings.HasPrefix(id, "r") {
+					return false
+				}
+			}
+			return true
+		},
+	}
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(l) }()
+	t.Cleanup(func() {
+		s.Shutdown(context.Background())
+		if err := <-done; err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+	})
+	return NewClient(socket)
+}
+
+func TestProxy(t *testing.T) {
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(targetHeader) != "" {
+			t.Error("target header forwarded upstream")
+		}
+		w.Header().Set("X-Echo-Cookie", r.Header.Get("Cookie"))
+		io.WriteString(w, r.Method+" "+r.URL.RequestURI()+" "+string(body))
+	}))
+	defer upstream.Close()
+	c := startServer(t, upstream)
+
+	hc := &http.Client{Transport: c.Transport(nil)}
+	req, _ := http.NewRequest(http.MethodPost, upstream.URL+"/_/data?rpcids=abc", strings.NewReader("f.req=1"))
+	req.Header.Set("Cookie", "SID=x")
+	resp, err := hc.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if got, want := string(body), "POST /_/data?rpcids=abc f.req=1"; got != want {
+		t.Errorf("proxied body = %q, want %q", got, want)
+	}
+	if got := resp.Header.Get("X-Echo-Cookie"); got != "SID=x" {
+		t.Errorf("cookie = %q, want SID=x", got)
+	}
+
+	st, err := c.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Requests != 1 || st.Version != "test" {
+		t.Errorf("Status = %+v", st)
+	}
+}
+
+func TestProxyCache(t *testing.T) {
+	var calls int
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprintf(w, "response %d", calls)
+	}))
+	defer upstream.Close()
+	c := startServer(t, upstream)
+	hc := &http.Client{Transport: c.Transport(nil)}
+	post := func(rpcID, reqID, cookie string) string {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, upstream.URL+"/_/data?rpcids="+rpcID+"&_reqid="+reqID, strings.NewReader("f.req=1"))
+		req.Header.Set("Cookie", cookie)
+		resp, err := hc.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	steps := []struct {
+		rpcID, reqID, cookie, want string
+	}{
+		{"rList", "1", "SID=a", "response 1"},
+		{"rList", "2", "SID=a", "response 1"}, // cached despite a new request ID
+		{"rList", "3", "SID=b", "response 2"}, // another account
+		{"wCreate", "4", "SID=a", "response 3"},
+		{"rList", "5", "SID=a", "response 4"}, // the write cleared the cache
+	}
+	for _, s := range steps {
+		if got := post(s.rpcID, s.reqID, s.cookie); got != s.want {
+			t.Errorf("%s %s as %s = %q, want %q", s.rpcID, s.reqID, s.cookie, got, s.want)
+		}
+	}
+	st, err := c.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.CacheHits != 1 {
+		t.Errorf("CacheHits = %d, want 1", st.CacheHits)
+	}
+}
+
+func TestListenPermissions(t *testing.T) {
+	dir := t.TempDir()
+	os.Chmod(dir, 0o755)
+	socket := filepath.Join(dir, SocketName)
+	l, err := Listen(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	fi, err := os.Stat(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := fi.Mode().Perm(); perm != 0o600 {
+		t.Errorf("socket mode = %v, want 0600", perm)
+	}
+	if !Running(socket) {
+		t.Error("no daemon accepting connections on the socket")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("directory holds %d entries, want only the socket", len(entries))
+	}
+}
+
+func TestProxyRejectsOtherHosts(t *testing.T) {
+	upstream := httptest.NewTLSServer(http.NotFoundHandler())
+	defer upstream.Close()
+	c := startServer(t, upstream)
+
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com/", nil)
+	resp, err := (&http.Client{Transport: c.Transport(nil)}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("status = %d, want 403", resp.StatusCode)
+	}
+}
+
+func TestTransportFallback(t *testing.T) {
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "direct")
+	}))
+	defer upstream.Close()
+
+	c := NewClient(filepath.Join(t.TempDir(), SocketName))
+	if _, err := c.Status(context.Background()); !errors.Is(err, ErrNotRunning) {
+		t.Fatalf("Status() error = %v, want ErrNotRunning", err)
+	}
+	hc := &http.Client{Transport: c.Transport(upstream.Client().Transport)}
+	resp, err := hc.Post(upstream.URL, "text/plain", strings.NewReader("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "direct" {
+		t.Errorf("body = %q, want direct", body)
+	}
+}
+
+func TestGoogleHost(t *testing.T) {
+	for host, want := range map[string]bool{
+		"notebooklm.google.com":         true,
+		"google.com":                    true,
+		"lh3.googleusercontent.com":     true,
+		"evilgoogle.com":                false,
+		"notebooklm.google.com.evil.io": false,
+	} {
+		if got := GoogleHost(host); got != want {
+			t.Errorf("GoogleHost(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+)
+
+// EditorSocketName is the name of the editor API socket in the state
+// directory.
+const EditorSocketName = "editor.sock"
+
+// maxRequest bounds a single editor request line, which may carry a large
+// selection.
+const maxRequest = 16 << 20
+
+// A Request is one line sent by an editor:
+//
+//	{"id": 1, "method": "add-selection", "params": {"text": "...", "file": "main.go", "line": 42}}
+type Request struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// A Response answers the request with the same ID, on one line.
+type Response struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Result any             `json:"result,omitempty"`
+	Error  *Error          `json:"error,omitempty"`
+}
+
+// Error is a failed request.
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string { return e.Code + ": " + e.Message }
+
+// Error codes.
+const (
+	CodeBadRequest    = "bad_request"
+	CodeUnknownMethod = "unknown_method"
+	CodeNoNotebook    = "no_notebook"
+	CodeFailed        = "failed"
+)
+
+// A MethodFunc handles one editor method. Returning an *Error sets its
+// code; other errors are reported as CodeFailed.
+type MethodFunc func(ctx context.Context, params json.RawMessage) (any, error)
+
+// EditorServer serves newline-delimited JSON requests from editor plugins.
+// Requests on one connection are answered in order.
+type EditorServer struct {
+	Methods map[string]MethodFunc
+
+	mu    sync.Mutex
+	l     net.Listener
+	conns map[net.Conn]bool
+}
+
+// Serve accepts connections on l until Close is called.
+func (s *EditorServer) Serve(l net.Listener) error {
+	s.mu.Lock()
+	s.l = l
+	s.conns = map[net.Conn]bool{}
+	s.mu.Unlock()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		s.mu.Lock()
+		s.conns[conn] = true
+		s.mu.Unlock()
+		go s.serveConn(conn)
+	}
+}
+
+// Close stops accepting connections and closes open ones.
+func (s *EditorServer) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.conns {
+		c.Close()
+	}
+	if s.l == nil {
+		return nil
+	}
+	return s.l.Close()
+}
+
+func (s *EditorServer) serveConn(conn net.Conn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+	sc := bufio.NewScanner(conn)
+	sc.Buffer(make([]byte, 0, 64<<10), maxRequest)
+	enc := json.NewEncoder(conn)
+	for sc.Scan() {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		if err := enc.Encode(s.handle(sc.Bytes())); err != nil {
+			return
+		}
+	}
+}
+
+func (s *EditorServer) handle(line []byte) Response {
+	var req Request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return Response{Error: &Error{Code: CodeBadRequest, Message: err.Error()}}
+	}
+	resp := Response{ID: req.ID}
+	m, ok := s.Methods[req.Method]
+	if !ok {
+		resp.Error = &Error{Code: CodeUnknownMethod, Message: "unknown method " + req.Method}
+		return resp
+	}
+	result, err := m(context.Background(), req.Params)
+	if err != nil {
+		var e *Error
+		if !errors.As(err, &e) {
+			e = &Error{Code: CodeFailed, Message: err.Error()}
+		}
+		resp.Error = e
+		return resp
+	}
+	resp.Result = result
+	return resp
+}
@@ -0,0 +1,70 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEditorServer(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), EditorSocketName)
+	l, err := Listen(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &EditorServer{Methods: map[string]MethodFunc{
+		"echo": func(_ context.Context, params json.RawMessage) (any, error) {
+			var p struct{ Text string }
+			if err := json.Unmarshal(params, &p); err != nil {
+				return nil, &Error{Code: CodeBadRequest, Message: err.Error()}
+			}
+			return map[string]string{"text": p.Text}, nil
+		},
+		"fail": func(context.Context, json.RawMessage) (any, error) {
+			return nil, errors.New("boom")
+		},
+	}}
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(l) }()
+	defer func() {
+		s.Close()
+		if err := <-done; err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+	}()
+
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	tests := []struct {
+		req  string
+		want string
+	}{
+		{`{"id":1,"method":"echo","params":{"text":"hi"}}`, `{"id":1,"result":{"text":"hi"}}`},
+		{`{"id":"a","method":"fail"}`, `{"id":"a","error":{"code":"failed","message":"boom"}}`},
+		{`{"id":2,"method":"nope"}`, `{"id":2,"error":{"code":"unknown_method","message":"unknown method nope"}}`},
+		{`not json`, `{"error":{"code":"bad_request","message":"invalid character 'o' in literal null (expecting 'u')"}}`},
+	}
+	for _, tt := range tests {
+		if _, err := conn.Write([]byte(tt.req + "\n")); err != nil {
+			t.Fatal(err)
+		}
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(tt.want+"\n", line); diff != "" {
+			t.Errorf("response to %s mismatch (-want +got):\n%s", tt.req, diff)
+		}
+	}
+}
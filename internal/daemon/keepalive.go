@@ -0,0 +1,32 @@
+package daemon
+
+import (
+	"context"
+	"time"
+)
+
+// KeepAlive calls ping every interval until ctx is done. A long-running
+// session that sits idle can otherwise find its cookies and CSRF token
+// stale by the time it is next used. report, if not nil, is called with
+// the result of each ping. An interval of zero or less disables the pings.
+func KeepAlive(ctx context.Context, interval time.Duration, ping func(ctx context.Context) error, report func(error)) {
+	if interval <= 0 {
+		return
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		err := ping(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if report != nil {
+			report(err)
+		}
+	}
+}
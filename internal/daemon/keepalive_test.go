@@ -0,0 +1,45 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestKeepAlive(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var results []error
+	pings := 0
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		KeepAlive(ctx, time.Millisecond, func(context.Context) error {
+			pings++
+			if pings == 2 {
+				return errors.New("expired")
+			}
+			return nil
+		}, func(err error) {
+			results = append(results, err)
+			if len(results) == 3 {
+				cancel()
+			}
+		})
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("KeepAlive did not stop when its context was canceled")
+	}
+	if len(results) != 3 || results[0] != nil || results[1] == nil || results[2] != nil {
+		t.Errorf("results = %v, want nil, an error, nil", results)
+	}
+}
+
+func TestKeepAliveDisabled(t *testing.T) {
+	KeepAlive(context.Background(), 0, func(context.Context) error {
+		t.Error("pinged with a zero interval")
+		return nil
+	}, nil)
+}
@@ -0,0 +1,291 @@
+// Package dirsync plans the changes that make a notebook's sources mirror
+// the files in a local directory. A manifest in the directory remembers,
+// for each file, the source it was uploaded as, the file's hash at upload
+// and the source's server-side modification time, so edits made on either
+// side since the last sync can be told apart and conflicts are never
+// resolved silently.
+package dirsync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ManifestName is the file name of the manifest inside a synced directory.
+const ManifestName = ".nlm-sync.json"
+
+// Policy decides conflicts: files changed locally whose sources were also
+// changed or removed in the notebook since the last sync.
+type Policy string
+
+// Conflict policies.
+const (
+	PreferLocal  Policy = "prefer-local"
+	PreferRemote Policy = "prefer-remote"
+	Prompt       Policy = "prompt"
+)
+
+// ParsePolicy parses a -conflict flag value.
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(s); p {
+	case PreferLocal, PreferRemote, Prompt:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown conflict policy %q (want prefer-local, prefer-remote or prompt)", s)
+}
+
+// Entry is the sync state of one file.
+type Entry struct {
+	SourceID string `json:"source_id,omitempty"`
+	Hash     string `json:"sha256"`
+	// Modified is the source's modification time as reported by the
+	// server after the last upload; zero if the server did not report one.
+	Modified time.Time `json:"modified,omitempty"`
+	// Removed records that the source was deleted in the notebook while
+	// the file was unchanged, so the file is not uploaded again until it
+	// is edited.
+	Removed bool `json:"removed,omitempty"`
+}
+
+// Manifest is the sync state of a directory.
+type Manifest struct {
+	NotebookID string           `json:"notebook_id"`
+	SyncedAt   time.Time        `json:"synced_at,omitempty"`
+	Files      map[string]Entry `json:"files"`
+
+	dir string
+}
+
+// Open loads the manifest in dir, or returns a new one if the directory
+// was never synced. It is an error to sync a directory with a different
+// notebook than before.
+func Open(dir, notebookID string) (*Manifest, error) {
+	m := &Manifest{NotebookID: notebookID, Files: make(map[string]Entry), dir: dir}
+	data, err := os.ReadFile(filepath.Join(dir, ManifestName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read sync manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("parse sync manifest: %w", err)
+	}
+	if m.NotebookID != notebookID {
+		return nil, fmt.Errorf("%s is synced with notebook %s, not %s", dir, m.NotebookID, notebookID)
+	}
+	if m.Files == nil {
+		m.Files = make(map[string]Entry)
+	}
+	m.dir = dir
+	return m, nil
+}
+
+// Save writes the manifest back to its directory.
+func (m *Manifest) Save() error {
+	m.SyncedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(m.dir, ManifestName+".tmp")
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(m.dir, ManifestName))
+}
+
+// Scan hashes every regular file under dir, keyed by slash-separated path
+// relative to dir. Hidden files and directories are skipped.
+func Scan(dir string) (map[string]string, error) {
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		sum, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = sum
+		return nil
+	})
+	return files, err
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Action is what a sync does for one file.
+type Action int
+
+// Actions, from the local side's point of view.
+const (
+	// Upload adds the file as a new source.
+	Upload Action = iota
+	// Replace uploads the file and removes its previous source.
+	Replace
+	// Delete removes the source of a file deleted locally.
+	Delete
+	// Forget stops tracking a file that is gone on both sides.
+	Forget
+	// Tombstone records that the source was removed in the notebook.
+	Tombstone
+)
+
+func (a Action) String() string {
+	switch a {
+	case Upload:
+		return "upload"
+	case Replace:
+		return "replace"
+	case Delete:
+		return "delete"
+	case Forget:
+		return "forget"
+	case Tombstone:
+		return "removed in notebook"
+	}
+	return fmt.Sprintf("Action(%d)", int(a))
+}
+
+// Change is one planned step.
+type Change struct {
+	Path     string
+	Action   Action
+	SourceID string // the file's current source, if any
+	Hash     string // the file's current hash, if it exists locally
+	// Conflict is set when the notebook changed too; Action is then what
+	// the local side wants, and the conflict policy decides.
+	Conflict string
+}
+
+// Plan compares the files in the directory (from Scan) and the notebook's
+// sources (source ID to modification time; zero if the server reports
+// none) against the manifest. A source whose modification time differs
+// from the recorded one was edited in the notebook. Sources without a
+// reported time fall back to comparing local hashes only.
+func Plan(m *Manifest, local map[string]string, remote map[string]time.Time) []Change {
+	var changes []Change
+	for path, hash := range local {
+		e, ok := m.Files[path]
+		if !ok || e.Removed && e.Hash != hash {
+			changes = append(changes, Change{Path: path, Action: Upload, Hash: hash})
+			continue
+		}
+		if e.Removed {
+			continue
+		}
+		mod, exists := remote[e.SourceID]
+		switch {
+		case !exists && e.Hash == hash:
+			changes = append(changes, Change{Path: path, Action: Tombstone, SourceID: e.SourceID, Hash: hash})
+		case !exists:
+			changes = append(changes, Change{Path: path, Action: Upload, Hash: hash, Conflict: "edited locally, removed in notebook"})
+		case e.Hash == hash:
+			// Unchanged locally; edits made in the notebook are kept.
+		case remoteChanged(e, mod):
+			changes = append(changes, Change{Path: path, Action: Replace, SourceID: e.SourceID, Hash: hash, Conflict: "edited on both sides"})
+		default:
+			changes = append(changes, Change{Path: path, Action: Replace, SourceID: e.SourceID, Hash: hash})
+		}
+	}
+	for path, e := range m.Files {
+		if _, ok := local[path]; ok {
+			continue
+		}
+		mod, exists := remote[e.SourceID]
+		switch {
+		case e.Removed || !exists:
+			changes = append(changes, Change{Path: path, Action: Forget, SourceID: e.SourceID})
+		case remoteChanged(e, mod):
+			changes = append(changes, Change{Path: path, Action: Delete, SourceID: e.SourceID, Conflict: "deleted locally, edited in notebook"})
+		default:
+			changes = append(changes, Change{Path: path, Action: Delete, SourceID: e.SourceID})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+func remoteChanged(e Entry, mod time.Time) bool {
+	return !e.Modified.IsZero() && !mod.IsZero() && !mod.Equal(e.Modified)
+}
+
+// Uploaded records that the file at path was uploaded as sourceID.
+func (m *Manifest) Uploaded(path, hash, sourceID string) {
+	m.Files[path] = Entry{SourceID: sourceID, Hash: hash}
+}
+
+// Apply records the outcome of a change that needs no upload: Delete,
+// Forget and Tombstone.
+func (m *Manifest) Apply(c Change) {
+	switch c.Action {
+	case Delete, Forget:
+		delete(m.Files, c.Path)
+	case Tombstone:
+		m.Files[c.Path] = Entry{Hash: c.Hash, Removed: true}
+	}
+}
+
+// KeepRemote records a conflict resolved in the notebook's favour: the
+// local edit is accepted as synced without being uploaded, and a file
+// deleted locally stops being tracked while its source stays.
+func (m *Manifest) KeepRemote(c Change, remote map[string]time.Time) {
+	switch {
+	case c.Action == Delete:
+		delete(m.Files, c.Path)
+	case c.SourceID == "":
+		m.Files[c.Path] = Entry{Hash: c.Hash, Removed: true}
+	default:
+		m.Files[c.Path] = Entry{SourceID: c.SourceID, Hash: c.Hash, Modified: remote[c.SourceID]}
+	}
+}
+
+// Stamp records the current server modification times of the sources of
+// files whose contents are in sync, typically after uploading, so the next
+// Plan can detect later edits in the notebook. Files with an unresolved
+// conflict keep their old time, so the conflict is reported again.
+func (m *Manifest) Stamp(local map[string]string, remote map[string]time.Time) {
+	for path, e := range m.Files {
+		mod, ok := remote[e.SourceID]
+		if !ok || e.SourceID == "" || local[path] != e.Hash {
+			continue
+		}
+		e.Modified = mod
+		m.Files[path] = e
+	}
+}
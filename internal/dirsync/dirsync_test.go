@@ -0,0 +1,136 @@
+package dirsync
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPlan(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Hour)
+	m := &Manifest{Files: map[string]Entry{
+		"same.md":          {SourceID: "s1", Hash: "a", Modified: t0},
+		"edited.md":        {SourceID: "s2", Hash: "a", Modified: t0},
+		"both.md":          {SourceID: "s3", Hash: "a", Modified: t0},
+		"remote-edit.md":   {SourceID: "s4", Hash: "a", Modified: t0},
+		"deleted.md":       {SourceID: "s5", Hash: "a", Modified: t0},
+		"deleted-edit.md":  {SourceID: "s6", Hash: "a", Modified: t0},
+		"removed.md":       {SourceID: "s7", Hash: "a", Modified: t0},
+		"removed-edit.md":  {SourceID: "s8", Hash: "a", Modified: t0},
+		"gone.md":          {SourceID: "s9", Hash: "a"},
+		"tombstone.md":     {Hash: "a", Removed: true},
+		"tombstone-new.md": {Hash: "a", Removed: true},
+		"untimed.md":       {SourceID: "s10", Hash: "a"},
+	}}
+	local := map[string]string{
+		"new.md":           "n",
+		"same.md":          "a",
+		"edited.md":        "b",
+		"both.md":          "b",
+		"remote-edit.md":   "a",
+		"removed.md":       "a",
+		"removed-edit.md":  "b",
+		"tombstone.md":     "a",
+		"tombstone-new.md": "b",
+		"untimed.md":       "b",
+	}
+	remote := map[string]time.Time{
+		"s1": t0, "s2": t0, "s3": t1, "s4": t1, "s5": t0, "s6": t1, "s10": {},
+	}
+	want := []Change{
+		{Path: "both.md", Action: Replace, SourceID: "s3", Hash: "b", Conflict: "edited on both sides"},
+		{Path: "deleted-edit.md", Action: Delete, SourceID: "s6", Conflict: "deleted locally, edited in notebook"},
+		{Path: "deleted.md", Action: Delete, SourceID: "s5"},
+		{Path: "edited.md", Action: Replace, SourceID: "s2", Hash: "b"},
+		{Path: "gone.md", Action: Forget, SourceID: "s9"},
+		{Path: "new.md", Action: Upload, Hash: "n"},
+		{Path: "removed-edit.md", Action: Upload, Hash: "b", Conflict: "edited locally, removed in notebook"},
+		{Path: "removed.md", Action: Tombstone, SourceID: "s7", Hash: "a"},
+		{Path: "tombstone-new.md", Action: Upload, Hash: "b"},
+		{Path: "untimed.md", Action: Replace, SourceID: "s10", Hash: "b"},
+	}
+	if diff := cmp.Diff(want, Plan(m, local, remote)); diff != "" {
+		t.Errorf("Plan mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestConflictPersistsUntilResolved(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Hour)
+	m := &Manifest{Files: map[string]Entry{"a.md": {SourceID: "s1", Hash: "old", Modified: t0}}}
+	local := map[string]string{"a.md": "new"}
+	remote := map[string]time.Time{"s1": t1}
+
+	// A skipped conflict must not be stamped away.
+	m.Stamp(local, remote)
+	if got := Plan(m, local, remote); len(got) != 1 || got[0].Conflict == "" {
+		t.Fatalf("after skip, Plan = %+v, want the conflict again", got)
+	}
+
+	m.KeepRemote(Plan(m, local, remote)[0], remote)
+	if got := Plan(m, local, remote); len(got) != 0 {
+		t.Errorf("after KeepRemote, Plan = %+v, want no changes", got)
+	}
+}
+
+func TestOpenScanSave(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"a.md":         "alpha",
+		"sub/b.txt":    "beta",
+		".hidden":      "x",
+		".git/config":  "x",
+		ManifestName:   "{}",
+		"sub/.tmp.swp": "x",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files, err := Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files["a.md"] == "" || files["sub/b.txt"] == "" {
+		t.Errorf("Scan = %v, want a.md and sub/b.txt", files)
+	}
+
+	os.Remove(filepath.Join(dir, ManifestName))
+	m, err := Open(dir, "nb1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Uploaded("a.md", files["a.md"], "s1")
+	if err := m.Save(); err != nil {
+		t.Fatal(err)
+	}
+	m, err = Open(dir, "nb1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Files["a.md"].SourceID != "s1" {
+		t.Errorf("reopened manifest lost a.md: %+v", m.Files)
+	}
+	if _, err := Open(dir, "nb2"); err == nil {
+		t.Error("Open with a different notebook succeeded")
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	for _, s := range []string{"prefer-local", "prefer-remote", "prompt"} {
+		if _, err := ParsePolicy(s); err != nil {
+			t.Errorf("ParsePolicy(%q): %v", s, err)
+		}
+	}
+	if _, err := ParsePolicy("newest"); err == nil {
+		t.Error("ParsePolicy accepted an unknown policy")
+	}
+}
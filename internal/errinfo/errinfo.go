@@ -0,0 +1,282 @@
+// Package errinfo classifies the errors nlm reports into a small set of
+// stable codes, each with a suggested next step, so failures can be read
+// by scripts as well as people.
+package errinfo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/url"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/batchexecute"
+	"github.com/tmc/nlm/internal/quota"
+	"github.com/tmc/nlm/internal/state"
+	"github.com/tmc/nlm/internal/validate"
+)
+
+// Error codes. They are part of nlm's output and must not change.
+const (
+	CodeUnauthenticated    = "unauthenticated"
+	CodeStaleAuth          = "stale_auth"
+	CodeReadOnly           = "read_only"
+	CodeReadOnlyMode       = "read_only_mode"
+	CodeFeatureUnavailable = "feature_unavailable"
+	CodeInvalidArgument    = "invalid_argument"
+	CodeNotFound           = "not_found"
+	CodeQuotaExceeded      = "quota_exceeded"
+	CodeRateLimited        = "rate_limited"
+	CodeFailedPrecondition = "failed_precondition"
+	CodeServerError        = "server_error"
+	CodeNetwork            = "network"
+	CodeCanceled           = "canceled"
+	CodeDeclined           = "declined"
+	CodeStateLocked        = "state_locked"
+	CodeBudgetExceeded     = "budget_exceeded"
+	CodeAccountAction      = "account_action_required"
+	CodeFile               = "file"
+	CodeUnknown            = "unknown"
+)
+
+// UsageError is an error in how nlm was run, such as a malformed flag
+// value or a missing argument, found before anything was sent.
+type UsageError struct {
+	Err error
+}
+
+func (e *UsageError) Error() string { return e.Err.Error() }
+func (e *UsageError) Unwrap() error { return e.Err }
+
+// Usage marks err as a UsageError. It returns nil if err is nil.
+func Usage(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &UsageError{err}
+}
+
+// Usagef formats a UsageError like fmt.Errorf.
+func Usagef(format string, a ...any) error {
+	return &UsageError{fmt.Errorf(format, a...)}
+}
+
+// ErrDeclined is returned when the user answers no at a confirmation
+// prompt.
+var ErrDeclined = errors.New("operation cancelled")
+
+// Info describes an error.
+type Info struct {
+	Code       string `json:"code"`
+	RPCID      string `json:"rpcid,omitempty"`
+	HTTPStatus int    `json:"http_status,omitempty"`
+	RPCCode    int    `json:"rpc_code,omitempty"` // google.rpc.Code from the response envelope
+	// RetryAfter is the wait the server asked for before retrying, such
+	// as "30s".
+	RetryAfter string `json:"retry_after,omitempty"`
+	// URL is the page Google asked the user to visit, for
+	// account_action_required and some sign-in errors.
+	URL        string `json:"url,omitempty"`
+	Suggestion string `json:"suggestion"`
+	// Explanation says in a few sentences what the code usually means.
+	Explanation string `json:"explanation,omitempty"`
+}
+
+// entry is the guidance kept for an error code.
+type entry struct {
+	suggestion  string
+	explanation string
+}
+
+// taxonomy maps each code to its guidance.
+var taxonomy = map[string]entry{
+	CodeUnauthenticated: {
+		"run `nlm auth` again",
+		"NotebookLM rejected the saved credentials. Browser sessions expire, and signing out of Google in the browser nlm copied them from ends them early. `nlm auth` copies a fresh session.",
+	},
+	CodeStaleAuth: {
+		"run `nlm auth` again",
+		"The server refused the request as malformed. With valid arguments this almost always means the `at` token saved with the cookies is stale: it is tied to the page load it was copied from and expires after a while, even when the cookies still work. `nlm auth` saves a new one.",
+	},
+	CodeReadOnly: {
+		"ask the notebook's owner for editor access",
+		"The notebook is shared with you as a viewer, so nlm refused to change it rather than fail part way through.",
+	},
+	CodeReadOnlyMode: {
+		"rerun without -read-only, or with -read-only=false if read_only is set in the global config",
+		"nlm is in read-only mode, so it refused a call that would change a notebook, source, note or sharing setting. Nothing was sent to NotebookLM.",
+	},
+	CodeFeatureUnavailable: {
+		"run `nlm features` to see what your account has",
+		"The server does not offer this call to your account. NotebookLM rolls features out gradually, and some are limited by region, age or Workspace policy.",
+	},
+	CodeInvalidArgument: {
+		"check the IDs and arguments; if they are right, run `nlm self-update`",
+		"The server could not use the request's arguments. Either an ID or value is wrong, or NotebookLM changed the call and this version of nlm sends the old form.",
+	},
+	CodeNotFound: {
+		"check the ID with `nlm list`, `nlm sources` or `nlm notes`",
+		"The notebook, source or note does not exist, or is no longer shared with you.",
+	},
+	CodeQuotaExceeded: {
+		"wait and retry, or remove sources or notebooks you no longer need",
+		"A limit of your account was reached, such as the number of notebooks, sources per notebook or daily generations.",
+	},
+	CodeRateLimited: {
+		"wait a minute and retry with fewer calls at once (for batch, lower -workers)",
+		"NotebookLM is throttling requests from this account.",
+	},
+	CodeFailedPrecondition: {
+		"wait for the notebook's sources to finish processing, then retry",
+		"The notebook is not in a state that allows the call yet, for example while sources are still being processed or an audio overview is being generated.",
+	},
+	CodeServerError: {
+		"retry later; if it persists, attach `nlm bugreport` to an issue",
+		"NotebookLM failed to handle the request. These errors are usually temporary.",
+	},
+	CodeNetwork: {
+		"check your connection and proxy settings, then retry",
+		"nlm could not reach the server.",
+	},
+	CodeCanceled: {
+		"rerun the command",
+		"The command was interrupted before it finished.",
+	},
+	CodeDeclined: {
+		"rerun the command and answer y to go ahead",
+		"You answered no when nlm asked for confirmation, so nothing was changed.",
+	},
+	CodeStateLocked: {
+		"set NLM_STATE_PASSPHRASE, or NLM_STATE_KEYCHAIN=1",
+		"nlm's local state is encrypted and no key to open it was given.",
+	},
+	CodeBudgetExceeded: {
+		"wait until tomorrow, or raise -budget",
+		"nlm stopped before sending a request that would take this profile over the daily budget set with -budget or NLM_BUDGET. Nothing was sent to NotebookLM. `nlm quota -local` shows what was used.",
+	},
+	CodeAccountAction: {
+		"open the URL in the browser signed in to this account, follow the page, then retry",
+		"Google answered with a page for the account holder, such as a consent screen or a \"verify it's you\" check, instead of NotebookLM's data. The saved credentials work again once it has been dealt with in the browser; `batch` waits for that when run from a terminal.",
+	},
+	CodeFile: {
+		"check the path and its permissions",
+		"nlm could not read or write a local file.",
+	},
+	CodeUnknown: {
+		"rerun with -debug; to report it, attach `nlm bugreport` to an issue",
+		"nlm does not recognize this error.",
+	},
+}
+
+// Classify describes err.
+func Classify(err error) Info {
+	info := Info{Code: classify(err)}
+	var be *batchexecute.BatchExecuteError
+	if errors.As(err, &be) {
+		info.RPCID, info.HTTPStatus = be.RPCIDs, be.StatusCode
+	}
+	var re *batchexecute.RPCError
+	if errors.As(err, &re) {
+		info.RPCID, info.RPCCode = re.ID, re.Code
+	}
+	var ie *batchexecute.InterstitialError
+	if errors.As(err, &ie) {
+		info.RPCID, info.URL = ie.RPCIDs, ie.URL
+	}
+	e := taxonomy[info.Code]
+	info.Suggestion, info.Explanation = e.suggestion, e.explanation
+	if d, ok := batchexecute.RetryDelay(err); ok {
+		info.RetryAfter = d.String()
+		info.Suggestion = "wait " + info.RetryAfter + ", as the server asked, then retry"
+	}
+	var ve *validate.Error
+	if errors.As(err, &ve) {
+		// Nothing reached the server, so the usual advice does not apply.
+		info.Suggestion = "correct the argument, or pass -no-validate if NotebookLM changed its format"
+		info.Explanation = "nlm checks IDs, titles, URLs and files before sending anything, and this one is malformed. Nothing was changed."
+	}
+	var ue *UsageError
+	if errors.As(err, &ue) {
+		info.Suggestion = "correct the command line; `nlm help <command>` shows its usage"
+		info.Explanation = "nlm could not use the command line: a flag value is malformed, or an argument is missing or extra. Nothing was sent to NotebookLM."
+	}
+	return info
+}
+
+func classify(err error) string {
+	var (
+		ro     *api.ReadOnlyError
+		ue     *api.UnavailableError
+		be     *batchexecute.BatchExecuteError
+		re     *batchexecute.RPCError
+		oe     *net.OpError
+		de     *net.DNSError
+		urlErr *url.Error
+		pe     *fs.PathError
+		ve     *validate.Error
+		use    *UsageError
+	)
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, context.Canceled):
+		return CodeCanceled
+	case errors.Is(err, ErrDeclined):
+		return CodeDeclined
+	case errors.As(err, &ve), errors.As(err, &use):
+		return CodeInvalidArgument
+	case errors.As(err, &ro):
+		return CodeReadOnly
+	case errors.Is(err, batchexecute.ErrReadOnlyMode):
+		return CodeReadOnlyMode
+	case errors.As(err, &ue):
+		return CodeFeatureUnavailable
+	case errors.Is(err, state.ErrLocked):
+		return CodeStateLocked
+	case errors.Is(err, quota.ErrExceeded):
+		return CodeBudgetExceeded
+	case errors.Is(err, batchexecute.ErrInterstitial):
+		return CodeAccountAction
+	case errors.As(err, &re):
+		switch re.Code {
+		case batchexecute.CodeUnauthenticated:
+			return CodeUnauthenticated
+		case batchexecute.CodePermissionDenied, batchexecute.CodeUnimplemented:
+			return CodeFeatureUnavailable
+		case batchexecute.CodeInvalidArgument:
+			return CodeInvalidArgument
+		case batchexecute.CodeNotFound:
+			return CodeNotFound
+		case batchexecute.CodeResourceExhausted:
+			return CodeQuotaExceeded
+		case batchexecute.CodeFailedPrecondition:
+			return CodeFailedPrecondition
+		case batchexecute.CodeUnavailable:
+			return CodeServerError
+		}
+	case errors.As(err, &be):
+		switch {
+		case be.StatusCode == 400:
+			return CodeStaleAuth
+		case be.StatusCode == 401 || be.StatusCode == 403:
+			return CodeUnauthenticated
+		case be.StatusCode == 404:
+			return CodeNotFound
+		case be.StatusCode == 429:
+			return CodeRateLimited
+		case be.StatusCode >= 500:
+			return CodeServerError
+		}
+	case errors.Is(err, batchexecute.ErrUnauthorized):
+		return CodeUnauthenticated
+	case errors.Is(err, batchexecute.ErrUnavailable):
+		return CodeFeatureUnavailable
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &oe), errors.As(err, &de), errors.As(err, &urlErr):
+		return CodeNetwork
+	case errors.As(err, &pe):
+		return CodeFile
+	}
+	return CodeUnknown
+}
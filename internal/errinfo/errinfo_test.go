@@ -0,0 +1,150 @@
+package errinfo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/batchexecute"
+	"github.com/tmc/nlm/internal/quota"
+	"github.com/tmc/nlm/internal/state"
+	"github.com/tmc/nlm/internal/validate"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Info
+	}{
+		{
+			name: "stale at token",
+			err:  fmt.Errorf("list projects: execute rpc: %w", &batchexecute.BatchExecuteError{RPCIDs: "wXbhsf", StatusCode: 400, Message: "request failed: 400 Bad Request"}),
+			want: Info{Code: CodeStaleAuth, RPCID: "wXbhsf", HTTPStatus: 400, Suggestion: "run `nlm auth` again"},
+		},
+		{
+			name: "expired cookies",
+			err:  &batchexecute.BatchExecuteError{RPCIDs: "wXbhsf", StatusCode: 401},
+			want: Info{Code: CodeUnauthenticated, RPCID: "wXbhsf", HTTPStatus: 401, Suggestion: "run `nlm auth` again"},
+		},
+		{
+			name: "rpc not found",
+			err:  fmt.Errorf("get project: %w", &batchexecute.RPCError{ID: "rLM1Ne", Code: batchexecute.CodeNotFound}),
+			want: Info{Code: CodeNotFound, RPCID: "rLM1Ne", RPCCode: 5, Suggestion: "check the ID with `nlm list`, `nlm sources` or `nlm notes`"},
+		},
+		{
+			name: "rpc permission denied",
+			err:  &batchexecute.RPCError{ID: "VUsiyb", Code: batchexecute.CodePermissionDenied},
+			want: Info{Code: CodeFeatureUnavailable, RPCID: "VUsiyb", RPCCode: 7, Suggestion: "run `nlm features` to see what your account has"},
+		},
+		{
+			name: "invalid argument before sending",
+			err:  fmt.Errorf("rm: %w", &validate.Error{Kind: "notebook ID", Arg: "My Notebook", Reason: "want a lowercase UUID"}),
+			want: Info{Code: CodeInvalidArgument, Suggestion: "correct the argument, or pass -no-validate if NotebookLM changed its format"},
+		},
+		{
+			name: "rate limited",
+			err:  &batchexecute.BatchExecuteError{RPCIDs: "izAoDd", StatusCode: 429},
+			want: Info{Code: CodeRateLimited, RPCID: "izAoDd", HTTPStatus: 429, Suggestion: "wait a minute and retry with fewer calls at once (for batch, lower -workers)"},
+		},
+		{
+			name: "rate limited with retry-after",
+			err:  fmt.Errorf("list projects: execute rpc: %w", &batchexecute.BatchExecuteError{RPCIDs: "wXbhsf", StatusCode: 429, RetryAfter: "30"}),
+			want: Info{Code: CodeRateLimited, RPCID: "wXbhsf", HTTPStatus: 429, RetryAfter: "30s", Suggestion: "wait 30s, as the server asked, then retry"},
+		},
+		{
+			name: "read-only",
+			err:  &api.ReadOnlyError{NotebookID: "nb1", Role: api.RoleViewer},
+			want: Info{Code: CodeReadOnly, Suggestion: "ask the notebook's owner for editor access"},
+		},
+		{
+			name: "read-only mode",
+			err:  fmt.Errorf("create project: execute rpc: %w", fmt.Errorf("rpc CCqFvf: %w", batchexecute.ErrReadOnlyMode)),
+			want: Info{Code: CodeReadOnlyMode, Suggestion: "rerun without -read-only, or with -read-only=false if read_only is set in the global config"},
+		},
+		{
+			name: "state locked",
+			err:  fmt.Errorf("open state: %w", state.ErrLocked),
+			want: Info{Code: CodeStateLocked, Suggestion: "set NLM_STATE_PASSPHRASE, or NLM_STATE_KEYCHAIN=1"},
+		},
+		{
+			name: "budget",
+			err:  fmt.Errorf("batch: %w", fmt.Errorf("%w: profile %q has sent 500 of 500 requests today", quota.ErrExceeded, "Default")),
+			want: Info{Code: CodeBudgetExceeded, Suggestion: "wait until tomorrow, or raise -budget"},
+		},
+		{
+			name: "consent screen",
+			err:  fmt.Errorf("list projects: execute rpc: %w", &batchexecute.InterstitialError{RPCIDs: "wXbhsf", Kind: batchexecute.InterstitialConsent, URL: "https://consent.google.com/ml"}),
+			want: Info{Code: CodeAccountAction, RPCID: "wXbhsf", URL: "https://consent.google.com/ml", Suggestion: "open the URL in the browser signed in to this account, follow the page, then retry"},
+		},
+		{
+			name: "signed out",
+			err:  &batchexecute.InterstitialError{RPCIDs: "wXbhsf", Kind: batchexecute.InterstitialSignIn, URL: "https://accounts.google.com/ServiceLogin"},
+			want: Info{Code: CodeUnauthenticated, RPCID: "wXbhsf", URL: "https://accounts.google.com/ServiceLogin", Suggestion: "run `nlm auth` again"},
+		},
+		{
+			name: "network",
+			err:  fmt.Errorf("execute request: %w", &net.OpError{Op: "dial", Err: errors.New("connection refused")}),
+			want: Info{Code: CodeNetwork, Suggestion: "check your connection and proxy settings, then retry"},
+		},
+		{
+			name: "missing file",
+			err:  fmt.Errorf("import: %w", &fs.PathError{Op: "open", Path: "x.zip", Err: syscall.ENOENT}),
+			want: Info{Code: CodeFile, Suggestion: "check the path and its permissions"},
+		},
+		{
+			name: "canceled",
+			err:  fmt.Errorf("crawl: %w", context.Canceled),
+			want: Info{Code: CodeCanceled, Suggestion: "rerun the command"},
+		},
+		{
+			name: "declined",
+			err:  fmt.Errorf("rm-source: %w", ErrDeclined),
+			want: Info{Code: CodeDeclined, Suggestion: "rerun the command and answer y to go ahead"},
+		},
+		{
+			name: "invalid flag value",
+			err:  fmt.Errorf("list: %w", Usagef("invalid -sort %q (want one of title, modified)", "size")),
+			want: Info{Code: CodeInvalidArgument, Suggestion: "correct the command line; `nlm help <command>` shows its usage"},
+		},
+		{
+			name: "usage",
+			err:  Usage(errors.New("usage: nlm trash [list]")),
+			want: Info{Code: CodeInvalidArgument, Suggestion: "correct the command line; `nlm help <command>` shows its usage"},
+		},
+		{
+			name: "unknown",
+			err:  errors.New("something else"),
+			want: Info{Code: CodeUnknown, Suggestion: "rerun with -debug; to report it, attach `nlm bugreport` to an issue"},
+		},
+	}
+	for _, tt := range tests {
+		got := Classify(tt.err)
+		if got.Explanation == "" {
+			t.Errorf("%s: no explanation", tt.name)
+		}
+		if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreFields(Info{}, "Explanation")); diff != "" {
+			t.Errorf("%s: Classify mismatch (-want +got):\n%s", tt.name, diff)
+		}
+	}
+}
+
+func TestTaxonomyComplete(t *testing.T) {
+	for _, code := range []string{
+		CodeUnauthenticated, CodeStaleAuth, CodeReadOnly, CodeReadOnlyMode, CodeFeatureUnavailable,
+		CodeInvalidArgument, CodeNotFound, CodeQuotaExceeded, CodeRateLimited,
+		CodeFailedPrecondition, CodeServerError, CodeNetwork, CodeCanceled, CodeDeclined,
+		CodeStateLocked, CodeBudgetExceeded, CodeAccountAction, CodeFile, CodeUnknown,
+	} {
+		if e := taxonomy[code]; e.suggestion == "" || e.explanation == "" {
+			t.Errorf("code %s has no guidance", code)
+		}
+	}
+}
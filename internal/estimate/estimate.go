@@ -0,0 +1,214 @@
+// Package estimate counts words and approximate model tokens in documents
+// before they are uploaded, and checks them against NotebookLM's limits.
+package estimate
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// Limits are the NotebookLM upload limits a corpus is checked against.
+type Limits struct {
+	WordsPerSource     int
+	BytesPerSource     int64
+	SourcesPerNotebook int
+}
+
+// DefaultLimits are the limits of a standard NotebookLM account.
+var DefaultLimits = Limits{
+	WordsPerSource:     500_000,
+	BytesPerSource:     200 << 20,
+	SourcesPerNotebook: 50,
+}
+
+// charsPerToken approximates tokens for English-like text; CJK text is
+// counted at one token per character.
+const charsPerToken = 4
+
+// Counts are the word and token counts of a text.
+type Counts struct {
+	Words  int
+	Tokens int
+}
+
+// Count counts words and approximate tokens in r. Runs of letters and
+// digits separated by spaces or punctuation are words; each Han, Hiragana,
+// Katakana or Hangul character counts as its own word and token, as those
+// scripts do not separate words with spaces.
+func Count(r io.Reader) (Counts, error) {
+	var c Counts
+	var chars int
+	inWord := false
+	br := bufio.NewReader(r)
+	for {
+		ch, _, err := br.ReadRune()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return c, err
+		}
+		switch {
+		case isCJK(ch):
+			c.Words++
+			c.Tokens++
+			inWord = false
+		case unicode.IsLetter(ch) || unicode.IsDigit(ch) || ch == '\'' && inWord:
+			if !inWord {
+				c.Words++
+				inWord = true
+			}
+			chars++
+		default:
+			inWord = false
+			if !unicode.IsSpace(ch) {
+				chars++
+			}
+		}
+	}
+	c.Tokens += (chars + charsPerToken - 1) / charsPerToken
+	return c, nil
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// File is the estimate for one file.
+type File struct {
+	Path  string
+	Bytes int64
+	Counts
+	// Known is false when the file's text could not be extracted, so only
+	// its size was checked.
+	Known    bool
+	Problems []string
+}
+
+// Report is the estimate for a set of files.
+type Report struct {
+	Files    []File
+	Total    Counts
+	Bytes    int64
+	Problems []string
+}
+
+// Fits reports whether the corpus fits in one notebook.
+func (r *Report) Fits() bool {
+	if len(r.Problems) > 0 {
+		return false
+	}
+	for _, f := range r.Files {
+		if len(f.Problems) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Dir estimates every regular file under root, skipping hidden files and
+// directories. root may also be a single file.
+func Dir(root string, limits Limits) (*Report, error) {
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != root && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return Files(paths, limits)
+}
+
+// Files estimates the given files.
+func Files(paths []string, limits Limits) (*Report, error) {
+	r := &Report{}
+	for _, path := range paths {
+		f, err := estimateFile(path, limits)
+		if err != nil {
+			return nil, err
+		}
+		r.Files = append(r.Files, f)
+		r.Total.Words += f.Words
+		r.Total.Tokens += f.Tokens
+		r.Bytes += f.Bytes
+	}
+	if n := len(r.Files); limits.SourcesPerNotebook > 0 && n > limits.SourcesPerNotebook {
+		r.Problems = append(r.Problems, fmt.Sprintf("%d files exceed the limit of %d sources per notebook", n, limits.SourcesPerNotebook))
+	}
+	return r, nil
+}
+
+func estimateFile(path string, limits Limits) (File, error) {
+	f := File{Path: path}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return f, err
+	}
+	f.Bytes = fi.Size()
+	if limits.BytesPerSource > 0 && f.Bytes > limits.BytesPerSource {
+		f.Problems = append(f.Problems, fmt.Sprintf("larger than %d MB", limits.BytesPerSource>>20))
+	}
+	text, ok, err := ReadText(path)
+	if err != nil {
+		return f, err
+	}
+	if ok {
+		f.Counts, err = Count(bytes.NewReader(text))
+		if err != nil {
+			return f, err
+		}
+		f.Known = true
+		if limits.WordsPerSource > 0 && f.Words > limits.WordsPerSource {
+			f.Problems = append(f.Problems, fmt.Sprintf("more than %d words", limits.WordsPerSource))
+		}
+	}
+	return f, nil
+}
+
+// ReadText returns the text of path if it is a text file, or a PDF and
+// pdftotext is installed.
+func ReadText(path string) ([]byte, bool, error) {
+	if strings.EqualFold(filepath.Ext(path), ".pdf") {
+		if _, err := exec.LookPath("pdftotext"); err != nil {
+			return nil, false, nil
+		}
+		out, err := exec.Command("pdftotext", path, "-").Output()
+		if err != nil {
+			return nil, false, nil
+		}
+		return out, true, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false, err
+	}
+	sniff := data
+	if len(sniff) > 512 {
+		sniff = sniff[:512]
+	}
+	if !strings.HasPrefix(http.DetectContentType(sniff), "text/") {
+		return nil, false, nil
+	}
+	return data, true, nil
+}
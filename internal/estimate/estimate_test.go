@@ -0,0 +1,75 @@
+package estimate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Counts
+	}{
+		{"", Counts{}},
+		{"Hello, world!", Counts{Words: 2, Tokens: 3}},
+		{"don't stop-believing 2024", Counts{Words: 4, Tokens: 6}},
+		{"naïve café", Counts{Words: 2, Tokens: 3}},
+		{"東京都", Counts{Words: 3, Tokens: 3}},
+		{"NotebookLM 是工具", Counts{Words: 4, Tokens: 6}},
+	}
+	for _, tt := range tests {
+		got, err := Count(strings.NewReader(tt.in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("Count(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDir(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.md", "one two three")
+	write("sub/b.txt", strings.Repeat("word ", 20))
+	write("image.png", "\x89PNG\r\n\x1a\n\x00\x00")
+	write(".git/config", "hidden")
+
+	limits := Limits{WordsPerSource: 10, BytesPerSource: 1 << 20, SourcesPerNotebook: 2}
+	r, err := Dir(dir, limits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Files) != 3 {
+		t.Fatalf("got %d files, want 3 (hidden files skipped)", len(r.Files))
+	}
+	byName := make(map[string]File)
+	for _, f := range r.Files {
+		rel, _ := filepath.Rel(dir, f.Path)
+		byName[filepath.ToSlash(rel)] = f
+	}
+	if f := byName["a.md"]; f.Words != 3 || !f.Known || len(f.Problems) != 0 {
+		t.Errorf("a.md = %+v", f)
+	}
+	if f := byName["sub/b.txt"]; f.Words != 20 || len(f.Problems) != 1 {
+		t.Errorf("sub/b.txt = %+v, want one word-limit problem", f)
+	}
+	if f := byName["image.png"]; f.Known {
+		t.Errorf("image.png counted as text: %+v", f)
+	}
+	if r.Total.Words != 23 {
+		t.Errorf("total words = %d, want 23", r.Total.Words)
+	}
+	if len(r.Problems) != 1 || r.Fits() {
+		t.Errorf("problems = %v, fits = %v; want source limit exceeded", r.Problems, r.Fits())
+	}
+}
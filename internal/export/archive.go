@@ -0,0 +1,204 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maxArchiveFile bounds the size of a single file read from an archive.
+const maxArchiveFile = 1 << 30
+
+// WriteZip writes the manifest and every file it records to w as a zip
+// archive, with manifest.json at the root.
+func (m *Manifest) WriteZip(w io.Writer) error {
+	zw := zip.NewWriter(w)
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode manifest: %w", err)
+	}
+	f, err := zw.Create(ManifestName)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	for _, it := range m.Items {
+		if err := addZipFile(zw, filepath.Join(m.dir, filepath.FromSlash(it.Path)), it.Path); err != nil {
+			return fmt.Errorf("archive %s: %w", it.Path, err)
+		}
+	}
+	return zw.Close()
+}
+
+func addZipFile(zw *zip.Writer, path, name string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	method := zip.Deflate
+	if strings.HasSuffix(name, ".wav") || strings.HasSuffix(name, ".mp3") {
+		method = zip.Store
+	}
+	dst, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: method})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// Archive is a notebook archive opened for reading.
+type Archive struct {
+	Manifest *Manifest
+	zr       *zip.ReadCloser
+}
+
+// OpenArchive opens a zip written by WriteZip.
+func OpenArchive(path string) (*Archive, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	a := &Archive{zr: zr}
+	data, err := a.readFile(ManifestName)
+	if err != nil {
+		zr.Close()
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		zr.Close()
+		return nil, fmt.Errorf("open archive: parse manifest: %w", err)
+	}
+	if m.Version > manifestVersion {
+		zr.Close()
+		return nil, fmt.Errorf("open archive: manifest version %d is newer than supported version %d", m.Version, manifestVersion)
+	}
+	a.Manifest = &m
+	return a, nil
+}
+
+// ReadItem returns the contents of an archived item, verifying them
+// against the checksum in the manifest.
+func (a *Archive) ReadItem(it Item) ([]byte, error) {
+	data, err := a.readFile(it.Path)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != it.SHA256 {
+		return nil, fmt.Errorf("%s: checksum mismatch", it.Path)
+	}
+	return data, nil
+}
+
+func (a *Archive) readFile(name string) ([]byte, error) {
+	f, err := a.zr.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, io.LimitReader(f, maxArchiveFile+1))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if n > maxArchiveFile {
+		return nil, fmt.Errorf("%s: larger than %d bytes", name, maxArchiveFile)
+	}
+	return buf.Bytes(), nil
+}
+
+// Close closes the archive.
+func (a *Archive) Close() error {
+	return a.zr.Close()
+}
+
+var urlRE = regexp.MustCompile(`^https?://\S+$`)
+
+// A LoadSource payload is [source, null, null, [[block...]], ...], where
+// source is the source record and every string in the blocks is a run of
+// the extracted text, in order.
+const sourceText = 3
+
+// sourceFields decodes the top-level fields of a raw source payload.
+func sourceFields(raw []byte) ([]interface{}, error) {
+	var fields []interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, fmt.Errorf("parse source: %w", err)
+	}
+	return fields, nil
+}
+
+// SourceURL returns the first web address in the source record of a raw
+// source payload, for sources that were added from a URL or YouTube video.
+func SourceURL(raw []byte) string {
+	fields, err := sourceFields(raw)
+	if err != nil || len(fields) == 0 {
+		return ""
+	}
+	var url string
+	walkStrings(fields[0], func(s string) bool {
+		if urlRE.MatchString(s) {
+			url = s
+			return false
+		}
+		return true
+	})
+	return url
+}
+
+// SourceText returns the extracted text of a raw source payload. It is an
+// error if the payload has no text at the position LoadSource puts it.
+func SourceText(raw []byte) (string, error) {
+	fields, err := sourceFields(raw)
+	if err != nil {
+		return "", err
+	}
+	var blocks []interface{}
+	if len(fields) > sourceText {
+		if v, ok := fields[sourceText].([]interface{}); ok && len(v) > 0 {
+			blocks, _ = v[0].([]interface{})
+		}
+	}
+	var runs []string
+	walkStrings(blocks, func(s string) bool {
+		runs = append(runs, s)
+		return true
+	})
+	if len(runs) == 0 {
+		return "", fmt.Errorf("source has no extracted text")
+	}
+	return strings.Join(runs, "\n"), nil
+}
+
+// walkStrings calls fn for every string in the decoded JSON value v until
+// fn returns false.
+func walkStrings(v interface{}, fn func(string) bool) {
+	var walk func(v interface{}) bool
+	walk = func(v interface{}) bool {
+		switch v := v.(type) {
+		case string:
+			return fn(v)
+		case []interface{}:
+			for _, e := range v {
+				if !walk(e) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	walk(v)
+}
@@ -0,0 +1,93 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	m, err := Open(filepath.Join(dir, "nb"), "nb1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Title = "Notebook"
+	files := map[string]string{
+		"sources/a.json": `[["s1"],"A"]`,
+		"notes/b.json":   `[["n1"],"B"]`,
+	}
+	if _, err := m.Write(KindSource, "s1", "A", "sources/a.json", []byte(files["sources/a.json"])); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Write(KindNote, "n1", "B", "notes/b.json", []byte(files["notes/b.json"])); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	zipPath := filepath.Join(dir, "nb.zip")
+	f, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.WriteZip(f); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	a, err := OpenArchive(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	if a.Manifest.NotebookID != "nb1" || a.Manifest.Title != "Notebook" || !a.Manifest.Complete {
+		t.Errorf("manifest = %+v", a.Manifest)
+	}
+	if len(a.Manifest.Items) != 2 {
+		t.Fatalf("got %d items, want 2", len(a.Manifest.Items))
+	}
+	for _, it := range a.Manifest.Items {
+		data, err := a.ReadItem(it)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != files[it.Path] {
+			t.Errorf("%s = %s, want %s", it.Path, data, files[it.Path])
+		}
+	}
+
+	tampered := a.Manifest.Items[0]
+	tampered.SHA256 = "00"
+	if _, err := a.ReadItem(tampered); err == nil {
+		t.Error("ReadItem with wrong checksum: want error")
+	}
+}
+
+func TestPayloadExtraction(t *testing.T) {
+	raw := []byte(`[[["fec1780c-5a14-4f07-8ee6-f8c3ee2930fa"],"Title",[null,"https://example.com/page"]],null,null,` +
+		`[[[0,12,[[[0,12,["First line."]]]]],[12,30,[[[12,30,["Second line."]]]]]]]]`)
+	if got, want := SourceURL(raw), "https://example.com/page"; got != want {
+		t.Errorf("SourceURL = %q, want %q", got, want)
+	}
+	got, err := SourceText(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "First line.\nSecond line."; got != want {
+		t.Errorf("SourceText = %q, want %q", got, want)
+	}
+
+	// A web address in the text is not the source's address.
+	raw = []byte(`[[["fec1780c-5a14-4f07-8ee6-f8c3ee2930fa"],"Title",[null]],null,null,[[["https://example.com/in-text"]]]]`)
+	if got := SourceURL(raw); got != "" {
+		t.Errorf("SourceURL = %q, want empty", got)
+	}
+	if _, err := SourceText([]byte(`[[["fec1780c-5a14-4f07-8ee6-f8c3ee2930fa"],"Title",[null,"The longest string, but not the text"]]]`)); err == nil {
+		t.Error("SourceText without text: want error")
+	}
+	if got := SourceURL([]byte(`["no url here"]`)); got != "" {
+		t.Errorf("SourceURL = %q, want empty", got)
+	}
+}
@@ -0,0 +1,277 @@
+// Package export writes notebook content to a directory tree and records
+// progress in a manifest, so an interrupted export can resume where it left
+// off instead of downloading every item again.
+package export
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ManifestName is the file name of the manifest inside an export directory.
+const ManifestName = "manifest.json"
+
+// manifestVersion is bumped when the manifest layout changes incompatibly.
+const manifestVersion = 1
+
+// Item kinds recorded in a manifest.
+const (
+	KindNotebook = "notebook"
+	KindSource   = "source"
+	KindNote     = "note"
+	KindAudio    = "audio"
+	KindGuide    = "guide"
+)
+
+// Item is one exported file.
+type Item struct {
+	Kind        string    `json:"kind"`
+	ID          string    `json:"id"`
+	Title       string    `json:"title,omitempty"`
+	Path        string    `json:"path"` // relative to the export directory
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// Manifest tracks the items written to an export directory.
+type Manifest struct {
+	Version    int       `json:"version"`
+	NotebookID string    `json:"notebook_id"`
+	Title      string    `json:"title,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Complete   bool      `json:"complete"`
+	Items      []Item    `json:"items"`
+
+	dir string
+}
+
+// Open loads the manifest in dir, creating dir and a new manifest if none
+// exists. It is an error to resume an export of a different notebook into
+// the same directory.
+func Open(dir, notebookID string) (*Manifest, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create export dir: %w", err)
+	}
+	m := &Manifest{
+		Version:    manifestVersion,
+		NotebookID: notebookID,
+		StartedAt:  time.Now().UTC(),
+		dir:        dir,
+	}
+	data, err := os.ReadFile(filepath.Join(dir, ManifestName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	if m.NotebookID != notebookID {
+		return nil, fmt.Errorf("%s belongs to notebook %s, not %s", filepath.Join(dir, ManifestName), m.NotebookID, notebookID)
+	}
+	if m.Version > manifestVersion {
+		return nil, fmt.Errorf("manifest version %d is newer than supported version %d", m.Version, manifestVersion)
+	}
+	m.dir = dir
+	return m, nil
+}
+
+// Dir returns the export directory.
+func (m *Manifest) Dir() string { return m.dir }
+
+// Lookup returns the recorded item for kind and id.
+func (m *Manifest) Lookup(kind, id string) (Item, bool) {
+	for _, it := range m.Items {
+		if it.Kind == kind && it.ID == id {
+			return it, true
+		}
+	}
+	return Item{}, false
+}
+
+// Done reports whether the item was exported and its file is still intact.
+func (m *Manifest) Done(kind, id string) bool {
+	it, ok := m.Lookup(kind, id)
+	if !ok {
+		return false
+	}
+	fi, err := os.Stat(filepath.Join(m.dir, it.Path))
+	return err == nil && fi.Size() == it.Size
+}
+
+// Begin starts an export into m. An unfinished export is resumed; after a
+// finished one, the recorded items are kept but are no longer Current, so
+// they are fetched again and rewritten only if they changed.
+func (m *Manifest) Begin() {
+	if m.Complete {
+		m.StartedAt = time.Now().UTC()
+		m.Complete = false
+	}
+}
+
+// Current reports whether the item was exported since the export began
+// and its file is still intact, so a resumed export can skip it.
+func (m *Manifest) Current(kind, id string) bool {
+	it, ok := m.Lookup(kind, id)
+	return ok && !it.CompletedAt.Before(m.StartedAt) && m.Done(kind, id)
+}
+
+// Unchanged reports whether the item is done and its recorded contents
+// equal data, so rewriting it can be skipped.
+func (m *Manifest) Unchanged(kind, id string, data []byte) bool {
+	it, ok := m.Lookup(kind, id)
+	if !ok || !m.Done(kind, id) {
+		return false
+	}
+	sum := sha256.Sum256(data)
+	return it.SHA256 == hex.EncodeToString(sum[:])
+}
+
+// Prune removes the items for which keep returns false, deleting their
+// files, and saves the manifest. It returns the number of items removed.
+func (m *Manifest) Prune(keep func(Item) bool) (int, error) {
+	var kept []Item
+	var removed int
+	for _, it := range m.Items {
+		if keep(it) {
+			kept = append(kept, it)
+			continue
+		}
+		if err := os.Remove(filepath.Join(m.dir, filepath.FromSlash(it.Path))); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return removed, fmt.Errorf("prune %s: %w", it.Path, err)
+		}
+		removed++
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	m.Items = kept
+	return removed, m.Save()
+}
+
+// Write stores data at the relative path rel, records it in the manifest and
+// saves the manifest. If rel is already used by a different item, a numeric
+// suffix is added. It returns the path actually written, relative to Dir.
+func (m *Manifest) Write(kind, id, title, rel string, data []byte) (string, error) {
+	return m.WriteFrom(kind, id, title, rel, bytes.NewReader(data))
+}
+
+// WriteFrom is like Write but copies the content from r, so large items
+// such as audio need not be held in memory.
+func (m *Manifest) WriteFrom(kind, id, title, rel string, r io.Reader) (string, error) {
+	if prev, ok := m.Lookup(kind, id); ok {
+		rel = prev.Path
+	} else {
+		rel = m.uniquePath(rel)
+	}
+	h := sha256.New()
+	n, err := writeAtomic(filepath.Join(m.dir, rel), io.TeeReader(r, h))
+	if err != nil {
+		return "", err
+	}
+	m.record(Item{
+		Kind:        kind,
+		ID:          id,
+		Title:       title,
+		Path:        filepath.ToSlash(rel),
+		Size:        n,
+		SHA256:      hex.EncodeToString(h.Sum(nil)),
+		CompletedAt: time.Now().UTC(),
+	})
+	return rel, m.Save()
+}
+
+func (m *Manifest) record(item Item) {
+	for i, it := range m.Items {
+		if it.Kind == item.Kind && it.ID == item.ID {
+			m.Items[i] = item
+			return
+		}
+	}
+	m.Items = append(m.Items, item)
+}
+
+// uniquePath returns rel, or rel with " (n)" inserted before the extension
+// when another item already uses it.
+func (m *Manifest) uniquePath(rel string) string {
+	used := make(map[string]bool, len(m.Items))
+	for _, it := range m.Items {
+		used[filepath.FromSlash(it.Path)] = true
+	}
+	if !used[rel] {
+		return rel
+	}
+	ext := filepath.Ext(rel)
+	base := strings.TrimSuffix(rel, ext)
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, n, ext)
+		if !used[candidate] {
+			return candidate
+		}
+	}
+}
+
+// Finish marks the export complete and saves the manifest.
+func (m *Manifest) Finish() error {
+	m.Complete = true
+	return m.Save()
+}
+
+// Save writes the manifest to the export directory.
+func (m *Manifest) Save() error {
+	m.UpdatedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode manifest: %w", err)
+	}
+	return WriteFileAtomic(filepath.Join(m.dir, ManifestName), append(data, '\n'))
+}
+
+// WriteFileAtomic writes data to path via a temporary file in the same
+// directory, so readers never observe a partially written file.
+func WriteFileAtomic(path string, data []byte) error {
+	_, err := writeAtomic(path, bytes.NewReader(data))
+	return err
+}
+
+// writeAtomic is WriteFileAtomic reading from r. It returns the number of
+// bytes written.
+func writeAtomic(path string, r io.Reader) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return 0, fmt.Errorf("create dir: %w", err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-"+filepath.Base(path)+"-*")
+	if err != nil {
+		return 0, fmt.Errorf("create temp file: %w", err)
+	}
+	tmp := f.Name()
+	n, err := io.Copy(f, r)
+	if err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return 0, fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return 0, fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return 0, fmt.Errorf("rename %s: %w", path, err)
+	}
+	return n, nil
+}
@@ -0,0 +1,85 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestManifestResume(t *testing.T) {
+	dir := t.TempDir()
+	m, err := Open(dir, "nb1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Write(KindSource, "s1", "Intro", "sources/Intro.json", []byte(`["s1"]`)); err != nil {
+		t.Fatal(err)
+	}
+	// A different source with the same title must not overwrite the first.
+	rel, err := m.Write(KindSource, "s2", "Intro", "sources/Intro.json", []byte(`["s2"]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join("sources", "Intro (2).json"); rel != want {
+		t.Errorf("second path = %q, want %q", rel, want)
+	}
+
+	// Reopen as a resumed export.
+	m, err = Open(dir, "nb1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Done(KindSource, "s1") || !m.Done(KindSource, "s2") {
+		t.Error("resumed manifest lost completed items")
+	}
+	if m.Done(KindNote, "n1") {
+		t.Error("Done reported an item that was never written")
+	}
+
+	// A truncated file is re-exported.
+	if err := os.WriteFile(filepath.Join(dir, "sources", "Intro.json"), []byte("["), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if m.Done(KindSource, "s1") {
+		t.Error("Done reported a truncated file as complete")
+	}
+
+	if _, err := Open(dir, "nb2"); err == nil {
+		t.Error("Open with a different notebook succeeded, want error")
+	}
+}
+
+func TestManifestBegin(t *testing.T) {
+	dir := t.TempDir()
+	m, err := Open(dir, "nb1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Begin()
+	if _, err := m.Write(KindSource, "s1", "Intro", "sources/Intro.json", []byte(`["s1"]`)); err != nil {
+		t.Fatal(err)
+	}
+	if !m.Current(KindSource, "s1") {
+		t.Error("item written by this export is not current")
+	}
+
+	// An unfinished export is resumed.
+	m, _ = Open(dir, "nb1")
+	m.Begin()
+	if !m.Current(KindSource, "s1") {
+		t.Error("resumed export: item is not current")
+	}
+
+	// After a finished export, items are kept but checked again.
+	if err := m.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	m, _ = Open(dir, "nb1")
+	m.Begin()
+	if m.Current(KindSource, "s1") {
+		t.Error("new export: item from the finished export is current")
+	}
+	if !m.Unchanged(KindSource, "s1", []byte(`["s1"]`)) {
+		t.Error("new export: item from the finished export is not unchanged")
+	}
+}
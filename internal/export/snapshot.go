@@ -0,0 +1,131 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// snapshotLayout names backup snapshot directories. Nanoseconds keep two
+// snapshots taken in the same second apart.
+const snapshotLayout = "20060102T150405.000000000Z"
+
+// SnapshotName returns the directory name for a snapshot taken at t.
+func SnapshotName(t time.Time) string {
+	return t.UTC().Format(snapshotLayout)
+}
+
+// ParseSnapshotName returns the time in a name made by SnapshotName.
+// Names without nanoseconds, from older versions, parse too, as
+// time.Parse accepts a fractional second that the layout does not
+// mention.
+func ParseSnapshotName(name string) (time.Time, error) {
+	return time.Parse("20060102T150405Z", name)
+}
+
+// Snapshots returns the snapshot directories in root, oldest first.
+// Entries that are not snapshot directories are ignored.
+func Snapshots(root string) ([]string, error) {
+	entries, err := os.ReadDir(root)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	taken := make(map[string]time.Time)
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if t, err := ParseSnapshotName(e.Name()); err == nil {
+			names = append(names, e.Name())
+			taken[e.Name()] = t
+		}
+	}
+	sort.Slice(names, func(i, j int) bool { return taken[names[i]].Before(taken[names[j]]) })
+	return names, nil
+}
+
+// PruneSnapshots removes all but the newest keep snapshots in root and
+// returns the names removed.
+func PruneSnapshots(root string, keep int) ([]string, error) {
+	if keep < 1 {
+		return nil, fmt.Errorf("keep must be at least 1, got %d", keep)
+	}
+	names, err := Snapshots(root)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) <= keep {
+		return nil, nil
+	}
+	old := names[:len(names)-keep]
+	for _, name := range old {
+		if err := os.RemoveAll(filepath.Join(root, name)); err != nil {
+			return nil, err
+		}
+	}
+	return old, nil
+}
+
+// LinkTree recreates the directory tree src at dst, hard linking files so
+// that unchanged content is shared between snapshots. Files are copied
+// where hard links are not supported. Because Manifest.Write replaces
+// files rather than modifying them, updating dst never changes src. It is
+// an error for dst to be src or to contain existing files.
+func LinkTree(src, dst string) error {
+	if filepath.Clean(src) == filepath.Clean(dst) {
+		return fmt.Errorf("link %s: source and destination are the same", src)
+	}
+	if si, err := os.Stat(src); err != nil {
+		return err
+	} else if di, err := os.Stat(dst); err == nil && os.SameFile(si, di) {
+		return fmt.Errorf("link %s: source and destination are the same", src)
+	}
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		err = os.Link(path, target)
+		if err == nil || errors.Is(err, fs.ErrExist) {
+			return err
+		}
+		return copyFile(path, target)
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	// Never truncate an existing file: it may be src itself.
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
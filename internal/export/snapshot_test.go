@@ -0,0 +1,127 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSnapshots(t *testing.T) {
+	root := t.TempDir()
+	start := time.Date(2024, 3, 1, 2, 0, 0, 0, time.UTC)
+	var want []string
+	for i := 0; i < 4; i++ {
+		name := SnapshotName(start.Add(time.Duration(i) * 24 * time.Hour))
+		want = append(want, name)
+		if err := os.Mkdir(filepath.Join(root, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Unrelated entries are left alone.
+	os.Mkdir(filepath.Join(root, "notes"), 0o755)
+	os.WriteFile(filepath.Join(root, "latest.json"), []byte("{}"), 0o644)
+
+	got, err := Snapshots(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Snapshots mismatch (-want +got):\n%s", diff)
+	}
+
+	removed, err := PruneSnapshots(root, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want[:2], removed); diff != "" {
+		t.Errorf("PruneSnapshots removed mismatch (-want +got):\n%s", diff)
+	}
+	got, _ = Snapshots(root)
+	if diff := cmp.Diff(want[2:], got); diff != "" {
+		t.Errorf("after prune (-want +got):\n%s", diff)
+	}
+	if _, err := os.Stat(filepath.Join(root, "notes")); err != nil {
+		t.Errorf("unrelated directory removed: %v", err)
+	}
+}
+
+func TestLinkTreeIsolation(t *testing.T) {
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	m, err := Open(src, "nb1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Write(KindNote, "n1", "Note", "notes/Note.json", []byte("v1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkTree(src, dst); err != nil {
+		t.Fatal(err)
+	}
+
+	m2, err := Open(dst, "nb1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m2.Unchanged(KindNote, "n1", []byte("v1")) {
+		t.Error("linked copy: note should be unchanged")
+	}
+	if _, err := m2.Write(KindNote, "n1", "Note", "notes/Note.json", []byte("v2")); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(src, "notes", "Note.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "v1" {
+		t.Errorf("writing the linked copy changed the original: %q", data)
+	}
+
+	n, err := m2.Prune(func(it Item) bool { return it.Kind != KindNote })
+	if err != nil || n != 1 {
+		t.Fatalf("Prune = %d, %v; want 1", n, err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "notes", "Note.json")); !os.IsNotExist(err) {
+		t.Errorf("pruned file still present: %v", err)
+	}
+}
+
+func TestLinkTreeSameDir(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes", "Note.json")
+	os.MkdirAll(filepath.Dir(path), 0o755)
+	if err := os.WriteFile(path, []byte("v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, dst := range []string{dir, dir + string(filepath.Separator) + "."} {
+		if err := LinkTree(dir, dst); err == nil {
+			t.Errorf("LinkTree(%q, %q): want error", dir, dst)
+		}
+	}
+	if data, _ := os.ReadFile(path); string(data) != "v1" {
+		t.Errorf("LinkTree onto itself changed the file: %q", data)
+	}
+}
+
+func TestSnapshotNameUnique(t *testing.T) {
+	t0 := time.Date(2024, 3, 1, 2, 0, 0, 0, time.UTC)
+	a, b := SnapshotName(t0), SnapshotName(t0.Add(time.Millisecond))
+	if a == b {
+		t.Errorf("snapshots in the same second share the name %q", a)
+	}
+	root := t.TempDir()
+	old := "20240301T015959Z" // named before nanoseconds were added
+	for _, name := range []string{b, old, a} {
+		os.Mkdir(filepath.Join(root, name), 0o755)
+	}
+	got, err := Snapshots(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{old, a, b}, got); diff != "" {
+		t.Errorf("Snapshots mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,510 @@
+// Package fake imitates the NotebookLM batchexecute API, so that nlm, and
+// the scripts and programs that run it, can be tested without an account
+// or network access.
+//
+// A Server is an http.RoundTripper that answers requests in process. It
+// keeps notebooks, their sources and audio overviews in a JSON file, so
+// that a notebook created by one nlm process is listed by the next, and
+// answers according to a Scenario. IDs are numbered in order of creation,
+// so a scripted run produces the same IDs every time.
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tmc/nlm/internal/batchexecute"
+	"github.com/tmc/nlm/internal/rpc"
+)
+
+// A Scenario is how the fake API behaves.
+type Scenario string
+
+const (
+	// Success answers every call, and audio overviews are ready when first
+	// fetched.
+	Success Scenario = "success"
+	// Fail answers every call with an HTTP 500 error.
+	Fail Scenario = "fail"
+	// Slow answers every call after Delay, and audio overviews are ready
+	// on the fourth fetch.
+	Slow Scenario = "slow"
+	// Partial answers reads and creates notebooks, but fails the first
+	// attempt to add each source and refuses audio overviews with the
+	// daily limit error.
+	Partial Scenario = "partial"
+)
+
+// Scenarios lists the scenarios in the order they are documented.
+var Scenarios = []Scenario{Success, Fail, Slow, Partial}
+
+// ParseScenario returns the scenario named s.
+func ParseScenario(s string) (Scenario, error) {
+	for _, sc := range Scenarios {
+		if string(sc) == s {
+			return sc, nil
+		}
+	}
+	names := make([]string, len(Scenarios))
+	for i, sc := range Scenarios {
+		names[i] = string(sc)
+	}
+	return "", fmt.Errorf("unknown scenario %q (want %s)", s, strings.Join(names, ", "))
+}
+
+// DefaultDelay is how long each call takes in the slow scenario when
+// Server.Delay is zero.
+const DefaultDelay = 2 * time.Second
+
+// slowPolls is the number of fetches an audio overview is not ready for
+// in the slow scenario.
+const slowPolls = 3
+
+// A Server answers NotebookLM requests from the state in a file.
+type Server struct {
+	Scenario Scenario
+	// Path is the JSON file the state is kept in. With no path, the state
+	// lasts as long as the Server.
+	Path string
+	// Delay is how long each call takes in the slow scenario.
+	Delay time.Duration
+
+	mu    sync.Mutex
+	state *State
+}
+
+// State is what the fake API knows.
+type State struct {
+	// LastID is the number of the last ID given out.
+	LastID    int         `json:"last_id"`
+	Notebooks []*Notebook `json:"notebooks"`
+	// Attempts counts the calls to add each source, by notebook ID and
+	// title, for the partial scenario.
+	Attempts map[string]int `json:"attempts,omitempty"`
+}
+
+// A Notebook is a notebook of the fake API.
+type Notebook struct {
+	ID       string    `json:"id"`
+	Title    string    `json:"title"`
+	Emoji    string    `json:"emoji"`
+	Created  time.Time `json:"created"`
+	Modified time.Time `json:"modified"`
+	Sources  []*Source `json:"sources,omitempty"`
+	Audio    *Audio    `json:"audio,omitempty"`
+}
+
+// A Source is a source of a fake notebook.
+type Source struct {
+	ID       string    `json:"id"`
+	Title    string    `json:"title"`
+	Type     int       `json:"type"`
+	Text     string    `json:"text,omitempty"`
+	Modified time.Time `json:"modified"`
+}
+
+// Audio is the audio overview of a fake notebook.
+type Audio struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	// Polls is the number of fetches left before the audio is ready.
+	Polls int `json:"polls"`
+}
+
+// RoundTrip answers a batchexecute request.
+func (s *Server) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if s.Scenario == Slow {
+		d := s.Delay
+		if d == 0 {
+			d = DefaultDelay
+		}
+		select {
+		case <-time.After(d):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+	status, text := s.serve(req.URL.Query().Get("rpcids"), body)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"application/json; charset=utf-8"}},
+		Body:          io.NopCloser(strings.NewReader(text)),
+		ContentLength: int64(len(text)),
+		Request:       req,
+	}, nil
+}
+
+// serve returns the HTTP status and body answering a call of the RPC id
+// with the form body.
+func (s *Server) serve(id string, body []byte) (int, string) {
+	if s.Scenario == Fail {
+		return http.StatusInternalServerError, "fake: the fail scenario fails every call"
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return http.StatusBadRequest, err.Error()
+	}
+	var envelope [][][]interface{}
+	if err := json.Unmarshal([]byte(form.Get("f.req")), &envelope); err != nil || len(envelope) == 0 || len(envelope[0]) == 0 || len(envelope[0][0]) < 2 {
+		return http.StatusBadRequest, "fake: malformed f.req"
+	}
+	var args []interface{}
+	if err := json.Unmarshal([]byte(str(envelope[0][0][1])), &args); err != nil {
+		return http.StatusBadRequest, "fake: malformed arguments"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, err := s.load()
+	if err != nil {
+		return http.StatusInternalServerError, err.Error()
+	}
+	data, code, changed := s.call(st, id, args)
+	if changed {
+		if err := s.save(st); err != nil {
+			return http.StatusInternalServerError, err.Error()
+		}
+	}
+	return http.StatusOK, reply(id, data, code)
+}
+
+// reply frames a payload, or a status code when code is not zero, as a
+// batchexecute response.
+func reply(id string, data interface{}, code int) string {
+	var env []interface{}
+	if code != 0 {
+		env = []interface{}{"wrb.fr", id, nil, nil, nil, []interface{}{code}, "generic"}
+	} else {
+		payload, _ := json.Marshal(data)
+		env = []interface{}{"wrb.fr", id, string(payload), nil, nil, nil, "generic"}
+	}
+	out, _ := json.Marshal([]interface{}{env})
+	return ")]}'\n\n" + string(out)
+}
+
+// call runs the RPC id on st and returns its payload or status code, and
+// whether st changed.
+func (s *Server) call(st *State, id string, args []interface{}) (data interface{}, code int, changed bool) {
+	if err := rpc.CheckArgs(id, args); err != nil {
+		return nil, batchexecute.CodeInvalidArgument, false
+	}
+	now := time.Now().UTC()
+	switch id {
+	case rpc.RPCListRecentlyViewedProjects:
+		nbs := []interface{}{}
+		for i := len(st.Notebooks) - 1; i >= 0; i-- {
+			nbs = append(nbs, st.Notebooks[i].project())
+		}
+		return []interface{}{nbs}, 0, false
+
+	case rpc.RPCCreateProject:
+		nb := &Notebook{
+			ID:       st.newID(),
+			Title:    str(at(args, 0)),
+			Emoji:    str(at(args, 1)),
+			Created:  now,
+			Modified: now,
+		}
+		st.Notebooks = append(st.Notebooks, nb)
+		return nb.project(), 0, true
+
+	case rpc.RPCGetProject:
+		nb := st.notebook(str(at(args, 0)))
+		if nb == nil {
+			return nil, batchexecute.CodeNotFound, false
+		}
+		return nb.project(), 0, false
+
+	case rpc.RPCDeleteProjects:
+		for _, v := range list(at(args, 0)) {
+			st.deleteNotebook(str(v))
+		}
+		return []interface{}{}, 0, true
+
+	case rpc.RPCAddSources:
+		nb := st.notebook(str(at(args, 1)))
+		if nb == nil {
+			return nil, batchexecute.CodeNotFound, false
+		}
+		var srcs []*Source
+		for _, v := range list(at(args, 0)) {
+			srcs = append(srcs, newSource(v, now))
+		}
+		if s.Scenario == Partial {
+			if st.Attempts == nil {
+				st.Attempts = make(map[string]int)
+			}
+			first := false
+			for _, src := range srcs {
+				key := nb.ID + " " + src.Title
+				st.Attempts[key]++
+				first = first || st.Attempts[key] == 1
+			}
+			if first {
+				return nil, batchexecute.CodeUnavailable, true
+			}
+		}
+		var added []interface{}
+		for _, src := range srcs {
+			src.ID = st.newID()
+			nb.Sources = append(nb.Sources, src)
+			added = append(added, src.source())
+		}
+		nb.Modified = now
+		return []interface{}{added}, 0, true
+
+	case rpc.RPCDeleteSources:
+		for _, v := range list(at(args, 0, 0, 0)) {
+			st.deleteSource(str(v), now)
+		}
+		return []interface{}{}, 0, true
+
+	case rpc.RPCLoadSource:
+		for _, nb := range st.Notebooks {
+			for _, src := range nb.Sources {
+				if src.ID == str(at(args, 0)) {
+					return src.loaded(), 0, false
+				}
+			}
+		}
+		return nil, batchexecute.CodeNotFound, false
+
+	case rpc.RPCGetNotes:
+		if st.notebook(str(at(args, 0))) == nil {
+			return nil, batchexecute.CodeNotFound, false
+		}
+		return []interface{}{[]interface{}{}}, 0, false
+
+	case rpc.RPCCreateAudioOverview:
+		nb := st.notebook(str(at(args, 0)))
+		if nb == nil {
+			return nil, batchexecute.CodeNotFound, false
+		}
+		if s.Scenario == Partial {
+			return nil, batchexecute.CodeResourceExhausted, false
+		}
+		nb.Audio = &Audio{ID: st.newID(), Title: nb.Title}
+		if s.Scenario == Slow {
+			nb.Audio.Polls = slowPolls
+		}
+		return []interface{}{nil, nil, nb.Audio.overview(false)}, 0, true
+
+	case rpc.RPCGetAudioOverview:
+		nb := st.notebook(str(at(args, 0)))
+		if nb == nil {
+			return nil, batchexecute.CodeNotFound, false
+		}
+		if nb.Audio == nil {
+			return []interface{}{}, 0, false
+		}
+		if nb.Audio.Polls > 0 {
+			nb.Audio.Polls--
+			return []interface{}{nil, nil, nb.Audio.overview(false)}, 0, true
+		}
+		return []interface{}{nil, nil, nb.Audio.overview(true)}, 0, false
+
+	case rpc.RPCDeleteAudioOverview:
+		nb := st.notebook(str(at(args, 0)))
+		if nb == nil {
+			return nil, batchexecute.CodeNotFound, false
+		}
+		nb.Audio = nil
+		return []interface{}{}, 0, true
+
+	case rpc.RPCGenerateNotebookGuide:
+		nb := st.notebook(str(at(args, 0)))
+		if nb == nil {
+			return nil, batchexecute.CodeNotFound, false
+		}
+		return []interface{}{fmt.Sprintf("A guide to %s, from %d sources.", nb.Title, len(nb.Sources))}, 0, false
+	}
+	return nil, batchexecute.CodeUnimplemented, false
+}
+
+// newSource returns the source an AddSources argument describes: text, a
+// file, a URL or a YouTube video.
+func newSource(v interface{}, now time.Time) *Source {
+	src := &Source{Modified: now}
+	switch {
+	case str(at(v, 1, 0)) != "":
+		src.Title, src.Type = str(at(v, 1, 0)), 1 // pasted text
+		src.Text = str(at(v, 1, 1))
+	case str(at(v, 1)) != "":
+		src.Title, src.Type = str(at(v, 1)), 6 // local file
+	case str(at(v, 2, 0)) != "":
+		src.Title, src.Type = str(at(v, 2, 0)), 7 // web page
+	default:
+		src.Title, src.Type = "YouTube video "+str(at(v, 2)), 9
+	}
+	return src
+}
+
+func (st *State) newID() string {
+	st.LastID++
+	return fmt.Sprintf("00000000-0000-4000-8000-%012d", st.LastID)
+}
+
+func (st *State) notebook(id string) *Notebook {
+	for _, nb := range st.Notebooks {
+		if nb.ID == id {
+			return nb
+		}
+	}
+	return nil
+}
+
+func (st *State) deleteNotebook(id string) {
+	for i, nb := range st.Notebooks {
+		if nb.ID == id {
+			st.Notebooks = append(st.Notebooks[:i], st.Notebooks[i+1:]...)
+			return
+		}
+	}
+}
+
+func (st *State) deleteSource(id string, now time.Time) {
+	for _, nb := range st.Notebooks {
+		for i, src := range nb.Sources {
+			if src.ID == id {
+				nb.Sources = append(nb.Sources[:i], nb.Sources[i+1:]...)
+				nb.Modified = now
+				return
+			}
+		}
+	}
+}
+
+// project returns nb in the layout of pb.Project. The user is the owner.
+func (nb *Notebook) project() []interface{} {
+	sources := []interface{}{}
+	for _, src := range nb.Sources {
+		sources = append(sources, src.source())
+	}
+	metadata := []interface{}{1, false, nil, nil, nil, timestamp(nb.Modified), nil, nil, timestamp(nb.Created)}
+	return []interface{}{nb.Title, sources, nb.ID, nb.Emoji, nil, metadata}
+}
+
+// source returns src in the layout of pb.Source. Sources are enabled.
+func (src *Source) source() []interface{} {
+	metadata := []interface{}{nil, nil, timestamp(src.Modified), nil, src.Type}
+	return []interface{}{[]interface{}{src.ID}, src.Title, metadata, []interface{}{nil, 1}}
+}
+
+// loaded returns src in the layout of a LoadSource response, which adds
+// the extracted text to the source record.
+func (src *Source) loaded() []interface{} {
+	text := []interface{}{[]interface{}{[]interface{}{src.Text}}}
+	return []interface{}{src.source(), nil, nil, text}
+}
+
+// overview returns a in the layout of the third element of the audio
+// overview responses.
+func (a *Audio) overview(ready bool) []interface{} {
+	var data interface{}
+	if ready {
+		data = silence
+	}
+	return []interface{}{3, data, a.ID, a.Title, nil, ready}
+}
+
+func timestamp(t time.Time) []interface{} {
+	return []interface{}{t.Unix(), t.Nanosecond()}
+}
+
+// load returns the state, reading it from Path the first time and on
+// every call after, as other processes may have changed it.
+func (s *Server) load() (*State, error) {
+	if s.Path == "" {
+		if s.state == nil {
+			s.state = &State{}
+		}
+		return s.state, nil
+	}
+	data, err := os.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return &State{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fake: %w", err)
+	}
+	st := &State{}
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, fmt.Errorf("fake: %s: %w", s.Path, err)
+	}
+	return st, nil
+}
+
+// save writes st to Path, replacing the file whole so that concurrent
+// readers never see a partial state.
+func (s *Server) save(st *State) error {
+	if s.Path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("fake: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o700); err != nil {
+		return fmt.Errorf("fake: %w", err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(s.Path), ".fake-*")
+	if err != nil {
+		return fmt.Errorf("fake: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("fake: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("fake: %w", err)
+	}
+	if err := os.Rename(f.Name(), s.Path); err != nil {
+		return fmt.Errorf("fake: %w", err)
+	}
+	return nil
+}
+
+// at returns the element of v at path, walking nested arrays, or nil if
+// there is none.
+func at(v interface{}, path ...int) interface{} {
+	for _, i := range path {
+		arr, ok := v.([]interface{})
+		if !ok || i >= len(arr) {
+			return nil
+		}
+		v = arr[i]
+	}
+	return v
+}
+
+func list(v interface{}) []interface{} {
+	arr, _ := v.([]interface{})
+	return arr
+}
+
+func str(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
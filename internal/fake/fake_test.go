@@ -0,0 +1,182 @@
+package fake
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tmc/nlm/internal/api"
+	"github.com/tmc/nlm/internal/batchexecute"
+)
+
+func newClient(s *Server) *api.Client {
+	return api.New("token", "cookies", batchexecute.WithHTTPClient(&http.Client{Transport: s}))
+}
+
+func TestSuccess(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fake.json")
+	c := newClient(&Server{Scenario: Success, Path: path})
+	nb, err := c.CreateProject("Moments", "📙")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "00000000-0000-4000-8000-000000000001"; nb.GetProjectId() != want {
+		t.Errorf("created notebook ID = %s, want %s", nb.GetProjectId(), want)
+	}
+	if _, err := c.AddSourceFromText(nb.GetProjectId(), "some text", "notes.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A second server on the same file sees the changes, as a second nlm
+	// process would.
+	c = newClient(&Server{Scenario: Success, Path: path})
+	nbs, err := c.ListRecentlyViewedProjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nbs) != 1 || nbs[0].GetTitle() != "Moments" || nbs[0].GetMetadata().GetUserRole() != 1 {
+		t.Fatalf("listed %v, want the created notebook", nbs)
+	}
+	p, err := c.GetProject(nb.GetProjectId())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Sources) != 1 || p.Sources[0].GetTitle() != "notes.txt" {
+		t.Fatalf("sources = %v, want notes.txt", p.Sources)
+	}
+
+	if _, err := c.CreateAudioOverview(nb.GetProjectId(), "overview"); err != nil {
+		t.Fatal(err)
+	}
+	audio, err := c.GetAudioOverview(nb.GetProjectId())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := api.AudioBytes(audio)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !audio.GetIsReady() || !strings.HasPrefix(string(data), "RIFF") {
+		t.Errorf("audio ready = %v, data %.4q; want ready WAV audio", audio.GetIsReady(), data)
+	}
+
+	if err := c.DeleteProjects([]string{nb.GetProjectId()}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetProject(nb.GetProjectId()); err == nil {
+		t.Error("GetProject of a deleted notebook succeeded")
+	}
+}
+
+// TestSharedClient runs calls at once on one client, as the workers of
+// nlm batch do; run it with -race.
+func TestSharedClient(t *testing.T) {
+	c := newClient(&Server{Scenario: Success, Path: filepath.Join(t.TempDir(), "fake.json")})
+	c.WatchShapes(api.Shapes{}, true)
+	c.OnChange(func(string) {})
+	nb, err := c.CreateProject("Shared", "📙")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := c.AddSourceFromText(nb.GetProjectId(), "text", fmt.Sprintf("s%d.txt", i)); err != nil {
+				errs <- err
+				return
+			}
+			if _, err := c.GetProject(nb.GetProjectId()); err != nil {
+				errs <- err
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	p, err := c.GetProject(nb.GetProjectId())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Sources) != 8 {
+		t.Errorf("got %d sources, want 8", len(p.Sources))
+	}
+	if u := c.Usage(); len(u.Calls) == 0 {
+		t.Error("no calls counted")
+	}
+}
+
+func TestFail(t *testing.T) {
+	c := newClient(&Server{Scenario: Fail})
+	_, err := c.ListRecentlyViewedProjects()
+	var be *batchexecute.BatchExecuteError
+	if !errors.As(err, &be) || be.StatusCode != http.StatusInternalServerError {
+		t.Errorf("error = %v, want an HTTP 500 error", err)
+	}
+}
+
+func TestSlow(t *testing.T) {
+	c := newClient(&Server{Scenario: Slow, Delay: time.Millisecond})
+	nb, err := c.CreateProject("slow", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.CreateAudioOverview(nb.GetProjectId(), "overview"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i <= slowPolls; i++ {
+		audio, err := c.GetAudioOverview(nb.GetProjectId())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ready := i == slowPolls; audio.GetIsReady() != ready {
+			t.Fatalf("fetch %d: ready = %v, want %v", i+1, audio.GetIsReady(), ready)
+		}
+	}
+}
+
+func TestPartial(t *testing.T) {
+	c := newClient(&Server{Scenario: Partial})
+	nb, err := c.CreateProject("partial", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.AddSourceFromText(nb.GetProjectId(), "text", "a.txt")
+	var re *batchexecute.RPCError
+	if !errors.As(err, &re) || re.Code != batchexecute.CodeUnavailable {
+		t.Fatalf("first add: error = %v, want service unavailable", err)
+	}
+	if _, err := c.AddSourceFromText(nb.GetProjectId(), "text", "a.txt"); err != nil {
+		t.Fatalf("second add: %v", err)
+	}
+	_, err = c.CreateAudioOverview(nb.GetProjectId(), "overview")
+	if !errors.As(err, &re) || re.Code != batchexecute.CodeResourceExhausted {
+		t.Errorf("audio: error = %v, want resource exhausted", err)
+	}
+}
+
+func TestUnimplemented(t *testing.T) {
+	c := newClient(&Server{Scenario: Success})
+	_, err := c.GenerateOutline("00000000-0000-4000-8000-000000000001")
+	if !errors.Is(err, batchexecute.ErrUnavailable) {
+		t.Errorf("error = %v, want an unavailable error", err)
+	}
+}
+
+func TestParseScenario(t *testing.T) {
+	if s, err := ParseScenario("slow"); s != Slow || err != nil {
+		t.Errorf(`ParseScenario("slow") = %q, %v`, s, err)
+	}
+	if _, err := ParseScenario("flaky"); err == nil || !strings.Contains(err.Error(), "success, fail, slow, partial") {
+		t.Errorf(`ParseScenario("flaky") error = %v, want the list of scenarios`, err)
+	}
+}
@@ -0,0 +1,26 @@
+package fake
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+)
+
+// silence is the base64 of the audio of every fake audio overview: a
+// tenth of a second of silence as 8 kHz, 8-bit mono WAV.
+var silence = func() string {
+	const rate, samples = 8000, 800
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(36+samples))
+	b.WriteString("WAVEfmt ")
+	binary.Write(&b, binary.LittleEndian, []uint32{16})
+	binary.Write(&b, binary.LittleEndian, []uint16{1, 1}) // PCM, mono
+	binary.Write(&b, binary.LittleEndian, []uint32{rate, rate})
+	binary.Write(&b, binary.LittleEndian, []uint16{1, 8}) // block align, bits
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, uint32(samples))
+	// 8-bit samples are unsigned, so silence is 128.
+	b.Write(bytes.Repeat([]byte{128}, samples))
+	return base64.StdEncoding.EncodeToString(b.Bytes())
+}()
@@ -0,0 +1,114 @@
+// Package fanout runs an operation on many notebooks at once, as commands
+// such as backup -all do. At most a fixed number run together, and each
+// notebook's failure is its own: the others carry on, unless the run is
+// told to stop at the first failure.
+package fanout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrSkipped is the error of items that were not started because the run
+// stopped early, after a failure with FailFast or on cancellation.
+var ErrSkipped = errors.New("skipped")
+
+// Options control a run.
+type Options struct {
+	// Workers is how many items run at once. Less than 1 means 1.
+	Workers int
+	// FailFast skips the items not yet started once one fails. Items
+	// already running are not interrupted.
+	FailFast bool
+}
+
+// A Result is the outcome of one item.
+type Result[R any] struct {
+	Value R
+	Err   error
+}
+
+// Skipped reports whether the item was never started.
+func (r Result[R]) Skipped() bool { return errors.Is(r.Err, ErrSkipped) }
+
+// Run calls fn for each item, with up to opts.Workers calls at once, and
+// returns their results in the order of items. Run returns once every
+// started call has returned. The context passed to fn is cancelled when
+// ctx is, or after a failure with FailFast.
+func Run[T, R any](ctx context.Context, items []T, opts Options, fn func(ctx context.Context, item T) (R, error)) []Result[R] {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	results := make([]Result[R], len(items))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, len(items)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if ctx.Err() != nil {
+					results[i].Err = ErrSkipped
+					continue
+				}
+				v, err := fn(ctx, items[i])
+				results[i] = Result[R]{Value: v, Err: err}
+				if err != nil && opts.FailFast {
+					cancel()
+				}
+			}
+		}()
+	}
+	for i := range items {
+		if ctx.Err() != nil {
+			results[i].Err = ErrSkipped
+			continue
+		}
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			results[i].Err = ErrSkipped
+		}
+	}
+	close(next)
+	wg.Wait()
+	return results
+}
+
+// A Report counts the outcomes of a run.
+type Report struct {
+	OK, Failed, Skipped int
+}
+
+// Summarize counts results.
+func Summarize[R any](results []Result[R]) Report {
+	var rep Report
+	for _, r := range results {
+		switch {
+		case r.Err == nil:
+			rep.OK++
+		case r.Skipped():
+			rep.Skipped++
+		default:
+			rep.Failed++
+		}
+	}
+	return rep
+}
+
+// Total is the number of items of the run.
+func (rep Report) Total() int { return rep.OK + rep.Failed + rep.Skipped }
+
+// String describes rep, as in "8 ok, 1 failed, 1 skipped".
+func (rep Report) String() string {
+	s := fmt.Sprintf("%d ok, %d failed", rep.OK, rep.Failed)
+	if rep.Skipped > 0 {
+		s += fmt.Sprintf(", %d skipped", rep.Skipped)
+	}
+	return s
+}
@@ -0,0 +1,91 @@
+package fanout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5, 6, 7, 8}
+	var running, most atomic.Int32
+	results := Run(context.Background(), items, Options{Workers: 3}, func(ctx context.Context, n int) (string, error) {
+		r := running.Add(1)
+		defer running.Add(-1)
+		for {
+			m := most.Load()
+			if r <= m || most.CompareAndSwap(m, r) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		if n%3 == 0 {
+			return "", fmt.Errorf("item %d failed", n)
+		}
+		return fmt.Sprint(n * 10), nil
+	})
+	if m := most.Load(); m > 3 {
+		t.Errorf("%d items ran at once, want at most 3", m)
+	}
+	for i, r := range results {
+		n := items[i]
+		switch {
+		case n%3 == 0 && (r.Err == nil || r.Skipped()):
+			t.Errorf("item %d: err = %v, want its failure", n, r.Err)
+		case n%3 != 0 && (r.Err != nil || r.Value != fmt.Sprint(n*10)):
+			t.Errorf("item %d = %q, %v; want %d0", n, r.Value, r.Err, n)
+		}
+	}
+	if got, want := Summarize(results), (Report{OK: 6, Failed: 2}); got != want {
+		t.Errorf("Summarize = %+v, want %+v", got, want)
+	}
+}
+
+func TestRunFailFast(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+	var calls atomic.Int32
+	results := Run(context.Background(), items, Options{Workers: 1, FailFast: true}, func(ctx context.Context, n int) (int, error) {
+		calls.Add(1)
+		if n == 2 {
+			return 0, errors.New("boom")
+		}
+		return n, nil
+	})
+	if c := calls.Load(); c != 2 {
+		t.Errorf("fn called %d times, want 2", c)
+	}
+	rep := Summarize(results)
+	if want := (Report{OK: 1, Failed: 1, Skipped: 3}); rep != want {
+		t.Errorf("Summarize = %+v, want %+v", rep, want)
+	}
+	if got, want := rep.String(), "1 ok, 1 failed, 3 skipped"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if !results[4].Skipped() || results[4].Err != ErrSkipped {
+		t.Errorf("last item err = %v, want ErrSkipped", results[4].Err)
+	}
+}
+
+func TestRunCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	results := Run(ctx, []int{1, 2, 3}, Options{Workers: 1}, func(ctx context.Context, n int) (int, error) {
+		cancel()
+		return n, nil
+	})
+	if rep := Summarize(results); rep != (Report{OK: 1, Skipped: 2}) {
+		t.Errorf("Summarize = %+v, want 1 ok and 2 skipped", rep)
+	}
+}
+
+func TestRunEmpty(t *testing.T) {
+	results := Run(context.Background(), nil, Options{}, func(context.Context, int) (int, error) {
+		t.Error("fn called")
+		return 0, nil
+	})
+	if len(results) != 0 {
+		t.Errorf("got %d results", len(results))
+	}
+}
@@ -0,0 +1,182 @@
+// Package feed reads podcast feeds: RSS 2.0 channels whose items carry
+// audio enclosures and, optionally, Podcasting 2.0 <podcast:transcript>
+// links.
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/mail"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A Feed is a podcast.
+type Feed struct {
+	Title    string
+	Episodes []Episode // newest first
+}
+
+// An Episode is one item of a feed.
+type Episode struct {
+	GUID        string
+	Title       string
+	Published   time.Time // zero if the feed gives no parsable date
+	Link        string
+	Description string
+	AudioURL    string
+	AudioType   string
+	Transcripts []Transcript
+}
+
+// A Transcript is a link to an episode transcript.
+type Transcript struct {
+	URL      string
+	Type     string // MIME type, such as text/vtt
+	Language string
+}
+
+// ID identifies the episode across fetches of the feed: its GUID, or
+// failing that its audio or page URL.
+func (e Episode) ID() string {
+	switch {
+	case e.GUID != "":
+		return e.GUID
+	case e.AudioURL != "":
+		return e.AudioURL
+	}
+	return e.Link
+}
+
+// transcriptRank orders transcript types by how well they convert to
+// text; types not listed are not used.
+var transcriptRank = map[string]int{
+	"text/vtt":             1,
+	"application/x-subrip": 2,
+	"application/srt":      2,
+	"text/srt":             2,
+	"application/json":     3,
+	"text/plain":           4,
+	"text/html":            5,
+}
+
+// Transcript returns the episode's most readily converted transcript.
+func (e Episode) Transcript() (Transcript, bool) {
+	var best Transcript
+	found := false
+	for _, t := range e.Transcripts {
+		r, ok := transcriptRank[t.Type]
+		if ok && (!found || r < transcriptRank[best.Type]) {
+			best, found = t, true
+		}
+	}
+	return best, found
+}
+
+// rss is the subset of RSS 2.0 that podcasts use.
+type rss struct {
+	Channel struct {
+		Title string `xml:"title"`
+		Items []struct {
+			GUID        string `xml:"guid"`
+			Title       string `xml:"title"`
+			PubDate     string `xml:"pubDate"`
+			Link        string `xml:"link"`
+			Description string `xml:"description"`
+			Summary     string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd summary"`
+			Enclosure   struct {
+				URL  string `xml:"url,attr"`
+				Type string `xml:"type,attr"`
+			} `xml:"enclosure"`
+			Transcripts []struct {
+				URL      string `xml:"url,attr"`
+				Type     string `xml:"type,attr"`
+				Language string `xml:"language,attr"`
+			} `xml:"https://podcastindex.org/namespace/1.0 transcript"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+// Parse reads an RSS podcast feed.
+func Parse(r io.Reader) (*Feed, error) {
+	var doc rss
+	d := xml.NewDecoder(r)
+	d.Strict = false
+	d.CharsetReader = func(_ string, r io.Reader) (io.Reader, error) { return r, nil }
+	if err := d.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("feed: %w", err)
+	}
+	f := &Feed{Title: strings.TrimSpace(doc.Channel.Title)}
+	for _, it := range doc.Channel.Items {
+		e := Episode{
+			GUID:        strings.TrimSpace(it.GUID),
+			Title:       strings.TrimSpace(it.Title),
+			Link:        strings.TrimSpace(it.Link),
+			Description: strings.TrimSpace(it.Description),
+			AudioURL:    strings.TrimSpace(it.Enclosure.URL),
+			AudioType:   strings.TrimSpace(it.Enclosure.Type),
+		}
+		if e.Description == "" {
+			e.Description = strings.TrimSpace(it.Summary)
+		}
+		if t, err := mail.ParseDate(strings.TrimSpace(it.PubDate)); err == nil {
+			e.Published = t
+		}
+		for _, t := range it.Transcripts {
+			typ, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(t.Type)), ";")
+			e.Transcripts = append(e.Transcripts, Transcript{URL: strings.TrimSpace(t.URL), Type: typ, Language: t.Language})
+		}
+		if e.ID() == "" {
+			continue
+		}
+		f.Episodes = append(f.Episodes, e)
+	}
+	sort.SliceStable(f.Episodes, func(i, j int) bool {
+		return f.Episodes[i].Published.After(f.Episodes[j].Published)
+	})
+	if f.Title == "" && len(f.Episodes) == 0 {
+		return nil, fmt.Errorf("feed: not an RSS feed")
+	}
+	return f, nil
+}
+
+// JSONTranscript renders a Podcasting 2.0 JSON transcript as text, one
+// paragraph per change of speaker.
+func JSONTranscript(r io.Reader) (string, error) {
+	var doc struct {
+		Segments []struct {
+			Speaker string `json:"speaker"`
+			Body    string `json:"body"`
+		} `json:"segments"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return "", fmt.Errorf("transcript: %w", err)
+	}
+	var b strings.Builder
+	speaker := "\x00"
+	for _, s := range doc.Segments {
+		body := strings.TrimSpace(s.Body)
+		if body == "" {
+			continue
+		}
+		if s.Speaker != speaker {
+			if b.Len() > 0 {
+				b.WriteString("\n\n")
+			}
+			if s.Speaker != "" {
+				b.WriteString(s.Speaker + ": ")
+			}
+			speaker = s.Speaker
+		} else {
+			b.WriteString(" ")
+		}
+		b.WriteString(body)
+	}
+	if b.Len() == 0 {
+		return "", fmt.Errorf("transcript: no segments")
+	}
+	return b.String() + "\n", nil
+}
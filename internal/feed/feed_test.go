@@ -0,0 +1,94 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd" xmlns:podcast="https://podcastindex.org/namespace/1.0">
+<channel>
+  <title>Go Time</title>
+  <item>
+    <title>Episode 1</title>
+    <guid isPermaLink="false">ep-1</guid>
+    <pubDate>Mon, 02 Jan 2006 15:04:05 -0700</pubDate>
+    <itunes:summary>The first one.</itunes:summary>
+    <enclosure url="https://cdn.example.com/1.mp3" type="audio/mpeg" length="123"/>
+  </item>
+  <item>
+    <title>Episode 2</title>
+    <guid>ep-2</guid>
+    <pubDate>Tue, 10 Jan 2006 10:00:00 GMT</pubDate>
+    <description><![CDATA[<p>Second &amp; better.</p>]]></description>
+    <enclosure url="https://cdn.example.com/2.mp3" type="audio/mpeg"/>
+    <podcast:transcript url="https://example.com/2.html" type="text/html"/>
+    <podcast:transcript url="https://example.com/2.vtt" type="text/vtt; charset=utf-8" language="en"/>
+  </item>
+  <item><title>No identity</title></item>
+</channel>
+</rss>`
+
+func TestParse(t *testing.T) {
+	f, err := Parse(strings.NewReader(testFeed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Feed{
+		Title: "Go Time",
+		Episodes: []Episode{
+			{
+				GUID:        "ep-2",
+				Title:       "Episode 2",
+				Published:   time.Date(2006, 1, 10, 10, 0, 0, 0, time.UTC),
+				Description: "<p>Second &amp; better.</p>",
+				AudioURL:    "https://cdn.example.com/2.mp3",
+				AudioType:   "audio/mpeg",
+				Transcripts: []Transcript{
+					{URL: "https://example.com/2.html", Type: "text/html"},
+					{URL: "https://example.com/2.vtt", Type: "text/vtt", Language: "en"},
+				},
+			},
+			{
+				GUID:        "ep-1",
+				Title:       "Episode 1",
+				Published:   time.Date(2006, 1, 2, 15, 4, 5, 0, time.FixedZone("", -7*3600)),
+				Description: "The first one.",
+				AudioURL:    "https://cdn.example.com/1.mp3",
+				AudioType:   "audio/mpeg",
+			},
+		},
+	}
+	opt := cmp.Comparer(func(a, b time.Time) bool { return a.Equal(b) })
+	if diff := cmp.Diff(want, f, opt); diff != "" {
+		t.Errorf("Parse mismatch (-want +got):\n%s", diff)
+	}
+	if tr, ok := f.Episodes[0].Transcript(); !ok || tr.Type != "text/vtt" {
+		t.Errorf("Transcript = %+v, %v; want the VTT transcript", tr, ok)
+	}
+	if _, ok := f.Episodes[1].Transcript(); ok {
+		t.Error("Transcript found one for an episode without transcripts")
+	}
+}
+
+func TestParseNotFeed(t *testing.T) {
+	if _, err := Parse(strings.NewReader("<html><body>hi</body></html>")); err == nil {
+		t.Error("Parse accepted an HTML page")
+	}
+}
+
+func TestJSONTranscript(t *testing.T) {
+	got, err := JSONTranscript(strings.NewReader(`{"version":"1.0.0","segments":[
+		{"speaker":"Ann","startTime":0,"body":"Hello"},
+		{"speaker":"Ann","startTime":1,"body":"there."},
+		{"speaker":"Bob","startTime":2,"body":"Hi Ann."}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Ann: Hello there.\n\nBob: Hi Ann.\n"; got != want {
+		t.Errorf("JSONTranscript = %q, want %q", got, want)
+	}
+}
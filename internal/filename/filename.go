@@ -0,0 +1,83 @@
+// Package filename derives portable file names from notebook, source and
+// note titles.
+package filename
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Form selects how titles are normalized before being used as file names.
+type Form int
+
+const (
+	// NFC composes characters, matching what most editors and the NotebookLM
+	// web UI produce. It is the default.
+	NFC Form = iota
+	// NFD decomposes characters, as older macOS file systems stored names.
+	NFD
+	// NFKC additionally folds compatibility characters such as fullwidth
+	// letters and ligatures into their plain equivalents.
+	NFKC
+	// ASCII strips diacritics and drops any remaining non-ASCII runes.
+	ASCII
+)
+
+var formNames = []string{
+	NFC:   "nfc",
+	NFD:   "nfd",
+	NFKC:  "nfkc",
+	ASCII: "ascii",
+}
+
+func (f Form) String() string {
+	if int(f) < len(formNames) {
+		return formNames[f]
+	}
+	return fmt.Sprintf("Form(%d)", int(f))
+}
+
+// Set implements flag.Value.
+func (f *Form) Set(s string) error {
+	v, err := ParseForm(s)
+	if err != nil {
+		return err
+	}
+	*f = v
+	return nil
+}
+
+// ParseForm returns the Form named by s.
+func ParseForm(s string) (Form, error) {
+	for i, name := range formNames {
+		if strings.EqualFold(s, name) {
+			return Form(i), nil
+		}
+	}
+	return NFC, fmt.Errorf("unknown normalization form %q (want one of %s)", s, strings.Join(formNames, ", "))
+}
+
+// Normalize returns s in the given normalization form. Invalid UTF-8 is
+// replaced with U+FFFD before normalizing.
+func Normalize(s string, f Form) string {
+	s = strings.ToValidUTF8(s, "�")
+	switch f {
+	case NFD:
+		return norm.NFD.String(s)
+	case NFKC:
+		return norm.NFKC.String(s)
+	case ASCII:
+		var b strings.Builder
+		for _, r := range norm.NFKD.String(s) {
+			if r < unicode.MaxASCII && !unicode.Is(unicode.Mn, r) {
+				b.WriteRune(r)
+			}
+		}
+		return b.String()
+	default:
+		return norm.NFC.String(s)
+	}
+}
@@ -0,0 +1,75 @@
+package filename
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in   string
+		form Form
+		want string
+	}{
+		{"Cafe\u0301 notes", NFC, "Caf\u00e9 notes"},
+		{"Caf\u00e9 notes", NFD, "Cafe\u0301 notes"},
+		{"ＡＢＣ ﬁle", NFKC, "ABC file"},
+		{"Café 📙 résumé", ASCII, "Cafe  resume"},
+		{"研究ノート", NFC, "研究ノート"},
+		{"ملاحظات", NFC, "ملاحظات"},
+		{"bad\xffbyte", NFC, "bad�byte"},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.in, tt.form); got != tt.want {
+			t.Errorf("Normalize(%q, %v) = %q, want %q", tt.in, tt.form, got, tt.want)
+		}
+	}
+}
+
+func TestParseForm(t *testing.T) {
+	for _, f := range []Form{NFC, NFD, NFKC, ASCII} {
+		got, err := ParseForm(f.String())
+		if err != nil || got != f {
+			t.Errorf("ParseForm(%q) = %v, %v", f.String(), got, err)
+		}
+	}
+	if _, err := ParseForm("nfx"); err == nil {
+		t.Error("ParseForm(nfx) succeeded, want error")
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		in     string
+		policy Policy
+		want   string
+	}{
+		{"Notes: 2024/06", PreserveUnicode, "Notes_ 2024_06"},
+		{"研究ノート 📙.md", PreserveUnicode, "研究ノート 📙.md"},
+		{"Q&A: What? <draft>.txt", WindowsSafe, "Q&A_ What_ _draft_.txt"},
+		{"trailing dots...", WindowsSafe, "trailing dots"},
+		{"con.txt", WindowsSafe, "_con.txt"},
+		{"Café Notes: Part 1.MD", Slugify, "cafe-notes-part-1.md"},
+		{"研究", Slugify, "untitled"},
+		{"../..", PreserveUnicode, ".._.."},
+		{"", PreserveUnicode, "untitled"},
+		{"v1.2 release notes", PreserveUnicode, "v1.2 release notes"},
+	}
+	for _, tt := range tests {
+		if got := Sanitize(tt.in, tt.policy); got != tt.want {
+			t.Errorf("Sanitize(%q, %v) = %q, want %q", tt.in, tt.policy, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeLength(t *testing.T) {
+	long := strings.Repeat("研", 100) + ".txt"
+	got := Sanitize(long, PreserveUnicode)
+	if len(got) > maxNameBytes {
+		t.Errorf("len(Sanitize(long)) = %d, want <= %d", len(got), maxNameBytes)
+	}
+	if !strings.HasSuffix(got, ".txt") || !utf8.ValidString(got) {
+		t.Errorf("Sanitize(long) = %q, want valid UTF-8 ending in .txt", got)
+	}
+}
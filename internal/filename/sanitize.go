@@ -0,0 +1,146 @@
+package filename
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Policy selects how unsafe characters in file names are handled.
+type Policy int
+
+const (
+	// PreserveUnicode keeps all printable characters except path
+	// separators, colons and control characters. It is the default.
+	PreserveUnicode Policy = iota
+	// Slugify lowercases the name and reduces it to ASCII letters, digits
+	// and single dashes.
+	Slugify
+	// WindowsSafe additionally removes the characters Windows rejects
+	// (<>:"/\|?*), trailing dots and spaces, and reserved device names.
+	WindowsSafe
+)
+
+var policyNames = []string{
+	PreserveUnicode: "preserve-unicode",
+	Slugify:         "slugify",
+	WindowsSafe:     "windows-safe",
+}
+
+func (p Policy) String() string {
+	if int(p) < len(policyNames) {
+		return policyNames[p]
+	}
+	return fmt.Sprintf("Policy(%d)", int(p))
+}
+
+// Set implements flag.Value.
+func (p *Policy) Set(s string) error {
+	v, err := ParsePolicy(s)
+	if err != nil {
+		return err
+	}
+	*p = v
+	return nil
+}
+
+// ParsePolicy returns the Policy named by s.
+func ParsePolicy(s string) (Policy, error) {
+	for i, name := range policyNames {
+		if strings.EqualFold(s, name) {
+			return Policy(i), nil
+		}
+	}
+	return PreserveUnicode, fmt.Errorf("unknown filename policy %q (want one of %s)", s, strings.Join(policyNames, ", "))
+}
+
+// maxNameBytes keeps names under the 255 byte limit common to most file
+// systems, leaving room for suffixes such as " (2)".
+const maxNameBytes = 200
+
+// windowsReserved are device names Windows refuses regardless of extension.
+var windowsReserved = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// Sanitize returns name made safe to use as a single path element under
+// policy p. A short alphanumeric extension is preserved. Names that sanitize
+// to nothing become "untitled".
+func Sanitize(name string, p Policy) string {
+	base, ext := splitExt(name)
+	switch p {
+	case Slugify:
+		base = slugify(base)
+		ext = strings.ToLower(ext)
+	case WindowsSafe:
+		base = replaceRunes(base, `<>:"/\|?*`)
+		base = strings.TrimRight(base, ". ")
+		if windowsReserved[strings.ToUpper(base)] {
+			base = "_" + base
+		}
+	default:
+		base = replaceRunes(base, `/\:`)
+	}
+	base = strings.TrimSpace(base)
+	if base == "" || base == "." || base == ".." {
+		base = "untitled"
+	}
+	return truncateBytes(base, maxNameBytes-len(ext)) + ext
+}
+
+// splitExt splits off an extension of up to five ASCII letters or digits.
+func splitExt(name string) (base, ext string) {
+	ext = filepath.Ext(name)
+	if len(ext) < 2 || len(ext) > 6 || len(ext) == len(name) {
+		return name, ""
+	}
+	for _, r := range ext[1:] {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return name, ""
+		}
+	}
+	return strings.TrimSuffix(name, ext), ext
+}
+
+// replaceRunes replaces control characters and any rune in unsafe with '_'.
+func replaceRunes(s, unsafe string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune(unsafe, r) {
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+func slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range Normalize(s, ASCII) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(unicode.ToLower(r))
+			dash = false
+		case !dash && b.Len() > 0:
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// truncateBytes shortens s to at most n bytes without splitting a rune.
+func truncateBytes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return strings.TrimSpace(s[:n])
+}
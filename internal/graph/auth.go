@@ -0,0 +1,172 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultScopes are the permissions asked for to read documents.
+const DefaultScopes = "Files.Read.All Sites.Read.All offline_access"
+
+// A Token is an OAuth access token with the refresh token that renews it.
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	Expiry       time.Time `json:"expiry"`
+}
+
+// Valid reports whether the access token can still be used.
+func (t *Token) Valid() bool {
+	return t != nil && t.AccessToken != "" && time.Until(t.Expiry) > time.Minute
+}
+
+// DeviceAuth signs a user in with the OAuth device code flow, which suits
+// command-line programs: the user enters a code on a web page, on any
+// device, while the program waits.
+type DeviceAuth struct {
+	ClientID string // application (client) ID of an Azure app registration
+	Tenant   string // directory ID or domain; default "organizations"
+	Scopes   string // default DefaultScopes
+	HTTP     *http.Client
+	// LoginURL is the identity platform endpoint; default
+	// https://login.microsoftonline.com.
+	LoginURL string
+	// Prompt shows the user the page to visit and the code to enter.
+	Prompt func(verificationURL, code string)
+}
+
+// minPollInterval bounds how often the token endpoint is polled.
+var minPollInterval = time.Second
+
+// ErrDeclined is returned when the user declines the sign-in.
+var ErrDeclined = errors.New("graph: sign-in declined")
+
+// Login runs the device code flow and returns the token it grants.
+func (a *DeviceAuth) Login(ctx context.Context) (*Token, error) {
+	var dc struct {
+		DeviceCode      string `json:"device_code"`
+		UserCode        string `json:"user_code"`
+		VerificationURI string `json:"verification_uri"`
+		ExpiresIn       int    `json:"expires_in"`
+		Interval        int    `json:"interval"`
+	}
+	if err := a.post(ctx, "devicecode", url.Values{"client_id": {a.ClientID}, "scope": {a.scopes()}}, &dc); err != nil {
+		return nil, err
+	}
+	if a.Prompt != nil {
+		a.Prompt(dc.VerificationURI, dc.UserCode)
+	}
+	interval := max(time.Duration(dc.Interval)*time.Second, minPollInterval)
+	deadline := time.Now().Add(time.Duration(dc.ExpiresIn) * time.Second)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+		t, err := a.token(ctx, url.Values{
+			"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+			"client_id":   {a.ClientID},
+			"device_code": {dc.DeviceCode},
+		})
+		var oe *oauthError
+		switch {
+		case errors.As(err, &oe) && oe.Code == "authorization_pending":
+			continue
+		case errors.As(err, &oe) && oe.Code == "slow_down":
+			interval += 5 * time.Second
+			continue
+		case errors.As(err, &oe) && oe.Code == "authorization_declined":
+			return nil, ErrDeclined
+		}
+		return t, err
+	}
+	return nil, errors.New("graph: sign-in code expired")
+}
+
+// Refresh renews a token with its refresh token.
+func (a *DeviceAuth) Refresh(ctx context.Context, t *Token) (*Token, error) {
+	if t == nil || t.RefreshToken == "" {
+		return nil, errors.New("graph: no refresh token")
+	}
+	nt, err := a.token(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {a.ClientID},
+		"refresh_token": {t.RefreshToken},
+		"scope":         {a.scopes()},
+	})
+	if err == nil && nt.RefreshToken == "" {
+		nt.RefreshToken = t.RefreshToken
+	}
+	return nt, err
+}
+
+func (a *DeviceAuth) token(ctx context.Context, form url.Values) (*Token, error) {
+	var r struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int    `json:"expires_in"`
+	}
+	if err := a.post(ctx, "token", form, &r); err != nil {
+		return nil, err
+	}
+	return &Token{
+		AccessToken:  r.AccessToken,
+		RefreshToken: r.RefreshToken,
+		Expiry:       time.Now().Add(time.Duration(r.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// oauthError is an error response of the token endpoints.
+type oauthError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *oauthError) Error() string {
+	msg, _, _ := strings.Cut(e.Description, "\r\n")
+	return fmt.Sprintf("graph: sign-in: %s: %s", e.Code, msg)
+}
+
+func (a *DeviceAuth) post(ctx context.Context, endpoint string, form url.Values, v interface{}) error {
+	base := a.LoginURL
+	if base == "" {
+		base = "https://login.microsoftonline.com"
+	}
+	tenant := a.Tenant
+	if tenant == "" {
+		tenant = "organizations"
+	}
+	u := fmt.Sprintf("%s/%s/oauth2/v2.0/%s", strings.TrimSuffix(base, "/"), url.PathEscape(tenant), endpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := httpClient(a.HTTP).Do(req)
+	if err != nil {
+		return fmt.Errorf("graph: sign-in: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		oe := new(oauthError)
+		if json.NewDecoder(resp.Body).Decode(oe) == nil && oe.Code != "" {
+			return oe
+		}
+		return fmt.Errorf("graph: sign-in: %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (a *DeviceAuth) scopes() string {
+	if a.Scopes != "" {
+		return a.Scopes
+	}
+	return DefaultScopes
+}
@@ -0,0 +1,204 @@
+// Package graph reads documents from SharePoint document libraries and
+// OneDrive with the Microsoft Graph API.
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the Microsoft Graph endpoint.
+const DefaultBaseURL = "https://graph.microsoft.com/v1.0"
+
+// An Item is a file in a drive.
+type Item struct {
+	ID       string
+	Name     string
+	Path     string // within the drive, such as Specs/Design.docx
+	Size     int64
+	Version  string // changes whenever the content does
+	Modified time.Time
+	WebURL   string
+}
+
+// A Client calls Microsoft Graph with an access token.
+type Client struct {
+	HTTP    *http.Client // defaults to a client with a 5 minute timeout
+	BaseURL string
+	Token   string
+}
+
+// Drive returns the ID of a document library. Site is a SharePoint site
+// such as contoso.sharepoint.com/sites/Engineering, or "me" for the
+// user's OneDrive, in which case library is ignored.
+func (c *Client) Drive(ctx context.Context, site, library string) (string, error) {
+	var d struct {
+		ID string `json:"id"`
+	}
+	if site == "me" {
+		if err := c.get(ctx, "/me/drive?$select=id", &d); err != nil {
+			return "", err
+		}
+		return d.ID, nil
+	}
+	site = strings.TrimPrefix(strings.TrimPrefix(site, "https://"), "http://")
+	host, sitePath, _ := strings.Cut(strings.TrimSuffix(site, "/"), "/")
+	ref := "/sites/" + host
+	if sitePath != "" {
+		ref += ":/" + sitePath + ":"
+	}
+	var drives struct {
+		Value []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"value"`
+	}
+	if err := c.get(ctx, ref+"/drives?$select=id,name", &drives); err != nil {
+		return "", err
+	}
+	var names []string
+	for _, d := range drives.Value {
+		if strings.EqualFold(d.Name, library) {
+			return d.ID, nil
+		}
+		names = append(names, d.Name)
+	}
+	return "", fmt.Errorf("graph: no library %q in %s (have %s)", library, site, strings.Join(names, ", "))
+}
+
+// driveItem is a file or folder as Graph returns it.
+type driveItem struct {
+	ID                   string    `json:"id"`
+	Name                 string    `json:"name"`
+	Size                 int64     `json:"size"`
+	CTag                 string    `json:"cTag"`
+	LastModifiedDateTime time.Time `json:"lastModifiedDateTime"`
+	WebURL               string    `json:"webUrl"`
+	Folder               *struct{} `json:"folder"`
+	File                 *struct{} `json:"file"`
+}
+
+// Files returns the files of a drive, walking its folders.
+func (c *Client) Files(ctx context.Context, driveID string) ([]Item, error) {
+	var items []Item
+	var walk func(id, dir string) error
+	walk = func(id, dir string) error {
+		next := "/drives/" + url.PathEscape(driveID) + "/items/" + url.PathEscape(id) + "/children?$top=200"
+		for next != "" {
+			var page struct {
+				Value    []driveItem `json:"value"`
+				NextLink string      `json:"@odata.nextLink"`
+			}
+			if err := c.get(ctx, next, &page); err != nil {
+				return err
+			}
+			for _, it := range page.Value {
+				p := path.Join(dir, it.Name)
+				switch {
+				case it.Folder != nil:
+					if err := walk(it.ID, p); err != nil {
+						return err
+					}
+				case it.File != nil:
+					items = append(items, Item{
+						ID: it.ID, Name: it.Name, Path: p, Size: it.Size,
+						Version: it.CTag, Modified: it.LastModifiedDateTime, WebURL: it.WebURL,
+					})
+				}
+			}
+			next = page.NextLink
+		}
+		return nil
+	}
+	if err := walk("root", ""); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Download returns the content of a file, converted to PDF by the
+// service if asPDF is set, which Graph supports for Office documents.
+func (c *Client) Download(ctx context.Context, driveID string, it Item, asPDF bool) ([]byte, error) {
+	u := "/drives/" + url.PathEscape(driveID) + "/items/" + url.PathEscape(it.ID) + "/content"
+	if asPDF {
+		u += "?format=pdf"
+	}
+	resp, err := c.do(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 200<<20))
+	if err != nil {
+		return nil, fmt.Errorf("graph: download %s: %w", it.Path, err)
+	}
+	return data, nil
+}
+
+// ErrUnauthorized is returned when the access token is rejected.
+var ErrUnauthorized = errors.New("graph: access token rejected")
+
+func (c *Client) get(ctx context.Context, u string, v interface{}) error {
+	resp, err := c.do(ctx, u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("graph: parse response: %w", err)
+	}
+	return nil
+}
+
+// do sends a GET request. u is a path below BaseURL or, for paging links,
+// an absolute URL.
+func (c *Client) do(ctx context.Context, u string) (*http.Response, error) {
+	if !strings.HasPrefix(u, "https://") && !strings.HasPrefix(u, "http://") {
+		base := c.BaseURL
+		if base == "" {
+			base = DefaultBaseURL
+		}
+		u = strings.TrimSuffix(base, "/") + u
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	resp, err := httpClient(c.HTTP).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("graph: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusUnauthorized {
+			return nil, ErrUnauthorized
+		}
+		var e struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&e) == nil && e.Error.Message != "" {
+			return nil, fmt.Errorf("graph: %s: %s", e.Error.Code, e.Error.Message)
+		}
+		return nil, fmt.Errorf("graph: %s", resp.Status)
+	}
+	return resp, nil
+}
+
+func httpClient(c *http.Client) *http.Client {
+	if c != nil {
+		return c
+	}
+	return &http.Client{Timeout: 5 * time.Minute}
+}
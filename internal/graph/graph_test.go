@@ -0,0 +1,124 @@
+package graph
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestFiles(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/sites/contoso.sharepoint.com:/sites/Eng:/drives":
+			w.Write([]byte(`{"value":[{"id":"d0","name":"Site Assets"},{"id":"d1","name":"Documents"}]}`))
+		case "/drives/d1/items/root/children":
+			if r.URL.Query().Get("page") == "" {
+				w.Write([]byte(`{"value":[{"id":"f1","name":"Specs","folder":{}}],"@odata.nextLink":"` + srv.URL + `/drives/d1/items/root/children?page=2"}`))
+				return
+			}
+			w.Write([]byte(`{"value":[{"id":"a","name":"Plan.docx","size":10,"cTag":"c1","file":{},"lastModifiedDateTime":"2024-05-01T10:00:00Z"}]}`))
+		case "/drives/d1/items/f1/children":
+			w.Write([]byte(`{"value":[{"id":"b","name":"API.pdf","size":20,"cTag":"c2","file":{}}]}`))
+		case "/drives/d1/items/a/content":
+			if r.URL.Query().Get("format") == "pdf" {
+				w.Write([]byte("%PDF-1.7"))
+				return
+			}
+			w.Write([]byte("PK..."))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":"itemNotFound","message":"The resource could not be found."}}`))
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := &Client{BaseURL: srv.URL, Token: "tok"}
+	drive, err := c.Drive(ctx, "https://contoso.sharepoint.com/sites/Eng", "documents")
+	if err != nil || drive != "d1" {
+		t.Fatalf("Drive = %q, %v; want d1", drive, err)
+	}
+	if _, err := c.Drive(ctx, "contoso.sharepoint.com/sites/Eng", "Missing"); err == nil || !strings.Contains(err.Error(), "Site Assets, Documents") {
+		t.Errorf("Drive(Missing) error = %v", err)
+	}
+
+	files, err := c.Files(ctx, drive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Item{
+		{ID: "b", Name: "API.pdf", Path: "Specs/API.pdf", Size: 20, Version: "c2"},
+		{ID: "a", Name: "Plan.docx", Path: "Plan.docx", Size: 10, Version: "c1", Modified: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+	}
+	if diff := cmp.Diff(want, files, cmpopts.EquateApproxTime(0)); diff != "" {
+		t.Errorf("Files mismatch (-want +got):\n%s", diff)
+	}
+
+	data, err := c.Download(ctx, drive, files[1], true)
+	if err != nil || string(data) != "%PDF-1.7" {
+		t.Errorf("Download(asPDF) = %q, %v", data, err)
+	}
+	if _, err := c.Download(ctx, drive, Item{ID: "gone"}, false); err == nil || !strings.Contains(err.Error(), "itemNotFound") {
+		t.Errorf("Download(missing) error = %v", err)
+	}
+	c.Token = "expired"
+	if _, err := c.Files(ctx, drive); err != ErrUnauthorized {
+		t.Errorf("Files with a bad token: err = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestDeviceLogin(t *testing.T) {
+	minPollInterval = time.Millisecond
+	polls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		switch r.URL.Path {
+		case "/contoso.com/oauth2/v2.0/devicecode":
+			if r.Form.Get("client_id") != "app" || !strings.Contains(r.Form.Get("scope"), "offline_access") {
+				t.Errorf("devicecode form = %v", r.Form)
+			}
+			w.Write([]byte(`{"device_code":"dev","user_code":"ABC-123","verification_uri":"https://microsoft.com/devicelogin","expires_in":60,"interval":0}`))
+		case "/contoso.com/oauth2/v2.0/token":
+			switch r.Form.Get("grant_type") {
+			case "refresh_token":
+				w.Write([]byte(`{"access_token":"at2","refresh_token":"rt2","expires_in":3600}`))
+				return
+			}
+			if polls++; polls < 3 {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"authorization_pending","error_description":"waiting"}`))
+				return
+			}
+			w.Write([]byte(`{"access_token":"at","refresh_token":"rt","expires_in":3600}`))
+		}
+	}))
+	defer srv.Close()
+
+	var shown string
+	a := &DeviceAuth{ClientID: "app", Tenant: "contoso.com", LoginURL: srv.URL,
+		Prompt: func(u, code string) { shown = u + " " + code }}
+	tok, err := a.Login(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.AccessToken != "at" || tok.RefreshToken != "rt" || !tok.Valid() {
+		t.Errorf("Login = %+v", tok)
+	}
+	if shown != "https://microsoft.com/devicelogin ABC-123" {
+		t.Errorf("Prompt got %q", shown)
+	}
+	if tok, err = a.Refresh(context.Background(), tok); err != nil || tok.AccessToken != "at2" {
+		t.Errorf("Refresh = %+v, %v", tok, err)
+	}
+}
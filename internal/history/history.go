@@ -0,0 +1,284 @@
+// Package history reads recently visited pages from the local history
+// databases of Chrome-family browsers and Firefox. The databases are
+// SQLite files, queried with the sqlite3 command-line shell.
+package history
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tmc/nlm/internal/cleanup"
+)
+
+// Browsers are the browsers whose history can be read.
+var Browsers = []string{"chrome", "chromium", "brave", "edge", "firefox"}
+
+// A Visit is a page in the history, with the time it was last visited.
+type Visit struct {
+	URL   string
+	Title string
+	Time  time.Time
+	Count int // number of visits
+}
+
+// Options select the visits Read returns.
+type Options struct {
+	Browser string // one of Browsers; default "chrome"
+	Profile string // browser profile; default "Default", or Firefox's most recently used
+	File    string // history database, overriding Browser and Profile
+	Since   time.Time
+	Match   *regexp.Regexp // if set, only visits whose URL or title match
+	SQLite  string         // sqlite3 binary; default "sqlite3" in PATH
+}
+
+// maxRows bounds the visits read from a database.
+const maxRows = 5000
+
+// Read returns the web pages visited since opts.Since, most recent first,
+// one per URL.
+func Read(ctx context.Context, opts Options) ([]Visit, error) {
+	browser := opts.Browser
+	if browser == "" {
+		browser = "chrome"
+	}
+	file := opts.File
+	if file == "" {
+		var err error
+		if file, err = File(browser, opts.Profile); err != nil {
+			return nil, err
+		}
+	}
+	sqlite := opts.SQLite
+	if sqlite == "" {
+		path, err := exec.LookPath("sqlite3")
+		if err != nil {
+			return nil, errors.New("history: sqlite3 not found; install the SQLite command-line shell")
+		}
+		sqlite = path
+	}
+
+	// The browser keeps its database locked while running, so query a copy.
+	dir, err := cleanup.MkdirTemp("", "nlm-history-")
+	if err != nil {
+		return nil, fmt.Errorf("history: %w", err)
+	}
+	defer cleanup.Remove(dir)
+	db := filepath.Join(dir, "history.sqlite")
+	if err := copyFile(file, db); err != nil {
+		return nil, fmt.Errorf("history: %w", err)
+	}
+	if err := copyFile(file+"-wal", db+"-wal"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("history: %w", err)
+	}
+
+	var query string
+	if isFirefox(browser, file) {
+		query = fmt.Sprintf(`SELECT url, COALESCE(title, '') AS title, last_visit_date AS t, visit_count AS n
+FROM moz_places WHERE last_visit_date >= %d AND hidden = 0 ORDER BY last_visit_date DESC LIMIT %d`, unixMicros(opts.Since), maxRows)
+	} else {
+		query = fmt.Sprintf(`SELECT url, title, last_visit_time AS t, visit_count AS n
+FROM urls WHERE last_visit_time >= %d AND hidden = 0 ORDER BY last_visit_time DESC LIMIT %d`, chromeMicros(opts.Since), maxRows)
+	}
+	var out, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, sqlite, "-readonly", "-json", db, query)
+	cmd.Stdout, cmd.Stderr = &out, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("history: %s: %w: %s", file, err, strings.TrimSpace(stderr.String()))
+	}
+	visits, err := parseRows(&out, isFirefox(browser, file))
+	if err != nil {
+		return nil, fmt.Errorf("history: %s: %w", file, err)
+	}
+	return filter(visits, opts.Match), nil
+}
+
+// chromeEpochOffset is the number of seconds from 1601-01-01, Chrome's
+// epoch, to the Unix epoch.
+const chromeEpochOffset = 11644473600
+
+func chromeMicros(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMicro() + chromeEpochOffset*1e6
+}
+
+func unixMicros(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMicro()
+}
+
+// parseRows reads sqlite3's JSON output. Times are in microseconds since
+// the Unix epoch for Firefox and since Chrome's epoch otherwise.
+func parseRows(r io.Reader, firefox bool) ([]Visit, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(data)) == 0 { // no rows
+		return nil, nil
+	}
+	var rows []struct {
+		URL   string `json:"url"`
+		Title string `json:"title"`
+		T     int64  `json:"t"`
+		N     int    `json:"n"`
+	}
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, fmt.Errorf("parse sqlite3 output: %w", err)
+	}
+	visits := make([]Visit, len(rows))
+	for i, r := range rows {
+		us := r.T
+		if !firefox {
+			us -= chromeEpochOffset * 1e6
+		}
+		visits[i] = Visit{URL: r.URL, Title: strings.TrimSpace(r.Title), Time: time.UnixMicro(us), Count: r.N}
+	}
+	return visits, nil
+}
+
+// filter keeps the http and https visits matching match, merging URLs
+// that differ only in their fragment, most recent first.
+func filter(visits []Visit, match *regexp.Regexp) []Visit {
+	sort.SliceStable(visits, func(i, j int) bool { return visits[i].Time.After(visits[j].Time) })
+	seen := make(map[string]bool)
+	var out []Visit
+	for _, v := range visits {
+		u, err := url.Parse(v.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+		u.Fragment = ""
+		v.URL = u.String()
+		if seen[v.URL] {
+			continue
+		}
+		seen[v.URL] = true
+		if match != nil && !match.MatchString(v.URL) && !match.MatchString(v.Title) {
+			continue
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+// File returns the history database of a browser profile.
+func File(browser, profile string) (string, error) {
+	root, err := profileRoot(browser)
+	if err != nil {
+		return "", err
+	}
+	if browser == "firefox" {
+		return firefoxFile(root, profile)
+	}
+	if profile == "" {
+		profile = "Default"
+	}
+	file := filepath.Join(root, profile, "History")
+	if _, err := os.Stat(file); err != nil {
+		return "", fmt.Errorf("history: no %s history for profile %q: %w", browser, profile, err)
+	}
+	return file, nil
+}
+
+// firefoxFile returns places.sqlite of the Firefox profile whose
+// directory name contains profile, or of the most recently used profile.
+func firefoxFile(root, profile string) (string, error) {
+	files, _ := filepath.Glob(filepath.Join(root, "*", "places.sqlite"))
+	var best string
+	var bestTime time.Time
+	for _, f := range files {
+		if profile != "" && !strings.Contains(filepath.Base(filepath.Dir(f)), profile) {
+			continue
+		}
+		fi, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		if best == "" || fi.ModTime().After(bestTime) {
+			best, bestTime = f, fi.ModTime()
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("history: no Firefox profile found in %s", root)
+	}
+	return best, nil
+}
+
+// profileRoot returns the directory holding a browser's profiles.
+func profileRoot(browser string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("history: %w", err)
+	}
+	type dirs struct{ darwin, linux, windows string }
+	var d dirs
+	switch browser {
+	case "chrome":
+		d = dirs{"Google/Chrome", "google-chrome", `Google\Chrome\User Data`}
+	case "chromium":
+		d = dirs{"Chromium", "chromium", `Chromium\User Data`}
+	case "brave":
+		d = dirs{"BraveSoftware/Brave-Browser", "BraveSoftware/Brave-Browser", `BraveSoftware\Brave-Browser\User Data`}
+	case "edge":
+		d = dirs{"Microsoft Edge", "microsoft-edge", `Microsoft\Edge\User Data`}
+	case "firefox":
+		switch runtime.GOOS {
+		case "darwin":
+			return filepath.Join(home, "Library", "Application Support", "Firefox", "Profiles"), nil
+		case "windows":
+			return filepath.Join(os.Getenv("APPDATA"), "Mozilla", "Firefox", "Profiles"), nil
+		}
+		return filepath.Join(home, ".mozilla", "firefox"), nil
+	default:
+		return "", fmt.Errorf("history: unknown browser %q (want one of %s)", browser, strings.Join(Browsers, ", "))
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join(home, "Library", "Application Support", d.darwin), nil
+	case "windows":
+		return filepath.Join(os.Getenv("LOCALAPPDATA"), d.windows), nil
+	}
+	config := os.Getenv("XDG_CONFIG_HOME")
+	if config == "" {
+		config = filepath.Join(home, ".config")
+	}
+	return filepath.Join(config, d.linux), nil
+}
+
+func isFirefox(browser, file string) bool {
+	return browser == "firefox" || filepath.Base(file) == "places.sqlite"
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
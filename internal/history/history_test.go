@@ -0,0 +1,76 @@
+package history
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseRows(t *testing.T) {
+	when := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	chrome := `[{"url":"https://a.example/","title":"A","t":` + strconv.FormatInt(chromeMicros(when), 10) + `,"n":3}]`
+	got, err := parseRows(strings.NewReader(chrome), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || !got[0].Time.Equal(when) || got[0].Count != 3 {
+		t.Errorf("parseRows(chrome) = %+v, want a visit at %v", got, when)
+	}
+	firefox := `[{"url":"https://a.example/","title":"A","t":` + strconv.FormatInt(when.UnixMicro(), 10) + `,"n":1}]`
+	if got, err = parseRows(strings.NewReader(firefox), true); err != nil || !got[0].Time.Equal(when) {
+		t.Errorf("parseRows(firefox) = %+v, %v; want a visit at %v", got, err, when)
+	}
+	if got, err = parseRows(strings.NewReader(""), false); err != nil || got != nil {
+		t.Errorf("parseRows(empty) = %v, %v; want no visits", got, err)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	at := func(min int) time.Time { return time.Date(2024, 5, 1, 12, min, 0, 0, time.UTC) }
+	visits := []Visit{
+		{URL: "https://docs.example/guide#intro", Title: "Guide", Time: at(1)},
+		{URL: "https://docs.example/guide#setup", Title: "Guide", Time: at(5)},
+		{URL: "https://arxiv.org/abs/2401.12345", Title: "A paper", Time: at(3)},
+		{URL: "https://news.example/", Title: "News", Time: at(4)},
+		{URL: "chrome://settings/", Title: "Settings", Time: at(6)},
+	}
+	got := filter(visits, regexp.MustCompile(`arxiv|docs`))
+	want := []Visit{
+		{URL: "https://docs.example/guide", Title: "Guide", Time: at(5)},
+		{URL: "https://arxiv.org/abs/2401.12345", Title: "A paper", Time: at(3)},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("filter mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReadChrome(t *testing.T) {
+	sqlite, err := exec.LookPath("sqlite3")
+	if err != nil {
+		t.Skip("sqlite3 not installed")
+	}
+	db := filepath.Join(t.TempDir(), "History")
+	now := time.Now().Truncate(time.Second)
+	old, recent := chromeMicros(now.Add(-48*time.Hour)), chromeMicros(now.Add(-time.Minute))
+	setup := `CREATE TABLE urls (id INTEGER PRIMARY KEY, url TEXT, title TEXT, visit_count INTEGER, last_visit_time INTEGER, hidden INTEGER DEFAULT 0);
+INSERT INTO urls (url, title, visit_count, last_visit_time) VALUES
+  ('https://docs.example/old', 'Old', 1, ` + strconv.FormatInt(old, 10) + `),
+  ('https://docs.example/new', 'New', 2, ` + strconv.FormatInt(recent, 10) + `);`
+	if out, err := exec.Command(sqlite, db, setup).CombinedOutput(); err != nil {
+		t.Fatalf("sqlite3: %v: %s", err, out)
+	}
+	got, err := Read(context.Background(), Options{File: db, Since: now.Add(-time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].URL != "https://docs.example/new" || got[0].Count != 2 {
+		t.Errorf("Read = %+v, want only the recent visit", got)
+	}
+}
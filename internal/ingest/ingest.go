@@ -0,0 +1,202 @@
+// Package ingest transforms document text before it is uploaded, so that
+// content the user does not want to share never leaves the machine.
+package ingest
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultReplacement replaces redacted text when a rule has no replacement.
+const DefaultReplacement = "[REDACTED]"
+
+// Options configure the pipeline. They are read from the ingest section of
+// .nlm.yaml.
+type Options struct {
+	// StripFrontMatter removes a leading YAML (---) or TOML (+++) block.
+	StripFrontMatter bool `yaml:"strip_front_matter"`
+	// DropCodeBlocks removes fenced code blocks (``` or ~~~).
+	DropCodeBlocks bool `yaml:"drop_code_blocks"`
+	// Redact lists regular expressions whose matches are replaced.
+	Redact []Redaction `yaml:"redact"`
+	// MaxLength truncates documents to this many characters; zero means no
+	// limit.
+	MaxLength int `yaml:"max_length"`
+}
+
+// Redaction replaces matches of Pattern with Replace.
+type Redaction struct {
+	Pattern string `yaml:"pattern"`
+	Replace string `yaml:"replace"`
+}
+
+// Stats describe what a pipeline changed in a document.
+type Stats struct {
+	FrontMatter bool
+	CodeBlocks  int
+	Redactions  int
+	Truncated   bool
+}
+
+// Changed reports whether anything was modified.
+func (s Stats) Changed() bool {
+	return s.FrontMatter || s.CodeBlocks > 0 || s.Redactions > 0 || s.Truncated
+}
+
+func (s Stats) String() string {
+	var parts []string
+	if s.FrontMatter {
+		parts = append(parts, "stripped front matter")
+	}
+	if s.CodeBlocks > 0 {
+		parts = append(parts, fmt.Sprintf("dropped %d code block(s)", s.CodeBlocks))
+	}
+	if s.Redactions > 0 {
+		parts = append(parts, fmt.Sprintf("redacted %d match(es)", s.Redactions))
+	}
+	if s.Truncated {
+		parts = append(parts, "truncated")
+	}
+	if len(parts) == 0 {
+		return "unchanged"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Pipeline applies the configured transforms in order: front matter, code
+// blocks, redactions, truncation.
+type Pipeline struct {
+	opts  Options
+	rules []*regexp.Regexp
+}
+
+// New compiles a pipeline.
+func New(opts Options) (*Pipeline, error) {
+	p := &Pipeline{opts: opts}
+	for i, r := range opts.Redact {
+		if r.Pattern == "" {
+			return nil, fmt.Errorf("ingest: redact rule %d: empty pattern", i+1)
+		}
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("ingest: redact rule %d: %w", i+1, err)
+		}
+		p.rules = append(p.rules, re)
+	}
+	if opts.MaxLength < 0 {
+		return nil, fmt.Errorf("ingest: max_length must not be negative")
+	}
+	return p, nil
+}
+
+// Empty reports whether the pipeline has no transforms.
+func (p *Pipeline) Empty() bool {
+	return p == nil || (!p.opts.StripFrontMatter && !p.opts.DropCodeBlocks && len(p.rules) == 0 && p.opts.MaxLength == 0)
+}
+
+// Redacts reports whether the pipeline has redaction rules. Documents that
+// cannot be read as text must not be uploaded when it does.
+func (p *Pipeline) Redacts() bool {
+	return p != nil && len(p.rules) > 0
+}
+
+// Apply transforms text.
+func (p *Pipeline) Apply(text string) (string, Stats) {
+	var s Stats
+	if p == nil {
+		return text, s
+	}
+	if p.opts.StripFrontMatter {
+		text, s.FrontMatter = stripFrontMatter(text)
+	}
+	if p.opts.DropCodeBlocks {
+		text, s.CodeBlocks = dropCodeBlocks(text)
+	}
+	for i, re := range p.rules {
+		repl := p.opts.Redact[i].Replace
+		if repl == "" {
+			repl = DefaultReplacement
+		}
+		s.Redactions += len(re.FindAllStringIndex(text, -1))
+		text = re.ReplaceAllString(text, repl)
+	}
+	if max := p.opts.MaxLength; max > 0 && utf8.RuneCountInString(text) > max {
+		text = truncate(text, max)
+		s.Truncated = true
+	}
+	return text, s
+}
+
+func stripFrontMatter(text string) (string, bool) {
+	body := strings.TrimPrefix(text, "\ufeff")
+	for _, delim := range []string{"---", "+++"} {
+		if !strings.HasPrefix(body, delim+"\n") && !strings.HasPrefix(body, delim+"\r\n") {
+			continue
+		}
+		rest := body[len(delim):]
+		for {
+			nl := strings.IndexByte(rest, '\n')
+			if nl < 0 {
+				return text, false
+			}
+			rest = rest[nl+1:]
+			line, _, _ := strings.Cut(rest, "\n")
+			if strings.TrimRight(line, "\r") == delim {
+				if i := strings.IndexByte(rest, '\n'); i >= 0 {
+					return strings.TrimLeft(rest[i+1:], "\r\n"), true
+				}
+				return "", true
+			}
+		}
+	}
+	return text, false
+}
+
+// dropCodeBlocks removes fenced code blocks. An unterminated fence runs to
+// the end of the document, as in CommonMark.
+func dropCodeBlocks(text string) (string, int) {
+	lines := strings.SplitAfter(text, "\n")
+	var b strings.Builder
+	var fence string
+	var n int
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if fence == "" {
+			if f := fenceOf(trimmed); f != "" {
+				fence = f
+				n++
+				continue
+			}
+			b.WriteString(line)
+			continue
+		}
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			fence = ""
+		}
+	}
+	return b.String(), n
+}
+
+// fenceOf returns the opening fence of a code block line, or "".
+func fenceOf(line string) string {
+	for _, c := range []string{"`", "~"} {
+		if strings.HasPrefix(line, c+c+c) {
+			n := len(line) - len(strings.TrimLeft(line, c))
+			return strings.Repeat(c, n)
+		}
+	}
+	return ""
+}
+
+func truncate(text string, max int) string {
+	n := 0
+	for i := range text {
+		if n == max {
+			return text[:i]
+		}
+		n++
+	}
+	return text
+}
@@ -0,0 +1,93 @@
+package ingest
+
+import (
+	"testing"
+)
+
+func TestPipeline(t *testing.T) {
+	doc := "---\ntitle: Secret plan\napi_key: abc\n---\n\n# Plan\n\nCall 555-1234 with token sk-ABCDEF123456.\n\n```go\nfmt.Println(\"code\")\n```\n\nMore text.\n~~~\nraw\n~~~\nEnd.\n"
+	tests := []struct {
+		name  string
+		opts  Options
+		want  string
+		stats Stats
+	}{
+		{
+			name: "empty",
+			want: doc,
+		},
+		{
+			name:  "front matter",
+			opts:  Options{StripFrontMatter: true},
+			want:  "# Plan\n\nCall 555-1234 with token sk-ABCDEF123456.\n\n```go\nfmt.Println(\"code\")\n```\n\nMore text.\n~~~\nraw\n~~~\nEnd.\n",
+			stats: Stats{FrontMatter: true},
+		},
+		{
+			name:  "code blocks",
+			opts:  Options{StripFrontMatter: true, DropCodeBlocks: true},
+			want:  "# Plan\n\nCall 555-1234 with token sk-ABCDEF123456.\n\n\nMore text.\nEnd.\n",
+			stats: Stats{FrontMatter: true, CodeBlocks: 2},
+		},
+		{
+			name: "redactions",
+			opts: Options{
+				StripFrontMatter: true,
+				DropCodeBlocks:   true,
+				Redact: []Redaction{
+					{Pattern: `sk-[A-Za-z0-9]+`},
+					{Pattern: `\d{3}-\d{4}`, Replace: "[phone]"},
+				},
+			},
+			want:  "# Plan\n\nCall [phone] with token [REDACTED].\n\n\nMore text.\nEnd.\n",
+			stats: Stats{FrontMatter: true, CodeBlocks: 2, Redactions: 2},
+		},
+		{
+			name:  "truncate",
+			opts:  Options{StripFrontMatter: true, MaxLength: 6},
+			want:  "# Plan",
+			stats: Stats{FrontMatter: true, Truncated: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, stats := p.Apply(doc)
+			if got != tt.want {
+				t.Errorf("Apply =\n%q\nwant\n%q", got, tt.want)
+			}
+			if stats != tt.stats {
+				t.Errorf("stats = %+v, want %+v", stats, tt.stats)
+			}
+		})
+	}
+}
+
+func TestFrontMatterNotClosed(t *testing.T) {
+	p, _ := New(Options{StripFrontMatter: true})
+	in := "---\nnot closed\n"
+	if got, s := p.Apply(in); got != in || s.FrontMatter {
+		t.Errorf("Apply = %q, %+v; want unchanged", got, s)
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	p, _ := New(Options{MaxLength: 2})
+	if got, _ := p.Apply("日本語"); got != "日本" {
+		t.Errorf("Apply = %q, want 日本", got)
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	for _, opts := range []Options{
+		{Redact: []Redaction{{Pattern: "("}}},
+		{Redact: []Redaction{{}}},
+		{MaxLength: -1},
+	} {
+		if _, err := New(opts); err == nil {
+			t.Errorf("New(%+v): want error", opts)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+// Package jobs keeps a registry of commands started in the background with
+// nlm -async, so they can be listed and waited for from other processes.
+//
+// Each job is a JSON file in the jobs directory of nlm's state. The
+// starting process records the job before the background process exists;
+// the background process then fills in its PID and, when it finishes, its
+// result. Changes to a job are made under a lock on its file, so that
+// concurrent processes do not overwrite each other's.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tmc/nlm/internal/state"
+)
+
+// Job states.
+const (
+	Running   = "running"
+	Succeeded = "succeeded"
+	Failed    = "failed"
+	// Lost is a job whose process exited without recording a result, for
+	// example because it was killed.
+	Lost = "lost"
+)
+
+// Dir is the directory of the registry in nlm's state.
+const Dir = "jobs"
+
+// ErrNotFound is returned for IDs that are not in the registry.
+var ErrNotFound = errors.New("no such job")
+
+// Job is a command running or run in the background.
+type Job struct {
+	ID      string   `json:"id"`
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+	// Argv is the full command line the job runs, flags included.
+	Argv []string `json:"argv,omitempty"`
+	PID  int      `json:"pid,omitempty"`
+	// Process identifies the process with PID, so that a later process
+	// reusing the PID is not taken for the job's; see Identity.
+	Process  string    `json:"process,omitempty"`
+	State    string    `json:"state"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	// Log is the file the job's output is written to.
+	Log string `json:"log"`
+	// Phase is the last checkpoint the job recorded, such as "submitted"
+	// once the server has accepted an operation that continues without
+	// nlm.
+	Phase string `json:"phase,omitempty"`
+	// Resumes counts how often the job was restarted with Resume.
+	Resumes int `json:"resumes,omitempty"`
+}
+
+// Done reports whether the job has stopped.
+func (j *Job) Done() bool { return j.State != Running }
+
+// Registry stores jobs in a state store.
+type Registry struct {
+	st *state.Store
+	// alive reports whether a process is running; it is replaced in tests.
+	alive func(pid int, process string) bool
+}
+
+// NewRegistry returns the registry kept in st.
+func NewRegistry(st *state.Store) *Registry {
+	return &Registry{st: st, alive: ProcessRunning}
+}
+
+// ProcessRunning reports whether the process with the given PID is running
+// and, if process is not empty, is still the one Identity returned it for.
+func ProcessRunning(pid int, process string) bool {
+	if !Alive(pid) {
+		return false
+	}
+	if process == "" {
+		return true
+	}
+	cur := Identity(pid)
+	return cur == "" || cur == process
+}
+
+// NewID returns a new job ID, such as "j-3f9a1c2b".
+func NewID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return "j-" + hex.EncodeToString(b)
+}
+
+func (r *Registry) name(id string) string { return Dir + "/" + id + ".json" }
+
+// LogPath returns the path of the log file of the job with the given ID.
+func (r *Registry) LogPath(id string) string { return r.st.Path(Dir + "/" + id + ".log") }
+
+// Create records a new running job.
+func (r *Registry) Create(command string, args, argv []string) (*Job, error) {
+	id := NewID()
+	j := &Job{
+		ID:      id,
+		Command: command,
+		Args:    args,
+		Argv:    argv,
+		State:   Running,
+		Started: time.Now().UTC(),
+		Log:     r.LogPath(id),
+	}
+	if err := r.Save(j); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Save writes j to the registry.
+func (r *Registry) Save(j *Job) error {
+	return r.st.Save(r.name(j.ID), j)
+}
+
+// Get returns the job with the given ID. A running job whose process has
+// exited is reported, and recorded, as Lost.
+func (r *Registry) Get(id string) (*Job, error) {
+	j, lost, err := r.load(id)
+	if err != nil || !lost {
+		return j, err
+	}
+	// Record the loss under the lock, rereading the job, as the process
+	// may have saved its result just before exiting.
+	return r.update(id, nil)
+}
+
+// load reads the job with the given ID. A running job whose process has
+// exited is marked Lost, and load reports whether it did so.
+func (r *Registry) load(id string) (*Job, bool, error) {
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return nil, false, fmt.Errorf("%w: %q", ErrNotFound, id)
+	}
+	var j Job
+	if err := r.st.Load(r.name(id), &j); err != nil {
+		return nil, false, err
+	}
+	if j.ID == "" {
+		return nil, false, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if j.State == Running && j.PID != 0 && !r.alive(j.PID, j.Process) {
+		j.State, j.Finished = Lost, time.Now().UTC()
+		j.Error = "the process exited without recording a result"
+		return &j, true, nil
+	}
+	return &j, false, nil
+}
+
+// update applies fn, if not nil, to the job with the given ID and saves
+// the result, holding the job's lock throughout. If fn fails, nothing is
+// saved.
+func (r *Registry) update(id string, fn func(j *Job) error) (*Job, error) {
+	if _, _, err := r.load(id); err != nil {
+		return nil, err
+	}
+	unlock, err := r.st.Lock(r.name(id))
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	j, _, err := r.load(id)
+	if err != nil {
+		return nil, err
+	}
+	if fn != nil {
+		if err := fn(j); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.Save(j); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// List returns all jobs, most recently started first.
+func (r *Registry) List() ([]*Job, error) {
+	entries, err := os.ReadDir(r.st.Path(Dir))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var jobs []*Job
+	for _, e := range entries {
+		name := e.Name()
+		if filepath.Ext(name) != ".json" || strings.HasPrefix(name, ".") {
+			continue
+		}
+		j, err := r.Get(strings.TrimSuffix(name, ".json"))
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	sort.Slice(jobs, func(i, k int) bool { return jobs[i].Started.After(jobs[k].Started) })
+	return jobs, nil
+}
+
+// Finish records the result of the job with the given ID.
+func (r *Registry) Finish(id string, err error) error {
+	_, uerr := r.update(id, func(j *Job) error {
+		j.State, j.Finished, j.Error = Succeeded, time.Now().UTC(), ""
+		if err != nil {
+			j.State, j.Error = Failed, err.Error()
+		}
+		return nil
+	})
+	return uerr
+}
+
+// SetPID records that the job with the given ID runs as process pid.
+func (r *Registry) SetPID(id string, pid int) error {
+	_, err := r.update(id, func(j *Job) error {
+		j.PID, j.Process = pid, Identity(pid)
+		return nil
+	})
+	return err
+}
+
+// SetPhase records a checkpoint of the job with the given ID.
+func (r *Registry) SetPhase(id, phase string) error {
+	_, err := r.update(id, func(j *Job) error {
+		j.Phase = phase
+		return nil
+	})
+	return err
+}
+
+// ErrNotResumable is returned by Resume for jobs that are running or
+// succeeded.
+var ErrNotResumable = errors.New("job is not resumable")
+
+// Resume marks a lost or failed job as running again, keeping its phase,
+// so that a new process can take it over. Of concurrent calls for the
+// same job, only one succeeds.
+func (r *Registry) Resume(id string) (*Job, error) {
+	return r.update(id, func(j *Job) error {
+		if j.State != Lost && j.State != Failed {
+			return fmt.Errorf("%w: %s is %s", ErrNotResumable, id, j.State)
+		}
+		j.State, j.PID, j.Process, j.Error, j.Finished = Running, 0, "", "", time.Time{}
+		j.Resumes++
+		return nil
+	})
+}
+
+// Wait polls the job with the given ID every interval until it is done or
+// ctx ends.
+func (r *Registry) Wait(ctx context.Context, id string, interval time.Duration) (*Job, error) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		j, err := r.Get(id)
+		if err != nil || j.Done() {
+			return j, err
+		}
+		select {
+		case <-ctx.Done():
+			return j, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tmc/nlm/internal/state"
+)
+
+func newTestRegistry(t *testing.T) *Registry {
+	t.Helper()
+	st, err := state.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewRegistry(st)
+}
+
+func TestLifecycle(t *testing.T) {
+	r := newTestRegistry(t)
+	alive := true
+	r.alive = func(int, string) bool { return alive }
+
+	j, err := r.Create("audio-create", []string{"nb1", "focus on chapter 2"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.PID = 1234
+	if err := r.Save(j); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := r.Get(j.ID); err != nil || got.State != Running {
+		t.Fatalf("Get = %+v, %v; want running", got, err)
+	}
+	if err := r.Finish(j.ID, errors.New("quota exceeded")); err != nil {
+		t.Fatal(err)
+	}
+	alive = false
+	got, err := r.Get(j.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.State != Failed || got.Error != "quota exceeded" || got.Finished.IsZero() {
+		t.Errorf("after Finish: %+v", got)
+	}
+}
+
+func TestLost(t *testing.T) {
+	r := newTestRegistry(t)
+	r.alive = func(int, string) bool { return false }
+	j, err := r.Create("import", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := r.Get(j.ID); got.State != Running {
+		t.Errorf("job without a PID yet: state %s, want running", got.State)
+	}
+	j.PID = 1234
+	r.Save(j)
+	got, err := r.Wait(context.Background(), j.ID, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.State != Lost {
+		t.Errorf("state = %s, want lost", got.State)
+	}
+}
+
+func TestListAndGet(t *testing.T) {
+	r := newTestRegistry(t)
+	if jobs, err := r.List(); err != nil || len(jobs) != 0 {
+		t.Fatalf("List of empty registry = %v, %v", jobs, err)
+	}
+	first, _ := r.Create("add", nil, nil)
+	first.Started = first.Started.Add(-time.Minute)
+	r.Save(first)
+	second, _ := r.Create("crawl", nil, nil)
+	jobs, err := r.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 2 || jobs[0].ID != second.ID || jobs[1].ID != first.ID {
+		t.Errorf("List = %v, want newest first", jobs)
+	}
+	for _, id := range []string{"j-missing", "../env", ""} {
+		if _, err := r.Get(id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(%q) = %v, want ErrNotFound", id, err)
+		}
+	}
+}
+
+func TestResume(t *testing.T) {
+	r := newTestRegistry(t)
+	r.alive = func(int, string) bool { return false }
+	j, _ := r.Create("audio-create", []string{"nb1", "x"}, []string{"audio-create", "nb1", "x"})
+	if _, err := r.Resume(j.ID); !errors.Is(err, ErrNotResumable) {
+		t.Errorf("Resume of running job = %v, want ErrNotResumable", err)
+	}
+	if err := r.SetPhase(j.ID, "submitted"); err != nil {
+		t.Fatal(err)
+	}
+	j, _ = r.Get(j.ID)
+	j.PID = 1234
+	r.Save(j)
+	if got, _ := r.Get(j.ID); got.State != Lost {
+		t.Fatalf("state = %s, want lost", got.State)
+	}
+	got, err := r.Resume(j.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.State != Running || got.PID != 0 || got.Phase != "submitted" || got.Resumes != 1 {
+		t.Errorf("after Resume: %+v", got)
+	}
+	r.Finish(j.ID, nil)
+	if _, err := r.Resume(j.ID); !errors.Is(err, ErrNotResumable) {
+		t.Errorf("Resume of succeeded job = %v, want ErrNotResumable", err)
+	}
+}
+
+func TestResumeOnce(t *testing.T) {
+	r := newTestRegistry(t)
+	r.alive = func(int, string) bool { return false }
+	j, _ := r.Create("sync", nil, nil)
+	r.Finish(j.ID, errors.New("network"))
+
+	const n = 8
+	var wg sync.WaitGroup
+	var resumed atomic.Int32
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := r.Resume(j.ID); err == nil {
+				resumed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := resumed.Load(); got != 1 {
+		t.Errorf("%d concurrent Resume calls succeeded, want 1", got)
+	}
+}
+
+func TestProcessRunning(t *testing.T) {
+	pid := os.Getpid()
+	id := Identity(pid)
+	if !ProcessRunning(pid, id) {
+		t.Errorf("ProcessRunning(self, %q) = false", id)
+	}
+	if id != "" && ProcessRunning(pid, id+"-earlier") {
+		t.Error("ProcessRunning with another process's identity = true")
+	}
+}
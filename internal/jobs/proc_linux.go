@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Identity returns the boot and start time of the process with the given
+// PID, which tell it apart from a later process that reuses the PID, or
+// "" if they cannot be read.
+func Identity(pid int) string {
+	boot, err := os.ReadFile("/proc/sys/kernel/random/boot_id")
+	if err != nil {
+		return ""
+	}
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return ""
+	}
+	// The command name, in parentheses, may contain spaces; the start
+	// time is the 20th field after it.
+	i := strings.LastIndexByte(string(stat), ')')
+	if i < 0 {
+		return ""
+	}
+	fields := strings.Fields(string(stat[i+1:]))
+	if len(fields) < 20 {
+		return ""
+	}
+	return strings.TrimSpace(string(boot)) + "/" + fields[19]
+}
@@ -0,0 +1,20 @@
+//go:build !linux && !windows
+
+package jobs
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Identity returns the start time of the process with the given PID, which
+// tells it apart from a later process that reuses the PID, or "" if it
+// cannot be read.
+func Identity(pid int) string {
+	out, err := exec.Command("ps", "-o", "lstart=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,20 @@
+//go:build !windows
+
+package jobs
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// Detach makes cmd run in its own session, so it outlives the terminal it
+// was started from.
+func Detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
+
+// Alive reports whether a process with the given PID is running.
+func Alive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
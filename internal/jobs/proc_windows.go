@@ -0,0 +1,52 @@
+//go:build windows
+
+package jobs
+
+import (
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+const (
+	createNewProcessGroup = 0x00000200
+	detachedProcess       = 0x00000008
+	processQueryLimited   = 0x1000
+	stillActive           = 259
+)
+
+// Detach makes cmd run without a console, so it outlives the one it was
+// started from.
+func Detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: createNewProcessGroup | detachedProcess}
+}
+
+// Alive reports whether a process with the given PID is running.
+func Alive(pid int) bool {
+	h, err := syscall.OpenProcess(processQueryLimited, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer syscall.CloseHandle(h)
+	var code uint32
+	if err := syscall.GetExitCodeProcess(h, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
+
+// Identity returns the creation time of the process with the given PID,
+// which tells it apart from a later process that reuses the PID, or "" if
+// it cannot be read.
+func Identity(pid int) string {
+	h, err := syscall.OpenProcess(processQueryLimited, false, uint32(pid))
+	if err != nil {
+		return ""
+	}
+	defer syscall.CloseHandle(h)
+	var created, exited, kernel, user syscall.Filetime
+	if err := syscall.GetProcessTimes(h, &created, &exited, &kernel, &user); err != nil {
+		return ""
+	}
+	return strconv.FormatInt(created.Nanoseconds(), 10)
+}
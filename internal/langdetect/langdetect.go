@@ -0,0 +1,143 @@
+// Package langdetect guesses the language of a document.
+//
+// Detection is deliberately simple: the writing system identifies most
+// languages outright, and Latin-script languages are told apart by how
+// often their most common words occur. That is enough to keep documents in
+// different languages out of the same notebook, not to classify short
+// snippets.
+package langdetect
+
+import (
+	"strings"
+	"unicode"
+)
+
+// maxSample bounds how much of a document is examined.
+const maxSample = 64 << 10
+
+// minWords is the number of common-word hits needed before a Latin-script
+// guess is trusted.
+const minWords = 5
+
+// Unknown is returned when the language cannot be determined.
+const Unknown = ""
+
+var scripts = []struct {
+	table *unicode.RangeTable
+	lang  string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Arabic, "ar"},
+	{unicode.Greek, "el"},
+	{unicode.Hebrew, "he"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Thai, "th"},
+}
+
+// stopwords are frequent, mostly language-specific function words.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "it", "with", "for", "this", "are", "was", "be", "on", "not", "which", "have"},
+	"es": {"el", "la", "de", "que", "y", "en", "los", "las", "del", "se", "por", "una", "con", "para", "es", "como", "pero", "más"},
+	"fr": {"le", "la", "les", "de", "des", "et", "est", "un", "une", "du", "que", "qui", "dans", "pour", "pas", "sur", "avec", "ce"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ein", "eine", "zu", "den", "mit", "von", "sich", "auf", "für", "auch", "dem", "wird"},
+	"it": {"il", "di", "che", "e", "la", "per", "un", "una", "non", "sono", "della", "del", "con", "gli", "le", "nel", "anche", "è"},
+	"pt": {"o", "de", "que", "e", "do", "da", "em", "um", "uma", "para", "com", "não", "os", "as", "dos", "das", "por", "mais"},
+	"nl": {"de", "het", "een", "en", "van", "is", "dat", "niet", "op", "te", "voor", "met", "zijn", "ook", "maar", "bij", "wordt", "aan"},
+}
+
+var stopwordIndex = func() map[string][]string {
+	idx := make(map[string][]string)
+	for lang, words := range stopwords {
+		for _, w := range words {
+			idx[w] = append(idx[w], lang)
+		}
+	}
+	return idx
+}()
+
+// Detect returns the ISO 639-1 code of the language text is written in,
+// or Unknown.
+func Detect(text string) string {
+	if len(text) > maxSample {
+		text = text[:maxSample]
+	}
+	var letters, han, kana, latin int
+	counts := make(map[string]int)
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		default:
+			for _, s := range scripts {
+				if unicode.Is(s.table, r) {
+					counts[s.lang]++
+					break
+				}
+			}
+		}
+	}
+	if letters == 0 {
+		return Unknown
+	}
+	// Japanese mixes kana with kanji; kana alone is enough to tell it from
+	// Chinese.
+	if kana > 0 && (han+kana)*2 > letters {
+		return "ja"
+	}
+	if han*2 > letters {
+		return "zh"
+	}
+	for lang, n := range counts {
+		if n*2 > letters {
+			return lang
+		}
+	}
+	if latin*2 > letters {
+		return detectLatin(text)
+	}
+	return Unknown
+}
+
+// detectLatin scores Latin-script text by common-word frequency.
+func detectLatin(text string) string {
+	scores := make(map[string]int)
+	var hits int
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		langs := stopwordIndex[w]
+		if len(langs) == 0 {
+			continue
+		}
+		hits++
+		for _, l := range langs {
+			scores[l]++
+		}
+	}
+	if hits < minWords {
+		return Unknown
+	}
+	best, second := Unknown, 0
+	for _, lang := range []string{"en", "es", "fr", "de", "it", "pt", "nl"} {
+		switch s := scores[lang]; {
+		case best == Unknown || s > scores[best]:
+			second = scores[best]
+			best = lang
+		case s > second:
+			second = s
+		}
+	}
+	if scores[best] == second {
+		return Unknown
+	}
+	return best
+}
@@ -0,0 +1,30 @@
+package langdetect
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"empty", "", Unknown},
+		{"numbers", "123 456 789", Unknown},
+		{"english", "The results of the study show that it is not possible to separate the effects of temperature and pressure in this regime.", "en"},
+		{"spanish", "Los resultados del estudio muestran que no es posible separar los efectos de la temperatura y la presión en este régimen.", "es"},
+		{"french", "Les résultats de l'étude montrent que il est pas possible de séparer les effets de la température et de la pression dans ce régime.", "fr"},
+		{"german", "Die Ergebnisse der Studie zeigen, dass es nicht möglich ist, die Effekte von Temperatur und Druck in diesem Regime zu trennen.", "de"},
+		{"italian", "I risultati della ricerca mostrano che non è possibile separare gli effetti della temperatura e della pressione in questo regime, anche per il modello.", "it"},
+		{"portuguese", "Os resultados do estudo mostram que não é possível separar os efeitos da temperatura e da pressão neste regime, e para o modelo.", "pt"},
+		{"chinese", "研究结果表明，在这种情况下无法区分温度和压力的影响。", "zh"},
+		{"japanese", "研究の結果、この領域では温度と圧力の影響を分離することはできないことが示された。", "ja"},
+		{"korean", "연구 결과에 따르면 이 영역에서는 온도와 압력의 영향을 분리할 수 없습니다.", "ko"},
+		{"russian", "Результаты исследования показывают, что в этом режиме невозможно разделить влияние температуры и давления.", "ru"},
+		{"too short", "Hello there", Unknown},
+	}
+	for _, tt := range tests {
+		if got := Detect(tt.text); got != tt.want {
+			t.Errorf("%s: Detect = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Package linkcheck checks whether web addresses still resolve.
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Status classifies a checked link.
+type Status int
+
+const (
+	// OK links answered with a 2xx status at their original address.
+	OK Status = iota
+	// Redirected links answered with a 2xx status after redirects.
+	Redirected
+	// Dead links failed to connect or answered with an error status.
+	Dead
+)
+
+func (s Status) String() string {
+	switch s {
+	case OK:
+		return "ok"
+	case Redirected:
+		return "redirected"
+	case Dead:
+		return "dead"
+	}
+	return fmt.Sprintf("Status(%d)", int(s))
+}
+
+// Result is the outcome of checking one link.
+type Result struct {
+	URL        string
+	FinalURL   string // after redirects
+	StatusCode int    // zero if no response was received
+	Status     Status
+	Err        error
+}
+
+// maxRedirects bounds redirect chains.
+const maxRedirects = 10
+
+// Checker checks links, trying HEAD first and falling back to GET for
+// servers that reject HEAD.
+type Checker struct {
+	Client      *http.Client  // defaults to a client with Timeout
+	Timeout     time.Duration // per request; defaults to 15s
+	Concurrency int           // defaults to 4
+	UserAgent   string
+}
+
+func (c *Checker) client() *http.Client {
+	if c.Client != nil {
+		return c.Client
+	}
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = 15 * time.Second
+	}
+	return &http.Client{Timeout: timeout}
+}
+
+// Check checks a single link.
+func (c *Checker) Check(ctx context.Context, url string) Result {
+	r := Result{URL: url, FinalURL: url}
+	resp, err := c.do(ctx, http.MethodHead, url)
+	if err == nil && rejectsHead(resp.StatusCode) {
+		resp, err = c.do(ctx, http.MethodGet, url)
+	}
+	if err != nil {
+		r.Status, r.Err = Dead, err
+		return r
+	}
+	r.StatusCode = resp.StatusCode
+	r.FinalURL = resp.Request.URL.String()
+	switch {
+	case resp.StatusCode >= 400:
+		r.Status = Dead
+		r.Err = fmt.Errorf("%s", resp.Status)
+	case r.FinalURL != url:
+		r.Status = Redirected
+	default:
+		r.Status = OK
+	}
+	return r
+}
+
+// rejectsHead reports status codes some servers return for HEAD requests
+// that they would answer for GET.
+func rejectsHead(code int) bool {
+	switch code {
+	case http.StatusMethodNotAllowed, http.StatusForbidden, http.StatusNotImplemented, http.StatusNotFound:
+		return true
+	}
+	return false
+}
+
+func (c *Checker) do(ctx context.Context, method, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	client := *c.client()
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return errors.New("too many redirects")
+		}
+		return nil
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	// Drain a little of the body so the connection can be reused, without
+	// downloading whole documents.
+	io.CopyN(io.Discard, resp.Body, 4<<10)
+	resp.Body.Close()
+	return resp, nil
+}
+
+// CheckAll checks urls concurrently and returns results in the same order.
+func (c *Checker) CheckAll(ctx context.Context, urls []string) []Result {
+	n := c.Concurrency
+	if n <= 0 {
+		n = 4
+	}
+	results := make([]Result, len(urls))
+	sem := make(chan struct{}, n)
+	var wg sync.WaitGroup
+	for i, u := range urls {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, u string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = c.Check(ctx, u)
+		}(i, u)
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,59 @@
+package linkcheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckAll(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusGone)
+	})
+	mux.HandleFunc("/nohead", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	tests := []struct {
+		path   string
+		status Status
+		code   int
+		final  string
+	}{
+		{"/ok", OK, 200, "/ok"},
+		{"/moved", Redirected, 200, "/ok"},
+		{"/gone", Dead, 410, "/gone"},
+		{"/nohead", OK, 200, "/nohead"},
+		{"/loop", Dead, 0, "/loop"},
+	}
+	var urls []string
+	for _, tt := range tests {
+		urls = append(urls, srv.URL+tt.path)
+	}
+	c := &Checker{Client: srv.Client()}
+	results := c.CheckAll(context.Background(), urls)
+	for i, tt := range tests {
+		r := results[i]
+		if r.Status != tt.status || r.StatusCode != tt.code || r.FinalURL != srv.URL+tt.final {
+			t.Errorf("%s: got status %v code %d final %s (err %v); want %v %d %s",
+				tt.path, r.Status, r.StatusCode, r.FinalURL, r.Err, tt.status, tt.code, tt.final)
+		}
+	}
+
+	if r := c.Check(context.Background(), "http://127.0.0.1:1/unreachable"); r.Status != Dead || r.Err == nil {
+		t.Errorf("unreachable: got %v, %v", r.Status, r.Err)
+	}
+}
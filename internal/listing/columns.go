@@ -0,0 +1,188 @@
+package listing
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tmc/nlm/internal/table"
+)
+
+// More field names, which are columns but cannot be sorted or filtered on.
+const (
+	Emoji  = "emoji"
+	Status = "status"
+	Pinned = "pinned"
+)
+
+// A Column is a value shown by list commands: a column of their tables,
+// named with -columns, and the key of the same value in their JSON output.
+type Column struct {
+	Name string
+	// Value returns the value for JSON output, or nil if the item has
+	// none.
+	Value func(r Record) any
+	// Cell formats the value for a table.
+	Cell func(r Record) string
+}
+
+// Header is the column's table header.
+func (c Column) Header() string { return strings.ToUpper(c.Name) }
+
+// columns are all the columns, by name.
+var columns = map[string]Column{
+	ID: {
+		Name:  ID,
+		Value: func(r Record) any { return r.ID },
+		Cell:  func(r Record) string { return r.ID },
+	},
+	Title: {
+		Name:  Title,
+		Value: func(r Record) any { return r.Title },
+		// The emoji of a notebook is shown with its title, after a pin if
+		// it is pinned.
+		Cell: func(r Record) string {
+			return strings.TrimSpace(pinMark(r) + " " + strings.TrimSpace(strings.TrimSpace(r.Emoji)+" "+r.Title))
+		},
+	},
+	Emoji: {
+		Name:  Emoji,
+		Value: func(r Record) any { return nonEmpty(r.Emoji) },
+		Cell:  func(r Record) string { return r.Emoji },
+	},
+	Type: {
+		Name:  Type,
+		Value: func(r Record) any { return nonEmpty(r.Type) },
+		Cell:  func(r Record) string { return r.Type },
+	},
+	Status: {
+		Name:  Status,
+		Value: func(r Record) any { return nonEmpty(r.Status) },
+		Cell:  func(r Record) string { return r.Status },
+	},
+	Created: {
+		Name:  Created,
+		Value: func(r Record) any { return timeValue(r.Created) },
+		Cell:  func(r Record) string { return timeCell(r.Created) },
+	},
+	Modified: {
+		Name:  Modified,
+		Value: func(r Record) any { return timeValue(r.Modified) },
+		Cell:  func(r Record) string { return timeCell(r.Modified) },
+	},
+	Sources: {
+		Name:  Sources,
+		Value: func(r Record) any { return r.Sources },
+		Cell:  func(r Record) string { return strconv.Itoa(r.Sources) },
+	},
+	Pinned: {
+		Name: Pinned,
+		Value: func(r Record) any {
+			if !r.Pinned {
+				return nil
+			}
+			return true
+		},
+		Cell: pinMark,
+	},
+}
+
+func pinMark(r Record) string {
+	if r.Pinned {
+		return "📌"
+	}
+	return ""
+}
+
+func nonEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func timeValue(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func timeCell(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// Columns returns the columns named in a comma-separated list such as
+// "id,title,modified", which must be among fields, the columns the items
+// have. An empty list selects def.
+func Columns(list string, fields []string, def ...string) ([]Column, error) {
+	names := def
+	if strings.TrimSpace(list) != "" {
+		names = strings.Split(list, ",")
+	}
+	var cols []Column
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !contains(fields, name) {
+			return nil, fmt.Errorf("invalid column %q (want one of %s)", name, strings.Join(fields, ", "))
+		}
+		cols = append(cols, columns[name])
+	}
+	return cols, nil
+}
+
+// Headers returns the table headers of the columns.
+func Headers(cols []Column) []string {
+	h := make([]string, len(cols))
+	for i, c := range cols {
+		h[i] = c.Header()
+	}
+	return h
+}
+
+// Append adds a row to t for each record, with the cells of the columns.
+func Append(t *table.Table, cols []Column, records []Record) {
+	for _, r := range records {
+		cells := make([]string, len(cols))
+		for i, c := range cols {
+			cells[i] = c.Cell(r)
+		}
+		t.Append(cells...)
+	}
+}
+
+// AppendValues is Append with the plain values of JSON output rather than
+// the cells formatted for reading, for output such as CSV that is read by
+// other programs: titles without emoji, and empty cells for missing
+// values.
+func AppendValues(t *table.Table, cols []Column, records []Record) {
+	for _, r := range records {
+		cells := make([]string, len(cols))
+		for i, c := range cols {
+			if v := c.Value(r); v != nil {
+				cells[i] = fmt.Sprint(v)
+			}
+		}
+		t.Append(cells...)
+	}
+}
+
+// Objects returns the records as JSON objects with the columns as keys,
+// leaving out the values an item lacks.
+func Objects(cols []Column, records []Record) []map[string]any {
+	out := make([]map[string]any, len(records))
+	for i, r := range records {
+		obj := make(map[string]any, len(cols))
+		for _, c := range cols {
+			if v := c.Value(r); v != nil {
+				obj[c.Name] = v
+			}
+		}
+		out[i] = obj
+	}
+	return out
+}
@@ -0,0 +1,213 @@
+// Package listing sorts and filters the items of list commands, so that
+// list, sources and notes treat -sort and -filter the same way.
+//
+// Each command describes its items as Records, and names the fields they
+// have; sorting or filtering on a field the items lack is an error rather
+// than a silent no-op.
+package listing
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Field names.
+const (
+	ID       = "id"
+	Title    = "title"
+	Type     = "type"
+	Created  = "created"
+	Modified = "modified"
+	Sources  = "sources"
+)
+
+// A Record is what sorting and filtering see of an item.
+type Record struct {
+	ID       string
+	Title    string
+	Emoji    string
+	Type     string
+	Status   string
+	Created  time.Time
+	Modified time.Time
+	Sources  int
+	Pinned   bool
+}
+
+// sortKeys are the fields items can be sorted by, in the order they are
+// listed in errors.
+var sortKeys = []string{Title, Created, Modified, Sources}
+
+// less orders records by a sort key: titles alphabetically, times newest
+// first and counts largest first.
+func less(key string, a, b Record) bool {
+	switch key {
+	case Title:
+		return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+	case Created:
+		return a.Created.After(b.Created)
+	case Modified:
+		return a.Modified.After(b.Modified)
+	case Sources:
+		return a.Sources > b.Sources
+	}
+	return false
+}
+
+// A Filter matches one field of a record against a value: exactly with
+// field=value, or with a regular expression with field~=regexp. != and
+// !~= keep the records that do not match.
+type Filter struct {
+	Field  string
+	Negate bool
+	Value  string         // for an exact match
+	Re     *regexp.Regexp // for a regular expression match
+	text   string
+}
+
+// filterFields are the fields filters can match.
+var filterFields = []string{ID, Title, Type}
+
+// ParseFilter parses a filter such as title~=regexp.
+func ParseFilter(s string) (Filter, error) {
+	i := strings.IndexAny(s, "!~=")
+	if i <= 0 {
+		return Filter{}, fmt.Errorf("invalid filter %q (want field=value or field~=regexp)", s)
+	}
+	f := Filter{Field: strings.TrimSpace(s[:i]), text: s}
+	op, value := s[i:], ""
+	for _, o := range []string{"!~=", "~=", "!=", "="} {
+		if strings.HasPrefix(op, o) {
+			op, value = o, op[len(o):]
+			break
+		}
+	}
+	if !contains(filterFields, f.Field) {
+		return Filter{}, fmt.Errorf("invalid filter %q: unknown field %q (want one of %s)", s, f.Field, strings.Join(filterFields, ", "))
+	}
+	switch op {
+	case "=", "!=":
+		f.Value = value
+	case "~=", "!~=":
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return Filter{}, fmt.Errorf("invalid filter %q: %w", s, err)
+		}
+		f.Re = re
+	default:
+		return Filter{}, fmt.Errorf("invalid filter %q (want field=value or field~=regexp)", s)
+	}
+	f.Negate = op[0] == '!'
+	return f, nil
+}
+
+// Match reports whether r passes the filter.
+func (f Filter) Match(r Record) bool {
+	var v string
+	switch f.Field {
+	case ID:
+		v = r.ID
+	case Title:
+		v = r.Title
+	case Type:
+		v = r.Type
+	}
+	var ok bool
+	if f.Re != nil {
+		ok = f.Re.MatchString(v)
+	} else {
+		ok = v == f.Value
+	}
+	return ok != f.Negate
+}
+
+// Filters is a flag.Value collecting repeated filters, all of which an
+// item must pass.
+type Filters []Filter
+
+func (fs *Filters) String() string {
+	if fs == nil {
+		return ""
+	}
+	parts := make([]string, len(*fs))
+	for i, f := range *fs {
+		parts[i] = f.text
+	}
+	return strings.Join(parts, " ")
+}
+
+// Set implements flag.Value.
+func (fs *Filters) Set(s string) error {
+	f, err := ParseFilter(s)
+	if err != nil {
+		return err
+	}
+	*fs = append(*fs, f)
+	return nil
+}
+
+// Apply returns the items that pass every filter, sorted by the sort key
+// if it is not empty, with pinned items first. record describes an item,
+// and fields are the fields the items have. Sorting is stable, so items
+// that compare equal keep the order the server gave them.
+func Apply[T any](items []T, record func(T) Record, sortKey string, filters Filters, fields ...string) ([]T, error) {
+	if sortKey != "" {
+		if !contains(sortKeys, sortKey) {
+			return nil, fmt.Errorf("invalid -sort %q (want one of %s)", sortKey, strings.Join(sortKeys, ", "))
+		}
+		if !contains(fields, sortKey) {
+			return nil, fmt.Errorf("cannot sort by %s here (want one of %s)", sortKey, strings.Join(intersect(sortKeys, fields), ", "))
+		}
+	}
+	for _, f := range filters {
+		if !contains(fields, f.Field) {
+			return nil, fmt.Errorf("cannot filter by %s here (want one of %s)", f.Field, strings.Join(intersect(filterFields, fields), ", "))
+		}
+	}
+	type entry struct {
+		item T
+		rec  Record
+	}
+	var kept []entry
+	for _, it := range items {
+		r := record(it)
+		ok := true
+		for _, f := range filters {
+			ok = ok && f.Match(r)
+		}
+		if ok {
+			kept = append(kept, entry{it, r})
+		}
+	}
+	if sortKey != "" {
+		sort.SliceStable(kept, func(i, j int) bool { return less(sortKey, kept[i].rec, kept[j].rec) })
+	}
+	sort.SliceStable(kept, func(i, j int) bool { return kept[i].rec.Pinned && !kept[j].rec.Pinned })
+	out := make([]T, len(kept))
+	for i, e := range kept {
+		out[i] = e.item
+	}
+	return out, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func intersect(a, b []string) []string {
+	var out []string
+	for _, v := range a {
+		if contains(b, v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,177 @@
+package listing
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tmc/nlm/internal/table"
+)
+
+var day = time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+var records = []Record{
+	{ID: "a", Title: "beta notes", Type: "pdf", Created: day, Modified: day.AddDate(0, 0, 3), Sources: 2},
+	{ID: "b", Title: "Alpha", Type: "web", Created: day.AddDate(0, 0, 2), Modified: day.AddDate(0, 0, 1), Sources: 5},
+	{ID: "c", Title: "gamma notes", Type: "pdf", Created: day.AddDate(0, 0, 1), Modified: day.AddDate(0, 0, 1), Sources: 0},
+}
+
+var allFields = []string{ID, Title, Type, Created, Modified, Sources}
+
+func ids(rs []Record) string {
+	var s []string
+	for _, r := range rs {
+		s = append(s, r.ID)
+	}
+	return strings.Join(s, "")
+}
+
+func self(r Record) Record { return r }
+
+func TestApplySort(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"", "abc"},
+		{Title, "bac"},
+		{Created, "bca"},
+		{Modified, "abc"}, // b and c tie and keep their order
+		{Sources, "bac"},
+	}
+	for _, tt := range tests {
+		got, err := Apply(records, self, tt.key, nil, allFields...)
+		if err != nil {
+			t.Fatalf("sort %q: %v", tt.key, err)
+		}
+		if ids(got) != tt.want {
+			t.Errorf("sort %q = %s, want %s", tt.key, ids(got), tt.want)
+		}
+	}
+}
+
+func TestApplyPinned(t *testing.T) {
+	pinned := func(r Record) Record {
+		r.Pinned = r.ID == "c"
+		return r
+	}
+	for key, want := range map[string]string{"": "cab", Title: "cba", Sources: "cba"} {
+		got, err := Apply(records, pinned, key, nil, allFields...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids(got) != want {
+			t.Errorf("sort %q with c pinned = %s, want %s", key, ids(got), want)
+		}
+	}
+}
+
+func TestApplyFilter(t *testing.T) {
+	tests := []struct {
+		filters []string
+		want    string
+	}{
+		{[]string{"title~=notes$"}, "ac"},
+		{[]string{"title~=(?i)^a"}, "b"},
+		{[]string{"type=pdf"}, "ac"},
+		{[]string{"type!=pdf"}, "b"},
+		{[]string{"title!~=beta"}, "bc"},
+		{[]string{"type=pdf", "title~=gamma"}, "c"},
+		{[]string{"id=zzz"}, ""},
+	}
+	for _, tt := range tests {
+		var fs Filters
+		for _, s := range tt.filters {
+			if err := fs.Set(s); err != nil {
+				t.Fatal(err)
+			}
+		}
+		got, err := Apply(records, self, Sources, fs, allFields...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids(got) != tt.want {
+			t.Errorf("filter %v = %q, want %q", tt.filters, ids(got), tt.want)
+		}
+		if fs.String() != strings.Join(tt.filters, " ") {
+			t.Errorf("String() = %q", fs.String())
+		}
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	if _, err := Apply(records, self, "size", nil, allFields...); err == nil {
+		t.Error("unknown sort key accepted")
+	}
+	if _, err := Apply(records, self, Sources, nil, Title, Modified); err == nil || !strings.Contains(err.Error(), "title, modified") {
+		t.Errorf("sort by a missing field: err = %v, want the fields that can be used", err)
+	}
+	f, _ := ParseFilter("type=pdf")
+	if _, err := Apply(records, self, "", Filters{f}, ID, Title); err == nil {
+		t.Error("filter on a missing field accepted")
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	for _, s := range []string{"", "title", "=x", "size=3", "title~=(", "title!x", "title<3"} {
+		if _, err := ParseFilter(s); err == nil {
+			t.Errorf("ParseFilter(%q) succeeded", s)
+		}
+	}
+	f, err := ParseFilter("title~=a=b")
+	if err != nil || f.Re.String() != "a=b" || f.Negate {
+		t.Errorf("ParseFilter(title~=a=b) = %+v, %v", f, err)
+	}
+}
+
+func TestColumns(t *testing.T) {
+	fields := []string{ID, Title, Emoji, Modified, Sources}
+	cols, err := Columns("", fields, ID, Title)
+	if err != nil || len(cols) != 2 || cols[1].Name != Title {
+		t.Fatalf("default columns = %v, %v", cols, err)
+	}
+	cols, err = Columns(" sources, Modified,title ", fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(Headers(cols), " "); got != "SOURCES MODIFIED TITLE" {
+		t.Errorf("Headers = %q", got)
+	}
+	if _, err := Columns("id,type", fields); err == nil || !strings.Contains(err.Error(), `"type"`) {
+		t.Errorf("column the items lack: err = %v", err)
+	}
+
+	r := Record{ID: "nb1", Title: "Plans", Emoji: "📙", Sources: 3}
+	cols, _ = Columns("id,title,emoji,modified,sources", fields)
+	objs := Objects(cols, []Record{r})
+	want := map[string]any{"id": "nb1", "title": "Plans", "emoji": "📙", "sources": 3}
+	if len(objs) != 1 || len(objs[0]) != len(want) {
+		t.Fatalf("Objects = %v, want %v", objs, want)
+	}
+	for k, v := range want {
+		if objs[0][k] != v {
+			t.Errorf("Objects[%s] = %v, want %v", k, objs[0][k], v)
+		}
+	}
+	var cells []string
+	for _, c := range cols {
+		cells = append(cells, c.Cell(r))
+	}
+	if got := strings.Join(cells, "|"); got != "nb1|📙 Plans|📙|unknown|3" {
+		t.Errorf("cells = %q", got)
+	}
+	tbl := table.New(Headers(cols)...)
+	AppendValues(tbl, cols, []Record{r})
+	if got := strings.Join(tbl.Rows[0], "|"); got != "nb1|Plans|📙||3" {
+		t.Errorf("values = %q", got)
+	}
+
+	r.Pinned = true
+	cols, _ = Columns("title,pinned", []string{Title, Pinned})
+	if got := cols[0].Cell(r) + "|" + cols[1].Cell(r); got != "📌 📙 Plans|📌" {
+		t.Errorf("pinned cells = %q", got)
+	}
+	if objs := Objects(cols, []Record{r, {Title: "x"}}); objs[0][Pinned] != true || len(objs[1]) != 1 {
+		t.Errorf("pinned Objects = %v, want pinned only on the first", objs)
+	}
+}
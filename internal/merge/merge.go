@@ -0,0 +1,117 @@
+// Package merge performs line-based three-way merges of text, used when a
+// note was edited locally and in the notebook at the same time.
+package merge
+
+import "strings"
+
+// Conflict markers written around lines changed differently on both sides.
+const (
+	MarkerOurs   = "<<<<<<< local"
+	MarkerSep    = "======="
+	MarkerTheirs = ">>>>>>> notebook"
+)
+
+// Merge combines the changes ours and theirs made to base. Hunks changed
+// on one side only, or identically on both, merge cleanly; other hunks are
+// written with conflict markers, and their number is returned.
+func Merge(base, ours, theirs string) (string, int) {
+	b, o, t := lines(base), lines(ours), lines(theirs)
+	mo, mt := match(b, o), match(b, t)
+
+	var out []string
+	conflicts := 0
+	i, j, k := 0, 0, 0
+	for {
+		// The next base line kept by both sides anchors the hunk.
+		ni := i
+		for ni < len(b) && (mo[ni] < 0 || mt[ni] < 0) {
+			ni++
+		}
+		nj, nk := len(o), len(t)
+		if ni < len(b) {
+			nj, nk = mo[ni], mt[ni]
+		}
+		hb, ho, ht := b[i:ni], o[j:nj], t[k:nk]
+		switch {
+		case equal(ho, hb):
+			out = append(out, ht...)
+		case equal(ht, hb), equal(ho, ht):
+			out = append(out, ho...)
+		default:
+			conflicts++
+			out = append(out, MarkerOurs)
+			out = append(out, ho...)
+			out = append(out, MarkerSep)
+			out = append(out, ht...)
+			out = append(out, MarkerTheirs)
+		}
+		if ni == len(b) {
+			break
+		}
+		out = append(out, b[ni])
+		i, j, k = ni+1, nj+1, nk+1
+	}
+	merged := strings.Join(out, "\n")
+	if len(out) > 0 && strings.HasSuffix(ours, "\n") {
+		merged += "\n"
+	}
+	return merged, conflicts
+}
+
+// lines splits s into lines without their newlines. The merged text ends
+// in a newline if ours does.
+func lines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// match returns, for each line of a, the index of the line of b it is
+// paired with in a longest common subsequence, or -1.
+func match(a, b []string) []int {
+	n, m := len(a), len(b)
+	// lcs[i][j] is the LCS length of a[i:] and b[j:].
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	pairs := make([]int, n)
+	for i := range pairs {
+		pairs[i] = -1
+	}
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case a[i] == b[j]:
+			pairs[i] = j
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return pairs
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,65 @@
+package merge
+
+import "testing"
+
+func TestMerge(t *testing.T) {
+	const base = "title\none\ntwo\nthree\n"
+	tests := []struct {
+		name          string
+		ours, theirs  string
+		want          string
+		wantConflicts int
+	}{
+		{
+			name:   "unchanged",
+			ours:   base,
+			theirs: base,
+			want:   base,
+		},
+		{
+			name:   "local edit only",
+			ours:   "title\none\n2\nthree\n",
+			theirs: base,
+			want:   "title\none\n2\nthree\n",
+		},
+		{
+			name:   "notebook edit only",
+			ours:   base,
+			theirs: "title\none\ntwo\nthree\nfour\n",
+			want:   "title\none\ntwo\nthree\nfour\n",
+		},
+		{
+			name:   "edits in different places",
+			ours:   "Title\none\ntwo\nthree\n",
+			theirs: "title\none\ntwo\n3\n",
+			want:   "Title\none\ntwo\n3\n",
+		},
+		{
+			name:   "same edit on both sides",
+			ours:   "title\none\n2\nthree\n",
+			theirs: "title\none\n2\nthree\n",
+			want:   "title\none\n2\nthree\n",
+		},
+		{
+			name:          "conflicting edits",
+			ours:          "title\none\nzwei\nthree\n",
+			theirs:        "title\none\ndeux\nthree\n",
+			want:          "title\none\n<<<<<<< local\nzwei\n=======\ndeux\n>>>>>>> notebook\nthree\n",
+			wantConflicts: 1,
+		},
+		{
+			name:   "deletion and unrelated addition",
+			ours:   "title\ntwo\nthree\n",
+			theirs: "title\none\ntwo\nthree\nfour\n",
+			want:   "title\ntwo\nthree\nfour\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, n := Merge(base, tt.ours, tt.theirs)
+			if got != tt.want || n != tt.wantConflicts {
+				t.Errorf("Merge = %q (%d conflicts), want %q (%d conflicts)", got, n, tt.want, tt.wantConflicts)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+// Package ocr recognizes text in images, either with a local tesseract
+// binary or by posting the image to an HTTP OCR service.
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNoText is returned when an image contains no recognizable text.
+var ErrNoText = errors.New("no text recognized")
+
+// Extensions are the image types OCR is attempted on.
+var Extensions = []string{".png", ".jpg", ".jpeg", ".tif", ".tiff", ".bmp", ".gif", ".webp"}
+
+// IsImage reports whether name has one of Extensions.
+func IsImage(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, e := range Extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// An Engine recognizes the text in an image.
+type Engine interface {
+	Recognize(ctx context.Context, name string, r io.Reader) (string, error)
+}
+
+// Config selects an engine. It is the ocr section of .nlm.yaml.
+type Config struct {
+	// URL is an HTTP OCR service. When empty, tesseract is used.
+	URL string `yaml:"url"`
+	// Command is the tesseract binary (default "tesseract").
+	Command string `yaml:"command"`
+	// Languages are tesseract language codes, such as "eng+deu".
+	Languages string `yaml:"languages"`
+}
+
+// Engine returns the engine c describes. token, if set, is sent to HTTP
+// services as a bearer token.
+func (c Config) Engine(token string) Engine {
+	if c.URL != "" {
+		return &HTTP{URL: c.URL, Token: token}
+	}
+	return &Tesseract{Path: c.Command, Languages: c.Languages}
+}
+
+// Tesseract runs the tesseract command line tool.
+type Tesseract struct {
+	Path      string // default "tesseract"
+	Languages string // passed as -l when set
+}
+
+// Recognize runs tesseract on the image read from r.
+func (t *Tesseract) Recognize(ctx context.Context, name string, r io.Reader) (string, error) {
+	path := t.Path
+	if path == "" {
+		path = "tesseract"
+	}
+	if _, err := exec.LookPath(path); err != nil {
+		return "", fmt.Errorf("ocr: %s not found; install tesseract or set ocr.url in .nlm.yaml", path)
+	}
+	args := []string{"stdin", "stdout"}
+	if t.Languages != "" {
+		args = append(args, "-l", t.Languages)
+	}
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdin = r
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("ocr: tesseract %s: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return result(stdout.String())
+}
+
+// HTTP posts the image to an OCR service. The request body is the image
+// with its content type; the response is either plain text or a JSON
+// object with a "text" field.
+type HTTP struct {
+	URL    string
+	Token  string
+	Client *http.Client // default http.DefaultClient
+}
+
+// Recognize posts the image read from r.
+func (h *HTTP) Recognize(ctx context.Context, name string, r io.Reader) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, r)
+	if err != nil {
+		return "", fmt.Errorf("ocr: %w", err)
+	}
+	ct := mime.TypeByExtension(strings.ToLower(filepath.Ext(name)))
+	if ct == "" {
+		ct = "application/octet-stream"
+	}
+	req.Header.Set("Content-Type", ct)
+	req.Header.Set("X-Filename", filepath.Base(name))
+	if h.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.Token)
+	}
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("ocr: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return "", fmt.Errorf("ocr: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		msg := strings.TrimSpace(string(body))
+		if len(msg) > 200 {
+			msg = msg[:200]
+		}
+		return "", fmt.Errorf("ocr: %s: %s: %s", h.URL, resp.Status, msg)
+	}
+	if mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mt == "application/json" {
+		var v struct {
+			Text string `json:"text"`
+		}
+		if err := json.Unmarshal(body, &v); err != nil {
+			return "", fmt.Errorf("ocr: decode response: %w", err)
+		}
+		return result(v.Text)
+	}
+	return result(string(body))
+}
+
+// result trims recognized text, dropping the form feeds tesseract emits
+// between pages.
+func result(s string) (string, error) {
+	s = strings.TrimSpace(strings.ReplaceAll(s, "\f", "\n"))
+	if s == "" {
+		return "", ErrNoText
+	}
+	return s + "\n", nil
+}
@@ -0,0 +1,72 @@
+package ocr
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTTP(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		want        string
+		wantErr     error
+	}{
+		{"plain text", "text/plain", "WHITEBOARD\n\fplan\n", http.StatusOK, "WHITEBOARD\n\nplan\n", nil},
+		{"json", "application/json; charset=utf-8", `{"text":"hello"}`, http.StatusOK, "hello\n", nil},
+		{"empty", "text/plain", "  \n", http.StatusOK, "", ErrNoText},
+		{"server error", "text/plain", "overloaded", http.StatusServiceUnavailable, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("Content-Type"); got != "image/png" {
+					t.Errorf("Content-Type = %q, want image/png", got)
+				}
+				if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+					t.Errorf("Authorization = %q", got)
+				}
+				if body, _ := io.ReadAll(r.Body); string(body) != "PNGDATA" {
+					t.Errorf("body = %q", body)
+				}
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			}))
+			defer srv.Close()
+
+			got, err := Config{URL: srv.URL}.Engine("secret").Recognize(context.Background(), "dir/board.png", strings.NewReader("PNGDATA"))
+			switch {
+			case tt.status != http.StatusOK:
+				if err == nil || !strings.Contains(err.Error(), "overloaded") {
+					t.Fatalf("Recognize() error = %v, want server message", err)
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Recognize() error = %v, want %v", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Fatal(err)
+			case got != tt.want:
+				t.Errorf("Recognize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsImage(t *testing.T) {
+	for name, want := range map[string]bool{
+		"scan.PNG": true, "photo.jpeg": true, "notes.txt": false, "png": false,
+	} {
+		if got := IsImage(name); got != want {
+			t.Errorf("IsImage(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
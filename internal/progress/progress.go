@@ -0,0 +1,66 @@
+// Package progress writes progress as newline-delimited JSON events, for
+// programs that wrap nlm and draw their own progress bars.
+package progress
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+// Event is one step of a long-running command.
+type Event struct {
+	Time time.Time `json:"time"`
+	// Stage names the work, such as "sync" or "backup".
+	Stage string `json:"stage"`
+	// Item is what the stage is working on, such as a file or title.
+	Item string `json:"item,omitempty"`
+	// Current is the number of items done before Item; Total is the
+	// number of items, or 0 if it is not known.
+	Current int `json:"current"`
+	Total   int `json:"total,omitempty"`
+	// Percent is Current as a share of Total, rounded to one decimal. It
+	// is absent when Total is not known.
+	Percent *float64 `json:"percent,omitempty"`
+	// Done is set on the last event of a stage.
+	Done bool `json:"done,omitempty"`
+}
+
+// Reporter writes events. A nil Reporter discards them.
+type Reporter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	now func() time.Time
+}
+
+// NewReporter returns a Reporter writing one JSON event per line to w.
+func NewReporter(w io.Writer) *Reporter {
+	return &Reporter{enc: json.NewEncoder(w), now: time.Now}
+}
+
+// Step reports that stage has started on item, after current of total
+// items.
+func (r *Reporter) Step(stage, item string, current, total int) {
+	r.write(Event{Stage: stage, Item: item, Current: current, Total: total})
+}
+
+// Done reports that stage has finished all total items.
+func (r *Reporter) Done(stage string, total int) {
+	r.write(Event{Stage: stage, Current: total, Total: total, Done: true})
+}
+
+func (r *Reporter) write(e Event) {
+	if r == nil {
+		return
+	}
+	if e.Total > 0 {
+		p := math.Round(float64(e.Current)*1000/float64(e.Total)) / 10
+		e.Percent = &p
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e.Time = r.now().UTC()
+	r.enc.Encode(e)
+}
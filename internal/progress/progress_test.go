@@ -0,0 +1,32 @@
+package progress
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReporter(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewReporter(&buf)
+	r.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+	r.Step("sync", "notes/a.md", 1, 3)
+	r.Step("audio", "waiting", 0, 0)
+	r.Done("sync", 3)
+
+	want := []string{
+		`{"time":"2026-01-02T03:04:05Z","stage":"sync","item":"notes/a.md","current":1,"total":3,"percent":33.3}`,
+		`{"time":"2026-01-02T03:04:05Z","stage":"audio","item":"waiting","current":0}`,
+		`{"time":"2026-01-02T03:04:05Z","stage":"sync","current":3,"total":3,"percent":100,"done":true}`,
+	}
+	if got := strings.Split(strings.TrimSpace(buf.String()), "\n"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("events:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestNilReporter(t *testing.T) {
+	var r *Reporter
+	r.Step("sync", "x", 0, 1)
+	r.Done("sync", 1)
+}
@@ -0,0 +1,123 @@
+// Gengo generates Go code for .proto files as protoc-gen-go does, parsing
+// them with package protoparse instead of protoc, so that only the Go
+// toolchain is needed.
+//
+// Usage:
+//
+//	go run ./internal/protoparse/gengo [-I dir] [-out dir] file.proto...
+//
+// Files are named relative to -I, and their code is written under -out
+// with the same relative path, as with paths=source_relative.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/tmc/nlm/internal/protoparse"
+	"google.golang.org/protobuf/cmd/protoc-gen-go/internal_gengo"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+func main() {
+	include := flag.String("I", ".", "the directory proto files are named relative to")
+	out := flag.String("out", ".", "the directory to write generated code to")
+	flag.Parse()
+	log.SetFlags(0)
+	log.SetPrefix("gengo: ")
+	if flag.NArg() == 0 {
+		log.Fatal("usage: gengo [-I dir] [-out dir] file.proto...")
+	}
+	files, err := generate(*include, flag.Args())
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, f := range files {
+		path := filepath.Join(*out, f.GetName())
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(f.GetContent()), 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// generate returns the Go files for the proto files names, which are
+// relative to include.
+func generate(include string, names []string) ([]*pluginpb.CodeGeneratorResponse_File, error) {
+	l := &loader{include: include, files: make(map[string]*descriptorpb.FileDescriptorProto)}
+	for _, name := range names {
+		if _, err := l.load(name); err != nil {
+			return nil, err
+		}
+	}
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: names,
+		Parameter:      proto.String("paths=source_relative"),
+		ProtoFile:      l.order,
+	}
+	gen, err := protogen.Options{}.New(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range gen.Files {
+		if f.Generate {
+			internal_gengo.GenerateFile(gen, f)
+		}
+	}
+	resp := gen.Response()
+	if resp.Error != nil {
+		return nil, fmt.Errorf("generate: %s", resp.GetError())
+	}
+	return resp.File, nil
+}
+
+// A loader parses proto files and their imports, keeping them in the
+// order the generator wants: each file after its imports.
+type loader struct {
+	include string
+	files   map[string]*descriptorpb.FileDescriptorProto
+	order   []*descriptorpb.FileDescriptorProto
+	// loading holds the files being loaded, to report import cycles.
+	loading []string
+}
+
+func (l *loader) load(name string) (*descriptorpb.FileDescriptorProto, error) {
+	if f, ok := l.files[name]; ok {
+		return f, nil
+	}
+	for _, n := range l.loading {
+		if n == name {
+			return nil, fmt.Errorf("import cycle: %v", append(l.loading, name))
+		}
+	}
+	f, err := protoparse.WellKnown(name)
+	if err != nil {
+		src, rerr := os.ReadFile(filepath.Join(l.include, name))
+		if rerr != nil {
+			return nil, rerr
+		}
+		l.loading = append(l.loading, name)
+		f, err = protoparse.Parse(name, src, l.load)
+		l.loading = l.loading[:len(l.loading)-1]
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		for _, dep := range f.Dependency {
+			if _, err := l.load(dep); err != nil {
+				return nil, err
+			}
+		}
+	}
+	l.files[name] = f
+	l.order = append(l.order, f)
+	return f, nil
+}
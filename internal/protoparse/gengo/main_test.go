@@ -0,0 +1,28 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestGeneratedUpToDate checks that the checked-in code for the
+// NotebookLM protos is what gengo generates from them.
+func TestGeneratedUpToDate(t *testing.T) {
+	const name = "notebooklm/v1alpha1/notebooklm.proto"
+	files, err := generate("../../../proto", []string{name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("generate returned %d files, want 1", len(files))
+	}
+	path := filepath.Join("../../../gen", files[0].GetName())
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files[0].GetContent() != string(want) {
+		t.Errorf("%s is out of date; run go generate ./gen/...", path)
+	}
+}
@@ -0,0 +1,196 @@
+package protoparse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// An item is a token, a comment or a line break of a .proto file.
+type item struct {
+	kind itemKind
+	// text is the token, or the content of a comment: for a line comment
+	// the text after // through the line break, and for a block comment
+	// the text between /* and */.
+	text string
+	// line and col locate the start of the item, and endLine and endCol
+	// its end, all zero based; the end is exclusive, so it is on the next
+	// line for line comments and line breaks.
+	line, col       int
+	endLine, endCol int
+}
+
+type itemKind int
+
+const (
+	itemToken itemKind = iota
+	itemString
+	itemLineComment
+	itemBlockComment
+	itemNewline
+)
+
+// lex splits src into items. Identifiers keep their dots, so that
+// google.protobuf.Timestamp is one token.
+func lex(src string) ([]item, error) {
+	var items []item
+	line, col := 0, 0
+	advance := func(s string) {
+		for _, r := range s {
+			if r == '\n' {
+				line++
+				col = 0
+			} else {
+				col++
+			}
+		}
+	}
+	for i := 0; i < len(src); {
+		c := src[i]
+		start := item{line: line, col: col}
+		var n int
+		switch {
+		case c == '\n':
+			start.kind, n = itemNewline, 1
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			advance(src[i : i+1])
+			i++
+			continue
+		case strings.HasPrefix(src[i:], "//"):
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				n = len(src) - i
+			} else {
+				n = end + 1
+			}
+			start.kind, start.text = itemLineComment, src[i+2:i+n]
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("%d:%d: unterminated comment", line+1, col+1)
+			}
+			n = end + 4
+			start.kind, start.text = itemBlockComment, blockText(src[i+2:i+2+end])
+		case c == '"' || c == '\'':
+			j := i + 1
+			for j < len(src) && src[j] != c {
+				if src[j] == '\\' {
+					j++
+				}
+				if j < len(src) && src[j] == '\n' {
+					return nil, fmt.Errorf("%d:%d: unterminated string", line+1, col+1)
+				}
+				j++
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("%d:%d: unterminated string", line+1, col+1)
+			}
+			n = j + 1 - i
+			start.kind, start.text = itemString, src[i:i+n]
+		case isWordByte(c) || c == '.' && i+1 < len(src) && isWordByte(src[i+1]):
+			j := i + 1
+			for j < len(src) && (isWordByte(src[j]) || src[j] == '.') {
+				j++
+			}
+			n = j - i
+			start.kind, start.text = itemToken, src[i:j]
+		default:
+			start.kind, start.text, n = itemToken, src[i:i+1], 1
+		}
+		advance(src[i : i+n])
+		start.endLine, start.endCol = line, col
+		items = append(items, start)
+		i += n
+	}
+	return items, nil
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// blockText strips the indentation and the leading * that continue each
+// line of a block comment, as protoc does.
+func blockText(s string) string {
+	lines := strings.Split(s, "\n")
+	for i := 1; i < len(lines); i++ {
+		lines[i] = strings.TrimPrefix(strings.TrimLeft(lines[i], " \t"), "*")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// comments are the comments around a declaration, in the form of
+// descriptorpb.SourceCodeInfo_Location.
+type comments struct {
+	leading  string
+	trailing string
+	detached []string
+}
+
+// A collector gathers comments after a token as protoc's tokenizer does:
+// a comment on the line of the previous token, or a block of comments
+// after it that ends in a blank line, trails that token; the block right
+// before the next token leads it; other blocks are detached.
+type collector struct {
+	buf             strings.Builder
+	hasComment      bool
+	isLine          bool
+	canAttachToPrev bool
+	numComments     int
+
+	trailing    string
+	hasTrailing bool
+	detached    []string
+}
+
+func (c *collector) lineBuffer() *strings.Builder {
+	if c.hasComment && !c.isLine {
+		c.flush()
+	}
+	c.hasComment, c.isLine = true, true
+	return &c.buf
+}
+
+func (c *collector) blockBuffer() *strings.Builder {
+	if c.hasComment {
+		c.flush()
+	}
+	c.hasComment, c.isLine = true, false
+	return &c.buf
+}
+
+func (c *collector) clear() {
+	c.buf.Reset()
+	c.hasComment = false
+}
+
+func (c *collector) flush() {
+	if !c.hasComment {
+		return
+	}
+	if c.canAttachToPrev {
+		c.trailing += c.buf.String()
+		c.hasTrailing = true
+		c.canAttachToPrev = false
+	} else {
+		c.detached = append(c.detached, c.buf.String())
+	}
+	c.clear()
+	c.numComments++
+}
+
+// maybeDetach detaches a lone comment whose token is ambiguous.
+func (c *collector) maybeDetach() {
+	count := c.numComments
+	if c.hasComment {
+		count++
+	}
+	if count != 1 {
+		return
+	}
+	if c.hasTrailing {
+		c.detached = append([]string{c.trailing}, c.detached...)
+		c.trailing = ""
+	}
+	c.canAttachToPrev = false
+	c.flush()
+}
@@ -0,0 +1,692 @@
+// Package protoparse parses .proto files into descriptors without protoc,
+// so that Go code can be generated from them with only the Go toolchain.
+//
+// It reads the subset of proto3 that nlm's protos use: messages, enums,
+// oneofs, repeated fields, reserved numbers and names, imports and the
+// go_package option. Comments are recorded as protoc records them, so the
+// generated code carries the same documentation.
+package protoparse
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	// The well-known types, which WellKnown finds.
+	_ "google.golang.org/protobuf/types/known/anypb"
+	_ "google.golang.org/protobuf/types/known/durationpb"
+	_ "google.golang.org/protobuf/types/known/emptypb"
+	_ "google.golang.org/protobuf/types/known/fieldmaskpb"
+	_ "google.golang.org/protobuf/types/known/structpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+	_ "google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// An Importer returns the descriptor of an imported file by its import
+// path.
+type Importer func(path string) (*descriptorpb.FileDescriptorProto, error)
+
+// WellKnown imports the well-known types, such as
+// google/protobuf/timestamp.proto.
+func WellKnown(path string) (*descriptorpb.FileDescriptorProto, error) {
+	fd, err := protoregistry.GlobalFiles.FindFileByPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return protodesc.ToFileDescriptorProto(fd), nil
+}
+
+var scalarTypes = map[string]descriptorpb.FieldDescriptorProto_Type{
+	"double":   descriptorpb.FieldDescriptorProto_TYPE_DOUBLE,
+	"float":    descriptorpb.FieldDescriptorProto_TYPE_FLOAT,
+	"int64":    descriptorpb.FieldDescriptorProto_TYPE_INT64,
+	"uint64":   descriptorpb.FieldDescriptorProto_TYPE_UINT64,
+	"int32":    descriptorpb.FieldDescriptorProto_TYPE_INT32,
+	"fixed64":  descriptorpb.FieldDescriptorProto_TYPE_FIXED64,
+	"fixed32":  descriptorpb.FieldDescriptorProto_TYPE_FIXED32,
+	"bool":     descriptorpb.FieldDescriptorProto_TYPE_BOOL,
+	"string":   descriptorpb.FieldDescriptorProto_TYPE_STRING,
+	"bytes":    descriptorpb.FieldDescriptorProto_TYPE_BYTES,
+	"uint32":   descriptorpb.FieldDescriptorProto_TYPE_UINT32,
+	"sfixed32": descriptorpb.FieldDescriptorProto_TYPE_SFIXED32,
+	"sfixed64": descriptorpb.FieldDescriptorProto_TYPE_SFIXED64,
+	"sint32":   descriptorpb.FieldDescriptorProto_TYPE_SINT32,
+	"sint64":   descriptorpb.FieldDescriptorProto_TYPE_SINT64,
+}
+
+// Source code info paths, the field numbers of descriptorpb messages.
+const (
+	pathPackage     = 2
+	pathDependency  = 3
+	pathMessage     = 4
+	pathEnum        = 5
+	pathOption      = 8
+	pathSyntax      = 12
+	pathField       = 2
+	pathNested      = 3
+	pathNestedEnum  = 4
+	pathOneof       = 8
+	pathEnumValue   = 2
+	pathReserved    = 9
+	pathEnumReserve = 4
+)
+
+// Parse parses the .proto file name, whose content is src, resolving the
+// types it refers to in the file itself and in the files imports returns.
+func Parse(name string, src []byte, imports Importer) (*descriptorpb.FileDescriptorProto, error) {
+	items, err := lex(string(src))
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", name, err)
+	}
+	p := &parser{items: items, pos: -1, file: &descriptorpb.FileDescriptorProto{Name: proto.String(name)}}
+	if err := p.parse(); err != nil {
+		return nil, fmt.Errorf("%s:%w", name, err)
+	}
+	p.file.SourceCodeInfo = &descriptorpb.SourceCodeInfo{Location: p.locs}
+	if err := resolve(p.file, p.refs, imports); err != nil {
+		return nil, fmt.Errorf("%s:%w", name, err)
+	}
+	return p.file, nil
+}
+
+type parser struct {
+	items []item
+	// pos is the index of the current token in items, or len(items) at
+	// the end.
+	pos  int
+	file *descriptorpb.FileDescriptorProto
+	locs []*descriptorpb.SourceCodeInfo_Location
+	refs []typeRef
+
+	// upcomingLeading and upcomingDetached are the comments before the
+	// next declaration.
+	upcomingLeading  string
+	upcomingDetached []string
+}
+
+// A typeRef is a field whose type name is resolved once the file is read.
+type typeRef struct {
+	field *descriptorpb.FieldDescriptorProto
+	// scope is the full name of the message declaring the field.
+	scope     string
+	line, col int
+}
+
+func (p *parser) parse() error {
+	// Read the comments before the first token, which lead it.
+	c := p.nextWithComments()
+	p.upcomingLeading, p.upcomingDetached = c.leading, c.detached
+	var messages, enums int32
+	for !p.atEnd() {
+		start := p.cur()
+		switch start.text {
+		case "syntax":
+			loc := p.location(start, pathSyntax)
+			p.next()
+			if err := p.expect("="); err != nil {
+				return err
+			}
+			s, err := p.str()
+			if err != nil {
+				return err
+			}
+			if s != "proto3" {
+				return p.errorf("syntax %q is not supported", s)
+			}
+			p.file.Syntax = proto.String(s)
+			if err := p.end(";", loc); err != nil {
+				return err
+			}
+		case "package":
+			loc := p.location(start, pathPackage)
+			p.next()
+			name, err := p.ident()
+			if err != nil {
+				return err
+			}
+			p.file.Package = proto.String(name)
+			if err := p.end(";", loc); err != nil {
+				return err
+			}
+		case "import":
+			loc := p.location(start, pathDependency, int32(len(p.file.Dependency)))
+			p.next()
+			if t := p.cur().text; t == "public" || t == "weak" {
+				return p.errorf("%s imports are not supported", t)
+			}
+			path, err := p.str()
+			if err != nil {
+				return err
+			}
+			p.file.Dependency = append(p.file.Dependency, path)
+			if err := p.end(";", loc); err != nil {
+				return err
+			}
+		case "option":
+			if err := p.fileOption(); err != nil {
+				return err
+			}
+		case "message":
+			m, err := p.message(p.file.GetPackage(), []int32{pathMessage, messages})
+			if err != nil {
+				return err
+			}
+			p.file.MessageType = append(p.file.MessageType, m)
+			messages++
+		case "enum":
+			e, err := p.enum([]int32{pathEnum, enums})
+			if err != nil {
+				return err
+			}
+			p.file.EnumType = append(p.file.EnumType, e)
+			enums++
+		case ";":
+			if err := p.end(";", nil); err != nil {
+				return err
+			}
+		default:
+			return p.errorf("unexpected %q", start.text)
+		}
+	}
+	return nil
+}
+
+// fileOption parses an option statement; only go_package is supported.
+func (p *parser) fileOption() error {
+	loc := p.location(p.cur(), pathOption)
+	p.next()
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if name != "go_package" {
+		return p.errorf("option %s is not supported", name)
+	}
+	if err := p.expect("="); err != nil {
+		return err
+	}
+	v, err := p.str()
+	if err != nil {
+		return err
+	}
+	if p.file.Options == nil {
+		p.file.Options = &descriptorpb.FileOptions{}
+	}
+	p.file.Options.GoPackage = proto.String(v)
+	return p.end(";", loc)
+}
+
+// message parses a message declared in scope, with source path path.
+func (p *parser) message(scope string, path []int32) (*descriptorpb.DescriptorProto, error) {
+	loc := p.location(p.cur(), path...)
+	p.next()
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	m := &descriptorpb.DescriptorProto{Name: proto.String(name)}
+	full := scope + "." + name
+	if scope == "" {
+		full = name
+	}
+	if err := p.end("{", loc); err != nil {
+		return nil, err
+	}
+	for p.cur().text != "}" {
+		if p.atEnd() {
+			return nil, p.errorf("missing } of message %s", name)
+		}
+		switch p.cur().text {
+		case "message":
+			nested, err := p.message(full, appendPath(path, pathNested, int32(len(m.NestedType))))
+			if err != nil {
+				return nil, err
+			}
+			m.NestedType = append(m.NestedType, nested)
+		case "enum":
+			e, err := p.enum(appendPath(path, pathNestedEnum, int32(len(m.EnumType))))
+			if err != nil {
+				return nil, err
+			}
+			m.EnumType = append(m.EnumType, e)
+		case "oneof":
+			if err := p.oneof(m, full, path); err != nil {
+				return nil, err
+			}
+		case "reserved":
+			if err := p.reserved(path, pathReserved, func(start, end int32) {
+				m.ReservedRange = append(m.ReservedRange, &descriptorpb.DescriptorProto_ReservedRange{Start: proto.Int32(start), End: proto.Int32(end + 1)})
+			}, func(name string) {
+				m.ReservedName = append(m.ReservedName, name)
+			}); err != nil {
+				return nil, err
+			}
+		case "option", "map", "extensions", "extend", "optional", "required", "group":
+			return nil, p.errorf("%s is not supported", p.cur().text)
+		case ";":
+			if err := p.end(";", nil); err != nil {
+				return nil, err
+			}
+		default:
+			f, err := p.field(full, appendPath(path, pathField, int32(len(m.Field))))
+			if err != nil {
+				return nil, err
+			}
+			m.Field = append(m.Field, f)
+		}
+	}
+	return m, p.end("}", nil)
+}
+
+// oneof parses a oneof of m, whose fields are appended to m.
+func (p *parser) oneof(m *descriptorpb.DescriptorProto, scope string, path []int32) error {
+	index := int32(len(m.OneofDecl))
+	loc := p.location(p.cur(), appendPath(path, pathOneof, index)...)
+	p.next()
+	name, err := p.name()
+	if err != nil {
+		return err
+	}
+	m.OneofDecl = append(m.OneofDecl, &descriptorpb.OneofDescriptorProto{Name: proto.String(name)})
+	if err := p.end("{", loc); err != nil {
+		return err
+	}
+	for p.cur().text != "}" {
+		if p.atEnd() {
+			return p.errorf("missing } of oneof %s", name)
+		}
+		if t := p.cur().text; t == "repeated" || t == "option" {
+			return p.errorf("%s in a oneof is not supported", t)
+		}
+		f, err := p.field(scope, appendPath(path, pathField, int32(len(m.Field))))
+		if err != nil {
+			return err
+		}
+		f.OneofIndex = proto.Int32(index)
+		m.Field = append(m.Field, f)
+	}
+	return p.end("}", nil)
+}
+
+// field parses a field declared in the message scope.
+func (p *parser) field(scope string, path []int32) (*descriptorpb.FieldDescriptorProto, error) {
+	loc := p.location(p.cur(), path...)
+	f := &descriptorpb.FieldDescriptorProto{Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()}
+	if p.cur().text == "repeated" {
+		f.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		p.next()
+	}
+	typ := p.cur()
+	typeName, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := scalarTypes[typeName]; ok {
+		f.Type = t.Enum()
+	} else {
+		f.TypeName = proto.String(typeName)
+		p.refs = append(p.refs, typeRef{field: f, scope: scope, line: typ.line, col: typ.col})
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	f.Name = proto.String(name)
+	f.JsonName = proto.String(jsonName(name))
+	if err := p.expect("="); err != nil {
+		return nil, err
+	}
+	n, err := p.number()
+	if err != nil {
+		return nil, err
+	}
+	if n < 1 {
+		return nil, p.errorf("field %s has number %d", name, n)
+	}
+	f.Number = proto.Int32(n)
+	if p.cur().text == "[" {
+		return nil, p.errorf("field options are not supported")
+	}
+	return f, p.end(";", loc)
+}
+
+// enum parses an enum with source path path.
+func (p *parser) enum(path []int32) (*descriptorpb.EnumDescriptorProto, error) {
+	loc := p.location(p.cur(), path...)
+	p.next()
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	e := &descriptorpb.EnumDescriptorProto{Name: proto.String(name)}
+	if err := p.end("{", loc); err != nil {
+		return nil, err
+	}
+	for p.cur().text != "}" {
+		if p.atEnd() {
+			return nil, p.errorf("missing } of enum %s", name)
+		}
+		switch p.cur().text {
+		case "option":
+			return nil, p.errorf("enum options are not supported")
+		case "reserved":
+			if err := p.reserved(path, pathEnumReserve, func(start, end int32) {
+				e.ReservedRange = append(e.ReservedRange, &descriptorpb.EnumDescriptorProto_EnumReservedRange{Start: proto.Int32(start), End: proto.Int32(end)})
+			}, func(name string) {
+				e.ReservedName = append(e.ReservedName, name)
+			}); err != nil {
+				return nil, err
+			}
+			continue
+		case ";":
+			if err := p.end(";", nil); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		vloc := p.location(p.cur(), appendPath(path, pathEnumValue, int32(len(e.Value)))...)
+		vname, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("="); err != nil {
+			return nil, err
+		}
+		n, err := p.number()
+		if err != nil {
+			return nil, err
+		}
+		e.Value = append(e.Value, &descriptorpb.EnumValueDescriptorProto{Name: proto.String(vname), Number: proto.Int32(n)})
+		if err := p.end(";", vloc); err != nil {
+			return nil, err
+		}
+	}
+	if len(e.Value) == 0 || e.Value[0].GetNumber() != 0 {
+		return nil, p.errorf("the first value of enum %s must be zero", name)
+	}
+	return e, p.end("}", nil)
+}
+
+// reserved parses a reserved statement, passing each range, inclusive,
+// to addRange and each name to addName.
+func (p *parser) reserved(path []int32, field int32, addRange func(start, end int32), addName func(string)) error {
+	loc := p.location(p.cur(), appendPath(path, field)...)
+	p.next()
+	for {
+		if p.cur().kind == itemString {
+			s, err := p.str()
+			if err != nil {
+				return err
+			}
+			addName(s)
+		} else {
+			start, err := p.number()
+			if err != nil {
+				return err
+			}
+			end := start
+			if p.cur().text == "to" {
+				p.next()
+				if p.cur().text == "max" {
+					p.next()
+					end = 1<<29 - 1
+				} else if end, err = p.number(); err != nil {
+					return err
+				}
+			}
+			addRange(start, end)
+		}
+		if p.cur().text != "," {
+			break
+		}
+		p.next()
+	}
+	return p.end(";", loc)
+}
+
+// A location is the source location of a declaration under construction.
+type location struct {
+	loc   *descriptorpb.SourceCodeInfo_Location
+	start item
+}
+
+// location starts the location of a declaration beginning at start.
+func (p *parser) location(start item, path ...int32) *location {
+	l := &location{
+		loc:   &descriptorpb.SourceCodeInfo_Location{Path: append([]int32(nil), path...)},
+		start: start,
+	}
+	p.locs = append(p.locs, l.loc)
+	return l
+}
+
+// end consumes the token text, which ends a declaration, and reads the
+// comments after it. The comments before the declaration and those
+// trailing its end are attached to loc, if it is not nil.
+func (p *parser) end(text string, loc *location) error {
+	last := p.cur()
+	if last.text != text || last.kind != itemToken {
+		return p.errorf("want %q, got %q", text, last.text)
+	}
+	c := p.nextWithComments()
+	leading := p.upcomingLeading
+	p.upcomingLeading = c.leading
+	switch {
+	case loc != nil:
+		detached := p.upcomingDetached
+		p.upcomingDetached = c.detached
+		l := loc.loc
+		if leading != "" {
+			l.LeadingComments = proto.String(leading)
+		}
+		if c.trailing != "" {
+			l.TrailingComments = proto.String(c.trailing)
+		}
+		l.LeadingDetachedComments = detached
+		l.Span = []int32{int32(loc.start.line), int32(loc.start.col), int32(last.endLine), int32(last.endCol)}
+		if last.endLine == loc.start.line {
+			l.Span = []int32{int32(loc.start.line), int32(loc.start.col), int32(last.endCol)}
+		}
+	case text == "}":
+		p.upcomingDetached = c.detached
+	default:
+		p.upcomingDetached = append(p.upcomingDetached, c.detached...)
+	}
+	return nil
+}
+
+// nextWithComments moves to the next token, collecting the comments
+// between the current token and it as protoc's tokenizer does.
+func (p *parser) nextWithComments() comments {
+	c := &collector{canAttachToPrev: true}
+	i := p.pos + 1
+	prevLine, trailingEndLine := -1, -1
+	if p.pos < 0 {
+		c.canAttachToPrev = false
+	} else {
+		prevLine = p.items[p.pos].endLine
+		// A comment on the same line is attached to the previous token.
+		if i < len(p.items) {
+			switch it := p.items[i]; it.kind {
+			case itemLineComment:
+				c.lineBuffer().WriteString(it.text)
+				c.flush()
+				i++
+			case itemBlockComment:
+				c.blockBuffer().WriteString(it.text)
+				trailingEndLine = it.endLine
+				i++
+				if i >= len(p.items) || p.items[i].kind != itemNewline {
+					// The next token is on the same line, so the comment
+					// could belong to either; it is dropped.
+					c.clear()
+					p.pos = p.skip(i)
+					return comments{}
+				}
+				i++
+				c.flush()
+			case itemNewline:
+				i++
+			default:
+				p.pos = p.skip(i)
+				return comments{}
+			}
+		}
+	}
+	for {
+		if i >= len(p.items) {
+			c.flush()
+			p.pos = len(p.items)
+			return comments{trailing: c.trailing, detached: c.detached}
+		}
+		switch it := p.items[i]; it.kind {
+		case itemLineComment:
+			c.lineBuffer().WriteString(it.text)
+			i++
+		case itemBlockComment:
+			c.blockBuffer().WriteString(it.text)
+			i++
+			if i < len(p.items) && p.items[i].kind == itemNewline {
+				i++
+			}
+		case itemNewline:
+			// A blank line.
+			c.flush()
+			c.canAttachToPrev = false
+			i++
+		default:
+			if it.text == "}" || it.text == "]" || it.text == ")" {
+				// The end of a scope leads nothing.
+				c.flush()
+			}
+			if prevLine == it.line || trailingEndLine == it.line {
+				c.maybeDetach()
+			}
+			var leading string
+			if c.hasComment {
+				leading = c.buf.String()
+			}
+			p.pos = i
+			return comments{leading: leading, trailing: c.trailing, detached: c.detached}
+		}
+	}
+}
+
+// skip returns the index of the first token at or after i.
+func (p *parser) skip(i int) int {
+	for i < len(p.items) && p.items[i].kind != itemToken && p.items[i].kind != itemString {
+		i++
+	}
+	return i
+}
+
+func (p *parser) atEnd() bool { return p.pos >= len(p.items) }
+
+// cur returns the current token, or an empty token at the end.
+func (p *parser) cur() item {
+	if p.atEnd() {
+		return item{kind: itemToken}
+	}
+	return p.items[p.pos]
+}
+
+// next moves to the next token, skipping comments.
+func (p *parser) next() { p.pos = p.skip(p.pos + 1) }
+
+func (p *parser) expect(text string) error {
+	if t := p.cur(); t.kind != itemToken || t.text != text {
+		return p.errorf("want %q, got %q", text, t.text)
+	}
+	p.next()
+	return nil
+}
+
+// ident consumes a possibly dotted identifier.
+func (p *parser) ident() (string, error) {
+	t := p.cur()
+	if t.kind != itemToken || t.text == "" || !isWordByte(t.text[0]) && t.text[0] != '.' || t.text[0] >= '0' && t.text[0] <= '9' {
+		return "", p.errorf("want an identifier, got %q", t.text)
+	}
+	p.next()
+	return t.text, nil
+}
+
+// name consumes an identifier without dots.
+func (p *parser) name() (string, error) {
+	t := p.cur()
+	name, err := p.ident()
+	if err == nil && strings.Contains(name, ".") {
+		return "", fmt.Errorf("%d:%d: want a name, got %q", t.line+1, t.col+1, name)
+	}
+	return name, err
+}
+
+func (p *parser) str() (string, error) {
+	t := p.cur()
+	if t.kind != itemString {
+		return "", p.errorf("want a string, got %q", t.text)
+	}
+	s := t.text
+	if s[0] == '\'' {
+		s = `"` + strings.ReplaceAll(s[1:len(s)-1], `"`, `\"`) + `"`
+	}
+	v, err := strconv.Unquote(s)
+	if err != nil {
+		return "", p.errorf("bad string %s", t.text)
+	}
+	p.next()
+	return v, nil
+}
+
+func (p *parser) number() (int32, error) {
+	neg := false
+	if p.cur().text == "-" {
+		neg = true
+		p.next()
+	}
+	t := p.cur()
+	n, err := strconv.ParseInt(t.text, 0, 32)
+	if err != nil {
+		return 0, p.errorf("want a number, got %q", t.text)
+	}
+	p.next()
+	if neg {
+		n = -n
+	}
+	return int32(n), nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	t := p.cur()
+	if p.atEnd() {
+		return fmt.Errorf(" unexpected end of file: "+format, args...)
+	}
+	return fmt.Errorf("%d:%d: "+format, append([]interface{}{t.line + 1, t.col + 1}, args...)...)
+}
+
+func appendPath(path []int32, elems ...int32) []int32 {
+	return append(append([]int32(nil), path...), elems...)
+}
+
+// jsonName returns the JSON name protoc gives the field name.
+func jsonName(name string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range name {
+		switch {
+		case r == '_':
+			upper = true
+		case upper:
+			b.WriteString(strings.ToUpper(string(r)))
+			upper = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,120 @@
+package protoparse
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestParseResolvesTypes(t *testing.T) {
+	src := `syntax = "proto3";
+
+package demo.v1;
+
+import "google/protobuf/timestamp.proto";
+
+message Outer {
+  message Inner {
+    Kind kind = 1;
+  }
+  enum Kind {
+    KIND_UNKNOWN = 0;
+  }
+  Inner inner = 1;
+  repeated Outer.Inner inners = 2;
+  google.protobuf.Timestamp create_time = 3;
+  .demo.v1.Outer self = 4;
+}
+`
+	f, err := Parse("demo.proto", []byte(src), WellKnown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, fd := range f.MessageType[0].Field {
+		got = append(got, fd.GetName()+" "+fd.GetType().String()+" "+fd.GetTypeName()+" "+fd.GetJsonName())
+	}
+	for _, fd := range f.MessageType[0].NestedType[0].Field {
+		got = append(got, fd.GetName()+" "+fd.GetType().String()+" "+fd.GetTypeName()+" "+fd.GetJsonName())
+	}
+	want := []string{
+		"inner TYPE_MESSAGE .demo.v1.Outer.Inner inner",
+		"inners TYPE_MESSAGE .demo.v1.Outer.Inner inners",
+		"create_time TYPE_MESSAGE .google.protobuf.Timestamp createTime",
+		"self TYPE_MESSAGE .demo.v1.Outer self",
+		"kind TYPE_ENUM .demo.v1.Outer.Kind kind",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("fields (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseComments(t *testing.T) {
+	src := `syntax = "proto3";
+
+package demo;
+
+// Detached.
+
+// Msg leads.
+message Msg { // Msg trails.
+  /* Block
+   * comment. */
+  string a = 1; // a trails.
+
+  // b leads.
+  string b = 2;
+}
+`
+	f, err := Parse("demo.proto", []byte(src), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type loc struct {
+		Path              []int32
+		Leading, Trailing string
+		Detached          []string
+	}
+	var got []loc
+	for _, l := range f.GetSourceCodeInfo().GetLocation() {
+		if l.LeadingComments == nil && l.TrailingComments == nil && len(l.LeadingDetachedComments) == 0 {
+			continue
+		}
+		got = append(got, loc{l.Path, l.GetLeadingComments(), l.GetTrailingComments(), l.LeadingDetachedComments})
+	}
+	want := []loc{
+		{Path: []int32{4, 0}, Leading: " Msg leads.\n", Trailing: " Msg trails.\n", Detached: []string{" Detached.\n"}},
+		{Path: []int32{4, 0, 2, 0}, Leading: " Block\n comment. ", Trailing: " a trails.\n"},
+		{Path: []int32{4, 0, 2, 1}, Leading: " b leads.\n"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("comments (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"unknown type", "syntax = \"proto3\";\nmessage M {\n  Missing m = 1;\n}\n", "x.proto:3:3: unknown type Missing"},
+		{"unterminated comment", "syntax = \"proto3\";\n/* open\n", "x.proto:2:1: unterminated comment"},
+		{"truncated", "syntax = \"proto3\";\nmessage M {\n", "unexpected end of file"},
+		{"proto2", "syntax = \"proto2\";\n", "proto2"},
+		{"map", "syntax = \"proto3\";\nmessage M {\n  map<string, int32> m = 1;\n}\n", "x.proto:3:3: map is not supported"},
+		{"missing import", "syntax = \"proto3\";\nimport \"other.proto\";\n", `import "other.proto"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse("x.proto", []byte(tt.src), func(path string) (*descriptorpb.FileDescriptorProto, error) {
+				return WellKnown(path)
+			})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
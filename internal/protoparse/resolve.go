@@ -0,0 +1,94 @@
+package protoparse
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// resolve sets the full names and kinds of the types refs name, searching
+// outwards from the scope of each reference as protoc does.
+func resolve(f *descriptorpb.FileDescriptorProto, refs []typeRef, imports Importer) error {
+	types := make(map[string]descriptorpb.FieldDescriptorProto_Type)
+	addTypes(types, f.GetPackage(), f.MessageType, f.EnumType)
+	for _, path := range f.Dependency {
+		if imports == nil {
+			return fmt.Errorf(" import %q: no importer", path)
+		}
+		dep, err := imports(path)
+		if err != nil {
+			return fmt.Errorf(" import %q: %w", path, err)
+		}
+		addTypes(types, dep.GetPackage(), dep.MessageType, dep.EnumType)
+	}
+	for _, r := range refs {
+		full, ok := lookup(types, r.scope, r.field.GetTypeName())
+		if !ok {
+			return fmt.Errorf("%d:%d: unknown type %s", r.line+1, r.col+1, r.field.GetTypeName())
+		}
+		r.field.TypeName = &full
+		r.field.Type = types[full].Enum()
+	}
+	return nil
+}
+
+// addTypes records the messages and enums declared in scope, and those
+// nested in them, by full name with a leading dot.
+func addTypes(types map[string]descriptorpb.FieldDescriptorProto_Type, scope string, msgs []*descriptorpb.DescriptorProto, enums []*descriptorpb.EnumDescriptorProto) {
+	prefix := "." + scope + "."
+	if scope == "" {
+		prefix = "."
+	}
+	for _, e := range enums {
+		types[prefix+e.GetName()] = descriptorpb.FieldDescriptorProto_TYPE_ENUM
+	}
+	for _, m := range msgs {
+		full := prefix + m.GetName()
+		types[full] = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+		addTypes(types, full[1:], m.NestedType, m.EnumType)
+	}
+}
+
+// lookup finds name from the scope of a reference: a relative name is
+// tried in scope, then in each enclosing scope.
+func lookup(types map[string]descriptorpb.FieldDescriptorProto_Type, scope, name string) (string, bool) {
+	if strings.HasPrefix(name, ".") {
+		_, ok := types[name]
+		return name, ok
+	}
+	// The first component of name picks the scope; the rest must be in it.
+	first, rest, _ := strings.Cut(name, ".")
+	for {
+		candidate := "." + first
+		if scope != "" {
+			candidate = "." + scope + "." + first
+		}
+		if _, ok := types[candidate]; ok || hasScope(types, candidate) {
+			if rest != "" {
+				candidate += "." + rest
+			}
+			_, ok := types[candidate]
+			return candidate, ok
+		}
+		if scope == "" {
+			return "", false
+		}
+		if i := strings.LastIndexByte(scope, '.'); i >= 0 {
+			scope = scope[:i]
+		} else {
+			scope = ""
+		}
+	}
+}
+
+// hasScope reports whether a type is declared inside the package or
+// message prefix.
+func hasScope(types map[string]descriptorpb.FieldDescriptorProto_Type, prefix string) bool {
+	for name := range types {
+		if strings.HasPrefix(name, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
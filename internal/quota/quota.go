@@ -0,0 +1,251 @@
+// Package quota counts the requests and upload bytes nlm sends each day
+// for each browser profile, and refuses requests that would take a profile
+// over a daily budget, so that a large batch job stops on its own before
+// it risks tripping Google's abuse detection.
+//
+// The counts are kept on this machine only. They cover the requests of
+// every nlm command, but not those made from the browser or other
+// machines with the same account.
+package quota
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tmc/nlm/internal/rpc"
+)
+
+// ErrExceeded is wrapped by the errors of requests refused for going over
+// the budget.
+var ErrExceeded = errors.New("daily budget exceeded")
+
+// DateFormat is the layout of the dates of a Ledger, which are local.
+const DateFormat = "2006-01-02"
+
+// A Day counts what a profile sent on one day.
+type Day struct {
+	Requests    int   `json:"requests"`
+	UploadBytes int64 `json:"upload_bytes"`
+}
+
+func (d Day) add(e Day) Day {
+	return Day{Requests: d.Requests + e.Requests, UploadBytes: d.UploadBytes + e.UploadBytes}
+}
+
+// A Ledger holds the counts of each profile by date.
+type Ledger struct {
+	Profiles map[string]map[string]Day `json:"profiles"`
+}
+
+// Get returns the counts of profile on date.
+func (l *Ledger) Get(profile, date string) Day {
+	return l.Profiles[profile][date]
+}
+
+// Add adds d to the counts of profile on date.
+func (l *Ledger) Add(profile, date string, d Day) {
+	if l.Profiles == nil {
+		l.Profiles = make(map[string]map[string]Day)
+	}
+	if l.Profiles[profile] == nil {
+		l.Profiles[profile] = make(map[string]Day)
+	}
+	l.Profiles[profile][date] = l.Profiles[profile][date].add(d)
+}
+
+// Prune drops the counts of the days before date.
+func (l *Ledger) Prune(date string) {
+	for p, days := range l.Profiles {
+		for d := range days {
+			if d < date {
+				delete(days, d)
+			}
+		}
+		if len(days) == 0 {
+			delete(l.Profiles, p)
+		}
+	}
+}
+
+// An Entry is the counts of a profile on a date.
+type Entry struct {
+	Profile string `json:"profile"`
+	Date    string `json:"date"`
+	Day
+}
+
+// Entries returns the counts of the days since date, by profile and then
+// newest first.
+func (l *Ledger) Entries(since string) []Entry {
+	var out []Entry
+	for p, days := range l.Profiles {
+		for d, day := range days {
+			if d >= since {
+				out = append(out, Entry{Profile: p, Date: d, Day: day})
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Profile != out[j].Profile {
+			return out[i].Profile < out[j].Profile
+		}
+		return out[i].Date > out[j].Date
+	})
+	return out
+}
+
+// A Budget caps what a profile may send in a day. A zero field is not
+// capped.
+type Budget struct {
+	Requests    int
+	UploadBytes int64
+}
+
+// IsZero reports whether b caps nothing.
+func (b Budget) IsZero() bool { return b == Budget{} }
+
+// ParseBudget parses a budget such as "requests=500,upload=200MB". A bare
+// number caps requests. Sizes take the suffixes KB, MB and GB, which are
+// multiples of 1024 as in nlm's output.
+func ParseBudget(s string) (Budget, error) {
+	var b Budget
+	if strings.TrimSpace(s) == "" {
+		return b, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			k, v = "requests", k
+		}
+		switch strings.TrimSpace(k) {
+		case "requests":
+			n, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil || n < 0 {
+				return Budget{}, fmt.Errorf("budget %q: requests must be a count", s)
+			}
+			b.Requests = n
+		case "upload", "uploads":
+			n, err := ParseSize(v)
+			if err != nil {
+				return Budget{}, fmt.Errorf("budget %q: %w", s, err)
+			}
+			b.UploadBytes = n
+		default:
+			return Budget{}, fmt.Errorf("budget %q: unknown limit %q, want requests or upload", s, k)
+		}
+	}
+	return b, nil
+}
+
+// ParseSize parses a byte count such as 512, 64KB, 200MB or 1.5GB.
+func ParseSize(s string) (int64, error) {
+	t := strings.ToUpper(strings.TrimSpace(s))
+	t = strings.TrimSuffix(strings.TrimSuffix(t, "B"), "I")
+	mult := 1.0
+	if n := len(t); n > 0 {
+		if i := strings.IndexByte("KMG", t[n-1]); i >= 0 {
+			mult = float64(int64(1) << (10 * (i + 1)))
+			t = t[:n-1]
+		}
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(f * mult), nil
+}
+
+// A Store holds a Ledger shared by several processes.
+type Store interface {
+	// Update calls fn with the stored ledger and saves the ledger if fn
+	// returns nil, holding a lock across both so that concurrent updates
+	// are not lost.
+	Update(fn func(*Ledger) error) error
+}
+
+// A Tracker counts the requests of one run on top of what the profile
+// sent earlier the same day, and refuses those that would go over the
+// budget. It is safe for concurrent use.
+type Tracker struct {
+	profile string
+	budget  Budget
+	store   Store  // nil for a Tracker made by NewTracker
+	date    string // the date counted in store
+
+	mu     sync.Mutex
+	before Day
+	run    Day
+}
+
+// NewTracker returns a Tracker for profile, which has sent before today.
+func NewTracker(profile string, before Day, b Budget) *Tracker {
+	return &Tracker{profile: profile, before: before, budget: b}
+}
+
+// NewSharedTracker returns a Tracker for profile that checks each request
+// against the counts s holds for date, and adds it to them before it is
+// sent, so that processes sharing s cannot together go over the budget.
+// The requests it counts are already in s.
+func NewSharedTracker(s Store, profile, date string, b Budget) *Tracker {
+	return &Tracker{profile: profile, budget: b, store: s, date: date}
+}
+
+// Spend counts a request of size bytes for the RPCs rpcIDs, or returns an
+// error wrapping ErrExceeded without counting it if it would go over the
+// budget. Requests adding sources count as uploads.
+func (t *Tracker) Spend(rpcIDs string, size int64) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	next := Day{Requests: 1}
+	if rpcIDs == rpc.RPCAddSources {
+		next.UploadBytes = size
+	}
+	if t.store == nil {
+		if err := t.check(t.before.add(t.run), next); err != nil {
+			return err
+		}
+	} else {
+		err := t.store.Update(func(l *Ledger) error {
+			if err := t.check(l.Get(t.profile, t.date), next); err != nil {
+				return err
+			}
+			l.Add(t.profile, t.date, next)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	t.run = t.run.add(next)
+	return nil
+}
+
+// check returns an error wrapping ErrExceeded if sending next on top of
+// total would go over the budget.
+func (t *Tracker) check(total, next Day) error {
+	if b := t.budget.Requests; b > 0 && total.Requests+next.Requests > b {
+		return fmt.Errorf("%w: profile %q has sent %d of %d requests today", ErrExceeded, t.profile, total.Requests, b)
+	}
+	if b := t.budget.UploadBytes; b > 0 && total.UploadBytes+next.UploadBytes > b {
+		return fmt.Errorf("%w: profile %q has uploaded %d of %d bytes today, and the next upload is %d bytes", ErrExceeded, t.profile, total.UploadBytes, b, next.UploadBytes)
+	}
+	return nil
+}
+
+// Shared reports whether t adds requests to a Store as it counts them.
+func (t *Tracker) Shared() bool { return t.store != nil }
+
+// Run returns the counts of the requests sent so far.
+func (t *Tracker) Run() Day {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.run
+}
+
+// Today returns the local date of now, as used for the ledger.
+func Today(now time.Time) string { return now.Local().Format(DateFormat) }
@@ -0,0 +1,129 @@
+package quota
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/tmc/nlm/internal/rpc"
+)
+
+func TestParseBudget(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Budget
+	}{
+		{"", Budget{}},
+		{"500", Budget{Requests: 500}},
+		{"requests=500,upload=200MB", Budget{Requests: 500, UploadBytes: 200 << 20}},
+		{"upload=1.5GiB", Budget{UploadBytes: 3 << 29}},
+		{" upload = 64kb , requests = 10 ", Budget{Requests: 10, UploadBytes: 64 << 10}},
+		{"upload=512", Budget{UploadBytes: 512}},
+	}
+	for _, tt := range tests {
+		got, err := ParseBudget(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseBudget(%q) = %+v, %v; want %+v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"lots", "requests=-1", "upload=big", "calls=5"} {
+		if _, err := ParseBudget(in); err == nil {
+			t.Errorf("ParseBudget(%q) succeeded", in)
+		}
+	}
+}
+
+func TestTracker(t *testing.T) {
+	tr := NewTracker("Work", Day{Requests: 8, UploadBytes: 100}, Budget{Requests: 10, UploadBytes: 150})
+	if err := tr.Spend(rpc.RPCListRecentlyViewedProjects, 1000); err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	if err := tr.Spend(rpc.RPCAddSources, 60); err == nil || !errors.Is(err, ErrExceeded) {
+		t.Fatalf("upload over budget: err = %v, want ErrExceeded", err)
+	}
+	if err := tr.Spend(rpc.RPCAddSources, 50); err != nil {
+		t.Fatalf("upload within budget: %v", err)
+	}
+	err := tr.Spend(rpc.RPCListRecentlyViewedProjects, 10)
+	if !errors.Is(err, ErrExceeded) || !strings.Contains(err.Error(), `"Work"`) {
+		t.Fatalf("request over budget: err = %v, want ErrExceeded naming the profile", err)
+	}
+	if got, want := tr.Run(), (Day{Requests: 2, UploadBytes: 50}); got != want {
+		t.Errorf("Run() = %+v, want %+v", got, want)
+	}
+}
+
+// memStore is a Store kept in memory.
+type memStore struct {
+	mu     sync.Mutex
+	ledger Ledger
+}
+
+func (s *memStore) Update(fn func(*Ledger) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fn(&s.ledger)
+}
+
+func TestSharedTracker(t *testing.T) {
+	// Two runs started together must not both spend what is left.
+	s := &memStore{}
+	s.ledger.Add("Work", "2026-10-16", Day{Requests: 5})
+	budget := Budget{Requests: 10}
+	a := NewSharedTracker(s, "Work", "2026-10-16", budget)
+	b := NewSharedTracker(s, "Work", "2026-10-16", budget)
+	sent := 0
+	for i := 0; i < 4; i++ {
+		for _, tr := range []*Tracker{a, b} {
+			if err := tr.Spend(rpc.RPCListRecentlyViewedProjects, 0); err == nil {
+				sent++
+			} else if !errors.Is(err, ErrExceeded) {
+				t.Fatal(err)
+			}
+		}
+	}
+	if sent != 5 {
+		t.Errorf("sent %d requests, want the 5 left in the budget", sent)
+	}
+	if got := s.ledger.Get("Work", "2026-10-16").Requests; got != 10 {
+		t.Errorf("stored requests = %d, want 10", got)
+	}
+	if got := a.Run().Requests + b.Run().Requests; got != 5 {
+		t.Errorf("runs counted %d requests, want 5", got)
+	}
+}
+
+func TestTrackerUnlimited(t *testing.T) {
+	tr := NewTracker("Default", Day{Requests: 1 << 20}, Budget{})
+	for i := 0; i < 3; i++ {
+		if err := tr.Spend(rpc.RPCAddSources, 1<<30); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := tr.Run(); got.Requests != 3 || got.UploadBytes != 3<<30 {
+		t.Errorf("Run() = %+v", got)
+	}
+}
+
+func TestLedger(t *testing.T) {
+	var l Ledger
+	l.Add("Default", "2026-10-14", Day{Requests: 3})
+	l.Add("Default", "2026-10-16", Day{Requests: 2, UploadBytes: 10})
+	l.Add("Default", "2026-10-16", Day{Requests: 1, UploadBytes: 5})
+	l.Add("Work", "2026-09-01", Day{Requests: 7})
+	if got, want := l.Get("Default", "2026-10-16"), (Day{Requests: 3, UploadBytes: 15}); got != want {
+		t.Errorf("Get = %+v, want %+v", got, want)
+	}
+	entries := l.Entries("2026-10-01")
+	if len(entries) != 2 || entries[0].Date != "2026-10-16" || entries[1].Date != "2026-10-14" {
+		t.Errorf("Entries = %+v, want Default's two days, newest first", entries)
+	}
+	l.Prune("2026-10-01")
+	if _, ok := l.Profiles["Work"]; ok {
+		t.Error("Prune kept a profile with no recent days")
+	}
+	if len(l.Profiles["Default"]) != 2 {
+		t.Errorf("Prune dropped recent days: %+v", l.Profiles["Default"])
+	}
+}
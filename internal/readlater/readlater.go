@@ -0,0 +1,293 @@
+// Package readlater reads the articles saved to read-later services:
+// Pocket, through its API, and Instapaper, from its CSV export.
+package readlater
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// An Article is a saved page.
+type Article struct {
+	URL     string
+	Title   string
+	Excerpt string
+	Tags    []string
+	Added   time.Time
+}
+
+// HasTag reports whether the article has any of tags, compared without
+// regard to case. Every article matches an empty list.
+func (a Article) HasTag(tags []string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	for _, want := range tags {
+		for _, t := range a.Tags {
+			if strings.EqualFold(t, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DefaultPocketURL is the Pocket API endpoint.
+const DefaultPocketURL = "https://getpocket.com/v3"
+
+// Pocket is a client of the Pocket API. ConsumerKey identifies the
+// application and AccessToken the user; see RequestToken and Authorize
+// for obtaining a token.
+type Pocket struct {
+	HTTP        *http.Client // defaults to a client with a 60s timeout
+	BaseURL     string
+	UserAgent   string
+	ConsumerKey string
+	AccessToken string
+}
+
+// A PocketQuery selects saved articles.
+type PocketQuery struct {
+	Tag   string    // only articles with this tag
+	Since time.Time // only articles saved or changed since
+}
+
+// Saved returns the articles in the user's list, excluding deleted ones,
+// oldest first, and the server time to pass as Since to fetch only later
+// changes.
+func (p *Pocket) Saved(ctx context.Context, q PocketQuery) ([]Article, time.Time, error) {
+	req := map[string]interface{}{
+		"consumer_key": p.ConsumerKey,
+		"access_token": p.AccessToken,
+		"state":        "all",
+		"detailType":   "complete",
+		"sort":         "oldest",
+	}
+	if q.Tag != "" {
+		req["tag"] = q.Tag
+	}
+	if !q.Since.IsZero() {
+		req["since"] = q.Since.Unix()
+	}
+	var resp struct {
+		Since int64           `json:"since"`
+		List  json.RawMessage `json:"list"`
+	}
+	if err := p.post(ctx, "/get", req, &resp); err != nil {
+		return nil, time.Time{}, err
+	}
+	// An empty list is sent as [] rather than {}.
+	var items map[string]struct {
+		Status        string `json:"status"`
+		GivenURL      string `json:"given_url"`
+		ResolvedURL   string `json:"resolved_url"`
+		GivenTitle    string `json:"given_title"`
+		ResolvedTitle string `json:"resolved_title"`
+		Excerpt       string `json:"excerpt"`
+		TimeAdded     string `json:"time_added"`
+		Tags          map[string]struct {
+			Tag string `json:"tag"`
+		} `json:"tags"`
+	}
+	if len(resp.List) > 0 && resp.List[0] == '{' {
+		if err := json.Unmarshal(resp.List, &items); err != nil {
+			return nil, time.Time{}, fmt.Errorf("pocket: parse response: %w", err)
+		}
+	}
+	var articles []Article
+	for _, it := range items {
+		if it.Status == "2" { // deleted
+			continue
+		}
+		a := Article{
+			URL:     firstNonEmpty(it.ResolvedURL, it.GivenURL),
+			Title:   strings.TrimSpace(firstNonEmpty(it.ResolvedTitle, it.GivenTitle)),
+			Excerpt: strings.TrimSpace(it.Excerpt),
+		}
+		if sec, err := strconv.ParseInt(it.TimeAdded, 10, 64); err == nil {
+			a.Added = time.Unix(sec, 0).UTC()
+		}
+		for t := range it.Tags {
+			a.Tags = append(a.Tags, t)
+		}
+		sort.Strings(a.Tags)
+		if a.URL != "" {
+			articles = append(articles, a)
+		}
+	}
+	sortArticles(articles)
+	return articles, time.Unix(resp.Since, 0).UTC(), nil
+}
+
+// RequestToken starts authorizing the application: the user approves it
+// at AuthURL(code, redirectURI), and Authorize then exchanges code for an
+// access token.
+func (p *Pocket) RequestToken(ctx context.Context, redirectURI string) (code string, err error) {
+	var resp struct {
+		Code string `json:"code"`
+	}
+	err = p.post(ctx, "/oauth/request", map[string]interface{}{
+		"consumer_key": p.ConsumerKey,
+		"redirect_uri": redirectURI,
+	}, &resp)
+	return resp.Code, err
+}
+
+// AuthURL returns the page where the user approves a request token.
+func AuthURL(code, redirectURI string) string {
+	return "https://getpocket.com/auth/authorize?" + url.Values{
+		"request_token": {code},
+		"redirect_uri":  {redirectURI},
+	}.Encode()
+}
+
+// Authorize exchanges an approved request token for an access token.
+func (p *Pocket) Authorize(ctx context.Context, code string) (token, username string, err error) {
+	var resp struct {
+		AccessToken string `json:"access_token"`
+		Username    string `json:"username"`
+	}
+	err = p.post(ctx, "/oauth/authorize", map[string]interface{}{
+		"consumer_key": p.ConsumerKey,
+		"code":         code,
+	}, &resp)
+	return resp.AccessToken, resp.Username, err
+}
+
+func (p *Pocket) post(ctx context.Context, path string, body, v interface{}) error {
+	base := p.BaseURL
+	if base == "" {
+		base = DefaultPocketURL
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	req.Header.Set("X-Accept", "application/json")
+	if p.UserAgent != "" {
+		req.Header.Set("User-Agent", p.UserAgent)
+	}
+	client := p.HTTP
+	if client == nil {
+		client = &http.Client{Timeout: 60 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("pocket: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		// Pocket explains errors in a header rather than the body.
+		if msg := resp.Header.Get("X-Error"); msg != "" {
+			return fmt.Errorf("pocket: %s: %s", resp.Status, msg)
+		}
+		return fmt.Errorf("pocket: %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("pocket: parse response: %w", err)
+	}
+	return nil
+}
+
+// ReadInstapaperCSV reads the CSV file Instapaper exports from its
+// settings page. The folder an article is in is reported as a tag, along
+// with its tags when the export has them.
+func ReadInstapaperCSV(r io.Reader) ([]Article, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("instapaper: %w", err)
+	}
+	col := make(map[string]int)
+	for i, h := range header {
+		col[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))] = i
+	}
+	if _, ok := col["url"]; !ok {
+		return nil, errors.New("instapaper: no URL column; is this an Instapaper export?")
+	}
+	field := func(rec []string, name string) string {
+		if i, ok := col[name]; ok && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+	var articles []Article
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("instapaper: %w", err)
+		}
+		a := Article{
+			URL:     field(rec, "url"),
+			Title:   field(rec, "title"),
+			Excerpt: field(rec, "selection"),
+		}
+		if a.URL == "" {
+			continue
+		}
+		if f := field(rec, "folder"); f != "" {
+			a.Tags = append(a.Tags, f)
+		}
+		a.Tags = append(a.Tags, splitTags(field(rec, "tags"))...)
+		if sec, err := strconv.ParseInt(field(rec, "timestamp"), 10, 64); err == nil {
+			a.Added = time.Unix(sec, 0).UTC()
+		}
+		articles = append(articles, a)
+	}
+	sortArticles(articles)
+	return articles, nil
+}
+
+// splitTags splits an Instapaper tag list, written either as a JSON array
+// or separated by commas.
+func splitTags(s string) []string {
+	var tags []string
+	if strings.HasPrefix(s, "[") && json.Unmarshal([]byte(s), &tags) == nil {
+		return tags
+	}
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// sortArticles orders articles oldest first, then by URL.
+func sortArticles(a []Article) {
+	sort.SliceStable(a, func(i, j int) bool {
+		if !a[i].Added.Equal(a[j].Added) {
+			return a[i].Added.Before(a[j].Added)
+		}
+		return a[i].URL < a[j].URL
+	})
+}
+
+func firstNonEmpty(s ...string) string {
+	for _, v := range s {
+		if strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
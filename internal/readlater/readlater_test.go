@@ -0,0 +1,103 @@
+package readlater
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPocketSaved(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		if req["access_token"] != "secret" {
+			w.Header().Set("X-Error", "Invalid access token")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if req["since"] != nil {
+			w.Write([]byte(`{"status":2,"since":1700000900,"list":[]}`))
+			return
+		}
+		if req["tag"] != "go" {
+			t.Errorf("tag = %v, want go", req["tag"])
+		}
+		w.Write([]byte(`{"status":1,"since":1700000500,"list":{
+			"2":{"status":"0","given_url":"https://b.example/x","resolved_url":"https://b.example/y","given_title":"","resolved_title":"Second","time_added":"1700000200","tags":{"go":{"tag":"go"},"api":{"tag":"api"}}},
+			"1":{"status":"1","given_url":"https://a.example/","given_title":"First","excerpt":"An intro.","time_added":"1700000100"},
+			"3":{"status":"2","given_url":"https://deleted.example/","time_added":"1700000300"}}}`))
+	}))
+	defer srv.Close()
+
+	p := &Pocket{BaseURL: srv.URL, ConsumerKey: "key", AccessToken: "secret"}
+	got, since, err := p.Saved(context.Background(), PocketQuery{Tag: "go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Article{
+		{URL: "https://a.example/", Title: "First", Excerpt: "An intro.", Added: time.Unix(1700000100, 0).UTC()},
+		{URL: "https://b.example/y", Title: "Second", Tags: []string{"api", "go"}, Added: time.Unix(1700000200, 0).UTC()},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Saved mismatch (-want +got):\n%s", diff)
+	}
+	if want := time.Unix(1700000500, 0).UTC(); !since.Equal(want) {
+		t.Errorf("since = %v, want %v", since, want)
+	}
+
+	got, _, err = p.Saved(context.Background(), PocketQuery{Since: since})
+	if err != nil || len(got) != 0 {
+		t.Errorf("Saved since = %v, %v; want no articles", got, err)
+	}
+
+	p.AccessToken = "wrong"
+	if _, _, err := p.Saved(context.Background(), PocketQuery{}); err == nil || !strings.Contains(err.Error(), "Invalid access token") {
+		t.Errorf("Saved with bad token: err = %v", err)
+	}
+}
+
+func TestReadInstapaperCSV(t *testing.T) {
+	in := "URL,Title,Selection,Folder,Timestamp,Tags\n" +
+		"https://b.example/,Later,,Unread,1700000200,\"[\"\"go\"\",\"\"db\"\"]\"\n" +
+		"https://a.example/,Earlier,A quote,Archive,1700000100,\n" +
+		",No URL,,Unread,1700000300,\n"
+	got, err := ReadInstapaperCSV(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Article{
+		{URL: "https://a.example/", Title: "Earlier", Excerpt: "A quote", Tags: []string{"Archive"}, Added: time.Unix(1700000100, 0).UTC()},
+		{URL: "https://b.example/", Title: "Later", Tags: []string{"Unread", "go", "db"}, Added: time.Unix(1700000200, 0).UTC()},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ReadInstapaperCSV mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := ReadInstapaperCSV(strings.NewReader("a,b\n1,2\n")); err == nil {
+		t.Error("ReadInstapaperCSV accepted a file without a URL column")
+	}
+}
+
+func TestHasTag(t *testing.T) {
+	a := Article{Tags: []string{"Go", "databases"}}
+	for _, tt := range []struct {
+		tags []string
+		want bool
+	}{
+		{nil, true},
+		{[]string{"go"}, true},
+		{[]string{"rust", "databases"}, true},
+		{[]string{"rust"}, false},
+	} {
+		if got := a.HasTag(tt.tags); got != tt.want {
+			t.Errorf("HasTag(%q) = %v, want %v", tt.tags, got, tt.want)
+		}
+	}
+}
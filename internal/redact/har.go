@@ -0,0 +1,377 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// HARStats counts what HAR removed from a trace.
+type HARStats struct {
+	Entries int `json:"entries"`
+	// Secrets counts masked header, cookie and parameter values.
+	Secrets int `json:"secrets"`
+	// Strings counts text replaced by its length in RPC payloads.
+	Strings int `json:"strings"`
+	// Bodies counts responses that are not RPC payloads, whose content
+	// was removed.
+	Bodies int `json:"bodies"`
+}
+
+// xssiPrefix starts batchexecute responses.
+const xssiPrefix = ")]}'"
+
+var (
+	// secretHeaders are masked whatever their value.
+	secretHeaders = map[string]bool{
+		"cookie":              true,
+		"set-cookie":          true,
+		"authorization":       true,
+		"proxy-authorization": true,
+		"x-goog-authuser":     true,
+		"x-goog-api-key":      true,
+		"x-client-data":       true,
+	}
+	// secretParams are masked in query strings and form bodies.
+	secretParams = map[string]bool{"at": true, "f.sid": true}
+
+	// protocolWords appear in batchexecute envelopes.
+	protocolWords = map[string]bool{"wrb.fr": true, "di": true, "af.httprm": true, "e": true, "er": true, "generic": true}
+
+	uuidRe     = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+	numberRe   = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+	typeURLRe  = regexp.MustCompile(`^type\.googleapis\.com/[A-Za-z0-9_.]+$`)
+	mimeTypeRe = regexp.MustCompile(`^(application|audio|image|text|video)/[A-Za-z0-9.+-]+$`)
+)
+
+// HAR returns a sanitized copy of a HAR trace, such as one saved from the
+// browser's developer tools or by mitmproxy. Cookies, auth headers and
+// tokens are masked, and the text in RPC requests and responses is
+// replaced by its length, keeping the structure of the payloads: arrays,
+// numbers, booleans, nulls, IDs and the RPC IDs of each request. Other
+// response bodies, such as pages and scripts, are removed. Finally the
+// secrets of r and the built-in patterns are masked everywhere.
+func (r *Redactor) HAR(data []byte) ([]byte, HARStats, error) {
+	var stats HARStats
+	var har map[string]interface{}
+	if err := unmarshal(data, &har); err != nil {
+		return nil, stats, fmt.Errorf("har: %w", err)
+	}
+	log, ok := har["log"].(map[string]interface{})
+	if !ok {
+		return nil, stats, errors.New("har: no log object")
+	}
+	if pages, ok := log["pages"].([]interface{}); ok {
+		s := &sanitizer{stats: &stats}
+		for _, p := range pages {
+			if p, ok := p.(map[string]interface{}); ok {
+				if t, ok := p["title"].(string); ok {
+					p["title"] = s.text(t)
+				}
+			}
+		}
+	}
+	entries, _ := log["entries"].([]interface{})
+	for _, e := range entries {
+		e, ok := e.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		stats.Entries++
+		req, _ := e["request"].(map[string]interface{})
+		resp, _ := e["response"].(map[string]interface{})
+		s := &sanitizer{stats: &stats, keep: make(map[string]bool)}
+		if req != nil {
+			s.request(req)
+		}
+		if resp != nil {
+			s.response(resp)
+		}
+	}
+	out, err := marshal(har, "  ")
+	if err != nil {
+		return nil, stats, fmt.Errorf("har: %w", err)
+	}
+	return []byte(r.String(string(out))), stats, nil
+}
+
+// sanitizer cleans one entry of a trace.
+type sanitizer struct {
+	stats *HARStats
+	// keep holds strings that are kept in payloads, such as the RPC IDs
+	// of the request.
+	keep map[string]bool
+}
+
+func (s *sanitizer) request(req map[string]interface{}) {
+	if u, ok := req["url"].(string); ok {
+		req["url"] = s.url(u)
+	}
+	s.headers(req["headers"])
+	s.cookies(req["cookies"])
+	s.params(req["queryString"])
+	post, _ := req["postData"].(map[string]interface{})
+	if post == nil {
+		return
+	}
+	s.params(post["params"])
+	if text, ok := post["text"].(string); ok {
+		mime, _ := post["mimeType"].(string)
+		if strings.HasPrefix(mime, "application/x-www-form-urlencoded") {
+			post["text"] = s.form(text)
+		} else {
+			post["text"] = s.body(text)
+		}
+	}
+}
+
+func (s *sanitizer) response(resp map[string]interface{}) {
+	s.headers(resp["headers"])
+	s.cookies(resp["cookies"])
+	if u, ok := resp["redirectURL"].(string); ok && u != "" {
+		resp["redirectURL"] = s.url(u)
+	}
+	content, _ := resp["content"].(map[string]interface{})
+	text, ok := content["text"].(string)
+	if !ok || text == "" {
+		return
+	}
+	if content["encoding"] == "base64" {
+		b, err := base64.StdEncoding.DecodeString(text)
+		if err != nil || !utf8.Valid(b) {
+			delete(content, "text")
+			s.stats.Bodies++
+			return
+		}
+		text = string(b)
+		delete(content, "encoding")
+	}
+	content["text"] = s.body(text)
+}
+
+// url masks the secret parameters of u, and records its RPC IDs as
+// strings to keep.
+func (s *sanitizer) url(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		s.stats.Secrets++
+		return Mask
+	}
+	q := u.Query()
+	for _, id := range strings.Split(q.Get("rpcids"), ",") {
+		if id != "" {
+			s.keep[id] = true
+		}
+	}
+	if s.maskValues(q) {
+		u.RawQuery = q.Encode()
+	}
+	return u.String()
+}
+
+// maskValues masks the secret parameters of q, reporting whether there
+// were any.
+func (s *sanitizer) maskValues(q url.Values) bool {
+	masked := false
+	for k := range q {
+		if secretParams[k] {
+			q[k] = []string{Mask}
+			s.stats.Secrets++
+			masked = true
+		}
+	}
+	return masked
+}
+
+func (s *sanitizer) headers(v interface{}) {
+	for _, h := range objects(v) {
+		name, _ := h["name"].(string)
+		if secretHeaders[strings.ToLower(name)] {
+			h["value"] = Mask
+			s.stats.Secrets++
+		} else if strings.EqualFold(name, "referer") || strings.EqualFold(name, "location") {
+			if val, ok := h["value"].(string); ok {
+				h["value"] = s.url(val)
+			}
+		}
+	}
+}
+
+func (s *sanitizer) cookies(v interface{}) {
+	for _, c := range objects(v) {
+		c["value"] = Mask
+		s.stats.Secrets++
+	}
+}
+
+// params sanitizes HAR name and value pairs from a query string or form.
+func (s *sanitizer) params(v interface{}) {
+	for _, p := range objects(v) {
+		name, _ := p["name"].(string)
+		val, _ := p["value"].(string)
+		switch {
+		case secretParams[name]:
+			p["value"] = Mask
+			s.stats.Secrets++
+		case name == "f.req":
+			p["value"] = s.payload(val)
+		}
+	}
+}
+
+// form sanitizes a form-encoded batchexecute request body.
+func (s *sanitizer) form(text string) string {
+	q, err := url.ParseQuery(text)
+	if err != nil {
+		s.stats.Bodies++
+		return ""
+	}
+	s.maskValues(q)
+	if req := q.Get("f.req"); req != "" {
+		q.Set("f.req", s.payload(req))
+	}
+	return q.Encode()
+}
+
+// body sanitizes a request or response body: a batchexecute response,
+// with or without chunk lengths, or JSON. Anything else is removed.
+func (s *sanitizer) body(text string) string {
+	rest, prefixed := strings.CutPrefix(text, xssiPrefix)
+	dec := json.NewDecoder(strings.NewReader(rest))
+	dec.UseNumber()
+	var values []interface{}
+	chunked := false
+	for {
+		var v interface{}
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			s.stats.Bodies++
+			return ""
+		}
+		if _, ok := v.(json.Number); ok && prefixed {
+			chunked = true // a chunk length, recomputed below
+			continue
+		}
+		values = append(values, s.value(v))
+	}
+	if len(values) == 0 {
+		return text
+	}
+	var b strings.Builder
+	if prefixed {
+		b.WriteString(xssiPrefix + "\n")
+	}
+	for _, v := range values {
+		out, err := marshal(v, "")
+		if err != nil {
+			s.stats.Bodies++
+			return ""
+		}
+		chunk := string(out) + "\n"
+		if chunked {
+			b.WriteString(strconv.Itoa(len(chunk)) + "\n")
+		}
+		b.WriteString(chunk)
+	}
+	return b.String()
+}
+
+// payload sanitizes a JSON payload such as f.req.
+func (s *sanitizer) payload(text string) string {
+	var v interface{}
+	if err := unmarshal([]byte(text), &v); err != nil {
+		return s.text(text)
+	}
+	out, err := marshal(s.value(v), "")
+	if err != nil {
+		return s.text(text)
+	}
+	return string(out)
+}
+
+// value sanitizes a decoded JSON value in place.
+func (s *sanitizer) value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return s.str(v)
+	case []interface{}:
+		for i := range v {
+			v[i] = s.value(v[i])
+		}
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = s.value(e)
+		}
+	}
+	return v
+}
+
+// str sanitizes a string in a payload. Payloads nest JSON in strings, so
+// strings that hold JSON are sanitized in turn.
+func (s *sanitizer) str(v string) interface{} {
+	if t := strings.TrimSpace(v); strings.HasPrefix(t, "[") || strings.HasPrefix(t, "{") {
+		var inner interface{}
+		if unmarshal([]byte(t), &inner) == nil {
+			if out, err := marshal(s.value(inner), ""); err == nil {
+				return string(out)
+			}
+		}
+	}
+	if s.keep[v] {
+		return v
+	}
+	return s.text(v)
+}
+
+// text replaces s by its length unless it is structural: empty, an ID, a
+// number, a protocol word, a type URL or a MIME type.
+func (s *sanitizer) text(v string) string {
+	if v == "" || protocolWords[v] || uuidRe.MatchString(v) || numberRe.MatchString(v) ||
+		typeURLRe.MatchString(v) || mimeTypeRe.MatchString(v) {
+		return v
+	}
+	s.stats.Strings++
+	return fmt.Sprintf("<%d chars>", utf8.RuneCountInString(v))
+}
+
+// objects returns the JSON objects in v, an array.
+func objects(v interface{}) []map[string]interface{} {
+	a, _ := v.([]interface{})
+	var objs []map[string]interface{}
+	for _, e := range a {
+		if o, ok := e.(map[string]interface{}); ok {
+			objs = append(objs, o)
+		}
+	}
+	return objs
+}
+
+// unmarshal decodes JSON keeping numbers as they are written.
+func unmarshal(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// marshal encodes v as JSON without escaping HTML characters.
+func marshal(v interface{}, indent string) ([]byte, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", indent)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,102 @@
+package redact
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestHAR(t *testing.T) {
+	const nb = "fec1780c-5a14-4f07-8ee6-f8c3ee2930fa"
+	freq := `[[["wXbhsf","[null,1,[\"Quarterly plan\"]]",null,"generic"]]]`
+	form := url.Values{"f.req": {freq}, "at": {"AJpMio-form-token:1700"}}.Encode()
+	payload := `[["` + nb + `","Quarterly plan",[["jane.doe@example.com"]],1700000000]]`
+	envelope, _ := json.Marshal([][]interface{}{{"wrb.fr", "wXbhsf", payload, nil, nil, nil, "generic"}})
+	chunk := string(envelope) + "\n"
+	body := ")]}'\n\n" + strconv.Itoa(len(chunk)) + "\n" + chunk + "25\n[[\"e\",4,null,null,131]]\n"
+	har := map[string]interface{}{"log": map[string]interface{}{
+		"pages": []interface{}{map[string]interface{}{"title": "Quarterly plan - NotebookLM"}},
+		"entries": []interface{}{
+			map[string]interface{}{
+				"request": map[string]interface{}{
+					"url": "https://notebooklm.google.com/_/LabsTailwindUi/data/batchexecute?rpcids=wXbhsf&f.sid=-7121977511756781186&hl=en",
+					"headers": []interface{}{
+						map[string]interface{}{"name": "Cookie", "value": "SID=g.a000secretsid; NID=511=secretnid"},
+						map[string]interface{}{"name": "x-same-domain", "value": "1"},
+					},
+					"cookies":     []interface{}{map[string]interface{}{"name": "SID", "value": "g.a000secretsid"}},
+					"queryString": []interface{}{map[string]interface{}{"name": "f.sid", "value": "-7121977511756781186"}},
+					"postData": map[string]interface{}{
+						"mimeType": "application/x-www-form-urlencoded;charset=UTF-8",
+						"text":     form,
+						"params":   []interface{}{map[string]interface{}{"name": "at", "value": "AJpMio-form-token:1700"}},
+					},
+				},
+				"response": map[string]interface{}{
+					"status":  200,
+					"headers": []interface{}{map[string]interface{}{"name": "set-cookie", "value": "SIDCC=secretsidcc"}},
+					"content": map[string]interface{}{"mimeType": "application/json; charset=utf-8", "text": body},
+				},
+			},
+			map[string]interface{}{
+				"request":  map[string]interface{}{"url": "https://notebooklm.google.com/notebook/" + nb},
+				"response": map[string]interface{}{"content": map[string]interface{}{"mimeType": "text/html", "text": `<html>"SNlM0e":"AJpMio-page-token" Quarterly plan</html>`}},
+			},
+		},
+	}}
+	data, err := json.Marshal(har)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, stats, err := New("AJpMio-configured-token").HAR(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"secretsid", "secretnid", "secretsidcc", "AJpMio", "jane.doe", "Quarterly", "7121977511756781186"} {
+		if strings.Contains(string(out), secret) {
+			t.Errorf("sanitized HAR contains %q:\n%s", secret, out)
+		}
+	}
+	for _, kept := range []string{nb, "wXbhsf", "x-same-domain", "<14 chars>", "1700000000", `[\"e\",4,null,null,131]`} {
+		if !strings.Contains(string(out), kept) {
+			t.Errorf("sanitized HAR lacks %q:\n%s", kept, out)
+		}
+	}
+	if stats.Entries != 2 || stats.Bodies != 1 || stats.Secrets == 0 || stats.Strings == 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	// The chunk lengths of the response match the sanitized chunks.
+	var clean struct {
+		Log struct {
+			Entries []struct {
+				Response struct {
+					Content struct{ Text string }
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(out, &clean); err != nil {
+		t.Fatalf("sanitized HAR is not JSON: %v", err)
+	}
+	text := strings.TrimPrefix(clean.Log.Entries[0].Response.Content.Text, ")]}'\n")
+	for text != "" {
+		line, rest, _ := strings.Cut(text, "\n")
+		n, err := strconv.Atoi(line)
+		if err != nil || n > len(rest) {
+			t.Fatalf("bad chunk length %q in %q", line, text)
+		}
+		if !json.Valid([]byte(rest[:n])) {
+			t.Errorf("chunk %q is not JSON", rest[:n])
+		}
+		text = rest[n:]
+	}
+}
+
+func TestHARNotHAR(t *testing.T) {
+	if _, _, err := New().HAR([]byte(`{"entries": []}`)); err == nil {
+		t.Error("HAR of a file without a log: want error")
+	}
+}
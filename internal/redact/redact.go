@@ -0,0 +1,131 @@
+// Package redact masks credentials and personal data in text that is shown
+// to users or shared with others, such as debug logs and error reports.
+package redact
+
+import (
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Mask replaces redacted values.
+const Mask = "REDACTED"
+
+// minSecretLen avoids masking short values that would match unrelated text.
+const minSecretLen = 8
+
+var (
+	// at=<token> in form bodies and query strings, raw or URL encoded.
+	tokenRe = regexp.MustCompile(`\b(at|SNlM0e)(=|":\s*"|%3D)([^&\s"',;]+)`)
+	// Google session cookies (SID, HSID, __Secure-1PSID, SIDCC, ...).
+	cookieRe = regexp.MustCompile(`\b((?:__Secure-|__Host-)?[0-9A-Za-z_-]*(?:SID|SIDCC|SIDTS|NID|PSIDRTS)[0-9A-Za-z_-]*)=([^;\s"']+)`)
+	// Values of cookie and authorization headers.
+	headerRe = regexp.MustCompile(`(?i)\b(cookie|set-cookie|authorization|x-goog-authuser)(:\s*|"\s*:\s*"|:\s*\[)([^\r\n"\]]+)`)
+	emailRe  = regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@([A-Za-z0-9-]+\.)+[A-Za-z]{2,}\b`)
+)
+
+// Redactor masks known secrets, such as the configured auth token and
+// cookie values, in addition to anything matching the built-in patterns.
+type Redactor struct {
+	secrets []string
+}
+
+// New returns a Redactor for the given secret values. A cookie header
+// string is split into its individual values.
+func New(secrets ...string) *Redactor {
+	r := &Redactor{}
+	for _, s := range secrets {
+		r.add(s)
+		for _, pair := range strings.Split(s, ";") {
+			if _, v, ok := strings.Cut(strings.TrimSpace(pair), "="); ok {
+				r.add(v)
+			}
+		}
+	}
+	// Replace longer secrets first so a secret containing another is
+	// masked as a whole.
+	sort.Slice(r.secrets, func(i, j int) bool { return len(r.secrets[i]) > len(r.secrets[j]) })
+	return r
+}
+
+func (r *Redactor) add(s string) {
+	s = strings.TrimSpace(s)
+	if len(s) < minSecretLen {
+		return
+	}
+	for _, have := range r.secrets {
+		if have == s {
+			return
+		}
+	}
+	r.secrets = append(r.secrets, s)
+}
+
+// String returns s with secrets, session cookies, auth tokens and email
+// addresses masked. A nil Redactor applies only the built-in patterns.
+func (r *Redactor) String(s string) string {
+	if r != nil {
+		for _, secret := range r.secrets {
+			s = strings.ReplaceAll(s, secret, Mask)
+		}
+	}
+	s = headerRe.ReplaceAllStringFunc(s, func(m string) string {
+		sub := headerRe.FindStringSubmatch(m)
+		return sub[1] + sub[2] + maskPairs(sub[3])
+	})
+	s = tokenRe.ReplaceAllString(s, "${1}${2}"+Mask)
+	s = cookieRe.ReplaceAllString(s, "${1}="+Mask)
+	s = emailRe.ReplaceAllStringFunc(s, maskEmail)
+	return s
+}
+
+// String masks s using only the built-in patterns.
+func String(s string) string {
+	return (*Redactor)(nil).String(s)
+}
+
+// maskPairs masks the values of "name=value; name=value" lists, keeping the
+// names, which are useful when debugging. Values without names are masked
+// entirely.
+func maskPairs(v string) string {
+	if !strings.Contains(v, "=") {
+		if scheme, _, ok := strings.Cut(v, " "); ok {
+			return scheme + " " + Mask
+		}
+		return Mask
+	}
+	pairs := strings.Split(v, ";")
+	for i, p := range pairs {
+		if name, _, ok := strings.Cut(p, "="); ok {
+			pairs[i] = name + "=" + Mask
+		}
+	}
+	return strings.Join(pairs, ";")
+}
+
+// maskEmail keeps the domain, which helps tell personal and Workspace
+// accounts apart, and hides the local part.
+func maskEmail(addr string) string {
+	_, domain, _ := strings.Cut(addr, "@")
+	return "***@" + domain
+}
+
+// Writer returns a writer that redacts each write before passing it to w.
+// Secrets split across separate writes are not detected, so callers should
+// write whole lines or messages.
+func (r *Redactor) Writer(w io.Writer) io.Writer {
+	return &writer{r: r, w: w}
+}
+
+type writer struct {
+	r *Redactor
+	w io.Writer
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, w.r.String(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,69 @@
+package redact
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestString(t *testing.T) {
+	r := New("AJpMio-secret-token:1700000000000", "SID=g.a000abcdefgh; NID=511=abcdefghijk; PREF=tz=UTC")
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "form token",
+			in:   "f.req=%5B%5D&at=AJpMio-other-token%3A1700",
+			want: "f.req=%5B%5D&at=REDACTED",
+		},
+		{
+			name: "known secret anywhere",
+			in:   `token is "AJpMio-secret-token:1700000000000"`,
+			want: `token is "REDACTED"`,
+		},
+		{
+			name: "cookie header",
+			in:   "Cookie: [SID=g.a000abcdefgh; PREF=tz=UTC]",
+			want: "Cookie: [SID=REDACTED; PREF=REDACTED]",
+		},
+		{
+			name: "session cookie outside header",
+			in:   "__Secure-1PSID=xyz123456789; other=1",
+			want: "__Secure-1PSID=REDACTED; other=1",
+		},
+		{
+			name: "email",
+			in:   `["owner","jane.doe@example.com"]`,
+			want: `["owner","***@example.com"]`,
+		},
+		{
+			name: "authorization",
+			in:   "Authorization: Bearer ya29.abcdef",
+			want: "Authorization: Bearer REDACTED",
+		},
+		{
+			name: "no secrets",
+			in:   `[["wrb.fr","wXbhsf","[]"]]`,
+			want: `[["wrb.fr","wXbhsf","[]"]]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.String(tt.in); got != tt.want {
+				t.Errorf("String(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var b strings.Builder
+	w := New("supersecretvalue").Writer(&b)
+	if _, err := w.Write([]byte("value=supersecretvalue\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b.String(), "value=REDACTED\n"; got != want {
+		t.Errorf("Writer output = %q, want %q", got, want)
+	}
+}
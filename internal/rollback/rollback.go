@@ -0,0 +1,48 @@
+// Package rollback undoes what a command made before it failed partway, so
+// that a failed import does not leave a half-filled notebook behind.
+package rollback
+
+import (
+	"errors"
+	"fmt"
+)
+
+// A Log records how to undo each step of a command as it completes. The
+// zero Log is empty and ready to use.
+type Log struct {
+	steps []step
+}
+
+type step struct {
+	what string
+	undo func() error
+}
+
+// Add records a completed step: what it made, as in `notebook "Notes"`, and
+// how to undo it.
+func (l *Log) Add(what string, undo func() error) {
+	l.steps = append(l.steps, step{what, undo})
+}
+
+// Len returns the number of steps recorded.
+func (l *Log) Len() int { return len(l.steps) }
+
+// Undo undoes the recorded steps, most recent first, and empties the log.
+// A step that cannot be undone does not stop the others; report, if not
+// nil, is called after each with what it made and the error undoing it.
+// The errors are returned together.
+func (l *Log) Undo(report func(what string, err error)) error {
+	var errs []error
+	for i := len(l.steps) - 1; i >= 0; i-- {
+		s := l.steps[i]
+		err := s.undo()
+		if report != nil {
+			report(s.what, err)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.what, err))
+		}
+	}
+	l.steps = nil
+	return errors.Join(errs...)
+}
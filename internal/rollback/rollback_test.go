@@ -0,0 +1,44 @@
+package rollback
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestUndo(t *testing.T) {
+	var l Log
+	var undone []string
+	undo := func(name string, err error) func() error {
+		return func() error {
+			undone = append(undone, name)
+			return err
+		}
+	}
+	l.Add("notebook", undo("notebook", nil))
+	l.Add("source a", undo("source a", errors.New("gone")))
+	l.Add("source b", undo("source b", nil))
+	if l.Len() != 3 {
+		t.Fatalf("Len = %d, want 3", l.Len())
+	}
+
+	var reported []string
+	err := l.Undo(func(what string, err error) {
+		if err != nil {
+			what += " failed"
+		}
+		reported = append(reported, what)
+	})
+	if got := strings.Join(undone, ","); got != "source b,source a,notebook" {
+		t.Errorf("undone %s, want the most recent first", got)
+	}
+	if got := strings.Join(reported, ","); got != "source b,source a failed,notebook" {
+		t.Errorf("reported %s", got)
+	}
+	if err == nil || !strings.Contains(err.Error(), "source a: gone") {
+		t.Errorf("err = %v, want the step that could not be undone", err)
+	}
+	if l.Len() != 0 || l.Undo(nil) != nil {
+		t.Error("Undo left steps in the log")
+	}
+}
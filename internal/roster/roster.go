@@ -0,0 +1,120 @@
+// Package roster reads CSV rosters of people to share a notebook with.
+//
+// Each row holds an email address and, optionally, a role: viewer (the
+// default; also reader) or editor (also writer). A header row naming the
+// columns "email" and "role" may come first, in which case the columns
+// can appear in any order and other columns are ignored.
+package roster
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"strings"
+
+	"github.com/tmc/nlm/internal/api"
+)
+
+// Row is one person from the roster. Rows that could not be read carry
+// Err and should be reported rather than shared.
+type Row struct {
+	Line  int
+	Email string
+	Role  api.Role
+	Err   error
+}
+
+// Read parses a roster. Blank lines and lines starting with # are
+// skipped, as are repeated addresses after the first.
+func Read(r io.Reader) ([]Row, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.Comment = '#'
+	cr.TrimLeadingSpace = true
+
+	emailCol, roleCol := 0, 1
+	seen := make(map[string]bool)
+	var rows []Row
+	for first := true; ; first = false {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read roster: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		if first && isHeader(rec) {
+			emailCol, roleCol = -1, -1
+			for i, name := range rec {
+				switch strings.ToLower(strings.TrimSpace(name)) {
+				case "email", "e-mail", "address":
+					emailCol = i
+				case "role", "access", "permission":
+					roleCol = i
+				}
+			}
+			if emailCol < 0 {
+				return nil, fmt.Errorf("read roster: line %d: header has no email column", line)
+			}
+			continue
+		}
+		row := parseRow(rec, emailCol, roleCol)
+		row.Line = line
+		if row.Err == nil {
+			key := strings.ToLower(row.Email)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// isHeader reports whether rec names columns rather than holding an
+// address.
+func isHeader(rec []string) bool {
+	for _, f := range rec {
+		if strings.Contains(f, "@") {
+			return false
+		}
+	}
+	return true
+}
+
+func parseRow(rec []string, emailCol, roleCol int) Row {
+	var row Row
+	if emailCol >= len(rec) || strings.TrimSpace(rec[emailCol]) == "" {
+		row.Err = errors.New("missing email address")
+		return row
+	}
+	addr, err := mail.ParseAddress(strings.TrimSpace(rec[emailCol]))
+	if err != nil {
+		row.Email = strings.TrimSpace(rec[emailCol])
+		row.Err = fmt.Errorf("invalid email address %q", row.Email)
+		return row
+	}
+	row.Email = addr.Address
+	row.Role = api.RoleViewer
+	if roleCol >= 0 && roleCol < len(rec) {
+		if row.Role, err = ParseRole(rec[roleCol]); err != nil {
+			row.Err = err
+		}
+	}
+	return row
+}
+
+// ParseRole parses a role name; an empty name is a viewer.
+func ParseRole(s string) (api.Role, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "viewer", "reader", "view", "read":
+		return api.RoleViewer, nil
+	case "editor", "writer", "edit", "write":
+		return api.RoleEditor, nil
+	}
+	return api.RoleUnknown, fmt.Errorf("unknown role %q (want viewer or editor)", strings.TrimSpace(s))
+}
@@ -0,0 +1,72 @@
+package roster
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tmc/nlm/internal/api"
+)
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []string // "line email role" or "line error"
+	}{
+		{
+			name: "no header",
+			in:   "ada@example.com,editor\nbob@example.com\n\n# comment\ncy@example.com, Viewer\n",
+			want: []string{"1 ada@example.com editor", "2 bob@example.com viewer", "5 cy@example.com viewer"},
+		},
+		{
+			name: "header with columns reordered",
+			in:   "Name,Role,Email\nAda,writer,ada@example.com\nBob,,\"Bob <bob@example.com>\"\n",
+			want: []string{"2 ada@example.com editor", "3 bob@example.com viewer"},
+		},
+		{
+			name: "bad rows are kept with errors",
+			in:   "ada@example.com,owner\nnot-an-address,viewer\n,editor\n",
+			want: []string{`1 unknown role "owner" (want viewer or editor)`, `2 invalid email address "not-an-address"`, "3 missing email address"},
+		},
+		{
+			name: "duplicates dropped",
+			in:   "ada@example.com\nADA@example.com,editor\n",
+			want: []string{"1 ada@example.com viewer"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := Read(strings.NewReader(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range rows {
+				if r.Err != nil {
+					got = append(got, strings.Join([]string{strconv.Itoa(r.Line), r.Err.Error()}, " "))
+					continue
+				}
+				got = append(got, strings.Join([]string{strconv.Itoa(r.Line), r.Email, r.Role.String()}, " "))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Read =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestReadHeaderWithoutEmail(t *testing.T) {
+	if _, err := Read(strings.NewReader("name,role\nAda,editor\n")); err == nil {
+		t.Error("Read accepted a header without an email column")
+	}
+}
+
+func TestParseRole(t *testing.T) {
+	if r, err := ParseRole("Editor"); err != nil || r != api.RoleEditor {
+		t.Errorf("ParseRole(Editor) = %v, %v", r, err)
+	}
+	if _, err := ParseRole("owner"); err == nil {
+		t.Error("ParseRole accepted owner")
+	}
+}
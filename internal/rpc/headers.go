@@ -0,0 +1,31 @@
+package rpc
+
+import "runtime"
+
+// ChromeVersion is the major Chrome version the default headers claim.
+const ChromeVersion = "131"
+
+// DefaultHeaders returns the User-Agent and client hint (sec-ch-*) headers
+// of desktop Chrome on the current operating system. New sends them with
+// every request unless an option replaces them. They must agree with each
+// other: requests whose hints contradict the User-Agent occasionally get
+// empty responses instead of errors.
+func DefaultHeaders() map[string]string {
+	return defaultHeaders(runtime.GOOS)
+}
+
+func defaultHeaders(goos string) map[string]string {
+	platform, system := "Linux", "X11; Linux x86_64"
+	switch goos {
+	case "darwin":
+		platform, system = "macOS", "Macintosh; Intel Mac OS X 10_15_7"
+	case "windows":
+		platform, system = "Windows", "Windows NT 10.0; Win64; x64"
+	}
+	return map[string]string{
+		"user-agent":         "Mozilla/5.0 (" + system + ") AppleWebKit/537.36 (KHTML, like Gecko) Chrome/" + ChromeVersion + ".0.0.0 Safari/537.36",
+		"sec-ch-ua":          `"Google Chrome";v="` + ChromeVersion + `", "Chromium";v="` + ChromeVersion + `", "Not_A Brand";v="24"`,
+		"sec-ch-ua-mobile":   "?0",
+		"sec-ch-ua-platform": `"` + platform + `"`,
+	}
+}
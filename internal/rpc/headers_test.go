@@ -0,0 +1,32 @@
+package rpc
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultHeadersAgree(t *testing.T) {
+	for goos, system := range map[string]string{
+		"darwin":  "Macintosh",
+		"windows": "Windows NT",
+		"linux":   "Linux",
+		"freebsd": "Linux",
+	} {
+		h := defaultHeaders(goos)
+		if !strings.Contains(h["user-agent"], system) {
+			t.Errorf("%s: user-agent %q does not name %s", goos, h["user-agent"], system)
+		}
+		if !strings.Contains(h["user-agent"], "Chrome/"+ChromeVersion+".") || !strings.Contains(h["sec-ch-ua"], `v="`+ChromeVersion+`"`) {
+			t.Errorf("%s: user-agent and sec-ch-ua claim different versions: %q, %q", goos, h["user-agent"], h["sec-ch-ua"])
+		}
+	}
+}
+
+func TestNewSendsDefaultHeaders(t *testing.T) {
+	c := New("token", "cookies")
+	for k, v := range DefaultHeaders() {
+		if got := c.Config.Headers[k]; got != v {
+			t.Errorf("header %s = %q, want %q", k, got, v)
+		}
+	}
+}
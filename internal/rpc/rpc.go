@@ -1,8 +1,10 @@
 package rpc
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"os"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/tmc/nlm/internal/batchexecute"
@@ -78,11 +80,15 @@ type Call struct {
 
 // Client handles NotebookLM RPC communication
 type Client struct {
-	Config batchexecute.Config
-	client *batchexecute.Client
+	Config      batchexecute.Config
+	client      *batchexecute.Client
+	checkArgs   bool
+	onResponse  func(id string, data json.RawMessage)
+	beforeWrite func(call Call) error
+	onWrite     func(call Call)
+	ctx         context.Context
 }
 
-// New creates a new NotebookLM RPC client
 // New creates a new NotebookLM RPC client
 func New(authToken, cookies string, options ...batchexecute.Option) *Client {
 	config := batchexecute.Config{
@@ -108,20 +114,94 @@ func New(authToken, cookies string, options ...batchexecute.Option) *Client {
 			//"rt":    "c",
 		},
 	}
+	for k, v := range DefaultHeaders() {
+		config.Headers[k] = v
+	}
+	// Options may change the configuration (e.g. WithDebug), so take it back
+	// from the batchexecute client rather than keeping the local copy.
+	client := batchexecute.NewClient(config, options...)
 	return &Client{
-		Config: config,
-		client: batchexecute.NewClient(config, options...),
+		Config: client.Config(),
+		client: client,
 	}
 }
 
+// Redact masks credentials and email addresses in s for display.
+func (c *Client) Redact(s string) string {
+	return c.client.Redact(s)
+}
+
+// LastExchange returns the most recent HTTP round trip, for diagnostics.
+func (c *Client) LastExchange() *batchexecute.Exchange {
+	return c.client.LastExchange()
+}
+
+// Usage returns the requests sent so far, by RPC ID.
+func (c *Client) Usage() batchexecute.Usage {
+	return c.client.Usage()
+}
+
+// SetCheckArgs makes Do check the arguments of each call against
+// ArgSchemas and fail before sending a call that does not match.
+func (c *Client) SetCheckArgs(check bool) {
+	c.checkArgs = check
+}
+
+// OnResponse registers fn to be called with the data of every successful
+// call.
+func (c *Client) OnResponse(fn func(id string, data json.RawMessage)) {
+	c.onResponse = fn
+}
+
+// WithContext returns a copy of c whose calls are bounded by ctx. The
+// copy shares c's connection, hooks and usage counts.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	cp := *c
+	cp.ctx = ctx
+	return &cp
+}
+
+// BeforeWrite registers fn to be called before each call that may change
+// data (see Writes) is sent. If fn returns an error, the call is not sent
+// and Do returns that error.
+func (c *Client) BeforeWrite(fn func(call Call) error) {
+	c.beforeWrite = fn
+}
+
+// OnWrite registers fn to be called after each call that may change data
+// was sent, whether or not it succeeded, since a failed call may still
+// have been applied in part.
+func (c *Client) OnWrite(fn func(call Call)) {
+	c.onWrite = fn
+}
+
+// debugf writes redacted debug output to stderr.
+func (c *Client) debugf(format string, args ...interface{}) {
+	fmt.Fprint(os.Stderr, c.Redact(fmt.Sprintf(format, args...)))
+}
+
 // Do executes a NotebookLM RPC call
 func (c *Client) Do(call Call) (json.RawMessage, error) {
 	if c.Config.Debug {
-		fmt.Printf("\n=== RPC Call ===\n")
-		fmt.Printf("ID: %s\n", call.ID)
-		fmt.Printf("NotebookID: %s\n", call.NotebookID)
-		fmt.Printf("Args:\n")
-		spew.Dump(call.Args)
+		c.debugf("\n=== RPC Call ===\n")
+		c.debugf("ID: %s\n", call.ID)
+		c.debugf("NotebookID: %s\n", call.NotebookID)
+		c.debugf("Args:\n%s", spew.Sdump(call.Args))
+	}
+	if c.checkArgs {
+		if err := CheckArgs(call.ID, call.Args); err != nil {
+			return nil, err
+		}
+	}
+	if Writes(call.ID) {
+		if c.beforeWrite != nil {
+			if err := c.beforeWrite(call); err != nil {
+				return nil, err
+			}
+		}
+		if c.onWrite != nil {
+			defer c.onWrite(call)
+		}
 	}
 
 	// Create request-specific URL parameters
@@ -141,11 +221,11 @@ func (c *Client) Do(call Call) (json.RawMessage, error) {
 		Args:      call.Args,
 		Index:     "generic",
 		URLParams: urlParams,
+		Context:   c.ctx,
 	}
 
 	if c.Config.Debug {
-		fmt.Printf("\nRPC Request:\n")
-		spew.Dump(rpc)
+		c.debugf("\nRPC Request:\n%s", spew.Sdump(rpc))
 	}
 
 	resp, err := c.client.Do(rpc)
@@ -154,8 +234,13 @@ func (c *Client) Do(call Call) (json.RawMessage, error) {
 	}
 
 	if c.Config.Debug {
-		fmt.Printf("\nRPC Response:\n")
-		spew.Dump(resp)
+		c.debugf("\nRPC Response:\n%s", spew.Sdump(resp))
+	}
+	if err := resp.Err(); err != nil {
+		return nil, fmt.Errorf("execute rpc: %w", err)
+	}
+	if c.onResponse != nil {
+		c.onResponse(call.ID, resp.Data)
 	}
 
 	return resp.Data, nil
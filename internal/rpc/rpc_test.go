@@ -0,0 +1,40 @@
+package rpc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tmc/nlm/internal/batchexecute"
+)
+
+func TestWriteHooks(t *testing.T) {
+	// Refuse every request in the transport, so nothing is sent.
+	c := New("token", "cookies", batchexecute.WithReadOnly(func(string) bool { return true }))
+	refused := errors.New("refused")
+	var checked, written []string
+	c.BeforeWrite(func(call Call) error {
+		checked = append(checked, call.NotebookID)
+		if call.NotebookID == "viewer" {
+			return refused
+		}
+		return nil
+	})
+	c.OnWrite(func(call Call) { written = append(written, call.NotebookID) })
+
+	c.Do(Call{ID: RPCGetProject, NotebookID: "viewer"})
+	if len(checked) != 0 || len(written) != 0 {
+		t.Fatalf("read call ran write hooks: checked %q, written %q", checked, written)
+	}
+	if _, err := c.Do(Call{ID: RPCDeleteSources, NotebookID: "viewer"}); !errors.Is(err, refused) {
+		t.Fatalf("refused write: err = %v, want %v", err, refused)
+	}
+	if len(written) != 0 {
+		t.Fatalf("refused write reported as written: %q", written)
+	}
+	if _, err := c.Do(Call{ID: RPCDeleteSources, NotebookID: "editor"}); err == nil {
+		t.Fatal("write sent through a transport refusing it")
+	}
+	if len(written) != 1 || written[0] != "editor" {
+		t.Errorf("failed write: written = %q, want [editor]", written)
+	}
+}
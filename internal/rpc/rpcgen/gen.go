@@ -0,0 +1,474 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tmc/nlm/internal/rpc"
+)
+
+// generate returns the source of package rpctypes, naming each RPC after
+// its ID constant in rpcFile.
+func generate(rpcFile string) ([]byte, error) {
+	names, err := rpcNames(rpcFile)
+	if err != nil {
+		return nil, err
+	}
+	g := &generator{types: make(map[string]string)}
+	fmt.Fprintf(&g.buf, "// Code generated by rpcgen from rpc.ArgSchemas and rpc.ResultSchemas; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&g.buf, "package rpctypes\n\n")
+	fmt.Fprintf(&g.buf, "import (\n\t\"encoding/json\"\n\t\"fmt\"\n\n\t\"github.com/tmc/nlm/internal/rpc\"\n)\n")
+	for _, set := range []struct {
+		schemas map[string]string
+		args    bool
+	}{{rpc.ArgSchemas, true}, {rpc.ResultSchemas, false}} {
+		for _, id := range sortedIDs(set.schemas, names) {
+			name, ok := names[id]
+			if !ok {
+				return nil, fmt.Errorf("%s: no RPC constant has this ID", id)
+			}
+			if err := g.rpc(name, id, set.schemas[id], set.args); err != nil {
+				return nil, fmt.Errorf("%s (%s): %w", name, id, err)
+			}
+		}
+	}
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format: %w", err)
+	}
+	return src, nil
+}
+
+// rpcNames returns the names of the RPC ID constants declared in file by
+// ID, without their RPC prefix.
+func rpcNames(file string) (map[string]string, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), file, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string)
+	for _, d := range f.Decls {
+		d, ok := d.(*ast.GenDecl)
+		if !ok || d.Tok != token.CONST {
+			continue
+		}
+		for _, s := range d.Specs {
+			s := s.(*ast.ValueSpec)
+			for i, n := range s.Names {
+				if i >= len(s.Values) || !strings.HasPrefix(n.Name, "RPC") {
+					continue
+				}
+				lit, ok := s.Values[i].(*ast.BasicLit)
+				if !ok || lit.Kind != token.STRING {
+					continue
+				}
+				id, err := strconv.Unquote(lit.Value)
+				if err != nil {
+					return nil, err
+				}
+				names[id] = strings.TrimPrefix(n.Name, "RPC")
+			}
+		}
+	}
+	return names, nil
+}
+
+// sortedIDs returns the IDs of schemas in the order of their names.
+func sortedIDs(schemas, names map[string]string) []string {
+	var ids []string
+	for id := range schemas {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if names[ids[i]] != names[ids[j]] {
+			return names[ids[i]] < names[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+type generator struct {
+	buf bytes.Buffer
+	// types holds the schema text of each declared struct by name.
+	types map[string]string
+}
+
+// A goType is the type of a field.
+type goType struct {
+	name string
+	// isStruct is set for generated structs, which have Args methods or
+	// decode functions.
+	isStruct bool
+	// elem is the element type of a slice.
+	elem *goType
+}
+
+var scalarTypes = map[string]string{
+	"string": "string",
+	"number": "int",
+	"bool":   "bool",
+	"object": "map[string]interface{}",
+	"null":   "interface{}",
+	"any":    "interface{}",
+}
+
+// rpc declares the arguments or result type of the RPC name.
+func (g *generator) rpc(name, id, schema string, args bool) error {
+	p, err := rpc.ParseSchema(schema)
+	if err != nil {
+		return err
+	}
+	if len(p.Alts) != 1 || p.Alts[0].Kind != "array" {
+		return fmt.Errorf("schema %q is not a single array", schema)
+	}
+	typeName := name + "Result"
+	what := "result"
+	if args {
+		typeName, what = name+"Args", "arguments"
+	}
+	doc := fmt.Sprintf("// %s holds the %s of %s (%s), laid out as\n//\n//\t%s", typeName, what, name, id, schema)
+	if err := g.structType(name, typeName, doc, p.Text, p.Alts[0], args); err != nil {
+		return err
+	}
+	if args {
+		call := fmt.Sprintf("rpc.Call{ID: rpc.RPC%s, Args: a.Args()}", name)
+		if g.hasField(p.Alts[0], "projectID") {
+			call = fmt.Sprintf("rpc.Call{ID: rpc.RPC%s, Args: a.Args(), NotebookID: a.ProjectID}", name)
+		}
+		fmt.Fprintf(&g.buf, "\n// Call returns the %s call with arguments a.\nfunc (a %s) Call() rpc.Call {\n\treturn %s\n}\n", name, typeName, call)
+		return nil
+	}
+	fmt.Fprintf(&g.buf, `
+// Decode%[1]s decodes a %[2]s response.
+// Named positions that are missing or hold another type are left zero.
+func Decode%[1]s(data json.RawMessage) (*%[1]s, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("decode %[2]s result: %%w", err)
+	}
+	r := decode%[1]s(v)
+	return &r, nil
+}
+`, typeName, name)
+	return nil
+}
+
+// structType declares typeName with the named positions of the array a,
+// with an Args method for arguments or a decode function for results.
+// Structs for nested positions are named after owner.
+func (g *generator) structType(owner, typeName, doc, text string, a rpc.Alt, args bool) error {
+	if _, ok := g.types[typeName]; ok {
+		return fmt.Errorf("type %s is declared twice", typeName)
+	}
+	g.types[typeName] = text
+	var fields []string
+	seen := make(map[string]bool)
+	if err := g.fields(owner, a, args, seen, &fields); err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "\n%s\ntype %s struct {\n", doc, typeName)
+	for _, f := range fields {
+		fmt.Fprintf(&b, "\t%s\n", f)
+	}
+	fmt.Fprintf(&b, "}\n")
+	if args {
+		expr, err := g.arrayExpr(owner, a, "a")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&b, "\n// Args returns a in the positional layout of the call.\nfunc (a %s) Args() []interface{} {\n\treturn %s\n}\n", typeName, expr)
+	} else {
+		var stmts bytes.Buffer
+		if err := g.decodeArray(owner, a, nil, &stmts); err != nil {
+			return err
+		}
+		fmt.Fprintf(&b, "\nfunc decode%s(v interface{}) %s {\n\tvar r %s\n%s\treturn r\n}\n", typeName, typeName, typeName, stmts.String())
+	}
+	g.buf.Write(b.Bytes())
+	return nil
+}
+
+// fields appends the fields for the named positions of a, and of the
+// unnamed arrays inside it, to fields.
+func (g *generator) fields(owner string, a rpc.Alt, args bool, seen map[string]bool, fields *[]string) error {
+	for i, e := range a.Elems {
+		repeated := a.Repeat && i == len(a.Elems)-1
+		if e.Name == "" {
+			if inner, ok := layout(e); ok {
+				if repeated {
+					return fmt.Errorf("name the repeated position %s", e.Text)
+				}
+				if err := g.fields(owner, inner, args, seen, fields); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		name := exported(e.Name)
+		if seen[name] {
+			return fmt.Errorf("position name %s is used twice", e.Name)
+		}
+		seen[name] = true
+		t, err := g.fieldType(owner, e, repeated, args)
+		if err != nil {
+			return err
+		}
+		*fields = append(*fields, name+" "+t.name)
+	}
+	return nil
+}
+
+// fieldType returns the type of the named position p, a slice if it is
+// repeated.
+func (g *generator) fieldType(owner string, p *rpc.Pattern, repeated, args bool) (goType, error) {
+	name := exported(p.Name)
+	if repeated {
+		elem, err := g.valueType(owner, singular(name), p, args)
+		if err != nil {
+			return goType{}, err
+		}
+		return sliceOf(elem)
+	}
+	return g.valueType(owner, name, p, args)
+}
+
+// valueType returns the type of one value of p, declaring a struct named
+// owner+name when p is an array with named positions.
+func (g *generator) valueType(owner, name string, p *rpc.Pattern, args bool) (goType, error) {
+	if len(p.Alts) != 1 {
+		return goType{name: "interface{}"}, nil
+	}
+	a := p.Alts[0]
+	if t, ok := scalarTypes[a.Kind]; ok {
+		return goType{name: t}, nil
+	}
+	if len(a.Elems) == 1 && a.Repeat && !a.Open {
+		elem, err := g.valueType(owner, singular(name), a.Elems[0], args)
+		if err != nil {
+			return goType{}, err
+		}
+		return sliceOf(elem)
+	}
+	if !hasNames(a) {
+		return goType{name: "interface{}"}, nil
+	}
+	typeName := owner + name
+	if text, ok := g.types[typeName]; ok {
+		if text != p.Text {
+			return goType{}, fmt.Errorf("type %s is declared twice", typeName)
+		}
+		return goType{name: typeName, isStruct: true}, nil
+	}
+	what := "result"
+	if args {
+		what = "arguments"
+	}
+	doc := fmt.Sprintf("// %s holds a %s in the %s of %s.", typeName, name, what, owner)
+	if err := g.structType(owner, typeName, doc, p.Text, a, args); err != nil {
+		return goType{}, err
+	}
+	return goType{name: typeName, isStruct: true}, nil
+}
+
+func sliceOf(elem goType) (goType, error) {
+	if elem.elem != nil {
+		return goType{}, fmt.Errorf("lists of lists need a name for the inner list")
+	}
+	return goType{name: "[]" + elem.name, elem: &elem}, nil
+}
+
+// arrayExpr returns an expression building the array a from the fields of
+// recv.
+func (g *generator) arrayExpr(owner string, a rpc.Alt, recv string) (string, error) {
+	n := len(a.Elems)
+	if a.Repeat {
+		n--
+	}
+	var parts []string
+	for _, e := range a.Elems[:n] {
+		expr, err := g.elemExpr(owner, e, recv)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, expr)
+	}
+	list := "[]interface{}{" + strings.Join(parts, ", ") + "}"
+	if !a.Repeat {
+		return list, nil
+	}
+	last := a.Elems[n]
+	if last.Name == "" {
+		return "", fmt.Errorf("name the repeated position %s", last.Text)
+	}
+	t, err := g.fieldType(owner, last, true, true)
+	if err != nil {
+		return "", err
+	}
+	rest := sliceExpr(t, recv+"."+exported(last.Name))
+	if n == 0 {
+		return rest, nil
+	}
+	return "append(" + list + ", " + rest + "...)", nil
+}
+
+// elemExpr returns an expression for the position p of an array.
+func (g *generator) elemExpr(owner string, p *rpc.Pattern, recv string) (string, error) {
+	if p.Name != "" {
+		name := exported(p.Name)
+		t, err := g.valueType(owner, name, p, true)
+		if err != nil {
+			return "", err
+		}
+		field := recv + "." + name
+		switch {
+		case t.elem != nil:
+			return sliceExpr(t, field), nil
+		case t.isStruct:
+			return field + ".Args()", nil
+		}
+		return field, nil
+	}
+	if len(p.Alts) == 1 {
+		switch a := p.Alts[0]; {
+		case a.Kind == "null":
+			return "nil", nil
+		case a.Kind == "array" && (hasNames(a) || len(a.Elems) == 0 && !a.Open):
+			return g.arrayExpr(owner, a, recv)
+		}
+	}
+	return "", fmt.Errorf("name the position %s, or make it null", p.Text)
+}
+
+// sliceExpr returns the elements of the slice field as []interface{}.
+func sliceExpr(t goType, field string) string {
+	if t.elem.isStruct {
+		return "each(" + field + ")"
+	}
+	return "list(" + field + ")"
+}
+
+// decodeArray writes the statements filling r from the array at path in
+// v.
+func (g *generator) decodeArray(owner string, a rpc.Alt, path []string, w *bytes.Buffer) error {
+	for i, e := range a.Elems {
+		at := append(append([]string(nil), path...), strconv.Itoa(i))
+		repeated := a.Repeat && i == len(a.Elems)-1
+		if e.Name == "" {
+			if inner, ok := layout(e); ok && !repeated {
+				if err := g.decodeArray(owner, inner, at, w); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		name := exported(e.Name)
+		var t goType
+		var err error
+		if repeated {
+			t, err = g.valueType(owner, singular(name), e, false)
+			if err == nil {
+				t, err = sliceOf(t)
+			}
+		} else {
+			t, err = g.valueType(owner, name, e, false)
+		}
+		if err != nil {
+			return err
+		}
+		field := "r." + name
+		switch {
+		case repeated:
+			fmt.Fprintf(w, "\tfor _, e := range elems(%s, %d) {\n\t\t%s = append(%s, %s)\n\t}\n", atExpr(path), i, field, field, convert(*t.elem, "e"))
+		case t.elem != nil:
+			fmt.Fprintf(w, "\tfor _, e := range elems(%s, 0) {\n\t\t%s = append(%s, %s)\n\t}\n", atExpr(at), field, field, convert(*t.elem, "e"))
+		default:
+			fmt.Fprintf(w, "\t%s = %s\n", field, convert(t, atExpr(at)))
+		}
+	}
+	return nil
+}
+
+// convert returns an expression converting the decoded JSON value expr to
+// t.
+func convert(t goType, expr string) string {
+	switch {
+	case t.isStruct:
+		return "decode" + t.name + "(" + expr + ")"
+	case t.name == "string":
+		return "str(" + expr + ")"
+	case t.name == "int":
+		return "num(" + expr + ")"
+	case t.name == "bool":
+		return "boolean(" + expr + ")"
+	case t.name == "map[string]interface{}":
+		return "obj(" + expr + ")"
+	}
+	return expr
+}
+
+func atExpr(path []string) string {
+	if len(path) == 0 {
+		return "v"
+	}
+	return "at(v, " + strings.Join(path, ", ") + ")"
+}
+
+// hasField reports whether a has a position with the given name.
+func (g *generator) hasField(a rpc.Alt, name string) bool {
+	for _, e := range a.Elems {
+		if e.Name == name {
+			return true
+		}
+		if inner, ok := layout(e); ok && e.Name == "" && g.hasField(inner, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// layout returns the array of p when it is a single array shape.
+func layout(p *rpc.Pattern) (rpc.Alt, bool) {
+	if len(p.Alts) != 1 || p.Alts[0].Kind != "array" {
+		return rpc.Alt{}, false
+	}
+	return p.Alts[0], true
+}
+
+// hasNames reports whether a has named positions, directly or in unnamed
+// arrays.
+func hasNames(a rpc.Alt) bool {
+	for _, e := range a.Elems {
+		if e.Name != "" {
+			return true
+		}
+		if inner, ok := layout(e); ok && hasNames(inner) {
+			return true
+		}
+	}
+	return false
+}
+
+// exported returns the Go field name for a position name.
+func exported(name string) string {
+	switch name {
+	case "id", "url":
+		return strings.ToUpper(name)
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+func singular(name string) string {
+	if len(name) > 1 && strings.HasSuffix(name, "s") {
+		return strings.TrimSuffix(name, "s")
+	}
+	return name
+}
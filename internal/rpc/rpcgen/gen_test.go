@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGeneratedUpToDate(t *testing.T) {
+	want, err := generate("../rpc.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile("../rpctypes/rpctypes_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("rpctypes_gen.go is stale; run go generate in internal/rpc")
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		schema string
+		args   bool
+		err    string
+	}{
+		{`[string]`, true, "name the position string"},
+		{`[[string, number]...]`, true, "name the repeated position"},
+		{`[id: string, [id: number]]`, false, "used twice"},
+		{`string`, true, "not a single array"},
+	}
+	for _, tt := range tests {
+		g := &generator{types: make(map[string]string)}
+		err := g.rpc("Test", "abc", tt.schema, tt.args)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: err = %v, want %q", tt.schema, err, tt.err)
+		}
+	}
+}
+
+func TestGenerateNested(t *testing.T) {
+	g := &generator{types: make(map[string]string)}
+	if err := g.rpc("Test", "abc", `[[items: [id: string, n: number]...], ...]`, false); err != nil {
+		t.Fatal(err)
+	}
+	src := g.buf.String()
+	for _, want := range []string{
+		"Items []TestItem",
+		"r.Items = append(r.Items, decodeTestItem(e))",
+		"r.N = num(at(v, 1))",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("output lacks %q:\n%s", want, src)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+// Rpcgen writes package rpctypes: typed arguments and results for the RPCs
+// with schemas in rpc.ArgSchemas and rpc.ResultSchemas.
+//
+// Each named position of a schema becomes a struct field. An arguments
+// struct has an Args method returning the positional arguments, and a
+// Call method; a result struct has a Decode function reading a response.
+// Unnamed arrays are layout only: the names inside them become fields of
+// the enclosing struct. Unnamed positions are left out of results, and in
+// arguments they must be null or an empty array.
+//
+// Usage, from internal/rpc:
+//
+//	go run ./rpcgen [-rpc rpc.go] [-o rpctypes/rpctypes_gen.go]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+func main() {
+	rpcFile := flag.String("rpc", "rpc.go", "the Go file declaring the RPC ID constants")
+	out := flag.String("o", "", "write to this file instead of stdout")
+	flag.Parse()
+	log.SetFlags(0)
+	log.SetPrefix("rpcgen: ")
+
+	src, err := generate(*rpcFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(src)
+	} else {
+		err = os.WriteFile(*out, src, 0o644)
+	}
+	if err != nil {
+		log.Fatal(fmt.Errorf("write: %w", err))
+	}
+}
@@ -0,0 +1,69 @@
+// Package rpctypes holds typed arguments and results for NotebookLM RPCs,
+// generated by rpcgen from the schemas in package rpc.
+//
+// To support a new RPC, add its ID constant to rpc.go, its argument layout
+// to rpc.ArgSchemas and, if its response is not decoded through a proto
+// message, its result layout to rpc.ResultSchemas, naming the positions
+// that matter. Then run go generate in internal/rpc.
+package rpctypes
+
+// at returns the element of v at path, walking nested arrays, or nil if
+// there is none.
+func at(v interface{}, path ...int) interface{} {
+	for _, i := range path {
+		arr, ok := v.([]interface{})
+		if !ok || i >= len(arr) {
+			return nil
+		}
+		v = arr[i]
+	}
+	return v
+}
+
+// elems returns the elements of the array v from index start.
+func elems(v interface{}, start int) []interface{} {
+	arr, ok := v.([]interface{})
+	if !ok || start >= len(arr) {
+		return nil
+	}
+	return arr[start:]
+}
+
+func str(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+func num(v interface{}) int {
+	f, _ := v.(float64)
+	return int(f)
+}
+
+func boolean(v interface{}) bool {
+	b, _ := v.(bool)
+	return b
+}
+
+func obj(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+// list returns s as an argument array, empty rather than null when s is
+// nil.
+func list[T any](s []T) []interface{} {
+	out := make([]interface{}, len(s))
+	for i, e := range s {
+		out[i] = e
+	}
+	return out
+}
+
+// each returns the positional arguments of each element of s.
+func each[T interface{ Args() []interface{} }](s []T) []interface{} {
+	out := make([]interface{}, len(s))
+	for i, e := range s {
+		out[i] = e.Args()
+	}
+	return out
+}
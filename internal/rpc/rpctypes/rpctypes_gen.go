@@ -0,0 +1,605 @@
+// Code generated by rpcgen from rpc.ArgSchemas and rpc.ResultSchemas; DO NOT EDIT.
+
+package rpctypes
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/tmc/nlm/internal/rpc"
+)
+
+// ActOnSourcesArgs holds the arguments of ActOnSources (yyryJe), laid out as
+//
+//	[projectID: string, action: string, sourceIDs: [string...]]
+type ActOnSourcesArgs struct {
+	ProjectID string
+	Action    string
+	SourceIDs []string
+}
+
+// Args returns a in the positional layout of the call.
+func (a ActOnSourcesArgs) Args() []interface{} {
+	return []interface{}{a.ProjectID, a.Action, list(a.SourceIDs)}
+}
+
+// Call returns the ActOnSources call with arguments a.
+func (a ActOnSourcesArgs) Call() rpc.Call {
+	return rpc.Call{ID: rpc.RPCActOnSources, Args: a.Args(), NotebookID: a.ProjectID}
+}
+
+// AddSourcesArgs holds the arguments of AddSources (izAoDd), laid out as
+//
+//	[sources: [[null, [string, string], null, number]|[string, string, string, string, number]|[null, null, [string]]|[null, null, string, null, number]...], projectID: string]
+type AddSourcesArgs struct {
+	Sources   []interface{}
+	ProjectID string
+}
+
+// Args returns a in the positional layout of the call.
+func (a AddSourcesArgs) Args() []interface{} {
+	return []interface{}{list(a.Sources), a.ProjectID}
+}
+
+// Call returns the AddSources call with arguments a.
+func (a AddSourcesArgs) Call() rpc.Call {
+	return rpc.Call{ID: rpc.RPCAddSources, Args: a.Args(), NotebookID: a.ProjectID}
+}
+
+// CheckSourceFreshnessArgs holds the arguments of CheckSourceFreshness (yR9Yof), laid out as
+//
+//	[sourceID: string]
+type CheckSourceFreshnessArgs struct {
+	SourceID string
+}
+
+// Args returns a in the positional layout of the call.
+func (a CheckSourceFreshnessArgs) Args() []interface{} {
+	return []interface{}{a.SourceID}
+}
+
+// Call returns the CheckSourceFreshness call with arguments a.
+func (a CheckSourceFreshnessArgs) Call() rpc.Call {
+	return rpc.Call{ID: rpc.RPCCheckSourceFreshness, Args: a.Args()}
+}
+
+// CreateAudioOverviewArgs holds the arguments of CreateAudioOverview (AHyHrd), laid out as
+//
+//	[projectID: string, audioType: number, [instructions: string]]
+type CreateAudioOverviewArgs struct {
+	ProjectID    string
+	AudioType    int
+	Instructions string
+}
+
+// Args returns a in the positional layout of the call.
+func (a CreateAudioOverviewArgs) Args() []interface{} {
+	return []interface{}{a.ProjectID, a.AudioType, []interface{}{a.Instructions}}
+}
+
+// Call returns the CreateAudioOverview call with arguments a.
+func (a CreateAudioOverviewArgs) Call() rpc.Call {
+	return rpc.Call{ID: rpc.RPCCreateAudioOverview, Args: a.Args(), NotebookID: a.ProjectID}
+}
+
+// CreateNoteArgs holds the arguments of CreateNote (CYK0Xb), laid out as
+//
+//	[projectID: string, content: string, [noteType: number], null, title: string]
+type CreateNoteArgs struct {
+	ProjectID string
+	Content   string
+	NoteType  int
+	Title     string
+}
+
+// Args returns a in the positional layout of the call.
+func (a CreateNoteArgs) Args() []interface{} {
+	return []interface{}{a.ProjectID, a.Content, []interface{}{a.NoteType}, nil, a.Title}
+}
+
+// Call returns the CreateNote call with arguments a.
+func (a CreateNoteArgs) Call() rpc.Call {
+	return rpc.Call{ID: rpc.RPCCreateNote, Args: a.Args(), NotebookID: a.ProjectID}
+}
+
+// CreateProjectArgs holds the arguments of CreateProject (CCqFvf), laid out as
+//
+//	[title: string, emoji: string]
+type CreateProjectArgs struct {
+	Title string
+	Emoji string
+}
+
+// Args returns a in the positional layout of the call.
+func (a CreateProjectArgs) Args() []interface{} {
+	return []interface{}{a.Title, a.Emoji}
+}
+
+// Call returns the CreateProject call with arguments a.
+func (a CreateProjectArgs) Call() rpc.Call {
+	return rpc.Call{ID: rpc.RPCCreateProject, Args: a.Args()}
+}
+
+// DeleteAudioOverviewArgs holds the arguments of DeleteAudioOverview (sJDbic), laid out as
+//
+//	[projectID: string]
+type DeleteAudioOverviewArgs struct {
+	ProjectID string
+}
+
+// Args returns a in the positional layout of the call.
+func (a DeleteAudioOverviewArgs) Args() []interface{} {
+	return []interface{}{a.ProjectID}
+}
+
+// Call returns the DeleteAudioOverview call with arguments a.
+func (a DeleteAudioOverviewArgs) Call() rpc.Call {
+	return rpc.Call{ID: rpc.RPCDeleteAudioOverview, Args: a.Args(), NotebookID: a.ProjectID}
+}
+
+// DeleteNotesArgs holds the arguments of DeleteNotes (AH0mwd), laid out as
+//
+//	[[[noteIDs: [string...]]]]
+type DeleteNotesArgs struct {
+	NoteIDs []string
+}
+
+// Args returns a in the positional layout of the call.
+func (a DeleteNotesArgs) Args() []interface{} {
+	return []interface{}{[]interface{}{[]interface{}{list(a.NoteIDs)}}}
+}
+
+// Call returns the DeleteNotes call with arguments a.
+func (a DeleteNotesArgs) Call() rpc.Call {
+	return rpc.Call{ID: rpc.RPCDeleteNotes, Args: a.Args()}
+}
+
+// DeleteProjectsArgs holds the arguments of DeleteProjects (WWINqb), laid out as
+//
+//	[projectIDs: [string...]]
+type DeleteProjectsArgs struct {
+	ProjectIDs []string
+}
+
+// Args returns a in the positional layout of the call.
+func (a DeleteProjectsArgs) Args() []interface{} {
+	return []interface{}{list(a.ProjectIDs)}
+}
+
+// Call returns the DeleteProjects call with arguments a.
+func (a DeleteProjectsArgs) Call() rpc.Call {
+	return rpc.Call{ID: rpc.RPCDeleteProjects, Args: a.Args()}
+}
+
+// DeleteSourcesArgs holds the arguments of DeleteSources (tGMBJ), laid out as
+//
+//	[[[sourceIDs: [string...]]]]
+type DeleteSourcesArgs struct {
+	SourceIDs []string
+}
+
+// Args returns a in the positional layout of the call.
+func (a DeleteSourcesArgs) Args() []interface{} {
+	return []interface{}{[]interface{}{[]interface{}{list(a.SourceIDs)}}}
+}
+
+// Call returns the DeleteSources call with arguments a.
+func (a DeleteSourcesArgs) Call() rpc.Call {
+	return rpc.Call{ID: rpc.RPCDeleteSources, Args: a.Args()}
+}
+
+// GenerateDocumentGuidesArgs holds the arguments of GenerateDocumentGuides (tr032e), laid out as
+//
+//	[projectID: string]
+type GenerateDocumentGuidesArgs struct {
+	ProjectID string
+}
+
+// Args returns a in the positional layout of the call.
+func (a GenerateDocumentGuidesArgs) Args() []interface{} {
+	return []interface{}{a.ProjectID}
+}
+
+// Call returns the GenerateDocumentGuides call with arguments a.
+func (a GenerateDocumentGuidesArgs) Call() rpc.Call {
+	return rpc.Call{ID: rpc.RPCGenerateDocumentGuides, Args: a.Args(), NotebookID: a.ProjectID}
+}
+
+// GenerateNotebookGuideArgs holds the arguments of GenerateNotebookGuide (VfAZjd), laid out as
+//
+//	[projectID: string]
+type GenerateNotebookGuideArgs struct {
+	ProjectID string
+}
+
+// Args returns a in the positional layout of the call.
+func (a GenerateNotebookGuideArgs) Args() []interface{} {
+	return []interface{}{a.ProjectID}
+}
+
+// Call returns the GenerateNotebookGuide call with arguments a.
+func (a GenerateNotebookGuideArgs) Call() rpc.Call {
+	return rpc.Call{ID: rpc.RPCGenerateNotebookGuide, Args: a.Args(), NotebookID: a.ProjectID}
+}
+
+// GenerateOutlineArgs holds the arguments of GenerateOutline (lCjAd), laid out as
+//
+//	[projectID: string]
+type GenerateOutlineArgs struct {
+	ProjectID string
+}
+
+// Args returns a in the positional layout of the call.
+func (a GenerateOutlineArgs) Args() []interface{} {
+	return []interface{}{a.ProjectID}
+}
+
+// Call returns the GenerateOutline call with arguments a.
+func (a GenerateOutlineArgs) Call() rpc.Call {
+	return rpc.Call{ID: rpc.RPCGenerateOutline, Args: a.Args(), NotebookID: a.ProjectID}
+}
+
+// GenerateSectionArgs holds the arguments of GenerateSection (BeTrYd), laid out as
+//
+//	[projectID: string]
+type GenerateSectionArgs struct {
+	ProjectID string
+}
+
+// Args returns a in the positional layout of the call.
+func (a GenerateSectionArgs) Args() []interface{} {
+	return []interface{}{a.ProjectID}
+}
+
+// Call returns the GenerateSection call with arguments a.
+func (a GenerateSectionArgs) Call() rpc.Call {
+	return rpc.Call{ID: rpc.RPCGenerateSection, Args: a.Args(), NotebookID: a.ProjectID}
+}
+
+// GetAudioOverviewArgs holds the arguments of GetAudioOverview (VUsiyb), laid out as
+//
+//	[projectID: string, requestType: number]
+type GetAudioOverviewArgs struct {
+	ProjectID   string
+	RequestType int
+}
+
+// Args returns a in the positional layout of the call.
+func (a GetAudioOverviewArgs) Args() []interface{} {
+	return []interface{}{a.ProjectID, a.RequestType}
+}
+
+// Call returns the GetAudioOverview call with arguments a.
+func (a GetAudioOverviewArgs) Call() rpc.Call {
+	return rpc.Call{ID: rpc.RPCGetAudioOverview, Args: a.Args(), NotebookID: a.ProjectID}
+}
+
+// GetNotesArgs holds the arguments of GetNotes (cFji9), laid out as
+//
+//	[projectID: string]
+type GetNotesArgs struct {
+	ProjectID string
+}
+
+// Args returns a in the positional layout of the call.
+func (a GetNotesArgs) Args() []interface{} {
+	return []interface{}{a.ProjectID}
+}
+
+// Call returns the GetNotes call with arguments a.
+func (a GetNotesArgs) Call() rpc.Call {
+	return rpc.Call{ID: rpc.RPCGetNotes, Args: a.Args(), NotebookID: a.ProjectID}
+}
+
+// GetProjectArgs holds the arguments of GetProject (rLM1Ne), laid out as
+//
+//	[projectID: string]
+type GetProjectArgs struct {
+	ProjectID string
+}
+
+// Args returns a in the positional layout of the call.
+func (a GetProjectArgs) Args() []interface{} {
+	return []interface{}{a.ProjectID}
+}
+
+// Call returns the GetProject call with arguments a.
+func (a GetProjectArgs) Call() rpc.Call {
+	return rpc.Call{ID: rpc.RPCGetProject, Args: a.Args(), NotebookID: a.ProjectID}
+}
+
+// GetProjectDetailsArgs holds the arguments of GetProjectDetails (JFMDGd), laid out as
+//
+//	[projectID: string]
+type GetProjectDetailsArgs struct {
+	ProjectID string
+}
+
+// Args returns a in the positional layout of the call.
+func (a GetProjectDetailsArgs) Args() []interface{} {
+	return []interface{}{a.ProjectID}
+}
+
+// Call returns the GetProjectDetails call with arguments a.
+func (a GetProjectDetailsArgs) Call() rpc.Call {
+	return rpc.Call{ID: rpc.RPCGetProjectDetails, Args: a.Args(), NotebookID: a.ProjectID}
+}
+
+// ListRecentlyViewedGuidebooksArgs holds the arguments of ListRecentlyViewedGuidebooks (YJBpHc), laid out as
+//
+//	[]
+type ListRecentlyViewedGuidebooksArgs struct {
+}
+
+// Args returns a in the positional layout of the call.
+func (a ListRecentlyViewedGuidebooksArgs) Args() []interface{} {
+	return []interface{}{}
+}
+
+// Call returns the ListRecentlyViewedGuidebooks call with arguments a.
+func (a ListRecentlyViewedGuidebooksArgs) Call() rpc.Call {
+	return rpc.Call{ID: rpc.RPCListRecentlyViewedGuidebooks, Args: a.Args()}
+}
+
+// ListRecentlyViewedProjectsArgs holds the arguments of ListRecentlyViewedProjects (wXbhsf), laid out as
+//
+//	[null, option: number]
+type ListRecentlyViewedProjectsArgs struct {
+	Option int
+}
+
+// Args returns a in the positional layout of the call.
+func (a ListRecentlyViewedProjectsArgs) Args() []interface{} {
+	return []interface{}{nil, a.Option}
+}
+
+// Call returns the ListRecentlyViewedProjects call with arguments a.
+func (a ListRecentlyViewedProjectsArgs) Call() rpc.Call {
+	return rpc.Call{ID: rpc.RPCListRecentlyViewedProjects, Args: a.Args()}
+}
+
+// LoadSourceArgs holds the arguments of LoadSource (hizoJc), laid out as
+//
+//	[sourceID: string]
+type LoadSourceArgs struct {
+	SourceID string
+}
+
+// Args returns a in the positional layout of the call.
+func (a LoadSourceArgs) Args() []interface{} {
+	return []interface{}{a.SourceID}
+}
+
+// Call returns the LoadSource call with arguments a.
+func (a LoadSourceArgs) Call() rpc.Call {
+	return rpc.Call{ID: rpc.RPCLoadSource, Args: a.Args()}
+}
+
+// MutateNoteArgs holds the arguments of MutateNote (cYAfTb), laid out as
+//
+//	[projectID: string, noteID: string, [[[content: string, title: string, []]]]]
+type MutateNoteArgs struct {
+	ProjectID string
+	NoteID    string
+	Content   string
+	Title     string
+}
+
+// Args returns a in the positional layout of the call.
+func (a MutateNoteArgs) Args() []interface{} {
+	return []interface{}{a.ProjectID, a.NoteID, []interface{}{[]interface{}{[]interface{}{a.Content, a.Title, []interface{}{}}}}}
+}
+
+// Call returns the MutateNote call with arguments a.
+func (a MutateNoteArgs) Call() rpc.Call {
+	return rpc.Call{ID: rpc.RPCMutateNote, Args: a.Args(), NotebookID: a.ProjectID}
+}
+
+// MutateProjectArgs holds the arguments of MutateProject (s0tc2d), laid out as
+//
+//	[projectID: string, updates: object|[...]]
+type MutateProjectArgs struct {
+	ProjectID string
+	Updates   interface{}
+}
+
+// Args returns a in the positional layout of the call.
+func (a MutateProjectArgs) Args() []interface{} {
+	return []interface{}{a.ProjectID, a.Updates}
+}
+
+// Call returns the MutateProject call with arguments a.
+func (a MutateProjectArgs) Call() rpc.Call {
+	return rpc.Call{ID: rpc.RPCMutateProject, Args: a.Args(), NotebookID: a.ProjectID}
+}
+
+// MutateSourceArgs holds the arguments of MutateSource (b7Wfje), laid out as
+//
+//	[sourceID: string, updates: object]
+type MutateSourceArgs struct {
+	SourceID string
+	Updates  map[string]interface{}
+}
+
+// Args returns a in the positional layout of the call.
+func (a MutateSourceArgs) Args() []interface{} {
+	return []interface{}{a.SourceID, a.Updates}
+}
+
+// Call returns the MutateSource call with arguments a.
+func (a MutateSourceArgs) Call() rpc.Call {
+	return rpc.Call{ID: rpc.RPCMutateSource, Args: a.Args()}
+}
+
+// RefreshSourceArgs holds the arguments of RefreshSource (FLmJqe), laid out as
+//
+//	[sourceID: string]
+type RefreshSourceArgs struct {
+	SourceID string
+}
+
+// Args returns a in the positional layout of the call.
+func (a RefreshSourceArgs) Args() []interface{} {
+	return []interface{}{a.SourceID}
+}
+
+// Call returns the RefreshSource call with arguments a.
+func (a RefreshSourceArgs) Call() rpc.Call {
+	return rpc.Call{ID: rpc.RPCRefreshSource, Args: a.Args()}
+}
+
+// RemoveRecentlyViewedArgs holds the arguments of RemoveRecentlyViewed (fejl7e), laid out as
+//
+//	[projectID: string]
+type RemoveRecentlyViewedArgs struct {
+	ProjectID string
+}
+
+// Args returns a in the positional layout of the call.
+func (a RemoveRecentlyViewedArgs) Args() []interface{} {
+	return []interface{}{a.ProjectID}
+}
+
+// Call returns the RemoveRecentlyViewed call with arguments a.
+func (a RemoveRecentlyViewedArgs) Call() rpc.Call {
+	return rpc.Call{ID: rpc.RPCRemoveRecentlyViewed, Args: a.Args(), NotebookID: a.ProjectID}
+}
+
+// ShareAudioArgs holds the arguments of ShareAudio (RGP97b), laid out as
+//
+//	[[shareOption: number], projectID: string]
+type ShareAudioArgs struct {
+	ShareOption int
+	ProjectID   string
+}
+
+// Args returns a in the positional layout of the call.
+func (a ShareAudioArgs) Args() []interface{} {
+	return []interface{}{[]interface{}{a.ShareOption}, a.ProjectID}
+}
+
+// Call returns the ShareAudio call with arguments a.
+func (a ShareAudioArgs) Call() rpc.Call {
+	return rpc.Call{ID: rpc.RPCShareAudio, Args: a.Args(), NotebookID: a.ProjectID}
+}
+
+// ShareProjectArgs holds the arguments of ShareProject (QDyure), laid out as
+//
+//	[[[projectID: string, users: [[string, null, number]...]|null, access: [number]|null, notify: bool]]]
+type ShareProjectArgs struct {
+	ProjectID string
+	Users     interface{}
+	Access    interface{}
+	Notify    bool
+}
+
+// Args returns a in the positional layout of the call.
+func (a ShareProjectArgs) Args() []interface{} {
+	return []interface{}{[]interface{}{[]interface{}{a.ProjectID, a.Users, a.Access, a.Notify}}}
+}
+
+// Call returns the ShareProject call with arguments a.
+func (a ShareProjectArgs) Call() rpc.Call {
+	return rpc.Call{ID: rpc.RPCShareProject, Args: a.Args(), NotebookID: a.ProjectID}
+}
+
+// StartDraftArgs holds the arguments of StartDraft (exXvGf), laid out as
+//
+//	[projectID: string]
+type StartDraftArgs struct {
+	ProjectID string
+}
+
+// Args returns a in the positional layout of the call.
+func (a StartDraftArgs) Args() []interface{} {
+	return []interface{}{a.ProjectID}
+}
+
+// Call returns the StartDraft call with arguments a.
+func (a StartDraftArgs) Call() rpc.Call {
+	return rpc.Call{ID: rpc.RPCStartDraft, Args: a.Args(), NotebookID: a.ProjectID}
+}
+
+// StartSectionArgs holds the arguments of StartSection (pGC7gf), laid out as
+//
+//	[projectID: string]
+type StartSectionArgs struct {
+	ProjectID string
+}
+
+// Args returns a in the positional layout of the call.
+func (a StartSectionArgs) Args() []interface{} {
+	return []interface{}{a.ProjectID}
+}
+
+// Call returns the StartSection call with arguments a.
+func (a StartSectionArgs) Call() rpc.Call {
+	return rpc.Call{ID: rpc.RPCStartSection, Args: a.Args(), NotebookID: a.ProjectID}
+}
+
+// ListRecentlyViewedProjectsProject holds a Project in the result of ListRecentlyViewedProjects.
+type ListRecentlyViewedProjectsProject struct {
+	Title     string
+	ProjectID string
+	Emoji     string
+}
+
+func decodeListRecentlyViewedProjectsProject(v interface{}) ListRecentlyViewedProjectsProject {
+	var r ListRecentlyViewedProjectsProject
+	r.Title = str(at(v, 0))
+	r.ProjectID = str(at(v, 2))
+	r.Emoji = str(at(v, 3))
+	return r
+}
+
+// ListRecentlyViewedProjectsResult holds the result of ListRecentlyViewedProjects (wXbhsf), laid out as
+//
+//	[[projects: [title: string, any, projectID: string, emoji: string, ...]...], ...]
+type ListRecentlyViewedProjectsResult struct {
+	Projects []ListRecentlyViewedProjectsProject
+}
+
+func decodeListRecentlyViewedProjectsResult(v interface{}) ListRecentlyViewedProjectsResult {
+	var r ListRecentlyViewedProjectsResult
+	for _, e := range elems(at(v, 0), 0) {
+		r.Projects = append(r.Projects, decodeListRecentlyViewedProjectsProject(e))
+	}
+	return r
+}
+
+// DecodeListRecentlyViewedProjectsResult decodes a ListRecentlyViewedProjects response.
+// Named positions that are missing or hold another type are left zero.
+func DecodeListRecentlyViewedProjectsResult(data json.RawMessage) (*ListRecentlyViewedProjectsResult, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("decode ListRecentlyViewedProjects result: %w", err)
+	}
+	r := decodeListRecentlyViewedProjectsResult(v)
+	return &r, nil
+}
+
+// ShareAudioResult holds the result of ShareAudio (RGP97b), laid out as
+//
+//	[[url: string, id: string, ...], ...]
+type ShareAudioResult struct {
+	URL string
+	ID  string
+}
+
+func decodeShareAudioResult(v interface{}) ShareAudioResult {
+	var r ShareAudioResult
+	r.URL = str(at(v, 0, 0))
+	r.ID = str(at(v, 0, 1))
+	return r
+}
+
+// DecodeShareAudioResult decodes a ShareAudio response.
+// Named positions that are missing or hold another type are left zero.
+func DecodeShareAudioResult(data json.RawMessage) (*ShareAudioResult, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("decode ShareAudio result: %w", err)
+	}
+	r := decodeShareAudioResult(v)
+	return &r, nil
+}
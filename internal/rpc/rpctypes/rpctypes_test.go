@@ -0,0 +1,61 @@
+package rpctypes
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tmc/nlm/internal/rpc"
+)
+
+func TestArgsMatchSchemas(t *testing.T) {
+	const nb = "fec1780c-5a14-4f07-8ee6-f8c3ee2930fa"
+	for _, call := range []rpc.Call{
+		CreateNoteArgs{ProjectID: nb, NoteType: 1, Title: "Title"}.Call(),
+		MutateNoteArgs{ProjectID: nb, NoteID: nb, Content: "text", Title: "Title"}.Call(),
+		DeleteSourcesArgs{}.Call(),
+		ShareProjectArgs{ProjectID: nb, Access: []int{1}}.Call(),
+		ListRecentlyViewedProjectsArgs{Option: 1}.Call(),
+		ShareAudioArgs{ProjectID: nb}.Call(),
+	} {
+		if err := rpc.CheckArgs(call.ID, call.Args); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestCallNotebookID(t *testing.T) {
+	c := CreateNoteArgs{ProjectID: "nb"}.Call()
+	if c.ID != rpc.RPCCreateNote || c.NotebookID != "nb" {
+		t.Errorf("Call() = %+v", c)
+	}
+}
+
+func TestDecodeResults(t *testing.T) {
+	lists, err := DecodeListRecentlyViewedProjectsResult([]byte(`[[
+		["Notes", [], "nb1", "📓", null, [1]],
+		["Drifted", null, ["nb2"]],
+		"not a project"
+	]]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ListRecentlyViewedProjectsProject{
+		{Title: "Notes", ProjectID: "nb1", Emoji: "📓"},
+		{Title: "Drifted"},
+		{},
+	}
+	if d := cmp.Diff(want, lists.Projects); d != "" {
+		t.Errorf("projects (-want +got):\n%s", d)
+	}
+
+	share, err := DecodeShareAudioResult([]byte(`[["https://notebooklm.google.com/s/x", "x"]]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if share.URL != "https://notebooklm.google.com/s/x" || share.ID != "x" {
+		t.Errorf("share = %+v", share)
+	}
+	if _, err := DecodeShareAudioResult([]byte(`[`)); err == nil {
+		t.Error("decoding invalid JSON succeeded")
+	}
+}
@@ -0,0 +1,354 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+//go:generate go run ./rpcgen -o rpctypes/rpctypes_gen.go
+
+// ArgSchemas describe the arguments of each RPC as the web client sends
+// them, in a small notation: string, number, bool, null, object and any
+// match JSON values of that type, [a, b] an array of exactly those
+// elements, a... as the last element any number of a, a bare ... any
+// further elements, and a|b either. A position can be named, as in
+// [title: string]; rpcgen turns named positions into the fields of the
+// structs in package rpctypes. Positions whose meaning is unknown are any.
+var ArgSchemas = map[string]string{
+	RPCListRecentlyViewedProjects: `[null, option: number]`,
+	RPCCreateProject:              `[title: string, emoji: string]`,
+	RPCGetProject:                 `[projectID: string]`,
+	RPCDeleteProjects:             `[projectIDs: [string...]]`,
+	RPCMutateProject:              `[projectID: string, updates: object|[...]]`,
+	RPCRemoveRecentlyViewed:       `[projectID: string]`,
+
+	// The sources of AddSources are text, a base64 file, a URL or a
+	// YouTube video.
+	RPCAddSources: `[sources: [` +
+		`[null, [string, string], null, number]|` +
+		`[string, string, string, string, number]|` +
+		`[null, null, [string]]|` +
+		`[null, null, string, null, number]...], projectID: string]`,
+	RPCDeleteSources:        `[[[sourceIDs: [string...]]]]`,
+	RPCMutateSource:         `[sourceID: string, updates: object]`,
+	RPCRefreshSource:        `[sourceID: string]`,
+	RPCLoadSource:           `[sourceID: string]`,
+	RPCCheckSourceFreshness: `[sourceID: string]`,
+	RPCActOnSources:         `[projectID: string, action: string, sourceIDs: [string...]]`,
+
+	RPCCreateNote:  `[projectID: string, content: string, [noteType: number], null, title: string]`,
+	RPCMutateNote:  `[projectID: string, noteID: string, [[[content: string, title: string, []]]]]`,
+	RPCDeleteNotes: `[[[noteIDs: [string...]]]]`,
+	RPCGetNotes:    `[projectID: string]`,
+
+	RPCCreateAudioOverview: `[projectID: string, audioType: number, [instructions: string]]`,
+	RPCGetAudioOverview:    `[projectID: string, requestType: number]`,
+	RPCDeleteAudioOverview: `[projectID: string]`,
+
+	RPCGenerateDocumentGuides: `[projectID: string]`,
+	RPCGenerateNotebookGuide:  `[projectID: string]`,
+	RPCGenerateOutline:        `[projectID: string]`,
+	RPCGenerateSection:        `[projectID: string]`,
+	RPCStartDraft:             `[projectID: string]`,
+	RPCStartSection:           `[projectID: string]`,
+
+	RPCShareAudio:                   `[[shareOption: number], projectID: string]`,
+	RPCGetProjectDetails:            `[projectID: string]`,
+	RPCShareProject:                 `[[[projectID: string, users: [[string, null, number]...]|null, access: [number]|null, notify: bool]]]`,
+	RPCListRecentlyViewedGuidebooks: `[]`,
+}
+
+// ResultSchemas describe the responses of RPCs that are decoded from their
+// positions rather than through a proto message, in the notation of
+// ArgSchemas. Responses grow new fields, so their arrays end in a bare ...
+// to leave room for them.
+var ResultSchemas = map[string]string{
+	RPCListRecentlyViewedProjects: `[[projects: [title: string, any, projectID: string, emoji: string, ...]...], ...]`,
+	RPCShareAudio:                 `[[url: string, id: string, ...], ...]`,
+}
+
+// An ArgsError reports arguments that do not match the schema of their
+// RPC, with each difference.
+type ArgsError struct {
+	ID     string
+	Schema string
+	Sent   string
+	// Diffs name each mismatched position, such as "args[0][1]: want
+	// string, got number".
+	Diffs []string
+}
+
+func (e *ArgsError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "rpc %s: arguments do not match the schema:\n", e.ID)
+	for _, d := range e.Diffs {
+		fmt.Fprintf(&b, "  %s\n", d)
+	}
+	fmt.Fprintf(&b, "- schema %s\n+ sent   %s", e.Schema, e.Sent)
+	return b.String()
+}
+
+// CheckArgs checks args against the schema of the RPC id. RPCs without a
+// schema are not checked.
+func CheckArgs(id string, args []interface{}) error {
+	schema, ok := ArgSchemas[id]
+	if !ok {
+		return nil
+	}
+	p, err := parsePattern(schema)
+	if err != nil {
+		return fmt.Errorf("rpc %s: %w", id, err)
+	}
+	data, err := json.Marshal(args)
+	if err != nil {
+		return fmt.Errorf("rpc %s: encode arguments: %w", id, err)
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("rpc %s: decode arguments: %w", id, err)
+	}
+	if diffs := p.match("", v, true); len(diffs) > 0 {
+		sent := string(data)
+		if len(sent) > 500 {
+			sent = sent[:500] + "..."
+		}
+		return &ArgsError{ID: id, Schema: schema, Sent: sent, Diffs: diffs}
+	}
+	return nil
+}
+
+// A Pattern is a parsed schema position. It matches a JSON value that one
+// of its alternatives matches.
+type Pattern struct {
+	// Name is the name given to the position, if any.
+	Name string
+	// Text is the notation of the pattern, without names.
+	Text string
+	Alts []Alt
+}
+
+// An Alt is a scalar kind, or an array when Kind is "array".
+type Alt struct {
+	Kind  string
+	Elems []*Pattern
+	// Repeat makes the last element match any number of values; Open
+	// allows any values after Elems.
+	Repeat, Open bool
+}
+
+func (p *Pattern) String() string { return p.Text }
+
+// ParseSchema parses a schema in the notation of ArgSchemas.
+func ParseSchema(s string) (*Pattern, error) { return parsePattern(s) }
+
+// Matches reports whether v, a decoded JSON value, has the layout of p.
+func (p *Pattern) Matches(v interface{}) bool { return len(p.match("", v, true)) == 0 }
+
+// match returns the differences between v and p, with path the position
+// of v. present is false when v is missing from its array.
+func (p *Pattern) match(path string, v interface{}, present bool) []string {
+	var arrays []Alt
+	for _, a := range p.Alts {
+		if a.Kind == "array" {
+			arrays = append(arrays, a)
+			continue
+		}
+		if present && kindMatches(a.Kind, v) {
+			return nil
+		}
+	}
+	elems, isArray := v.([]interface{})
+	if present && isArray {
+		var best []string
+		for _, a := range arrays {
+			diffs := a.matchArray(path, elems)
+			if len(diffs) == 0 {
+				return nil
+			}
+			if best == nil || len(diffs) < len(best) {
+				best = diffs
+			}
+		}
+		// With a single array shape, point at the element that differs.
+		if len(arrays) == 1 {
+			return best
+		}
+	}
+	return []string{fmt.Sprintf("%s: want %s, got %s", pathName(path), p, describe(v, present))}
+}
+
+func (a Alt) matchArray(path string, elems []interface{}) []string {
+	var diffs []string
+	n := len(a.Elems)
+	if a.Repeat {
+		n--
+	}
+	for i := 0; i < n; i++ {
+		ok := i < len(elems)
+		var v interface{}
+		if ok {
+			v = elems[i]
+		}
+		diffs = append(diffs, a.Elems[i].match(fmt.Sprintf("%s[%d]", path, i), v, ok)...)
+	}
+	for i := n; i < len(elems); i++ {
+		switch {
+		case a.Repeat:
+			diffs = append(diffs, a.Elems[n].match(fmt.Sprintf("%s[%d]", path, i), elems[i], true)...)
+		case !a.Open:
+			diffs = append(diffs, fmt.Sprintf("%s: want nothing, got %s", pathName(fmt.Sprintf("%s[%d]", path, i)), describe(elems[i], true)))
+		}
+	}
+	return diffs
+}
+
+func kindMatches(kind string, v interface{}) bool {
+	switch kind {
+	case "any":
+		return true
+	case "null":
+		return v == nil
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "bool":
+		_, ok := v.(bool)
+		return ok
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	}
+	return false
+}
+
+func pathName(path string) string {
+	if path == "" {
+		return "args"
+	}
+	return "args" + path
+}
+
+// describe names the type of v for a difference.
+func describe(v interface{}, present bool) string {
+	if !present {
+		return "nothing"
+	}
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "bool"
+	case []interface{}:
+		return fmt.Sprintf("array of %d", len(v))
+	}
+	return "object"
+}
+
+// parsePattern parses the schema notation of ArgSchemas.
+func parsePattern(s string) (*Pattern, error) {
+	ps := &patternParser{s: s}
+	p, err := ps.pattern()
+	if err != nil {
+		return nil, err
+	}
+	ps.space()
+	if ps.i < len(ps.s) {
+		return nil, fmt.Errorf("schema %q: unexpected %q at %d", s, ps.s[ps.i:], ps.i)
+	}
+	return p, nil
+}
+
+type patternParser struct {
+	s string
+	i int
+}
+
+func (ps *patternParser) space() {
+	for ps.i < len(ps.s) && ps.s[ps.i] == ' ' {
+		ps.i++
+	}
+}
+
+func (ps *patternParser) consume(tok string) bool {
+	ps.space()
+	if strings.HasPrefix(ps.s[ps.i:], tok) {
+		ps.i += len(tok)
+		return true
+	}
+	return false
+}
+
+func (ps *patternParser) pattern() (*Pattern, error) {
+	ps.space()
+	p := &Pattern{Name: ps.name()}
+	start := ps.i
+	for {
+		a, err := ps.alt()
+		if err != nil {
+			return nil, err
+		}
+		p.Alts = append(p.Alts, a)
+		if !ps.consume("|") {
+			break
+		}
+	}
+	p.Text = nameRe.ReplaceAllString(strings.TrimSpace(ps.s[start:ps.i]), "")
+	return p, nil
+}
+
+// nameRe matches the name of a position and its colon.
+var nameRe = regexp.MustCompile(`[A-Za-z][A-Za-z0-9]*: *`)
+
+// name consumes the name of the position at ps.i, if it has one.
+func (ps *patternParser) name() string {
+	m := nameRe.FindStringIndex(ps.s[ps.i:])
+	if m == nil || m[0] != 0 {
+		return ""
+	}
+	name := strings.TrimRight(ps.s[ps.i:ps.i+m[1]], ": ")
+	ps.i += m[1]
+	return name
+}
+
+func (ps *patternParser) alt() (Alt, error) {
+	if ps.consume("[") {
+		a := Alt{Kind: "array"}
+		if ps.consume("]") {
+			return a, nil
+		}
+		for {
+			if ps.consume("...") {
+				a.Open = true
+			} else {
+				p, err := ps.pattern()
+				if err != nil {
+					return a, err
+				}
+				a.Elems = append(a.Elems, p)
+				a.Repeat = ps.consume("...")
+			}
+			if ps.consume("]") {
+				return a, nil
+			}
+			if a.Open || a.Repeat || !ps.consume(",") {
+				return a, fmt.Errorf("schema %q: want ] at %d", ps.s, ps.i)
+			}
+		}
+	}
+	ps.space()
+	for _, kind := range []string{"string", "number", "bool", "null", "object", "any"} {
+		if ps.consume(kind) {
+			return Alt{Kind: kind}, nil
+		}
+	}
+	return Alt{}, fmt.Errorf("schema %q: unknown type at %d", ps.s, ps.i)
+}
@@ -0,0 +1,155 @@
+package rpc
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestArgSchemasParse(t *testing.T) {
+	for id, s := range ArgSchemas {
+		if _, err := parsePattern(s); err != nil {
+			t.Errorf("%s: %v", id, err)
+		}
+	}
+	for id, s := range ResultSchemas {
+		if _, err := parsePattern(s); err != nil {
+			t.Errorf("%s result: %v", id, err)
+		}
+	}
+}
+
+func TestParseSchemaNames(t *testing.T) {
+	p, err := ParseSchema(`[id: string, [kind: number]|null, tags: [string...], ...]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `[string, [number]|null, [string...], ...]`; p.Text != want {
+		t.Errorf("Text = %q, want %q", p.Text, want)
+	}
+	elems := p.Alts[0].Elems
+	var names []string
+	for _, e := range elems {
+		names = append(names, e.Name)
+	}
+	if d := cmp.Diff([]string{"id", "", "tags"}, names); d != "" {
+		t.Errorf("names (-want +got):\n%s", d)
+	}
+	if got := elems[1].Alts[0].Elems[0].Name; got != "kind" {
+		t.Errorf("nested name = %q, want kind", got)
+	}
+	if !p.Alts[0].Open {
+		t.Error("Open = false")
+	}
+}
+
+func TestCheckArgs(t *testing.T) {
+	const nb = "fec1780c-5a14-4f07-8ee6-f8c3ee2930fa"
+	tests := []struct {
+		name  string
+		id    string
+		args  []interface{}
+		diffs []string
+	}{
+		{
+			name: "list",
+			id:   RPCListRecentlyViewedProjects,
+			args: []interface{}{nil, 1},
+		},
+		{
+			name: "text source",
+			id:   RPCAddSources,
+			args: []interface{}{[]interface{}{[]interface{}{nil, []string{"Title", "text"}, nil, 2}}, nb},
+		},
+		{
+			name: "url and youtube sources",
+			id:   RPCAddSources,
+			args: []interface{}{[]interface{}{
+				[]interface{}{nil, nil, []string{"https://example.com"}},
+				[]interface{}{nil, nil, "dQw4w9WgXcQ", nil, 9},
+			}, nb},
+		},
+		{
+			name:  "source without notebook",
+			id:    RPCAddSources,
+			args:  []interface{}{[]interface{}{[]interface{}{nil, []string{"Title", "text"}, nil, 2}}},
+			diffs: []string{"args[1]: want string, got nothing"},
+		},
+		{
+			name:  "shifted source fields",
+			id:    RPCAddSources,
+			args:  []interface{}{[]interface{}{[]interface{}{[]string{"Title", "text"}, nil, 2}}, nb},
+			diffs: []string{"args[0][0]: want [null, [string, string], null, number]|[string, string, string, string, number]|[null, null, [string]]|[null, null, string, null, number], got array of 3"},
+		},
+		{
+			name: "share",
+			id:   RPCShareProject,
+			args: []interface{}{[]interface{}{[]interface{}{nb, []interface{}{[]interface{}{"a@example.com", nil, 3}}, nil, true}}},
+		},
+		{
+			name:  "note type not in an array",
+			id:    RPCCreateNote,
+			args:  []interface{}{nb, "", 1, nil, "Title"},
+			diffs: []string{"args[2]: want [number], got number"},
+		},
+		{
+			name:  "extra argument",
+			id:    RPCGetProject,
+			args:  []interface{}{nb, 1},
+			diffs: []string{"args[1]: want nothing, got number"},
+		},
+		{
+			name: "unknown rpc",
+			id:   "xxxxxx",
+			args: []interface{}{1, 2, 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckArgs(tt.id, tt.args)
+			var ae *ArgsError
+			if err != nil && !errors.As(err, &ae) {
+				t.Fatalf("CheckArgs: %v", err)
+			}
+			var diffs []string
+			if ae != nil {
+				diffs = ae.Diffs
+			}
+			if d := cmp.Diff(tt.diffs, diffs); d != "" {
+				t.Errorf("diffs (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestArgsErrorShowsSchemaAndArguments(t *testing.T) {
+	err := CheckArgs(RPCCreateAudioOverview, []interface{}{"nb", 0, "focus"})
+	if err == nil {
+		t.Fatal("CheckArgs succeeded")
+	}
+	for _, want := range []string{
+		"rpc AHyHrd: arguments do not match the schema:\n",
+		"  args[2]: want [string], got string\n",
+		"- schema [projectID: string, audioType: number, [instructions: string]]\n",
+		`+ sent   ["nb",0,"focus"]`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error lacks %q:\n%s", want, err)
+		}
+	}
+}
+
+func TestWrites(t *testing.T) {
+	for _, id := range []string{RPCCreateProject, RPCDeleteSources, RPCMutateNote, RPCShareProject, RPCActOnSources, RPCGetOrCreateAccount, "unknown"} {
+		if !Writes(id) {
+			t.Errorf("Writes(%s) = false, want true", id)
+		}
+	}
+	for _, id := range []string{RPCListRecentlyViewedProjects, RPCGetProject, RPCGetNotes, RPCGenerateNotebookGuide} {
+		if Writes(id) {
+			t.Errorf("Writes(%s) = true, want false", id)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package rpc
+
+import "sort"
+
+// NotebookSetting describes a per-notebook setting: the call that reads
+// it and where its value sits in the response.
+type NotebookSetting struct {
+	Name        string
+	Description string
+	// Get is the call whose response holds the value.
+	Get string
+	// Path is the position of the value in the Get response, by array
+	// index.
+	Path []int
+	// Values maps names to the numbers on the wire. Settings without
+	// Values are free text.
+	Values map[string]int
+	// Default is the name shown when the response leaves the value out.
+	Default string
+}
+
+// NotebookSettings lists the notebook settings nlm can read. Field 7 of a
+// project is taken to hold its chat configuration as
+// [[goal, prompt], [length], [language]]. This layout and the enum values
+// have not been checked against a captured payload, so nlm only reads
+// the settings: a wrong guess shows a wrong value, where writing one could
+// corrupt the notebook's configuration. Add a way to change them only
+// together with a recorded request from the web client.
+var NotebookSettings = []NotebookSetting{
+	{
+		Name:        "chat.goal",
+		Description: "conversational style of chat answers",
+		Get:         RPCGetProject,
+		Path:        []int{6, 0, 0},
+		Values:      map[string]int{"default": 1, "custom": 2, "learning-guide": 3},
+		Default:     "default",
+	},
+	{
+		Name:        "chat.prompt",
+		Description: "instructions for chat answers, used when chat.goal is custom",
+		Get:         RPCGetProject,
+		Path:        []int{6, 0, 1},
+	},
+	{
+		Name:        "chat.length",
+		Description: "length of chat answers",
+		Get:         RPCGetProject,
+		Path:        []int{6, 1, 0},
+		Values:      map[string]int{"default": 1, "longer": 4, "shorter": 5},
+		Default:     "default",
+	},
+	{
+		Name:        "language",
+		Description: "language of answers and generated content, as a BCP 47 tag such as de or pt-BR",
+		Get:         RPCGetProject,
+		Path:        []int{6, 2, 0},
+		Default:     "auto",
+	},
+	{
+		Name:        "sharing.link",
+		Description: "access for anyone with the link",
+		Get:         RPCGetProjectDetails,
+		Path:        []int{1, 0},
+		Values:      map[string]int{"restricted": 0, "public": 1},
+		Default:     "restricted",
+	},
+}
+
+// LookupNotebookSetting returns the setting with the given name.
+func LookupNotebookSetting(name string) (NotebookSetting, bool) {
+	for _, s := range NotebookSettings {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return NotebookSetting{}, false
+}
+
+// ValueName returns the name of the wire value v, and false if the setting
+// has no name for it.
+func (s NotebookSetting) ValueName(v int) (string, bool) {
+	for name, n := range s.Values {
+		if n == v {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// ValueNames returns the names the setting accepts, sorted.
+func (s NotebookSetting) ValueNames() []string {
+	names := make([]string, 0, len(s.Values))
+	for name := range s.Values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,29 @@
+package rpc
+
+// reads are the calls known only to read data. Generating guides, outlines
+// and chat answers only reads the notebook, so those calls are listed too.
+var reads = map[string]bool{
+	RPCListRecentlyViewedProjects:   true,
+	RPCGetProject:                   true,
+	RPCLoadSource:                   true,
+	RPCCheckSourceFreshness:         true,
+	RPCGetNotes:                     true,
+	RPCGetAudioOverview:             true,
+	RPCGenerateDocumentGuides:       true,
+	RPCGenerateNotebookGuide:        true,
+	RPCGenerateOutline:              true,
+	RPCGenerateSection:              true,
+	RPCGetProjectAnalytics:          true,
+	RPCGetProjectDetails:            true,
+	RPCGetGuidebook:                 true,
+	RPCListRecentlyViewedGuidebooks: true,
+	RPCGetGuidebookDetails:          true,
+	RPCGuidebookGenerateAnswer:      true,
+}
+
+// Writes reports whether the call with the given ID may change data. Only
+// the calls known to read are assumed not to, so that read-only mode fails
+// closed for calls added later or sent by ID.
+func Writes(id string) bool {
+	return !reads[id]
+}
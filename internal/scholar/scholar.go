@@ -0,0 +1,291 @@
+// Package scholar resolves DOIs to paper metadata and open-access PDFs
+// with the Semantic Scholar and Unpaywall APIs.
+package scholar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Default API endpoints.
+const (
+	DefaultSemanticScholarURL = "https://api.semanticscholar.org/graph/v1"
+	DefaultUnpaywallURL       = "https://api.unpaywall.org/v2"
+)
+
+var (
+	// ErrNotFound is returned when neither service knows a DOI.
+	ErrNotFound = errors.New("paper not found")
+	// ErrNoPDF is returned by PDF for papers without an open-access copy.
+	ErrNoPDF = errors.New("no open-access PDF")
+)
+
+// A Paper is the resolved metadata of a DOI.
+type Paper struct {
+	DOI       string
+	Title     string
+	Authors   []string
+	Venue     string
+	Year      int
+	Published string // YYYY-MM-DD when known
+	Abstract  string
+	URL       string
+	// PDFURLs are candidate open-access PDFs, best first. Some point to
+	// landing pages rather than PDFs, so callers should check what they
+	// download.
+	PDFURLs []string
+	License string
+}
+
+var doiRE = regexp.MustCompile(`^10\.\d{4,9}/\S+$`)
+
+// NormalizeDOI returns a DOI without a doi.org URL or "doi:" prefix, in
+// lower case, as DOIs are case-insensitive.
+func NormalizeDOI(s string) (string, error) {
+	in := s
+	s = strings.TrimSpace(s)
+	for _, prefix := range []string{"https://doi.org/", "http://doi.org/", "https://dx.doi.org/", "http://dx.doi.org/", "doi:"} {
+		if len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix) {
+			s = strings.TrimSpace(s[len(prefix):])
+			break
+		}
+	}
+	if u, err := url.PathUnescape(s); err == nil {
+		s = u
+	}
+	s = strings.ToLower(s)
+	if !doiRE.MatchString(s) {
+		return "", fmt.Errorf("scholar: invalid DOI %q", in)
+	}
+	return s, nil
+}
+
+// A Client resolves DOIs. Its zero value queries Semantic Scholar only;
+// set Email to also query Unpaywall, which requires a contact address.
+type Client struct {
+	HTTP      *http.Client // defaults to a client with a 60s timeout
+	UserAgent string
+	// Email identifies the caller to Unpaywall.
+	Email string
+	// APIKey is an optional Semantic Scholar API key for higher rate
+	// limits.
+	APIKey string
+
+	SemanticScholarURL string
+	UnpaywallURL       string
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTP != nil {
+		return c.HTTP
+	}
+	return &http.Client{Timeout: 60 * time.Second}
+}
+
+// Resolve returns the metadata and open-access PDFs of a DOI, combining
+// both services: Unpaywall's PDF locations are preferred, and Semantic
+// Scholar supplies the abstract.
+func (c *Client) Resolve(ctx context.Context, doi string) (*Paper, error) {
+	doi, err := NormalizeDOI(doi)
+	if err != nil {
+		return nil, err
+	}
+	p := &Paper{DOI: doi, URL: "https://doi.org/" + doi}
+	s2err := c.semanticScholar(ctx, p)
+	var upErr error
+	if c.Email != "" {
+		upErr = c.unpaywall(ctx, p)
+	}
+	switch {
+	case p.Title != "":
+		p.PDFURLs = dedupe(p.PDFURLs)
+		return p, nil
+	case s2err != nil && !errors.Is(s2err, ErrNotFound):
+		return nil, s2err
+	case upErr != nil && !errors.Is(upErr, ErrNotFound):
+		return nil, upErr
+	}
+	return nil, fmt.Errorf("scholar: %s: %w", doi, ErrNotFound)
+}
+
+func (c *Client) semanticScholar(ctx context.Context, p *Paper) error {
+	base := c.SemanticScholarURL
+	if base == "" {
+		base = DefaultSemanticScholarURL
+	}
+	u := base + "/paper/DOI:" + p.DOI + "?fields=title,authors,venue,year,publicationDate,abstract,url,openAccessPdf,externalIds"
+	var r struct {
+		Title   string `json:"title"`
+		Authors []struct {
+			Name string `json:"name"`
+		} `json:"authors"`
+		Venue           string `json:"venue"`
+		Year            int    `json:"year"`
+		PublicationDate string `json:"publicationDate"`
+		Abstract        string `json:"abstract"`
+		URL             string `json:"url"`
+		OpenAccessPDF   *struct {
+			URL string `json:"url"`
+		} `json:"openAccessPdf"`
+		ExternalIDs map[string]interface{} `json:"externalIds"`
+	}
+	h := http.Header{}
+	if c.APIKey != "" {
+		h.Set("x-api-key", c.APIKey)
+	}
+	if err := c.getJSON(ctx, u, h, &r); err != nil {
+		return fmt.Errorf("semantic scholar: %w", err)
+	}
+	p.Title = strings.TrimSpace(r.Title)
+	for _, a := range r.Authors {
+		p.Authors = append(p.Authors, a.Name)
+	}
+	p.Venue, p.Year, p.Published = r.Venue, r.Year, r.PublicationDate
+	p.Abstract = strings.TrimSpace(r.Abstract)
+	if r.OpenAccessPDF != nil && r.OpenAccessPDF.URL != "" {
+		p.PDFURLs = append(p.PDFURLs, r.OpenAccessPDF.URL)
+	}
+	if id, ok := r.ExternalIDs["ArXiv"].(string); ok && id != "" {
+		p.PDFURLs = append(p.PDFURLs, "https://arxiv.org/pdf/"+id)
+	}
+	return nil
+}
+
+func (c *Client) unpaywall(ctx context.Context, p *Paper) error {
+	base := c.UnpaywallURL
+	if base == "" {
+		base = DefaultUnpaywallURL
+	}
+	u := base + "/" + url.PathEscape(p.DOI) + "?email=" + url.QueryEscape(c.Email)
+	type location struct {
+		URLForPDF string `json:"url_for_pdf"`
+		License   string `json:"license"`
+	}
+	var r struct {
+		Title   string `json:"title"`
+		Year    int    `json:"year"`
+		Journal string `json:"journal_name"`
+		Date    string `json:"published_date"`
+		Authors []struct {
+			Given  string `json:"given"`
+			Family string `json:"family"`
+		} `json:"z_authors"`
+		Best *location  `json:"best_oa_location"`
+		All  []location `json:"oa_locations"`
+	}
+	if err := c.getJSON(ctx, u, nil, &r); err != nil {
+		return fmt.Errorf("unpaywall: %w", err)
+	}
+	var pdfs []string
+	for _, l := range append([]*location{r.Best}, ptrs(r.All)...) {
+		if l == nil || l.URLForPDF == "" {
+			continue
+		}
+		pdfs = append(pdfs, l.URLForPDF)
+		if p.License == "" {
+			p.License = l.License
+		}
+	}
+	p.PDFURLs = append(pdfs, p.PDFURLs...)
+	if p.Title == "" {
+		p.Title = strings.TrimSpace(r.Title)
+		p.Venue, p.Year, p.Published = r.Journal, r.Year, r.Date
+		for _, a := range r.Authors {
+			p.Authors = append(p.Authors, strings.TrimSpace(a.Given+" "+a.Family))
+		}
+	}
+	return nil
+}
+
+// dedupe removes repeated strings, keeping the first of each.
+func dedupe(s []string) []string {
+	seen := make(map[string]bool, len(s))
+	out := s[:0]
+	for _, v := range s {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func ptrs[T any](s []T) []*T {
+	out := make([]*T, len(s))
+	for i := range s {
+		out[i] = &s[i]
+	}
+	return out
+}
+
+func (c *Client) getJSON(ctx context.Context, u string, h http.Header, v interface{}) error {
+	resp, err := c.get(ctx, u, h)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("parse response: %w", err)
+	}
+	return nil
+}
+
+// PDF downloads the first of a paper's candidate PDFs that is a PDF.
+func (c *Client) PDF(ctx context.Context, p *Paper) ([]byte, error) {
+	if len(p.PDFURLs) == 0 {
+		return nil, ErrNoPDF
+	}
+	var errs []error
+	for _, u := range p.PDFURLs {
+		resp, err := c.get(ctx, u, nil)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		data, err := io.ReadAll(io.LimitReader(resp.Body, 200<<20))
+		resp.Body.Close()
+		switch {
+		case err != nil:
+			errs = append(errs, err)
+		case !strings.HasPrefix(string(data), "%PDF"):
+			errs = append(errs, fmt.Errorf("%s is not a PDF", u))
+		default:
+			return data, nil
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+func (c *Client) get(ctx context.Context, u string, h http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range h {
+		req.Header[k] = v
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrNotFound
+	case resp.StatusCode >= 400:
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %s", req.URL.Redacted(), resp.Status)
+	}
+	return resp, nil
+}
@@ -0,0 +1,109 @@
+package scholar
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNormalizeDOI(t *testing.T) {
+	tests := []struct {
+		in, want string
+		wantErr  bool
+	}{
+		{in: "10.1101/2024.01.01.123456", want: "10.1101/2024.01.01.123456"},
+		{in: "https://doi.org/10.1038/NATURE12373", want: "10.1038/nature12373"},
+		{in: "doi:10.1145/3292500.3330701", want: "10.1145/3292500.3330701"},
+		{in: "https://doi.org/10.1002%2F%28SICI%291097", want: "10.1002/(sici)1097"},
+		{in: "11.1234/x", wantErr: true},
+		{in: "10.1101", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeDOI(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NormalizeDOI(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NormalizeDOI(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func testServer(t *testing.T) *httptest.Server {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/s2/paper/DOI:10.1234/open":
+			w.Write([]byte(`{"title":"Open Paper","authors":[{"name":"Ada Lovelace"}],"venue":"Nature","year":2024,
+				"publicationDate":"2024-03-01","abstract":"We study things.","openAccessPdf":{"url":"` + srv.URL + `/landing"},
+				"externalIds":{"ArXiv":"2401.12345","DOI":"10.1234/open"}}`))
+		case r.URL.Path == "/up/10.1234/open":
+			if r.URL.Query().Get("email") != "me@example.com" {
+				t.Errorf("unpaywall email = %q", r.URL.Query().Get("email"))
+			}
+			w.Write([]byte(`{"title":"Open Paper","best_oa_location":{"url_for_pdf":"` + srv.URL + `/paper.pdf","license":"cc-by"},
+				"oa_locations":[{"url_for_pdf":"` + srv.URL + `/paper.pdf"},{"url_for_pdf":"` + srv.URL + `/landing"},{"url_for_pdf":null}]}`))
+		case r.URL.Path == "/up/10.1234/closed":
+			w.Write([]byte(`{"title":"Closed Paper","year":2020,"journal_name":"J","z_authors":[{"given":"Alan","family":"Turing"}],"best_oa_location":null}`))
+		case r.URL.Path == "/landing":
+			w.Write([]byte("<html>landing page</html>"))
+		case r.URL.Path == "/paper.pdf":
+			w.Write([]byte("%PDF-1.7 ..."))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	return srv
+}
+
+func TestResolve(t *testing.T) {
+	srv := testServer(t)
+	defer srv.Close()
+	c := &Client{Email: "me@example.com", SemanticScholarURL: srv.URL + "/s2", UnpaywallURL: srv.URL + "/up"}
+
+	p, err := c.Resolve(context.Background(), "https://doi.org/10.1234/OPEN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Paper{
+		DOI:       "10.1234/open",
+		Title:     "Open Paper",
+		Authors:   []string{"Ada Lovelace"},
+		Venue:     "Nature",
+		Year:      2024,
+		Published: "2024-03-01",
+		Abstract:  "We study things.",
+		URL:       "https://doi.org/10.1234/open",
+		PDFURLs:   []string{srv.URL + "/paper.pdf", srv.URL + "/landing", "https://arxiv.org/pdf/2401.12345"},
+		License:   "cc-by",
+	}
+	if diff := cmp.Diff(want, p); diff != "" {
+		t.Errorf("Resolve mismatch (-want +got):\n%s", diff)
+	}
+	pdf, err := c.PDF(context.Background(), &Paper{PDFURLs: []string{srv.URL + "/landing", srv.URL + "/paper.pdf"}})
+	if err != nil || !strings.HasPrefix(string(pdf), "%PDF") {
+		t.Errorf("PDF = %q, %v; want the PDF after skipping the landing page", pdf, err)
+	}
+
+	// Semantic Scholar does not know the paper, but Unpaywall does.
+	p, err = c.Resolve(context.Background(), "10.1234/closed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Title != "Closed Paper" || len(p.PDFURLs) != 0 || !cmp.Equal(p.Authors, []string{"Alan Turing"}) {
+		t.Errorf("Resolve(closed) = %+v", p)
+	}
+	if _, err := c.PDF(context.Background(), p); err == nil {
+		t.Error("PDF succeeded without candidates")
+	}
+
+	if _, err := c.Resolve(context.Background(), "10.1234/missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Resolve(missing) error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,116 @@
+package state
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Sealed files start with magic, followed by the scrypt salt, the GCM nonce
+// and the ciphertext.
+var magic = []byte("NLMENC1\n")
+
+const (
+	saltSize = 16
+	keySize  = 32
+
+	// scrypt parameters recommended for interactive use.
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+func isSealed(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+// sealer encrypts and decrypts file contents. Deriving a key with scrypt is
+// deliberately slow, so derived keys are cached per salt and every file
+// written by one process shares a salt.
+type sealer struct {
+	secret []byte
+
+	mu   sync.Mutex
+	salt []byte
+	keys map[string]cipher.AEAD
+}
+
+func newSealer(secret []byte) *sealer {
+	return &sealer{secret: secret, keys: make(map[string]cipher.AEAD)}
+}
+
+func (s *sealer) aead(salt []byte) (cipher.AEAD, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a, ok := s.keys[string(salt)]; ok {
+		return a, nil
+	}
+	key, err := scrypt.Key(s.secret, salt, scryptN, scryptR, scryptP, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	a, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	s.keys[string(salt)] = a
+	return a, nil
+}
+
+func (s *sealer) seal(plain []byte) ([]byte, error) {
+	s.mu.Lock()
+	if s.salt == nil {
+		s.salt = make([]byte, saltSize)
+		if _, err := rand.Read(s.salt); err != nil {
+			s.mu.Unlock()
+			return nil, fmt.Errorf("generate salt: %w", err)
+		}
+	}
+	salt := s.salt
+	s.mu.Unlock()
+
+	a, err := s.aead(salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, a.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+	out := make([]byte, 0, len(magic)+saltSize+len(nonce)+len(plain)+a.Overhead())
+	out = append(out, magic...)
+	out = append(out, salt...)
+	out = append(out, nonce...)
+	return a.Seal(out, nonce, plain, magic), nil
+}
+
+func (s *sealer) open(data []byte) ([]byte, error) {
+	data = data[len(magic):]
+	if len(data) < saltSize {
+		return nil, errors.New("encrypted file is truncated")
+	}
+	salt, data := data[:saltSize], data[saltSize:]
+	a, err := s.aead(salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < a.NonceSize() {
+		return nil, errors.New("encrypted file is truncated")
+	}
+	nonce, data := data[:a.NonceSize()], data[a.NonceSize():]
+	plain, err := a.Open(nil, nonce, data, magic)
+	if err != nil {
+		return nil, errors.New("decrypt failed: wrong passphrase or corrupted file")
+	}
+	return plain, nil
+}
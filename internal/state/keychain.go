@@ -0,0 +1,46 @@
+package state
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+const (
+	keychainService = "nlm"
+	keychainAccount = "state-encryption-key"
+)
+
+// errKeyNotFound is returned by keychainGet when the keychain has no key
+// for nlm, as opposed to failing to answer.
+var errKeyNotFound = errors.New("no key in keychain")
+
+// KeychainKey returns the state encryption key kept in the operating system
+// keychain, generating and storing a new random key on first use.
+func KeychainKey() ([]byte, error) {
+	return keychainKey(keychainGet, keychainSet)
+}
+
+// keychainKey is KeychainKey with the keychain access passed in. A new key
+// is only generated when the keychain reports that it has none: a locked
+// keychain or a timeout must not replace the key that existing state is
+// encrypted with.
+func keychainKey(get func(service, account string) (string, error), set func(service, account, secret string) error) ([]byte, error) {
+	key, err := get(keychainService, keychainAccount)
+	if err == nil && key != "" {
+		return []byte(key), nil
+	}
+	if err != nil && !errors.Is(err, errKeyNotFound) {
+		return nil, fmt.Errorf("read key from keychain: %w", err)
+	}
+	b := make([]byte, keySize)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("generate key: %w", err)
+	}
+	key = hex.EncodeToString(b)
+	if err := set(keychainService, keychainAccount, key); err != nil {
+		return nil, fmt.Errorf("store key in keychain: %w", err)
+	}
+	return []byte(key), nil
+}
@@ -0,0 +1,35 @@
+//go:build darwin
+
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// notFoundStatus is the exit status of security find-generic-password
+// when there is no such item.
+const notFoundStatus = 44
+
+func keychainGet(service, account string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", service, "-a", account, "-w").Output()
+	var ee *exec.ExitError
+	if errors.As(err, &ee) && ee.ExitCode() == notFoundStatus {
+		return "", errKeyNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// keychainSet runs security in interactive mode with the command on stdin,
+// so that the secret is not in its arguments, where ps would show it. The
+// service, account and hex key have no spaces, so need no quoting.
+func keychainSet(service, account, secret string) error {
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n", service, account, secret))
+	return cmd.Run()
+}
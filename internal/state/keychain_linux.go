@@ -0,0 +1,40 @@
+//go:build linux
+
+package state
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// The Secret Service is reached through libsecret's secret-tool.
+
+// keychainGet looks up the secret. secret-tool lookup exits with status 1
+// and prints nothing both when there is no such secret and, with a message
+// on stderr, when it fails, so only a silent failure means not found.
+func keychainGet(service, account string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("secret-tool", "lookup", "service", service, "account", account)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	var ee *exec.ExitError
+	if errors.As(err, &ee) && ee.ExitCode() == 1 && len(out) == 0 && stderr.Len() == 0 {
+		return "", errKeyNotFound
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func keychainSet(service, account, secret string) error {
+	cmd := exec.Command("secret-tool", "store", "--label=nlm state key", "service", service, "account", account)
+	cmd.Stdin = strings.NewReader(secret)
+	return cmd.Run()
+}
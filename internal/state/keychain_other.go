@@ -0,0 +1,15 @@
+//go:build !darwin && !linux
+
+package state
+
+import "errors"
+
+var errNoKeychain = errors.New("keychain not supported on this platform; use NLM_STATE_PASSPHRASE")
+
+func keychainGet(service, account string) (string, error) {
+	return "", errNoKeychain
+}
+
+func keychainSet(service, account, secret string) error {
+	return errNoKeychain
+}
@@ -0,0 +1,31 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Lock takes an exclusive lock on the named file, waiting while another
+// process holds it, and returns a func that releases it. Processes that
+// read, change and write back the same file take its lock first, so that
+// none of them overwrites the others' changes. The lock is a separate
+// file beside the named one.
+func (s *Store) Lock(name string) (unlock func(), err error) {
+	path := s.Path(name) + ".lock"
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("lock %s: %w", name, err)
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,171 @@
+// Package state stores nlm's local files (credentials, caches, history and
+// request logs) under a single directory, optionally encrypting them at rest.
+//
+// Files written while a passphrase or keychain key is configured are sealed
+// with AES-256-GCM. Reading is transparent: plaintext files written before
+// encryption was enabled are still readable, and are sealed on their next
+// write.
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ErrLocked is returned when reading an encrypted file without a key.
+var ErrLocked = errors.New("state file is encrypted; set NLM_STATE_PASSPHRASE or NLM_STATE_KEYCHAIN=1")
+
+// Dir returns the state directory: home if it is set, as from NLM_HOME,
+// or else ~/.nlm.
+func Dir(home string) (string, error) {
+	if home != "" {
+		return home, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("get home dir: %w", err)
+	}
+	return filepath.Join(home, ".nlm"), nil
+}
+
+// Store reads and writes files in a state directory.
+type Store struct {
+	dir    string
+	sealer *sealer // nil when encryption is disabled
+}
+
+// Option configures a Store.
+type Option func(*Store)
+
+// WithPassphrase enables encryption with a key derived from passphrase.
+func WithPassphrase(passphrase string) Option {
+	return func(s *Store) {
+		s.sealer = newSealer([]byte(passphrase))
+	}
+}
+
+// WithKey enables encryption with a random key, such as one kept in the
+// operating system keychain.
+func WithKey(key []byte) Option {
+	return func(s *Store) {
+		s.sealer = newSealer(key)
+	}
+}
+
+// Open returns a Store for dir, creating the directory if needed.
+func Open(dir string, opts ...Option) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create state dir: %w", err)
+	}
+	s := &Store{dir: dir}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// Dir returns the state directory.
+func (s *Store) Dir() string { return s.dir }
+
+// Path returns the absolute path of the named state file.
+func (s *Store) Path(name string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(name))
+}
+
+// Encrypted reports whether files are sealed on write.
+func (s *Store) Encrypted() bool { return s.sealer != nil }
+
+// ReadFile returns the contents of the named file, decrypting it if needed.
+func (s *Store) ReadFile(name string) ([]byte, error) {
+	data, err := os.ReadFile(s.Path(name))
+	if err != nil {
+		return nil, err
+	}
+	if !isSealed(data) {
+		return data, nil
+	}
+	if s.sealer == nil {
+		return nil, fmt.Errorf("%s: %w", name, ErrLocked)
+	}
+	plain, err := s.sealer.open(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return plain, nil
+}
+
+// WriteFile atomically replaces the named file with data, encrypting it when
+// the store has a key. Files are only readable by the current user.
+func (s *Store) WriteFile(name string, data []byte) error {
+	if s.sealer != nil {
+		sealed, err := s.sealer.seal(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		data = sealed
+	}
+	return writeFileAtomic(s.Path(name), data, 0o600)
+}
+
+// Remove deletes the named file. Removing a missing file is not an error.
+func (s *Store) Remove(name string) error {
+	if err := os.Remove(s.Path(name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Load decodes the named JSON file into v. A missing file leaves v unchanged
+// and is not an error.
+func (s *Store) Load(name string, v interface{}) error {
+	data, err := s.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("parse %s: %w", name, err)
+	}
+	return nil
+}
+
+// Save encodes v as JSON into the named file.
+func (s *Store) Save(name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", name, err)
+	}
+	return s.WriteFile(name, append(data, '\n'))
+}
+
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-"+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
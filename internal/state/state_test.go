@@ -0,0 +1,128 @@
+package state
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncryptedRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, WithPassphrase("correct horse"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte("NLM_COOKIES=\"SID=secret\"\n")
+	if err := s.WriteFile("env", want); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := os.ReadFile(s.Path("env"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(raw, []byte("secret")) {
+		t.Error("encrypted file contains plaintext")
+	}
+
+	// A fresh store with the same passphrase can read it.
+	s2, _ := Open(dir, WithPassphrase("correct horse"))
+	got, err := s2.ReadFile("env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("ReadFile = %q, want %q", got, want)
+	}
+
+	plain, _ := Open(dir)
+	if _, err := plain.ReadFile("env"); !errors.Is(err, ErrLocked) {
+		t.Errorf("ReadFile without key: err = %v, want ErrLocked", err)
+	}
+
+	wrong, _ := Open(dir, WithPassphrase("wrong"))
+	if _, err := wrong.ReadFile("env"); err == nil {
+		t.Error("ReadFile with wrong passphrase succeeded")
+	}
+}
+
+func TestPlaintextStillReadable(t *testing.T) {
+	dir := t.TempDir()
+	plain, _ := Open(dir)
+	if err := plain.Save("pins.json", []string{"a", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	enc, _ := Open(dir, WithPassphrase("p"))
+	var got []string
+	if err := enc.Load("pins.json", &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Errorf("Load = %v, want 2 entries", got)
+	}
+	var missing []string
+	if err := enc.Load("missing.json", &missing); err != nil || missing != nil {
+		t.Errorf("Load(missing) = %v, %v; want nil, nil", missing, err)
+	}
+}
+
+func TestKeychainKey(t *testing.T) {
+	var stored string
+	set := func(service, account, secret string) error {
+		stored = secret
+		return nil
+	}
+	missing := func(service, account string) (string, error) { return "", errKeyNotFound }
+	key, err := keychainKey(missing, set)
+	if err != nil || string(key) != stored || len(key) != 2*keySize {
+		t.Fatalf("first use: key %q, stored %q, err %v", key, stored, err)
+	}
+
+	present := func(service, account string) (string, error) { return stored, nil }
+	if again, err := keychainKey(present, set); err != nil || string(again) != string(key) {
+		t.Errorf("second use = %q, %v; want the stored key", again, err)
+	}
+
+	stored = "kept"
+	locked := func(service, account string) (string, error) { return "", errors.New("keychain locked") }
+	if _, err := keychainKey(locked, set); err == nil || !strings.Contains(err.Error(), "locked") {
+		t.Errorf("locked keychain: err = %v", err)
+	}
+	if stored != "kept" {
+		t.Errorf("a locked keychain replaced the stored key with %q", stored)
+	}
+}
+
+func TestLock(t *testing.T) {
+	s, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock, err := s.Lock("quota.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	locked := make(chan func())
+	go func() {
+		unlock, err := s.Lock("quota.json")
+		if err != nil {
+			t.Error(err)
+		}
+		locked <- unlock
+	}()
+	select {
+	case <-locked:
+		t.Fatal("second Lock returned while the first was held")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case unlock := <-locked:
+		unlock()
+	case <-time.After(5 * time.Second):
+		t.Fatal("second Lock did not return after Unlock")
+	}
+}
@@ -0,0 +1,313 @@
+// Package table renders column aligned text tables for terminal output.
+//
+// Tables are sized to fit a target width: when the natural width of the
+// rows exceeds it, the widest columns are shrunk and their cells are either
+// truncated with an ellipsis or wrapped onto continuation lines. Columns whose
+// cells are all numeric are right aligned.
+package table
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/width"
+)
+
+const (
+	ansiBold  = "\x1b[1m"
+	ansiReset = "\x1b[0m"
+
+	// minColumnWidth is the narrowest a column is shrunk to when fitting.
+	minColumnWidth = 8
+
+	ellipsis = "…"
+)
+
+// Table is a set of rows rendered under a header line.
+type Table struct {
+	Headers []string
+	Rows    [][]string
+
+	// Width is the maximum line width. Zero means unlimited.
+	Width int
+	// Color enables ANSI styling of the header line.
+	Color bool
+	// Wrap wraps overflowing cells onto continuation lines instead of
+	// truncating them.
+	Wrap bool
+	// Padding is the number of spaces between columns. Zero means 2.
+	Padding int
+}
+
+// New returns a table with the given column headers.
+func New(headers ...string) *Table {
+	return &Table{Headers: headers}
+}
+
+// Append adds a row. Missing trailing cells are rendered empty.
+func (t *Table) Append(cells ...string) {
+	t.Rows = append(t.Rows, cells)
+}
+
+// Render writes the table to w.
+func (t *Table) Render(w io.Writer) error {
+	padding := t.Padding
+	if padding == 0 {
+		padding = 2
+	}
+	widths := t.columnWidths(padding)
+	numeric := t.numericColumns()
+
+	var b strings.Builder
+	header := t.formatLine(t.Headers, widths, nil, padding)
+	for _, line := range header {
+		if t.Color {
+			line = ansiBold + line + ansiReset
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	for _, row := range t.Rows {
+		for _, line := range t.formatLine(row, widths, numeric, padding) {
+			b.WriteString(line)
+			b.WriteByte('\n')
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// RenderCSV writes the table to w as CSV as described in RFC 4180: a
+// header record, then one record per row, with CRLF line endings and
+// cells quoted where needed. Cells are written whole, and short rows are
+// padded so that every record has a field for each header.
+func (t *Table) RenderCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.UseCRLF = true
+	if err := cw.Write(t.Headers); err != nil {
+		return err
+	}
+	for _, row := range t.Rows {
+		if len(row) < len(t.Headers) {
+			row = append(row[:len(row):len(row)], make([]string, len(t.Headers)-len(row))...)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// columnWidths returns the rendered width of each column, shrinking the
+// widest columns until the table fits in t.Width.
+func (t *Table) columnWidths(padding int) []int {
+	n := len(t.Headers)
+	for _, row := range t.Rows {
+		if len(row) > n {
+			n = len(row)
+		}
+	}
+	widths := make([]int, n)
+	measure := func(cells []string) {
+		for i, c := range cells {
+			if w := StringWidth(c); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+	measure(t.Headers)
+	for _, row := range t.Rows {
+		measure(row)
+	}
+	if t.Width <= 0 {
+		return widths
+	}
+
+	total := func() int {
+		sum := padding * (len(widths) - 1)
+		for _, w := range widths {
+			sum += w
+		}
+		return sum
+	}
+	for total() > t.Width {
+		widest := 0
+		for i, w := range widths {
+			if w > widths[widest] {
+				widest = i
+			}
+		}
+		if widths[widest] <= minColumnWidth {
+			break
+		}
+		widths[widest]--
+	}
+	return widths
+}
+
+// numericColumns reports which columns contain only numbers (or are empty).
+func (t *Table) numericColumns() []bool {
+	numeric := make([]bool, len(t.Headers))
+	for i := range numeric {
+		numeric[i] = len(t.Rows) > 0
+	}
+	for _, row := range t.Rows {
+		for i := range numeric {
+			if i >= len(row) || row[i] == "" {
+				continue
+			}
+			if _, err := strconv.ParseFloat(strings.TrimSpace(row[i]), 64); err != nil {
+				numeric[i] = false
+			}
+		}
+	}
+	return numeric
+}
+
+// formatLine lays out one row, returning more than one line when cells wrap.
+func (t *Table) formatLine(cells []string, widths []int, numeric []bool, padding int) []string {
+	columns := make([][]string, len(widths))
+	height := 1
+	for i, width := range widths {
+		var cell string
+		if i < len(cells) {
+			cell = cells[i]
+		}
+		if t.Wrap {
+			columns[i] = wrap(cell, width)
+		} else {
+			columns[i] = []string{truncate(cell, width)}
+		}
+		if len(columns[i]) > height {
+			height = len(columns[i])
+		}
+	}
+
+	lines := make([]string, height)
+	for l := range lines {
+		var b strings.Builder
+		for i, width := range widths {
+			var cell string
+			if l < len(columns[i]) {
+				cell = columns[i][l]
+			}
+			pad := strings.Repeat(" ", width-StringWidth(cell))
+			cell = isolateRTL(cell)
+			if numeric != nil && i < len(numeric) && numeric[i] {
+				b.WriteString(pad + cell)
+			} else {
+				b.WriteString(cell + pad)
+			}
+			if i < len(widths)-1 {
+				b.WriteString(strings.Repeat(" ", padding))
+			}
+		}
+		lines[l] = strings.TrimRight(b.String(), " ")
+	}
+	return lines
+}
+
+// truncate shortens s to at most width columns, marking the cut with an
+// ellipsis.
+func truncate(s string, width int) string {
+	if StringWidth(s) <= width {
+		return s
+	}
+	var b strings.Builder
+	used := 0
+	for _, r := range s {
+		w := runeWidth(r)
+		if used+w > width-1 {
+			break
+		}
+		b.WriteRune(r)
+		used += w
+	}
+	return b.String() + ellipsis
+}
+
+// wrap breaks s into lines of at most width columns, preferring to break at
+// spaces.
+func wrap(s string, width int) []string {
+	var lines []string
+	for StringWidth(s) > width {
+		cut, used, lastSpace := 0, 0, -1
+		for i, r := range s {
+			w := runeWidth(r)
+			if used+w > width {
+				break
+			}
+			if r == ' ' {
+				lastSpace = i
+			}
+			used += w
+			cut = i + utf8.RuneLen(r)
+		}
+		if lastSpace > 0 {
+			cut = lastSpace
+		}
+		if cut == 0 {
+			// A single rune wider than the column; emit it anyway.
+			_, size := utf8.DecodeRuneInString(s)
+			cut = size
+		}
+		lines = append(lines, strings.TrimRight(s[:cut], " "))
+		s = strings.TrimLeft(s[cut:], " ")
+	}
+	return append(lines, s)
+}
+
+// StringWidth returns the number of terminal columns needed to display s.
+func StringWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeWidth(r)
+	}
+	return n
+}
+
+// runeWidth returns the number of terminal columns needed to display r.
+// Combining marks, format characters (zero width joiners, bidi controls) and
+// variation selectors take no space; East Asian wide and fullwidth runes and
+// pictographic emoji take two columns.
+func runeWidth(r rune) int {
+	switch {
+	case r == 0, unicode.Is(unicode.Mn, r), unicode.Is(unicode.Me, r), unicode.Is(unicode.Cf, r):
+		return 0
+	case unicode.IsControl(r):
+		return 0
+	case isEmoji(r):
+		return 2
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// isEmoji reports whether r is in one of the pictographic blocks terminals
+// render as double width.
+func isEmoji(r rune) bool {
+	return (r >= 0x1F300 && r <= 0x1F64F) || // Misc Symbols and Pictographs, Emoticons
+		(r >= 0x1F680 && r <= 0x1F6FF) || // Transport and Map
+		(r >= 0x1F900 && r <= 0x1F9FF) || // Supplemental Symbols and Pictographs
+		(r >= 0x1FA70 && r <= 0x1FAFF) // Symbols and Pictographs Extended-A
+}
+
+// isolateRTL wraps s in Unicode first-strong isolates when it contains
+// right-to-left text, so bidi reordering stays inside the cell instead of
+// swapping neighbouring columns.
+func isolateRTL(s string) string {
+	for _, r := range s {
+		if unicode.In(r, unicode.Hebrew, unicode.Arabic, unicode.Syriac, unicode.Thaana, unicode.Nko) {
+			return "\u2068" + s + "\u2069"
+		}
+	}
+	return s
+}
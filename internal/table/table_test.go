@@ -0,0 +1,150 @@
+package table
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name  string
+		table *Table
+		want  string
+	}{
+		{
+			name: "natural width",
+			table: &Table{
+				Headers: []string{"ID", "TITLE", "SOURCES"},
+				Rows: [][]string{
+					{"a1", "Short", "3"},
+					{"b2", "A somewhat longer title", "12"},
+				},
+			},
+			want: `
+ID  TITLE                    SOURCES
+a1  Short                          3
+b2  A somewhat longer title       12
+`,
+		},
+		{
+			name: "truncate to width",
+			table: &Table{
+				Headers: []string{"ID", "TITLE"},
+				Rows: [][]string{
+					{"a1", "A title that is far too long to fit"},
+				},
+				Width: 20,
+			},
+			want: `
+ID  TITLE
+a1  A title that is…
+`,
+		},
+		{
+			name: "wrap to width",
+			table: &Table{
+				Headers: []string{"ID", "TITLE"},
+				Rows: [][]string{
+					{"a1", "A title that is far too long"},
+				},
+				Width: 20,
+				Wrap:  true,
+			},
+			want: `
+ID  TITLE
+a1  A title that is
+    far too long
+`,
+		},
+		{
+			name: "wide runes",
+			table: &Table{
+				Headers: []string{"ID", "TITLE", "N"},
+				Rows: [][]string{
+					{"a1", "📙 研究ノート", "1"},
+					{"b2", "Cafe\u0301", "2"},
+				},
+			},
+			want: "\n" +
+				"ID  TITLE          N\n" +
+				"a1  📙 研究ノート  1\n" +
+				"b2  Cafe\u0301           2\n",
+		},
+		{
+			name: "rtl isolated",
+			table: &Table{
+				Headers: []string{"ID", "TITLE"},
+				Rows:    [][]string{{"a1", "ملاحظات"}},
+			},
+			want: "\nID  TITLE\na1  \u2068ملاحظات\u2069\n",
+		},
+		{
+			name: "color header",
+			table: &Table{
+				Headers: []string{"ID"},
+				Rows:    [][]string{{"x"}},
+				Color:   true,
+			},
+			want: "\n" + ansiBold + "ID" + ansiReset + "\nx\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := tt.table.Render(&b); err != nil {
+				t.Fatal(err)
+			}
+			want := strings.TrimPrefix(tt.want, "\n")
+			if diff := cmp.Diff(want, b.String()); diff != "" {
+				t.Errorf("Render mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRenderCSV(t *testing.T) {
+	tbl := &Table{
+		Headers: []string{"ID", "TITLE", "SOURCES"},
+		Rows: [][]string{
+			{"a1", "Plain", "3"},
+			{"b2", `Quotes "and", commas`, "12"},
+			{"c3", "Two\nlines"},
+		},
+		Width: 10, // ignored
+	}
+	var b strings.Builder
+	if err := tbl.RenderCSV(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := "ID,TITLE,SOURCES\r\n" +
+		"a1,Plain,3\r\n" +
+		"b2,\"Quotes \"\"and\"\", commas\",12\r\n" +
+		"c3,\"Two\r\nlines\",\r\n" // line breaks in cells become CRLF too
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("RenderCSV mismatch (-want +got):\n%s", diff)
+	}
+	if len(tbl.Rows[2]) != 2 {
+		t.Error("RenderCSV changed the rows")
+	}
+}
+
+func TestStringWidth(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"abc", 3},
+		{"研究", 4},
+		{"📙", 2},
+		{"Cafe\u0301", 4},
+		{"ＡＢ", 4},
+		{"a\u200db", 2},
+	}
+	for _, tt := range tests {
+		if got := StringWidth(tt.in); got != tt.want {
+			t.Errorf("StringWidth(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
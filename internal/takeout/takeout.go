@@ -0,0 +1,263 @@
+// Package takeout reads Google Keep notes and Google Tasks lists from
+// Google Takeout archives.
+package takeout
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A KeepNote is a Google Keep note.
+type KeepNote struct {
+	ID       string // name of the note's file in the archive, without .json
+	Title    string
+	Text     string
+	Items    []ListItem // for checklists
+	Labels   []string
+	Links    []string
+	Pinned   bool
+	Archived bool
+	Trashed  bool
+	Created  time.Time
+	Edited   time.Time
+}
+
+// A ListItem is an entry of a checklist.
+type ListItem struct {
+	Text    string
+	Checked bool
+}
+
+// Markdown renders the note's content, with checklists as task lists.
+func (n KeepNote) Markdown() string {
+	var b strings.Builder
+	if t := strings.TrimSpace(n.Text); t != "" {
+		b.WriteString(t + "\n")
+	}
+	if len(n.Items) > 0 && b.Len() > 0 {
+		b.WriteString("\n")
+	}
+	for _, it := range n.Items {
+		box := " "
+		if it.Checked {
+			box = "x"
+		}
+		fmt.Fprintf(&b, "- [%s] %s\n", box, it.Text)
+	}
+	if len(n.Links) > 0 {
+		b.WriteString("\nLinks:\n")
+		for _, l := range n.Links {
+			fmt.Fprintf(&b, "- %s\n", l)
+		}
+	}
+	return b.String()
+}
+
+// A TaskList is a Google Tasks list.
+type TaskList struct {
+	ID      string
+	Title   string
+	Updated time.Time
+	Tasks   []Task
+}
+
+// A Task is an entry of a task list.
+type Task struct {
+	Title     string
+	Notes     string
+	Completed bool
+	Due       time.Time
+}
+
+// Markdown renders the list as a task list, open tasks first.
+func (l TaskList) Markdown() string {
+	tasks := append([]Task(nil), l.Tasks...)
+	sort.SliceStable(tasks, func(i, j int) bool { return !tasks[i].Completed && tasks[j].Completed })
+	var b strings.Builder
+	for _, t := range tasks {
+		box := " "
+		if t.Completed {
+			box = "x"
+		}
+		fmt.Fprintf(&b, "- [%s] %s", box, t.Title)
+		if !t.Due.IsZero() {
+			fmt.Fprintf(&b, " (due %s)", t.Due.Format("2006-01-02"))
+		}
+		b.WriteString("\n")
+		for _, line := range strings.Split(strings.TrimSpace(t.Notes), "\n") {
+			if line != "" {
+				fmt.Fprintf(&b, "  %s\n", line)
+			}
+		}
+	}
+	return b.String()
+}
+
+// An Archive is the Keep and Tasks content of one or more Takeout zips.
+type Archive struct {
+	Notes []KeepNote // by last edit, oldest first
+	Lists []TaskList
+}
+
+// Open reads the Takeout zips at paths; a large export is split across
+// several.
+func Open(paths ...string) (*Archive, error) {
+	a := new(Archive)
+	for _, p := range paths {
+		zr, err := zip.OpenReader(p)
+		if err != nil {
+			return nil, fmt.Errorf("takeout: %w", err)
+		}
+		err = a.read(&zr.Reader)
+		zr.Close()
+		if err != nil {
+			return nil, fmt.Errorf("takeout: %s: %w", p, err)
+		}
+	}
+	a.sort()
+	return a, nil
+}
+
+// Read reads the Keep and Tasks content of a Takeout zip.
+func Read(zr *zip.Reader) (*Archive, error) {
+	a := new(Archive)
+	if err := a.read(zr); err != nil {
+		return nil, fmt.Errorf("takeout: %w", err)
+	}
+	a.sort()
+	return a, nil
+}
+
+func (a *Archive) sort() {
+	sort.SliceStable(a.Notes, func(i, j int) bool { return a.Notes[i].Edited.Before(a.Notes[j].Edited) })
+}
+
+func (a *Archive) read(zr *zip.Reader) error {
+	for _, f := range zr.File {
+		dir, name := path.Split(f.Name)
+		switch {
+		case path.Base(dir) == "Keep" && path.Ext(name) == ".json":
+			var n keepJSON
+			if err := decodeFile(f, &n); err != nil {
+				return err
+			}
+			a.Notes = append(a.Notes, n.note(strings.TrimSuffix(name, ".json")))
+		case path.Base(dir) == "Tasks" && path.Ext(name) == ".json":
+			var t tasksJSON
+			if err := decodeFile(f, &t); err != nil {
+				return err
+			}
+			a.Lists = append(a.Lists, t.lists()...)
+		}
+	}
+	return nil
+}
+
+func decodeFile(f *zip.File, v interface{}) error {
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if err := json.NewDecoder(io.LimitReader(r, 50<<20)).Decode(v); err != nil {
+		return fmt.Errorf("%s: %w", f.Name, err)
+	}
+	return nil
+}
+
+// keepJSON is a note as Takeout exports it.
+type keepJSON struct {
+	Title       string `json:"title"`
+	TextContent string `json:"textContent"`
+	ListContent []struct {
+		Text      string `json:"text"`
+		IsChecked bool   `json:"isChecked"`
+	} `json:"listContent"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	Annotations []struct {
+		URL   string `json:"url"`
+		Title string `json:"title"`
+	} `json:"annotations"`
+	IsPinned                bool  `json:"isPinned"`
+	IsArchived              bool  `json:"isArchived"`
+	IsTrashed               bool  `json:"isTrashed"`
+	CreatedTimestampUsec    int64 `json:"createdTimestampUsec"`
+	UserEditedTimestampUsec int64 `json:"userEditedTimestampUsec"`
+}
+
+func (k keepJSON) note(id string) KeepNote {
+	n := KeepNote{
+		ID:       id,
+		Title:    strings.TrimSpace(k.Title),
+		Text:     k.TextContent,
+		Pinned:   k.IsPinned,
+		Archived: k.IsArchived,
+		Trashed:  k.IsTrashed,
+		Created:  usec(k.CreatedTimestampUsec),
+		Edited:   usec(k.UserEditedTimestampUsec),
+	}
+	for _, it := range k.ListContent {
+		n.Items = append(n.Items, ListItem{Text: strings.TrimSpace(it.Text), Checked: it.IsChecked})
+	}
+	for _, l := range k.Labels {
+		n.Labels = append(n.Labels, l.Name)
+	}
+	for _, an := range k.Annotations {
+		if an.URL != "" {
+			n.Links = append(n.Links, an.URL)
+		}
+	}
+	return n
+}
+
+func usec(v int64) time.Time {
+	if v == 0 {
+		return time.Time{}
+	}
+	return time.UnixMicro(v).UTC()
+}
+
+// tasksJSON is the Tasks.json file of a Takeout archive.
+type tasksJSON struct {
+	Items []struct {
+		ID      string `json:"id"`
+		Title   string `json:"title"`
+		Updated string `json:"updated"`
+		Items   []struct {
+			Title   string `json:"title"`
+			Notes   string `json:"notes"`
+			Status  string `json:"status"`
+			Due     string `json:"due"`
+			Deleted bool   `json:"deleted"`
+		} `json:"items"`
+	} `json:"items"`
+}
+
+func (t tasksJSON) lists() []TaskList {
+	var lists []TaskList
+	for _, l := range t.Items {
+		tl := TaskList{ID: l.ID, Title: strings.TrimSpace(l.Title)}
+		tl.Updated, _ = time.Parse(time.RFC3339, l.Updated)
+		for _, it := range l.Items {
+			if it.Deleted || strings.TrimSpace(it.Title) == "" {
+				continue
+			}
+			task := Task{Title: strings.TrimSpace(it.Title), Notes: it.Notes, Completed: it.Status == "completed"}
+			task.Due, _ = time.Parse(time.RFC3339, it.Due)
+			tl.Tasks = append(tl.Tasks, task)
+		}
+		if tl.ID == "" {
+			tl.ID = tl.Title
+		}
+		lists = append(lists, tl)
+	}
+	return lists
+}
@@ -0,0 +1,67 @@
+package takeout
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRead(t *testing.T) {
+	files := map[string]string{
+		"Takeout/Keep/Groceries.json": `{"title":"Groceries","listContent":[{"text":"Milk","isChecked":true},{"text":"Eggs","isChecked":false}],
+			"labels":[{"name":"home"}],"isPinned":true,"userEditedTimestampUsec":1700000200000000}`,
+		"Takeout/Keep/Idea.json": `{"title":"","textContent":"Try a retrieval benchmark.","labels":[{"name":"research"},{"name":"ml"}],
+			"annotations":[{"url":"https://example.com/paper","title":"Paper"}],"isArchived":true,"userEditedTimestampUsec":1700000100000000}`,
+		"Takeout/Keep/Idea.html": `<html>ignored</html>`,
+		"Takeout/Tasks/Tasks.json": `{"kind":"tasks#taskLists","items":[{"id":"L1","title":"Thesis","updated":"2024-05-01T10:00:00Z",
+			"items":[{"title":"Write intro","status":"completed"},{"title":"Run experiments","notes":"GPU cluster","status":"needsAction","due":"2024-06-01T00:00:00Z"},
+			{"title":"Old","deleted":true}]}]}`,
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := Read(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantNotes := []KeepNote{
+		{ID: "Idea", Text: "Try a retrieval benchmark.", Labels: []string{"research", "ml"}, Links: []string{"https://example.com/paper"},
+			Archived: true, Edited: time.UnixMicro(1700000100000000).UTC()},
+		{ID: "Groceries", Title: "Groceries", Items: []ListItem{{"Milk", true}, {"Eggs", false}}, Labels: []string{"home"},
+			Pinned: true, Edited: time.UnixMicro(1700000200000000).UTC()},
+	}
+	if diff := cmp.Diff(wantNotes, a.Notes); diff != "" {
+		t.Errorf("Notes mismatch (-want +got):\n%s", diff)
+	}
+	if got, want := a.Notes[1].Markdown(), "- [x] Milk\n- [ ] Eggs\n"; got != want {
+		t.Errorf("Markdown() = %q, want %q", got, want)
+	}
+	if got, want := a.Notes[0].Markdown(), "Try a retrieval benchmark.\n\nLinks:\n- https://example.com/paper\n"; got != want {
+		t.Errorf("Markdown() = %q, want %q", got, want)
+	}
+
+	if len(a.Lists) != 1 {
+		t.Fatalf("got %d task lists, want 1", len(a.Lists))
+	}
+	want := "- [ ] Run experiments (due 2024-06-01)\n  GPU cluster\n- [x] Write intro\n"
+	if got := a.Lists[0].Markdown(); got != want {
+		t.Errorf("TaskList.Markdown() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,156 @@
+// Package transcribe produces text transcripts of audio files locally,
+// using ffmpeg to decode and whisper.cpp to recognize speech.
+package transcribe
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/tmc/nlm/internal/cleanup"
+)
+
+// Extensions are the audio and video types that can be transcribed.
+var Extensions = []string{".mp3", ".wav", ".m4a", ".aac", ".ogg", ".oga", ".opus", ".flac", ".wma", ".webm", ".mp4", ".mov", ".mkv"}
+
+// IsAudio reports whether name has one of Extensions.
+func IsAudio(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, e := range Extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrNoModel is returned when no whisper model is configured.
+var ErrNoModel = errors.New("no whisper model configured")
+
+// whisperCommands are the names whisper.cpp's CLI is installed under.
+var whisperCommands = []string{"whisper-cli", "whisper-cpp", "whisper.cpp"}
+
+// Whisper transcribes with whisper.cpp. It is the transcribe section of
+// .nlm.yaml.
+type Whisper struct {
+	// Command is the whisper.cpp binary. By default whisper-cli and
+	// whisper-cpp are searched for in PATH.
+	Command string `yaml:"command"`
+	// Model is the path of a ggml model file, such as ggml-base.en.bin.
+	Model string `yaml:"model"`
+	// Language is the spoken language code, or "auto" (the default).
+	Language string `yaml:"language"`
+	// FFmpeg is the ffmpeg binary (default "ffmpeg").
+	FFmpeg string `yaml:"ffmpeg"`
+}
+
+// Transcribe returns the transcript of the audio file at path.
+func (w Whisper) Transcribe(ctx context.Context, path string) (string, error) {
+	if w.Model == "" {
+		return "", fmt.Errorf("transcribe: %w; set transcribe.model in .nlm.yaml or NLM_WHISPER_MODEL to a ggml model file", ErrNoModel)
+	}
+	model := w.Model
+	if rest, ok := strings.CutPrefix(model, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			model = filepath.Join(home, rest)
+		}
+	}
+	whisper, err := w.command()
+	if err != nil {
+		return "", err
+	}
+	ffmpeg := w.FFmpeg
+	if ffmpeg == "" {
+		ffmpeg = "ffmpeg"
+	}
+
+	dir, err := cleanup.MkdirTemp("", "nlm-transcribe-")
+	if err != nil {
+		return "", fmt.Errorf("transcribe: %w", err)
+	}
+	defer cleanup.Remove(dir)
+
+	// whisper.cpp reads 16 kHz mono 16-bit WAV.
+	wav := filepath.Join(dir, "audio.wav")
+	if err := run(ctx, nil, ffmpeg, "-nostdin", "-loglevel", "error", "-i", path, "-ar", "16000", "-ac", "1", "-c:a", "pcm_s16le", "-y", wav); err != nil {
+		return "", fmt.Errorf("transcribe: decode %s: %w", path, err)
+	}
+
+	lang := w.Language
+	if lang == "" {
+		lang = "auto"
+	}
+	var out bytes.Buffer
+	if err := run(ctx, &out, whisper, "-m", model, "-f", wav, "-l", lang, "-nt", "-np"); err != nil {
+		return "", fmt.Errorf("transcribe: %s: %w", path, err)
+	}
+	text := tidy(out.String())
+	if text == "" {
+		return "", fmt.Errorf("transcribe: %s: no speech recognized", path)
+	}
+	return text, nil
+}
+
+func (w Whisper) command() (string, error) {
+	if w.Command != "" {
+		return w.Command, nil
+	}
+	for _, name := range whisperCommands {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", errors.New("transcribe: whisper.cpp not found; install it or set transcribe.command in .nlm.yaml")
+}
+
+// run runs a command, returning its stderr in the error.
+func run(ctx context.Context, stdout io.Writer, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if i := strings.LastIndexByte(msg, '\n'); i >= 0 {
+			msg = msg[i+1:]
+		}
+		if msg != "" {
+			return fmt.Errorf("%s: %w: %s", filepath.Base(name), err, msg)
+		}
+		return fmt.Errorf("%s: %w", filepath.Base(name), err)
+	}
+	return nil
+}
+
+// tidy joins whisper's segment lines into paragraphs, starting a new
+// paragraph after roughly every 600 bytes at a sentence end.
+func tidy(s string) string {
+	var paras []string
+	var cur strings.Builder
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line == "[BLANK_AUDIO]" {
+			continue
+		}
+		if cur.Len() > 0 {
+			cur.WriteByte(' ')
+		}
+		cur.WriteString(line)
+		if cur.Len() >= 600 && strings.ContainsAny(line[len(line)-1:], ".?!") {
+			paras = append(paras, cur.String())
+			cur.Reset()
+		}
+	}
+	if cur.Len() > 0 {
+		paras = append(paras, cur.String())
+	}
+	if len(paras) == 0 {
+		return ""
+	}
+	return strings.Join(paras, "\n\n") + "\n"
+}
@@ -0,0 +1,68 @@
+package transcribe
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeTool writes an executable shell script into dir.
+func fakeTool(t *testing.T, dir, name, script string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestWhisperTranscribe(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	dir := t.TempDir()
+	// The fake ffmpeg copies its input to the last argument; the fake
+	// whisper checks its flags and prints segments.
+	ffmpeg := fakeTool(t, dir, "ffmpeg", `in=""; prev=""; for a; do [ "$prev" = "-i" ] && in="$a"; prev="$a"; last="$a"; done; cp "$in" "$last"`)
+	whisper := fakeTool(t, dir, "whisper", `
+case "$*" in *"-m model.bin"*"-l de"*"-nt"*) ;; *) echo "bad args: $*" >&2; exit 1;; esac
+echo " Hallo zusammen."
+echo " [BLANK_AUDIO]"
+echo " Willkommen zum Meeting."
+`)
+	audio := filepath.Join(dir, "meeting.m4a")
+	os.WriteFile(audio, []byte("audio"), 0o644)
+
+	w := Whisper{Command: whisper, FFmpeg: ffmpeg, Model: "model.bin", Language: "de"}
+	got, err := w.Transcribe(context.Background(), audio)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Hallo zusammen. Willkommen zum Meeting.\n"; got != want {
+		t.Errorf("Transcribe() = %q, want %q", got, want)
+	}
+
+	failing := fakeTool(t, dir, "broken", `echo "unsupported format" >&2; exit 1`)
+	w.FFmpeg = failing
+	if _, err := w.Transcribe(context.Background(), audio); err == nil || !strings.Contains(err.Error(), "unsupported format") {
+		t.Errorf("Transcribe() with failing ffmpeg error = %v", err)
+	}
+
+	if _, err := (Whisper{}).Transcribe(context.Background(), audio); !errors.Is(err, ErrNoModel) {
+		t.Errorf("Transcribe() without model error = %v, want ErrNoModel", err)
+	}
+}
+
+func TestIsAudio(t *testing.T) {
+	for name, want := range map[string]bool{
+		"call.MP3": true, "memo.m4a": true, "notes.md": false, "scan.png": false,
+	} {
+		if got := IsAudio(name); got != want {
+			t.Errorf("IsAudio(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,402 @@
+// Package update checks GitHub releases for newer versions of nlm and
+// replaces the running binary with a verified download.
+//
+// A release is expected to contain one raw binary per platform, named by
+// AssetName, a release.json Manifest naming the release version and the
+// SHA-256 of each binary, and release.json.sig, a base64 Ed25519
+// signature of release.json made with SignManifest. The manifest comes
+// from the same release as the binary, so it only proves anything once
+// its signature is verified against a public key compiled into nlm;
+// without the key or the signature nothing is installed. Since the
+// version is signed too, an older release cannot be passed off as a newer
+// one to downgrade nlm.
+package update
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Release asset names.
+const (
+	ManifestName  = "release.json"
+	SignatureName = "release.json.sig"
+)
+
+// DefaultAPI is the GitHub REST endpoint used to look up releases.
+const DefaultAPI = "https://api.github.com"
+
+// maxDownload bounds the size of any downloaded asset.
+const maxDownload = 200 << 20
+
+var (
+	// ErrNoAsset is returned when a release has no binary for this platform.
+	ErrNoAsset = errors.New("no release asset for this platform")
+	// ErrChecksum is returned when a download does not match the
+	// manifest.
+	ErrChecksum = errors.New("checksum mismatch")
+	// ErrSignature is returned when the manifest has a missing or invalid
+	// signature, or is for another release.
+	ErrSignature = errors.New("invalid release signature")
+	// ErrNotNewer is returned when the signed version of a release is not
+	// newer than the running one.
+	ErrNotNewer = errors.New("release is not newer")
+	// ErrNoKey is returned when there is no public key to verify a
+	// release with.
+	ErrNoKey = errors.New("no release signing key")
+)
+
+// Asset is a file attached to a release.
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+	Size int64  `json:"size"`
+}
+
+// Release is a published GitHub release.
+type Release struct {
+	TagName string  `json:"tag_name"`
+	Name    string  `json:"name"`
+	URL     string  `json:"html_url"`
+	Assets  []Asset `json:"assets"`
+}
+
+// Asset returns the asset with the given name.
+func (r *Release) Asset(name string) (Asset, bool) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Asset{}, false
+}
+
+// Checker looks up and downloads releases of a GitHub repository.
+type Checker struct {
+	Repo       string // owner/name
+	API        string // defaults to DefaultAPI
+	HTTPClient *http.Client
+	// PublicKey must have signed the release manifest.
+	PublicKey ed25519.PublicKey
+}
+
+func (c *Checker) client() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// Latest returns the latest published release.
+func (c *Checker) Latest(ctx context.Context) (*Release, error) {
+	api := c.API
+	if api == "" {
+		api = DefaultAPI
+	}
+	url := fmt.Sprintf("%s/repos/%s/releases/latest", strings.TrimSuffix(api, "/"), c.Repo)
+	data, err := c.get(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("latest release: %w", err)
+	}
+	var r Release
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("latest release: %w", err)
+	}
+	if r.TagName == "" {
+		return nil, fmt.Errorf("latest release: missing tag name")
+	}
+	return &r, nil
+}
+
+// Download fetches this platform's binary from r and verifies it against
+// the release manifest, after verifying the manifest's signature with the
+// public key. It refuses a release whose signed version differs from its
+// tag or is not newer than current.
+func (c *Checker) Download(ctx context.Context, r *Release, current string) ([]byte, error) {
+	if c.PublicKey == nil {
+		return nil, ErrNoKey
+	}
+	name := AssetName(runtime.GOOS, runtime.GOARCH)
+	bin, ok := r.Asset(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoAsset, name)
+	}
+	ma, ok := r.Asset(ManifestName)
+	if !ok {
+		return nil, fmt.Errorf("%w: release %s has no %s", ErrSignature, r.TagName, ManifestName)
+	}
+	manifest, err := c.get(ctx, ma.URL)
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", ManifestName, err)
+	}
+	sig, ok := r.Asset(SignatureName)
+	if !ok {
+		return nil, fmt.Errorf("%w: release %s is not signed", ErrSignature, r.TagName)
+	}
+	sigData, err := c.get(ctx, sig.URL)
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", SignatureName, err)
+	}
+	m, err := VerifyManifest(c.PublicKey, manifest, sigData)
+	if err != nil {
+		return nil, err
+	}
+	if m.Version != r.TagName {
+		return nil, fmt.Errorf("%w: manifest of release %s is for %s", ErrSignature, r.TagName, m.Version)
+	}
+	if !Newer(m.Version, current) {
+		return nil, fmt.Errorf("%w: %s is not newer than %s", ErrNotNewer, m.Version, current)
+	}
+	want, ok := m.Files[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoAsset, name)
+	}
+	data, err := c.get(ctx, bin.URL)
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", name, err)
+	}
+	if got := sha256.Sum256(data); hex.EncodeToString(got[:]) != strings.ToLower(want) {
+		return nil, fmt.Errorf("%w: %s", ErrChecksum, name)
+	}
+	return data, nil
+}
+
+func (c *Checker) get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if tok := os.Getenv("GITHUB_TOKEN"); tok != "" && strings.HasPrefix(url, c.apiBase()) {
+		req.Header.Set("Authorization", "Bearer "+tok)
+	}
+	resp, err := c.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDownload+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxDownload {
+		return nil, fmt.Errorf("%s: larger than %d bytes", url, maxDownload)
+	}
+	return data, nil
+}
+
+func (c *Checker) apiBase() string {
+	if c.API == "" {
+		return DefaultAPI
+	}
+	return c.API
+}
+
+// AssetName returns the release binary name for a platform, for example
+// nlm_linux_amd64 or nlm_windows_amd64.exe.
+func AssetName(goos, goarch string) string {
+	name := fmt.Sprintf("nlm_%s_%s", goos, goarch)
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// Manifest lists the binaries of a release. It is signed as a whole, so
+// the version cannot be changed without breaking the signature.
+type Manifest struct {
+	Version string `json:"version"`
+	// Files maps asset names to their hex SHA-256.
+	Files map[string]string `json:"files"`
+}
+
+// NewManifest returns the manifest of release version with the files at
+// paths, named by their base names.
+func NewManifest(version string, paths ...string) (*Manifest, error) {
+	m := &Manifest{Version: version, Files: make(map[string]string)}
+	for _, p := range paths {
+		f, err := os.Open(p)
+		if err != nil {
+			return nil, err
+		}
+		h := sha256.New()
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		m.Files[filepath.Base(p)] = hex.EncodeToString(h.Sum(nil))
+	}
+	return m, nil
+}
+
+// SignManifest encodes m and signs it with key. It returns the contents
+// of release.json and release.json.sig.
+func SignManifest(key ed25519.PrivateKey, m *Manifest) (data, sig []byte, err error) {
+	data, err = json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	data = append(data, '\n')
+	sig = []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(key, data)) + "\n")
+	return data, sig, nil
+}
+
+// VerifyManifest checks the base64 Ed25519 signature sig of a manifest
+// and decodes it.
+func VerifyManifest(key ed25519.PublicKey, data, sig []byte) (*Manifest, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSignature, err)
+	}
+	if !ed25519.Verify(key, data, raw) {
+		return nil, ErrSignature
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSignature, err)
+	}
+	return &m, nil
+}
+
+// ParsePrivateKey decodes a base64 Ed25519 private key, either its 32
+// byte seed or the 64 byte key.
+func ParsePrivateKey(s string) (ed25519.PrivateKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("private key: %w", err)
+	}
+	switch len(raw) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(raw), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(raw), nil
+	}
+	return nil, fmt.Errorf("private key: want %d or %d bytes, got %d", ed25519.SeedSize, ed25519.PrivateKeySize, len(raw))
+}
+
+// ParsePublicKey decodes a base64 Ed25519 public key.
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("public key: %w", err)
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("public key: want %d bytes, got %d", ed25519.PublicKeySize, len(raw))
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// Newer reports whether version a is newer than b. Versions are compared
+// as dotted numbers with an optional leading "v"; pre-release suffixes sort
+// before the release they precede. Unparseable versions are never newer.
+func Newer(a, b string) bool {
+	av, apre, ok := parseVersion(a)
+	if !ok {
+		return false
+	}
+	bv, bpre, ok := parseVersion(b)
+	if !ok {
+		return true
+	}
+	for i := 0; i < 3; i++ {
+		if av[i] != bv[i] {
+			return av[i] > bv[i]
+		}
+	}
+	switch {
+	case apre == bpre:
+		return false
+	case apre == "":
+		return true
+	case bpre == "":
+		return false
+	}
+	return apre > bpre
+}
+
+// pseudoVersion matches the suffix of Go module pseudo-versions, such as
+// v0.0.0-20261016103000-abcdef123456, which name commits, not releases.
+var pseudoVersion = regexp.MustCompile(`\d{14}-[0-9a-f]{12}$`)
+
+// IsRelease reports whether v is the version of a release, rather than of
+// a development build ("devel") or an untagged commit.
+func IsRelease(v string) bool {
+	_, pre, ok := parseVersion(v)
+	return ok && !pseudoVersion.MatchString(pre)
+}
+
+func parseVersion(s string) (v [3]int, pre string, ok bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	s, _, _ = strings.Cut(s, "+")
+	s, pre, _ = strings.Cut(s, "-")
+	parts := strings.Split(s, ".")
+	if len(parts) == 0 || len(parts) > 3 {
+		return v, "", false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, "", false
+		}
+		v[i] = n
+	}
+	return v, pre, true
+}
+
+// Replace atomically replaces the executable at path with data, keeping
+// its permissions. On Windows, where a running executable cannot be
+// overwritten, the old binary is first moved aside to path+".old".
+func Replace(path string, data []byte) error {
+	path, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fmt.Errorf("replace: %w", err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("replace: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".nlm-update-*")
+	if err != nil {
+		return fmt.Errorf("replace: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("replace: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("replace: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), fi.Mode().Perm()|0o111); err != nil {
+		return fmt.Errorf("replace: %w", err)
+	}
+	if runtime.GOOS == "windows" {
+		old := path + ".old"
+		os.Remove(old)
+		if err := os.Rename(path, old); err != nil {
+			return fmt.Errorf("replace: %w", err)
+		}
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("replace: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package update
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"v1.2.0", "v1.1.9", true},
+		{"v1.1.9", "v1.2.0", false},
+		{"1.2", "v1.2.0", false},
+		{"v1.10.0", "v1.9.0", true},
+		{"v1.2.0", "v1.2.0-rc1", true},
+		{"v1.2.0-rc2", "v1.2.0-rc1", true},
+		{"v1.2.0-rc1", "v1.2.0", false},
+		{"v0.1.0", "devel", true},
+		{"garbage", "v0.1.0", false},
+	}
+	for _, tt := range tests {
+		if got := Newer(tt.a, tt.b); got != tt.want {
+			t.Errorf("Newer(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestIsRelease(t *testing.T) {
+	for v, want := range map[string]bool{
+		"v1.2.0":                               true,
+		"v1.2.0-rc1":                           true,
+		"devel":                                false,
+		"":                                     false,
+		"v0.0.0-20261016103000-abcdef123456":   false,
+		"v1.2.1-0.20261016103000-abcdef123456": false,
+	} {
+		if got := IsRelease(v); got != want {
+			t.Errorf("IsRelease(%q) = %v, want %v", v, got, want)
+		}
+	}
+}
+
+func TestManifest(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "nlm_linux_amd64")
+	if err := os.WriteFile(path, []byte("bin"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewManifest("v1.2.3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seed := base64.StdEncoding.EncodeToString(priv.Seed())
+	key, err := ParsePrivateKey(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, sig, err := SignManifest(key, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := VerifyManifest(pub, data, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte("bin"))
+	if got.Version != "v1.2.3" || got.Files["nlm_linux_amd64"] != hex.EncodeToString(sum[:]) {
+		t.Errorf("VerifyManifest = %+v", got)
+	}
+	// Changing the version breaks the signature.
+	forged := []byte(strings.Replace(string(data), "v1.2.3", "v9.9.9", 1))
+	if _, err := VerifyManifest(pub, forged, sig); !errors.Is(err, ErrSignature) {
+		t.Errorf("VerifyManifest of changed version = %v, want %v", err, ErrSignature)
+	}
+}
+
+// newServer serves release tag whose binary is bin, with a manifest for
+// version. If corrupt is set the served binary does not match its
+// checksum.
+func newServer(t *testing.T, tag, version string, bin []byte, priv ed25519.PrivateKey, corrupt bool) *httptest.Server {
+	t.Helper()
+	name := AssetName(runtime.GOOS, runtime.GOARCH)
+	sum := sha256.Sum256(bin)
+	manifest, _ := json.Marshal(Manifest{Version: version, Files: map[string]string{name: hex.EncodeToString(sum[:])}})
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	assets := []Asset{
+		{Name: name, URL: srv.URL + "/bin"},
+		{Name: ManifestName, URL: srv.URL + "/manifest"},
+	}
+	if priv != nil {
+		assets = append(assets, Asset{Name: SignatureName, URL: srv.URL + "/sig"})
+	}
+	mux.HandleFunc("/repos/o/r/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Release{TagName: tag, Assets: assets})
+	})
+	mux.HandleFunc("/bin", func(w http.ResponseWriter, r *http.Request) {
+		if corrupt {
+			w.Write([]byte("tampered"))
+			return
+		}
+		w.Write(bin)
+	})
+	mux.HandleFunc("/manifest", func(w http.ResponseWriter, r *http.Request) { w.Write(manifest) })
+	mux.HandleFunc("/sig", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, manifest))))
+	})
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestDownload(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+	bin := []byte("#!/bin/sh\necho new\n")
+
+	tests := []struct {
+		name    string
+		tag     string
+		version string // signed in the manifest; defaults to tag
+		current string // defaults to v1.0.0
+		priv    ed25519.PrivateKey
+		pub     ed25519.PublicKey
+		corrupt bool
+		wantErr error
+	}{
+		{name: "no key", priv: priv, wantErr: ErrNoKey},
+		{name: "signed", priv: priv, pub: pub},
+		{name: "wrong key", priv: priv, pub: otherPub, wantErr: ErrSignature},
+		{name: "unsigned", pub: pub, wantErr: ErrSignature},
+		{name: "corrupt", priv: priv, pub: pub, corrupt: true, wantErr: ErrChecksum},
+		{name: "old release retagged", tag: "v1.2.3", version: "v0.9.0", priv: priv, pub: pub, wantErr: ErrSignature},
+		{name: "downgrade", tag: "v0.9.0", priv: priv, pub: pub, wantErr: ErrNotNewer},
+		{name: "same version", current: "v1.2.3", priv: priv, pub: pub, wantErr: ErrNotNewer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.tag == "" {
+				tt.tag = "v1.2.3"
+			}
+			if tt.version == "" {
+				tt.version = tt.tag
+			}
+			if tt.current == "" {
+				tt.current = "v1.0.0"
+			}
+			srv := newServer(t, tt.tag, tt.version, bin, tt.priv, tt.corrupt)
+			c := &Checker{Repo: "o/r", API: srv.URL, PublicKey: tt.pub}
+			rel, err := c.Latest(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rel.TagName != tt.tag {
+				t.Errorf("TagName = %q, want %q", rel.TagName, tt.tag)
+			}
+			got, err := c.Download(context.Background(), rel, tt.current)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Download error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && string(got) != string(bin) {
+				t.Errorf("Download = %q, want %q", got, bin)
+			}
+		})
+	}
+}
+
+func TestReplace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nlm")
+	if err := os.WriteFile(path, []byte("old"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := Replace(path, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "new" {
+		t.Errorf("content = %q, want new", data)
+	}
+	fi, _ := os.Stat(path)
+	if fi.Mode().Perm()&0o100 == 0 {
+		t.Errorf("mode = %v, want executable", fi.Mode())
+	}
+}
@@ -0,0 +1,153 @@
+// Package validate checks command arguments before any request is sent,
+// so that a malformed ID, title, URL or file is reported precisely instead
+// of failing part way through a command that changes several things.
+package validate
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Limits. MaxFileSize matches the largest source NotebookLM accepts.
+const (
+	MaxTitleLength = 500
+	MaxFileSize    = 200 << 20
+)
+
+// An Error describes an invalid argument.
+type Error struct {
+	// Kind is what the argument should be, such as "notebook ID".
+	Kind   string
+	Arg    string
+	Reason string
+}
+
+func (e *Error) Error() string {
+	arg := e.Arg
+	if utf8.RuneCountInString(arg) > 60 {
+		arg = string([]rune(arg)[:57]) + "..."
+	}
+	return fmt.Sprintf("invalid %s %q: %s", e.Kind, arg, e.Reason)
+}
+
+// idRe matches the IDs of notebooks, sources and notes.
+var idRe = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// ID checks the ID of a notebook, source or note; kind is one of those
+// words.
+func ID(kind, id string) error {
+	if idRe.MatchString(id) {
+		return nil
+	}
+	reason := "want a lowercase UUID such as 0f9e5c3a-8d2b-4c1e-9a7f-3b6d2e1c0a94"
+	switch {
+	case id == "":
+		reason = "empty"
+	case idRe.MatchString(strings.ToLower(id)):
+		reason = "IDs are lowercase"
+	case strings.TrimSpace(id) != id:
+		reason = "leading or trailing space"
+	}
+	return &Error{Kind: kind + " ID", Arg: id, Reason: reason}
+}
+
+// looseIDRe matches strings made of hex digits and dashes, as IDs are.
+var looseIDRe = regexp.MustCompile(`^[0-9a-fA-F]{8}[0-9a-fA-F-]*-[0-9a-fA-F-]*$`)
+
+// LooksLikeID reports whether s was meant as an ID, valid or not: hex
+// digits and dashes, such as an uppercase or mistyped UUID.
+func LooksLikeID(s string) bool {
+	return looseIDRe.MatchString(strings.TrimSpace(s))
+}
+
+// Title checks the title of a notebook, source or note.
+func Title(title string) error {
+	reason := ""
+	switch n := utf8.RuneCountInString(title); {
+	case strings.TrimSpace(title) == "":
+		reason = "empty"
+	case n > MaxTitleLength:
+		reason = fmt.Sprintf("%d characters, more than the limit of %d", n, MaxTitleLength)
+	case !utf8.ValidString(title):
+		reason = "not valid UTF-8"
+	case strings.IndexFunc(title, unicode.IsControl) >= 0:
+		reason = "contains a line break or control character"
+	default:
+		return nil
+	}
+	return &Error{Kind: "title", Arg: title, Reason: reason}
+}
+
+// URL checks a web address given as a source.
+func URL(s string) error {
+	u, err := url.Parse(s)
+	reason := ""
+	switch {
+	case err != nil:
+		reason = "cannot parse it"
+	case u.Scheme != "http" && u.Scheme != "https":
+		reason = fmt.Sprintf("scheme %q is not http or https", u.Scheme)
+	case u.Host == "":
+		reason = "no host"
+	default:
+		return nil
+	}
+	return &Error{Kind: "URL", Arg: s, Reason: reason}
+}
+
+// File checks a local file given as a source.
+func File(path string) error {
+	fi, err := os.Stat(path)
+	reason := ""
+	switch {
+	case os.IsNotExist(err):
+		reason = "no such file"
+	case err != nil:
+		reason = err.Error()
+	case fi.IsDir():
+		reason = "a directory, not a file"
+	case !fi.Mode().IsRegular():
+		reason = "not a regular file"
+	case fi.Size() == 0:
+		reason = "empty"
+	case fi.Size() > MaxFileSize:
+		reason = fmt.Sprintf("%d MB, more than the limit of %d MB", fi.Size()>>20, MaxFileSize>>20)
+	default:
+		return nil
+	}
+	return &Error{Kind: "file", Arg: path, Reason: reason}
+}
+
+// fileNameRe matches text that reads as a file name rather than as text
+// to add: a single word ending in a short extension.
+var fileNameRe = regexp.MustCompile(`^\S+\.[A-Za-z][A-Za-z0-9]{0,4}$`)
+
+// Input checks an input of nlm add: "-" for stdin, a URL, a file, or
+// text. Text that looks like the name of a missing file is taken for a
+// mistyped path rather than added as text.
+func Input(s string) error {
+	switch {
+	case s == "-":
+		return nil
+	case s == "":
+		return &Error{Kind: "input", Arg: s, Reason: "empty; give a file, a URL, text or - for stdin"}
+	case strings.Contains(s, "://") && !strings.ContainsAny(s, " \t\n"):
+		return URL(s)
+	}
+	if _, err := os.Stat(s); err == nil {
+		return File(s)
+	}
+	if fileNameRe.MatchString(s) || strings.ContainsRune(s, filepath.Separator) && !strings.ContainsAny(s, " \t\n") {
+		return &Error{Kind: "input", Arg: s, Reason: "no such file (quote text with spaces, or pipe it to nlm add <id> -)"}
+	}
+	if len(s) > MaxFileSize {
+		return &Error{Kind: "input", Arg: s, Reason: fmt.Sprintf("text longer than the limit of %d MB", MaxFileSize>>20)}
+	}
+	return nil
+}
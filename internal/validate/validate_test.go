@@ -0,0 +1,127 @@
+package validate
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestID(t *testing.T) {
+	for _, tt := range []struct {
+		id     string
+		reason string
+	}{
+		{"fec1780c-5a14-4f07-8ee6-f8c3ee2930fa", ""},
+		{"FEC1780C-5A14-4F07-8EE6-F8C3EE2930FA", "IDs are lowercase"},
+		{" fec1780c-5a14-4f07-8ee6-f8c3ee2930fa", "leading or trailing space"},
+		{"", "empty"},
+		{"My Notebook", "want a lowercase UUID"},
+	} {
+		err := ID("notebook", tt.id)
+		if tt.reason == "" {
+			if err != nil {
+				t.Errorf("ID(%q) = %v", tt.id, err)
+			}
+			continue
+		}
+		var ve *Error
+		if !errors.As(err, &ve) || !strings.HasPrefix(ve.Reason, tt.reason) || ve.Kind != "notebook ID" {
+			t.Errorf("ID(%q) = %v, want reason %q", tt.id, err, tt.reason)
+		}
+	}
+}
+
+func TestLooksLikeID(t *testing.T) {
+	for _, tt := range []struct {
+		s    string
+		want bool
+	}{
+		{"fec1780c-5a14-4f07-8ee6-f8c3ee2930fa", true},
+		{"FEC1780C-5A14-4F07-8EE6-F8C3EE2930FA", true},
+		{"fec1780c-5a14-4f07-8ee6-f8c3ee2930f", true},
+		{" fec1780c-5a14-4f07-8ee6-f8c3ee2930fa", true},
+		{"doc.txt", false},
+		{"https://example.com", false},
+		{"deadbeef", false},
+		{"My Notebook", false},
+	} {
+		if got := LooksLikeID(tt.s); got != tt.want {
+			t.Errorf("LooksLikeID(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestTitle(t *testing.T) {
+	for _, tt := range []struct {
+		title string
+		ok    bool
+	}{
+		{"Reading group", true},
+		{"Lesekreis 📚", true},
+		{"  ", false},
+		{"two\nlines", false},
+		{strings.Repeat("é", MaxTitleLength), true},
+		{strings.Repeat("é", MaxTitleLength+1), false},
+	} {
+		if err := Title(tt.title); (err == nil) != tt.ok {
+			t.Errorf("Title(%.20q) = %v, want ok %v", tt.title, err, tt.ok)
+		}
+	}
+}
+
+func TestURL(t *testing.T) {
+	for _, tt := range []struct {
+		url string
+		ok  bool
+	}{
+		{"https://example.com/post", true},
+		{"http://localhost:8080/", true},
+		{"ftp://example.com/file", false},
+		{"https:///path", false},
+		{"javascript:alert(1)", false},
+	} {
+		if err := URL(tt.url); (err == nil) != tt.ok {
+			t.Errorf("URL(%q) = %v, want ok %v", tt.url, err, tt.ok)
+		}
+	}
+}
+
+func TestInput(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "notes.md")
+	if err := os.WriteFile(file, []byte("# Notes\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	empty := filepath.Join(dir, "empty.txt")
+	if err := os.WriteFile(empty, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		in string
+		ok bool
+	}{
+		{"-", true},
+		{file, true},
+		{"https://example.com/post", true},
+		{"Some text to add as a source.", true},
+		{"ftp://example.com/file", false},
+		{empty, false},
+		{dir, false},
+		{"paper.pdf", false},
+		{filepath.Join(dir, "missing"), false},
+		{"", false},
+	} {
+		if err := Input(tt.in); (err == nil) != tt.ok {
+			t.Errorf("Input(%q) = %v, want ok %v", tt.in, err, tt.ok)
+		}
+	}
+}
+
+func TestErrorTruncatesLongArguments(t *testing.T) {
+	err := Title(strings.Repeat("x", MaxTitleLength+1))
+	if len(err.Error()) > 200 {
+		t.Errorf("error is %d bytes long: %s", len(err.Error()), err)
+	}
+}
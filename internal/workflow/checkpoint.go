@@ -0,0 +1,109 @@
+package workflow
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// Checkpoint is the durable progress of a workflow: the steps that
+// completed and their outputs. A runner with a checkpoint records every
+// completed step in it, so a failed or interrupted run can be resumed
+// without repeating uploads and other finished work.
+type Checkpoint struct {
+	Workflow string                    `json:"workflow"`
+	Steps    map[string]StepCheckpoint `json:"steps"`
+	// Partial holds the progress of steps that did not complete, such
+	// as the inputs an add step uploaded before it failed.
+	Partial   map[string]PartialStep `json:"partial,omitempty"`
+	UpdatedAt time.Time              `json:"updated_at"`
+}
+
+// PartialStep records the progress of a step that did not complete; see
+// Run.Progress.
+type PartialStep struct {
+	Digest string `json:"digest"`
+	// Notebook is the notebook the step acted on; progress on another
+	// notebook is not reused.
+	Notebook  string            `json:"notebook,omitempty"`
+	Done      map[string]string `json:"done"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// StepCheckpoint records a completed step.
+type StepCheckpoint struct {
+	// Digest identifies the step's definition; a step edited since it
+	// completed runs again.
+	Digest     string            `json:"digest"`
+	Outputs    map[string]string `json:"outputs,omitempty"`
+	FinishedAt time.Time         `json:"finished_at"`
+}
+
+// NewCheckpoint returns an empty checkpoint for the named workflow.
+func NewCheckpoint(workflow string) *Checkpoint {
+	return &Checkpoint{Workflow: workflow, Steps: make(map[string]StepCheckpoint)}
+}
+
+// Digest identifies the definition of s: its action, parameters and
+// dependencies.
+func (s *Step) Digest() string {
+	data, _ := json.Marshal(struct {
+		Action string                 `json:"action"`
+		With   map[string]interface{} `json:"with"`
+		Needs  []string               `json:"needs"`
+	}{s.Action, s.With, s.Needs})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// done returns the recorded outputs of s if it completed with its current
+// definition.
+func (c *Checkpoint) done(s *Step) (map[string]string, bool) {
+	if c == nil {
+		return nil, false
+	}
+	sc, ok := c.Steps[s.ID]
+	if !ok || sc.Digest != s.Digest() {
+		return nil, false
+	}
+	return sc.Outputs, true
+}
+
+func (c *Checkpoint) record(s *Step, outputs map[string]string) {
+	now := time.Now().UTC()
+	c.Steps[s.ID] = StepCheckpoint{Digest: s.Digest(), Outputs: outputs, FinishedAt: now}
+	delete(c.Partial, s.ID)
+	c.UpdatedAt = now
+}
+
+// partial returns a copy of the progress recorded for s on notebook, if
+// it was made with s's current definition.
+func (c *Checkpoint) partial(s *Step, notebook string) map[string]string {
+	if c == nil {
+		return nil
+	}
+	p, ok := c.Partial[s.ID]
+	if !ok || p.Digest != s.Digest() || p.Notebook != notebook {
+		return nil
+	}
+	done := make(map[string]string, len(p.Done))
+	for k, v := range p.Done {
+		done[k] = v
+	}
+	return done
+}
+
+// progress records the progress of s on notebook.
+func (c *Checkpoint) progress(s *Step, notebook string, done map[string]string) {
+	if c.Partial == nil {
+		c.Partial = make(map[string]PartialStep)
+	}
+	now := time.Now().UTC()
+	cp := make(map[string]string, len(done))
+	for k, v := range done {
+		cp[k] = v
+	}
+	c.Partial[s.ID] = PartialStep{Digest: s.Digest(), Notebook: notebook, Done: cp, UpdatedAt: now}
+	c.UpdatedAt = now
+}
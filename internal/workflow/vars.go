@@ -0,0 +1,161 @@
+package workflow
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Vars are the values substituted for ${name} references in step
+// parameters. The outputs of earlier steps are available as
+// ${steps.<id>.<output>}, and names not otherwise defined are looked up
+// in the environment. $$ is a literal $.
+type Vars map[string]string
+
+// expand replaces the ${name} references in s using lookup.
+func expand(s string, lookup func(string) (string, error)) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(s, '$')
+		if i < 0 || i == len(s)-1 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		b.WriteString(s[:i])
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			s = s[i+2:]
+		case '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated ${ in %q", s)
+			}
+			v, err := lookup(strings.TrimSpace(s[i+2 : i+end]))
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(v)
+			s = s[i+end+1:]
+		default:
+			b.WriteByte('$')
+			s = s[i+1:]
+		}
+	}
+}
+
+// expandValue expands the strings in a parameter value.
+func expandValue(v interface{}, lookup func(string) (string, error)) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		return expand(v, lookup)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			x, err := expandValue(e, lookup)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = x
+		}
+		return out, nil
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			x, err := expandValue(e, lookup)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = x
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+// expand returns a copy of s with its parameters expanded.
+func (s *Step) expand(lookup func(string) (string, error)) (*Step, error) {
+	with, err := expandValue(map[string]interface{}(s.With), lookup)
+	if err != nil {
+		return nil, fmt.Errorf("step %q: %w", s.ID, err)
+	}
+	c := *s
+	c.With = with.(map[string]interface{})
+	return &c, nil
+}
+
+// Combinations returns the variable sets the workflow runs with: one per
+// combination of matrix values, or a single empty set without a matrix.
+// Matrix values containing glob characters are expanded to the matching
+// paths, found relative to the workflow's directory, so a workflow can run
+// once per directory.
+func (w *Workflow) Combinations() ([]Vars, error) {
+	keys := make([]string, 0, len(w.Matrix))
+	for k := range w.Matrix {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	combos := []Vars{{}}
+	for _, k := range keys {
+		var values []string
+		for _, v := range w.Matrix[k] {
+			if !strings.ContainsAny(v, "*?[") {
+				values = append(values, v)
+				continue
+			}
+			// Matches are kept relative, as written, for use in titles;
+			// steps resolve them again when used as paths.
+			rel := w.Dir != "" && !filepath.IsAbs(v)
+			pattern := v
+			if rel {
+				pattern = filepath.Join(w.Dir, v)
+			}
+			matches, err := filepath.Glob(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("workflow: matrix %s: %w", k, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("workflow: matrix %s: nothing matches %s", k, v)
+			}
+			for _, m := range matches {
+				if rel {
+					m, _ = filepath.Rel(w.Dir, m)
+				}
+				values = append(values, m)
+			}
+		}
+		if len(values) == 0 {
+			return nil, fmt.Errorf("workflow: matrix %s has no values", k)
+		}
+		next := make([]Vars, 0, len(combos)*len(values))
+		for _, c := range combos {
+			for _, v := range values {
+				n := make(Vars, len(c)+1)
+				for ck, cv := range c {
+					n[ck] = cv
+				}
+				n[k] = v
+				next = append(next, n)
+			}
+		}
+		combos = next
+	}
+	return combos, nil
+}
+
+// String formats vars as sorted key=value pairs.
+func (v Vars) String() string {
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for i, k := range keys {
+		keys[i] = k + "=" + v[k]
+	}
+	return strings.Join(keys, " ")
+}
@@ -0,0 +1,122 @@
+package workflow
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExpand(t *testing.T) {
+	vars := map[string]string{"course": "physics", "term": "fall"}
+	lookup := func(name string) (string, error) {
+		if v, ok := vars[name]; ok {
+			return v, nil
+		}
+		return "", os.ErrNotExist
+	}
+	tests := []struct {
+		in, want string
+		wantErr  bool
+	}{
+		{in: "plain", want: "plain"},
+		{in: "${course} (${ term })", want: "physics (fall)"},
+		{in: "cost $$5 and $x", want: "cost $5 and $x"},
+		{in: "trailing $", want: "trailing $"},
+		{in: "${missing}", wantErr: true},
+		{in: "${course", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := expand(tt.in, lookup)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("expand(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("expand(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCombinations(t *testing.T) {
+	dir := t.TempDir()
+	for _, d := range []string{"bio", "chem"} {
+		if err := os.Mkdir(filepath.Join(dir, d), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w := &Workflow{Matrix: map[string][]string{
+		"course": {filepath.Join(dir, "*")},
+		"term":   {"fall", "spring"},
+	}}
+	got, err := w.Combinations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var strs []string
+	for _, c := range got {
+		strs = append(strs, c.String())
+	}
+	bio, chem := filepath.Join(dir, "bio"), filepath.Join(dir, "chem")
+	want := []string{
+		"course=" + bio + " term=fall",
+		"course=" + bio + " term=spring",
+		"course=" + chem + " term=fall",
+		"course=" + chem + " term=spring",
+	}
+	if diff := cmp.Diff(want, strs); diff != "" {
+		t.Errorf("combinations mismatch (-want +got):\n%s", diff)
+	}
+
+	if got, err := (&Workflow{}).Combinations(); err != nil || len(got) != 1 || len(got[0]) != 0 {
+		t.Errorf("no matrix: Combinations = %v, %v; want one empty set", got, err)
+	}
+	w.Matrix = map[string][]string{"course": {filepath.Join(dir, "none-*")}}
+	if _, err := w.Combinations(); err == nil {
+		t.Error("Combinations accepted a glob matching nothing")
+	}
+}
+
+func TestRunVars(t *testing.T) {
+	t.Setenv("NLM_TEST_OWNER", "ada")
+	w, err := Parse([]byte(`
+vars: {term: fall, course: default}
+steps:
+  - {id: create, action: create, with: {title: "${course} ${term}"}}
+  - {id: note, action: note, with: {body: ["${steps.create.notebook}", "${NLM_TEST_OWNER}"]}}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	rn := &Runner{
+		Vars: Vars{"course": "physics"},
+		Actions: map[string]Action{
+			"create": func(ctx context.Context, run *Run, s *Step) (map[string]string, error) {
+				got = append(got, s.String("title"))
+				return map[string]string{"notebook": "nb1"}, nil
+			},
+			"note": func(ctx context.Context, run *Run, s *Step) (map[string]string, error) {
+				got = append(got, s.Strings("body")...)
+				return nil, nil
+			},
+		},
+	}
+	run, err := rn.Run(context.Background(), w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Failed() {
+		t.Fatalf("run failed: %+v", run.Results)
+	}
+	if diff := cmp.Diff([]string{"physics fall", "nb1", "ada"}, got); diff != "" {
+		t.Errorf("expanded parameters mismatch (-want +got):\n%s", diff)
+	}
+
+	w.Steps[1].With["body"] = "${undefined_var}"
+	if _, err := rn.Run(context.Background(), w); err == nil {
+		t.Error("Run started with an undefined variable")
+	}
+}
@@ -0,0 +1,562 @@
+// Package workflow runs multi-step NotebookLM jobs described in YAML, such
+// as creating a notebook, adding sources, generating a study guide and an
+// audio overview, and exporting the result.
+//
+// A workflow file lists steps in order:
+//
+//	name: course notes
+//	steps:
+//	  - id: notebook
+//	    action: create-notebook
+//	    with: {title: "Physics 101"}
+//	  - id: sources
+//	    action: add
+//	    with: {sources: ["notes/*.md"]}
+//	  - id: audio
+//	    action: audio-create
+//	    needs: [sources]
+//	    retries: 3
+//	    timeout: 20m
+//
+// A step runs after the steps named in needs; a step without needs runs
+// after the step before it. When a step fails, after its retries, the
+// steps that depend on it are skipped and the others still run.
+//
+// Step parameters may refer to variables as ${name}: defaults under vars,
+// values given to the runner, matrix values, outputs of earlier steps as
+// ${steps.<id>.<output>}, and environment variables. A matrix runs the
+// workflow once for every combination of its values:
+//
+//	vars: {term: fall}
+//	matrix:
+//	  course: ["courses/*"]
+//	steps:
+//	  - action: create-notebook
+//	    with: {title: "${course} (${term})"}
+//	  - action: sync
+//	    with: {dir: "${course}"}
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Workflow is a parsed workflow file.
+type Workflow struct {
+	// Dir is the directory relative paths in the workflow are resolved
+	// against: that of the file, for a loaded workflow.
+	Dir  string `yaml:"-"`
+	Name string `yaml:"name"`
+	// Vars are default variable values.
+	Vars Vars `yaml:"vars"`
+	// Matrix lists the values of each matrix variable.
+	Matrix map[string][]string `yaml:"matrix"`
+	Steps  []*Step             `yaml:"steps"`
+}
+
+// Step is one action in a workflow.
+type Step struct {
+	// ID names the step for needs and in logs; it defaults to the action.
+	ID     string   `yaml:"id"`
+	Action string   `yaml:"action"`
+	Needs  []string `yaml:"needs"`
+	// With holds the action's parameters.
+	With map[string]interface{} `yaml:"with"`
+	// Retries is the number of extra attempts after a failure.
+	Retries int `yaml:"retries"`
+	// Timeout bounds each attempt, including the requests it makes; zero
+	// means no limit.
+	Timeout Duration `yaml:"timeout"`
+}
+
+// Duration is a time.Duration written as a string such as "90s" or "20m".
+type Duration time.Duration
+
+// UnmarshalYAML parses a duration string.
+func (d *Duration) UnmarshalYAML(n *yaml.Node) error {
+	var s string
+	if err := n.Decode(&s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", n.Line, err)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Load reads and parses a workflow file. Relative paths in it are
+// resolved against the file's directory.
+func Load(path string) (*Workflow, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("workflow: %w", err)
+	}
+	data, err := os.ReadFile(abs)
+	if err != nil {
+		return nil, fmt.Errorf("workflow: %w", err)
+	}
+	w, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	w.Dir = filepath.Dir(abs)
+	return w, nil
+}
+
+// Parse parses a workflow and fills in default step IDs and dependencies.
+// Unknown keys, such as a misspelled retries or timeout, are errors.
+func Parse(data []byte) (*Workflow, error) {
+	var w Workflow
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&w); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("workflow: %w", err)
+	}
+	if len(w.Steps) == 0 {
+		return nil, errors.New("workflow: no steps")
+	}
+	seen := make(map[string]bool)
+	for i, s := range w.Steps {
+		if s.Action == "" {
+			return nil, fmt.Errorf("workflow: step %d has no action", i+1)
+		}
+		if s.ID == "" {
+			s.ID = s.Action
+		}
+		if seen[s.ID] {
+			return nil, fmt.Errorf("workflow: duplicate step id %q (set id on repeated actions)", s.ID)
+		}
+		seen[s.ID] = true
+		if s.Needs == nil && i > 0 {
+			s.Needs = []string{w.Steps[i-1].ID}
+		}
+	}
+	for _, s := range w.Steps {
+		for _, dep := range s.Needs {
+			if !seen[dep] {
+				return nil, fmt.Errorf("workflow: step %q needs unknown step %q", s.ID, dep)
+			}
+		}
+	}
+	if _, err := w.order(); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// order returns the steps so that each comes after the steps it needs,
+// keeping file order otherwise.
+func (w *Workflow) order() ([]*Step, error) {
+	index := make(map[string]int, len(w.Steps))
+	for i, s := range w.Steps {
+		index[s.ID] = i
+	}
+	done := make([]bool, len(w.Steps))
+	var out []*Step
+	for len(out) < len(w.Steps) {
+		progressed := false
+		for i, s := range w.Steps {
+			if done[i] {
+				continue
+			}
+			ready := true
+			for _, dep := range s.Needs {
+				if !done[index[dep]] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				done[i] = true
+				out = append(out, s)
+				progressed = true
+				break
+			}
+		}
+		if !progressed {
+			var stuck []string
+			for i, s := range w.Steps {
+				if !done[i] {
+					stuck = append(stuck, s.ID)
+				}
+			}
+			sort.Strings(stuck)
+			return nil, fmt.Errorf("workflow: dependency cycle among steps %v", stuck)
+		}
+	}
+	return out, nil
+}
+
+// String returns the string parameter key, or "".
+func (s *Step) String(key string) string {
+	switch v := s.With[key].(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Strings returns the list parameter key; a single string is a list of
+// one.
+func (s *Step) Strings(key string) []string {
+	switch v := s.With[key].(type) {
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, e := range v {
+			out = append(out, fmt.Sprint(e))
+		}
+		return out
+	case nil:
+		return nil
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
+
+// Bool returns the boolean parameter key, or def if it is unset.
+func (s *Step) Bool(key string, def bool) bool {
+	if v, ok := s.With[key].(bool); ok {
+		return v
+	}
+	return def
+}
+
+// Action performs a step. The outputs it returns are recorded on the run;
+// an output named "notebook" becomes the notebook of later steps.
+type Action func(ctx context.Context, run *Run, s *Step) (map[string]string, error)
+
+// Step statuses.
+const (
+	StatusOK      = "ok"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+	// StatusResumed marks a step completed by an earlier run.
+	StatusResumed = "resumed"
+)
+
+// Result is the outcome of one step.
+type Result struct {
+	Step     string        `json:"step"`
+	Status   string        `json:"status"`
+	Attempts int           `json:"attempts,omitempty"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Run is the state of one execution of a workflow.
+type Run struct {
+	Workflow *Workflow
+	// Notebook is the notebook steps act on unless they name one.
+	Notebook string
+	Outputs  map[string]map[string]string
+	Results  []Result
+
+	progress map[string]map[string]string
+	// saveProgress, if set, persists the progress of a step.
+	saveProgress func(s *Step, done map[string]string) error
+}
+
+// Progress returns the record of the work done by earlier attempts of s,
+// in this run or, when resuming, in the run being resumed. Actions use it
+// to make retries idempotent: an action records each part it completes
+// with Record, such as an uploaded input, and skips the parts already
+// recorded when it is attempted again. The returned map must not be
+// modified.
+func (r *Run) Progress(s *Step) map[string]string {
+	if r.progress == nil {
+		r.progress = make(map[string]map[string]string)
+	}
+	p := r.progress[s.ID]
+	if p == nil {
+		p = make(map[string]string)
+		r.progress[s.ID] = p
+	}
+	return p
+}
+
+// Record adds a completed part of s to its progress and, when the run
+// has a checkpoint, saves it there before returning, so that a resumed
+// run skips the part even if this one is killed.
+func (r *Run) Record(s *Step, key, value string) error {
+	p := r.Progress(s)
+	p[key] = value
+	if r.saveProgress == nil {
+		return nil
+	}
+	if err := r.saveProgress(s, p); err != nil {
+		return fmt.Errorf("save checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Path resolves a path given in the workflow against its directory.
+func (r *Run) Path(p string) string {
+	if p == "" || filepath.IsAbs(p) || r.Workflow.Dir == "" {
+		return p
+	}
+	return filepath.Join(r.Workflow.Dir, p)
+}
+
+// NotebookFor returns the notebook a step acts on: its notebook parameter,
+// or the run's current notebook.
+func (r *Run) NotebookFor(s *Step) (string, error) {
+	if id := s.String("notebook"); id != "" {
+		return id, nil
+	}
+	if r.Notebook == "" {
+		return "", fmt.Errorf("step %q: no notebook (add a create-notebook step or set notebook)", s.ID)
+	}
+	return r.Notebook, nil
+}
+
+// Failed reports whether any step failed or was skipped.
+func (r *Run) Failed() bool {
+	for _, res := range r.Results {
+		if res.Status != StatusOK && res.Status != StatusResumed {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxRetryAfter caps the wait a server may ask for before a step is
+// retried, so that a bad or hostile hint cannot stall a run for days.
+const MaxRetryAfter = 15 * time.Minute
+
+// Runner executes workflows.
+type Runner struct {
+	Actions map[string]Action
+	// Logger receives a structured record for every attempt; nil discards.
+	Logger *slog.Logger
+	// RetryDelay is the wait before the first retry, doubled for each
+	// further one. Zero means 5 seconds.
+	RetryDelay time.Duration
+	// RetryAfter, if set, returns the wait the server asked for after a
+	// failure, which is used instead of RetryDelay for that retry, up to
+	// MaxRetryAfter.
+	RetryAfter func(error) (time.Duration, bool)
+	// Checkpoint, if set, holds the steps completed by an earlier run,
+	// which are not run again unless their definition or a step they need
+	// changed. Completed steps are recorded in it and passed to Save.
+	Checkpoint *Checkpoint
+	Save       func(*Checkpoint) error
+	// Vars override the workflow's default variables.
+	Vars Vars
+}
+
+// Check reports unknown actions in w.
+func (rn *Runner) Check(w *Workflow) error {
+	for _, s := range w.Steps {
+		if _, ok := rn.Actions[s.Action]; !ok {
+			return fmt.Errorf("workflow: step %q: unknown action %q", s.ID, s.Action)
+		}
+	}
+	return nil
+}
+
+// Run executes w. It returns an error only if the workflow cannot start;
+// step failures are recorded in the run's results.
+func (rn *Runner) Run(ctx context.Context, w *Workflow) (*Run, error) {
+	if err := rn.Check(w); err != nil {
+		return nil, err
+	}
+	steps, err := w.order()
+	if err != nil {
+		return nil, err
+	}
+	log := rn.Logger
+	if log == nil {
+		log = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	run := &Run{Workflow: w, Outputs: make(map[string]map[string]string)}
+	if rn.Checkpoint != nil {
+		run.saveProgress = func(s *Step, done map[string]string) error {
+			rn.Checkpoint.progress(s, run.Notebook, done)
+			if rn.Save == nil {
+				return nil
+			}
+			return rn.Save(rn.Checkpoint)
+		}
+	}
+	for _, s := range steps {
+		if _, err := s.expand(rn.lookup(w, nil)); err != nil {
+			return nil, fmt.Errorf("workflow: %w", err)
+		}
+	}
+	status := make(map[string]string, len(steps))
+	for _, s := range steps {
+		if ctx.Err() != nil {
+			return run, ctx.Err()
+		}
+		var blocked string
+		resumable := true
+		for _, dep := range s.Needs {
+			if status[dep] != StatusOK && status[dep] != StatusResumed {
+				blocked = dep
+				break
+			}
+			resumable = resumable && status[dep] == StatusResumed
+		}
+		if blocked == "" {
+			x, err := s.expand(rn.lookup(w, run))
+			if err != nil {
+				log.Error("step failed", "step", s.ID, "action", s.Action, "error", err)
+				status[s.ID] = StatusFailed
+				run.Results = append(run.Results, Result{Step: s.ID, Status: StatusFailed, Error: err.Error()})
+				continue
+			}
+			s = x
+		}
+		if out, ok := rn.Checkpoint.done(s); ok && resumable && blocked == "" {
+			log.Info("step resumed", "step", s.ID, "action", s.Action)
+			rn.completed(run, s, out)
+			status[s.ID] = StatusResumed
+			run.Results = append(run.Results, Result{Step: s.ID, Status: StatusResumed})
+			continue
+		}
+		if blocked != "" {
+			log.Warn("step skipped", "step", s.ID, "action", s.Action, "blocked_by", blocked)
+			status[s.ID] = StatusSkipped
+			run.Results = append(run.Results, Result{Step: s.ID, Status: StatusSkipped, Error: fmt.Sprintf("needs %s, which did not succeed", blocked)})
+			continue
+		}
+		if done := rn.Checkpoint.partial(s, run.Notebook); len(done) > 0 {
+			log.Info("step partly done", "step", s.ID, "action", s.Action, "done", len(done))
+			if run.progress == nil {
+				run.progress = make(map[string]map[string]string)
+			}
+			run.progress[s.ID] = done
+		}
+		res := rn.runStep(ctx, log, run, s)
+		status[s.ID] = res.Status
+		run.Results = append(run.Results, res)
+		if res.Status == StatusOK && rn.Checkpoint != nil {
+			rn.Checkpoint.record(s, run.Outputs[s.ID])
+			if rn.Save != nil {
+				if err := rn.Save(rn.Checkpoint); err != nil {
+					return run, fmt.Errorf("workflow: save checkpoint: %w", err)
+				}
+			}
+		}
+	}
+	return run, nil
+}
+
+// lookup resolves variable references for steps of w. Before the run
+// starts, run is nil and step outputs resolve to "" if the step exists.
+func (rn *Runner) lookup(w *Workflow, run *Run) func(string) (string, error) {
+	return func(name string) (string, error) {
+		if ref, ok := strings.CutPrefix(name, "steps."); ok {
+			i := strings.LastIndexByte(ref, '.')
+			if i < 0 {
+				return "", fmt.Errorf("invalid reference ${%s} (want ${steps.<id>.<output>})", name)
+			}
+			id, key := ref[:i], ref[i+1:]
+			found := false
+			for _, s := range w.Steps {
+				found = found || s.ID == id
+			}
+			if !found {
+				return "", fmt.Errorf("${%s} refers to unknown step %q", name, id)
+			}
+			if run == nil {
+				return "", nil
+			}
+			v, ok := run.Outputs[id][key]
+			if !ok {
+				return "", fmt.Errorf("step %q has no output %q", id, key)
+			}
+			return v, nil
+		}
+		if v, ok := rn.Vars[name]; ok {
+			return v, nil
+		}
+		if v, ok := w.Vars[name]; ok {
+			return v, nil
+		}
+		if v, ok := os.LookupEnv(name); ok {
+			return v, nil
+		}
+		return "", fmt.Errorf("undefined variable ${%s} (set it with vars, -set or the environment)", name)
+	}
+}
+
+// completed records the outputs of a finished step on the run.
+func (rn *Runner) completed(run *Run, s *Step, out map[string]string) {
+	run.Outputs[s.ID] = out
+	if id := out["notebook"]; id != "" {
+		run.Notebook = id
+	}
+}
+
+func (rn *Runner) runStep(ctx context.Context, log *slog.Logger, run *Run, s *Step) Result {
+	delay := rn.RetryDelay
+	if delay == 0 {
+		delay = 5 * time.Second
+	}
+	start := time.Now()
+	res := Result{Step: s.ID}
+	for attempt := 1; ; attempt++ {
+		res.Attempts = attempt
+		log.Info("step started", "step", s.ID, "action", s.Action, "attempt", attempt)
+		out, err := rn.attempt(ctx, run, s)
+		if err == nil {
+			rn.completed(run, s, out)
+			res.Status = StatusOK
+			res.Duration = time.Since(start)
+			log.Info("step finished", "step", s.ID, "action", s.Action, "attempt", attempt, "duration", res.Duration.Round(time.Millisecond))
+			return res
+		}
+		log.Error("step failed", "step", s.ID, "action", s.Action, "attempt", attempt, "error", err)
+		if attempt > s.Retries || ctx.Err() != nil {
+			res.Status = StatusFailed
+			res.Error = err.Error()
+			res.Duration = time.Since(start)
+			return res
+		}
+		wait := delay
+		if rn.RetryAfter != nil {
+			if d, ok := rn.RetryAfter(err); ok {
+				wait = min(d, MaxRetryAfter)
+				log.Info("server asked to wait", "step", s.ID, "asked", d, "wait", wait)
+			}
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			res.Status = StatusFailed
+			res.Error = err.Error()
+			res.Duration = time.Since(start)
+			return res
+		}
+		delay *= 2
+	}
+}
+
+func (rn *Runner) attempt(ctx context.Context, run *Run, s *Step) (map[string]string, error) {
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(s.Timeout))
+		defer cancel()
+	}
+	return rn.Actions[s.Action](ctx, run, s)
+}
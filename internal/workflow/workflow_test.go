@@ -0,0 +1,399 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParse(t *testing.T) {
+	w, err := Parse([]byte(`
+name: demo
+steps:
+  - action: create-notebook
+    with: {title: Physics}
+  - id: docs
+    action: add
+    with:
+      sources: [a.md, "b/*.md"]
+  - action: audio-create
+    needs: [create-notebook]
+    retries: 2
+    timeout: 90s
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range w.Steps {
+		got = append(got, s.ID+" needs "+strings.Join(s.Needs, ","))
+	}
+	want := []string{"create-notebook needs ", "docs needs create-notebook", "audio-create needs create-notebook"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("steps mismatch (-want +got):\n%s", diff)
+	}
+	if got := w.Steps[1].Strings("sources"); !cmp.Equal(got, []string{"a.md", "b/*.md"}) {
+		t.Errorf("Strings(sources) = %q", got)
+	}
+	if w.Steps[2].Timeout != Duration(90*time.Second) || w.Steps[2].Retries != 2 {
+		t.Errorf("audio step = %+v", w.Steps[2])
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"no steps":     `name: x`,
+		"no action":    "steps:\n  - id: a",
+		"duplicate":    "steps:\n  - action: add\n  - action: add",
+		"unknown need": "steps:\n  - action: add\n    needs: [nope]",
+		"cycle":        "steps:\n  - {id: a, action: add, needs: [b]}\n  - {id: b, action: add, needs: [a]}",
+		"bad timeout":  "steps:\n  - {action: add, timeout: soon}",
+		"unknown key":  "steps:\n  - {action: add, retry: 3}",
+	}
+	for name, in := range tests {
+		if _, err := Parse([]byte(in)); err == nil {
+			t.Errorf("%s: Parse succeeded", name)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	w, err := Parse([]byte(`
+steps:
+  - {id: create, action: create}
+  - {id: flaky, action: flaky, retries: 2}
+  - {id: broken, action: fail, needs: [create]}
+  - {id: after-broken, action: record, needs: [broken]}
+  - {id: independent, action: record, needs: [flaky]}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var flakyCalls int
+	var notebooks []string
+	rn := &Runner{
+		RetryDelay: time.Millisecond,
+		Actions: map[string]Action{
+			"create": func(ctx context.Context, run *Run, s *Step) (map[string]string, error) {
+				return map[string]string{"notebook": "nb1"}, nil
+			},
+			"flaky": func(ctx context.Context, run *Run, s *Step) (map[string]string, error) {
+				if flakyCalls++; flakyCalls < 3 {
+					return nil, errors.New("timeout")
+				}
+				return nil, nil
+			},
+			"fail": func(ctx context.Context, run *Run, s *Step) (map[string]string, error) {
+				return nil, errors.New("boom")
+			},
+			"record": func(ctx context.Context, run *Run, s *Step) (map[string]string, error) {
+				nb, err := run.NotebookFor(s)
+				notebooks = append(notebooks, nb)
+				return nil, err
+			},
+		},
+	}
+	run, err := rn.Run(context.Background(), w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range run.Results {
+		got = append(got, r.Step+" "+r.Status)
+	}
+	want := []string{"create ok", "flaky ok", "broken failed", "after-broken skipped", "independent ok"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("results mismatch (-want +got):\n%s", diff)
+	}
+	if run.Results[1].Attempts != 3 {
+		t.Errorf("flaky attempts = %d, want 3", run.Results[1].Attempts)
+	}
+	if !cmp.Equal(notebooks, []string{"nb1"}) {
+		t.Errorf("notebooks = %q, want [nb1]", notebooks)
+	}
+	if !run.Failed() {
+		t.Error("Failed = false with a failed step")
+	}
+}
+
+func TestRunRetryAfter(t *testing.T) {
+	w, err := Parse([]byte(`
+steps:
+  - {id: limited, action: limited, retries: 1}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errLimited := errors.New("rate limited")
+	var calls int
+	rn := &Runner{
+		RetryDelay: time.Hour,
+		RetryAfter: func(err error) (time.Duration, bool) {
+			return time.Millisecond, errors.Is(err, errLimited)
+		},
+		Actions: map[string]Action{
+			"limited": func(ctx context.Context, run *Run, s *Step) (map[string]string, error) {
+				if calls++; calls == 1 {
+					return nil, errLimited
+				}
+				return nil, nil
+			},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	run, err := rn.Run(ctx, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := run.Results[0]; r.Status != StatusOK || r.Attempts != 2 || r.Duration > time.Second {
+		t.Errorf("result = %+v, want ok after 2 attempts without the hour-long delay", r)
+	}
+
+	// A wait longer than the run may take ends when the run is canceled.
+	calls = 0
+	rn.RetryAfter = func(error) (time.Duration, bool) { return 1000 * time.Hour, true }
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	run, err = rn.Run(ctx, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := run.Results[0]; r.Status != StatusFailed || r.Attempts != 1 || r.Duration > time.Second {
+		t.Errorf("result = %+v, want failed after 1 attempt once canceled", r)
+	}
+}
+
+func TestLoadPaths(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "courses", "physics"), 0o755)
+	file := filepath.Join(dir, "course.yaml")
+	os.WriteFile(file, []byte("matrix: {course: [\"courses/*\"]}\nsteps:\n  - action: add\n"), 0o644)
+	w, err := Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	combos, err := w.Combinations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join("courses", "physics"); len(combos) != 1 || combos[0]["course"] != want {
+		t.Errorf("Combinations = %v, want course %s", combos, want)
+	}
+	run := &Run{Workflow: w}
+	if got, want := run.Path("notes/a.md"), filepath.Join(dir, "notes", "a.md"); got != want {
+		t.Errorf("Path = %s, want %s", got, want)
+	}
+	if got := run.Path("/abs/a.md"); got != "/abs/a.md" {
+		t.Errorf("Path(absolute) = %s", got)
+	}
+}
+
+func TestRunProgress(t *testing.T) {
+	w, err := Parse([]byte("steps:\n  - {action: add, retries: 1}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var added []string
+	interrupted := false
+	rn := &Runner{
+		RetryDelay: time.Millisecond,
+		Actions: map[string]Action{
+			"add": func(ctx context.Context, run *Run, s *Step) (map[string]string, error) {
+				progress := run.Progress(s)
+				for _, in := range []string{"a", "b"} {
+					if progress[in] != "" {
+						continue
+					}
+					if in == "b" && !interrupted {
+						interrupted = true
+						return nil, errors.New("upload interrupted")
+					}
+					added = append(added, in)
+					if err := run.Record(s, in, "src-"+in); err != nil {
+						return nil, err
+					}
+				}
+				return nil, nil
+			},
+		},
+	}
+	run, err := rn.Run(context.Background(), w)
+	if err != nil || run.Failed() {
+		t.Fatalf("Run: %v, %+v", err, run.Results)
+	}
+	if !cmp.Equal(added, []string{"a", "b"}) {
+		t.Errorf("added %q; a retry added an input again", added)
+	}
+}
+
+func TestRunResumeProgress(t *testing.T) {
+	const src = `
+steps:
+  - {id: create, action: create}
+  - {id: upload, action: upload}
+`
+	w, err := Parse([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var added []string
+	fail := "c"
+	actions := map[string]Action{
+		"create": func(ctx context.Context, run *Run, s *Step) (map[string]string, error) {
+			return map[string]string{"notebook": "nb1"}, nil
+		},
+		"upload": func(ctx context.Context, run *Run, s *Step) (map[string]string, error) {
+			progress := run.Progress(s)
+			for _, in := range []string{"a", "b", "c"} {
+				if progress[in] != "" {
+					continue
+				}
+				if in == fail {
+					return nil, errors.New("upload failed")
+				}
+				added = append(added, in)
+				if err := run.Record(s, in, "src-"+in); err != nil {
+					return nil, err
+				}
+			}
+			return nil, nil
+		},
+	}
+	cp := NewCheckpoint("test")
+	var saved Checkpoint
+	rn := &Runner{
+		Actions:    actions,
+		Checkpoint: cp,
+		Save: func(c *Checkpoint) error {
+			b, err := json.Marshal(c)
+			if err != nil {
+				return err
+			}
+			saved = Checkpoint{}
+			return json.Unmarshal(b, &saved)
+		},
+	}
+	if run, err := rn.Run(context.Background(), w); err != nil || !run.Failed() {
+		t.Fatalf("first run: err = %v, want a failed run", err)
+	}
+	if diff := cmp.Diff(map[string]string{"a": "src-a", "b": "src-b"}, saved.Partial["upload"].Done); diff != "" {
+		t.Errorf("saved progress mismatch (-want +got):\n%s", diff)
+	}
+
+	// The resumed run uploads only the input that failed.
+	fail = ""
+	rn.Checkpoint = &saved
+	if run, err := rn.Run(context.Background(), w); err != nil || run.Failed() {
+		t.Fatalf("resumed run: %v, %+v", err, run.Results)
+	}
+	if !cmp.Equal(added, []string{"a", "b", "c"}) {
+		t.Errorf("added %q; the resumed run added an input again", added)
+	}
+	if _, ok := saved.Partial["upload"]; ok {
+		t.Error("progress of the completed step was kept")
+	}
+}
+
+func TestRunUnknownAction(t *testing.T) {
+	w, err := Parse([]byte("steps:\n  - action: teleport"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (&Runner{}).Run(context.Background(), w); err == nil {
+		t.Error("Run accepted an unknown action")
+	}
+}
+
+func TestRunResume(t *testing.T) {
+	const src = `
+steps:
+  - {id: create, action: create}
+  - {id: upload, action: upload}
+  - {id: audio, action: audio}
+  - {id: export, action: upload, needs: [audio]}
+`
+	w, err := Parse([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := make(map[string]int)
+	audioFails := true
+	actions := map[string]Action{
+		"create": func(ctx context.Context, run *Run, s *Step) (map[string]string, error) {
+			calls[s.ID]++
+			return map[string]string{"notebook": "nb1"}, nil
+		},
+		"upload": func(ctx context.Context, run *Run, s *Step) (map[string]string, error) {
+			calls[s.ID]++
+			_, err := run.NotebookFor(s)
+			return nil, err
+		},
+		"audio": func(ctx context.Context, run *Run, s *Step) (map[string]string, error) {
+			calls[s.ID]++
+			if audioFails {
+				return nil, errors.New("not ready")
+			}
+			return nil, nil
+		},
+	}
+	cp := NewCheckpoint("test")
+	var saves int
+	rn := &Runner{
+		Actions:    actions,
+		RetryDelay: time.Millisecond,
+		Checkpoint: cp,
+		Save:       func(*Checkpoint) error { saves++; return nil },
+	}
+	if run, err := rn.Run(context.Background(), w); err != nil || !run.Failed() {
+		t.Fatalf("first run: err = %v, want a failed run", err)
+	}
+	if saves != 2 {
+		t.Errorf("saves = %d, want 2", saves)
+	}
+
+	audioFails = false
+	run, err := rn.Run(context.Background(), w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range run.Results {
+		got = append(got, r.Step+" "+r.Status)
+	}
+	want := []string{"create resumed", "upload resumed", "audio ok", "export ok"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("results mismatch (-want +got):\n%s", diff)
+	}
+	if run.Failed() || run.Notebook != "nb1" {
+		t.Errorf("Failed = %v, Notebook = %q; want false, nb1", run.Failed(), run.Notebook)
+	}
+	if diff := cmp.Diff(map[string]int{"create": 1, "upload": 1, "audio": 2, "export": 1}, calls); diff != "" {
+		t.Errorf("calls mismatch (-want +got):\n%s", diff)
+	}
+
+	// Editing a step reruns it and the steps after it.
+	w2, err := Parse([]byte(strings.Replace(src, "{id: upload, action: upload}", "{id: upload, action: upload, with: {sources: [a.md]}}", 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	run, err = rn.Run(context.Background(), w2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = got[:0]
+	for _, r := range run.Results {
+		got = append(got, r.Step+" "+r.Status)
+	}
+	want = []string{"create resumed", "upload ok", "audio ok", "export ok"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("after edit, results mismatch (-want +got):\n%s", diff)
+	}
+}
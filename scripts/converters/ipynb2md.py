@@ -0,0 +1,46 @@
+#!/usr/bin/env python3
+"""Convert a Jupyter notebook on stdin to Markdown on stdout.
+
+An example nlm converter in the style of `jupyter nbconvert --to markdown`,
+with no dependencies beyond the standard library. Register it in .nlm.yaml:
+
+    converters:
+      .ipynb: [python3, scripts/converters/ipynb2md.py]
+
+nlm sets NLM_CONVERT_NAME to the notebook's file name.
+"""
+
+import json
+import os
+import sys
+
+
+def source(cell):
+    src = cell.get("source", "")
+    return "".join(src) if isinstance(src, list) else src
+
+
+def main():
+    nb = json.load(sys.stdin)
+    lang = (
+        nb.get("metadata", {}).get("language_info", {}).get("name")
+        or nb.get("metadata", {}).get("kernelspec", {}).get("language")
+        or ""
+    )
+    out = []
+    name = os.environ.get("NLM_CONVERT_NAME")
+    if name:
+        out.append("# " + os.path.splitext(name)[0])
+    for cell in nb.get("cells", []):
+        text = source(cell).rstrip()
+        if not text:
+            continue
+        if cell.get("cell_type") == "code":
+            out.append("```" + lang + "\n" + text + "\n```")
+        else:
+            out.append(text)
+    sys.stdout.write("\n\n".join(out) + "\n")
+
+
+if __name__ == "__main__":
+    main()
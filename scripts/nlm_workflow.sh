@@ -104,15 +104,14 @@ upload_pdf() {
     log "Upload attempt $attempt of $max_attempts..."
     
     # Run nlm add and capture both stdout and stderr
-    local out
-    out=$(nlm add "$id" "$pdf" 2>&1)
-    local exit_code=$?
+    # Capture the status without letting set -e end the script, so that a
+    # failed attempt is retried.
+    local out exit_code
+    out=$(nlm add "$id" "$pdf" 2>&1) && exit_code=0 || exit_code=$?
     
-    # Check for various success indicators
-    if [[ $exit_code -eq 0 ]] || \
-       echo "$out" | grep -q "Adding " || \
-       echo "$out" | grep -q "successfully added" || \
-       echo "$out" | grep -q "uploaded successfully"; then
+    # nlm add prints "Adding ..." before it uploads, so only the exit
+    # status tells whether the upload worked.
+    if [[ $exit_code -eq 0 ]]; then
       log "Upload succeeded"
       success=true
       break
@@ -0,0 +1,30 @@
+#!/bin/sh
+# Builds the release binaries of nlm into dist/ and signs them for
+# nlm self-update. Attach everything in dist/ to the GitHub release tagged
+# with the same version.
+#
+# Usage: NLM_RELEASE_KEY=... scripts/release.sh v1.2.3
+set -eu
+
+version=${1:?usage: scripts/release.sh <version>}
+: "${NLM_RELEASE_KEY:?set NLM_RELEASE_KEY to the release signing key (see go run ./scripts/signrelease -genkey)}"
+export NLM_RELEASE_KEY
+
+cd "$(dirname "$0")/.."
+pubkey=$(go run ./scripts/signrelease -pubkey)
+
+rm -rf dist
+mkdir dist
+for platform in linux/amd64 linux/arm64 darwin/amd64 darwin/arm64 windows/amd64; do
+	goos=${platform%/*}
+	goarch=${platform#*/}
+	out=dist/nlm_${goos}_${goarch}
+	if [ "$goos" = windows ]; then
+		out=$out.exe
+	fi
+	GOOS=$goos GOARCH=$goarch CGO_ENABLED=0 go build -trimpath \
+		-ldflags "-X main.version=$version -X main.updatePublicKey=$pubkey" \
+		-o "$out" ./cmd/nlm
+done
+go run ./scripts/signrelease -version "$version" dist/nlm_*
+ls -l dist
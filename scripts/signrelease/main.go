@@ -0,0 +1,79 @@
+// Command signrelease writes the signed manifest that nlm self-update
+// verifies releases with.
+//
+// Usage:
+//
+//	signrelease -genkey
+//	signrelease -pubkey
+//	signrelease -version v1.2.3 [-o dir] binary...
+//
+// The signing key is read from NLM_RELEASE_KEY, the base64 Ed25519 seed
+// printed by -genkey, rather than from a flag, so it stays out of process
+// listings. -pubkey prints the public key to build release binaries with.
+// Given a version and the release binaries, signrelease writes
+// release.json and release.json.sig to the directory of the first binary,
+// or -o, to attach to the GitHub release tagged with the same version.
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/tmc/nlm/internal/update"
+)
+
+func main() {
+	var (
+		genKey  = flag.Bool("genkey", false, "print a new signing key and its public key")
+		pubKey  = flag.Bool("pubkey", false, "print the public key of NLM_RELEASE_KEY")
+		version = flag.String("version", "", "release version, the tag of the GitHub release")
+		outDir  = flag.String("o", "", "directory to write the manifest to (default: that of the first binary)")
+	)
+	log.SetFlags(0)
+	log.SetPrefix("signrelease: ")
+	flag.Parse()
+
+	if *genKey {
+		pub, priv, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("NLM_RELEASE_KEY=%s\n", base64.StdEncoding.EncodeToString(priv.Seed()))
+		fmt.Printf("public key: %s\n", base64.StdEncoding.EncodeToString(pub))
+		return
+	}
+	key, err := update.ParsePrivateKey(os.Getenv("NLM_RELEASE_KEY"))
+	if err != nil {
+		log.Fatalf("NLM_RELEASE_KEY: %v", err)
+	}
+	if *pubKey {
+		fmt.Println(base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)))
+		return
+	}
+	if !update.IsRelease(*version) || flag.NArg() == 0 {
+		log.Fatal("usage: signrelease -version v1.2.3 [-o dir] binary...")
+	}
+	m, err := update.NewManifest(*version, flag.Args()...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	data, sig, err := update.SignManifest(key, m)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dir := *outDir
+	if dir == "" {
+		dir = filepath.Dir(flag.Arg(0))
+	}
+	if err := os.WriteFile(filepath.Join(dir, update.ManifestName), data, 0o644); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, update.SignatureName), sig, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
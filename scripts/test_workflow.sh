@@ -0,0 +1,50 @@
+#!/usr/bin/env bash
+# Test nlm_workflow.sh against the fake NotebookLM of nlm fake.
+# Usage: ./test_workflow.sh
+# Requirements: nlm built from this repository on PATH; no login is needed.
+#
+# The slow scenario is left out, as the workflow polls for audio every ten
+# seconds and would take over a minute.
+
+set -euo pipefail
+
+script_dir="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+pdf="$script_dir/chen_dalang-15-moments.pdf"
+work=$(mktemp -d)
+trap 'rm -rf "$work"' EXIT
+cd "$work"
+
+failed=0
+
+# check <scenario> <want-status> <log-pattern>... runs the workflow under
+# the scenario and checks its exit status and that its output matches
+# each pattern.
+check() {
+  local scenario="$1" want="$2" status=0
+  shift 2
+  rm -f ./*.wav
+  nlm fake "$scenario" -- "$script_dir/nlm_workflow.sh" "$pdf" >"$scenario.log" 2>&1 || status=$?
+  if [[ $status -ne $want ]]; then
+    echo "FAIL $scenario: exit status $status, want $want" >&2
+    sed 's/^/    /' "$scenario.log" >&2
+    failed=1
+    return
+  fi
+  local pattern
+  for pattern in "$@"; do
+    if ! grep -q -- "$pattern" "$scenario.log"; then
+      echo "FAIL $scenario: output does not match '$pattern'" >&2
+      sed 's/^/    /' "$scenario.log" >&2
+      failed=1
+      return
+    fi
+  done
+  echo "ok   $scenario"
+}
+
+check success 0 "Workflow complete"
+[[ -s chen_dalang-15-moments.wav ]] || { echo "FAIL success: no audio saved" >&2; failed=1; }
+check partial 1 "Upload attempt 2 of 3" "Upload succeeded" "Failed to request audio overview"
+check fail 1 "Failed to create notebook"
+
+exit "$failed"